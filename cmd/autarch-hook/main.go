@@ -0,0 +1,115 @@
+// Command autarch-hook reports agent state to Bigend. Agent hook configs
+// run it on every state change:
+//
+//	autarch-hook claude            # Claude Code hooks, payload on stdin
+//	autarch-hook gemini            # Gemini CLI hooks, payload on stdin
+//	autarch-hook codex <payload>   # Codex CLI notify
+//	autarch-hook config <agent>    # print the hook config for an agent
+//
+// It never fails the agent: errors are reported on stderr with exit
+// status 0.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/pkg/agenthook"
+)
+
+const usage = "usage: autarch-hook claude|codex|gemini [payload] | autarch-hook config claude|codex|gemini"
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if os.Args[1] == "config" {
+		if err := printConfig(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+	if err := emit(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "autarch-hook:", err)
+	}
+}
+
+func emit(agent string, args []string) error {
+	var payload []byte
+	if agent == agenthook.AgentCodex {
+		if len(args) > 0 {
+			payload = []byte(args[len(args)-1])
+		}
+	} else {
+		var err error
+		if payload, err = io.ReadAll(io.LimitReader(os.Stdin, 1<<20)); err != nil {
+			return err
+		}
+	}
+	e, err := agenthook.Translate(agent, payload, time.Now())
+	if err != nil {
+		return err
+	}
+	if e.ProjectDir == "" {
+		e.ProjectDir, _ = os.Getwd()
+	}
+	e.Session = tmuxSession()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return agenthook.Send(ctx, agenthook.DefaultSocket(), e)
+}
+
+// tmuxSession names the tmux session the agent runs in, if any.
+func tmuxSession() string {
+	if os.Getenv("TMUX") == "" {
+		return ""
+	}
+	args := []string{"display-message", "-p"}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	out, err := exec.Command("tmux", append(args, "#S")...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func printConfig(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf(usage)
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		bin = "autarch-hook"
+	}
+	switch args[0] {
+	case agenthook.AgentClaude:
+		return printJSON("~/.claude/settings.json", agenthook.ClaudeHooks(bin))
+	case agenthook.AgentGemini:
+		return printJSON("~/.gemini/settings.json", agenthook.GeminiHooks(bin))
+	case agenthook.AgentCodex:
+		argv, _ := json.Marshal(agenthook.CodexNotify(bin))
+		fmt.Printf("# ~/.codex/config.toml\nnotify = %s\n", argv)
+		return nil
+	}
+	return fmt.Errorf("unknown agent %q", args[0])
+}
+
+func printJSON(file string, hooks map[string]any) error {
+	data, err := json.MarshalIndent(map[string]any{"hooks": hooks}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("// merge into %s\n%s\n", file, data)
+	return nil
+}
@@ -21,6 +21,10 @@ import (
 	"github.com/mistakeknot/autarch/internal/bigend/config"
 	"github.com/mistakeknot/autarch/internal/bigend/daemon"
 	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+	"github.com/mistakeknot/autarch/internal/bigend/federation"
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+	"github.com/mistakeknot/autarch/internal/bigend/rules"
+	bigendTmux "github.com/mistakeknot/autarch/internal/bigend/tmux"
 	bigendTui "github.com/mistakeknot/autarch/internal/bigend/tui"
 	"github.com/mistakeknot/autarch/internal/bigend/web"
 	coldwineCli "github.com/mistakeknot/autarch/internal/coldwine/cli"
@@ -309,6 +313,7 @@ func bigendCmd() *cobra.Command {
 		tuiMode    bool
 		daemonMode bool
 		daemonAddr string
+		daemonSock string
 	)
 
 	cmd := &cobra.Command{
@@ -361,11 +366,23 @@ func bigendCmd() *cobra.Command {
 			}
 
 			if daemonMode {
-				return runBigendDaemon(daemonAddr, cfg.Discovery.ScanRoots)
-			} else if tuiMode {
-				return runBigendTUI(agg)
+				return runBigendDaemon(cfg, agg, daemonAddr, daemonSock)
 			}
-			return runBigendWeb(cfg, agg)
+
+			fleet, err := federation.New(agg, cfg.Federation)
+			if err != nil {
+				slog.Error("federation peers skipped", "error", err)
+			}
+			if len(fleet.Peers()) > 0 {
+				if err := fleet.Refresh(context.Background()); err != nil {
+					slog.Error("initial peer refresh failed", "error", err)
+				}
+			}
+
+			if tuiMode {
+				return runBigendTUI(fleet)
+			}
+			return runBigendWeb(cfg, fleet)
 		},
 	}
 
@@ -376,15 +393,131 @@ func bigendCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&tuiMode, "tui", false, "Run in TUI mode instead of web server")
 	cmd.Flags().BoolVar(&daemonMode, "daemon", false, "Run as daemon with HTTP API")
 	cmd.Flags().StringVar(&daemonAddr, "daemon-addr", "127.0.0.1:8100", "Daemon HTTP API address")
+	cmd.Flags().StringVar(&daemonSock, "daemon-socket", "", "Serve the daemon API on a unix socket instead of daemon-addr")
+
+	cmd.AddCommand(bigendReplayCmd(), bigendRulesCmd())
+
+	return cmd
+}
+
+func bigendReplayCmd() *cobra.Command {
+	var (
+		cfgPath string
+		opts    bigendTui.ReplayOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "replay <session|file.cast>",
+		Short: "Play back a recorded agent pane",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(cfgPath)
+			if err != nil {
+				return err
+			}
+			path, err := recording.Resolve(cfg.Recording.Dir, args[0])
+			if err != nil {
+				return err
+			}
+			cast, err := recording.Load(path)
+			if err != nil {
+				return err
+			}
+			return bigendTui.Replay(cmd.OutOrStdout(), cast, filepath.Base(path), opts)
+		},
+	}
+	cmd.Flags().StringVar(&cfgPath, "config", "", "Path to config file")
+	cmd.Flags().DurationVar(&opts.At, "at", 0, "Offset into the recording to start from or print")
+	cmd.Flags().StringVar(&opts.Search, "search", "", "Jump to output containing this text; with --print, list every match")
+	cmd.Flags().BoolVar(&opts.Print, "print", false, "Print instead of opening the player")
+	return cmd
+}
+
+func bigendRulesCmd() *cobra.Command {
+	var cfgPath string
+	loadRules := func() (*config.Config, []*rules.Rule, error) {
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		compiled, err := rules.Compile(cfg.Automation.Rules)
+		return cfg, compiled, err
+	}
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Check the automation rules in the Bigend config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, compiled, err := loadRules()
+			if err != nil {
+				return err
+			}
+			if len(compiled) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No automation rules configured")
+				return nil
+			}
+			rules.WriteRules(cmd.OutOrStdout(), compiled)
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&cfgPath, "config", "", "Path to config file")
 
+	var projectPath, agentType string
+	test := &cobra.Command{
+		Use:   "test <session|file.cast>...",
+		Short: "Dry-run the rules against recorded sessions",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, compiled, err := loadRules()
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			for _, arg := range args {
+				path, err := recording.Resolve(cfg.Recording.Dir, arg)
+				if err != nil {
+					return err
+				}
+				cast, err := recording.Load(path)
+				if err != nil {
+					return err
+				}
+				session := rules.Session{Name: cast.Header.Title, AgentType: agentType, ProjectPath: projectPath}
+				if session.AgentType == "" {
+					session.AgentType = "unknown"
+					if info := bigendTmux.NewDetector(nil).DetectAgent(bigendTmux.Session{Name: session.Name}); info != nil {
+						session.AgentType = string(info.Type)
+					}
+				}
+				firings := rules.SimulateRecording(compiled, rules.Coldwine{}, session, cast)
+				fmt.Fprintf(out, "%s: %d firing(s)\n", filepath.Base(path), len(firings))
+				rules.WriteFirings(out, firings)
+			}
+			return nil
+		},
+	}
+	test.Flags().StringVar(&projectPath, "project", "", "Project the recorded sessions worked in, for task_status rules")
+	test.Flags().StringVar(&agentType, "agent", "", "Agent type the recorded sessions ran (default: guessed from the session name)")
+	cmd.AddCommand(test)
 	return cmd
 }
 
-func runBigendDaemon(addr string, scanRoots []string) error {
-	srv := daemon.NewServer(daemon.Config{
+func runBigendDaemon(cfg *config.Config, agg *aggregator.Aggregator, addr, socket string) error {
+	srv, err := daemon.NewServer(daemon.Config{
 		Addr:        addr,
-		ProjectDirs: scanRoots,
+		Socket:      socket,
+		ProjectDirs: cfg.Discovery.ScanRoots,
+		StatePath:   cfg.Daemon.StatePath,
+		State:       agg,
 	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bigendRefreshLoop(ctx, agg, cfg.Discovery.ScanInterval)
+	go agg.ListenHooks(ctx)
+	go agg.WatchSignals(ctx)
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -392,6 +525,7 @@ func runBigendDaemon(addr string, scanRoots []string) error {
 	go func() {
 		<-quit
 		slog.Info("shutting down daemon")
+		cancel()
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		_ = srv.Shutdown(ctx)
@@ -403,8 +537,17 @@ func runBigendDaemon(addr string, scanRoots []string) error {
 	return nil
 }
 
-func runBigendTUI(agg *aggregator.Aggregator) error {
-	m := bigendTui.New(agg, buildInfoString())
+func runBigendTUI(fleet *federation.Fleet) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fleet.RecordPanes(ctx)
+	go fleet.SampleMetrics(ctx)
+	go fleet.ListenHooks(ctx)
+	go fleet.WatchSignals(ctx)
+	fleet.Connect(ctx)
+	fleet.AttachTerminal(os.Stdout)
+
+	m := bigendTui.New(fleet, buildInfoString())
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	_, err := p.Run()
 	return err
@@ -443,24 +586,16 @@ func buildInfoString() string {
 	return ""
 }
 
-func runBigendWeb(cfg *config.Config, agg *aggregator.Aggregator) error {
-	srv := web.NewServer(cfg.Server, agg)
+func runBigendWeb(cfg *config.Config, fleet *federation.Fleet) error {
+	srv := web.NewServer(cfg.Server, fleet)
 
 	ctx, cancel := context.WithCancel(context.Background())
-	go func() {
-		ticker := time.NewTicker(cfg.Discovery.ScanInterval)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				if err := agg.Refresh(ctx); err != nil {
-					slog.Error("refresh failed", "error", err)
-				}
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+	go fleet.RecordPanes(ctx)
+	go fleet.SampleMetrics(ctx)
+	go fleet.ListenHooks(ctx)
+	go fleet.WatchSignals(ctx)
+	fleet.Connect(ctx)
+	go bigendRefreshLoop(ctx, fleet, cfg.Discovery.ScanInterval)
 
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	slog.Info("starting server", "addr", addr)
@@ -485,6 +620,22 @@ func runBigendWeb(cfg *config.Config, agg *aggregator.Aggregator) error {
 	return srv.Shutdown(shutdownCtx)
 }
 
+// bigendRefreshLoop rescans on every interval until ctx is done.
+func bigendRefreshLoop(ctx context.Context, r interface{ Refresh(context.Context) error }, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil {
+				slog.Error("refresh failed", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func gurgehCmd() *cobra.Command {
 	cmd := gurgehCli.NewRoot()
 	cmd.Use = "gurgeh"
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime/debug"
 	"strings"
 	"syscall"
@@ -19,12 +20,31 @@ import (
 	"github.com/mistakeknot/autarch/internal/bigend/config"
 	"github.com/mistakeknot/autarch/internal/bigend/daemon"
 	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+	"github.com/mistakeknot/autarch/internal/bigend/federation"
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+	"github.com/mistakeknot/autarch/internal/bigend/rules"
+	"github.com/mistakeknot/autarch/internal/bigend/tmux"
 	"github.com/mistakeknot/autarch/internal/bigend/tui"
 	"github.com/mistakeknot/autarch/internal/bigend/web"
 	"github.com/mistakeknot/autarch/pkg/intermute"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "bigend replay:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rules" {
+		if err := runRules(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "bigend rules:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var (
 		port       = flag.Int("port", 8099, "HTTP server port")
 		host       = flag.String("host", "0.0.0.0", "HTTP server bind address")
@@ -33,6 +53,7 @@ func main() {
 		tuiMode    = flag.Bool("tui", false, "Run in TUI mode instead of web server")
 		daemonMode = flag.Bool("daemon", false, "Run as daemon with HTTP API (schmux-style)")
 		daemonAddr = flag.String("daemon-addr", "127.0.0.1:8100", "Daemon HTTP API address")
+		daemonSock = flag.String("daemon-socket", "", "Serve the daemon API on a unix socket instead of daemon-addr")
 	)
 	flag.Parse()
 
@@ -86,19 +107,47 @@ func main() {
 	}
 
 	if *daemonMode {
-		runDaemon(*daemonAddr, cfg.Discovery.ScanRoots)
-	} else if *tuiMode {
-		runTUI(agg)
+		runDaemon(cfg, agg, *daemonAddr, *daemonSock)
+		return
+	}
+
+	// Merge in the views of peer daemons on other hosts
+	fleet, err := federation.New(agg, cfg.Federation)
+	if err != nil {
+		slog.Error("federation peers skipped", "error", err)
+	}
+	if len(fleet.Peers()) > 0 {
+		if err := fleet.Refresh(context.Background()); err != nil {
+			slog.Error("initial peer refresh failed", "error", err)
+		}
+	}
+
+	if *tuiMode {
+		runTUI(fleet)
 	} else {
-		runWeb(cfg, agg)
+		runWeb(cfg, fleet)
 	}
 }
 
-func runDaemon(addr string, scanRoots []string) {
-	srv := daemon.NewServer(daemon.Config{
+func runDaemon(cfg *config.Config, agg *aggregator.Aggregator, addr, socket string) {
+	srv, err := daemon.NewServer(daemon.Config{
 		Addr:        addr,
-		ProjectDirs: scanRoots,
+		Socket:      socket,
+		ProjectDirs: cfg.Discovery.ScanRoots,
+		StatePath:   cfg.Daemon.StatePath,
+		State:       agg,
 	})
+	if err != nil {
+		slog.Error("daemon error", "error", err)
+		os.Exit(1)
+	}
+
+	// Keep the view shared with federation peers current
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go refreshLoop(ctx, agg, cfg.Discovery.ScanInterval)
+	go agg.ListenHooks(ctx)
+	go agg.WatchSignals(ctx)
 
 	// Setup signal handling
 	quit := make(chan os.Signal, 1)
@@ -107,6 +156,7 @@ func runDaemon(addr string, scanRoots []string) {
 	go func() {
 		<-quit
 		slog.Info("shutting down daemon")
+		cancel()
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		_ = srv.Shutdown(ctx)
@@ -118,8 +168,17 @@ func runDaemon(addr string, scanRoots []string) {
 	}
 }
 
-func runTUI(agg *aggregator.Aggregator) {
-	m := tui.New(agg, buildInfoString())
+func runTUI(fleet *federation.Fleet) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fleet.RecordPanes(ctx)
+	go fleet.SampleMetrics(ctx)
+	go fleet.ListenHooks(ctx)
+	go fleet.WatchSignals(ctx)
+	fleet.Connect(ctx)
+	fleet.AttachTerminal(os.Stdout)
+
+	m := tui.New(fleet, buildInfoString())
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
@@ -161,26 +220,107 @@ func buildInfoString() string {
 	return ""
 }
 
-func runWeb(cfg *config.Config, agg *aggregator.Aggregator) {
+// runReplay plays back a recorded agent pane: bigend replay <session|file.cast>.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	var (
+		cfgPath  = fs.String("config", "", "Path to config file")
+		at       = fs.Duration("at", 0, "Offset into the recording to start from or print")
+		search   = fs.String("search", "", "Jump to output containing this text; with --print, list every match")
+		printOut = fs.Bool("print", false, "Print instead of opening the player")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: bigend replay [flags] <session|file.cast>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected a session name or recording file")
+	}
+	cfg, err := config.Load(*cfgPath)
+	if err != nil {
+		return err
+	}
+	path, err := recording.Resolve(cfg.Recording.Dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cast, err := recording.Load(path)
+	if err != nil {
+		return err
+	}
+	return tui.Replay(os.Stdout, cast, filepath.Base(path), tui.ReplayOptions{At: *at, Search: *search, Print: *printOut})
+}
+
+// runRules checks the automation rules in the config and, with "test",
+// dry-runs them against recorded sessions:
+// bigend rules [test [--project dir] [--agent type] <session|file.cast>...].
+func runRules(args []string) error {
+	test := len(args) > 0 && args[0] == "test"
+	if test {
+		args = args[1:]
+	}
+	fs := flag.NewFlagSet("rules", flag.ExitOnError)
+	cfgPath := fs.String("config", "", "Path to config file")
+	projectPath := fs.String("project", "", "Project the recorded sessions worked in, for task_status rules")
+	agentType := fs.String("agent", "", "Agent type the recorded sessions ran (default: guessed from the session name)")
+	fs.Parse(args)
+
+	cfg, err := config.Load(*cfgPath)
+	if err != nil {
+		return err
+	}
+	compiled, err := rules.Compile(cfg.Automation.Rules)
+	if err != nil {
+		return err
+	}
+	if !test {
+		if len(compiled) == 0 {
+			fmt.Println("No automation rules configured")
+			return nil
+		}
+		rules.WriteRules(os.Stdout, compiled)
+		return nil
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: bigend rules test [--project dir] [--agent type] <session|file.cast>...")
+	}
+	for _, arg := range fs.Args() {
+		path, err := recording.Resolve(cfg.Recording.Dir, arg)
+		if err != nil {
+			return err
+		}
+		cast, err := recording.Load(path)
+		if err != nil {
+			return err
+		}
+		session := rules.Session{Name: cast.Header.Title, AgentType: *agentType, ProjectPath: *projectPath}
+		if session.AgentType == "" {
+			session.AgentType = "unknown"
+			if info := tmux.NewDetector(nil).DetectAgent(tmux.Session{Name: session.Name}); info != nil {
+				session.AgentType = string(info.Type)
+			}
+		}
+		firings := rules.SimulateRecording(compiled, rules.Coldwine{}, session, cast)
+		fmt.Printf("%s: %d firing(s)\n", filepath.Base(path), len(firings))
+		rules.WriteFirings(os.Stdout, firings)
+	}
+	return nil
+}
+
+func runWeb(cfg *config.Config, fleet *federation.Fleet) {
 	// Create web server
-	srv := web.NewServer(cfg.Server, agg)
+	srv := web.NewServer(cfg.Server, fleet)
 
 	// Start background refresh
 	ctx, cancel := context.WithCancel(context.Background())
-	go func() {
-		ticker := time.NewTicker(cfg.Discovery.ScanInterval)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				if err := agg.Refresh(ctx); err != nil {
-					slog.Error("refresh failed", "error", err)
-				}
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+	go fleet.RecordPanes(ctx)
+	go fleet.SampleMetrics(ctx)
+	go fleet.ListenHooks(ctx)
+	go fleet.WatchSignals(ctx)
+	fleet.Connect(ctx)
+	go refreshLoop(ctx, fleet, cfg.Discovery.ScanInterval)
 
 	// Start server
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -208,3 +348,19 @@ func runWeb(cfg *config.Config, agg *aggregator.Aggregator) {
 		slog.Error("shutdown error", "error", err)
 	}
 }
+
+// refreshLoop rescans on every interval until ctx is done.
+func refreshLoop(ctx context.Context, r interface{ Refresh(context.Context) error }, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil {
+				slog.Error("refresh failed", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
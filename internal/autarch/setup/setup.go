@@ -14,6 +14,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/mistakeknot/autarch/pkg/agenthook"
 )
 
 //go:embed hooks/*
@@ -42,13 +44,15 @@ func Check() Status {
 	// Check Claude Code configuration
 	claudeSettings := filepath.Join(home, ".claude", "settings.json")
 	if data, err := os.ReadFile(claudeSettings); err == nil {
-		status.ClaudeConfigured = strings.Contains(string(data), "emit-state.sh")
+		status.ClaudeConfigured = strings.Contains(string(data), "emit-state.sh") ||
+			strings.Contains(string(data), "autarch-hook")
 	}
 
 	// Check Codex configuration
 	codexConfig := filepath.Join(home, ".codex", "config.toml")
 	if data, err := os.ReadFile(codexConfig); err == nil {
-		status.CodexConfigured = strings.Contains(string(data), "codex-notify.sh")
+		status.CodexConfigured = strings.Contains(string(data), "codex-notify.sh") ||
+			strings.Contains(string(data), "autarch-hook")
 	}
 
 	// Check tmux availability
@@ -148,9 +152,13 @@ func configureClaudeCode(home, hooksDir string) error {
 		}
 	}
 
-	// Add our hooks
-	emitScript := filepath.Join(hooksDir, "emit-state.sh")
-	settings["hooks"] = buildClaudeHooks(emitScript)
+	// Add our hooks, preferring the hook event emitter when installed
+	if bin, err := exec.LookPath("autarch-hook"); err == nil {
+		settings["hooks"] = agenthook.ClaudeHooks(bin)
+	} else {
+		emitScript := filepath.Join(hooksDir, "emit-state.sh")
+		settings["hooks"] = buildClaudeHooks(emitScript)
+	}
 
 	// Write back
 	data, err := json.MarshalIndent(settings, "", "  ")
@@ -184,8 +192,13 @@ func configureCodexCLI(home, hooksDir string) error {
 		content = "# Codex CLI configuration\n"
 	}
 
-	// Append notify setting
-	content += fmt.Sprintf("\n# Autarch agent state hooks\nnotify = %q\n", notifyScript)
+	// Append notify setting, preferring the hook event emitter when installed
+	if bin, err := exec.LookPath("autarch-hook"); err == nil {
+		argv, _ := json.Marshal(agenthook.CodexNotify(bin))
+		content += fmt.Sprintf("\n# Autarch agent state hooks\nnotify = %s\n", argv)
+	} else {
+		content += fmt.Sprintf("\n# Autarch agent state hooks\nnotify = %q\n", notifyScript)
+	}
 
 	return os.WriteFile(configPath, []byte(content), 0644)
 }
@@ -2,11 +2,15 @@ package aggregator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,10 +21,16 @@ import (
 	"github.com/mistakeknot/autarch/internal/bigend/config"
 	"github.com/mistakeknot/autarch/internal/bigend/discovery"
 	"github.com/mistakeknot/autarch/internal/bigend/mcp"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
+	"github.com/mistakeknot/autarch/internal/bigend/notify"
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+	"github.com/mistakeknot/autarch/internal/bigend/rules"
 	"github.com/mistakeknot/autarch/internal/bigend/statedetect"
 	"github.com/mistakeknot/autarch/internal/bigend/tmux"
 	gurgSpecs "github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	pollardState "github.com/mistakeknot/autarch/internal/pollard/state"
 	"github.com/mistakeknot/autarch/pkg/intermute"
+	"github.com/mistakeknot/autarch/pkg/signals"
 )
 
 // Agent represents a detected AI agent
@@ -47,6 +57,11 @@ type TmuxSession struct {
 	AgentType    string    `json:"agent_type,omitempty"`
 	ProjectPath  string    `json:"project_path,omitempty"`
 
+	// Federation: the peer the session runs on ("" for this host) and how
+	// to attach to it from here.
+	Host          string `json:"host,omitempty"`
+	AttachCommand string `json:"attach_command,omitempty"`
+
 	// State detection fields (NudgeNik-style)
 	State           string    `json:"state"`            // working, waiting, blocked, stalled, done, error
 	StateConfidence float64   `json:"state_confidence"` // 0.0-1.0 detection certainty
@@ -61,6 +76,7 @@ type Activity struct {
 	AgentName   string    `json:"agent_name,omitempty"`
 	ProjectPath string    `json:"project_path"`
 	Summary     string    `json:"summary"`
+	Host        string    `json:"host,omitempty"`
 }
 
 // State holds the aggregated view of all projects and agents
@@ -71,9 +87,18 @@ type State struct {
 	Colonies   []colony.Colony                  `json:"colonies"`
 	MCP        map[string][]mcp.ComponentStatus `json:"mcp"`
 	Activities []Activity                       `json:"activities"`
+	Hosts      []HostStatus                     `json:"hosts,omitempty"`
 	UpdatedAt  time.Time                        `json:"updated_at"`
 }
 
+// HostStatus is the reachability of a federated peer.
+type HostStatus struct {
+	Name      string    `json:"name"`
+	Reachable bool      `json:"reachable"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type tmuxAPI interface {
 	IsAvailable() bool
 	ListSessions() ([]tmux.Session, error)
@@ -83,6 +108,7 @@ type tmuxAPI interface {
 	RenameSession(oldName, newName string) error
 	KillSession(name string) error
 	AttachSession(name string) error
+	SendKeys(sessionName string, keys string) error
 }
 
 // EventHandler processes aggregator events (spec changes, agent updates, etc.)
@@ -105,9 +131,22 @@ type Aggregator struct {
 	intermuteClient *intermute.Client
 	mcpManager      *mcp.Manager
 	resolver        *agentcmd.Resolver
+	recorder        *recording.Recorder
+	rules           *rules.Engine
+	metrics         *metrics.Store
+	hookEvents      *statedetect.EventStore
+	notifier        *notify.Center
+	warner          colony.Warner
 	cfg             *config.Config
 	mu              sync.RWMutex
 	state           State
+	lastMetrics     []metrics.Sample
+
+	// Worktree conflict prediction, re-run every cfg.Colony.Interval
+	colonyMu        sync.Mutex
+	colonyPairs     map[string][]colony.Pair // by colony root
+	colonyCheckedAt time.Time
+	conflictWarned  map[string]bool // pairs warned while at risk
 
 	// WebSocket event handling
 	handlers    map[string][]EventHandler
@@ -132,13 +171,23 @@ func New(scanner *discovery.Scanner, cfg *config.Config) *Aggregator {
 		ic = icClient
 	}
 
-	return &Aggregator{
+	var rec *recording.Recorder
+	if cfg.Recording.Enabled && cfg.Recording.Dir != "" {
+		rec = recording.NewRecorderWithOptions(cfg.Recording.Dir, recording.Options{
+			Retention: cfg.Recording.Retention,
+			MaxBytes:  cfg.Recording.MaxBytes,
+		})
+	}
+
+	a := &Aggregator{
 		scanner:         scanner,
 		tmuxClient:      tmux.NewClient(),
 		stateDetector:   statedetect.NewDetector(),
 		intermuteClient: ic,
-		mcpManager:      mcp.NewManager(),
+		mcpManager:      mcp.NewManagerWithOptions(mcpOptions(cfg.MCP)),
 		resolver:        agentcmd.NewResolver(cfg),
+		recorder:        rec,
+		warner:          colony.ColdwineInbox{},
 		cfg:             cfg,
 		handlers:        make(map[string][]EventHandler),
 		state: State{
@@ -150,6 +199,32 @@ func New(scanner *discovery.Scanner, cfg *config.Config) *Aggregator {
 			Activities: []Activity{},
 		},
 	}
+	if cfg.Metrics.Enabled && cfg.Metrics.Path != "" {
+		store, err := metrics.Open(cfg.Metrics.Path)
+		if err != nil {
+			slog.Warn("metrics history disabled", "error", err)
+		} else {
+			a.metrics = store
+		}
+	}
+	if cfg.Hooks.Enabled && cfg.Hooks.Socket != "" {
+		a.hookEvents = statedetect.NewEventStore(cfg.Hooks.StaleAfter)
+		a.stateDetector.SetEventStore(a.hookEvents)
+	}
+	if center, err := notify.New(cfg.Notify); err != nil {
+		slog.Error("notifications disabled", "error", err)
+	} else {
+		a.notifier = center
+	}
+	if len(cfg.Automation.Rules) > 0 {
+		compiled, err := rules.Compile(cfg.Automation.Rules)
+		if err != nil {
+			slog.Error("automation rules disabled", "error", err)
+		} else {
+			a.rules = rules.NewEngine(compiled, ruleActions{a}, rules.Coldwine{}, cfg.Automation.DryRun)
+		}
+	}
+	return a
 }
 
 // ConnectWebSocket establishes a WebSocket connection to Intermute for real-time events.
@@ -383,6 +458,7 @@ func (a *Aggregator) Refresh(ctx context.Context) error {
 
 	// Detect colonies
 	colonies := colony.Detect(projects)
+	a.predictConflicts(ctx, colonies)
 
 	// Load MCP statuses
 	mcpStatuses := a.loadMCPStatuses(projects)
@@ -407,6 +483,72 @@ func (a *Aggregator) Refresh(ctx context.Context) error {
 	return nil
 }
 
+// predictConflicts fills in the worktree pair risks of each colony. The
+// dry-run merges are re-run every cfg.Colony.Interval; in between, the
+// last results are reused.
+func (a *Aggregator) predictConflicts(ctx context.Context, colonies []colony.Colony) {
+	if !a.cfg.Colony.Conflicts {
+		return
+	}
+	a.colonyMu.Lock()
+	defer a.colonyMu.Unlock()
+	if now := time.Now(); a.colonyCheckedAt.IsZero() || now.Sub(a.colonyCheckedAt) >= a.cfg.Colony.Interval {
+		a.colonyCheckedAt = now
+		a.colonyPairs = make(map[string][]colony.Pair)
+		var all []colony.Pair
+		var roots []string
+		for _, c := range colonies {
+			if len(c.Worktrees) < 2 {
+				continue
+			}
+			pairs, err := colony.PredictConflicts(ctx, c.Worktrees)
+			if err != nil {
+				slog.Warn("conflict prediction failed", "colony", c.Root, "error", err)
+				continue
+			}
+			a.colonyPairs[c.Root] = pairs
+			for _, p := range pairs {
+				all = append(all, p)
+				roots = append(roots, c.Root)
+			}
+		}
+		a.warnConflicts(roots, all)
+	}
+	for i := range colonies {
+		colonies[i].Pairs = a.colonyPairs[colonies[i].Root]
+	}
+}
+
+// warnConflicts mails the agents of each pair whose risk reaches
+// cfg.Colony.WarnAt, once until the risk drops below it again.
+func (a *Aggregator) warnConflicts(roots []string, pairs []colony.Pair) {
+	threshold := a.cfg.Colony.WarnAt
+	if threshold <= 0 || a.warner == nil {
+		return
+	}
+	warned := make(map[string]bool)
+	for i, p := range pairs {
+		if p.Risk < threshold {
+			continue
+		}
+		key := p.Key()
+		if a.conflictWarned[key] {
+			warned[key] = true
+			continue
+		}
+		err := a.warner.Warn(roots[i], p)
+		switch {
+		case errors.Is(err, colony.ErrNoRecipients):
+			slog.Debug("conflict risk without Coldwine agents", "a", p.A.Path, "b", p.B.Path, "risk", p.Risk)
+		case err != nil:
+			slog.Warn("failed to send conflict warning", "a", p.A.Path, "b", p.B.Path, "error", err)
+			continue
+		}
+		warned[key] = true
+	}
+	a.conflictWarned = warned
+}
+
 // enrichWithTaskStats loads Coldwine task statistics for each project
 func (a *Aggregator) enrichWithTaskStats(projects []discovery.Project) {
 	for i := range projects {
@@ -485,11 +627,30 @@ func (a *Aggregator) enrichWithPollardStats(projects []discovery.Project) {
 			Sources:    sourceCount,
 			Insights:   insightCount,
 			Reports:    reportCount,
+			Scans:      countPollardScans(projects[i].Path),
 			LastReport: lastReport,
 		}
 	}
 }
 
+// countPollardScans counts hunter runs in the project's Pollard state
+func countPollardScans(projectPath string) int {
+	if _, err := os.Stat(filepath.Join(projectPath, ".pollard", "state.db")); err != nil {
+		return 0
+	}
+	db, err := pollardState.Open(projectPath)
+	if err != nil {
+		slog.Warn("failed to open pollard state", "project", projectPath, "error", err)
+		return 0
+	}
+	defer db.Close()
+	stats, err := db.GetStats()
+	if err != nil {
+		return 0
+	}
+	return stats.TotalRuns
+}
+
 // countYAMLFiles counts YAML files in a directory
 func countYAMLFiles(dir string) int {
 	count := 0
@@ -613,6 +774,30 @@ func (a *Aggregator) loadTmuxSessions(projects []discovery.Project) []TmuxSessio
 		a.detectSessionState(&sessions[i])
 	}
 
+	if a.recorder != nil {
+		names := make([]string, 0, len(sessions))
+		for _, s := range sessions {
+			if s.AgentType != "" {
+				names = append(names, s.Name)
+			}
+		}
+		a.recorder.Retain(names)
+	}
+	if a.rules != nil {
+		names := make([]string, 0, len(sessions))
+		for _, s := range sessions {
+			names = append(names, s.Name)
+		}
+		a.rules.Retain(names)
+	}
+	if a.notifier != nil {
+		names := make([]string, 0, len(sessions))
+		for _, s := range sessions {
+			names = append(names, s.Name)
+		}
+		a.notifier.Retain(names)
+	}
+
 	return sessions
 }
 
@@ -649,6 +834,329 @@ func (a *Aggregator) detectSessionState(session *TmuxSession) {
 	session.StateConfidence = result.Confidence
 	session.StateSource = string(result.Source)
 	session.StateAt = result.DetectedAt
+
+	if a.rules != nil {
+		a.rules.Observe(rules.Session{
+			Name:        session.Name,
+			AgentType:   session.AgentType,
+			ProjectPath: session.ProjectPath,
+		}, session.State, result.DetectedAt)
+	}
+	a.notifier.Observe(session.Name, session.AgentType, session.ProjectPath, session.State, result.DetectedAt)
+
+	if a.recorder != nil {
+		if err := a.recorder.Capture(session.Name, output, result.DetectedAt); err != nil {
+			slog.Warn("failed to record pane", "session", session.Name, "error", err)
+		} else if err := a.recorder.Mark(session.Name, session.State, result.DetectedAt); err != nil {
+			slog.Warn("failed to record state", "session", session.Name, "error", err)
+		}
+	}
+}
+
+// RuleFirings returns recent automation rule firings, newest first.
+func (a *Aggregator) RuleFirings() []rules.Firing {
+	if a.rules == nil {
+		return nil
+	}
+	return a.rules.History()
+}
+
+// ruleActions carries out automation rule actions for the aggregator.
+type ruleActions struct {
+	a *Aggregator
+}
+
+func (r ruleActions) SendKeys(session string, keys ...string) error {
+	for _, k := range keys {
+		if err := r.a.tmuxClient.SendKeys(session, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r ruleActions) Restart(s rules.Session) error {
+	return r.a.RestartSession(s.Name, s.ProjectPath, s.AgentType)
+}
+
+func (r ruleActions) Dispose(session string) error {
+	return r.a.DisposeSession(session)
+}
+
+func (r ruleActions) Mail(projectPath string, m rules.Mail) error {
+	return rules.Coldwine{}.Mail(projectPath, m)
+}
+
+func (r ruleActions) Signal(sig signals.Signal) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return signals.NewClient(signals.DefaultServerURL()).Publish(ctx, sig)
+}
+
+// RecordingDir is where pane recordings are written, or "" when recording
+// is disabled.
+func (a *Aggregator) RecordingDir() string {
+	if a.recorder == nil {
+		return ""
+	}
+	return a.recorder.Dir()
+}
+
+// RecordPanes captures agent panes into their recordings every
+// cfg.Recording.Interval until ctx is done. Refresh records a frame and the
+// detected state on each scan; this fills in the output between scans.
+func (a *Aggregator) RecordPanes(ctx context.Context) {
+	if a.recorder == nil {
+		return
+	}
+	defer a.recorder.Close()
+	interval := a.cfg.Recording.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.mu.RLock()
+			sessions := a.state.Sessions
+			a.mu.RUnlock()
+			for _, s := range sessions {
+				if s.AgentType == "" {
+					continue
+				}
+				output, err := a.tmuxClient.CapturePane(s.Name, 50)
+				if err != nil {
+					continue
+				}
+				if err := a.recorder.Capture(s.Name, output, now); err != nil {
+					slog.Warn("failed to record pane", "session", s.Name, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// ListenHooks records the agent hook events sent to cfg.Hooks.Socket until
+// ctx is done, so state detection can use them ahead of pane output. When
+// another Bigend serves the socket, this one keeps detecting from panes.
+func (a *Aggregator) ListenHooks(ctx context.Context) {
+	if a.hookEvents == nil {
+		return
+	}
+	ln, err := statedetect.ListenSocket(a.cfg.Hooks.Socket)
+	if errors.Is(err, statedetect.ErrSocketInUse) {
+		slog.Info("hook socket served elsewhere", "socket", a.cfg.Hooks.Socket)
+		return
+	}
+	if err != nil {
+		slog.Warn("hook events disabled", "error", err)
+		return
+	}
+	defer os.Remove(a.cfg.Hooks.Socket)
+	if err := a.hookEvents.Serve(ctx, ln); err != nil {
+		slog.Warn("hook socket closed", "error", err)
+	}
+}
+
+// WatchSignals notifies of critical signals from the signals server until
+// ctx is done, reconnecting with backoff while the server is down.
+func (a *Aggregator) WatchSignals(ctx context.Context) {
+	if !a.notifier.WantsSignals() {
+		return
+	}
+	url := a.cfg.Notify.SignalsURL
+	if url == "" {
+		url = signals.DefaultServerURL()
+	}
+	client := signals.NewClient(url)
+	delay := time.Second
+	for {
+		started := time.Now()
+		err := client.Stream(ctx, nil, a.notifier.Signal)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > time.Minute {
+			delay = time.Second
+		}
+		slog.Debug("signals stream ended", "error", err, "retry", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, time.Minute)
+	}
+}
+
+// AttachTerminal gives bell notification sinks the terminal a TUI runs in.
+func (a *Aggregator) AttachTerminal(w io.Writer) {
+	a.notifier.AttachTerminal(w)
+}
+
+// ErrNotificationsDisabled is returned for test notifications when no
+// sinks are configured.
+var ErrNotificationsDisabled = errors.New("no notification sinks configured")
+
+// Notifications returns recent notifications, newest first, with what
+// each sink did with them.
+func (a *Aggregator) Notifications() []notify.Entry {
+	return a.notifier.History()
+}
+
+// NotificationSinks describes the configured notification sinks.
+func (a *Aggregator) NotificationSinks() []notify.SinkInfo {
+	return a.notifier.Sinks()
+}
+
+// TestNotification sends a test notification to every sink.
+func (a *Aggregator) TestNotification() error {
+	if !a.notifier.Enabled() {
+		return ErrNotificationsDisabled
+	}
+	a.notifier.Test()
+	return nil
+}
+
+// ErrMetricsDisabled is returned for series queries when the metrics
+// history is turned off.
+var ErrMetricsDisabled = errors.New("metrics history is disabled")
+
+// SampleMetrics samples fleet metrics every cfg.Metrics.Interval until ctx
+// is done, storing them when the metrics history is enabled.
+func (a *Aggregator) SampleMetrics(ctx context.Context) {
+	if a.metrics != nil {
+		defer a.metrics.Close()
+	}
+	interval := a.cfg.Metrics.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.sampleMetrics(now)
+		}
+	}
+}
+
+func (a *Aggregator) sampleMetrics(now time.Time) {
+	a.mu.RLock()
+	scanned := !a.state.UpdatedAt.IsZero()
+	a.mu.RUnlock()
+	if !scanned {
+		return
+	}
+	samples := a.collectMetrics()
+	a.mu.Lock()
+	a.lastMetrics = samples
+	a.mu.Unlock()
+	if a.metrics == nil {
+		return
+	}
+	if err := a.metrics.Record(now, samples); err != nil {
+		slog.Warn("failed to record metrics", "error", err)
+	}
+	if keep := a.cfg.Metrics.Retention; keep > 0 {
+		if _, err := a.metrics.Prune(now.Add(-keep)); err != nil {
+			slog.Warn("failed to prune metrics", "error", err)
+		}
+	}
+}
+
+// collectMetrics takes one sample of every metric from the current state.
+func (a *Aggregator) collectMetrics() []metrics.Sample {
+	state := a.GetState()
+	var samples []metrics.Sample
+
+	// Agent sessions by state, every state for projects that have any.
+	byProject := map[string]map[string]int{}
+	var projects []string
+	for _, s := range state.Sessions {
+		if s.AgentType == "" {
+			continue
+		}
+		counts := byProject[s.ProjectPath]
+		if counts == nil {
+			counts = map[string]int{}
+			byProject[s.ProjectPath] = counts
+			projects = append(projects, s.ProjectPath)
+		}
+		st := s.State
+		if st == "" {
+			st = string(statedetect.StateUnknown)
+		}
+		counts[st]++
+	}
+	for _, p := range projects {
+		for _, st := range agentStates {
+			samples = append(samples, metrics.Sample{
+				Name: metrics.MetricAgents, Project: p, Label: string(st), Value: float64(byProject[p][string(st)]),
+			})
+		}
+	}
+
+	for _, p := range state.Projects {
+		if p.TaskStats != nil {
+			samples = append(samples,
+				metrics.Sample{Name: metrics.MetricTasksDone, Project: p.Path, Value: float64(p.TaskStats.Done)},
+				metrics.Sample{Name: metrics.MetricReviewQueue, Project: p.Path, Value: float64(p.TaskStats.Review)},
+			)
+		}
+		if n, ok := countCommits(p.Path); ok {
+			samples = append(samples, metrics.Sample{Name: metrics.MetricCommits, Project: p.Path, Value: float64(n)})
+		}
+		if p.PollardStats != nil {
+			samples = append(samples, metrics.Sample{Name: metrics.MetricPollardScans, Project: p.Path, Value: float64(p.PollardStats.Scans)})
+		}
+	}
+	return samples
+}
+
+var agentStates = []statedetect.AgentState{
+	statedetect.StateWorking, statedetect.StateWaiting, statedetect.StateBlocked,
+	statedetect.StateStalled, statedetect.StateDone, statedetect.StateError, statedetect.StateUnknown,
+}
+
+// countCommits counts commits reachable from HEAD in a git repository
+func countCommits(dir string) (int, bool) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return 0, false
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-list", "--count", "HEAD").Output()
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	return n, err == nil
+}
+
+// Metrics returns the latest fleet metrics sample, taking one if none has
+// been taken yet.
+func (a *Aggregator) Metrics() []metrics.Sample {
+	a.mu.RLock()
+	samples := a.lastMetrics
+	a.mu.RUnlock()
+	if samples == nil {
+		samples = a.collectMetrics()
+	}
+	return samples
+}
+
+// MetricSeries queries the metrics history.
+func (a *Aggregator) MetricSeries(q metrics.Query) ([]metrics.Point, error) {
+	if a.metrics == nil {
+		return nil, ErrMetricsDisabled
+	}
+	return a.metrics.Series(q)
 }
 
 func (a *Aggregator) loadMCPStatuses(projects []discovery.Project) map[string][]mcp.ComponentStatus {
@@ -667,6 +1175,7 @@ func (a *Aggregator) loadMCPStatuses(projects []discovery.Project) map[string][]
 
 		list := make([]mcp.ComponentStatus, 0, len(components))
 		for _, component := range components {
+			a.mcpManager.Track(p.Path, component)
 			status := a.mcpManager.Status(p.Path, component)
 			if status == nil {
 				status = &mcp.ComponentStatus{
@@ -794,6 +1303,11 @@ func (a *Aggregator) RestartSession(name, projectPath, agentType string) error {
 	return a.tmuxClient.NewSession(name, projectPath, full)
 }
 
+// DisposeSession kills a tmux session.
+func (a *Aggregator) DisposeSession(name string) error {
+	return a.tmuxClient.KillSession(name)
+}
+
 // ForkSession creates a new session in the same project.
 func (a *Aggregator) ForkSession(name, projectPath, agentType string) error {
 	return a.NewSession(name, projectPath, agentType)
@@ -809,13 +1323,15 @@ func (a *Aggregator) AttachSession(name string) error {
 	return a.tmuxClient.AttachSession(name)
 }
 
-// StartMCP starts a repo MCP component.
+// StartMCP starts a repo MCP component. It is supervised until stopped:
+// restarted after crashes and failed liveness probes, with its log
+// persisted under cfg.MCP.LogDir.
 func (a *Aggregator) StartMCP(ctx context.Context, projectPath, component string) error {
-	cmd, workdir, err := a.resolveMCPCommand(projectPath, component)
+	spec, err := a.resolveMCPSpec(projectPath, component)
 	if err != nil {
 		return err
 	}
-	return a.mcpManager.Start(ctx, projectPath, component, cmd, workdir)
+	return a.mcpManager.StartSpec(ctx, projectPath, component, spec)
 }
 
 // StopMCP stops a repo MCP component.
@@ -823,7 +1339,44 @@ func (a *Aggregator) StopMCP(projectPath, component string) error {
 	return a.mcpManager.Stop(projectPath, component)
 }
 
-func (a *Aggregator) resolveMCPCommand(projectPath, component string) ([]string, string, error) {
+// RestartMCP restarts a repo MCP component, re-reading its command from
+// the config.
+func (a *Aggregator) RestartMCP(ctx context.Context, projectPath, component string) error {
+	spec, err := a.resolveMCPSpec(projectPath, component)
+	if err != nil {
+		return err
+	}
+	if err := a.mcpManager.Stop(projectPath, component); err != nil {
+		return err
+	}
+	return a.mcpManager.StartSpec(ctx, projectPath, component, spec)
+}
+
+// MCPComponents returns every MCP component Bigend has started, including
+// stopped and restarting ones, with live status.
+func (a *Aggregator) MCPComponents() []mcp.ComponentStatus {
+	return a.mcpManager.Statuses()
+}
+
+// MCPTail returns the last n lines of a component's log.
+func (a *Aggregator) MCPTail(projectPath, component string, n int) []string {
+	return a.mcpManager.Tail(projectPath, component, n)
+}
+
+func mcpOptions(cfg config.MCPConfig) mcp.Options {
+	return mcp.Options{
+		LogDir:        cfg.LogDir,
+		LogMaxBytes:   cfg.LogMaxBytes,
+		LogBackups:    cfg.LogBackups,
+		ProbeInterval: cfg.ProbeInterval,
+		ProbeTimeout:  cfg.ProbeTimeout,
+		ProbeFailures: cfg.ProbeFailures,
+		BackoffMin:    cfg.BackoffMin,
+		BackoffMax:    cfg.BackoffMax,
+	}
+}
+
+func (a *Aggregator) resolveMCPSpec(projectPath, component string) (mcp.Spec, error) {
 	if a.cfg != nil {
 		var cfg config.MCPComponentConfig
 		switch component {
@@ -832,18 +1385,37 @@ func (a *Aggregator) resolveMCPCommand(projectPath, component string) ([]string,
 		case "client":
 			cfg = a.cfg.MCP.Client
 		default:
-			return nil, "", fmt.Errorf("unknown component: %s", component)
+			return mcp.Spec{}, fmt.Errorf("unknown component: %s", component)
+		}
+		if cfg.Probe != mcp.ProbeNone && cfg.Probe != mcp.ProbeStdio && cfg.Probe != mcp.ProbeHTTP {
+			return mcp.Spec{}, fmt.Errorf("mcp %s: unknown probe %q", component, cfg.Probe)
+		}
+		if cfg.Probe == mcp.ProbeHTTP && cfg.URL == "" {
+			return mcp.Spec{}, fmt.Errorf("mcp %s: http probe needs a url", component)
 		}
 		if cfg.Command != "" {
-			cmd := append([]string{cfg.Command}, cfg.Args...)
 			workdir := cfg.Workdir
 			if workdir == "" {
 				workdir = projectPath
 			}
-			return cmd, workdir, nil
+			return mcp.Spec{
+				Cmd:     append([]string{cfg.Command}, cfg.Args...),
+				Workdir: workdir,
+				Probe:   cfg.Probe,
+				URL:     cfg.URL,
+			}, nil
+		}
+		if cfg.Probe != mcp.ProbeNone {
+			spec, err := defaultMCPSpec(projectPath, component)
+			spec.Probe, spec.URL = cfg.Probe, cfg.URL
+			return spec, err
 		}
 	}
+	return defaultMCPSpec(projectPath, component)
+}
 
+// defaultMCPSpec runs the component's dev script from its directory.
+func defaultMCPSpec(projectPath, component string) (mcp.Spec, error) {
 	var dir string
 	switch component {
 	case "server":
@@ -851,10 +1423,10 @@ func (a *Aggregator) resolveMCPCommand(projectPath, component string) ([]string,
 	case "client":
 		dir = filepath.Join(projectPath, "mcp-client")
 	default:
-		return nil, "", fmt.Errorf("unknown component: %s", component)
+		return mcp.Spec{}, fmt.Errorf("unknown component: %s", component)
 	}
 	if !pathIsDir(dir) {
-		return nil, "", fmt.Errorf("mcp %s directory not found", component)
+		return mcp.Spec{}, fmt.Errorf("mcp %s directory not found", component)
 	}
-	return []string{"npm", "run", "dev"}, dir, nil
+	return mcp.Spec{Cmd: []string{"npm", "run", "dev"}, Workdir: dir}, nil
 }
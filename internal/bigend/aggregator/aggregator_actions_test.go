@@ -14,6 +14,7 @@ type fakeTmux struct {
 	name    string
 	path    string
 	cmd     []string
+	keys    []string
 }
 
 func (f *fakeTmux) IsAvailable() bool                                   { return true }
@@ -24,6 +25,7 @@ func (f *fakeTmux) NewSession(name, path string, cmd []string) error    { f.crea
 func (f *fakeTmux) RenameSession(oldName, newName string) error         { return nil }
 func (f *fakeTmux) KillSession(name string) error                       { f.killed = true; return nil }
 func (f *fakeTmux) AttachSession(name string) error                     { return nil }
+func (f *fakeTmux) SendKeys(sessionName string, keys string) error      { f.keys = append(f.keys, keys); return nil }
 
 func TestRestartSession(t *testing.T) {
 	scanner := discovery.NewScanner(config.DiscoveryConfig{})
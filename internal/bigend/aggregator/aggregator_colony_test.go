@@ -0,0 +1,81 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/colony"
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+)
+
+type fakeWarner struct {
+	warned []string
+	err    error
+}
+
+func (f *fakeWarner) Warn(root string, p colony.Pair) error {
+	f.warned = append(f.warned, p.Key())
+	return f.err
+}
+
+func TestWarnConflictsOncePerCrossing(t *testing.T) {
+	agg := New(discovery.NewScanner(config.DiscoveryConfig{}), &config.Config{
+		Colony: config.ColonyConfig{Conflicts: true, WarnAt: 0.5},
+	})
+	fw := &fakeWarner{}
+	agg.warner = fw
+
+	risky := colony.Pair{A: colony.Worktree{Path: "/r/a"}, B: colony.Worktree{Path: "/r/b"}, Risk: 0.75}
+	calm := colony.Pair{A: colony.Worktree{Path: "/r/a"}, B: colony.Worktree{Path: "/r/c"}, Risk: 0.2}
+	roots := []string{"/r", "/r"}
+
+	agg.warnConflicts(roots, []colony.Pair{risky, calm})
+	agg.warnConflicts(roots, []colony.Pair{risky, calm})
+	if len(fw.warned) != 1 || fw.warned[0] != risky.Key() {
+		t.Fatalf("warned = %q, want one warning for the risky pair", fw.warned)
+	}
+
+	// Dropping below the threshold re-arms the warning
+	risky.Risk = 0.1
+	agg.warnConflicts(roots[:1], []colony.Pair{risky})
+	risky.Risk = 0.6
+	agg.warnConflicts(roots[:1], []colony.Pair{risky})
+	if len(fw.warned) != 2 {
+		t.Fatalf("warned = %q, want a second warning after re-crossing", fw.warned)
+	}
+
+	// Failed sends are retried on the next check
+	fw.err = errors.New("db locked")
+	other := colony.Pair{A: colony.Worktree{Path: "/r/x"}, B: colony.Worktree{Path: "/r/y"}, Risk: 1}
+	agg.warnConflicts(roots[:1], []colony.Pair{other})
+	fw.err = nil
+	agg.warnConflicts(roots[:1], []colony.Pair{other})
+	if len(fw.warned) != 4 {
+		t.Fatalf("warned = %q, want the failed warning retried", fw.warned)
+	}
+}
+
+func TestPredictConflictsReusesResultsWithinInterval(t *testing.T) {
+	agg := New(discovery.NewScanner(config.DiscoveryConfig{}), &config.Config{
+		Colony: config.ColonyConfig{Conflicts: true, Interval: time.Hour},
+	})
+	cached := []colony.Pair{{A: colony.Worktree{Path: "/r"}, B: colony.Worktree{Path: "/r/wt"}, Risk: 0.4}}
+	agg.colonyPairs = map[string][]colony.Pair{"/r": cached}
+	agg.colonyCheckedAt = time.Now()
+
+	colonies := []colony.Colony{{Root: "/r", Worktrees: []colony.Worktree{{Path: "/r"}, {Path: "/r/wt"}}}}
+	agg.predictConflicts(context.Background(), colonies)
+	if len(colonies[0].Pairs) != 1 || colonies[0].Pairs[0].Risk != 0.4 {
+		t.Fatalf("pairs = %+v, want the cached prediction", colonies[0].Pairs)
+	}
+
+	agg.cfg.Colony.Conflicts = false
+	colonies[0].Pairs = nil
+	agg.predictConflicts(context.Background(), colonies)
+	if colonies[0].Pairs != nil {
+		t.Fatal("prediction ran while disabled")
+	}
+}
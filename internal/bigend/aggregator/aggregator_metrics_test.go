@@ -0,0 +1,74 @@
+package aggregator
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
+)
+
+func TestSampleMetricsRecordsFleetHistory(t *testing.T) {
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "one"},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "two"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Skipf("git unavailable: %v %s", err, out)
+		}
+	}
+
+	agg := New(discovery.NewScanner(config.DiscoveryConfig{}), &config.Config{
+		Metrics: config.MetricsConfig{Enabled: true, Path: filepath.Join(t.TempDir(), "metrics.db"), Retention: time.Hour},
+	})
+	defer agg.metrics.Close()
+
+	now := time.Now().Truncate(time.Minute)
+	agg.sampleMetrics(now)
+	if agg.lastMetrics != nil {
+		t.Fatal("expected no sample before the first scan")
+	}
+
+	agg.state = State{
+		Projects: []discovery.Project{{
+			Path:         repo,
+			TaskStats:    &discovery.TaskStats{Done: 4, Review: 2},
+			PollardStats: &discovery.PollardStats{Scans: 3},
+		}},
+		Sessions: []TmuxSession{
+			{Name: "claude-api", AgentType: "claude", ProjectPath: repo, State: "working"},
+			{Name: "codex-api", AgentType: "codex", ProjectPath: repo, State: "waiting"},
+			{Name: "shell", ProjectPath: repo},
+		},
+		UpdatedAt: now,
+	}
+	agg.sampleMetrics(now)
+
+	values := map[string]float64{}
+	for _, s := range agg.Metrics() {
+		values[s.Name+"/"+s.Label] = s.Value
+	}
+	for key, want := range map[string]float64{
+		"agents/working": 1, "agents/waiting": 1, "agents/error": 0,
+		"tasks_done/": 4, "review_queue/": 2, "commits/": 2, "pollard_scans/": 3,
+	} {
+		if got, ok := values[key]; !ok || got != want {
+			t.Errorf("%s = %v (present %v), want %v", key, got, ok, want)
+		}
+	}
+
+	points, err := agg.MetricSeries(metrics.Trends[0].Query("", time.Hour, now.Add(time.Minute), 60))
+	if err != nil || len(points) != 1 || points[0].Value != 1 {
+		t.Fatalf("expected the sample in history, got %+v (%v)", points, err)
+	}
+
+	off := New(discovery.NewScanner(config.DiscoveryConfig{}), &config.Config{})
+	if _, err := off.MetricSeries(metrics.Query{}); err != ErrMetricsDisabled {
+		t.Fatalf("expected ErrMetricsDisabled, got %v", err)
+	}
+}
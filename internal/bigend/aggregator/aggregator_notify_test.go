@@ -0,0 +1,57 @@
+package aggregator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+	"github.com/mistakeknot/autarch/internal/bigend/notify"
+)
+
+// paneTmux serves a settable pane for state detection.
+type paneTmux struct {
+	fakeTmux
+	pane string
+}
+
+func (p *paneTmux) CapturePane(sessionName string, lines int) (string, error) { return p.pane, nil }
+
+func TestDetectSessionStateNotifies(t *testing.T) {
+	agg := New(discovery.NewScanner(config.DiscoveryConfig{}), &config.Config{
+		Notify: config.NotifyConfig{
+			States: []string{"blocked"},
+			Sinks:  []config.NotifySinkConfig{{Name: "term", Type: notify.TypeBell}},
+		},
+	})
+	pane := &paneTmux{}
+	agg.tmuxClient = pane
+	var term bytes.Buffer
+	agg.AttachTerminal(&term)
+
+	session := TmuxSession{Name: "claude-api", AgentType: "claude", ProjectPath: "/srv/api"}
+	pane.pane = "Reading files"
+	agg.detectSessionState(&session)
+	pane.pane = "Allow this edit? [y/n]"
+	agg.detectSessionState(&session)
+	agg.notifier.Wait()
+
+	history := agg.Notifications()
+	if len(history) != 1 || history[0].Title != "claude-api is blocked" || history[0].Previous != "working" {
+		t.Fatalf("unexpected notifications %+v", history)
+	}
+	if term.String() != "\a" {
+		t.Errorf("terminal got %q", term.String())
+	}
+	if sinks := agg.NotificationSinks(); len(sinks) != 1 || sinks[0].Name != "term" {
+		t.Errorf("unexpected sinks %+v", sinks)
+	}
+
+	// Without sinks nothing is tracked, and tests are refused
+	plain := New(discovery.NewScanner(config.DiscoveryConfig{}), &config.Config{})
+	if err := plain.TestNotification(); err != ErrNotificationsDisabled {
+		t.Errorf("test without sinks: %v", err)
+	}
+	plain.WatchSignals(context.Background()) // returns at once
+}
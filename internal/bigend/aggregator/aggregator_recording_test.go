@@ -0,0 +1,39 @@
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+)
+
+func TestDetectSessionStateRecordsAgentPanes(t *testing.T) {
+	dir := t.TempDir()
+	agg := New(discovery.NewScanner(config.DiscoveryConfig{}), &config.Config{
+		Recording: config.RecordingConfig{Enabled: true, Dir: dir},
+	})
+	agg.tmuxClient = &fakeTmux{}
+
+	agg.detectSessionState(&TmuxSession{Name: "shell"})
+	agg.detectSessionState(&TmuxSession{Name: "claude-api", AgentType: "claude"})
+	agg.recorder.Close()
+
+	if list, _ := recording.List(dir, "shell"); len(list) != 0 {
+		t.Fatalf("expected plain shells to stay unrecorded, got %+v", list)
+	}
+	list, err := recording.List(dir, "claude-api")
+	if err != nil || len(list) != 1 {
+		t.Fatalf("expected one recording, got %+v (%v)", list, err)
+	}
+	cast, err := recording.Load(list[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if markers := cast.Markers(); len(markers) != 1 || markers[0].State() == "" {
+		t.Fatalf("expected the detected state as a marker, got %+v", markers)
+	}
+	if agg.RecordingDir() != dir {
+		t.Fatalf("unexpected recording dir %q", agg.RecordingDir())
+	}
+}
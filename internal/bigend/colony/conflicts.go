@@ -0,0 +1,362 @@
+package colony
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Pair is the predicted merge conflict risk between two worktrees of a
+// colony, comparing each side's committed and uncommitted work against
+// their merge base.
+type Pair struct {
+	A         Worktree `json:"a"`
+	B         Worktree `json:"b"`
+	Base      string   `json:"base"`                // merge base commit
+	ChangedA  int      `json:"changed_a"`           // files A changed since Base
+	ChangedB  int      `json:"changed_b"`           // files B changed since Base
+	Shared    []string `json:"shared,omitempty"`    // files both changed
+	Conflicts []string `json:"conflicts,omitempty"` // files a merge would conflict in
+	Risk      float64  `json:"risk"`                // 0 to 1
+}
+
+// Key identifies the pair by its worktree paths.
+func (p Pair) Key() string {
+	return p.A.Path + "\x00" + p.B.Path
+}
+
+// snapshot is a worktree's HEAD and its uncommitted changes, including
+// untracked files.
+type snapshot struct {
+	wt    Worktree
+	head  string
+	dirty map[string]string // path: blob id of the working file, "" if deleted
+}
+
+// change is a file one side changed since the merge base.
+type change struct {
+	base string // blob at the merge base, "" if absent
+	blob string // blob on the side, "" if deleted
+}
+
+// version is one side's content of a file: its blob id, and the working
+// file to read when the blob was hashed but never stored.
+type version struct {
+	blob string // "" when the file is absent
+	dir  string // worktree whose repository holds the blob
+	file string
+}
+
+// hashBatch is how many paths go to one hash-object call.
+const hashBatch = 500
+
+// PredictConflicts compares every pair of worktrees and dry-run merges the
+// files that both sides of a pair changed. Nothing is written to the
+// worktrees, their indexes or the object store: working files are hashed
+// without being stored and merged from temporary copies.
+// Worktrees that cannot be read (missing, no commits) are skipped.
+func PredictConflicts(ctx context.Context, worktrees []Worktree) ([]Pair, error) {
+	tmp, err := os.MkdirTemp("", "bigend-colony-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	var snaps []snapshot
+	for _, wt := range worktrees {
+		s, err := snapshotWorktree(ctx, wt, worktrees)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		snaps = append(snaps, s)
+	}
+
+	var pairs []Pair
+	for i := 0; i < len(snaps); i++ {
+		for j := i + 1; j < len(snaps); j++ {
+			p, err := comparePair(ctx, snaps[i], snaps[j], tmp)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				continue
+			}
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs, nil
+}
+
+// snapshotWorktree records the worktree's HEAD and hashes the files that
+// differ from it or are untracked. Only those paths are read, and
+// hash-object runs without -w so no objects are written. Other worktrees
+// nested inside it, as Coldwine places them, are left out, and so are
+// symlinks and nested repositories.
+func snapshotWorktree(ctx context.Context, wt Worktree, all []Worktree) (snapshot, error) {
+	head, err := git(ctx, wt.Path, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return snapshot{}, err
+	}
+	spec := []string{"--", "."}
+	for _, other := range all {
+		if rel, err := filepath.Rel(wt.Path, other.Path); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			spec = append(spec, ":(exclude,literal)"+filepath.ToSlash(rel))
+		}
+	}
+	tracked, err := gitList(ctx, wt.Path, append([]string{"diff", "-z", "--name-only", "--no-renames", "HEAD"}, spec...)...)
+	if err != nil {
+		return snapshot{}, err
+	}
+	untracked, err := gitList(ctx, wt.Path, append([]string{"ls-files", "-z", "--others", "--exclude-standard"}, spec...)...)
+	if err != nil {
+		return snapshot{}, err
+	}
+
+	dirty := make(map[string]string)
+	var present []string
+	for _, path := range append(tracked, untracked...) {
+		info, err := os.Lstat(filepath.Join(wt.Path, path))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			dirty[path] = ""
+		case err != nil:
+			return snapshot{}, err
+		case info.Mode().IsRegular():
+			present = append(present, path)
+		}
+	}
+	for len(present) > 0 {
+		batch := present[:min(len(present), hashBatch)]
+		present = present[len(batch):]
+		out, err := git(ctx, wt.Path, append([]string{"hash-object", "--"}, batch...)...)
+		if err != nil {
+			return snapshot{}, err
+		}
+		ids := strings.Fields(out)
+		if len(ids) != len(batch) {
+			return snapshot{}, fmt.Errorf("hash-object returned %d ids for %d files", len(ids), len(batch))
+		}
+		for i, path := range batch {
+			dirty[path] = ids[i]
+		}
+	}
+	return snapshot{wt: wt, head: head, dirty: dirty}, nil
+}
+
+func comparePair(ctx context.Context, a, b snapshot, tmp string) (Pair, error) {
+	p := Pair{A: a.wt, B: b.wt}
+	base, err := git(ctx, a.wt.Path, "merge-base", a.head, b.head)
+	if err != nil {
+		return p, err
+	}
+	p.Base = base
+	changedA, err := changes(ctx, a, base)
+	if err != nil {
+		return p, err
+	}
+	changedB, err := changes(ctx, b, base)
+	if err != nil {
+		return p, err
+	}
+	p.ChangedA, p.ChangedB = len(changedA), len(changedB)
+	for path := range changedA {
+		if _, ok := changedB[path]; ok {
+			p.Shared = append(p.Shared, path)
+		}
+	}
+	sort.Strings(p.Shared)
+	for _, path := range p.Shared {
+		ca, cb := changedA[path], changedB[path]
+		if ca.blob == cb.blob {
+			continue
+		}
+		base := version{blob: ca.base, dir: a.wt.Path}
+		conflict, err := mergeConflicts(ctx, tmp, base, a.version(path, ca.blob), b.version(path, cb.blob))
+		if err != nil {
+			return p, err
+		}
+		if conflict {
+			p.Conflicts = append(p.Conflicts, path)
+		}
+	}
+	p.Risk = risk(p)
+	return p, nil
+}
+
+// risk scores a pair: up to 0.4 for how much of the smaller change set
+// overlaps the other, and 0.5 to 1 once a merge would conflict, by the
+// share of overlapping files that conflict.
+func risk(p Pair) float64 {
+	if len(p.Shared) == 0 {
+		return 0
+	}
+	if len(p.Conflicts) > 0 {
+		return 0.5 + 0.5*float64(len(p.Conflicts))/float64(len(p.Shared))
+	}
+	smaller := min(p.ChangedA, p.ChangedB)
+	if smaller == 0 {
+		return 0
+	}
+	return 0.4 * float64(len(p.Shared)) / float64(smaller)
+}
+
+// changes lists the files that differ between base and the snapshot:
+// committed since base, or changed in the worktree since.
+func changes(ctx context.Context, s snapshot, base string) (map[string]change, error) {
+	committed, err := gitList(ctx, s.wt.Path, "diff", "-z", "--name-only", "--no-renames", base, s.head)
+	if err != nil {
+		return nil, err
+	}
+	var objects []string
+	for _, path := range committed {
+		if _, ok := s.dirty[path]; !ok {
+			objects = append(objects, s.head+":"+path, base+":"+path)
+		}
+	}
+	for path := range s.dirty {
+		objects = append(objects, base+":"+path)
+	}
+	blobs, err := lookupBlobs(ctx, s.wt.Path, objects)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]change)
+	for _, path := range committed {
+		if _, ok := s.dirty[path]; !ok {
+			if c := (change{base: blobs[base+":"+path], blob: blobs[s.head+":"+path]}); c.base != c.blob {
+				out[path] = c
+			}
+		}
+	}
+	for path, blob := range s.dirty {
+		if c := (change{base: blobs[base+":"+path], blob: blob}); c.base != c.blob {
+			out[path] = c
+		}
+	}
+	return out, nil
+}
+
+// lookupBlobs resolves rev:path names to blob ids. Names that are missing
+// or not blobs resolve to "".
+func lookupBlobs(ctx context.Context, dir string, names []string) (map[string]string, error) {
+	blobs := make(map[string]string, len(names))
+	var query []string
+	for _, name := range names {
+		if !strings.Contains(name, "\n") {
+			query = append(query, name)
+		}
+	}
+	if len(query) == 0 {
+		return blobs, nil
+	}
+	out, err := gitInput(ctx, dir, strings.Join(query, "\n")+"\n", "cat-file", "--batch-check=%(objectname) %(objecttype)")
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) != len(query) {
+		return nil, fmt.Errorf("cat-file returned %d lines for %d objects", len(lines), len(query))
+	}
+	for i, line := range lines {
+		if id, kind, _ := strings.Cut(line, " "); kind == "blob" {
+			blobs[query[i]] = id
+		}
+	}
+	return blobs, nil
+}
+
+// version returns the side's content of path at blob. Uncommitted files
+// are read from the worktree, since their blobs were never stored.
+func (s snapshot) version(path, blob string) version {
+	v := version{blob: blob, dir: s.wt.Path}
+	if id, ok := s.dirty[path]; ok && id == blob && blob != "" {
+		v.file = filepath.Join(s.wt.Path, path)
+	}
+	return v
+}
+
+func (v version) read(ctx context.Context) ([]byte, error) {
+	if v.file != "" {
+		return os.ReadFile(v.file)
+	}
+	return gitBytes(ctx, v.dir, "cat-file", "blob", v.blob)
+}
+
+// mergeConflicts reports whether a three-way merge of a file would leave
+// conflict markers. A side deleting the file while the other changed it
+// is a conflict.
+func mergeConflicts(ctx context.Context, tmp string, base, ours, theirs version) (bool, error) {
+	if ours.blob == "" || theirs.blob == "" {
+		kept := ours.blob + theirs.blob
+		return kept != "" && kept != base.blob, nil
+	}
+	files := make([]string, 3)
+	for i, v := range []version{ours, base, theirs} {
+		files[i] = filepath.Join(tmp, fmt.Sprintf("stage-%d", i))
+		var content []byte
+		if v.blob != "" {
+			out, err := v.read(ctx)
+			if err != nil {
+				return false, err
+			}
+			content = out
+		}
+		if err := os.WriteFile(files[i], content, 0o600); err != nil {
+			return false, err
+		}
+	}
+	// merge-file exits with the number of conflicts, or negative on error
+	err := exec.CommandContext(ctx, "git", "merge-file", "-p", "-q", files[0], files[1], files[2]).Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() > 0 && exit.ExitCode() < 128 {
+		return true, nil
+	}
+	return false, err
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := gitBytes(ctx, dir, args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// gitList runs git and splits its NUL-terminated output.
+func gitList(ctx context.Context, dir string, args ...string) ([]string, error) {
+	out, err := gitBytes(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, entry := range strings.Split(string(out), "\x00") {
+		if entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list, nil
+}
+
+func gitBytes(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	return gitInput(ctx, dir, "", args...)
+}
+
+// gitInput runs git in dir with input on stdin.
+func gitInput(ctx context.Context, dir, input string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
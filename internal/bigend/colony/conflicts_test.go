@@ -0,0 +1,177 @@
+package colony
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Skipf("git unavailable: %v %s", err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// colonyRepo makes a repo with two linked worktrees, feature-a and
+// feature-b, branched from a commit with api.go and util.go.
+func colonyRepo(t *testing.T) (root string, worktrees []Worktree) {
+	root = t.TempDir()
+	runGit(t, root, "init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(root, "api.go"), "package api\n\nfunc A() int { return 1 }\n\nfunc B() int { return 2 }\n")
+	writeFile(t, filepath.Join(root, "util.go"), "package api\n")
+	runGit(t, root, "add", ".")
+	runGit(t, root, "commit", "-q", "-m", "base")
+	for _, name := range []string{"feature-a", "feature-b"} {
+		runGit(t, root, "worktree", "add", "-q", "-b", name, filepath.Join(root, ".tandemonium", "worktrees", name))
+	}
+	return root, detectWorktrees(root)
+}
+
+func TestPredictConflicts(t *testing.T) {
+	root, worktrees := colonyRepo(t)
+	if len(worktrees) != 3 {
+		t.Fatalf("expected 3 worktrees, got %+v", worktrees)
+	}
+	a, b := worktrees[1].Path, worktrees[2].Path
+
+	// a commits a change to A(); b changes A() without committing and
+	// adds an untracked file a has too
+	writeFile(t, filepath.Join(a, "api.go"), "package api\n\nfunc A() int { return 10 }\n\nfunc B() int { return 2 }\n")
+	runGit(t, a, "commit", "-q", "-am", "a")
+	writeFile(t, filepath.Join(b, "api.go"), "package api\n\nfunc A() int { return 20 }\n\nfunc B() int { return 2 }\n")
+	writeFile(t, filepath.Join(a, "new.go"), "package api\n\nvar X = 1\n")
+	writeFile(t, filepath.Join(b, "new.go"), "package api\n\nvar X = 2\n")
+	// both touch util.go in different places
+	writeFile(t, filepath.Join(a, "util.go"), "// a\npackage api\n")
+	writeFile(t, filepath.Join(b, "util.go"), "package api\n// b\n")
+
+	pairs, err := PredictConflicts(context.Background(), worktrees)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 3 {
+		t.Fatalf("expected 3 pairs, got %d", len(pairs))
+	}
+	var ab Pair
+	for _, p := range pairs {
+		if p.A.Path == a && p.B.Path == b {
+			ab = p
+		} else if p.Risk != 0 || len(p.Shared) != 0 || p.ChangedA != 0 {
+			t.Errorf("pair with untouched main worktree at risk: %+v", p)
+		}
+	}
+	if got := strings.Join(ab.Shared, ","); got != "api.go,new.go,util.go" {
+		t.Errorf("shared = %s", got)
+	}
+	if got := strings.Join(ab.Conflicts, ","); got != "api.go,new.go" {
+		t.Errorf("conflicts = %s", got)
+	}
+	if ab.Risk < 0.5 || ab.Risk > 1 {
+		t.Errorf("risk = %v", ab.Risk)
+	}
+	if ab.Base != runGit(t, root, "rev-parse", "main") {
+		t.Errorf("base = %s", ab.Base)
+	}
+
+	// Neither worktree's index nor files were touched
+	if status := runGit(t, b, "status", "--porcelain"); status != "M api.go\n M util.go\n?? new.go" {
+		t.Errorf("status of b changed: %q", status)
+	}
+	if staged := runGit(t, a, "diff", "--cached", "--name-only"); staged != "" {
+		t.Errorf("a has staged files: %q", staged)
+	}
+	// nor were their working files stored as objects
+	for _, file := range []string{"api.go", "new.go"} {
+		id := runGit(t, b, "hash-object", file)
+		if err := exec.Command("git", "-C", b, "cat-file", "-e", id).Run(); err == nil {
+			t.Errorf("%s of b was written to the object store", file)
+		}
+	}
+}
+
+func TestPredictConflictsOverlapWithoutConflict(t *testing.T) {
+	_, worktrees := colonyRepo(t)
+	a, b := worktrees[1].Path, worktrees[2].Path
+	writeFile(t, filepath.Join(a, "api.go"), "package api\n\nfunc A() int { return 10 }\n\nfunc B() int { return 2 }\n")
+	writeFile(t, filepath.Join(b, "api.go"), "package api\n\nfunc A() int { return 1 }\n\nfunc B() int { return 20 }\n")
+	writeFile(t, filepath.Join(b, "other.go"), "package api\n")
+
+	pairs, err := PredictConflicts(context.Background(), worktrees[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 1 {
+		t.Fatalf("expected 1 pair, got %d", len(pairs))
+	}
+	p := pairs[0]
+	if len(p.Shared) != 1 || len(p.Conflicts) != 0 {
+		t.Fatalf("unexpected pair %+v", p)
+	}
+	if p.Risk != 0.4 {
+		t.Errorf("risk = %v, want 0.4 (all of a's one change overlaps)", p.Risk)
+	}
+}
+
+func TestColdwineInboxWarn(t *testing.T) {
+	root, worktrees := colonyRepo(t)
+	db, err := storage.OpenShared(project.StateDBPath(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.MigrateV2(db); err != nil {
+		t.Fatal(err)
+	}
+	// feature-b is registered to a task; feature-a is found by its path
+	for _, err := range []error{
+		storage.InsertEpic(db, storage.Epic{ID: "EPIC-001", Title: "e", Status: "open"}),
+		storage.InsertStory(db, storage.Story{ID: "STORY-001", EpicID: "EPIC-001", Title: "s", Status: "draft"}),
+		storage.InsertWorkTask(db, storage.WorkTask{ID: "TAND-002", StoryID: "STORY-001", Title: "t", Status: "todo"}),
+		storage.InsertWorktree(db, storage.Worktree{ID: "wt-b", TaskID: "TAND-002", Path: worktrees[2].Path, Branch: "feature-b", Status: "active"}),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := Pair{A: worktrees[1], B: worktrees[2], Base: "0123456789abcdef", Shared: []string{"api.go"}, Conflicts: []string{"api.go"}, Risk: 1}
+	if err := (ColdwineInbox{}).Warn(root, p); err != nil {
+		t.Fatal(err)
+	}
+	for _, to := range []string{"tand-feature-a", "tand-TAND-002"} {
+		inbox, err := storage.FetchInbox(db, to, 10)
+		if err != nil || len(inbox) != 1 {
+			t.Fatalf("inbox of %s: %+v (%v)", to, inbox, err)
+		}
+		msg := inbox[0].Message
+		if msg.Sender != Sender || !strings.Contains(msg.Subject, "feature-a and feature-b") || !strings.Contains(msg.Body, "- api.go") {
+			t.Errorf("unexpected warning %+v", msg)
+		}
+	}
+
+	if err := (ColdwineInbox{}).Warn(t.TempDir(), p); err != ErrNoRecipients {
+		t.Errorf("without Coldwine: got %v, want ErrNoRecipients", err)
+	}
+}
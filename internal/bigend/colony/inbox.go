@@ -0,0 +1,117 @@
+package colony
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/mailbus"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+// Sender is the mail sender of conflict warnings.
+const Sender = "bigend-colony"
+
+// ErrNoRecipients is returned when no agent of either worktree can be
+// found in Coldwine.
+var ErrNoRecipients = errors.New("no Coldwine agent works in these worktrees")
+
+// Warner tells the agents of two worktrees that their work is likely to
+// conflict.
+type Warner interface {
+	Warn(root string, p Pair) error
+}
+
+// ColdwineInbox sends conflict warnings to the Coldwine inboxes of the
+// agents working in each worktree.
+type ColdwineInbox struct{}
+
+// Warn mails both agents of the pair in the Coldwine state of the repo at
+// root.
+func (ColdwineInbox) Warn(root string, p Pair) error {
+	path := project.StateDBPath(root)
+	if _, err := os.Stat(path); err != nil {
+		return ErrNoRecipients
+	}
+	db, err := storage.OpenShared(path)
+	if err != nil {
+		return err
+	}
+	mailbus.Attach(db, project.MailBusDir(root))
+
+	var to []string
+	for _, wt := range []Worktree{p.A, p.B} {
+		if name := worktreeAgent(db, root, wt.Path); name != "" {
+			to = append(to, name)
+		}
+	}
+	if len(to) == 0 {
+		return ErrNoRecipients
+	}
+	return storage.SendMessage(db, storage.Message{
+		ID:         fmt.Sprintf("msg-%d", time.Now().UTC().UnixNano()),
+		ThreadID:   "colony-conflict-" + filepath.Base(p.A.Path) + "-" + filepath.Base(p.B.Path),
+		Sender:     Sender,
+		Subject:    warningSubject(p),
+		Body:       warningBody(p),
+		Importance: "high",
+	}, to)
+}
+
+// worktreeAgent finds the Coldwine agent working in a worktree: the agent
+// session registered there, or the session of the task the worktree was
+// created for.
+func worktreeAgent(db *sql.DB, root, path string) string {
+	if sessions, err := storage.ListActiveAgentSessions(db); err == nil {
+		for _, s := range sessions {
+			if s.WorktreePath != "" && filepath.Clean(s.WorktreePath) == filepath.Clean(path) {
+				return s.AgentName
+			}
+		}
+	}
+	if wt, err := storage.GetWorktreeByPath(db, path); err == nil && wt.TaskID != "" {
+		return agent.SessionID(wt.TaskID)
+	}
+	if rel, err := filepath.Rel(project.WorktreesDir(root), path); err == nil && !strings.HasPrefix(rel, "..") && rel != "." {
+		return agent.SessionID(strings.Split(rel, string(filepath.Separator))[0])
+	}
+	return ""
+}
+
+func warningSubject(p Pair) string {
+	if len(p.Conflicts) > 0 {
+		return fmt.Sprintf("Merge conflict predicted between %s and %s: %d file(s)", worktreeLabel(p.A), worktreeLabel(p.B), len(p.Conflicts))
+	}
+	return fmt.Sprintf("Overlapping changes in %s and %s: %d file(s)", worktreeLabel(p.A), worktreeLabel(p.B), len(p.Shared))
+}
+
+func warningBody(p Pair) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s and %s changed the same files since %.12s (risk %.0f%%).\n",
+		worktreeLabel(p.A), worktreeLabel(p.B), p.Base, p.Risk*100)
+	if len(p.Conflicts) > 0 {
+		b.WriteString("\nA merge would conflict in:\n")
+		for _, f := range p.Conflicts {
+			fmt.Fprintf(&b, "- %s\n", f)
+		}
+	}
+	b.WriteString("\nChanged by both:\n")
+	for _, f := range p.Shared {
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+	b.WriteString("\nCoordinate before continuing, e.g. reserve the files with `coldwine lock reserve`.\n")
+	return b.String()
+}
+
+func worktreeLabel(wt Worktree) string {
+	if wt.Branch != "" {
+		return wt.Branch
+	}
+	return filepath.Base(wt.Path)
+}
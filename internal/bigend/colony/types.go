@@ -7,6 +7,7 @@ type Colony struct {
 	Worktrees []Worktree     `json:"worktrees"`
 	Members   []ColonyMember `json:"members"`
 	Markers   []string       `json:"markers,omitempty"`
+	Pairs     []Pair         `json:"pairs,omitempty"` // conflict risk between worktrees
 }
 
 // Worktree represents a git worktree entry.
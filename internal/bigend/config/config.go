@@ -14,11 +14,28 @@ type Config struct {
 	Tmux      TmuxConfig      `toml:"tmux"`
 	Agents    map[string]AgentCommand `toml:"agents"`
 	MCP       MCPConfig       `toml:"mcp"`
+	Daemon    DaemonConfig    `toml:"daemon"`
+	Recording RecordingConfig `toml:"recording"`
+	Automation AutomationConfig `toml:"automation"`
+	Metrics   MetricsConfig   `toml:"metrics"`
+	Federation FederationConfig `toml:"federation"`
+	Hooks     HooksConfig     `toml:"hooks"`
+	Colony    ColonyConfig    `toml:"colony"`
+	Notify    NotifyConfig    `toml:"notify"`
 }
 
 type ServerConfig struct {
-	Port int    `toml:"port"`
-	Host string `toml:"host"`
+	Port  int         `toml:"port"`
+	Host  string      `toml:"host"`
+	Share ShareConfig `toml:"share"`
+}
+
+// ShareConfig configures terminal sharing. Share tokens and the audit log
+// of keystrokes sent are kept in the SQLite database at Path; tokens
+// expire after TTL unless a shorter one is asked for.
+type ShareConfig struct {
+	Path string        `toml:"path"`
+	TTL  time.Duration `toml:"ttl"`
 }
 
 type DiscoveryConfig struct {
@@ -31,20 +48,180 @@ type TmuxConfig struct {
 	SocketPath string `toml:"socket_path"`
 }
 
+// DaemonConfig configures the daemon's session registry.
+type DaemonConfig struct {
+	StatePath string `toml:"state_path"`
+}
+
+// RecordingConfig configures pane recording. Agent panes are captured
+// every Interval and written to Dir as asciicast files, which are dropped
+// after Retention or, oldest first, once Dir holds more than MaxBytes.
+type RecordingConfig struct {
+	Enabled   bool          `toml:"enabled"`
+	Dir       string        `toml:"dir"`
+	Interval  time.Duration `toml:"interval"`
+	Retention time.Duration `toml:"retention"`
+	MaxBytes  int64         `toml:"max_bytes"`
+}
+
+// MetricsConfig configures the fleet metrics history. Samples are taken
+// every Interval, stored in the SQLite database at Path and dropped after
+// Retention.
+type MetricsConfig struct {
+	Enabled   bool          `toml:"enabled"`
+	Path      string        `toml:"path"`
+	Interval  time.Duration `toml:"interval"`
+	Retention time.Duration `toml:"retention"`
+}
+
+// HooksConfig configures the socket autarch-hook reports agent events to.
+// Events older than StaleAfter that say the agent is working are no
+// longer trusted, and state falls back to scraping the pane.
+type HooksConfig struct {
+	Enabled    bool          `toml:"enabled"`
+	Socket     string        `toml:"socket"`
+	StaleAfter time.Duration `toml:"stale_after"`
+}
+
+// ColonyConfig configures merge conflict prediction between the worktrees
+// of a colony. Worktree pairs are dry-run merged every Interval, and the
+// agents of a pair whose risk reaches WarnAt (0 to 1) are mailed a warning
+// in Coldwine; a WarnAt of 0 turns warnings off.
+type ColonyConfig struct {
+	Conflicts bool          `toml:"conflicts"`
+	Interval  time.Duration `toml:"interval"`
+	WarnAt    float64       `toml:"warn_at"`
+}
+
+// FederationConfig lists peer Bigend daemons whose projects and sessions
+// are merged into this host's view.
+type FederationConfig struct {
+	Peers   []PeerConfig  `toml:"peers"`
+	Timeout time.Duration `toml:"timeout"`
+}
+
+// PeerConfig is one peer daemon, reached on a loopback URL or a unix socket
+// forwarded over SSH. With SSH set, Bigend runs the tunnel itself,
+// forwarding Socket to Remote on the SSH host.
+type PeerConfig struct {
+	Name   string `toml:"name"`   // host label, e.g. "devbox1"
+	URL    string `toml:"url"`    // e.g. "http://127.0.0.1:18100" from ssh -L
+	Socket string `toml:"socket"` // local unix socket forwarded to the peer daemon
+	SSH    string `toml:"ssh"`    // ssh destination, e.g. "me@devbox1"
+	Remote string `toml:"remote"` // peer daemon address or socket on the SSH host
+}
+
+// NotifyConfig configures notifications. An agent session entering one
+// of States notifies, and so does a critical signal from the signals
+// server at SignalsURL (AUTARCH_SIGNALS_URL or the default when empty)
+// when Signals is set. Nothing is sent without Sinks.
+type NotifyConfig struct {
+	States     []string           `toml:"states"`
+	Signals    bool               `toml:"signals"`
+	SignalsURL string             `toml:"signals_url"`
+	Sinks      []NotifySinkConfig `toml:"sinks"`
+}
+
+// NotifySinkConfig is one place notifications go. Type "desktop" runs
+// Command (notify-send by default), "bell" rings the terminal of a
+// running TUI, adding an OSC 9 desktop notification when OSC9 is set, and
+// "webhook" posts the notification as JSON to URL, which must be on
+// loopback. Routes pick what the sink receives; without routes it gets
+// everything. It stays silent during QuietHours, "22:00-07:00" in local
+// time, and sends at most RateLimit notifications per RateWindow.
+type NotifySinkConfig struct {
+	Name       string              `toml:"name"`
+	Type       string              `toml:"type"`
+	Command    string              `toml:"command"`
+	URL        string              `toml:"url"`
+	OSC9       bool                `toml:"osc9"`
+	Routes     []NotifyRouteConfig `toml:"routes"`
+	QuietHours string              `toml:"quiet_hours"`
+	RateLimit  int                 `toml:"rate_limit"`
+	RateWindow time.Duration       `toml:"rate_window"`
+}
+
+// NotifyRouteConfig matches notifications by project and state; the first
+// route that matches decides, and Mute drops what it matches. A project
+// pattern with a slash is a glob on the project path, otherwise on its
+// name. States are agent states, and "signal" for critical signals. Empty
+// lists match everything.
+type NotifyRouteConfig struct {
+	Projects []string `toml:"projects"`
+	States   []string `toml:"states"`
+	Mute     bool     `toml:"mute"`
+}
+
+// AutomationConfig holds rules that act on agent state transitions. With
+// DryRun set, matching rules are logged but their actions are not run.
+type AutomationConfig struct {
+	DryRun bool         `toml:"dry_run"`
+	Rules  []RuleConfig `toml:"rules"`
+}
+
+// RuleConfig is one automation rule: when an agent session matching
+// Session and Agent has been in State for at least For, has entered it
+// Count times within Within, and its Coldwine task has TaskStatus, run
+// Actions. A rule fires at most once per stay in the state and not again
+// within Cooldown.
+type RuleConfig struct {
+	Name       string         `toml:"name"`
+	Session    string         `toml:"session"` // glob on the tmux session name
+	Agent      string         `toml:"agent"`   // agent type, e.g. "claude"
+	State      string         `toml:"state"`
+	For        time.Duration  `toml:"for"`
+	Count      int            `toml:"count"`
+	Within     time.Duration  `toml:"within"`
+	TaskStatus string         `toml:"task_status"`
+	Cooldown   time.Duration  `toml:"cooldown"`
+	Actions    []ActionConfig `toml:"actions"`
+}
+
+// ActionConfig is one step of a rule. Text fields may use the placeholders
+// {rule}, {session}, {agent}, {state}, {duration}, {count}, {project},
+// {task} and {task_status}.
+type ActionConfig struct {
+	Type     string   `toml:"type"` // nudge, restart, dispose, send-keys, mail, signal
+	Keys     string   `toml:"keys"` // send-keys: tmux key names, e.g. "C-c"
+	Text     string   `toml:"text"` // nudge and send-keys: text typed into the pane
+	To       []string `toml:"to"`   // mail recipients
+	Subject  string   `toml:"subject"`
+	Body     string   `toml:"body"`
+	Severity string   `toml:"severity"` // signal: info, warning or critical
+}
+
 type AgentCommand struct {
 	Command string   `toml:"command"`
 	Args    []string `toml:"args"`
 }
 
+// MCPComponentConfig is how a repo MCP component is run. Probe picks its
+// liveness probe: "stdio" sends JSON-RPC initialize and ping over the
+// process's stdin and stdout, "http" posts them to URL, and "" only
+// watches the process.
 type MCPComponentConfig struct {
 	Command string   `toml:"command"`
 	Args    []string `toml:"args"`
 	Workdir string   `toml:"workdir"`
+	Probe   string   `toml:"probe"`
+	URL     string   `toml:"url"`
 }
 
+// MCPConfig configures repo MCP components and their supervision. A
+// component that crashes or fails ProbeFailures probes in a row is
+// restarted after a backoff doubling from BackoffMin to BackoffMax. Logs
+// are written under LogDir and rotated past LogMaxBytes.
 type MCPConfig struct {
-	Server MCPComponentConfig `toml:"server"`
-	Client MCPComponentConfig `toml:"client"`
+	Server        MCPComponentConfig `toml:"server"`
+	Client        MCPComponentConfig `toml:"client"`
+	LogDir        string             `toml:"log_dir"`
+	LogMaxBytes   int64              `toml:"log_max_bytes"`
+	LogBackups    int                `toml:"log_backups"`
+	ProbeInterval time.Duration      `toml:"probe_interval"`
+	ProbeTimeout  time.Duration      `toml:"probe_timeout"`
+	ProbeFailures int                `toml:"probe_failures"`
+	BackoffMin    time.Duration      `toml:"backoff_min"`
+	BackoffMax    time.Duration      `toml:"backoff_max"`
 }
 
 func Load(path string) (*Config, error) {
@@ -52,12 +229,59 @@ func Load(path string) (*Config, error) {
 		Server: ServerConfig{
 			Port: 8099,
 			Host: "0.0.0.0",
+			Share: ShareConfig{
+				Path: "~/.config/bigend/share.db",
+				TTL:  24 * time.Hour,
+			},
 		},
 		Discovery: DiscoveryConfig{
 			ScanRoots:       []string{expandHome("~/projects")},
 			ScanInterval:    30 * time.Second,
 			ExcludePatterns: []string{"node_modules", ".git", "vendor", "target"},
 		},
+		MCP: MCPConfig{
+			LogDir:        "~/.config/bigend/mcp-logs",
+			LogMaxBytes:   1 << 20,
+			LogBackups:    3,
+			ProbeInterval: 15 * time.Second,
+			ProbeTimeout:  5 * time.Second,
+			ProbeFailures: 3,
+			BackoffMin:    time.Second,
+			BackoffMax:    time.Minute,
+		},
+		Daemon: DaemonConfig{
+			StatePath: "~/.config/bigend/daemon.db",
+		},
+		Recording: RecordingConfig{
+			Enabled:   true,
+			Dir:       "~/.config/bigend/recordings",
+			Interval:  time.Second,
+			Retention: 30 * 24 * time.Hour,
+			MaxBytes:  1 << 30,
+		},
+		Metrics: MetricsConfig{
+			Enabled:   true,
+			Path:      "~/.config/bigend/metrics.db",
+			Interval:  time.Minute,
+			Retention: 7 * 24 * time.Hour,
+		},
+		Federation: FederationConfig{
+			Timeout: 5 * time.Second,
+		},
+		Hooks: HooksConfig{
+			Enabled:    true,
+			Socket:     "~/.autarch/bigend.sock",
+			StaleAfter: 10 * time.Minute,
+		},
+		Colony: ColonyConfig{
+			Conflicts: true,
+			Interval:  5 * time.Minute,
+			WarnAt:    0.5,
+		},
+		Notify: NotifyConfig{
+			States:  []string{"stalled", "blocked", "error"},
+			Signals: true,
+		},
 	}
 
 	// Try default paths if not specified
@@ -88,6 +312,22 @@ func Load(path string) (*Config, error) {
 	for i, root := range cfg.Discovery.ScanRoots {
 		cfg.Discovery.ScanRoots[i] = expandHome(root)
 	}
+	cfg.Daemon.StatePath = expandHome(cfg.Daemon.StatePath)
+	cfg.Recording.Dir = expandHome(cfg.Recording.Dir)
+	cfg.Metrics.Path = expandHome(cfg.Metrics.Path)
+	cfg.MCP.LogDir = expandHome(cfg.MCP.LogDir)
+	cfg.Hooks.Socket = expandHome(cfg.Hooks.Socket)
+	cfg.Server.Share.Path = expandHome(cfg.Server.Share.Path)
+	for i := range cfg.Notify.Sinks {
+		for j := range cfg.Notify.Sinks[i].Routes {
+			for k, p := range cfg.Notify.Sinks[i].Routes[j].Projects {
+				cfg.Notify.Sinks[i].Routes[j].Projects[k] = expandHome(p)
+			}
+		}
+	}
+	for i := range cfg.Federation.Peers {
+		cfg.Federation.Peers[i].Socket = expandHome(cfg.Federation.Peers[i].Socket)
+	}
 
 	return cfg, nil
 }
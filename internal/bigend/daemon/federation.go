@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+)
+
+func (s *Server) handleFederationState(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.state.GetState())
+}
+
+func (s *Server) handleFederationRestart(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.federatedSession(w, r)
+	if !ok {
+		return
+	}
+	if session.AgentType == "" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("session %q is not an agent session", session.Name))
+		return
+	}
+	if err := s.state.RestartSession(session.Name, session.ProjectPath, session.AgentType); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "restarted"})
+}
+
+func (s *Server) handleFederationDispose(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.federatedSession(w, r)
+	if !ok {
+		return
+	}
+	if err := s.state.DisposeSession(session.Name); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "disposed"})
+}
+
+// federatedSession finds the tmux session named in the path.
+func (s *Server) federatedSession(w http.ResponseWriter, r *http.Request) (aggregator.TmuxSession, bool) {
+	name := r.PathValue("name")
+	for _, session := range s.state.GetState().Sessions {
+		if session.Name == name {
+			return session, true
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", name))
+	return aggregator.TmuxSession{}, false
+}
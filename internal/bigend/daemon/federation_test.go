@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+)
+
+type fakeState struct {
+	state    aggregator.State
+	restarts []string
+	disposed []string
+}
+
+func (f *fakeState) GetState() aggregator.State { return f.state }
+func (f *fakeState) RestartSession(name, projectPath, agentType string) error {
+	f.restarts = append(f.restarts, name+" "+projectPath+" "+agentType)
+	return nil
+}
+func (f *fakeState) DisposeSession(name string) error {
+	f.disposed = append(f.disposed, name)
+	return nil
+}
+
+func TestFederationEndpoints(t *testing.T) {
+	state := &fakeState{state: aggregator.State{Sessions: []aggregator.TmuxSession{
+		{Name: "claude-api", AgentType: "claude", ProjectPath: "/p/api"},
+		{Name: "shell"},
+	}}}
+	srv := &Server{mux: http.NewServeMux(), sessions: NewSessionManager(), state: state}
+	srv.setupRoutes()
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	if rec := do("GET", "/api/federation/state"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"claude-api"`) {
+		t.Fatalf("unexpected state %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("POST", "/api/federation/sessions/claude-api/restart"); rec.Code != http.StatusOK {
+		t.Fatalf("restart failed %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(state.restarts) != 1 || state.restarts[0] != "claude-api /p/api claude" {
+		t.Fatalf("unexpected restarts %v", state.restarts)
+	}
+	if rec := do("POST", "/api/federation/sessions/shell/restart"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected plain shells to be refused, got %d", rec.Code)
+	}
+	if rec := do("POST", "/api/federation/sessions/shell/dispose"); rec.Code != http.StatusOK || state.disposed[0] != "shell" {
+		t.Fatalf("dispose failed %d", rec.Code)
+	}
+	if rec := do("POST", "/api/federation/sessions/gone/dispose"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown session, got %d", rec.Code)
+	}
+
+	plain := &Server{mux: http.NewServeMux(), sessions: NewSessionManager()}
+	plain.setupRoutes()
+	rec := httptest.NewRecorder()
+	plain.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/api/federation/state", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected no federation API without a state source, got %d", rec.Code)
+	}
+}
+
+func TestStartRefusesNonLoopback(t *testing.T) {
+	srv := &Server{addr: "0.0.0.0:0", mux: http.NewServeMux()}
+	if err := srv.Start(); err == nil || !strings.Contains(err.Error(), "non-loopback") {
+		t.Fatalf("expected the bind to be refused, got %v", err)
+	}
+}
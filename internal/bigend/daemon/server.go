@@ -6,45 +6,104 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
 	"github.com/mistakeknot/autarch/internal/bigend/tmux"
+	"github.com/mistakeknot/autarch/pkg/netguard"
 	"nhooyr.io/websocket"
 )
 
 // Server is the Vauxhall daemon HTTP server
 type Server struct {
 	addr       string
+	socket     string
+	state      StateSource
 	mux        *http.ServeMux
 	server     *http.Server
 	sessions   *SessionManager
+	store      *SessionStore
 	projects   *ProjectManager
 	tmuxClient *tmux.Client
 	mu         sync.RWMutex
 	startedAt  time.Time
+
+	reconcileEvery time.Duration
+	stop           chan struct{}
+	stopOnce       sync.Once
 }
 
+// DefaultReconcileInterval is how often a daemon with a session registry
+// checks its sessions against tmux.
+const DefaultReconcileInterval = 30 * time.Second
+
 // Config holds server configuration
 type Config struct {
 	Addr        string
 	ProjectDirs []string
+	// StatePath is the SQLite session registry. Empty keeps sessions in
+	// memory, lost when the daemon stops.
+	StatePath string
+	// Socket, when set, is a unix socket to serve on instead of Addr, for
+	// peers that reach the daemon through a forwarded socket.
+	Socket string
+	// State, when set, serves the aggregated view and session actions to
+	// federated Bigend peers under /api/federation.
+	State StateSource
+	// ReconcileInterval is how often registered sessions are matched
+	// against tmux while the daemon runs; zero uses
+	// DefaultReconcileInterval. Only used with a StatePath.
+	ReconcileInterval time.Duration
+}
+
+// StateSource is the aggregated view shared with federated peers.
+type StateSource interface {
+	GetState() aggregator.State
+	RestartSession(name, projectPath, agentType string) error
+	DisposeSession(name string) error
 }
 
-// NewServer creates a new daemon server
-func NewServer(cfg Config) *Server {
+// NewServer creates a new daemon server. With a state path it reloads the
+// sessions of the previous daemon and adopts those still running in tmux.
+func NewServer(cfg Config) (*Server, error) {
 	s := &Server{
 		addr:       cfg.Addr,
+		socket:     cfg.Socket,
+		state:      cfg.State,
 		mux:        http.NewServeMux(),
 		sessions:   NewSessionManager(),
 		projects:   NewProjectManager(cfg.ProjectDirs),
 		tmuxClient: tmux.NewClient(),
 		startedAt:  time.Now(),
+		stop:       make(chan struct{}),
+	}
+	if cfg.StatePath != "" {
+		store, err := OpenSessionStore(cfg.StatePath)
+		if err != nil {
+			return nil, fmt.Errorf("open session registry: %w", err)
+		}
+		sessions, err := NewPersistentSessionManager(store, s.tmuxClient)
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("load session registry: %w", err)
+		}
+		if err := sessions.Reconcile(); err != nil {
+			log.Printf("session reconcile skipped: %v", err)
+		}
+		s.store, s.sessions = store, sessions
+		s.reconcileEvery = cfg.ReconcileInterval
+		if s.reconcileEvery <= 0 {
+			s.reconcileEvery = DefaultReconcileInterval
+		}
 	}
 	s.setupRoutes()
-	return s
+	return s, nil
 }
 
 func (s *Server) setupRoutes() {
@@ -58,6 +117,8 @@ func (s *Server) setupRoutes() {
 	s.mux.HandleFunc("DELETE /api/dispose/{id}", s.handleDispose)
 	s.mux.HandleFunc("POST /api/sessions/{id}/restart", s.handleRestart)
 	s.mux.HandleFunc("POST /api/sessions/{id}/attach", s.handleAttach)
+	s.mux.HandleFunc("GET /api/sessions/{id}/history", s.handleSessionHistory)
+	s.mux.HandleFunc("GET /api/history", s.handleHistory)
 
 	// Projects API
 	s.mux.HandleFunc("GET /api/projects", s.handleListProjects)
@@ -69,21 +130,93 @@ func (s *Server) setupRoutes() {
 
 	// WebSocket for terminal streaming
 	s.mux.HandleFunc("GET /ws/terminal/{id}", s.handleWebSocket)
+
+	// Federation API, for Bigend peers
+	if s.state != nil {
+		s.mux.HandleFunc("GET /api/federation/state", s.handleFederationState)
+		s.mux.HandleFunc("POST /api/federation/sessions/{name}/restart", s.handleFederationRestart)
+		s.mux.HandleFunc("POST /api/federation/sessions/{name}/dispose", s.handleFederationDispose)
+	}
 }
 
-// Start starts the HTTP server
+// Handler returns the daemon's routes.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Start starts the HTTP server. The daemon only listens on loopback or a
+// unix socket; peers on other hosts reach it through SSH forwarding.
 func (s *Server) Start() error {
 	s.server = &http.Server{
 		Addr:    s.addr,
 		Handler: s.mux,
 	}
+	if s.reconcileEvery > 0 {
+		go s.reconcileLoop()
+	}
+	if s.socket != "" {
+		ln, err := listenUnix(s.socket)
+		if err != nil {
+			return err
+		}
+		log.Printf("Vauxhall daemon starting on %s", s.socket)
+		return s.server.Serve(ln)
+	}
+	if err := netguard.EnsureLocalOnly(s.addr); err != nil {
+		return err
+	}
 	log.Printf("Vauxhall daemon starting on %s", s.addr)
 	return s.server.ListenAndServe()
 }
 
+// listenUnix listens on a socket only the owner can connect to, replacing
+// a stale socket left by an earlier daemon.
+func listenUnix(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// reconcileLoop reconciles the sessions every reconcileEvery until
+// Shutdown, so sessions that die or come back while the daemon runs are
+// noticed without a restart.
+func (s *Server) reconcileLoop() {
+	ticker := time.NewTicker(s.reconcileEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.sessions.Reconcile(); err != nil {
+				log.Printf("session reconcile failed: %v", err)
+			}
+		}
+	}
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	s.stopOnce.Do(func() { close(s.stop) })
+	err := s.server.Shutdown(ctx)
+	if s.store != nil {
+		if cerr := s.store.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
 }
 
 // Health response
@@ -135,6 +268,9 @@ const (
 	SessionRunning  SessionStatus = "running"  // Session actively running
 	SessionDone     SessionStatus = "done"     // Agent completed work
 	SessionDisposed SessionStatus = "disposed" // Session destroyed
+	SessionDead     SessionStatus = "dead"     // tmux session gone without being disposed
+	// SessionSuperseded is a dead session whose name a newer session took.
+	SessionSuperseded SessionStatus = "superseded"
 )
 
 // Session represents a managed tmux session
@@ -144,9 +280,13 @@ type Session struct {
 	ProjectPath string        `json:"project_path"`
 	AgentType   string        `json:"agent_type"`
 	Status      SessionStatus `json:"status"`
-	CreatedAt   time.Time     `json:"created_at"`
+	CreatedAt   time.Time     `json:"created_at"` // Spawn time; kept across restarts
 
 	// Lifecycle tracking
+	RestartCount int       `json:"restart_count"`
+	RestartedAt  time.Time `json:"restarted_at,omitempty"`
+	AdoptedAt    time.Time `json:"adopted_at,omitempty"`     // Taken over by a restarted daemon
+	EndedAt      time.Time `json:"ended_at,omitempty"`       // Found dead or disposed
 	LastOutputAt time.Time `json:"last_output_at,omitempty"` // Last terminal output
 	LastViewedAt time.Time `json:"last_viewed_at,omitempty"` // Last time user viewed
 
@@ -236,6 +376,44 @@ func (s *Server) handleAttach(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "attached"})
 }
 
+func (s *Server) handleSessionHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "session id required")
+		return
+	}
+	s.writeHistory(w, r, id)
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	s.writeHistory(w, r, "")
+}
+
+func (s *Server) writeHistory(w http.ResponseWriter, r *http.Request, id string) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+	events, err := s.sessions.History(id, limit)
+	if err != nil {
+		writeError(w, http.StatusNotImplemented, err.Error())
+		return
+	}
+	if id != "" && len(events) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no history for session %q", id))
+		return
+	}
+	if events == nil {
+		events = []SessionEvent{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
 // Project represents a discovered project
 type Project struct {
 	Path           string        `json:"path"`
@@ -1,17 +1,28 @@
 package daemon
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"os/exec"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mistakeknot/autarch/internal/bigend/tmux"
 )
 
+// SessionLister lists the live tmux sessions.
+type SessionLister interface {
+	ListSessions() ([]tmux.Session, error)
+}
+
 // SessionManager manages tmux sessions
 type SessionManager struct {
 	sessions map[string]*Session
+	store    *SessionStore // nil keeps sessions in memory only
+	tmux     SessionLister
+	live     map[string]bool // sessions seen running by this daemon
 	mu       sync.RWMutex
 }
 
@@ -19,6 +30,43 @@ type SessionManager struct {
 func NewSessionManager() *SessionManager {
 	return &SessionManager{
 		sessions: make(map[string]*Session),
+		live:     make(map[string]bool),
+	}
+}
+
+// NewPersistentSessionManager creates a session manager backed by store,
+// loading the sessions a previous daemon left behind. Call Reconcile to
+// adopt the ones still running.
+func NewPersistentSessionManager(store *SessionStore, lister SessionLister) (*SessionManager, error) {
+	m := NewSessionManager()
+	m.store = store
+	m.tmux = lister
+	sessions, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		m.sessions[s.ID] = s
+	}
+	return m, nil
+}
+
+// record saves the session and appends a history event. The tmux side of a
+// change has already happened by then, so a failure is logged rather than
+// undoing it.
+func (m *SessionManager) record(s *Session, kind, detail string, at time.Time) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.Save(s); err != nil {
+		log.Printf("session %s: save failed: %v", s.Name, err)
+		return
+	}
+	if kind == "" {
+		return
+	}
+	if err := m.store.Record(s, kind, detail, at); err != nil {
+		log.Printf("session %s: history failed: %v", s.Name, err)
 	}
 }
 
@@ -56,7 +104,7 @@ func (m *SessionManager) Spawn(name, projectPath, agentType string) (*Session, e
 
 	// Check for duplicate name
 	for _, s := range m.sessions {
-		if s.Name == name {
+		if s.Name == name && s.Status != SessionDead && s.Status != SessionSuperseded {
 			return nil, fmt.Errorf("session with name %q already exists", name)
 		}
 	}
@@ -78,15 +126,25 @@ func (m *SessionManager) Spawn(name, projectPath, agentType string) (*Session, e
 		}
 	}
 
+	now := time.Now()
+	// Dead sessions of the same name would otherwise be adopted along with
+	// this one; they keep their history but are never adopted again.
+	for _, s := range m.sessions {
+		if s.Name == name && s.Status == SessionDead {
+			m.supersede(s, id, now)
+		}
+	}
 	session := &Session{
 		ID:          id,
 		Name:        name,
 		ProjectPath: projectPath,
 		AgentType:   agentType,
 		Status:      SessionRunning,
-		CreatedAt:   time.Now(),
+		CreatedAt:   now,
 	}
 	m.sessions[id] = session
+	m.live[id] = true
+	m.record(session, EventSpawned, projectPath, now)
 
 	return session, nil
 }
@@ -105,7 +163,14 @@ func (m *SessionManager) Dispose(id string) error {
 	cmd := exec.Command("tmux", "kill-session", "-t", session.Name)
 	_ = cmd.Run() // Ignore errors if session already gone
 
+	now := time.Now()
+	session.Status = SessionDisposed
+	if session.EndedAt.IsZero() {
+		session.EndedAt = now
+	}
+	m.record(session, EventDisposed, "", now)
 	delete(m.sessions, id)
+	delete(m.live, id)
 	return nil
 }
 
@@ -118,6 +183,9 @@ func (m *SessionManager) Restart(id string) (*Session, error) {
 	if !ok {
 		return nil, fmt.Errorf("session %q not found", id)
 	}
+	if session.Status == SessionSuperseded {
+		return nil, fmt.Errorf("session %q was replaced by a newer %q session", id, session.Name)
+	}
 
 	// Kill and recreate
 	killCmd := exec.Command("tmux", "kill-session", "-t", session.Name)
@@ -137,8 +205,13 @@ func (m *SessionManager) Restart(id string) (*Session, error) {
 		}
 	}
 
+	now := time.Now()
 	session.Status = SessionRunning
-	session.CreatedAt = time.Now()
+	session.RestartCount++
+	session.RestartedAt = now
+	session.EndedAt = time.Time{}
+	m.live[id] = true
+	m.record(session, EventRestarted, fmt.Sprintf("restart %d", session.RestartCount), now)
 	return session, nil
 }
 
@@ -158,22 +231,93 @@ func (m *SessionManager) Attach(id string) error {
 	return cmd.Start()
 }
 
-// DiscoverExisting finds existing tmux sessions and adds them to the manager
+// DiscoverExisting reconciles the manager's sessions with the live tmux
+// sessions; see Reconcile.
 func (m *SessionManager) DiscoverExisting() error {
-	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}:#{session_path}")
-	output, err := cmd.Output()
+	return m.Reconcile()
+}
+
+// Reconcile matches the manager's sessions against tmux list-sessions by
+// name. Sessions still running that this daemon has not seen yet, such as
+// those spawned before a restart, are adopted; those whose tmux session is
+// gone are marked dead and kept, with their history, until disposed. When
+// several records share a name only the newest can own the tmux session;
+// the older ones are superseded. tmux sessions the daemon never spawned are
+// left alone. The server runs it at startup and then periodically.
+func (m *SessionManager) Reconcile() error {
+	if m.tmux == nil {
+		return errors.New("no tmux client to reconcile against")
+	}
+	sessions, err := m.tmux.ListSessions()
 	if err != nil {
-		return nil // No sessions or tmux not running
+		return err
+	}
+	live := make(map[string]tmux.Session, len(sessions))
+	for _, ts := range sessions {
+		live[ts.Name] = ts
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	now := time.Now()
+	newest := make(map[string]*Session)
+	for _, s := range m.sessions {
+		if s.Status == SessionSuperseded {
+			continue
+		}
+		cur := newest[s.Name]
+		if cur == nil || s.CreatedAt.After(cur.CreatedAt) || (s.CreatedAt.Equal(cur.CreatedAt) && s.ID > cur.ID) {
+			newest[s.Name] = s
+		}
+	}
+	for id, s := range m.sessions {
+		if s.Status == SessionSuperseded {
+			continue
+		}
+		if n := newest[s.Name]; n != s {
+			m.supersede(s, n.ID, now)
+			continue
+		}
+		_, alive := live[s.Name]
+		switch {
+		case alive && !m.live[id]:
+			detail := ""
+			if s.Status != SessionRunning {
+				detail = "was " + string(s.Status)
+			}
+			s.Status = SessionRunning
+			s.AdoptedAt = now
+			s.EndedAt = time.Time{}
+			m.live[id] = true
+			m.record(s, EventAdopted, detail, now)
+		case !alive && s.Status != SessionDead:
+			s.Status = SessionDead
+			s.EndedAt = now
+			delete(m.live, id)
+			m.record(s, EventDied, "tmux session not found", now)
+		}
+	}
+	return nil
+}
 
-	// Parse output and add sessions
-	// Format: name:path
-	_ = output // TODO: Parse and add existing sessions
+// supersede retires s in favour of the session by, which has its name.
+// The caller holds m.mu.
+func (m *SessionManager) supersede(s *Session, by string, now time.Time) {
+	s.Status = SessionSuperseded
+	if s.EndedAt.IsZero() {
+		s.EndedAt = now
+	}
+	delete(m.live, s.ID)
+	m.record(s, EventSuperseded, "by "+by, now)
+}
 
-	return nil
+// History returns a session's lifecycle events, newest first, or those of
+// every session when id is empty. Disposed sessions keep their history.
+func (m *SessionManager) History(id string, limit int) ([]SessionEvent, error) {
+	if m.store == nil {
+		return nil, errors.New("session history is not recorded without a state database")
+	}
+	return m.store.History(id, limit)
 }
 
 // UpdateGitStatuses refreshes git status for all sessions.
@@ -183,6 +327,7 @@ func (m *SessionManager) UpdateGitStatuses() {
 
 	for _, s := range m.sessions {
 		UpdateSessionGitStatus(s)
+		m.record(s, "", "", time.Time{})
 	}
 }
 
@@ -0,0 +1,193 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/tmux"
+)
+
+type fakeLister struct {
+	names []string
+}
+
+func (f *fakeLister) ListSessions() ([]tmux.Session, error) {
+	out := make([]tmux.Session, 0, len(f.names))
+	for _, n := range f.names {
+		out = append(out, tmux.Session{Name: n})
+	}
+	return out, nil
+}
+
+func openStore(t *testing.T, path string) *SessionStore {
+	t.Helper()
+	store, err := OpenSessionStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func kinds(events []SessionEvent) []string {
+	out := make([]string, len(events))
+	for i, ev := range events {
+		out[i] = ev.Kind
+	}
+	return out
+}
+
+func TestReconcileAdoptsLiveSessionsAndMarksDeadOnes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.db")
+	spawned := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// What a previous daemon left behind.
+	prev := openStore(t, path)
+	for _, s := range []*Session{
+		{ID: "a1", Name: "claude-api", ProjectPath: "/p/api", AgentType: "claude", Status: SessionRunning, CreatedAt: spawned, RestartCount: 2},
+		{ID: "b2", Name: "codex-web", ProjectPath: "/p/web", AgentType: "codex", Status: SessionRunning, CreatedAt: spawned},
+		{ID: "c3", Name: "old", ProjectPath: "/p/old", Status: SessionDisposed, CreatedAt: spawned},
+	} {
+		if err := prev.Save(s); err != nil {
+			t.Fatal(err)
+		}
+		if err := prev.Record(s, EventSpawned, s.ProjectPath, spawned); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tmuxSessions := &fakeLister{names: []string{"claude-api", "unrelated"}}
+	m, err := NewPersistentSessionManager(openStore(t, path), tmuxSessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Count() != 2 {
+		t.Fatalf("expected the disposed session to stay out, got %d sessions", m.Count())
+	}
+	if err := m.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	api, _ := m.Get("a1")
+	web, _ := m.Get("b2")
+	if api.Status != SessionRunning || api.AdoptedAt.IsZero() || api.RestartCount != 2 || !api.CreatedAt.Equal(spawned) {
+		t.Fatalf("expected claude-api adopted with its history, got %+v", api)
+	}
+	if web.Status != SessionDead || web.EndedAt.IsZero() {
+		t.Fatalf("expected codex-web dead, got %+v", web)
+	}
+
+	// A second pass changes nothing.
+	if err := m.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	all, err := m.History("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("expected 3 spawns, 1 adoption and 1 death, got %v", kinds(all))
+	}
+	apiEvents, err := m.History("a1", 0)
+	if err != nil || len(apiEvents) != 2 || apiEvents[0].Kind != EventAdopted {
+		t.Fatalf("unexpected claude-api history: %v, %v", kinds(apiEvents), err)
+	}
+
+	// The session comes back, and the next daemon sees the dead one revived.
+	tmuxSessions.names = append(tmuxSessions.names, "codex-web")
+	next, err := NewPersistentSessionManager(openStore(t, path), tmuxSessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := next.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	web, _ = next.Get("b2")
+	if web.Status != SessionRunning || !web.EndedAt.IsZero() {
+		t.Fatalf("expected codex-web adopted again, got %+v", web)
+	}
+	webEvents, _ := next.History("b2", 1)
+	if len(webEvents) != 1 || webEvents[0].Kind != EventAdopted || webEvents[0].Detail != "was dead" {
+		t.Fatalf("unexpected codex-web history: %+v", webEvents)
+	}
+}
+
+func TestReconcileAdoptsOnlyTheNewestSessionOfAName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.db")
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	prev := openStore(t, path)
+	for _, s := range []*Session{
+		{ID: "old", Name: "claude-api", ProjectPath: "/p/api", Status: SessionDead, CreatedAt: first, EndedAt: first.Add(time.Hour)},
+		{ID: "new", Name: "claude-api", ProjectPath: "/p/api", Status: SessionRunning, CreatedAt: first.Add(2 * time.Hour)},
+	} {
+		if err := prev.Save(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := NewPersistentSessionManager(openStore(t, path), &fakeLister{names: []string{"claude-api"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := m.Reconcile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old, _ := m.Get("old")
+	cur, _ := m.Get("new")
+	if old.Status != SessionSuperseded || !old.EndedAt.Equal(first.Add(time.Hour)) {
+		t.Fatalf("expected the older record superseded, got %+v", old)
+	}
+	if cur.Status != SessionRunning || cur.AdoptedAt.IsZero() {
+		t.Fatalf("expected the newer record adopted, got %+v", cur)
+	}
+	events, err := m.History("old", 0)
+	if err != nil || len(events) != 1 || events[0].Kind != EventSuperseded || events[0].Detail != "by new" {
+		t.Fatalf("unexpected history for the older record: %+v, %v", events, err)
+	}
+	if _, err := m.Restart("old"); err == nil {
+		t.Fatal("expected a superseded session to refuse restarts")
+	}
+}
+
+func TestHistoryEndpoint(t *testing.T) {
+	store := openStore(t, filepath.Join(t.TempDir(), "daemon.db"))
+	s := &Session{ID: "a1", Name: "claude-api", ProjectPath: "/p/api", Status: SessionRunning, CreatedAt: time.Now()}
+	if err := store.Save(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Record(s, EventSpawned, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewPersistentSessionManager(store, &fakeLister{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{mux: http.NewServeMux(), sessions: m}
+	srv.setupRoutes()
+
+	rec := httptest.NewRecorder()
+	srv.mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/sessions/a1/history", nil))
+	var events []SessionEvent
+	if err := json.Unmarshal(rec.Body.Bytes(), &events); rec.Code != http.StatusOK || err != nil || len(events) != 1 {
+		t.Fatalf("unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/sessions/missing/history", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	mem := &Server{mux: http.NewServeMux(), sessions: NewSessionManager()}
+	mem.setupRoutes()
+	rec = httptest.NewRecorder()
+	mem.mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/history", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without a registry, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"time"
+
+	autarchdb "github.com/mistakeknot/autarch/pkg/db"
+)
+
+// Session lifecycle events recorded in the store.
+const (
+	EventSpawned    = "spawned"
+	EventRestarted  = "restarted"
+	EventAdopted    = "adopted"    // a live tmux session taken over after a daemon restart
+	EventDied       = "died"       // the tmux session was gone when the daemon looked
+	EventSuperseded = "superseded" // a newer session took the name
+	EventDisposed   = "disposed"
+)
+
+// SessionEvent is one entry in a session's lifecycle history.
+type SessionEvent struct {
+	ID          int64     `json:"id"`
+	SessionID   string    `json:"session_id"`
+	SessionName string    `json:"session_name"`
+	Kind        string    `json:"kind"`
+	Detail      string    `json:"detail,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// SessionStore persists sessions and their lifecycle history in SQLite so
+// they survive daemon restarts.
+type SessionStore struct {
+	db *sql.DB
+}
+
+// OpenSessionStore opens, creating if needed, the store at path.
+func OpenSessionStore(path string) (*SessionStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := autarchdb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS sessions (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  project_path TEXT NOT NULL,
+  agent_type TEXT,
+  status TEXT NOT NULL,
+  created_ts TEXT NOT NULL,
+  restart_count INTEGER NOT NULL DEFAULT 0,
+  restarted_ts TEXT,
+  adopted_ts TEXT,
+  ended_ts TEXT,
+  git_branch TEXT,
+  git_dirty INTEGER NOT NULL DEFAULT 0,
+  commits_ahead INTEGER NOT NULL DEFAULT 0,
+  commits_behind INTEGER NOT NULL DEFAULT 0,
+  updated_ts TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS session_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  session_id TEXT NOT NULL,
+  session_name TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  detail TEXT,
+  created_ts TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_status ON sessions(status);
+CREATE INDEX IF NOT EXISTS idx_session_events_session ON session_events(session_id);
+`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SessionStore{db: db}, nil
+}
+
+// Close closes the store.
+func (s *SessionStore) Close() error {
+	return s.db.Close()
+}
+
+func formatTS(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTS(v sql.NullString) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, v.String)
+	return t
+}
+
+// Save writes the session's current state.
+func (s *SessionStore) Save(sess *Session) error {
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (id, name, project_path, agent_type, status, created_ts, restart_count, restarted_ts, adopted_ts, ended_ts,
+		  git_branch, git_dirty, commits_ahead, commits_behind, updated_ts)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+		  name = excluded.name,
+		  project_path = excluded.project_path,
+		  agent_type = excluded.agent_type,
+		  status = excluded.status,
+		  restart_count = excluded.restart_count,
+		  restarted_ts = excluded.restarted_ts,
+		  adopted_ts = excluded.adopted_ts,
+		  ended_ts = excluded.ended_ts,
+		  git_branch = excluded.git_branch,
+		  git_dirty = excluded.git_dirty,
+		  commits_ahead = excluded.commits_ahead,
+		  commits_behind = excluded.commits_behind,
+		  updated_ts = excluded.updated_ts`,
+		sess.ID, sess.Name, sess.ProjectPath, sess.AgentType, string(sess.Status), formatTS(sess.CreatedAt),
+		sess.RestartCount, formatTS(sess.RestartedAt), formatTS(sess.AdoptedAt), formatTS(sess.EndedAt),
+		sess.GitBranch, sess.GitDirty, sess.CommitsAhead, sess.CommitsBehind, formatTS(time.Now()))
+	return err
+}
+
+// Load returns every session that has not been disposed.
+func (s *SessionStore) Load() ([]*Session, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, project_path, agent_type, status, created_ts, restart_count, restarted_ts, adopted_ts, ended_ts,
+		  git_branch, git_dirty, commits_ahead, commits_behind
+		FROM sessions WHERE status != ? ORDER BY created_ts ASC`, string(SessionDisposed))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Session
+	for rows.Next() {
+		var sess Session
+		var agentType, branch, created, restarted, adopted, ended sql.NullString
+		var status string
+		if err := rows.Scan(&sess.ID, &sess.Name, &sess.ProjectPath, &agentType, &status, &created, &sess.RestartCount,
+			&restarted, &adopted, &ended, &branch, &sess.GitDirty, &sess.CommitsAhead, &sess.CommitsBehind); err != nil {
+			return nil, err
+		}
+		sess.AgentType, sess.GitBranch, sess.Status = agentType.String, branch.String, SessionStatus(status)
+		sess.CreatedAt, sess.RestartedAt = parseTS(created), parseTS(restarted)
+		sess.AdoptedAt, sess.EndedAt = parseTS(adopted), parseTS(ended)
+		out = append(out, &sess)
+	}
+	return out, rows.Err()
+}
+
+// Record appends an event to a session's history.
+func (s *SessionStore) Record(sess *Session, kind, detail string, at time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO session_events (session_id, session_name, kind, detail, created_ts) VALUES (?, ?, ?, ?, ?)`,
+		sess.ID, sess.Name, kind, detail, formatTS(at))
+	return err
+}
+
+// History returns lifecycle events, newest first: those of one session, or
+// of all sessions when sessionID is empty. A limit of zero or less returns
+// them all.
+func (s *SessionStore) History(sessionID string, limit int) ([]SessionEvent, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(`
+		SELECT id, session_id, session_name, kind, detail, created_ts FROM session_events
+		WHERE ? = '' OR session_id = ?
+		ORDER BY id DESC LIMIT ?`, sessionID, sessionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SessionEvent
+	for rows.Next() {
+		var ev SessionEvent
+		var detail, at sql.NullString
+		if err := rows.Scan(&ev.ID, &ev.SessionID, &ev.SessionName, &ev.Kind, &detail, &at); err != nil {
+			return nil, err
+		}
+		ev.Detail, ev.At = detail.String, parseTS(at)
+		out = append(out, ev)
+	}
+	return out, rows.Err()
+}
@@ -21,6 +21,7 @@ type Project struct {
 	TaskStats      *TaskStats    `json:"task_stats,omitempty"`
 	PollardStats   *PollardStats `json:"pollard_stats,omitempty"`
 	GurgStats      *GurgStats    `json:"gurg_stats,omitempty"`
+	Host           string        `json:"host,omitempty"` // federated peer; "" for this host
 }
 
 // PollardStats holds research data statistics
@@ -28,6 +29,7 @@ type PollardStats struct {
 	Sources    int `json:"sources"`
 	Insights   int `json:"insights"`
 	Reports    int `json:"reports"`
+	Scans      int `json:"scans"`
 	LastReport string `json:"last_report,omitempty"`
 }
 
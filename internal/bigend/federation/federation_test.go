@@ -0,0 +1,172 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/daemon"
+	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+)
+
+type peerState struct {
+	state    aggregator.State
+	restarts []string
+	disposed []string
+}
+
+func (p *peerState) GetState() aggregator.State { return p.state }
+func (p *peerState) RestartSession(name, projectPath, agentType string) error {
+	p.restarts = append(p.restarts, name+" "+projectPath+" "+agentType)
+	return nil
+}
+func (p *peerState) DisposeSession(name string) error {
+	p.disposed = append(p.disposed, name)
+	return nil
+}
+
+// servePeer serves a daemon on a unix socket, as an ssh forward would.
+func servePeer(t *testing.T, state daemon.StateSource) string {
+	t.Helper()
+	srv, err := daemon.NewServer(daemon.Config{State: state})
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(t.TempDir(), "peer.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	hs := httptest.NewUnstartedServer(srv.Handler())
+	hs.Listener = ln
+	hs.Start()
+	t.Cleanup(hs.Close)
+	return socket
+}
+
+func TestFleetMergesPeersAndRoutesActions(t *testing.T) {
+	devbox := &peerState{state: aggregator.State{
+		Projects:   []discovery.Project{{Path: "/home/me/api", Name: "api"}},
+		Sessions:   []aggregator.TmuxSession{{Name: "claude-api", AgentType: "claude", ProjectPath: "/home/me/api", State: "working"}},
+		Activities: []aggregator.Activity{{ProjectPath: "/home/me/api", Summary: "commit"}},
+	}}
+	socket := servePeer(t, devbox)
+
+	// A daemon without an aggregated view does not serve federation.
+	legacy, err := daemon.NewServer(daemon.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := httptest.NewServer(legacy.Handler())
+	defer old.Close()
+
+	local := aggregator.New(discovery.NewScanner(config.DiscoveryConfig{}), &config.Config{})
+	fleet, err := New(local, config.FederationConfig{Peers: []config.PeerConfig{
+		{Name: "devbox", Socket: socket},
+		{Name: "old", URL: old.URL},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fleet.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	state := fleet.GetState()
+	if len(state.Hosts) != 2 || !state.Hosts[0].Reachable || state.Hosts[1].Reachable ||
+		!strings.Contains(state.Hosts[1].Error, ErrNoFederation.Error()) {
+		t.Fatalf("unexpected hosts %+v", state.Hosts)
+	}
+	var session *aggregator.TmuxSession
+	for i := range state.Sessions {
+		if state.Sessions[i].Host == "devbox" {
+			session = &state.Sessions[i]
+		}
+	}
+	if session == nil || session.Name != "devbox:claude-api" || session.ProjectPath != "devbox:/home/me/api" || session.State != "working" {
+		t.Fatalf("expected the remote session qualified by host, got %+v", state.Sessions)
+	}
+	if p := fleet.GetProject("devbox:/home/me/api"); p == nil || p.Host != "devbox" || p.Name != "api" {
+		t.Fatalf("expected the remote project, got %+v", p)
+	}
+	if a := state.Activities[len(state.Activities)-1]; a.Host != "devbox" || a.ProjectPath != "devbox:/home/me/api" {
+		t.Fatalf("unexpected activity %+v", a)
+	}
+
+	if err := fleet.RestartSession(session.Name, session.ProjectPath, session.AgentType); err != nil {
+		t.Fatal(err)
+	}
+	if err := fleet.DisposeSession(session.Name); err != nil {
+		t.Fatal(err)
+	}
+	if len(devbox.restarts) != 1 || devbox.restarts[0] != "claude-api /home/me/api claude" || devbox.disposed[0] != "claude-api" {
+		t.Fatalf("actions did not reach the owning host: %v %v", devbox.restarts, devbox.disposed)
+	}
+	if err := fleet.DisposeSession("devbox:gone"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected the peer's error, got %v", err)
+	}
+	if err := fleet.RestartSession("nowhere:x", "", "claude"); err == nil {
+		t.Fatal("expected an unknown host to fail")
+	}
+	if err := fleet.RenameSession(session.Name, "other"); !errors.Is(err, ErrRemoteUnsupported) {
+		t.Fatalf("expected rename to stay local, got %v", err)
+	}
+	if err := fleet.AttachSession(session.Name); err == nil || !strings.Contains(err.Error(), "needs ssh") {
+		t.Fatalf("expected attach to need ssh, got %v", err)
+	}
+}
+
+func TestNewPeerValidation(t *testing.T) {
+	for _, tc := range []struct {
+		cfg  config.PeerConfig
+		want string
+	}{
+		{config.PeerConfig{Name: "a:b", URL: "http://127.0.0.1:1"}, "must be non-empty"},
+		{config.PeerConfig{Name: "box", URL: "http://10.0.0.5:8100"}, "non-loopback"},
+		{config.PeerConfig{Name: "box"}, "needs url, socket or ssh"},
+		{config.PeerConfig{Name: "box", URL: "http://127.0.0.1:1", Socket: "/tmp/x.sock"}, "not both"},
+	} {
+		if _, err := NewPeer(tc.cfg); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: expected %q, got %v", tc.cfg, tc.want, err)
+		}
+	}
+
+	p, err := NewPeer(config.PeerConfig{Name: "gpu", SSH: "me@gpu", Socket: "/tmp/gpu.sock"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(p.TunnelArgs(), " "); !strings.HasSuffix(got, "-L /tmp/gpu.sock:127.0.0.1:8100 me@gpu") {
+		t.Fatalf("unexpected tunnel args %q", got)
+	}
+	if got := strings.Join(p.AttachCommand("claude-api"), " "); got != "ssh -t me@gpu tmux attach-session -t claude-api" {
+		t.Fatalf("unexpected attach command %q", got)
+	}
+	for _, name := range []string{"x; rm -rf ~", "$(id)", "a b", "-x", "`id`", ""} {
+		if cmd := p.AttachCommand(name); cmd != nil {
+			t.Fatalf("expected no attach command for %q, got %q", name, cmd)
+		}
+	}
+
+	if _, err := New(nil, config.FederationConfig{Peers: []config.PeerConfig{
+		{Name: "x", Socket: "/tmp/1.sock"}, {Name: "x", Socket: "/tmp/2.sock"},
+	}}); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("expected duplicate names to be rejected, got %v", err)
+	}
+}
+
+func TestSplitAndQualify(t *testing.T) {
+	for _, id := range []string{"claude-api", "/home/me/api", ""} {
+		if host, local := Split(id); host != "" || local != id {
+			t.Errorf("Split(%q) = %q, %q", id, host, local)
+		}
+	}
+	if host, local := Split(Qualify("devbox", "/home/me/api")); host != "devbox" || local != "/home/me/api" {
+		t.Errorf("round trip gave %q, %q", host, local)
+	}
+}
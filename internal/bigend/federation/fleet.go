@@ -0,0 +1,284 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+	"github.com/mistakeknot/autarch/internal/bigend/coldwine"
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+)
+
+// DefaultTimeout bounds each request to a peer.
+const DefaultTimeout = 5 * time.Second
+
+// ErrRemoteUnsupported is returned for actions that only work on this host.
+var ErrRemoteUnsupported = errors.New("not supported for sessions on other hosts")
+
+// Qualify labels a session name or project path with its host. Local ids
+// are returned unchanged.
+func Qualify(host, id string) string {
+	if host == "" || id == "" {
+		return id
+	}
+	return host + ":" + id
+}
+
+// Split separates a qualified id into its host and the id on that host.
+// Local paths start with "/" and tmux session names cannot contain ":",
+// so local ids never split.
+func Split(id string) (host, local string) {
+	if strings.HasPrefix(id, "/") {
+		return "", id
+	}
+	if i := strings.IndexByte(id, ':'); i > 0 {
+		return id[:i], id[i+1:]
+	}
+	return "", id
+}
+
+// remote is the last view fetched from a peer.
+type remote struct {
+	state aggregator.State
+	err   error
+	at    time.Time
+}
+
+// Fleet is the local aggregator with the views of its peers merged in. It
+// stands in for the aggregator in the web server and TUI.
+type Fleet struct {
+	*aggregator.Aggregator
+	peers   []*Peer
+	timeout time.Duration
+
+	mu      sync.RWMutex
+	remotes map[string]remote
+}
+
+// New wraps the local aggregator with the configured peers.
+func New(local *aggregator.Aggregator, cfg config.FederationConfig) (*Fleet, error) {
+	f := &Fleet{Aggregator: local, timeout: cfg.Timeout, remotes: map[string]remote{}}
+	if f.timeout <= 0 {
+		f.timeout = DefaultTimeout
+	}
+	var errs []error
+	seen := map[string]bool{}
+	for _, pc := range cfg.Peers {
+		p, err := NewPeer(pc)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if seen[p.Name] {
+			errs = append(errs, fmt.Errorf("peer %s: duplicate name", p.Name))
+			continue
+		}
+		seen[p.Name] = true
+		f.peers = append(f.peers, p)
+	}
+	return f, errors.Join(errs...)
+}
+
+// Peers returns the configured peers.
+func (f *Fleet) Peers() []*Peer {
+	return f.peers
+}
+
+// Connect starts the ssh tunnels of peers that have one. They stay up until
+// ctx is done.
+func (f *Fleet) Connect(ctx context.Context) {
+	for _, p := range f.peers {
+		go p.RunTunnel(ctx)
+	}
+}
+
+// Refresh rescans this host and fetches every peer's view. An unreachable
+// peer is reported in the state's hosts, not as an error.
+func (f *Fleet) Refresh(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, p := range f.peers {
+		wg.Add(1)
+		go func(p *Peer) {
+			defer wg.Done()
+			pctx, cancel := context.WithTimeout(ctx, f.timeout)
+			defer cancel()
+			state, err := p.State(pctx)
+			f.mu.Lock()
+			f.remotes[p.Name] = remote{state: state, err: err, at: time.Now()}
+			f.mu.Unlock()
+		}(p)
+	}
+	err := f.Aggregator.Refresh(ctx)
+	wg.Wait()
+	return err
+}
+
+// GetState returns this host's view followed by each reachable peer's,
+// with remote names and paths qualified by host.
+func (f *Fleet) GetState() aggregator.State {
+	state := f.Aggregator.GetState()
+	if len(f.peers) == 0 {
+		return state
+	}
+	state.Projects = append([]discovery.Project(nil), state.Projects...)
+	state.Sessions = append([]aggregator.TmuxSession(nil), state.Sessions...)
+	state.Activities = append([]aggregator.Activity(nil), state.Activities...)
+	state.Hosts = nil
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, p := range f.peers {
+		r, ok := f.remotes[p.Name]
+		status := aggregator.HostStatus{Name: p.Name, Reachable: ok && r.err == nil, UpdatedAt: r.at}
+		if r.err != nil {
+			status.Error = r.err.Error()
+		}
+		state.Hosts = append(state.Hosts, status)
+		if !status.Reachable {
+			continue
+		}
+		for _, proj := range r.state.Projects {
+			proj.Path = Qualify(p.Name, proj.Path)
+			proj.Host = p.Name
+			state.Projects = append(state.Projects, proj)
+		}
+		for _, s := range r.state.Sessions {
+			if cmd := p.AttachCommand(s.Name); cmd != nil {
+				s.AttachCommand = strings.Join(cmd, " ")
+			}
+			s.Name = Qualify(p.Name, s.Name)
+			s.ProjectPath = Qualify(p.Name, s.ProjectPath)
+			s.Host = p.Name
+			state.Sessions = append(state.Sessions, s)
+		}
+		for _, a := range r.state.Activities {
+			a.ProjectPath = Qualify(p.Name, a.ProjectPath)
+			a.Host = p.Name
+			state.Activities = append(state.Activities, a)
+		}
+	}
+	return state
+}
+
+// GetProject finds a project, local or remote.
+func (f *Fleet) GetProject(path string) *discovery.Project {
+	if host, _ := Split(path); host == "" {
+		return f.Aggregator.GetProject(path)
+	}
+	for _, p := range f.GetState().Projects {
+		if p.Path == path {
+			return &p
+		}
+	}
+	return nil
+}
+
+// peer resolves the host of a qualified session name.
+func (f *Fleet) peer(name string) (*Peer, string, error) {
+	host, local := Split(name)
+	if host == "" {
+		return nil, name, nil
+	}
+	for _, p := range f.peers {
+		if p.Name == host {
+			return p, local, nil
+		}
+	}
+	return nil, "", fmt.Errorf("unknown host %q", host)
+}
+
+// RestartSession restarts an agent session on the host that owns it.
+func (f *Fleet) RestartSession(name, projectPath, agentType string) error {
+	p, local, err := f.peer(name)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return f.Aggregator.RestartSession(name, projectPath, agentType)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	return p.Restart(ctx, local)
+}
+
+// DisposeSession kills a session on the host that owns it.
+func (f *Fleet) DisposeSession(name string) error {
+	p, local, err := f.peer(name)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return f.Aggregator.DisposeSession(name)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	return p.Dispose(ctx, local)
+}
+
+// AttachSession attaches this terminal to a session, over ssh when it runs
+// on a peer.
+func (f *Fleet) AttachSession(name string) error {
+	p, local, err := f.peer(name)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return f.Aggregator.AttachSession(name)
+	}
+	if p.SSH == "" {
+		return fmt.Errorf("attaching to %s needs ssh in the %s peer config", name, p.Name)
+	}
+	args := p.AttachCommand(local)
+	if args == nil {
+		return fmt.Errorf("cannot attach to %s over ssh: unsafe session name", name)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// RenameSession renames a session on this host.
+func (f *Fleet) RenameSession(oldName, newName string) error {
+	if err := f.localOnly(oldName); err != nil {
+		return err
+	}
+	return f.Aggregator.RenameSession(oldName, newName)
+}
+
+// ForkSession starts a session in a project on this host.
+func (f *Fleet) ForkSession(name, projectPath, agentType string) error {
+	if err := f.localOnly(projectPath); err != nil {
+		return err
+	}
+	return f.Aggregator.ForkSession(name, projectPath, agentType)
+}
+
+// NewSession starts a session in a project on this host.
+func (f *Fleet) NewSession(name, projectPath, agentType string) error {
+	if err := f.localOnly(projectPath); err != nil {
+		return err
+	}
+	return f.Aggregator.NewSession(name, projectPath, agentType)
+}
+
+// GetProjectTasks loads the tasks of a project on this host.
+func (f *Fleet) GetProjectTasks(projectPath string) (map[string][]coldwine.Task, error) {
+	if err := f.localOnly(projectPath); err != nil {
+		return nil, err
+	}
+	return f.Aggregator.GetProjectTasks(projectPath)
+}
+
+func (f *Fleet) localOnly(id string) error {
+	if host, _ := Split(id); host != "" {
+		return fmt.Errorf("%s: %w", id, ErrRemoteUnsupported)
+	}
+	return nil
+}
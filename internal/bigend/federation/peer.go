@@ -0,0 +1,166 @@
+// Package federation merges the views of peer Bigend daemons on other
+// hosts into the local one. Peers are reached over loopback URLs or unix
+// sockets forwarded by SSH, so no daemon ever listens beyond loopback.
+// Remote projects and sessions are labelled with their host, and actions
+// on them are sent to the daemon that owns them.
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/pkg/netguard"
+)
+
+// DefaultRemote is the peer daemon address tunnels forward to.
+const DefaultRemote = "127.0.0.1:8100"
+
+// ErrNoFederation is returned for daemons that do not serve the federation
+// API; they run without an aggregated view to share.
+var ErrNoFederation = errors.New("daemon does not serve the federation API")
+
+// Peer is a remote Bigend daemon.
+type Peer struct {
+	Name   string
+	SSH    string
+	Socket string
+	Remote string
+	base   string
+	client *http.Client
+}
+
+// NewPeer validates a peer config.
+func NewPeer(cfg config.PeerConfig) (*Peer, error) {
+	if cfg.Name == "" || strings.ContainsAny(cfg.Name, ":/ ") {
+		return nil, fmt.Errorf("peer name %q must be non-empty without ':', '/' or spaces", cfg.Name)
+	}
+	p := &Peer{Name: cfg.Name, SSH: cfg.SSH, Socket: cfg.Socket, Remote: cfg.Remote}
+	if p.SSH != "" {
+		if cfg.URL != "" {
+			return nil, fmt.Errorf("peer %s: ssh tunnels use a socket, not url", p.Name)
+		}
+		if p.Socket == "" {
+			home, _ := os.UserHomeDir()
+			p.Socket = filepath.Join(home, ".config", "bigend", "peers", p.Name+".sock")
+		}
+		if p.Remote == "" {
+			p.Remote = DefaultRemote
+		}
+	}
+
+	switch {
+	case p.Socket != "" && cfg.URL != "":
+		return nil, fmt.Errorf("peer %s: set url or socket, not both", p.Name)
+	case p.Socket != "":
+		socket := p.Socket
+		p.base = "http://" + p.Name
+		p.client = &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}}
+	case cfg.URL != "":
+		u, err := url.Parse(cfg.URL)
+		if err != nil || u.Scheme != "http" || u.Host == "" {
+			return nil, fmt.Errorf("peer %s: url must be http://host:port", p.Name)
+		}
+		if err := netguard.EnsureLocalOnly(u.Host); err != nil {
+			return nil, fmt.Errorf("peer %s: %w; forward the peer's port over ssh", p.Name, err)
+		}
+		p.base = strings.TrimSuffix(u.String(), "/")
+		p.client = &http.Client{}
+	default:
+		return nil, fmt.Errorf("peer %s: needs url, socket or ssh", p.Name)
+	}
+	return p, nil
+}
+
+// State fetches the peer's aggregated view.
+func (p *Peer) State(ctx context.Context) (aggregator.State, error) {
+	var state aggregator.State
+	resp, err := p.do(ctx, http.MethodGet, "/api/federation/state")
+	if err != nil {
+		return state, err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return state, fmt.Errorf("%s: decode state: %w", p.Name, err)
+	}
+	return state, nil
+}
+
+// Restart restarts an agent session on the peer.
+func (p *Peer) Restart(ctx context.Context, session string) error {
+	return p.action(ctx, session, "restart")
+}
+
+// Dispose kills a session on the peer.
+func (p *Peer) Dispose(ctx context.Context, session string) error {
+	return p.action(ctx, session, "dispose")
+}
+
+func (p *Peer) action(ctx context.Context, session, action string) error {
+	resp, err := p.do(ctx, http.MethodPost, "/api/federation/sessions/"+url.PathEscape(session)+"/"+action)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// attachableSession matches the session names AttachCommand will pass to
+// ssh. ssh joins its arguments into a command line for the remote shell,
+// and the joined command is also shown for pasting into a local one, so
+// names from the peer are limited to characters neither shell
+// interprets.
+var attachableSession = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.@+=,-]*$`)
+
+// AttachCommand is the command that attaches this terminal to a session on
+// the peer, or nil when the peer has no SSH destination or the session
+// name is not safe to hand to a shell.
+func (p *Peer) AttachCommand(session string) []string {
+	if p.SSH == "" || !attachableSession.MatchString(session) {
+		return nil
+	}
+	return []string{"ssh", "-t", p.SSH, "tmux", "attach-session", "-t", session}
+}
+
+func (p *Peer) do(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.Name, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &body) != nil || body.Error == "" {
+			if resp.StatusCode == http.StatusNotFound {
+				// The route is missing, not the session.
+				return nil, fmt.Errorf("%s: %w", p.Name, ErrNoFederation)
+			}
+			body.Error = strings.TrimSpace(string(data))
+		}
+		return nil, fmt.Errorf("%s: %s", p.Name, body.Error)
+	}
+	return resp, nil
+}
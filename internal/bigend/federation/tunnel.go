@@ -0,0 +1,63 @@
+package federation
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Tunnel backoff bounds. A tunnel that stayed up for tunnelHealthy starts
+// over from the shortest wait.
+const (
+	tunnelMinBackoff = time.Second
+	tunnelMaxBackoff = 30 * time.Second
+	tunnelHealthy    = time.Minute
+)
+
+// TunnelArgs are the ssh arguments that forward the peer's socket to its
+// daemon. The forward binds a unix socket, so nothing listens on the
+// network on either host.
+func (p *Peer) TunnelArgs() []string {
+	return []string{
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ServerAliveInterval=15",
+		"-o", "StreamLocalBindUnlink=yes",
+		"-L", p.Socket + ":" + p.Remote,
+		p.SSH,
+	}
+}
+
+// RunTunnel keeps the peer's ssh tunnel up until ctx is done. Peers without
+// an SSH destination return at once.
+func (p *Peer) RunTunnel(ctx context.Context) {
+	if p.SSH == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p.Socket), 0o700); err != nil {
+		slog.Error("federation tunnel disabled", "peer", p.Name, "error", err)
+		return
+	}
+	backoff := tunnelMinBackoff
+	for {
+		started := time.Now()
+		cmd := exec.CommandContext(ctx, "ssh", p.TunnelArgs()...)
+		err := cmd.Run()
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > tunnelHealthy {
+			backoff = tunnelMinBackoff
+		}
+		slog.Warn("federation tunnel down", "peer", p.Name, "ssh", p.SSH, "error", err, "retry", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, tunnelMaxBackoff)
+	}
+}
@@ -0,0 +1,156 @@
+package mcp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogPath is where a component's log is written under dir: one directory
+// per project, named after the project path. It is "" when component is
+// not a valid name.
+func LogPath(dir, project, component string) string {
+	if !ValidComponent(component) {
+		return ""
+	}
+	name := strings.Trim(strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(filepath.Clean(project)), "_")
+	if name == "" {
+		name = "root"
+	}
+	return filepath.Join(dir, name, component+".log")
+}
+
+// ValidComponent reports whether component can name a log file: not
+// empty, with no path separators and no "..".
+func ValidComponent(component string) bool {
+	return component != "" && !strings.ContainsAny(component, `/\`) && !strings.Contains(component, "..")
+}
+
+// rotatingLog appends timestamped lines to a file, moving it to .1, .2, …
+// once it grows past maxBytes and keeping at most backups old files.
+type rotatingLog struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func openRotatingLog(path string, maxBytes int64, backups int) (*rotatingLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	l := &rotatingLog{path: path, maxBytes: maxBytes, backups: backups}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *rotatingLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+// Line writes one line of a stream ("out", "err" or "bigend").
+func (l *rotatingLog) Line(at time.Time, stream, text string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return os.ErrClosed
+	}
+	line := fmt.Sprintf("%s %s %s\n", at.UTC().Format(time.RFC3339), stream, text)
+	if l.maxBytes > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.f.WriteString(line)
+	l.size += int64(n)
+	return err
+}
+
+func (l *rotatingLog) rotate() error {
+	l.f.Close()
+	l.f = nil
+	if l.backups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", l.path, l.backups))
+		for i := l.backups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		}
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Truncate(l.path, 0); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Close closes the file.
+func (l *rotatingLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// tailFile returns the last n lines of a log written by rotatingLog, with
+// the timestamp and stream stripped, reading the newest rotated file too
+// when the current one is short.
+func tailFile(path string, n int) []string {
+	lines := readLogLines(path)
+	if len(lines) < n {
+		lines = append(readLogLines(path+".1"), lines...)
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+func readLogLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// "<time> <stream> <text>"
+		if _, rest, ok := strings.Cut(line, " "); ok {
+			if stream, text, ok := strings.Cut(rest, " "); ok {
+				if stream == "bigend" {
+					text = "[bigend] " + text
+				}
+				line = text
+			} else {
+				line = ""
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -3,9 +3,12 @@ package mcp
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os/exec"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -14,9 +17,19 @@ import (
 type Status string
 
 const (
-	StatusRunning Status = "running"
-	StatusStopped Status = "stopped"
-	StatusError   Status = "error"
+	StatusRunning    Status = "running"
+	StatusStopped    Status = "stopped"
+	StatusError      Status = "error"
+	StatusRestarting Status = "restarting" // crashed; waiting out the backoff
+)
+
+// Health is the outcome of a component's liveness probes.
+type Health string
+
+const (
+	HealthUnknown   Health = ""          // not probed
+	HealthHealthy   Health = "healthy"   // answered the last probe
+	HealthUnhealthy Health = "unhealthy" // failed the last probe
 )
 
 // ComponentStatus tracks MCP component state.
@@ -28,6 +41,19 @@ type ComponentStatus struct {
 	StartedAt   time.Time
 	LastError   string
 	LogTail     []string
+
+	Health       Health
+	Probe        string // stdio, http or "" when unprobed
+	LastProbeAt  time.Time
+	ProbeLatency time.Duration
+	Restarts     int       // automatic restarts since the last manual start
+	NextRestart  time.Time // while restarting
+	LogPath      string    // on-disk log, when logs are persisted
+
+	// Resources of the process and its children, sampled with the probes
+	CPUPercent float64
+	RSSBytes   int64
+	Procs      int
 }
 
 // Process represents a running MCP process.
@@ -44,21 +70,92 @@ type Runner interface {
 	Start(ctx context.Context, cmd []string, workdir string) (Process, error)
 }
 
+// Spec is how a component is run and probed.
+type Spec struct {
+	Cmd     []string
+	Workdir string
+	Probe   string // ProbeNone, ProbeStdio or ProbeHTTP
+	URL     string // endpoint of an http probe
+}
+
+// Options configures supervision. Zero values take the defaults below;
+// logs stay in memory when LogDir is empty.
+type Options struct {
+	LogDir        string
+	LogMaxBytes   int64
+	LogBackups    int
+	ProbeInterval time.Duration // liveness probes and resource sampling
+	ProbeTimeout  time.Duration
+	ProbeFailures int // failed probes in a row before a restart
+	BackoffMin    time.Duration
+	BackoffMax    time.Duration
+	ProcDir       string // /proc, for resource usage
+}
+
+func (o Options) withDefaults() Options {
+	if o.LogMaxBytes <= 0 {
+		o.LogMaxBytes = 1 << 20
+	}
+	if o.LogBackups < 0 {
+		o.LogBackups = 0
+	}
+	if o.ProbeInterval <= 0 {
+		o.ProbeInterval = 15 * time.Second
+	}
+	if o.ProbeTimeout <= 0 {
+		o.ProbeTimeout = 5 * time.Second
+	}
+	if o.ProbeFailures <= 0 {
+		o.ProbeFailures = 3
+	}
+	if o.BackoffMin <= 0 {
+		o.BackoffMin = time.Second
+	}
+	if o.BackoffMax < o.BackoffMin {
+		o.BackoffMax = max(time.Minute, o.BackoffMin)
+	}
+	if o.ProcDir == "" {
+		o.ProcDir = "/proc"
+	}
+	return o
+}
+
+// logTailLines is how many log lines a status carries.
+const logTailLines = 50
+
 type managedProcess struct {
 	status *ComponentStatus
 	proc   Process
+	spec   Spec
+
+	gen      int                // bumped on every launch; stale goroutines check it
+	cancel   context.CancelFunc // ends the process's context
+	stopping bool               // stopped on purpose: exit is not a crash
+	killed   string             // why the supervisor killed it, if it did
+	crashes  int                // in a row, for the backoff
+	timer    *time.Timer        // pending restart
+	log      *rotatingLog
+	stdio    *stdioProber
 }
 
-// Manager supervises MCP processes.
+// Manager supervises MCP processes: it restarts crashed and unresponsive
+// components with backoff, persists their logs and samples their usage.
 type Manager struct {
 	mu     sync.RWMutex
 	items  map[string]*managedProcess
 	runner Runner
+	opts   Options
+	logs   sync.WaitGroup // output consumers, drained by Close
 }
 
 // NewManager creates a new MCP manager.
 func NewManager() *Manager {
-	return &Manager{items: make(map[string]*managedProcess)}
+	return NewManagerWithOptions(Options{})
+}
+
+// NewManagerWithOptions creates a manager with supervision options.
+func NewManagerWithOptions(opts Options) *Manager {
+	return &Manager{items: make(map[string]*managedProcess), opts: opts.withDefaults()}
 }
 
 // NewManagerWithRunner creates a manager with an injected runner (for tests).
@@ -82,66 +179,325 @@ func (m *Manager) Stop(project, component string) error {
 		m.items[k] = &managedProcess{status: &ComponentStatus{ProjectPath: project, Component: component, Status: StatusStopped}}
 		return nil
 	}
+	item.stopping = true
+	item.gen++ // the old process's exit is not a crash
+	if item.timer != nil {
+		item.timer.Stop()
+		item.timer = nil
+	}
 	if item.proc != nil {
+		m.logLocked(item, "stopped")
 		_ = item.proc.Stop()
 		item.proc = nil
 	}
+	if item.cancel != nil {
+		item.cancel()
+		item.cancel = nil
+	}
 	item.status.Status = StatusStopped
 	item.status.Pid = 0
+	item.status.NextRestart = time.Time{}
 	return nil
 }
 
 // Start starts a component process and begins log tailing.
 func (m *Manager) Start(ctx context.Context, project, component string, cmd []string, workdir string) error {
-	if len(cmd) == 0 {
+	return m.StartSpec(ctx, project, component, Spec{Cmd: cmd, Workdir: workdir})
+}
+
+// StartSpec starts a component and supervises it until Stop. The process
+// outlives ctx, which only bounds the start itself.
+func (m *Manager) StartSpec(ctx context.Context, project, component string, spec Spec) error {
+	if len(spec.Cmd) == 0 {
 		return fmt.Errorf("missing command")
 	}
-	m.mu.RLock()
-	if existing, ok := m.items[key(project, component)]; ok && existing.status != nil {
-		if existing.status.Status == StatusRunning && existing.proc != nil {
-			m.mu.RUnlock()
-			return nil
+	if !ValidComponent(component) {
+		return fmt.Errorf("invalid component name %q", component)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	k := key(project, component)
+	m.mu.Lock()
+	item, ok := m.items[k]
+	if ok && item.proc != nil && item.status != nil && item.status.Status == StatusRunning {
+		m.mu.Unlock()
+		return nil
+	}
+	if !ok {
+		item = &managedProcess{}
+		m.items[k] = item
+	}
+	if item.timer != nil {
+		item.timer.Stop()
+		item.timer = nil
+	}
+	var tail []string
+	if item.status != nil {
+		tail = item.status.LogTail
+	}
+	item.spec = spec
+	item.stopping = false
+	item.crashes = 0
+	item.status = &ComponentStatus{ProjectPath: project, Component: component, Probe: spec.Probe, LogTail: tail}
+	if m.opts.LogDir != "" && item.log == nil {
+		path := LogPath(m.opts.LogDir, project, component)
+		if log, err := openRotatingLog(path, m.opts.LogMaxBytes, m.opts.LogBackups); err == nil {
+			item.log = log
 		}
 	}
+	if item.log != nil {
+		item.status.LogPath = item.log.path
+		item.status.LogTail = tailFile(item.log.path, logTailLines)
+	}
+	m.mu.Unlock()
+	return m.launch(project, component)
+}
+
+// Restart stops a component and starts it again with the same spec.
+func (m *Manager) Restart(ctx context.Context, project, component string) error {
+	m.mu.RLock()
+	item, ok := m.items[key(project, component)]
+	var spec Spec
+	if ok {
+		spec = item.spec
+	}
 	m.mu.RUnlock()
+	if len(spec.Cmd) == 0 {
+		return fmt.Errorf("mcp %s was never started", component)
+	}
+	if err := m.Stop(project, component); err != nil {
+		return err
+	}
+	return m.StartSpec(ctx, project, component, spec)
+}
+
+// launch starts a new generation of a component's process.
+func (m *Manager) launch(project, component string) error {
+	k := key(project, component)
+	m.mu.Lock()
+	item, ok := m.items[k]
+	if !ok || item.stopping {
+		m.mu.Unlock()
+		return nil
+	}
 	if m.runner == nil {
 		m.runner = &execRunner{}
 	}
+	runner, spec := m.runner, item.spec
+	item.gen++
+	gen := item.gen
+	m.mu.Unlock()
+
+	procCtx, cancel := context.WithCancel(context.Background())
+	proc, err := runner.Start(procCtx, spec.Cmd, spec.Workdir)
 
-	proc, err := m.runner.Start(ctx, cmd, workdir)
-	status := &ComponentStatus{
-		ProjectPath: project,
-		Component:   component,
-		Status:      StatusRunning,
-		Pid:         0,
-		StartedAt:   time.Now(),
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if item.gen != gen || item.stopping {
+		// Stopped or restarted while starting
+		cancel()
+		if proc != nil {
+			_ = proc.Stop()
+		}
+		return nil
 	}
+	now := time.Now()
+	item.status.StartedAt = now
+	item.status.NextRestart = time.Time{}
 	if err != nil {
-		status.Status = StatusError
-		status.LastError = err.Error()
-		m.mu.Lock()
-		m.items[key(project, component)] = &managedProcess{status: status}
-		m.mu.Unlock()
+		cancel()
+		item.status.Status = StatusError
+		item.status.LastError = err.Error()
+		m.logLocked(item, "start failed: "+err.Error())
+		if item.status.Restarts > 0 {
+			// A restart that cannot start is retried like a crash
+			m.scheduleRestartLocked(project, component, item, gen, now)
+		}
 		return err
 	}
-	status.Pid = proc.Pid()
+	item.proc, item.cancel, item.killed = proc, cancel, ""
+	item.status.Status = StatusRunning
+	item.status.Pid = proc.Pid()
+	item.status.Health = HealthUnknown
+	item.stdio = nil
+	var probe prober
+	switch spec.Probe {
+	case ProbeStdio:
+		if sp, ok := proc.(StdinProcess); ok {
+			item.stdio = newStdioProber(sp.Stdin())
+			probe = item.stdio
+		}
+	case ProbeHTTP:
+		if spec.URL != "" {
+			probe = newHTTPProber(spec.URL)
+		}
+	}
+	m.logLocked(item, fmt.Sprintf("started pid %d: %s", proc.Pid(), strings.Join(spec.Cmd, " ")))
 
+	m.consumeLogs(project, component, gen, "out", proc.Stdout())
+	m.consumeLogs(project, component, gen, "err", proc.Stderr())
+	go func() {
+		waitErr := proc.Wait()
+		m.exited(project, component, gen, waitErr)
+	}()
+	go m.supervise(procCtx, project, component, gen, proc.Pid(), probe)
+	return nil
+}
+
+// exited records the end of a process generation and restarts it after a
+// backoff unless it was stopped or exited cleanly.
+func (m *Manager) exited(project, component string, gen int, err error) {
 	m.mu.Lock()
-	m.items[key(project, component)] = &managedProcess{status: status, proc: proc}
-	m.mu.Unlock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key(project, component)]
+	if !ok || item.gen != gen || item.status == nil {
+		return
+	}
+	now := time.Now()
+	uptime := now.Sub(item.status.StartedAt)
+	item.proc = nil
+	item.status.Pid = 0
+	item.status.CPUPercent, item.status.RSSBytes, item.status.Procs = 0, 0, 0
+	if item.cancel != nil {
+		item.cancel()
+		item.cancel = nil
+	}
+	if item.stopping {
+		item.status.Status = StatusStopped
+		return
+	}
+	if item.killed != "" {
+		err = errors.New(item.killed)
+	}
+	if err == nil {
+		m.logLocked(item, "exited")
+		item.status.Status = StatusStopped
+		return
+	}
+	m.logLocked(item, "exited: "+err.Error())
+	item.status.Status = StatusError
+	item.status.LastError = err.Error()
+	if uptime >= m.opts.BackoffMax {
+		item.crashes = 0
+	}
+	m.scheduleRestartLocked(project, component, item, gen, now)
+}
+
+func (m *Manager) scheduleRestartLocked(project, component string, item *managedProcess, gen int, now time.Time) {
+	item.crashes++
+	delay := backoff(item.crashes, m.opts.BackoffMin, m.opts.BackoffMax)
+	item.status.Status = StatusRestarting
+	item.status.NextRestart = now.Add(delay)
+	m.logLocked(item, fmt.Sprintf("restarting in %s", delay))
+	item.timer = time.AfterFunc(delay, func() {
+		m.mu.Lock()
+		current := item.gen == gen && !item.stopping
+		if current {
+			item.timer = nil
+			item.status.Restarts++
+		}
+		m.mu.Unlock()
+		if current {
+			_ = m.launch(project, component)
+		}
+	})
+}
 
-	m.consumeLogs(project, component, proc.Stdout())
-	m.consumeLogs(project, component, proc.Stderr())
+// backoff is the delay before the nth restart in a row: first, doubling
+// up to limit.
+func backoff(n int, first, limit time.Duration) time.Duration {
+	d := first
+	for i := 1; i < n && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
 
-	go func() {
-		if waitErr := proc.Wait(); waitErr != nil {
-			m.setError(project, component, waitErr)
+// supervise probes a process generation and samples its usage until its
+// context ends. After opts.ProbeFailures failed probes in a row the
+// process is killed, and exited restarts it.
+func (m *Manager) supervise(ctx context.Context, project, component string, gen, pid int, probe prober) {
+	ticker := time.NewTicker(m.opts.ProbeInterval)
+	defer ticker.Stop()
+	initialized := false
+	failures := 0
+	var prev Usage
+	var prevAt time.Time
+	for {
+		if probe != nil {
+			start := time.Now()
+			pctx, cancel := context.WithTimeout(ctx, m.opts.ProbeTimeout)
+			var err error
+			if !initialized {
+				if err = probe.Call(pctx, "initialize", initializeParams()); err == nil {
+					initialized = true
+					err = probe.Notify(pctx, "notifications/initialized")
+				}
+			} else {
+				err = probe.Call(pctx, "ping", nil)
+			}
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				failures++
+			} else {
+				failures = 0
+			}
+			if !m.recordProbe(project, component, gen, start, err, failures) {
+				return
+			}
+		}
+
+		if u, err := ReadUsage(m.opts.ProcDir, pid); err == nil {
+			now := time.Now()
+			cpu := 0.0
+			if !prevAt.IsZero() {
+				cpu = cpuPercent(prev, u, now.Sub(prevAt))
+			}
+			prev, prevAt = u, now
+			m.mu.Lock()
+			if item, ok := m.items[key(project, component)]; ok && item.gen == gen && item.proc != nil {
+				item.status.CPUPercent, item.status.RSSBytes, item.status.Procs = cpu, u.RSSBytes, u.Procs
+			}
+			m.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
 			return
+		case <-ticker.C:
 		}
-		m.setStatus(project, component, StatusStopped)
-	}()
+	}
+}
 
-	return nil
+// recordProbe stores a probe result, killing the process once it has
+// failed too often. It reports whether supervision should go on.
+func (m *Manager) recordProbe(project, component string, gen int, start time.Time, err error, failures int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key(project, component)]
+	if !ok || item.gen != gen || item.proc == nil {
+		return false
+	}
+	item.status.LastProbeAt = start
+	if err == nil {
+		item.status.Health = HealthHealthy
+		item.status.ProbeLatency = time.Since(start)
+		item.crashes = 0
+		return true
+	}
+	item.status.Health = HealthUnhealthy
+	item.status.LastError = "liveness probe: " + err.Error()
+	if failures < m.opts.ProbeFailures {
+		return true
+	}
+	item.killed = fmt.Sprintf("killed after %d failed liveness probes: %v", failures, err)
+	m.logLocked(item, item.killed)
+	_ = item.proc.Stop()
+	return false
 }
 
 // Status returns a snapshot of the component status.
@@ -152,56 +508,148 @@ func (m *Manager) Status(project, component string) *ComponentStatus {
 	if !ok || item.status == nil {
 		return nil
 	}
-	clone := *item.status
-	if item.status.LogTail != nil {
-		clone.LogTail = append([]string(nil), item.status.LogTail...)
+	return cloneStatus(item.status)
+}
+
+// Statuses returns every managed component, by project then component.
+func (m *Manager) Statuses() []ComponentStatus {
+	m.mu.RLock()
+	out := make([]ComponentStatus, 0, len(m.items))
+	for _, item := range m.items {
+		if item.status != nil {
+			out = append(out, *cloneStatus(item.status))
+		}
 	}
-	return &clone
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ProjectPath != out[j].ProjectPath {
+			return out[i].ProjectPath < out[j].ProjectPath
+		}
+		return out[i].Component < out[j].Component
+	})
+	return out
 }
 
-func (m *Manager) setStatus(project, component string, status Status) {
+// Track registers a component that exists but is not running, so its
+// persisted log can be read after Bigend restarts. Known components are
+// left as they are.
+func (m *Manager) Track(project, component string) {
+	if !ValidComponent(component) {
+		return
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	item, ok := m.items[key(project, component)]
-	if !ok || item.status == nil {
+	k := key(project, component)
+	if _, ok := m.items[k]; ok {
 		return
 	}
-	item.status.Status = status
-	if status != StatusRunning {
-		item.status.Pid = 0
+	status := &ComponentStatus{ProjectPath: project, Component: component, Status: StatusStopped}
+	if m.opts.LogDir != "" {
+		status.LogPath = LogPath(m.opts.LogDir, project, component)
+		status.LogTail = tailFile(status.LogPath, logTailLines)
 	}
+	m.items[k] = &managedProcess{status: status}
 }
 
-func (m *Manager) setError(project, component string, err error) {
+// Tail returns the last n lines of a tracked or started component's log:
+// the on-disk log when persisted, which survives Bigend restarts, else
+// the in-memory tail. Unknown components have none.
+func (m *Manager) Tail(project, component string, n int) []string {
+	status := m.Status(project, component)
+	if status == nil {
+		return nil
+	}
+	if path := m.LogPath(project, component); path != "" {
+		return tailFile(path, n)
+	}
+	lines := status.LogTail
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// LogPath is where a component's log is persisted, or "" when logs are
+// kept in memory or the component is unknown.
+func (m *Manager) LogPath(project, component string) string {
+	if m.opts.LogDir == "" || m.Status(project, component) == nil {
+		return ""
+	}
+	return LogPath(m.opts.LogDir, project, component)
+}
+
+// Close stops every component and closes their logs once everything the
+// processes printed has been written.
+func (m *Manager) Close() {
+	m.mu.RLock()
+	var keys [][2]string
+	for _, item := range m.items {
+		if item.status != nil {
+			keys = append(keys, [2]string{item.status.ProjectPath, item.status.Component})
+		}
+	}
+	m.mu.RUnlock()
+	for _, k := range keys {
+		_ = m.Stop(k[0], k[1])
+	}
+	// Write out what the stopped processes printed before closing the logs
+	m.logs.Wait()
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	item, ok := m.items[key(project, component)]
-	if !ok || item.status == nil {
-		return
+	for _, item := range m.items {
+		if item.log != nil {
+			item.log.Close()
+			item.log = nil
+		}
 	}
-	item.status.Status = StatusError
-	item.status.LastError = err.Error()
-	item.status.Pid = 0
+	m.mu.Unlock()
+}
+
+func cloneStatus(s *ComponentStatus) *ComponentStatus {
+	clone := *s
+	if s.LogTail != nil {
+		clone.LogTail = append([]string(nil), s.LogTail...)
+	}
+	return &clone
 }
 
-func (m *Manager) consumeLogs(project, component string, lines <-chan string) {
+func (m *Manager) consumeLogs(project, component string, gen int, stream string, lines <-chan string) {
+	m.logs.Add(1)
 	go func() {
+		defer m.logs.Done()
 		for line := range lines {
-			m.appendLog(project, component, line)
+			m.appendLog(project, component, gen, stream, line)
 		}
 	}()
 }
 
-func (m *Manager) appendLog(project, component, line string) {
+func (m *Manager) appendLog(project, component string, gen int, stream, line string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	item, ok := m.items[key(project, component)]
 	if !ok || item.status == nil {
 		return
 	}
-	item.status.LogTail = append(item.status.LogTail, line)
-	if len(item.status.LogTail) > 50 {
-		item.status.LogTail = item.status.LogTail[len(item.status.LogTail)-50:]
+	if stream == "out" && item.gen == gen && item.stdio != nil && item.stdio.deliver(line) {
+		return
+	}
+	if item.log != nil {
+		_ = item.log.Line(time.Now(), stream, line)
+	}
+	appendTail(item.status, line)
+}
+
+// logLocked records a supervision event in the component's log.
+func (m *Manager) logLocked(item *managedProcess, event string) {
+	if item.log != nil {
+		_ = item.log.Line(time.Now(), "bigend", event)
+	}
+	appendTail(item.status, "[bigend] "+event)
+}
+
+func appendTail(status *ComponentStatus, line string) {
+	status.LogTail = append(status.LogTail, line)
+	if len(status.LogTail) > logTailLines {
+		status.LogTail = status.LogTail[len(status.LogTail)-logTailLines:]
 	}
 }
 
@@ -212,10 +660,16 @@ func (r *execRunner) Start(ctx context.Context, cmd []string, workdir string) (P
 		return nil, fmt.Errorf("missing command")
 	}
 	c := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	// A child that inherited the pipes must not keep Wait, and so Close,
+	// from returning
+	c.WaitDelay = 5 * time.Second
 	if workdir != "" {
 		c.Dir = workdir
 	}
-
+	stdinPipe, err := c.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
 	stdoutPipe, err := c.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -224,30 +678,29 @@ func (r *execRunner) Start(ctx context.Context, cmd []string, workdir string) (P
 	if err != nil {
 		return nil, err
 	}
-
 	if err := c.Start(); err != nil {
 		return nil, err
 	}
-
 	p := &execProcess{
 		cmd:    c,
+		stdin:  stdinPipe,
 		stdout: make(chan string, 64),
 		stderr: make(chan string, 64),
 	}
-
 	go scanLines(stdoutPipe, p.stdout)
 	go scanLines(stderrPipe, p.stderr)
-
 	return p, nil
 }
 
 type execProcess struct {
 	cmd    *exec.Cmd
+	stdin  io.WriteCloser
 	stdout chan string
 	stderr chan string
 }
 
 func (p *execProcess) Pid() int              { return p.cmd.Process.Pid }
+func (p *execProcess) Stdin() io.Writer      { return p.stdin }
 func (p *execProcess) Stdout() <-chan string { return p.stdout }
 func (p *execProcess) Stderr() <-chan string { return p.stderr }
 func (p *execProcess) Wait() error           { return p.cmd.Wait() }
@@ -0,0 +1,240 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Probe kinds of a component Spec.
+const (
+	ProbeNone  = ""
+	ProbeStdio = "stdio"
+	ProbeHTTP  = "http"
+)
+
+// protocolVersion is the MCP revision Bigend announces when probing.
+const protocolVersion = "2025-06-18"
+
+// StdinProcess is a Process whose stdin can be written, which stdio
+// probes need.
+type StdinProcess interface {
+	Process
+	Stdin() io.Writer
+}
+
+// prober checks an MCP server is alive: initialize once, then ping.
+type prober interface {
+	Call(ctx context.Context, method string, params any) error
+	Notify(ctx context.Context, method string) error
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      *int64 `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
+func initializeParams() any {
+	return map[string]any{
+		"protocolVersion": protocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "bigend", "version": "1"},
+	}
+}
+
+// stdioProber sends requests on the process's stdin. Responses arrive on
+// stdout among the server's other output and are handed over by deliver.
+type stdioProber struct {
+	w io.Writer
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcResponse
+}
+
+func newStdioProber(w io.Writer) *stdioProber {
+	return &stdioProber{w: w, pending: make(map[int64]chan rpcResponse)}
+}
+
+func (p *stdioProber) write(req rpcRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	_, err = p.w.Write(append(data, '\n'))
+	return err
+}
+
+func (p *stdioProber) Call(ctx context.Context, method string, params any) error {
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	ch := make(chan rpcResponse, 1)
+	p.pending[id] = ch
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}()
+
+	if err := p.write(rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		return err
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: no response: %w", method, ctx.Err())
+	}
+}
+
+func (p *stdioProber) Notify(ctx context.Context, method string) error {
+	return p.write(rpcRequest{JSONRPC: "2.0", Method: method})
+}
+
+// deliver passes a stdout line to the call waiting for it, reporting
+// whether it was a probe response.
+func (p *stdioProber) deliver(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") || !strings.Contains(trimmed, `"jsonrpc"`) {
+		return false
+	}
+	var resp rpcResponse
+	if err := json.Unmarshal([]byte(trimmed), &resp); err != nil || resp.ID == nil {
+		return false
+	}
+	p.mu.Lock()
+	ch, ok := p.pending[*resp.ID]
+	p.mu.Unlock()
+	if !ok {
+		return false
+	}
+	ch <- resp
+	return true
+}
+
+// httpProber posts requests to a streamable HTTP endpoint, keeping the
+// session the server assigns on initialize.
+type httpProber struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	nextID  int64
+	session string
+}
+
+func newHTTPProber(url string) *httpProber {
+	return &httpProber{url: url, client: &http.Client{}}
+}
+
+func (p *httpProber) post(ctx context.Context, req rpcRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	httpReq.Header.Set("MCP-Protocol-Version", protocolVersion)
+	p.mu.Lock()
+	if p.session != "" {
+		httpReq.Header.Set("Mcp-Session-Id", p.session)
+	}
+	p.mu.Unlock()
+	return p.client.Do(httpReq)
+}
+
+func (p *httpProber) Call(ctx context.Context, method string, params any) error {
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	p.mu.Unlock()
+
+	resp, err := p.post(ctx, rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: HTTP %s", method, resp.Status)
+	}
+	if s := resp.Header.Get("Mcp-Session-Id"); s != "" {
+		p.mu.Lock()
+		p.session = s
+		p.mu.Unlock()
+	}
+	var rpc rpcResponse
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		err = readEvent(resp.Body, &rpc)
+	} else {
+		err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&rpc)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if rpc.Error != nil {
+		return rpc.Error
+	}
+	return nil
+}
+
+func (p *httpProber) Notify(ctx context.Context, method string) error {
+	resp, err := p.post(ctx, rpcRequest{JSONRPC: "2.0", Method: method})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// readEvent decodes the first server-sent event's data.
+func readEvent(r io.Reader, v any) error {
+	scanner := bufio.NewScanner(io.LimitReader(r, 1<<20))
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" && data.Len() > 0 {
+			break
+		}
+		if rest, ok := strings.CutPrefix(line, "data:"); ok {
+			data.WriteString(strings.TrimPrefix(rest, " "))
+		}
+	}
+	if data.Len() == 0 {
+		return errors.New("empty event stream")
+	}
+	return json.Unmarshal([]byte(data.String()), v)
+}
@@ -0,0 +1,395 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// crashProcess is a fake process that exits with an error when told to,
+// and answers stdio probes while healthy. Like real pipes, its output
+// streams close when it exits.
+type crashProcess struct {
+	pid    int
+	stdout chan string
+	stderr chan string
+	done   chan error
+
+	mu       sync.Mutex
+	healthy  bool
+	exited   bool
+	methods  []string
+	stopOnce sync.Once
+}
+
+func newCrashProcess(pid int) *crashProcess {
+	return &crashProcess{
+		pid:     pid,
+		stdout:  make(chan string, 64),
+		stderr:  make(chan string, 64),
+		done:    make(chan error, 1),
+		healthy: true,
+	}
+}
+
+func (p *crashProcess) Pid() int              { return p.pid }
+func (p *crashProcess) Stdout() <-chan string { return p.stdout }
+func (p *crashProcess) Stderr() <-chan string { return p.stderr }
+func (p *crashProcess) Wait() error           { return <-p.done }
+func (p *crashProcess) Stdin() io.Writer      { return p }
+func (p *crashProcess) Stop() error {
+	p.exit(errors.New("signal: killed"))
+	return nil
+}
+
+func (p *crashProcess) exit(err error) {
+	p.stopOnce.Do(func() {
+		p.mu.Lock()
+		p.exited = true
+		close(p.stdout)
+		close(p.stderr)
+		p.mu.Unlock()
+		p.done <- err
+	})
+}
+
+// Write answers JSON-RPC requests on stdout while the process is healthy.
+func (p *crashProcess) Write(data []byte) (int, error) {
+	var req rpcRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return 0, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.exited {
+		return 0, io.ErrClosedPipe
+	}
+	p.methods = append(p.methods, req.Method)
+	if p.healthy && req.ID != nil {
+		resp, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": *req.ID, "result": map[string]any{}})
+		p.stdout <- string(resp)
+	}
+	return len(data), nil
+}
+
+func (p *crashProcess) setHealthy(healthy bool) {
+	p.mu.Lock()
+	p.healthy = healthy
+	p.mu.Unlock()
+}
+
+func (p *crashProcess) calls() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.methods...)
+}
+
+type crashRunner struct {
+	mu    sync.Mutex
+	procs []*crashProcess
+}
+
+func (r *crashRunner) Start(ctx context.Context, cmd []string, workdir string) (Process, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := newCrashProcess(1000 + len(r.procs))
+	r.procs = append(r.procs, p)
+	return p, nil
+}
+
+func (r *crashRunner) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.procs)
+}
+
+func (r *crashRunner) last() *crashProcess {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.procs[len(r.procs)-1]
+}
+
+func testManager(r Runner, opts Options) *Manager {
+	opts.ProcDir = os.DevNull // no usage sampling
+	m := NewManagerWithOptions(opts)
+	m.runner = r
+	return m
+}
+
+func TestManagerRestartsCrashedComponent(t *testing.T) {
+	runner := &crashRunner{}
+	m := testManager(runner, Options{BackoffMin: 10 * time.Millisecond, BackoffMax: time.Second})
+	defer m.Close()
+	if err := m.Start(context.Background(), "/p", "server", []string{"server"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	runner.last().exit(errors.New("exit status 1"))
+	waitFor(t, func() bool { return runner.count() == 2 })
+	waitFor(t, func() bool {
+		s := m.Status("/p", "server")
+		return s.Status == StatusRunning && s.Pid == 1001
+	})
+	s := m.Status("/p", "server")
+	if s.Restarts != 1 || s.LastError != "exit status 1" {
+		t.Errorf("unexpected status %+v", s)
+	}
+	if !strings.Contains(strings.Join(s.LogTail, "\n"), "[bigend] restarting in 10ms") {
+		t.Errorf("restart not logged: %q", s.LogTail)
+	}
+
+	// A clean exit is not a crash
+	runner.last().exit(nil)
+	waitFor(t, func() bool { return m.Status("/p", "server").Status == StatusStopped })
+	time.Sleep(30 * time.Millisecond)
+	if runner.count() != 2 {
+		t.Errorf("clean exit restarted: %d starts", runner.count())
+	}
+}
+
+func TestManagerStopCancelsRestart(t *testing.T) {
+	runner := &crashRunner{}
+	m := testManager(runner, Options{BackoffMin: 20 * time.Millisecond, BackoffMax: time.Second})
+	defer m.Close()
+	if err := m.Start(context.Background(), "/p", "server", []string{"server"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	runner.last().exit(errors.New("exit status 1"))
+	waitFor(t, func() bool { return m.Status("/p", "server").Status == StatusRestarting })
+	if err := m.Stop("/p", "server"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if runner.count() != 1 {
+		t.Errorf("stopped component restarted: %d starts", runner.count())
+	}
+	if s := m.Status("/p", "server"); s.Status != StatusStopped || !s.NextRestart.IsZero() {
+		t.Errorf("unexpected status %+v", s)
+	}
+}
+
+func TestManagerManualRestart(t *testing.T) {
+	runner := &crashRunner{}
+	m := testManager(runner, Options{BackoffMin: 10 * time.Millisecond})
+	defer m.Close()
+	if err := m.Restart(context.Background(), "/p", "server"); err == nil {
+		t.Error("restart of a never started component should fail")
+	}
+	if err := m.Start(context.Background(), "/p", "server", []string{"server"}, "/work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Restart(context.Background(), "/p", "server"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	// The killed first process is not restarted on top of the new one
+	if runner.count() != 2 {
+		t.Fatalf("expected 2 starts, got %d", runner.count())
+	}
+	if s := m.Status("/p", "server"); s.Status != StatusRunning || s.Pid != 1001 || s.Restarts != 0 {
+		t.Errorf("unexpected status %+v", s)
+	}
+}
+
+func TestManagerStdioProbeKillsUnresponsive(t *testing.T) {
+	runner := &crashRunner{}
+	m := testManager(runner, Options{
+		ProbeInterval: 5 * time.Millisecond,
+		ProbeTimeout:  20 * time.Millisecond,
+		ProbeFailures: 2,
+		BackoffMin:    10 * time.Millisecond,
+	})
+	defer m.Close()
+	spec := Spec{Cmd: []string{"server"}, Probe: ProbeStdio}
+	if err := m.StartSpec(context.Background(), "/p", "server", spec); err != nil {
+		t.Fatal(err)
+	}
+	first := runner.last()
+	waitFor(t, func() bool { return m.Status("/p", "server").Health == HealthHealthy })
+	waitFor(t, func() bool {
+		calls := first.calls()
+		return len(calls) >= 3 && calls[2] == "ping"
+	})
+	if calls := first.calls(); calls[0] != "initialize" || calls[1] != "notifications/initialized" {
+		t.Errorf("unexpected handshake %q", calls)
+	}
+	for _, line := range m.Status("/p", "server").LogTail {
+		if strings.Contains(line, `"jsonrpc"`) {
+			t.Errorf("probe response logged: %s", line)
+		}
+	}
+
+	first.setHealthy(false)
+	waitFor(t, func() bool { return runner.count() == 2 })
+	s := m.Status("/p", "server")
+	if !strings.Contains(strings.Join(s.LogTail, "\n"), "killed after 2 failed liveness probes") {
+		t.Errorf("kill not logged: %q", s.LogTail)
+	}
+	waitFor(t, func() bool { return m.Status("/p", "server").Health == HealthHealthy })
+}
+
+func TestHTTPProber(t *testing.T) {
+	var mu sync.Mutex
+	var sessions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		sessions = append(sessions, r.Header.Get("Mcp-Session-Id"))
+		mu.Unlock()
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "s1")
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{}}\n\n")
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "ping":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"jsonrpc":"2.0","id":2,"result":{}}`)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"no such method"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	p := newHTTPProber(srv.URL)
+	ctx := context.Background()
+	if err := p.Call(ctx, "initialize", initializeParams()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Notify(ctx, "notifications/initialized"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Call(ctx, "ping", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Call(ctx, "bogus", nil); err == nil || !strings.Contains(err.Error(), "no such method") {
+		t.Errorf("expected rpc error, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(sessions, ",") != ",s1,s1,s1" {
+		t.Errorf("session not kept: %q", sessions)
+	}
+}
+
+func TestManagerPersistsLogs(t *testing.T) {
+	dir := t.TempDir()
+	runner := &crashRunner{}
+	m := testManager(runner, Options{LogDir: dir, LogMaxBytes: 400, LogBackups: 2})
+	if err := m.Start(context.Background(), "/home/me/proj", "server", []string{"server"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	proc := runner.last()
+	for i := 0; i < 20; i++ {
+		proc.stdout <- "line " + strings.Repeat("x", 20)
+	}
+	proc.stderr <- "boom"
+	path := LogPath(dir, "/home/me/proj", "server")
+	if path != filepath.Join(dir, "home_me_proj", "server.log") {
+		t.Errorf("log path = %s", path)
+	}
+	// Close writes out everything the process printed, so no polling
+	m.Close()
+	live := m.Status("/home/me/proj", "server").LogTail
+	want := live[len(live)-3:]
+	if all := strings.Join(live, "\n"); strings.Count(all, "line x") != 20 ||
+		!strings.Contains(all, "boom") || !strings.Contains(all, "[bigend] stopped") {
+		t.Errorf("output lost on close: %q", all)
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("log not rotated: %v", err)
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("more backups kept than configured")
+	}
+	for _, suffix := range []string{"", ".1", ".2"} {
+		if info, err := os.Stat(path + suffix); err == nil && info.Size() > 400 {
+			t.Errorf("%s is %d bytes", path+suffix, info.Size())
+		}
+	}
+
+	// A new manager reads the tail back from disk once it tracks the
+	// component, and never tails a name it does not know
+	m2 := testManager(&crashRunner{}, Options{LogDir: dir})
+	if tail := m2.Tail("/home/me/proj", "server", 3); tail != nil {
+		t.Errorf("untracked component tailed: %q", tail)
+	}
+	for _, bad := range []string{"../server", "a/b", `a\b`, ".."} {
+		m2.Track("/home/me/proj", bad)
+		if LogPath(dir, "/home/me/proj", bad) != "" || m2.Status("/home/me/proj", bad) != nil {
+			t.Errorf("component %q accepted", bad)
+		}
+		if err := m2.Start(context.Background(), "/home/me/proj", bad, []string{"server"}, ""); err == nil {
+			t.Errorf("started component %q", bad)
+		}
+	}
+	m2.Track("/home/me/proj", "server")
+	if tail := m2.Tail("/home/me/proj", "server", 3); len(tail) != 3 || strings.Join(tail, ",") != strings.Join(want, ",") {
+		t.Errorf("tail from disk %q, want %q", tail, want)
+	}
+}
+
+func TestReadUsage(t *testing.T) {
+	dir := t.TempDir()
+	stat := func(pid int, comm string, ppid int, utime, stime, rss int) {
+		fields := []string{"S", strconv.Itoa(ppid)}
+		for i := 2; i < 24; i++ {
+			fields = append(fields, "0")
+		}
+		fields[11], fields[12], fields[21] = strconv.Itoa(utime), strconv.Itoa(stime), strconv.Itoa(rss)
+		writeStat(t, filepath.Join(dir, strconv.Itoa(pid), "stat"), strconv.Itoa(pid)+" ("+comm+") "+strings.Join(fields, " "))
+	}
+	stat(10, "npm run", 1, 100, 50, 10)
+	stat(11, "node", 10, 200, 0, 20)
+	stat(12, "sh", 11, 0, 0, 1)
+	stat(20, "other", 1, 999, 999, 999)
+
+	u, err := ReadUsage(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Procs != 3 || u.CPUSeconds != 3.5 || u.RSSBytes != 31*int64(os.Getpagesize()) {
+		t.Errorf("unexpected usage %+v", u)
+	}
+	if _, err := ReadUsage(dir, 99); err == nil {
+		t.Error("expected error for a missing pid")
+	}
+	if got := cpuPercent(Usage{CPUSeconds: 1}, Usage{CPUSeconds: 1.5}, time.Second); got != 50 {
+		t.Errorf("cpu = %v", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for n, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 10: time.Minute} {
+		if got := backoff(n, time.Second, time.Minute); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func writeStat(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
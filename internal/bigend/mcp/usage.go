@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc/<pid>/stat. It is
+// 100 on every Linux platform Bigend runs on.
+const clockTicks = 100
+
+// Usage is the resources used by a process and its descendants, as npm
+// and similar launchers run the server in a child process.
+type Usage struct {
+	CPUSeconds float64
+	RSSBytes   int64
+	Procs      int
+}
+
+// procStat is the part of /proc/<pid>/stat usage needs.
+type procStat struct {
+	ppid  int
+	ticks int64 // utime + stime
+	rss   int64 // pages
+}
+
+// ReadUsage sums the usage of pid and its descendants from /proc. It
+// fails where /proc is unavailable.
+func ReadUsage(procDir string, pid int) (Usage, error) {
+	root, err := readProcStat(procDir, pid)
+	if err != nil {
+		return Usage{}, err
+	}
+	children := make(map[int][]int)
+	stats := map[int]procStat{pid: root}
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return Usage{}, err
+	}
+	for _, e := range entries {
+		other, err := strconv.Atoi(e.Name())
+		if err != nil || other == pid {
+			continue
+		}
+		st, err := readProcStat(procDir, other)
+		if err != nil {
+			continue
+		}
+		stats[other] = st
+		children[st.ppid] = append(children[st.ppid], other)
+	}
+
+	var u Usage
+	page := int64(os.Getpagesize())
+	queue := []int{pid}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		st := stats[p]
+		u.Procs++
+		u.CPUSeconds += float64(st.ticks) / clockTicks
+		u.RSSBytes += st.rss * page
+		queue = append(queue, children[p]...)
+	}
+	return u, nil
+}
+
+func readProcStat(procDir string, pid int) (procStat, error) {
+	data, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return procStat{}, err
+	}
+	// The command name is parenthesised and may contain spaces; the
+	// fields after it start with the state.
+	s := string(data)
+	end := strings.LastIndexByte(s, ')')
+	if end < 0 {
+		return procStat{}, os.ErrInvalid
+	}
+	fields := strings.Fields(s[end+1:])
+	if len(fields) < 22 {
+		return procStat{}, os.ErrInvalid
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	utime, _ := strconv.ParseInt(fields[11], 10, 64)
+	stime, _ := strconv.ParseInt(fields[12], 10, 64)
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+	return procStat{ppid: ppid, ticks: utime + stime, rss: rss}, nil
+}
+
+// cpuPercent is the CPU used between two samples, as a percentage of one
+// core.
+func cpuPercent(prev, cur Usage, elapsed time.Duration) float64 {
+	if elapsed <= 0 || cur.CPUSeconds < prev.CPUSeconds {
+		return 0
+	}
+	return (cur.CPUSeconds - prev.CPUSeconds) / elapsed.Seconds() * 100
+}
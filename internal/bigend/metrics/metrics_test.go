@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSeriesSumsProjectsAndRates(t *testing.T) {
+	s := openStore(t)
+	record := func(min int, samples ...Sample) {
+		t.Helper()
+		if err := s.Record(t0.Add(time.Duration(min)*time.Minute), samples); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record(0,
+		Sample{Name: MetricCommits, Project: "/p/api", Value: 10},
+		Sample{Name: MetricAgents, Project: "/p/api", Label: "working", Value: 1},
+		Sample{Name: MetricAgents, Project: "/p/api", Label: "waiting", Value: 1},
+	)
+	record(1,
+		Sample{Name: MetricCommits, Project: "/p/api", Value: 12},
+		Sample{Name: MetricCommits, Project: "/p/web", Value: 500}, // new project, not throughput
+		Sample{Name: MetricAgents, Project: "/p/api", Label: "working", Value: 2},
+		Sample{Name: MetricAgents, Project: "/p/web", Label: "working", Value: 3},
+	)
+	record(2,
+		Sample{Name: MetricCommits, Project: "/p/api", Value: 11}, // history rewritten
+		Sample{Name: MetricCommits, Project: "/p/web", Value: 501},
+	)
+
+	q := Query{Name: MetricCommits, Since: t0, Until: t0.Add(3 * time.Minute), Step: time.Minute, Rate: true}
+	points, err := s.Series(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Values(points); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Fatalf("unexpected commit rate %v", got)
+	}
+
+	q = Query{Name: MetricAgents, Labels: []string{"working"}, Since: t0, Until: t0.Add(3 * time.Minute), Step: time.Minute}
+	points, _ = s.Series(q)
+	if got := Values(points); len(got) != 2 || got[0] != 1 || got[1] != 5 {
+		t.Fatalf("unexpected working agents %v", got)
+	}
+	q.Project = "/p/web"
+	points, _ = s.Series(q)
+	if len(points) != 1 || points[0].Value != 3 || !points[0].At.Equal(t0.Add(time.Minute)) {
+		t.Fatalf("unexpected project series %+v", points)
+	}
+
+	// Wider buckets keep the last sample of each.
+	q = Query{Name: MetricCommits, Project: "/p/api", Since: t0, Until: t0.Add(4 * time.Minute), Step: 2 * time.Minute}
+	points, _ = s.Series(q)
+	if got := Values(points); len(got) != 2 || got[0] != 12 || got[1] != 11 {
+		t.Fatalf("unexpected bucketed values %v", got)
+	}
+
+	if n, err := s.Prune(t0.Add(2 * time.Minute)); err != nil || n != 7 {
+		t.Fatalf("expected seven samples pruned, got %d (%v)", n, err)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	err := WritePrometheus(&buf, []Sample{
+		{Name: MetricReviewQueue, Project: `/p/"odd"`, Value: 2},
+		{Name: MetricAgents, Project: "/p/api", Label: "working", Value: 1},
+		{Name: MetricTasksDone, Project: "/p/api", Value: 7.5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP bigend_agents Agent sessions by detected state.
+# TYPE bigend_agents gauge
+bigend_agents{project="/p/api",state="working"} 1
+# HELP bigend_review_queue Coldwine tasks waiting for review.
+# TYPE bigend_review_queue gauge
+bigend_review_queue{project="/p/\"odd\""} 2
+# HELP bigend_tasks_done Coldwine tasks done.
+# TYPE bigend_tasks_done gauge
+bigend_tasks_done{project="/p/api"} 7.5
+`
+	if buf.String() != want {
+		t.Fatalf("unexpected exposition:\n%s", buf.String())
+	}
+}
+
+func TestTrendQueryAndSparkline(t *testing.T) {
+	q := Trends[2].Query("/p/api", time.Hour, t0, 0)
+	if q.Name != MetricTasksDone || !q.Rate || q.Step != time.Minute || !q.Since.Equal(t0.Add(-time.Hour)) {
+		t.Fatalf("unexpected query %+v", q)
+	}
+	if got := Sparkline([]float64{0, 1, 2, 4, 8}); got != "▁▁▂▄█" {
+		t.Fatalf("unexpected sparkline %q", got)
+	}
+	if got := Sparkline([]float64{0, 0}); strings.Trim(got, "▁") != "" {
+		t.Fatalf("expected a flat line, got %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type definition struct {
+	kind string // gauge or counter
+	help string
+}
+
+// Tasks done and commits are gauges: a task can be reopened and HEAD can
+// move back, and a counter that drops reads as a reset to Prometheus.
+var definitions = map[string]definition{
+	MetricAgents:       {"gauge", "Agent sessions by detected state."},
+	MetricTasksDone:    {"gauge", "Coldwine tasks done."},
+	MetricReviewQueue:  {"gauge", "Coldwine tasks waiting for review."},
+	MetricCommits:      {"gauge", "Commits reachable from HEAD."},
+	MetricPollardScans: {"counter", "Pollard hunter runs."},
+}
+
+// PrometheusName is the exposition name of a metric.
+func PrometheusName(name string) string {
+	n := "bigend_" + name
+	if definitions[name].kind == "counter" {
+		n += "_total"
+	}
+	return n
+}
+
+// WritePrometheus writes samples in the Prometheus text exposition format.
+func WritePrometheus(w io.Writer, samples []Sample) error {
+	byName := map[string][]Sample{}
+	for _, s := range samples {
+		byName[s.Name] = append(byName[s.Name], s)
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		def, ok := definitions[name]
+		if !ok {
+			def = definition{kind: "gauge"}
+		}
+		pname := PrometheusName(name)
+		if def.help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", pname, def.help)
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", pname, def.kind)
+		for _, s := range byName[name] {
+			labels := `project="` + escapeLabel(s.Project) + `"`
+			if s.Label != "" {
+				labels += `,state="` + escapeLabel(s.Label) + `"`
+			}
+			fmt.Fprintf(bw, "%s{%s} %s\n", pname, labels, strconv.FormatFloat(s.Value, 'g', -1, 64))
+		}
+	}
+	return bw.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
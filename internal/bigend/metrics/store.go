@@ -0,0 +1,230 @@
+// Package metrics keeps a history of fleet metrics: agent states, task
+// throughput, commits, review queue depth and Pollard scans per project.
+// The aggregator samples them on an interval into an embedded SQLite
+// store; dashboards query bucketed series and /metrics serves the latest
+// sample as Prometheus text.
+package metrics
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"time"
+
+	autarchdb "github.com/mistakeknot/autarch/pkg/db"
+)
+
+// Metric names.
+const (
+	MetricAgents       = "agents"        // agent sessions, labelled by detected state
+	MetricTasksDone    = "tasks_done"    // Coldwine tasks done
+	MetricReviewQueue  = "review_queue"  // Coldwine tasks in review
+	MetricCommits      = "commits"       // commits reachable from HEAD
+	MetricPollardScans = "pollard_scans" // Pollard hunter runs
+)
+
+// DefaultPoints is how many buckets a series is split into when a query
+// gives no step.
+const DefaultPoints = 60
+
+// Sample is one metric value for a project at sampling time.
+type Sample struct {
+	Name    string  `json:"name"`
+	Project string  `json:"project"`
+	Label   string  `json:"label,omitempty"` // the agent state for MetricAgents
+	Value   float64 `json:"value"`
+}
+
+// Point is one bucket of a series.
+type Point struct {
+	At    time.Time `json:"at"`
+	Value float64   `json:"value"`
+}
+
+// Query selects a series. Values are the last sample of each project and
+// label in a bucket, summed.
+type Query struct {
+	Name    string
+	Project string   // "" sums across projects
+	Labels  []string // empty matches any label
+	Since   time.Time
+	Until   time.Time // zero means now
+	Step    time.Duration
+	Rate    bool // the increase per bucket, for counters
+}
+
+// Store is the SQLite time-series store.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens, creating if needed, the store at path.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := autarchdb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS samples (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  ts INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  project TEXT NOT NULL,
+  label TEXT NOT NULL DEFAULT '',
+  value REAL NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_samples_name_ts ON samples(name, ts);
+`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record stores samples taken at the same time.
+func (s *Store) Record(at time.Time, samples []Sample) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT INTO samples (ts, name, project, label, value) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, sm := range samples {
+		if _, err := stmt.Exec(at.Unix(), sm.Name, sm.Project, sm.Label, sm.Value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Prune drops samples taken before the cutoff.
+func (s *Store) Prune(before time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM samples WHERE ts < ?`, before.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Series returns the buckets of a query that have samples, oldest first.
+// For a rate, each bucket holds the increase since the previous sample of
+// the same project, so projects appearing or counters going backwards do
+// not show as throughput.
+func (s *Store) Series(q Query) ([]Point, error) {
+	until := q.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	step := q.Step
+	if step <= 0 {
+		step = until.Sub(q.Since) / DefaultPoints
+	}
+	step = max(step.Truncate(time.Second), time.Second)
+	since := q.Since.Unix()
+	width := int64(step / time.Second)
+
+	// One bucket before the range gives rates a baseline.
+	query := `SELECT ts, project, label, value FROM samples WHERE name = ? AND ts >= ? AND ts < ?`
+	args := []any{q.Name, since - width, until.Unix()}
+	if q.Project != "" {
+		query += ` AND project = ?`
+		args = append(args, q.Project)
+	}
+	rows, err := s.db.Query(query+` ORDER BY ts, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type key struct{ project, label string }
+	type bucketed struct {
+		order []int64
+		last  map[int64]float64
+	}
+	series := map[key]*bucketed{}
+	var keys []key
+	for rows.Next() {
+		var (
+			ts             int64
+			project, label string
+			value          float64
+		)
+		if err := rows.Scan(&ts, &project, &label, &value); err != nil {
+			return nil, err
+		}
+		if !matchLabel(q.Labels, label) {
+			continue
+		}
+		k := key{project, label}
+		b := series[k]
+		if b == nil {
+			b = &bucketed{last: map[int64]float64{}}
+			series[k] = b
+			keys = append(keys, k)
+		}
+		bucket := floorDiv(ts-since, width)
+		if _, ok := b.last[bucket]; !ok {
+			b.order = append(b.order, bucket)
+		}
+		b.last[bucket] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sums := map[int64]float64{}
+	for _, k := range keys {
+		b := series[k]
+		for i, bucket := range b.order {
+			v := b.last[bucket]
+			if q.Rate {
+				if i == 0 {
+					continue
+				}
+				v = max(v-b.last[b.order[i-1]], 0)
+			}
+			if bucket >= 0 {
+				sums[bucket] += v
+			}
+		}
+	}
+	var points []Point
+	for bucket := int64(0); bucket*width < until.Unix()-since; bucket++ {
+		if v, ok := sums[bucket]; ok {
+			points = append(points, Point{At: time.Unix(since+bucket*width, 0), Value: v})
+		}
+	}
+	return points, nil
+}
+
+func matchLabel(labels []string, label string) bool {
+	if len(labels) == 0 {
+		return true
+	}
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
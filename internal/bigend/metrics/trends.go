@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+)
+
+// Trend is a series shown on the dashboards.
+type Trend struct {
+	Title  string
+	Name   string
+	Labels []string
+	Rate   bool
+}
+
+// Trends are the series the web and TUI dashboards chart, in order.
+var Trends = []Trend{
+	{Title: "Agents working", Name: MetricAgents, Labels: []string{"working"}},
+	{Title: "Agents waiting or stuck", Name: MetricAgents, Labels: []string{"waiting", "blocked", "stalled", "error"}},
+	{Title: "Tasks done", Name: MetricTasksDone, Rate: true},
+	{Title: "Commits", Name: MetricCommits, Rate: true},
+	{Title: "Review queue", Name: MetricReviewQueue},
+	{Title: "Pollard scans", Name: MetricPollardScans, Rate: true},
+}
+
+// Query selects the trend for a project ("" for the fleet) over the
+// window ending at until, split into points buckets.
+func (t Trend) Query(project string, window time.Duration, until time.Time, points int) Query {
+	if points <= 0 {
+		points = DefaultPoints
+	}
+	return Query{
+		Name:    t.Name,
+		Project: project,
+		Labels:  t.Labels,
+		Since:   until.Add(-window),
+		Until:   until,
+		Step:    window / time.Duration(points),
+		Rate:    t.Rate,
+	}
+}
+
+// Summary reduces a series to its latest value, or for a rate to its total
+// over the window, and its peak.
+func Summary(t Trend, points []Point) (value, peak float64) {
+	for _, p := range points {
+		if t.Rate {
+			value += p.Value
+		} else {
+			value = p.Value
+		}
+		peak = max(peak, p.Value)
+	}
+	return value, peak
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as block characters scaled from zero to the
+// largest value.
+func Sparkline(values []float64) string {
+	var peak float64
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if peak > 0 && v > 0 {
+			i = int(v / peak * float64(len(sparks)-1))
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
+
+// Values returns the values of points.
+func Values(points []Point) []float64 {
+	out := make([]float64, len(points))
+	for i, p := range points {
+		out[i] = p.Value
+	}
+	return out
+}
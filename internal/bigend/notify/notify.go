@@ -0,0 +1,386 @@
+// Package notify tells you when agents need attention. Agent sessions
+// entering a watched state and critical signals become notifications,
+// which each configured sink (desktop, terminal bell or webhook) routes by
+// project and state, holds back during its quiet hours and rate-limits.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/statedetect"
+	"github.com/mistakeknot/autarch/pkg/signals"
+)
+
+// Notification kinds.
+const (
+	KindState  = "state"
+	KindSignal = "signal"
+	KindTest   = "test"
+)
+
+// Notification is something worth telling the user about.
+type Notification struct {
+	Kind     string    `json:"kind"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Severity string    `json:"severity"`
+	State    string    `json:"state"`              // agent state, or StateSignal
+	Previous string    `json:"previous,omitempty"` // state left
+	Session  string    `json:"session,omitempty"`
+	Agent    string    `json:"agent,omitempty"`
+	Project  string    `json:"project,omitempty"`
+	Source   string    `json:"source,omitempty"` // tool that raised a signal
+	At       time.Time `json:"at"`
+}
+
+// Delivery results.
+const (
+	ResultPending     = "pending"
+	ResultSent        = "sent"
+	ResultFailed      = "failed"
+	ResultQuiet       = "quiet"
+	ResultRateLimited = "rate-limited"
+)
+
+// Outcome is what one sink did with a notification.
+type Outcome struct {
+	Sink   string `json:"sink"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Entry is a notification with what each sink routed to did with it.
+type Entry struct {
+	Notification
+	Outcomes []Outcome `json:"outcomes"`
+}
+
+// SinkInfo describes a configured sink.
+type SinkInfo struct {
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	Routes     int           `json:"routes"`
+	QuietHours string        `json:"quiet_hours,omitempty"`
+	Quiet      bool          `json:"quiet"` // in its quiet hours now
+	RateLimit  int           `json:"rate_limit,omitempty"`
+	RateWindow time.Duration `json:"rate_window,omitempty"`
+}
+
+const (
+	historySize = 100
+	sendTimeout = 10 * time.Second
+)
+
+// Center turns state transitions and signals into notifications and
+// hands them to its sinks.
+type Center struct {
+	sinks   []*sink
+	states  map[string]bool
+	signals bool
+	now     func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]seen
+	history  []*Entry
+	sending  sync.WaitGroup
+}
+
+type seen struct {
+	state string
+	since time.Time
+}
+
+// New builds a center from the [notify] config, reporting every problem
+// with it. It has no sinks, and sends nothing, when none are configured.
+func New(cfg config.NotifyConfig) (*Center, error) {
+	c := &Center{
+		states:   make(map[string]bool),
+		signals:  cfg.Signals,
+		now:      time.Now,
+		sessions: make(map[string]seen),
+	}
+	var errs []error
+	for _, st := range cfg.States {
+		if !validState(st, false) {
+			errs = append(errs, fmt.Errorf("unknown state %q", st))
+		}
+		c.states[st] = true
+	}
+	names := map[string]bool{}
+	for i, sc := range cfg.Sinks {
+		label := sc.Name
+		if label == "" {
+			label = "sink " + strconv.Itoa(i+1)
+		}
+		s, sinkErrs := compileSink(sc)
+		for _, err := range sinkErrs {
+			errs = append(errs, fmt.Errorf("%s: %w", label, err))
+		}
+		if s.Sink != nil {
+			if names[s.Name()] {
+				errs = append(errs, fmt.Errorf("%s: duplicate sink name %q", label, s.Name()))
+			}
+			names[s.Name()] = true
+		}
+		c.sinks = append(c.sinks, s)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return c, nil
+}
+
+// Enabled reports whether any sink is configured.
+func (c *Center) Enabled() bool {
+	return c != nil && len(c.sinks) > 0
+}
+
+// WantsSignals reports whether critical signals should be fed to Signal.
+func (c *Center) WantsSignals() bool {
+	return c.Enabled() && c.signals
+}
+
+// AttachTerminal gives bell sinks the terminal to ring, nil to detach.
+func (c *Center) AttachTerminal(w io.Writer) {
+	if c == nil {
+		return
+	}
+	for _, s := range c.sinks {
+		if b, ok := s.Sink.(*Bell); ok {
+			b.Attach(w)
+		}
+	}
+}
+
+// Observe records an agent session's detected state, notifying when it
+// enters a watched state. The first state seen of a session only sets the
+// baseline, so restarting Bigend does not repeat old news.
+func (c *Center) Observe(session, agent, project, state string, at time.Time) {
+	if !c.Enabled() {
+		return
+	}
+	c.mu.Lock()
+	prev, known := c.sessions[session]
+	if known && prev.state == state {
+		c.mu.Unlock()
+		return
+	}
+	c.sessions[session] = seen{state: state, since: at}
+	c.mu.Unlock()
+	if !known || !c.states[state] {
+		return
+	}
+
+	body := fmt.Sprintf("%s agent", orDefault(agent, "An"))
+	if project != "" {
+		body += " in " + project
+	}
+	if prev.state != "" {
+		body += fmt.Sprintf(", %s for %s before", prev.state, at.Sub(prev.since).Round(time.Second))
+	}
+	c.Notify(Notification{
+		Kind:     KindState,
+		Title:    fmt.Sprintf("%s is %s", session, state),
+		Body:     body,
+		Severity: stateSeverity(state),
+		State:    state,
+		Previous: prev.state,
+		Session:  session,
+		Agent:    agent,
+		Project:  project,
+		At:       at,
+	})
+}
+
+func stateSeverity(state string) string {
+	switch statedetect.AgentState(state) {
+	case statedetect.StateError:
+		return string(signals.SeverityCritical)
+	case statedetect.StateBlocked, statedetect.StateStalled:
+		return string(signals.SeverityWarning)
+	default:
+		return string(signals.SeverityInfo)
+	}
+}
+
+// Retain forgets sessions not in names.
+func (c *Center) Retain(names []string) {
+	if !c.Enabled() {
+		return
+	}
+	keep := make(map[string]bool, len(names))
+	for _, n := range names {
+		keep[n] = true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name := range c.sessions {
+		if !keep[name] {
+			delete(c.sessions, name)
+		}
+	}
+}
+
+// Signal notifies of a critical signal; others are ignored.
+func (c *Center) Signal(sig signals.Signal) {
+	if !c.WantsSignals() || sig.Severity != signals.SeverityCritical || sig.Dismissed {
+		return
+	}
+	at := sig.CreatedAt
+	if at.IsZero() {
+		at = c.now()
+	}
+	c.Notify(Notification{
+		Kind:     KindSignal,
+		Title:    sig.Title,
+		Body:     orDefault(sig.Detail, string(sig.Type)),
+		Severity: string(sig.Severity),
+		State:    StateSignal,
+		Session:  sig.AffectedField,
+		Source:   sig.Source,
+		At:       at,
+	})
+}
+
+// Test sends a test notification to every sink, bypassing routes, quiet
+// hours and rate limits.
+func (c *Center) Test() {
+	if !c.Enabled() {
+		return
+	}
+	n := Notification{
+		Kind:     KindTest,
+		Title:    "Bigend test notification",
+		Body:     "Notifications reach this sink.",
+		Severity: string(signals.SeverityInfo),
+		At:       c.now(),
+	}
+	c.deliver(n, func(*sink) string { return "" })
+}
+
+// Notify routes a notification to the sinks that take it. Sends happen in
+// the background; Wait waits for them.
+func (c *Center) Notify(n Notification) {
+	if !c.Enabled() {
+		return
+	}
+	now := c.now()
+	c.deliver(n, func(s *sink) string {
+		if !s.routed(n) {
+			return "-"
+		}
+		if s.quiet.contains(now) {
+			return ResultQuiet
+		}
+		if !s.allow(now) {
+			return ResultRateLimited
+		}
+		return ""
+	})
+}
+
+// deliver sends n to each sink that gate lets through ("") and records
+// the rest with the result gate gave; "-" skips the sink entirely.
+func (c *Center) deliver(n Notification, gate func(*sink) string) {
+	entry := &Entry{Notification: n}
+	type send struct {
+		sink *sink
+		i    int // its outcome
+	}
+	var sends []send
+	for _, s := range c.sinks {
+		if b, ok := s.Sink.(*Bell); ok && !b.attached() {
+			continue
+		}
+		result := gate(s)
+		if result == "-" {
+			continue
+		}
+		if result == "" {
+			result = ResultPending
+			sends = append(sends, send{s, len(entry.Outcomes)})
+		}
+		entry.Outcomes = append(entry.Outcomes, Outcome{Sink: s.Name(), Result: result})
+	}
+	if len(entry.Outcomes) == 0 {
+		return
+	}
+	c.mu.Lock()
+	c.history = append(c.history, entry)
+	if extra := len(c.history) - historySize; extra > 0 {
+		c.history = c.history[extra:]
+	}
+	c.mu.Unlock()
+
+	for _, snd := range sends {
+		s, i := snd.sink, snd.i
+		c.sending.Add(1)
+		go func() {
+			defer c.sending.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			err := s.Send(ctx, n)
+			c.mu.Lock()
+			if err != nil {
+				entry.Outcomes[i].Result, entry.Outcomes[i].Error = ResultFailed, err.Error()
+			} else {
+				entry.Outcomes[i].Result = ResultSent
+			}
+			c.mu.Unlock()
+			if err != nil {
+				slog.Warn("notification failed", "sink", s.Name(), "title", n.Title, "error", err)
+			}
+		}()
+	}
+}
+
+// Wait waits for notifications being sent.
+func (c *Center) Wait() {
+	if c != nil {
+		c.sending.Wait()
+	}
+}
+
+// History returns recent notifications, newest first.
+func (c *Center) History() []Entry {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Entry, len(c.history))
+	for i, e := range c.history {
+		clone := *e
+		clone.Outcomes = append([]Outcome(nil), e.Outcomes...)
+		out[len(out)-1-i] = clone
+	}
+	return out
+}
+
+// Sinks describes the configured sinks.
+func (c *Center) Sinks() []SinkInfo {
+	if c == nil {
+		return nil
+	}
+	now := c.now()
+	out := make([]SinkInfo, 0, len(c.sinks))
+	for _, s := range c.sinks {
+		info := SinkInfo{Name: s.Name(), Type: s.kind, Routes: len(s.routes), Quiet: s.quiet.contains(now), RateLimit: s.limit}
+		if s.quiet != nil {
+			info.QuietHours = s.quiet.spec
+		}
+		if s.limit > 0 {
+			info.RateWindow = s.window
+		}
+		out = append(out, info)
+	}
+	return out
+}
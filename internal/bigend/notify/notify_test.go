@@ -0,0 +1,306 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/pkg/signals"
+)
+
+// recordSink remembers what it was sent.
+type recordSink struct {
+	name string
+
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (r *recordSink) Name() string { return r.name }
+
+func (r *recordSink) Send(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func (r *recordSink) titles() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for _, n := range r.sent {
+		out = append(out, n.Title)
+	}
+	sort.Strings(out) // sends are concurrent
+	return out
+}
+
+func (r *recordSink) find(title string) Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range r.sent {
+		if n.Title == title {
+			return n
+		}
+	}
+	return Notification{}
+}
+
+// testCenter builds a center from cfg and swaps each sink for a recorder.
+func testCenter(t *testing.T, cfg config.NotifyConfig, now time.Time) (*Center, map[string]*recordSink) {
+	t.Helper()
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.now = func() time.Time { return now }
+	recs := map[string]*recordSink{}
+	for _, s := range c.sinks {
+		rec := &recordSink{name: s.Name()}
+		recs[s.Name()] = rec
+		s.Sink = rec
+	}
+	return c, recs
+}
+
+func TestCenterRoutesStateTransitions(t *testing.T) {
+	noon := time.Date(2026, 1, 5, 12, 0, 0, 0, time.Local)
+	c, recs := testCenter(t, config.NotifyConfig{
+		States: []string{"blocked", "error"},
+		Sinks: []config.NotifySinkConfig{
+			{Name: "all", Type: TypeDesktop},
+			{Name: "api-errors", Type: TypeDesktop, Routes: []config.NotifyRouteConfig{
+				{Projects: []string{"/srv/*/api"}, States: []string{"error"}},
+			}},
+			{Name: "not-scratch", Type: TypeDesktop, Routes: []config.NotifyRouteConfig{
+				{Projects: []string{"scratch*"}, Mute: true},
+				{},
+			}},
+		},
+	}, noon)
+
+	c.Observe("api-claude", "claude", "/srv/x/api", "working", noon)
+	c.Observe("api-claude", "claude", "/srv/x/api", "working", noon.Add(time.Minute))
+	c.Observe("api-claude", "claude", "/srv/x/api", "error", noon.Add(3*time.Minute))
+	c.Observe("api-claude", "claude", "/srv/x/api", "error", noon.Add(4*time.Minute))
+	c.Observe("scratch-codex", "codex", "/srv/scratch1", "working", noon)
+	c.Observe("scratch-codex", "codex", "/srv/scratch1", "blocked", noon)
+	c.Observe("scratch-codex", "codex", "/srv/scratch1", "waiting", noon) // not watched
+	// A session first seen blocked is only a baseline
+	c.Observe("web-claude", "claude", "/srv/web", "blocked", noon)
+	c.Wait()
+
+	if got := strings.Join(recs["all"].titles(), ","); got != "api-claude is error,scratch-codex is blocked" {
+		t.Errorf("all got %s", got)
+	}
+	if got := strings.Join(recs["api-errors"].titles(), ","); got != "api-claude is error" {
+		t.Errorf("api-errors got %s", got)
+	}
+	if got := strings.Join(recs["not-scratch"].titles(), ","); got != "api-claude is error" {
+		t.Errorf("not-scratch got %s", got)
+	}
+
+	n := recs["all"].find("api-claude is error")
+	if n.Severity != "critical" || n.Previous != "working" || n.Body != "claude agent in /srv/x/api, working for 3m0s before" {
+		t.Errorf("unexpected notification %+v", n)
+	}
+	history := c.History()
+	if len(history) != 2 || history[0].Title != "scratch-codex is blocked" {
+		t.Fatalf("unexpected history %+v", history)
+	}
+	if len(history[1].Outcomes) != 3 || history[1].Outcomes[1] != (Outcome{Sink: "api-errors", Result: ResultSent}) {
+		t.Errorf("unexpected outcomes %+v", history[1].Outcomes)
+	}
+}
+
+func TestCenterQuietHoursAndRateLimit(t *testing.T) {
+	now := time.Date(2026, 1, 5, 23, 30, 0, 0, time.Local)
+	c, recs := testCenter(t, config.NotifyConfig{
+		Sinks: []config.NotifySinkConfig{
+			{Name: "night-off", Type: TypeDesktop, QuietHours: "22:00-07:00"},
+			{Name: "two-a-minute", Type: TypeDesktop, RateLimit: 2},
+		},
+	}, now)
+	for i := 0; i < 3; i++ {
+		c.Notify(Notification{Title: "n" + string(rune('1'+i)), State: "error"})
+	}
+	c.Wait()
+	if len(recs["night-off"].sent) != 0 {
+		t.Errorf("sent during quiet hours: %v", recs["night-off"].titles())
+	}
+	if got := strings.Join(recs["two-a-minute"].titles(), ","); got != "n1,n2" {
+		t.Errorf("rate limited sink got %s", got)
+	}
+	if o := c.History()[0].Outcomes; o[0].Result != ResultQuiet || o[1].Result != ResultRateLimited {
+		t.Errorf("unexpected outcomes %+v", o)
+	}
+
+	// A minute on, the limit has room again; morning ends the quiet hours
+	c.now = func() time.Time { return now.Add(8 * time.Hour) }
+	c.Notify(Notification{Title: "n4", State: "error"})
+	c.Wait()
+	if len(recs["night-off"].sent) != 1 || len(recs["two-a-minute"].sent) != 3 {
+		t.Errorf("after the window: %v, %v", recs["night-off"].titles(), recs["two-a-minute"].titles())
+	}
+
+	// Test notifications ignore both
+	c.now = func() time.Time { return now }
+	c.Test()
+	c.Wait()
+	if len(recs["night-off"].sent) != 2 {
+		t.Error("test notification held back by quiet hours")
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	q, err := parseQuietHours("09:30-17:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 5, h, m, 0, 0, time.UTC) }
+	if q.contains(at(9, 29)) || !q.contains(at(9, 30)) || !q.contains(at(16, 59)) || q.contains(at(17, 0)) {
+		t.Error("day span wrong")
+	}
+	for _, bad := range []string{"9", "25:00-01:00", "08:00-08:00"} {
+		if _, err := parseQuietHours(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestCenterSignals(t *testing.T) {
+	c, recs := testCenter(t, config.NotifyConfig{
+		Signals: true,
+		Sinks: []config.NotifySinkConfig{
+			{Name: "signals-only", Type: TypeDesktop, Routes: []config.NotifyRouteConfig{{States: []string{StateSignal}}}},
+		},
+	}, time.Now())
+	c.Signal(signals.Signal{Type: signals.SignalSpecHealthLow, Source: "gurgeh", Severity: signals.SeverityWarning, Title: "meh"})
+	c.Signal(signals.Signal{Type: signals.SignalExecutionDrift, Source: "coldwine", Severity: signals.SeverityCritical, Title: "drift"})
+	c.Wait()
+	sent := recs["signals-only"].sent
+	if len(sent) != 1 || sent[0].Title != "drift" || sent[0].Body != "execution_drift" || sent[0].Source != "coldwine" {
+		t.Errorf("unexpected signal notifications %+v", sent)
+	}
+
+	off, recs := testCenter(t, config.NotifyConfig{Sinks: []config.NotifySinkConfig{{Type: TypeDesktop}}}, time.Now())
+	off.Signal(signals.Signal{Severity: signals.SeverityCritical, Title: "drift"})
+	off.Wait()
+	if len(recs["desktop"].sent) != 0 {
+		t.Error("signal notified with signals off")
+	}
+}
+
+func TestNewReportsConfigErrors(t *testing.T) {
+	_, err := New(config.NotifyConfig{
+		States: []string{"sleepy"},
+		Sinks: []config.NotifySinkConfig{
+			{Type: "pager"},
+			{Name: "hook", Type: TypeWebhook, URL: "https://example.com/hook"},
+			{Name: "d", Type: TypeDesktop, QuietHours: "late", RateLimit: -1,
+				Routes: []config.NotifyRouteConfig{{States: []string{"idle"}, Projects: []string{"["}}}},
+			{Name: "d", Type: TypeDesktop},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{`unknown state "sleepy"`, `sink 1: unknown type "pager"`, "hook: webhook url", "non-loopback",
+		`d: quiet hours "late"`, `d: route 1: unknown state "idle"`, "bad project pattern", "cannot be negative", `duplicate sink name "d"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+
+	c, err := New(config.NotifyConfig{States: []string{"error"}})
+	if err != nil || c.Enabled() {
+		t.Errorf("no sinks: %v, enabled %v", err, c.Enabled())
+	}
+}
+
+func TestDesktopArgs(t *testing.T) {
+	d := NewDesktop("", "")
+	var got []string
+	d.run = func(ctx context.Context, name string, args ...string) error {
+		got = append([]string{name}, args...)
+		return nil
+	}
+	if err := d.Send(context.Background(), Notification{Title: "--icon=/tmp/x is error", Body: "claude agent", Severity: "critical"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "|") != "notify-send|--app-name=Bigend|--urgency=critical|--|--icon=/tmp/x is error|claude agent" {
+		t.Errorf("ran %q", got)
+	}
+}
+
+func TestBell(t *testing.T) {
+	b := NewBell("", true)
+	if err := b.Send(context.Background(), Notification{Title: "x"}); err != ErrNoTerminal {
+		t.Errorf("unattached bell: %v", err)
+	}
+	var term bytes.Buffer
+	b.Attach(&term)
+	if err := b.Send(context.Background(), Notification{Title: "api\x1b]0;evil", Body: "is\nerror"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := term.String(); got != "\a\x1b]9;api ]0;evil: is error\a" {
+		t.Errorf("wrote %q", got)
+	}
+
+	// Without a terminal, bells are left out of deliveries
+	c, err := New(config.NotifyConfig{Sinks: []config.NotifySinkConfig{{Type: TypeBell}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Notify(Notification{Title: "x"})
+	if len(c.History()) != 0 {
+		t.Error("unattached bell recorded")
+	}
+	c.AttachTerminal(&term)
+	c.Notify(Notification{Title: "x"})
+	c.Wait()
+	if h := c.History(); len(h) != 1 || h[0].Outcomes[0].Result != ResultSent {
+		t.Errorf("unexpected history %+v", h)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	got := make(chan Notification, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&n) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		got <- n
+		if n.Title == "fail" {
+			http.Error(w, "nope", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(config.NotifyConfig{Sinks: []config.NotifySinkConfig{{Name: "hook", Type: TypeWebhook, URL: srv.URL + "/bigend"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Notify(Notification{Kind: KindState, Title: "api is blocked", State: "blocked", Session: "api"})
+	c.Wait()
+	if n := <-got; n.Title != "api is blocked" || n.Session != "api" || n.State != "blocked" {
+		t.Errorf("posted %+v", n)
+	}
+	c.Notify(Notification{Title: "fail"})
+	c.Wait()
+	<-got
+	if o := c.History()[0].Outcomes[0]; o.Result != ResultFailed || !strings.Contains(o.Error, "500") {
+		t.Errorf("unexpected outcome %+v", o)
+	}
+}
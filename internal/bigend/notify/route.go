@@ -0,0 +1,210 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/statedetect"
+)
+
+// StateSignal is the state routes use for critical signals.
+const StateSignal = "signal"
+
+// DefaultRateWindow applies to sinks with a rate limit but no window.
+const DefaultRateWindow = time.Minute
+
+// sink is a configured Sink with its routing, quiet hours and rate limit.
+type sink struct {
+	Sink
+	kind   string
+	routes []config.NotifyRouteConfig
+	quiet  *quietHours
+	limit  int
+	window time.Duration
+
+	mu   sync.Mutex
+	sent []time.Time // within the window
+}
+
+// routed reports whether the sink takes n: the first matching route
+// decides, and without routes every notification goes through.
+func (s *sink) routed(n Notification) bool {
+	if len(s.routes) == 0 {
+		return true
+	}
+	for _, r := range s.routes {
+		if matchProject(r.Projects, n.Project) && matchState(r.States, n.State) {
+			return !r.Mute
+		}
+	}
+	return false
+}
+
+func matchProject(patterns []string, project string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	if project == "" {
+		return false
+	}
+	for _, p := range patterns {
+		target := filepath.Base(project)
+		if strings.Contains(p, "/") {
+			target = project
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchState(states []string, state string) bool {
+	if len(states) == 0 {
+		return true
+	}
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// allow takes a slot of the rate limit at now, reporting whether one was
+// free.
+func (s *sink) allow(now time.Time) bool {
+	if s.limit <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.sent[:0]
+	for _, t := range s.sent {
+		if now.Sub(t) < s.window {
+			kept = append(kept, t)
+		}
+	}
+	s.sent = kept
+	if len(s.sent) >= s.limit {
+		return false
+	}
+	s.sent = append(s.sent, now)
+	return true
+}
+
+// quietHours is a daily span of local time, which may wrap past midnight.
+type quietHours struct {
+	start, end time.Duration // since midnight
+	spec       string
+}
+
+func parseQuietHours(spec string) (*quietHours, error) {
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, fmt.Errorf("quiet hours %q: want HH:MM-HH:MM", spec)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours %q: %w", spec, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours %q: %w", spec, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("quiet hours %q: empty span", spec)
+	}
+	return &quietHours{start: start, end: end, spec: spec}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether t falls in the quiet hours, in t's location.
+func (q *quietHours) contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if q.start < q.end {
+		return clock >= q.start && clock < q.end
+	}
+	return clock >= q.start || clock < q.end
+}
+
+var knownStates = []string{
+	string(statedetect.StateWorking), string(statedetect.StateWaiting), string(statedetect.StateBlocked),
+	string(statedetect.StateStalled), string(statedetect.StateDone), string(statedetect.StateError),
+	string(statedetect.StateUnknown),
+}
+
+func validState(s string, signal bool) bool {
+	if signal && s == StateSignal {
+		return true
+	}
+	for _, known := range knownStates {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// compileSink validates a sink config and builds its sink. Every problem
+// is reported.
+func compileSink(c config.NotifySinkConfig) (*sink, []error) {
+	var errs []error
+	s := &sink{kind: c.Type, routes: c.Routes, limit: c.RateLimit, window: c.RateWindow}
+	switch c.Type {
+	case TypeDesktop:
+		s.Sink = NewDesktop(c.Name, c.Command)
+	case TypeBell:
+		s.Sink = NewBell(c.Name, c.OSC9)
+	case TypeWebhook:
+		if hook, err := NewWebhook(c.Name, c.URL); err != nil {
+			errs = append(errs, err)
+		} else {
+			s.Sink = hook
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown type %q", c.Type))
+	}
+	for i, r := range c.Routes {
+		for _, p := range r.Projects {
+			if _, err := path.Match(p, ""); err != nil {
+				errs = append(errs, fmt.Errorf("route %d: bad project pattern %q: %w", i+1, p, err))
+			}
+		}
+		for _, st := range r.States {
+			if !validState(st, true) {
+				errs = append(errs, fmt.Errorf("route %d: unknown state %q", i+1, st))
+			}
+		}
+	}
+	if c.QuietHours != "" {
+		q, err := parseQuietHours(c.QuietHours)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		s.quiet = q
+	}
+	if c.RateLimit < 0 || c.RateWindow < 0 {
+		errs = append(errs, errors.New("rate_limit and rate_window cannot be negative"))
+	}
+	if s.window == 0 {
+		s.window = DefaultRateWindow
+	}
+	return s, errs
+}
@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistakeknot/autarch/pkg/netguard"
+	"github.com/mistakeknot/autarch/pkg/signals"
+)
+
+// Sink types.
+const (
+	TypeDesktop = "desktop"
+	TypeBell    = "bell"
+	TypeWebhook = "webhook"
+)
+
+// Sink delivers notifications somewhere.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// ErrNoTerminal is returned by a bell with no terminal to ring, as when
+// Bigend serves the web UI or runs as a daemon.
+var ErrNoTerminal = errors.New("no terminal attached")
+
+// Desktop shows notifications with notify-send, or a command taking the
+// same arguments.
+type Desktop struct {
+	name    string
+	command string
+	run     func(ctx context.Context, name string, args ...string) error
+}
+
+// NewDesktop returns a desktop sink running command, notify-send when
+// empty.
+func NewDesktop(name, command string) *Desktop {
+	if command == "" {
+		command = "notify-send"
+	}
+	return &Desktop{name: orDefault(name, TypeDesktop), command: command, run: runCommand}
+}
+
+func runCommand(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *Desktop) Name() string { return d.name }
+
+// Send shows the notification. Titles and bodies come from session names
+// and agent output, so "--" keeps one starting with "-" from being read
+// as an option.
+func (d *Desktop) Send(ctx context.Context, n Notification) error {
+	return d.run(ctx, d.command, "--app-name=Bigend", "--urgency="+urgency(n.Severity), "--", n.Title, n.Body)
+}
+
+// urgency maps a severity to a notify-send urgency level.
+func urgency(severity string) string {
+	switch signals.Severity(severity) {
+	case signals.SeverityCritical:
+		return "critical"
+	case signals.SeverityWarning:
+		return "normal"
+	default:
+		return "low"
+	}
+}
+
+// Bell rings the terminal a TUI runs in and, with OSC 9, asks the
+// terminal to show a desktop notification too.
+type Bell struct {
+	name string
+	osc9 bool
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewBell returns a bell sink; it rings once a terminal is attached.
+func NewBell(name string, osc9 bool) *Bell {
+	return &Bell{name: orDefault(name, TypeBell), osc9: osc9}
+}
+
+// Attach sets the terminal to ring, nil to detach it.
+func (b *Bell) Attach(w io.Writer) {
+	b.mu.Lock()
+	b.w = w
+	b.mu.Unlock()
+}
+
+func (b *Bell) attached() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.w != nil
+}
+
+func (b *Bell) Name() string { return b.name }
+
+func (b *Bell) Send(ctx context.Context, n Notification) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.w == nil {
+		return ErrNoTerminal
+	}
+	seq := "\a"
+	if b.osc9 {
+		seq += "\x1b]9;" + oscText(n.Title+": "+n.Body) + "\a"
+	}
+	// One write, so it cannot land inside a frame the TUI is drawing
+	_, err := io.WriteString(b.w, seq)
+	return err
+}
+
+// oscText makes text safe inside an OSC sequence: no control characters,
+// and short enough for terminals that cap it.
+func oscText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+	if r := []rune(s); len(r) > 200 {
+		s = string(r[:200])
+	}
+	return s
+}
+
+// Webhook posts notifications as JSON to a local endpoint.
+type Webhook struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+// NewWebhook returns a webhook sink posting to rawURL, which must be an
+// http or https URL on loopback.
+func NewWebhook(name, rawURL string) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook url %q: want http://127.0.0.1:<port>/<path>", rawURL)
+	}
+	if err := netguard.EnsureLocalOnly(u.Host); err != nil {
+		return nil, fmt.Errorf("webhook url: %w", err)
+	}
+	return &Webhook{
+		name:   orDefault(name, TypeWebhook),
+		url:    rawURL,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+func (h *Webhook) Name() string { return h.name }
+
+func (h *Webhook) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bigend-notify")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,325 @@
+// Package recording keeps what agent panes showed. Each session is written
+// as an asciicast v2 file: pane output as timestamped frames and detected
+// state transitions as markers, so a session can be replayed after the
+// agent has cleared its screen.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event codes used in recordings.
+const (
+	EventOutput = "o"
+	EventMarker = "m"
+)
+
+// clearScreen starts every output frame: frames are full redraws of the
+// captured pane, so any frame can be shown without replaying earlier ones.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// statePrefix labels markers that record a detected state transition.
+const statePrefix = "state:"
+
+// Header is the first line of an asciicast v2 file.
+type Header struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// Start is when the recording began.
+func (h Header) Start() time.Time {
+	return time.Unix(h.Timestamp, 0)
+}
+
+// Event is one line after the header: seconds since the start, an event
+// code and its data.
+type Event struct {
+	Time float64
+	Code string
+	Data string
+}
+
+// MarshalJSON encodes the event as asciicast's [time, code, data] array.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time, e.Code, e.Data})
+}
+
+// UnmarshalJSON decodes a [time, code, data] array.
+func (e *Event) UnmarshalJSON(b []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("event has %d fields, want 3", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &e.Time); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw[1], &e.Code); err != nil {
+		return err
+	}
+	return json.Unmarshal(raw[2], &e.Data)
+}
+
+// State returns the agent state a marker records, or "" for other events.
+func (e Event) State() string {
+	if e.Code != EventMarker {
+		return ""
+	}
+	return strings.TrimPrefix(e.Data, statePrefix)
+}
+
+// Screen returns the pane text an output frame shows.
+func (e Event) Screen() string {
+	return screenText(e.Data)
+}
+
+// Frame encodes captured pane content as a full-screen redraw.
+func Frame(content string) string {
+	content = strings.TrimRight(content, "\n")
+	return clearScreen + strings.ReplaceAll(content, "\n", "\r\n")
+}
+
+func screenText(data string) string {
+	data = strings.TrimPrefix(data, clearScreen)
+	return stripANSI(strings.ReplaceAll(data, "\r\n", "\n"))
+}
+
+// stripANSI drops CSI and OSC escape sequences.
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != 0x1b || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '[':
+			j := i + 2
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			i = j
+		case ']':
+			j := i + 2
+			for j < len(s) && s[j] != 0x07 && !(s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\') {
+				j++
+			}
+			if j < len(s) && s[j] == 0x1b {
+				j++
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return b.String()
+}
+
+// Cast is a loaded recording.
+type Cast struct {
+	Header Header
+	Events []Event
+}
+
+// Load reads a recording from disk.
+func Load(path string) (*Cast, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// Decode reads a recording. A truncated last line, left by a recorder that
+// was killed mid-write, is ignored.
+func Decode(r io.Reader) (*Cast, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("empty recording")
+	}
+	c := &Cast{}
+	if err := json.Unmarshal(sc.Bytes(), &c.Header); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	if c.Header.Version != 2 {
+		return nil, fmt.Errorf("unsupported asciicast version %d", c.Header.Version)
+	}
+	var pending error
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if pending != nil {
+			return nil, pending
+		}
+		var ev Event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			pending = fmt.Errorf("invalid event after %.3fs: %w", c.Duration(), err)
+			continue
+		}
+		c.Events = append(c.Events, ev)
+	}
+	return c, sc.Err()
+}
+
+// Duration is the time of the last event.
+func (c *Cast) Duration() float64 {
+	if len(c.Events) == 0 {
+		return 0
+	}
+	return c.Events[len(c.Events)-1].Time
+}
+
+// Frames returns the output events in order.
+func (c *Cast) Frames() []Event {
+	var out []Event
+	for _, ev := range c.Events {
+		if ev.Code == EventOutput {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// Markers returns the marker events in order.
+func (c *Cast) Markers() []Event {
+	var out []Event
+	for _, ev := range c.Events {
+		if ev.Code == EventMarker {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// FrameAt returns the last frame shown at or before t seconds, and false
+// when nothing had been captured yet.
+func (c *Cast) FrameAt(t float64) (Event, bool) {
+	frames := c.Frames()
+	i := sort.Search(len(frames), func(i int) bool { return frames[i].Time > t })
+	if i == 0 {
+		return Event{}, false
+	}
+	return frames[i-1], true
+}
+
+// StateAt returns the last state recorded at or before t seconds.
+func (c *Cast) StateAt(t float64) string {
+	state := ""
+	for _, m := range c.Markers() {
+		if m.Time > t {
+			break
+		}
+		state = m.State()
+	}
+	return state
+}
+
+// Match is a frame whose screen contains a search query.
+type Match struct {
+	Time float64 `json:"time"`
+	Line string  `json:"line"`
+}
+
+// Search returns, in order, the frames where query appears on screen,
+// case-insensitively. A frame matches only when the text was not already
+// on the previous frame, so a line that stays on screen is found once.
+func (c *Cast) Search(query string) []Match {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+	var out []Match
+	prev := false
+	for _, f := range c.Frames() {
+		screen := f.Screen()
+		lower := strings.ToLower(screen)
+		hit := strings.Contains(lower, q)
+		if hit && !prev {
+			out = append(out, Match{Time: f.Time, Line: matchLine(screen, lower, q)})
+		}
+		prev = hit
+	}
+	return out
+}
+
+func matchLine(screen, lower, q string) string {
+	i := strings.Index(lower, q)
+	start := strings.LastIndex(screen[:i], "\n") + 1
+	end := strings.Index(screen[i:], "\n")
+	if end < 0 {
+		return strings.TrimSpace(screen[start:])
+	}
+	return strings.TrimSpace(screen[start : i+end])
+}
+
+// Writer appends events to an asciicast stream.
+type Writer struct {
+	w     io.Writer
+	start time.Time
+}
+
+// NewWriter writes the header and returns a writer whose event times are
+// measured from start.
+func NewWriter(w io.Writer, h Header, start time.Time) (*Writer, error) {
+	h.Version = 2
+	h.Timestamp = start.Unix()
+	b, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(b, '\n')); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, start: start}, nil
+}
+
+// Output records a frame of pane content.
+func (w *Writer) Output(at time.Time, content string) error {
+	return w.write(Event{Time: w.offset(at), Code: EventOutput, Data: Frame(content)})
+}
+
+// State records a detected state transition.
+func (w *Writer) State(at time.Time, state string) error {
+	return w.write(Event{Time: w.offset(at), Code: EventMarker, Data: statePrefix + state})
+}
+
+func (w *Writer) offset(at time.Time) float64 {
+	d := at.Sub(w.start)
+	if d < 0 {
+		d = 0
+	}
+	return float64(d.Milliseconds()) / 1000
+}
+
+func (w *Writer) write(ev Event) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,327 @@
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	stampLayout = "20060102-150405"
+	castExt     = ".cast"
+)
+
+// Default terminal size for a recording opened before any output.
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// pruneEvery is the least time between two prunes by Retain.
+const pruneEvery = time.Minute
+
+// Options limit how much a Recorder keeps. Zero fields are unlimited.
+type Options struct {
+	// Retention drops recordings started longer ago than this.
+	Retention time.Duration
+	// MaxBytes drops the oldest recordings while the directory holds more.
+	MaxBytes int64
+}
+
+// Recorder writes one recording per session into a directory. A session's
+// recording stays open while the session is seen and a new one starts the
+// next time it appears after Retain dropped it. Recordings hold whatever the
+// agents printed, so they are readable by the owner only.
+type Recorder struct {
+	dir  string
+	opts Options
+
+	mu         sync.Mutex
+	sessions   map[string]*track
+	lastPruned time.Time
+}
+
+type track struct {
+	file  *os.File
+	w     *Writer
+	last  string
+	state string
+}
+
+// NewRecorder returns a recorder writing into dir that keeps everything.
+func NewRecorder(dir string) *Recorder {
+	return NewRecorderWithOptions(dir, Options{})
+}
+
+// NewRecorderWithOptions returns a recorder writing into dir that prunes
+// old recordings as opts allow.
+func NewRecorderWithOptions(dir string, opts Options) *Recorder {
+	return &Recorder{dir: dir, opts: opts, sessions: make(map[string]*track)}
+}
+
+// Dir is where recordings are written.
+func (r *Recorder) Dir() string {
+	return r.dir
+}
+
+// Capture records the pane content of a session if it changed since the
+// last capture.
+func (r *Recorder) Capture(session, content string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, err := r.open(session, content, at)
+	if err != nil {
+		return err
+	}
+	if content == t.last {
+		return nil
+	}
+	t.last = content
+	return t.w.Output(at, content)
+}
+
+// Mark records a session's detected state if it differs from the last one
+// recorded.
+func (r *Recorder) Mark(session, state string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, err := r.open(session, "", at)
+	if err != nil {
+		return err
+	}
+	if state == t.state {
+		return nil
+	}
+	t.state = state
+	return t.w.State(at, state)
+}
+
+// Retain closes the recordings of sessions not in names, then prunes old
+// recordings at most once every pruneEvery.
+func (r *Recorder) Retain(names []string) {
+	keep := make(map[string]bool, len(names))
+	for _, n := range names {
+		keep[n] = true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, t := range r.sessions {
+		if !keep[name] {
+			t.file.Close()
+			delete(r.sessions, name)
+		}
+	}
+	if now := time.Now(); now.Sub(r.lastPruned) >= pruneEvery {
+		r.lastPruned = now
+		if _, err := r.prune(now); err != nil {
+			slog.Warn("failed to prune recordings", "dir", r.dir, "error", err)
+		}
+	}
+}
+
+// Prune removes the recordings that Options no longer allow, oldest first,
+// and returns their names. Open recordings are never removed.
+func (r *Recorder) Prune(now time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.prune(now)
+}
+
+func (r *Recorder) prune(now time.Time) ([]string, error) {
+	if r.opts.Retention <= 0 && r.opts.MaxBytes <= 0 {
+		return nil, nil
+	}
+	list, err := List(r.dir, "")
+	if err != nil {
+		return nil, err
+	}
+	open := make(map[string]bool, len(r.sessions))
+	for _, t := range r.sessions {
+		open[filepath.Base(t.file.Name())] = true
+	}
+	var total int64
+	for _, info := range list {
+		total += info.Size
+	}
+	var removed []string
+	var errs []error
+	// List is newest first.
+	for i := len(list) - 1; i >= 0; i-- {
+		info := list[i]
+		expired := r.opts.Retention > 0 && now.Sub(info.Started) > r.opts.Retention
+		over := r.opts.MaxBytes > 0 && total > r.opts.MaxBytes
+		if !expired && !over {
+			break
+		}
+		if open[info.Name] {
+			continue
+		}
+		if err := os.Remove(info.Path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		total -= info.Size
+		removed = append(removed, info.Name)
+	}
+	return removed, errors.Join(errs...)
+}
+
+// Close closes every open recording.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for name, t := range r.sessions {
+		errs = append(errs, t.file.Close())
+		delete(r.sessions, name)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Recorder) open(session, content string, at time.Time) (*track, error) {
+	if t, ok := r.sessions[session]; ok {
+		return t, nil
+	}
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := createCast(r.dir, session, at)
+	if err != nil {
+		return nil, err
+	}
+	width, height := screenSize(content)
+	w, err := NewWriter(f, Header{Width: width, Height: height, Title: session}, at)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	t := &track{file: f, w: w}
+	r.sessions[session] = t
+	return t, nil
+}
+
+// createCast creates a new recording file, moving the stamp forward if a
+// recording of the session already started in the same second.
+func createCast(dir, session string, at time.Time) (*os.File, error) {
+	for i := 0; ; i++ {
+		name := fileName(session, at.Add(time.Duration(i)*time.Second))
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil || !errors.Is(err, fs.ErrExist) || i == 9 {
+			return f, err
+		}
+	}
+}
+
+func screenSize(content string) (int, int) {
+	width, height := defaultWidth, defaultHeight
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) > height {
+		height = len(lines)
+	}
+	for _, l := range lines {
+		if n := len([]rune(l)); n > width {
+			width = n
+		}
+	}
+	return width, height
+}
+
+// fileName is "<session>-YYYYMMDD-HHMMSS.cast", with characters that do
+// not belong in a file name replaced.
+func fileName(session string, at time.Time) string {
+	return safeName(session) + "-" + at.Format(stampLayout) + castExt
+}
+
+func safeName(session string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, session)
+}
+
+// Info describes a recording on disk.
+type Info struct {
+	Session string    `json:"session"`
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Started time.Time `json:"started"`
+	Size    int64     `json:"size"`
+}
+
+func parseName(name string) (string, time.Time, bool) {
+	base, ok := strings.CutSuffix(name, castExt)
+	if !ok || len(base) < len(stampLayout)+2 {
+		return "", time.Time{}, false
+	}
+	cut := len(base) - len(stampLayout)
+	if base[cut-1] != '-' {
+		return "", time.Time{}, false
+	}
+	at, err := time.ParseInLocation(stampLayout, base[cut:], time.Local)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return base[:cut-1], at, true
+}
+
+// List returns the recordings in dir, newest first: all of them, or those
+// of one session when session is not empty.
+func List(dir, session string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Info
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name, started, ok := parseName(e.Name())
+		if !ok || (session != "" && name != safeName(session)) {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, Info{Session: name, Name: e.Name(), Path: filepath.Join(dir, e.Name()), Started: started, Size: fi.Size()})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Started.Equal(out[j].Started) {
+			return out[i].Started.After(out[j].Started)
+		}
+		return out[i].Name > out[j].Name
+	})
+	return out, nil
+}
+
+// Resolve finds a recording from a command-line argument: a path to a
+// .cast file, or a session name whose latest recording in dir is used.
+func Resolve(dir, arg string) (string, error) {
+	if strings.HasSuffix(arg, castExt) {
+		if _, err := os.Stat(arg); err == nil {
+			return arg, nil
+		}
+	}
+	list, err := List(dir, arg)
+	if err != nil {
+		return "", err
+	}
+	if len(list) == 0 {
+		return "", fmt.Errorf("no recordings of %q in %s", arg, dir)
+	}
+	return list[0].Path, nil
+}
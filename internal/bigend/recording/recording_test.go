@@ -0,0 +1,174 @@
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorderWritesReplayableCast(t *testing.T) {
+	dir := t.TempDir()
+	rec := NewRecorder(dir)
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.Local)
+	at := func(s float64) time.Time { return start.Add(time.Duration(s * float64(time.Second))) }
+
+	steps := []func() error{
+		func() error { return rec.Capture("claude/api", "$ go test ./...\n", at(0)) },
+		func() error { return rec.Mark("claude/api", "working", at(0)) },
+		func() error { return rec.Capture("claude/api", "$ go test ./...\n", at(1)) }, // unchanged, skipped
+		func() error { return rec.Capture("claude/api", "$ go test ./...\nFAIL pkg/db\n", at(2)) },
+		func() error { return rec.Mark("claude/api", "working", at(2)) }, // unchanged, skipped
+		func() error { return rec.Capture("claude/api", "", at(3.5)) },   // agent cleared the screen
+		func() error { return rec.Mark("claude/api", "waiting", at(4)) },
+		func() error { return rec.Capture("claude/api", "fixed; FAIL gone\n", at(5)) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := List(dir, "claude/api")
+	if err != nil || len(list) != 1 {
+		t.Fatalf("expected one recording, got %+v (%v)", list, err)
+	}
+	if list[0].Name != "claude_api-20260304-100000.cast" || list[0].Session != "claude_api" {
+		t.Fatalf("unexpected recording %+v", list[0])
+	}
+	path, err := Resolve(dir, "claude/api")
+	if err != nil || path != list[0].Path {
+		t.Fatalf("resolve: %q, %v", path, err)
+	}
+
+	cast, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cast.Header.Version != 2 || cast.Header.Title != "claude/api" || cast.Header.Width != 80 {
+		t.Fatalf("unexpected header %+v", cast.Header)
+	}
+	if n := len(cast.Frames()); n != 4 {
+		t.Fatalf("expected 4 frames, got %d", n)
+	}
+	if cast.Duration() != 5 {
+		t.Fatalf("expected 5s, got %v", cast.Duration())
+	}
+
+	frame, ok := cast.FrameAt(3)
+	if !ok || frame.Screen() != "$ go test ./...\nFAIL pkg/db" {
+		t.Fatalf("unexpected frame at 3s: %q", frame.Screen())
+	}
+	if frame, _ := cast.FrameAt(4); frame.Screen() != "" {
+		t.Fatalf("expected the cleared screen at 4s, got %q", frame.Screen())
+	}
+	if _, ok := cast.FrameAt(-1); ok {
+		t.Fatal("expected no frame before the start")
+	}
+
+	markers := cast.Markers()
+	if len(markers) != 2 || markers[0].State() != "working" || markers[1].State() != "waiting" || markers[1].Time != 4 {
+		t.Fatalf("unexpected markers %+v", markers)
+	}
+	if s := cast.StateAt(4.5); s != "waiting" {
+		t.Fatalf("expected waiting at 4.5s, got %q", s)
+	}
+
+	matches := cast.Search("fail")
+	if len(matches) != 2 || matches[0].Time != 2 || matches[0].Line != "FAIL pkg/db" || matches[1].Time != 5 {
+		t.Fatalf("unexpected matches %+v", matches)
+	}
+}
+
+func TestRecorderStartsNewFileAfterRetainDropsSession(t *testing.T) {
+	dir := t.TempDir()
+	rec := NewRecorder(dir)
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.Local)
+	if err := rec.Capture("a", "one", now); err != nil {
+		t.Fatal(err)
+	}
+	rec.Retain(nil)
+	if err := rec.Capture("a", "two", now); err != nil {
+		t.Fatal(err)
+	}
+	rec.Close()
+	list, _ := List(dir, "a")
+	if len(list) != 2 || list[0].Name != "a-20260304-100001.cast" {
+		t.Fatalf("expected a second recording a second later, got %+v", list)
+	}
+}
+
+func TestDecodeIgnoresTruncatedTail(t *testing.T) {
+	data := `{"version":2,"width":80,"height":24,"timestamp":0}
+[0.5,"o","\u001b[H\u001b[2Jhi \u001b[1mthere\u001b[0m"]
+[1.0,"o","\u001b[H`
+	cast, err := Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cast.Events) != 1 || cast.Events[0].Screen() != "hi there" {
+		t.Fatalf("unexpected events %+v", cast.Events)
+	}
+
+	bad := strings.Replace(data, `[1.0,"o","\u001b[H`, "oops\n[1.0,\"o\",\"x\"]", 1)
+	if _, err := Decode(strings.NewReader(bad)); err == nil {
+		t.Fatal("expected a corrupt line in the middle to fail")
+	}
+}
+
+func TestResolveAcceptsPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "elsewhere.cast")
+	if err := os.WriteFile(path, []byte(`{"version":2,"width":80,"height":24,"timestamp":0}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Resolve(t.TempDir(), path)
+	if err != nil || got != path {
+		t.Fatalf("resolve: %q, %v", got, err)
+	}
+	if _, err := Resolve(t.TempDir(), "nobody"); err == nil {
+		t.Fatal("expected an error for a session without recordings")
+	}
+}
+
+func TestPruneDropsExpiredAndOversizedRecordings(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 10, 0, 0, 0, time.Local)
+	rec := NewRecorderWithOptions(dir, Options{Retention: 48 * time.Hour})
+	for i, at := range []time.Time{now.Add(-96 * time.Hour), now.Add(-3 * time.Hour), now.Add(-2 * time.Hour)} {
+		name := fileName("old", at)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("x", 150+i)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Capture("live", strings.Repeat("y", 200), now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+
+	info, err := os.Stat(filepath.Join(dir, fileName("live", now.Add(-time.Hour))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected recordings readable by the owner only, got %v", info.Mode().Perm())
+	}
+	// Room for the open recording and the newest closed one only.
+	rec.opts.MaxBytes = info.Size() + 160
+
+	removed, err := rec.Prune(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{fileName("old", now.Add(-96*time.Hour)), fileName("old", now.Add(-3*time.Hour))}
+	if strings.Join(removed, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v removed, got %v", want, removed)
+	}
+	list, _ := List(dir, "")
+	if len(list) != 2 || list[0].Session != "live" {
+		t.Fatalf("expected the open recording and the newest closed one kept, got %+v", list)
+	}
+}
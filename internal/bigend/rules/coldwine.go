@@ -0,0 +1,75 @@
+package rules
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/mailbus"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+// Sender is the mail sender for rule actions.
+const Sender = "bigend-rules"
+
+// ErrNoColdwine is returned for projects without Coldwine state.
+var ErrNoColdwine = errors.New("project has no Coldwine state")
+
+// Coldwine looks up tasks and sends mail in a session's project.
+type Coldwine struct{}
+
+func openColdwine(root string) (*sql.DB, error) {
+	path := project.StateDBPath(root)
+	if _, err := os.Stat(path); err != nil {
+		return nil, ErrNoColdwine
+	}
+	db, err := storage.OpenShared(path)
+	if err != nil {
+		return nil, err
+	}
+	mailbus.Attach(db, project.MailBusDir(root))
+	return db, nil
+}
+
+// TaskStatus finds the task whose agent runs in the session: the one the
+// Coldwine session of that name belongs to, or the task the session is
+// named after.
+func (Coldwine) TaskStatus(s Session) (string, string, error) {
+	db, err := openColdwine(s.ProjectPath)
+	if err != nil {
+		return "", "", err
+	}
+	var taskID string
+	err = db.QueryRow(`SELECT task_id FROM sessions WHERE id = ?`, s.Name).Scan(&taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		taskID = strings.TrimPrefix(s.Name, agent.SessionID(""))
+	} else if err != nil {
+		return "", "", err
+	}
+	task, err := storage.GetTask(db, taskID)
+	if err != nil {
+		return "", "", fmt.Errorf("no task for session %s: %w", s.Name, err)
+	}
+	return task.ID, task.Status, nil
+}
+
+// Mail sends a Coldwine message in the project.
+func (Coldwine) Mail(projectPath string, m Mail) error {
+	db, err := openColdwine(projectPath)
+	if err != nil {
+		return err
+	}
+	return storage.SendMessage(db, storage.Message{
+		ID:         fmt.Sprintf("msg-%d", time.Now().UTC().UnixNano()),
+		ThreadID:   m.Thread,
+		Sender:     Sender,
+		Subject:    m.Subject,
+		Body:       m.Body,
+		Importance: "urgent",
+	}, m.To)
+}
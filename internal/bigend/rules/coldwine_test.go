@@ -0,0 +1,52 @@
+package rules
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+func TestColdwineTaskStatusAndMail(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".tandemonium"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.OpenShared(project.StateDBPath(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range []storage.Task{{ID: "TAND-001", Title: "a", Status: "review"}, {ID: "TAND-002", Title: "b", Status: "in_progress"}} {
+		if err := storage.InsertTask(db, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := storage.InsertSession(db, storage.Session{ID: "claude-api", TaskID: "TAND-002", State: "working"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cw := Coldwine{}
+	if id, status, err := cw.TaskStatus(Session{Name: "tand-TAND-001", ProjectPath: root}); err != nil || id != "TAND-001" || status != "review" {
+		t.Fatalf("by session name: %q %q %v", id, status, err)
+	}
+	if id, status, err := cw.TaskStatus(Session{Name: "claude-api", ProjectPath: root}); err != nil || id != "TAND-002" || status != "in_progress" {
+		t.Fatalf("by Coldwine session: %q %q %v", id, status, err)
+	}
+	if _, _, err := cw.TaskStatus(Session{Name: "x", ProjectPath: t.TempDir()}); !errors.Is(err, ErrNoColdwine) {
+		t.Fatalf("expected ErrNoColdwine, got %v", err)
+	}
+
+	if err := cw.Mail(root, Mail{To: []string{"reviewer"}, Subject: "TAND-001 waiting", Body: "see pane", Thread: "rule-x"}); err != nil {
+		t.Fatal(err)
+	}
+	inbox, err := storage.FetchInbox(db, "reviewer", 10)
+	if err != nil || len(inbox) != 1 || inbox[0].Message.Sender != Sender || inbox[0].Message.ThreadID != "rule-x" {
+		t.Fatalf("unexpected inbox %+v (%v)", inbox, err)
+	}
+}
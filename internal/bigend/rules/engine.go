@@ -0,0 +1,401 @@
+package rules
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+	"github.com/mistakeknot/autarch/pkg/signals"
+)
+
+// Session is an agent session as rules see it.
+type Session struct {
+	Name        string
+	AgentType   string
+	ProjectPath string
+}
+
+// Mail is a Coldwine message sent by a rule.
+type Mail struct {
+	To      []string
+	Subject string
+	Body    string
+	Thread  string
+}
+
+// Executor carries out actions.
+type Executor interface {
+	SendKeys(session string, keys ...string) error
+	Restart(s Session) error
+	Dispose(session string) error
+	Mail(projectPath string, m Mail) error
+	Signal(sig signals.Signal) error
+}
+
+// TaskLookup finds the Coldwine task an agent session works on.
+type TaskLookup interface {
+	TaskStatus(s Session) (taskID, status string, err error)
+}
+
+// Firing is a rule that matched, with the actions it took or, in a dry
+// run, would have taken.
+type Firing struct {
+	Rule    string    `json:"rule"`
+	Session string    `json:"session"`
+	State   string    `json:"state"`
+	At      time.Time `json:"at"`
+	DryRun  bool      `json:"dry_run,omitempty"`
+	Actions []string  `json:"actions"`
+	Errors  []string  `json:"errors,omitempty"`
+}
+
+const historySize = 100
+
+// Text for mail and signals whose action does not set it.
+const (
+	defaultSubject = "{session} {state} for {duration}"
+	defaultBody    = "Rule {rule}: {session} ({agent}) has been {state} for {duration}."
+)
+
+// Engine tracks each session's state over time and fires rules.
+type Engine struct {
+	rules  []*Rule
+	exec   Executor
+	tasks  TaskLookup
+	dryRun bool
+	quiet  bool // no logging, for simulations
+
+	mu       sync.Mutex
+	sessions map[string]*track
+	history  []Firing
+}
+
+type track struct {
+	state   string
+	since   time.Time
+	entries map[string][]time.Time // when each state was entered
+	fired   map[string]firedAt     // by rule name
+}
+
+type firedAt struct {
+	at      time.Time
+	episode time.Time // the since of the stay the rule fired in
+}
+
+// NewEngine returns an engine running rules through exec. A nil exec, or
+// dryRun, only reports what would be done.
+func NewEngine(rules []*Rule, exec Executor, tasks TaskLookup, dryRun bool) *Engine {
+	return &Engine{
+		rules:    rules,
+		exec:     exec,
+		tasks:    tasks,
+		dryRun:   dryRun || exec == nil,
+		sessions: make(map[string]*track),
+	}
+}
+
+// Rules returns the engine's rules.
+func (e *Engine) Rules() []*Rule {
+	return e.rules
+}
+
+// History returns recent firings, newest first.
+func (e *Engine) History() []Firing {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Firing, len(e.history))
+	for i, f := range e.history {
+		out[len(out)-1-i] = f
+	}
+	return out
+}
+
+// Retain drops the tracked state of sessions not in names.
+func (e *Engine) Retain(names []string) {
+	keep := make(map[string]bool, len(names))
+	for _, n := range names {
+		keep[n] = true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for name := range e.sessions {
+		if !keep[name] {
+			delete(e.sessions, name)
+		}
+	}
+}
+
+// Observe records a session's detected state at a point in time and runs
+// the rules that now match. Observations must arrive in time order.
+func (e *Engine) Observe(s Session, state string, at time.Time) []Firing {
+	e.mu.Lock()
+	t, ok := e.sessions[s.Name]
+	if !ok {
+		t = &track{entries: map[string][]time.Time{}, fired: map[string]firedAt{}}
+		e.sessions[s.Name] = t
+	}
+	if !ok || t.state != state {
+		t.state, t.since = state, at
+		t.entries[state] = append(t.entries[state], at)
+	}
+	var due []pending
+	for _, r := range e.rules {
+		if p, ok := e.due(r, s, t, at); ok {
+			due = append(due, p)
+			t.fired[r.Name] = firedAt{at: at, episode: t.since}
+		}
+	}
+	e.mu.Unlock()
+
+	var out []Firing
+	for _, p := range due {
+		v := p.vars
+		if p.rule.TaskStatus == "" && e.tasks != nil && s.ProjectPath != "" {
+			// Only for the placeholders; rules with a task status
+			// looked it up when they matched.
+			v.task, v.taskStatus, _ = e.tasks.TaskStatus(s)
+		}
+		out = append(out, e.run(p.rule, s, v, at))
+	}
+	if len(out) > 0 {
+		e.mu.Lock()
+		e.history = append(e.history, out...)
+		if n := len(e.history) - historySize; n > 0 {
+			e.history = e.history[n:]
+		}
+		e.mu.Unlock()
+	}
+	return out
+}
+
+type pending struct {
+	rule *Rule
+	vars vars
+}
+
+// due checks a rule's trigger against a session's tracked state. It is
+// called with e.mu held; the task lookup it may do reads another database
+// but never calls back into the engine.
+func (e *Engine) due(r *Rule, s Session, t *track, at time.Time) (pending, bool) {
+	if t.state != r.State || !r.Matches(s) {
+		return pending{}, false
+	}
+	stay := at.Sub(t.since)
+	if stay < r.For {
+		return pending{}, false
+	}
+	last, fired := t.fired[r.Name]
+	if fired && (last.episode.Equal(t.since) || at.Sub(last.at) < r.Cooldown) {
+		return pending{}, false
+	}
+	count := 0
+	for _, entered := range t.entries[r.State] {
+		if fired && !entered.After(last.at) {
+			continue // counted towards the previous firing
+		}
+		if r.Within > 0 && at.Sub(entered) > r.Within {
+			continue
+		}
+		count++
+	}
+	if count < r.Count {
+		return pending{}, false
+	}
+	p := pending{rule: r, vars: vars{rule: r.Name, session: s, state: t.state, duration: stay, count: count}}
+	if r.TaskStatus != "" {
+		if e.tasks == nil || s.ProjectPath == "" {
+			return pending{}, false
+		}
+		task, status, err := e.tasks.TaskStatus(s)
+		if err != nil || status != r.TaskStatus {
+			return pending{}, false
+		}
+		p.vars.task, p.vars.taskStatus = task, status
+	}
+	return p, true
+}
+
+func (e *Engine) run(r *Rule, s Session, v vars, at time.Time) Firing {
+	f := Firing{Rule: r.Name, Session: s.Name, State: v.state, At: at, DryRun: e.dryRun}
+	for _, a := range r.Actions {
+		f.Actions = append(f.Actions, describe(a, v.expand))
+		if e.dryRun {
+			continue
+		}
+		if err := e.do(a, s, v, at); err != nil {
+			f.Errors = append(f.Errors, fmt.Sprintf("%s: %v", a.Type, err))
+		}
+	}
+	if e.quiet {
+		return f
+	}
+	if e.dryRun {
+		slog.Info("rule matched (dry run)", "rule", r.Name, "session", s.Name, "state", v.state, "actions", f.Actions)
+	} else if len(f.Errors) > 0 {
+		slog.Warn("rule fired with errors", "rule", r.Name, "session", s.Name, "errors", f.Errors)
+	} else {
+		slog.Info("rule fired", "rule", r.Name, "session", s.Name, "state", v.state, "actions", f.Actions)
+	}
+	return f
+}
+
+func (e *Engine) do(a config.ActionConfig, s Session, v vars, at time.Time) error {
+	switch a.Type {
+	case ActionNudge:
+		text := a.Text
+		if text == "" {
+			text = DefaultNudge
+		}
+		return e.exec.SendKeys(s.Name, v.expand(text), "Enter")
+	case ActionSendKeys:
+		var keys []string
+		if a.Text != "" {
+			keys = append(keys, v.expand(a.Text))
+		}
+		if a.Keys != "" {
+			keys = append(keys, a.Keys)
+		}
+		return e.exec.SendKeys(s.Name, keys...)
+	case ActionRestart:
+		return e.exec.Restart(s)
+	case ActionDispose:
+		return e.exec.Dispose(s.Name)
+	case ActionMail:
+		if s.ProjectPath == "" {
+			return fmt.Errorf("session has no project")
+		}
+		return e.exec.Mail(s.ProjectPath, Mail{
+			To:      a.To,
+			Subject: v.expand(orDefault(a.Subject, defaultSubject)),
+			Body:    v.expand(orDefault(a.Body, defaultBody)),
+			Thread:  "rule-" + v.rule + "-" + s.Name,
+		})
+	case ActionSignal:
+		severity := signals.Severity(a.Severity)
+		if severity == "" {
+			severity = signals.SeverityWarning
+		}
+		return e.exec.Signal(signals.Signal{
+			ID:            fmt.Sprintf("bigend-rule-%d", at.UnixNano()),
+			Type:          signals.SignalAgentState,
+			Source:        "bigend",
+			SpecID:        v.task,
+			AffectedField: s.Name,
+			Severity:      severity,
+			Title:         v.expand(orDefault(a.Subject, defaultSubject)),
+			Detail:        v.expand(orDefault(a.Body, defaultBody)),
+			CreatedAt:     at,
+		})
+	}
+	return fmt.Errorf("unknown action %q", a.Type)
+}
+
+// describe renders an action for logs and dry-run output, filling
+// placeholders with expand.
+func describe(a config.ActionConfig, expand func(string) string) string {
+	switch a.Type {
+	case ActionNudge:
+		return fmt.Sprintf("nudge %q", expand(orDefault(a.Text, DefaultNudge)))
+	case ActionSendKeys:
+		return fmt.Sprintf("send-keys %q %s", expand(a.Text), a.Keys)
+	case ActionMail:
+		return fmt.Sprintf("mail %v %q", a.To, expand(orDefault(a.Subject, defaultSubject)))
+	case ActionSignal:
+		return fmt.Sprintf("signal %s %q", orDefault(a.Severity, string(signals.SeverityWarning)), expand(orDefault(a.Subject, defaultSubject)))
+	}
+	return a.Type
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// Transition is a state change at a point in time.
+type Transition struct {
+	State string
+	At    time.Time
+}
+
+// Simulate replays a session's state transitions, such as the markers of
+// a recording, through e and returns what fired. Each stay is also checked
+// when its rules' For durations elapse, and the last one until end.
+func Simulate(e *Engine, s Session, transitions []Transition, end time.Time) []Firing {
+	var out []Firing
+	for i, tr := range transitions {
+		until := end
+		if i+1 < len(transitions) {
+			until = transitions[i+1].At
+		}
+		out = append(out, e.Observe(s, tr.State, tr.At)...)
+		checks := map[time.Time]bool{}
+		for _, r := range e.rules {
+			if r.State == tr.State && r.For > 0 && tr.At.Add(r.For).Before(until) {
+				checks[tr.At.Add(r.For)] = true
+			}
+		}
+		for _, at := range sortedTimes(checks) {
+			out = append(out, e.Observe(s, tr.State, at)...)
+		}
+	}
+	return out
+}
+
+func sortedTimes(set map[time.Time]bool) []time.Time {
+	out := make([]time.Time, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// SimulateRecording dry-runs rules against the state changes marked in a
+// recording of session s.
+func SimulateRecording(rules []*Rule, tasks TaskLookup, s Session, cast *recording.Cast) []Firing {
+	start := cast.Header.Start()
+	offset := func(t float64) time.Time { return start.Add(time.Duration(t * float64(time.Second))) }
+	var transitions []Transition
+	for _, m := range cast.Markers() {
+		if state := m.State(); state != "" {
+			transitions = append(transitions, Transition{State: state, At: offset(m.Time)})
+		}
+	}
+	e := NewEngine(rules, nil, tasks, true)
+	e.quiet = true
+	return Simulate(e, s, transitions, offset(cast.Duration()))
+}
+
+// WriteRules lists rules with their triggers and actions.
+func WriteRules(w io.Writer, rules []*Rule) {
+	for _, r := range rules {
+		fmt.Fprintf(w, "%s: %s\n", r.Name, r.Describe())
+		for _, a := range r.Actions {
+			fmt.Fprintf(w, "  - %s\n", describe(a, func(s string) string { return s }))
+		}
+	}
+}
+
+// WriteFirings lists firings, one per line with its actions below.
+func WriteFirings(w io.Writer, firings []Firing) {
+	for _, f := range firings {
+		mode := ""
+		if f.DryRun {
+			mode = " (dry run)"
+		}
+		fmt.Fprintf(w, "%s  %s  %s  %s%s\n", f.At.Local().Format("2006-01-02 15:04:05"), f.Rule, f.Session, f.State, mode)
+		for _, a := range f.Actions {
+			fmt.Fprintf(w, "  - %s\n", a)
+		}
+		for _, e := range f.Errors {
+			fmt.Fprintf(w, "  ! %s\n", e)
+		}
+	}
+}
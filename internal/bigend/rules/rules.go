@@ -0,0 +1,198 @@
+// Package rules runs declarative automation on agent state transitions.
+// Rules come from the [automation] section of the Bigend config; each
+// names the sessions it watches, the state and how long or how often it
+// must hold, and the actions to take: nudge, restart, dispose, send-keys,
+// Coldwine mail or a published signal.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/statedetect"
+	"github.com/mistakeknot/autarch/pkg/signals"
+)
+
+// Action types.
+const (
+	ActionNudge    = "nudge"
+	ActionRestart  = "restart"
+	ActionDispose  = "dispose"
+	ActionSendKeys = "send-keys"
+	ActionMail     = "mail"
+	ActionSignal   = "signal"
+)
+
+// DefaultNudge is typed by a nudge action without text.
+const DefaultNudge = "continue"
+
+var states = []statedetect.AgentState{
+	statedetect.StateWorking, statedetect.StateWaiting, statedetect.StateBlocked,
+	statedetect.StateStalled, statedetect.StateDone, statedetect.StateError, statedetect.StateUnknown,
+}
+
+// Rule is a validated rule.
+type Rule struct {
+	config.RuleConfig
+}
+
+// Compile validates rule configs. Every problem is reported, prefixed with
+// the rule's name or position.
+func Compile(cfgs []config.RuleConfig) ([]*Rule, error) {
+	var (
+		out  []*Rule
+		errs []error
+		seen = map[string]bool{}
+	)
+	for i, c := range cfgs {
+		label := c.Name
+		if label == "" {
+			label = "rule " + strconv.Itoa(i+1)
+		}
+		if seen[c.Name] && c.Name != "" {
+			errs = append(errs, fmt.Errorf("%s: duplicate rule name", label))
+		}
+		seen[c.Name] = true
+		if c.Name == "" {
+			c.Name = label
+		}
+		for _, err := range validate(c) {
+			errs = append(errs, fmt.Errorf("%s: %w", label, err))
+		}
+		out = append(out, &Rule{RuleConfig: c})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+func validate(c config.RuleConfig) []error {
+	var errs []error
+	if !knownState(c.State) {
+		errs = append(errs, fmt.Errorf("unknown state %q", c.State))
+	}
+	if c.Session != "" {
+		if _, err := path.Match(c.Session, ""); err != nil {
+			errs = append(errs, fmt.Errorf("bad session pattern %q: %w", c.Session, err))
+		}
+	}
+	if c.For < 0 || c.Within < 0 || c.Cooldown < 0 || c.Count < 0 {
+		errs = append(errs, errors.New("for, within, cooldown and count cannot be negative"))
+	}
+	if c.Within > 0 && c.Count == 0 {
+		errs = append(errs, errors.New("within needs a count"))
+	}
+	if len(c.Actions) == 0 {
+		errs = append(errs, errors.New("no actions"))
+	}
+	for i, a := range c.Actions {
+		if err := validateAction(a); err != nil {
+			errs = append(errs, fmt.Errorf("action %d: %w", i+1, err))
+		}
+	}
+	return errs
+}
+
+func knownState(s string) bool {
+	for _, st := range states {
+		if string(st) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func validateAction(a config.ActionConfig) error {
+	switch a.Type {
+	case ActionNudge, ActionRestart, ActionDispose:
+	case ActionSendKeys:
+		if a.Keys == "" && a.Text == "" {
+			return errors.New("send-keys needs keys or text")
+		}
+	case ActionMail:
+		if len(a.To) == 0 {
+			return errors.New("mail needs recipients")
+		}
+	case ActionSignal:
+		switch signals.Severity(a.Severity) {
+		case "", signals.SeverityInfo, signals.SeverityWarning, signals.SeverityCritical:
+		default:
+			return fmt.Errorf("unknown severity %q", a.Severity)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", a.Type)
+	}
+	return nil
+}
+
+// Matches reports whether the rule watches a session.
+func (r *Rule) Matches(s Session) bool {
+	if s.AgentType == "" {
+		return false
+	}
+	if r.Agent != "" && r.Agent != s.AgentType {
+		return false
+	}
+	if r.Session != "" {
+		if ok, _ := path.Match(r.Session, s.Name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Describe summarises the rule's trigger.
+func (r *Rule) Describe() string {
+	var b strings.Builder
+	b.WriteString("state " + r.State)
+	if r.For > 0 {
+		b.WriteString(" for " + r.For.String())
+	}
+	if r.Count > 0 {
+		fmt.Fprintf(&b, ", entered %d times", r.Count)
+		if r.Within > 0 {
+			b.WriteString(" within " + r.Within.String())
+		}
+	}
+	if r.TaskStatus != "" {
+		b.WriteString(", task " + r.TaskStatus)
+	}
+	if r.Agent != "" {
+		b.WriteString(", agent " + r.Agent)
+	}
+	if r.Session != "" {
+		b.WriteString(", session " + r.Session)
+	}
+	return b.String()
+}
+
+// vars fills placeholders in action text.
+type vars struct {
+	rule       string
+	session    Session
+	state      string
+	duration   time.Duration
+	count      int
+	task       string
+	taskStatus string
+}
+
+func (v vars) expand(s string) string {
+	return strings.NewReplacer(
+		"{rule}", v.rule,
+		"{session}", v.session.Name,
+		"{agent}", v.session.AgentType,
+		"{state}", v.state,
+		"{duration}", v.duration.Round(time.Second).String(),
+		"{count}", strconv.Itoa(v.count),
+		"{project}", v.session.ProjectPath,
+		"{task}", v.task,
+		"{task_status}", v.taskStatus,
+	).Replace(s)
+}
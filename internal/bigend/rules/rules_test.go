@@ -0,0 +1,233 @@
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+	"github.com/mistakeknot/autarch/pkg/signals"
+)
+
+type fakeExec struct {
+	calls []string
+	mail  []Mail
+	sigs  []signals.Signal
+}
+
+func (f *fakeExec) SendKeys(session string, keys ...string) error {
+	f.calls = append(f.calls, "keys "+session+" "+strings.Join(keys, "|"))
+	return nil
+}
+func (f *fakeExec) Restart(s Session) error {
+	f.calls = append(f.calls, "restart "+s.Name)
+	return nil
+}
+func (f *fakeExec) Dispose(session string) error {
+	f.calls = append(f.calls, "dispose "+session)
+	return nil
+}
+func (f *fakeExec) Mail(projectPath string, m Mail) error {
+	f.calls = append(f.calls, "mail "+projectPath)
+	f.mail = append(f.mail, m)
+	return nil
+}
+func (f *fakeExec) Signal(sig signals.Signal) error {
+	f.calls = append(f.calls, "signal "+string(sig.Severity))
+	f.sigs = append(f.sigs, sig)
+	return nil
+}
+
+type fakeTasks map[string]string
+
+func (f fakeTasks) TaskStatus(s Session) (string, string, error) {
+	status, ok := f[s.Name]
+	if !ok {
+		return "", "", errors.New("no task")
+	}
+	return "TAND-001", status, nil
+}
+
+func mustCompile(t *testing.T, cfgs ...config.RuleConfig) []*Rule {
+	t.Helper()
+	rules, err := Compile(cfgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+var (
+	api = Session{Name: "tand-TAND-001", AgentType: "claude", ProjectPath: "/p/api"}
+	t0  = time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+)
+
+func TestWaitingInReviewMailsReviewer(t *testing.T) {
+	rules := mustCompile(t, config.RuleConfig{
+		Name:       "review-stuck",
+		Agent:      "claude",
+		State:      "waiting",
+		For:        5 * time.Minute,
+		TaskStatus: "review",
+		Actions: []config.ActionConfig{{
+			Type:    ActionMail,
+			To:      []string{"reviewer"},
+			Subject: "{task} waiting on review",
+		}},
+	})
+	exec := &fakeExec{}
+	tasks := fakeTasks{api.Name: "in_progress"}
+	e := NewEngine(rules, exec, tasks, false)
+
+	e.Observe(api, "waiting", t0)
+	if f := e.Observe(api, "waiting", t0.Add(6*time.Minute)); len(f) != 0 {
+		t.Fatalf("expected no firing while the task is in progress, got %+v", f)
+	}
+	tasks[api.Name] = "review"
+	if f := e.Observe(api, "waiting", t0.Add(4*time.Minute)); len(f) != 0 {
+		t.Fatal("fired before the for duration")
+	}
+	fired := e.Observe(api, "waiting", t0.Add(7*time.Minute))
+	if len(fired) != 1 || len(exec.mail) != 1 {
+		t.Fatalf("expected one mail, got %+v / %+v", fired, exec.calls)
+	}
+	m := exec.mail[0]
+	if m.To[0] != "reviewer" || m.Subject != "TAND-001 waiting on review" || !strings.Contains(m.Body, "waiting for 7m0s") {
+		t.Fatalf("unexpected mail %+v", m)
+	}
+	if f := e.Observe(api, "waiting", t0.Add(20*time.Minute)); len(f) != 0 {
+		t.Fatal("fired twice in one stay")
+	}
+
+	// Another stay fires again.
+	e.Observe(api, "working", t0.Add(21*time.Minute))
+	e.Observe(api, "waiting", t0.Add(22*time.Minute))
+	if f := e.Observe(api, "waiting", t0.Add(28*time.Minute)); len(f) != 1 {
+		t.Fatalf("expected a second firing, got %+v", f)
+	}
+	if h := e.History(); len(h) != 2 || !h[0].At.Equal(t0.Add(28*time.Minute)) {
+		t.Fatalf("unexpected history %+v", h)
+	}
+}
+
+func TestRepeatedErrorsRestartAndSignal(t *testing.T) {
+	rules := mustCompile(t, config.RuleConfig{
+		Name:    "error-loop",
+		State:   "error",
+		Count:   3,
+		Within:  time.Hour,
+		Actions: []config.ActionConfig{{Type: ActionRestart}, {Type: ActionSignal, Severity: "critical"}},
+	})
+	exec := &fakeExec{}
+	e := NewEngine(rules, exec, nil, false)
+
+	at := t0
+	errorOnce := func() []Firing {
+		e.Observe(api, "working", at)
+		at = at.Add(time.Minute)
+		f := e.Observe(api, "error", at)
+		at = at.Add(time.Minute)
+		return f
+	}
+	errorOnce()
+	errorOnce()
+	if f := errorOnce(); len(f) != 1 {
+		t.Fatalf("expected the third error to fire, got %+v", f)
+	}
+	if strings.Join(exec.calls, ",") != "restart tand-TAND-001,signal critical" {
+		t.Fatalf("unexpected calls %v", exec.calls)
+	}
+	if sig := exec.sigs[0]; sig.Type != signals.SignalAgentState || sig.Source != "bigend" || sig.AffectedField != api.Name {
+		t.Fatalf("unexpected signal %+v", sig)
+	}
+	if f := errorOnce(); len(f) != 0 {
+		t.Fatal("errors before the last firing counted again")
+	}
+	errorOnce()
+	if f := errorOnce(); len(f) != 1 {
+		t.Fatalf("expected three more errors to fire again, got %+v", f)
+	}
+
+	// Errors spread wider than the window do not add up.
+	e = NewEngine(rules, exec, nil, false)
+	for i := 0; i < 3; i++ {
+		at = at.Add(40 * time.Minute)
+		if f := errorOnce(); len(f) != 0 {
+			t.Fatalf("error %d fired outside the window", i+1)
+		}
+	}
+}
+
+func TestCompileReportsEveryProblem(t *testing.T) {
+	_, err := Compile([]config.RuleConfig{
+		{Name: "a", State: "sleeping", Actions: []config.ActionConfig{{Type: ActionNudge}}},
+		{Name: "a", State: "waiting", Session: "[", Actions: []config.ActionConfig{{Type: "page"}}},
+		{State: "error", Within: time.Minute, Actions: []config.ActionConfig{{Type: ActionMail}, {Type: ActionSignal, Severity: "loud"}}},
+	})
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		`a: unknown state "sleeping"`,
+		"a: duplicate rule name",
+		"bad session pattern",
+		`action 1: unknown action "page"`,
+		"rule 3: within needs a count",
+		"rule 3: action 1: mail needs recipients",
+		`rule 3: action 2: unknown severity "loud"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestSimulateDryRun(t *testing.T) {
+	rules := mustCompile(t,
+		config.RuleConfig{Name: "stalled", Session: "tand-*", State: "stalled", For: 2 * time.Minute,
+			Actions: []config.ActionConfig{{Type: ActionNudge, Text: "keep going on {task}"}}},
+		config.RuleConfig{Name: "codex-only", Agent: "codex", State: "stalled",
+			Actions: []config.ActionConfig{{Type: ActionDispose}}},
+	)
+	e := NewEngine(rules, nil, fakeTasks{api.Name: "in_progress"}, false)
+	fired := Simulate(e, api, []Transition{
+		{State: "working", At: t0},
+		{State: "stalled", At: t0.Add(time.Minute)},
+		{State: "working", At: t0.Add(2 * time.Minute)}, // recovered before 2m
+		{State: "stalled", At: t0.Add(5 * time.Minute)},
+	}, t0.Add(10*time.Minute))
+	if len(fired) != 1 {
+		t.Fatalf("expected one firing, got %+v", fired)
+	}
+	f := fired[0]
+	if !f.DryRun || f.Rule != "stalled" || !f.At.Equal(t0.Add(7*time.Minute)) || f.Actions[0] != `nudge "keep going on TAND-001"` {
+		t.Fatalf("unexpected firing %+v", f)
+	}
+}
+
+func TestSimulateRecording(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := recording.NewWriter(&buf, recording.Header{Title: "claude-api"}, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []struct {
+		state string
+		at    time.Duration
+	}{{"error", 0}, {"working", time.Minute}, {"error", 2 * time.Minute}, {"working", 3 * time.Minute}, {"error", 4 * time.Minute}} {
+		_ = w.State(t0.Add(m.at), m.state)
+	}
+	cast, err := recording.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := mustCompile(t, config.RuleConfig{Name: "error-loop", State: "error", Count: 3,
+		Actions: []config.ActionConfig{{Type: ActionRestart}}})
+	fired := SimulateRecording(rules, nil, Session{Name: "claude-api", AgentType: "claude"}, cast)
+	if len(fired) != 1 || !fired[0].At.Equal(t0.Add(4*time.Minute)) || !fired[0].DryRun {
+		t.Fatalf("unexpected firings %+v", fired)
+	}
+}
@@ -0,0 +1,64 @@
+package share
+
+import "sync"
+
+// Viewer is one connection to a shared session.
+type Viewer struct {
+	ID      string `json:"id"`    // unique per connection
+	Actor   string `json:"actor"` // token label, or "owner"
+	TokenID string `json:"token_id,omitempty"`
+	Scope   Scope  `json:"scope"`
+}
+
+// CanType reports whether the viewer's scope allows input.
+func (v Viewer) CanType() bool {
+	return v.Scope == ScopeInteract
+}
+
+// Control tracks which viewer holds each session's control lease. Only
+// the holder's keystrokes reach the pane; taking control hands it over.
+type Control struct {
+	mu      sync.Mutex
+	holders map[string]Viewer
+}
+
+// NewControl returns an empty lease table.
+func NewControl() *Control {
+	return &Control{holders: map[string]Viewer{}}
+}
+
+// Take gives v control of session and returns the previous holder, if
+// any. Observers cannot take control.
+func (c *Control) Take(session string, v Viewer) (Viewer, bool) {
+	if !v.CanType() {
+		return Viewer{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev, had := c.holders[session]
+	c.holders[session] = v
+	return prev, had && prev.ID != v.ID
+}
+
+// Holder returns the viewer in control of session.
+func (c *Control) Holder(session string) (Viewer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.holders[session]
+	return v, ok
+}
+
+// Holds reports whether the connection id is in control of session.
+func (c *Control) Holds(session, id string) bool {
+	v, ok := c.Holder(session)
+	return ok && v.ID == id
+}
+
+// Release drops the lease if id holds it, as when its viewer leaves.
+func (c *Control) Release(session, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.holders[session]; ok && v.ID == id {
+		delete(c.holders, session)
+	}
+}
@@ -0,0 +1,110 @@
+package share
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "share.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestTokensAuthorizeOnlyTheirSessionUntilRevokedOrExpired(t *testing.T) {
+	s := openStore(t)
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	tok, secret, err := s.Create("claude-api", ScopeObserve, "alice", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Authorize("claude-api", secret); err != nil || got.ID != tok.ID || got.Scope != ScopeObserve || got.Label != "alice" {
+		t.Fatalf("expected the token, got %+v (%v)", got, err)
+	}
+	for _, tc := range []struct {
+		session, secret string
+	}{{"claude-web", secret}, {"claude-api", "nope"}, {"claude-api", ""}} {
+		if _, err := s.Authorize(tc.session, tc.secret); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Authorize(%q, %q) = %v", tc.session, tc.secret, err)
+		}
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := s.Authorize("claude-api", secret); !errors.Is(err, ErrExpired) || s.Valid(tok.ID) {
+		t.Fatalf("expected the token to expire, got %v", err)
+	}
+
+	other, secret2, _ := s.Create("claude-api", ScopeInteract, "", time.Hour)
+	if other.Label == "" {
+		t.Fatal("expected a generated label")
+	}
+	if err := s.Revoke(other.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authorize("claude-api", secret2); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected the token to be revoked, got %v", err)
+	}
+	if err := s.Revoke("missing"); err == nil {
+		t.Fatal("expected revoking an unknown token to fail")
+	}
+	if _, _, err := s.Create("claude-api", Scope("admin"), "", time.Hour); err == nil {
+		t.Fatal("expected an unknown scope to be refused")
+	}
+
+	tokens, err := s.Tokens("claude-api")
+	if err != nil || len(tokens) != 2 || tokens[0].ID != other.ID || !tokens[0].Revoked {
+		t.Fatalf("unexpected tokens %+v (%v)", tokens, err)
+	}
+}
+
+func TestAuditNewestFirst(t *testing.T) {
+	s := openStore(t)
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, kind := range []string{EventJoin, EventControl, EventKeys} {
+		if err := s.Record(Event{At: at.Add(time.Duration(i) * time.Second), Session: "claude-api", Actor: "bob", Kind: kind, Data: "ls"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Record(Event{Session: "claude-web", Actor: "owner", Kind: EventJoin})
+
+	events, err := s.Audit("claude-api", 2)
+	if err != nil || len(events) != 2 || events[0].Kind != EventKeys || events[1].Kind != EventControl {
+		t.Fatalf("unexpected audit %+v (%v)", events, err)
+	}
+	if !events[0].At.Equal(at.Add(2 * time.Second)) {
+		t.Fatalf("unexpected time %v", events[0].At)
+	}
+}
+
+func TestControlHandoff(t *testing.T) {
+	c := NewControl()
+	alice := Viewer{ID: "v1", Actor: "alice", Scope: ScopeInteract}
+	bob := Viewer{ID: "v2", Actor: "bob", Scope: ScopeInteract}
+	carol := Viewer{ID: "v3", Actor: "carol", Scope: ScopeObserve}
+
+	if _, replaced := c.Take("s", alice); replaced || !c.Holds("s", "v1") {
+		t.Fatal("expected alice to take control")
+	}
+	if _, replaced := c.Take("s", carol); replaced || !c.Holds("s", "v1") {
+		t.Fatal("expected an observer not to take control")
+	}
+	if prev, replaced := c.Take("s", bob); !replaced || prev.Actor != "alice" || !c.Holds("s", "v2") {
+		t.Fatalf("expected bob to take over from alice, got %+v", prev)
+	}
+	c.Release("s", "v1")
+	if !c.Holds("s", "v2") {
+		t.Fatal("expected a former holder leaving to keep bob in control")
+	}
+	c.Release("s", "v2")
+	if _, ok := c.Holder("s"); ok {
+		t.Fatal("expected the lease to be free")
+	}
+}
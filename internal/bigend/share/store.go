@@ -0,0 +1,288 @@
+// Package share hands out scoped tokens for watching or driving a tmux
+// session from the Bigend web UI. Observers only see the pane; interactive
+// viewers may type once they hold the session's control lease. Every
+// keystroke sent and every handoff of control is written to an audit log.
+package share
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	autarchdb "github.com/mistakeknot/autarch/pkg/db"
+)
+
+// Scope is what a token allows.
+type Scope string
+
+const (
+	ScopeObserve  Scope = "observe"  // read-only
+	ScopeInteract Scope = "interact" // may take control and type
+)
+
+// ParseScope validates a scope name.
+func ParseScope(s string) (Scope, error) {
+	switch Scope(s) {
+	case ScopeObserve, ScopeInteract:
+		return Scope(s), nil
+	}
+	return "", fmt.Errorf("unknown share scope %q (want observe or interact)", s)
+}
+
+// Token errors.
+var (
+	ErrInvalidToken = errors.New("invalid share token")
+	ErrExpired      = errors.New("share token expired")
+	ErrRevoked      = errors.New("share token revoked")
+)
+
+// Token is a share of one session. The secret is only known when the
+// token is created; the store keeps its hash.
+type Token struct {
+	ID        string    `json:"id"`
+	Session   string    `json:"session"`
+	Scope     Scope     `json:"scope"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Revoked   bool      `json:"revoked"`
+}
+
+// Active reports whether the token can still be used at now.
+func (t Token) Active(now time.Time) bool {
+	return !t.Revoked && now.Before(t.ExpiresAt)
+}
+
+// Audit event kinds.
+const (
+	EventJoin    = "join"    // a viewer connected
+	EventLeave   = "leave"   // a viewer disconnected
+	EventControl = "control" // a viewer took control
+	EventKeys    = "keys"    // keystrokes were sent to the pane
+	EventDenied  = "denied"  // input was refused
+)
+
+// Event is one audit log entry. Actor is the token label, or "owner" for
+// the local user.
+type Event struct {
+	At      time.Time `json:"at"`
+	Session string    `json:"session"`
+	TokenID string    `json:"token_id,omitempty"`
+	Actor   string    `json:"actor"`
+	Kind    string    `json:"kind"`
+	Data    string    `json:"data,omitempty"`
+}
+
+// Store persists tokens and the audit log in SQLite.
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// Open opens, creating if needed, the store at path.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	db, err := autarchdb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS share_tokens (
+  id TEXT PRIMARY KEY,
+  hash TEXT NOT NULL UNIQUE,
+  session TEXT NOT NULL,
+  scope TEXT NOT NULL,
+  label TEXT NOT NULL,
+  created_at INTEGER NOT NULL,
+  expires_at INTEGER NOT NULL,
+  revoked INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS share_audit (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  ts INTEGER NOT NULL,
+  session TEXT NOT NULL,
+  token_id TEXT NOT NULL DEFAULT '',
+  actor TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  data TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_share_audit_session ON share_audit(session, ts);
+`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db, now: time.Now}, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Create issues a token for session valid for ttl. It returns the token
+// and its secret, which is not stored and cannot be recovered.
+func (s *Store) Create(session string, scope Scope, label string, ttl time.Duration) (Token, string, error) {
+	if _, err := ParseScope(string(scope)); err != nil {
+		return Token{}, "", err
+	}
+	if session == "" {
+		return Token{}, "", errors.New("share needs a session")
+	}
+	if ttl <= 0 {
+		return Token{}, "", errors.New("share ttl must be positive")
+	}
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return Token{}, "", err
+	}
+	secret := hex.EncodeToString(raw)
+	hash := hashSecret(secret)
+	now := s.now()
+	t := Token{
+		ID:        hash[:12],
+		Session:   session,
+		Scope:     scope,
+		Label:     label,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if t.Label == "" {
+		t.Label = "guest-" + t.ID[:4]
+	}
+	_, err := s.db.Exec(`INSERT INTO share_tokens (id, hash, session, scope, label, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, hash, t.Session, string(t.Scope), t.Label, t.CreatedAt.Unix(), t.ExpiresAt.Unix())
+	if err != nil {
+		return Token{}, "", err
+	}
+	return t, secret, nil
+}
+
+// Authorize resolves a secret to its token, checking it is active and
+// shares session.
+func (s *Store) Authorize(session, secret string) (Token, error) {
+	if secret == "" {
+		return Token{}, ErrInvalidToken
+	}
+	row := s.db.QueryRow(`SELECT id, session, scope, label, created_at, expires_at, revoked FROM share_tokens WHERE hash = ?`, hashSecret(secret))
+	t, err := scanToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Token{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	switch {
+	case t.Session != session:
+		return Token{}, ErrInvalidToken
+	case t.Revoked:
+		return Token{}, ErrRevoked
+	case !t.Active(s.now()):
+		return Token{}, ErrExpired
+	}
+	return t, nil
+}
+
+// Revoke disables a token. Viewers using it are disconnected on their
+// next check.
+func (s *Store) Revoke(id string) error {
+	res, err := s.db.Exec(`UPDATE share_tokens SET revoked = 1 WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("share %s: %w", id, ErrInvalidToken)
+	}
+	return nil
+}
+
+// Valid reports whether the token id is still active.
+func (s *Store) Valid(id string) bool {
+	row := s.db.QueryRow(`SELECT id, session, scope, label, created_at, expires_at, revoked FROM share_tokens WHERE id = ?`, id)
+	t, err := scanToken(row)
+	return err == nil && t.Active(s.now())
+}
+
+// Tokens lists the tokens of a session, newest first. An empty session
+// lists all of them.
+func (s *Store) Tokens(session string) ([]Token, error) {
+	rows, err := s.db.Query(`SELECT id, session, scope, label, created_at, expires_at, revoked FROM share_tokens
+WHERE ? = '' OR session = ? ORDER BY created_at DESC, id`, session, session)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Token
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Record appends to the audit log. A zero At is stamped with the
+// current time.
+func (s *Store) Record(e Event) error {
+	if e.At.IsZero() {
+		e.At = s.now()
+	}
+	_, err := s.db.Exec(`INSERT INTO share_audit (ts, session, token_id, actor, kind, data) VALUES (?, ?, ?, ?, ?, ?)`,
+		e.At.UnixMilli(), e.Session, e.TokenID, e.Actor, e.Kind, e.Data)
+	return err
+}
+
+// Audit returns the latest limit events of a session, newest first.
+func (s *Store) Audit(session string, limit int) ([]Event, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.Query(`SELECT ts, session, token_id, actor, kind, data FROM share_audit
+WHERE session = ? ORDER BY ts DESC, id DESC LIMIT ?`, session, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Event
+	for rows.Next() {
+		var e Event
+		var ts int64
+		if err := rows.Scan(&ts, &e.Session, &e.TokenID, &e.Actor, &e.Kind, &e.Data); err != nil {
+			return nil, err
+		}
+		e.At = time.UnixMilli(ts)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanToken(row scanner) (Token, error) {
+	var t Token
+	var scope string
+	var created, expires int64
+	if err := row.Scan(&t.ID, &t.Session, &scope, &t.Label, &created, &expires, &t.Revoked); err != nil {
+		return Token{}, err
+	}
+	t.Scope = Scope(scope)
+	t.CreatedAt = time.Unix(created, 0)
+	t.ExpiresAt = time.Unix(expires, 0)
+	return t, nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -38,7 +38,8 @@ func DefaultConfig() DetectorConfig {
 
 // Detector performs four-tier agent state detection.
 //
-// Tier 0: Hook-based state (authoritative, from agent hooks)
+// Tier 0: Hook-based state (authoritative, from agent hooks): events sent
+// over the hook socket first, then hook state files
 // Tier 1: Fast pattern matching (handles ~90% of cases)
 // Tier 2: Repetition detection for stall states
 // Tier 3: Activity-based fallback
@@ -48,6 +49,7 @@ type Detector struct {
 	config     DetectorConfig
 	matcher    *PatternMatcher
 	hookReader *HookStateReader
+	events     *EventStore
 
 	// Per-session output history for repetition detection
 	mu      sync.RWMutex
@@ -89,6 +91,11 @@ func (d *Detector) Detect(sessionName, output, agentType string, lastActivity ti
 	now := time.Now()
 
 	// Tier 0: Hook-based state (authoritative, highest confidence)
+	if d.events != nil {
+		if event, ok := d.events.Lookup(sessionName, now); ok {
+			return EventState(event)
+		}
+	}
 	if d.hookReader != nil {
 		if event := d.hookReader.GetStateBySession(sessionName); event != nil {
 			result := event.ToStateResult()
@@ -135,6 +142,11 @@ func (d *Detector) Detect(sessionName, output, agentType string, lastActivity ti
 	}
 }
 
+// SetEventStore makes d consult hook socket events before anything else.
+func (d *Detector) SetEventStore(events *EventStore) {
+	d.events = events
+}
+
 // isRepeating checks if recent output is repeating (stall indicator).
 func (d *Detector) isRepeating(sessionName, output string) bool {
 	d.mu.Lock()
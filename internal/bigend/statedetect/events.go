@@ -0,0 +1,174 @@
+package statedetect
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mistakeknot/autarch/pkg/agenthook"
+)
+
+// ErrSocketInUse is returned when another process serves the hook socket.
+var ErrSocketInUse = errors.New("hook socket is served by another process")
+
+// EventStore keeps the latest hook event of each agent session, as
+// reported over the hook socket by autarch-hook.
+//
+// Events that end a phase (idle, permission prompt, error, stop) hold until
+// the next event. Events that start work (session start, prompt, tool
+// call) go stale after staleAfter, since an agent that dies mid-turn
+// sends nothing more; detection then falls back to the pane.
+type EventStore struct {
+	staleAfter time.Duration
+
+	mu        sync.RWMutex
+	bySession map[string]agenthook.Event
+	byProject map[string]agenthook.Event // "{agent}-{project name}", for events without a tmux session
+}
+
+// NewEventStore returns an empty store.
+func NewEventStore(staleAfter time.Duration) *EventStore {
+	return &EventStore{
+		staleAfter: staleAfter,
+		bySession:  make(map[string]agenthook.Event),
+		byProject:  make(map[string]agenthook.Event),
+	}
+}
+
+// Record stores an event if it is newer than the one it replaces.
+func (s *EventStore) Record(e agenthook.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.Session != "" {
+		if prev, ok := s.bySession[e.Session]; !ok || !e.At.Before(prev.At) {
+			s.bySession[e.Session] = e
+		}
+	}
+	if e.ProjectDir != "" {
+		key := e.Agent + "-" + filepath.Base(e.ProjectDir)
+		if prev, ok := s.byProject[key]; !ok || !e.At.Before(prev.At) {
+			s.byProject[key] = e
+		}
+	}
+}
+
+// Lookup returns the current event of a tmux session. Sessions the
+// emitter could not name are matched like hook state files, by the agent
+// and project in the session name.
+func (s *EventStore) Lookup(sessionName string, now time.Time) (agenthook.Event, bool) {
+	s.mu.RLock()
+	e, ok := s.bySession[sessionName]
+	if !ok {
+		if agent, project := parseSessionName(sessionName); agent != "" {
+			e, ok = s.byProject[agent+"-"+project]
+		}
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return e, false
+	}
+	switch e.Kind {
+	case agenthook.KindSessionStart, agenthook.KindPrompt, agenthook.KindToolCall:
+		if s.staleAfter > 0 && now.Sub(e.At) > s.staleAfter {
+			return e, false
+		}
+	}
+	return e, true
+}
+
+// Forget drops a session's events, as when it is killed.
+func (s *EventStore) Forget(sessionName string) {
+	s.mu.Lock()
+	delete(s.bySession, sessionName)
+	s.mu.Unlock()
+}
+
+// EventState maps a hook event to the agent state it reports.
+func EventState(e agenthook.Event) StateResult {
+	state := StateUnknown
+	switch e.Kind {
+	case agenthook.KindSessionStart, agenthook.KindIdle:
+		state = StateWaiting
+	case agenthook.KindPrompt, agenthook.KindToolCall:
+		state = StateWorking
+	case agenthook.KindPermission:
+		state = StateBlocked
+	case agenthook.KindError:
+		state = StateError
+	case agenthook.KindStop:
+		state = StateDone
+	}
+	return StateResult{
+		State:          state,
+		Confidence:     1.0,
+		Source:         SourceHook,
+		MatchedPattern: string(e.Kind),
+		DetectedAt:     e.At,
+	}
+}
+
+// ListenSocket listens on the hook socket at path. A socket left behind
+// by a dead process is replaced; a live one is ErrSocketInUse.
+func ListenSocket(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, ErrSocketInUse
+	}
+	os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Serve records the events sent to ln, one JSON object per line, until
+// ctx is done.
+func (s *EventStore) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.read(conn)
+	}
+}
+
+func (s *EventStore) read(conn net.Conn) {
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var e agenthook.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			slog.Debug("malformed hook event", "error", err)
+			continue
+		}
+		if err := e.Validate(); err != nil {
+			slog.Debug("rejected hook event", "error", err)
+			continue
+		}
+		s.Record(e)
+	}
+}
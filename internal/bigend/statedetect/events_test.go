@@ -0,0 +1,110 @@
+package statedetect
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/pkg/agenthook"
+)
+
+func TestEventStoreLookup(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := NewEventStore(10 * time.Minute)
+
+	store.Record(agenthook.Event{V: 1, Kind: agenthook.KindToolCall, Agent: "claude", Session: "api", At: now})
+	store.Record(agenthook.Event{V: 1, Kind: agenthook.KindPrompt, Agent: "claude", Session: "api", At: now.Add(-time.Minute)})
+	e, ok := store.Lookup("api", now)
+	if !ok || e.Kind != agenthook.KindToolCall {
+		t.Fatalf("older event replaced newer one: %+v %v", e, ok)
+	}
+
+	// Work in progress goes stale; an ended phase does not
+	if _, ok := store.Lookup("api", now.Add(11*time.Minute)); ok {
+		t.Error("tool_call should go stale")
+	}
+	store.Record(agenthook.Event{V: 1, Kind: agenthook.KindPermission, Agent: "claude", Session: "api", At: now})
+	if _, ok := store.Lookup("api", now.Add(time.Hour)); !ok {
+		t.Error("permission_prompt should not go stale")
+	}
+
+	// Events without a tmux session match by agent and project
+	store.Record(agenthook.Event{V: 1, Kind: agenthook.KindIdle, Agent: "codex", ProjectDir: "/src/web", At: now})
+	if e, ok := store.Lookup("codex-web", now); !ok || e.Kind != agenthook.KindIdle {
+		t.Errorf("lookup by project = %+v %v", e, ok)
+	}
+
+	store.Forget("api")
+	if _, ok := store.Lookup("api", now); ok {
+		t.Error("forgotten session still found")
+	}
+}
+
+func TestEventState(t *testing.T) {
+	tests := map[agenthook.Kind]AgentState{
+		agenthook.KindSessionStart: StateWaiting,
+		agenthook.KindPrompt:       StateWorking,
+		agenthook.KindToolCall:     StateWorking,
+		agenthook.KindPermission:   StateBlocked,
+		agenthook.KindIdle:         StateWaiting,
+		agenthook.KindError:        StateError,
+		agenthook.KindStop:         StateDone,
+	}
+	for kind, want := range tests {
+		got := EventState(agenthook.Event{Kind: kind})
+		if got.State != want || got.Source != SourceHook || got.MatchedPattern != string(kind) {
+			t.Errorf("EventState(%s) = %+v, want %s", kind, got, want)
+		}
+	}
+}
+
+func TestEventSocketFeedsDetector(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "bigend.sock")
+	ln, err := ListenSocket(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListenSocket(socket); err != ErrSocketInUse {
+		t.Fatalf("second listener: got %v, want ErrSocketInUse", err)
+	}
+
+	store := NewEventStore(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- store.Serve(ctx, ln) }()
+
+	detector := NewDetector()
+	detector.SetEventStore(store)
+
+	e := agenthook.Event{V: 1, Kind: agenthook.KindPermission, Agent: "claude", Session: "claude-api", Tool: "Bash", At: time.Now()}
+	if err := agenthook.Send(ctx, socket, e); err != nil {
+		t.Fatal(err)
+	}
+	// Newer protocol versions are rejected
+	future := e
+	future.V, future.Kind = agenthook.Version+1, agenthook.KindIdle
+	if err := agenthook.Send(ctx, socket, future); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	var result StateResult
+	for time.Now().Before(deadline) {
+		// Pane output says working; the hook event wins
+		result = detector.Detect("claude-api", "⠋ Thinking...", "claude", time.Now())
+		if result.Source == SourceHook {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if result.Source != SourceHook || result.State != StateBlocked {
+		t.Fatalf("Detect = %+v, want blocked from hook", result)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
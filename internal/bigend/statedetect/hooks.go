@@ -145,6 +145,7 @@ func parseSessionName(name string) (agentType, projectName string) {
 		{"codex-", "codex"},
 		{"cx-", "codex"},
 		{"aider-", "aider"},
+		{"gemini-", "gemini"},
 	}
 
 	for _, p := range prefixes {
@@ -160,6 +161,9 @@ func parseSessionName(name string) (agentType, projectName string) {
 	if strings.Contains(lower, "codex") {
 		return "codex", extractProjectName(name, "codex")
 	}
+	if strings.Contains(lower, "gemini") {
+		return "gemini", extractProjectName(name, "gemini")
+	}
 
 	return "", ""
 }
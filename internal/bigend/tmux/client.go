@@ -364,6 +364,12 @@ func (c *Client) SendKeys(sessionName string, keys string) error {
 	return cmd.Run()
 }
 
+// SendLiteral types text into a session without interpreting key names
+func (c *Client) SendLiteral(sessionName string, text string) error {
+	cmd := exec.Command(c.tmuxPath, "send-keys", "-t", sessionName, "-l", "--", text)
+	return cmd.Run()
+}
+
 // AttachSession attaches to an existing session (for TUI integration)
 func (c *Client) AttachSession(sessionName string) error {
 	cmd := exec.Command(c.tmuxPath, "attach-session", "-t", sessionName)
@@ -16,6 +16,7 @@ import (
 
 	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
 	"github.com/mistakeknot/autarch/internal/bigend/mcp"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
 	"github.com/mistakeknot/autarch/internal/bigend/tmux"
 	shared "github.com/mistakeknot/autarch/pkg/tui"
 )
@@ -56,6 +57,7 @@ type aggregatorAPI interface {
 	AttachSession(name string) error
 	StartMCP(ctx context.Context, projectPath, component string) error
 	StopMCP(projectPath, component string) error
+	MetricSeries(q metrics.Query) ([]metrics.Point, error)
 }
 
 type statusClient interface {
@@ -283,6 +285,8 @@ type Model struct {
 	promptSess    *aggregator.TmuxSession
 	err           error
 	lastRefresh   time.Time
+	trends        []trendLine
+	trendsAt      time.Time
 	quitting      bool
 	keys          shared.CommonKeys
 	helpOverlay   shared.HelpOverlay
@@ -543,6 +547,24 @@ type refreshMsg struct{}
 type errMsg error
 type tickMsg time.Time
 
+// trendsMsg carries fleet trends loaded from the metrics history.
+type trendsMsg []trendLine
+
+// trendLine is one fleet trend on the dashboard.
+type trendLine struct {
+	Title string
+	Spark string
+	Value float64
+}
+
+// Fleet trends cover trendsWindow in trendsWidth buckets and are reloaded
+// every trendsInterval.
+const (
+	trendsWindow   = 6 * time.Hour
+	trendsWidth    = 36
+	trendsInterval = time.Minute
+)
+
 // New creates a new TUI model
 func New(agg aggregatorAPI, buildInfo string) Model {
 	// Create session list
@@ -676,6 +698,28 @@ func (m Model) refresh() tea.Cmd {
 	}
 }
 
+// loadTrends queries the fleet trends. Nothing is shown when the metrics
+// history is disabled.
+func (m Model) loadTrends() tea.Cmd {
+	return func() tea.Msg {
+		now := time.Now()
+		var lines []trendLine
+		for _, t := range metrics.Trends {
+			points, err := m.agg.MetricSeries(t.Query("", trendsWindow, now, trendsWidth))
+			if err != nil {
+				return trendsMsg(nil)
+			}
+			value, _ := metrics.Summary(t, points)
+			lines = append(lines, trendLine{
+				Title: t.Title,
+				Spark: metrics.Sparkline(metrics.Values(points)),
+				Value: value,
+			})
+		}
+		return trendsMsg(lines)
+	}
+}
+
 func (m Model) tick() tea.Cmd {
 	return tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
 		return tickMsg(t)
@@ -1007,8 +1051,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		// Auto-refresh every tick
 		cmds = append(cmds, m.refresh(), m.tick())
+		if time.Since(m.trendsAt) >= trendsInterval {
+			m.trendsAt = time.Now()
+			cmds = append(cmds, m.loadTrends())
+		}
 		return m, tea.Batch(cmds...)
 
+	case trendsMsg:
+		m.trends = msg
+		return m, nil
+
 	case errMsg:
 		m.err = msg
 		return m, nil
@@ -1476,13 +1528,21 @@ func (m Model) renderDashboard() string {
 		recentAgents = append(recentAgents, LabelStyle.Render("  No agents registered"))
 	}
 
-	return lipgloss.JoinVertical(lipgloss.Left,
-		statsRow,
-		"",
+	sections := []string{statsRow, ""}
+	if len(m.trends) > 0 {
+		sections = append(sections, SubtitleStyle.Render("Fleet Trends (6h)"))
+		for _, t := range m.trends {
+			sections = append(sections, fmt.Sprintf("  %-24s %s %s",
+				t.Title, t.Spark, LabelStyle.Render(fmt.Sprintf("%.0f", t.Value))))
+		}
+		sections = append(sections, "")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, append(sections,
 		recentTitle,
 		strings.Join(recentSessions, "\n"),
 		"",
 		agentsTitle,
 		strings.Join(recentAgents, "\n"),
-	)
+	)...)
 }
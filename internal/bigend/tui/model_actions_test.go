@@ -9,6 +9,7 @@ import (
 
 	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
 	"github.com/mistakeknot/autarch/internal/bigend/mcp"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
 )
 
 type fakeAgg struct {
@@ -32,6 +33,7 @@ func (f *fakeAgg) ForkSession(name, projectPath, agentType string) error
 func (f *fakeAgg) AttachSession(name string) error                                   { return nil }
 func (f *fakeAgg) StartMCP(ctx context.Context, projectPath, component string) error { return nil }
 func (f *fakeAgg) StopMCP(projectPath, component string) error                       { return nil }
+func (f *fakeAgg) MetricSeries(q metrics.Query) ([]metrics.Point, error)             { return nil, nil }
 
 func TestRestartKeyTriggersAction(t *testing.T) {
 	agg := &fakeAgg{state: aggregator.State{Sessions: []aggregator.TmuxSession{{
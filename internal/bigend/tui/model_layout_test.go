@@ -11,10 +11,12 @@ import (
 
 	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
 	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
 )
 
 type fakeAggLayout struct {
-	state aggregator.State
+	state  aggregator.State
+	series []metrics.Point
 }
 
 func (f *fakeAggLayout) GetState() aggregator.State                     { return f.state }
@@ -26,6 +28,9 @@ func (f *fakeAggLayout) ForkSession(string, string, string) error       { return
 func (f *fakeAggLayout) AttachSession(string) error                     { return nil }
 func (f *fakeAggLayout) StartMCP(context.Context, string, string) error { return nil }
 func (f *fakeAggLayout) StopMCP(string, string) error                   { return nil }
+func (f *fakeAggLayout) MetricSeries(metrics.Query) ([]metrics.Point, error) {
+	return f.series, nil
+}
 
 func TestFilterSessionsByProject(t *testing.T) {
 	agg := &fakeAggLayout{state: aggregator.State{
@@ -201,3 +206,18 @@ func TestHeaderTabsExcludeProjects(t *testing.T) {
 		t.Fatalf("did not expect Projects in header tabs")
 	}
 }
+
+func TestDashboardShowsFleetTrends(t *testing.T) {
+	agg := &fakeAggLayout{series: []metrics.Point{{Value: 1}, {Value: 4}}}
+	m := New(agg, "")
+	m.width = 120
+	m.height = 40
+	if view := m.renderDashboard(); strings.Contains(view, "Fleet Trends") {
+		t.Fatalf("expected no trends before they load")
+	}
+	updated, _ := m.Update(m.loadTrends()())
+	view := updated.(Model).renderDashboard()
+	if !strings.Contains(view, "Fleet Trends") || !strings.Contains(view, "▂█ 5") {
+		t.Fatalf("expected trend sparklines, got:\n%s", view)
+	}
+}
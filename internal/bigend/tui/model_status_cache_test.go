@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
 	"github.com/mistakeknot/autarch/internal/bigend/tmux"
 )
 
@@ -22,6 +23,7 @@ func (f *fakeAggStatus) ForkSession(string, string, string) error { return nil }
 func (f *fakeAggStatus) AttachSession(string) error { return nil }
 func (f *fakeAggStatus) StartMCP(context.Context, string, string) error { return nil }
 func (f *fakeAggStatus) StopMCP(string, string) error { return nil }
+func (f *fakeAggStatus) MetricSeries(metrics.Query) ([]metrics.Point, error) { return nil, nil }
 
 type fakeStatusClient struct {
 	calls map[string]int
@@ -0,0 +1,294 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+)
+
+const replayTick = 100 * time.Millisecond
+
+// ReplayOptions controls `bigend replay`.
+type ReplayOptions struct {
+	At     time.Duration // where playback starts, or the frame to print
+	Search string        // jump to, or with Print list, frames containing this text
+	Print  bool          // write to the terminal instead of opening the player
+}
+
+// Replay plays back a recording. With opts.Print it writes the frame at
+// opts.At, or the matches for opts.Search, to w and returns.
+func Replay(w io.Writer, cast *recording.Cast, title string, opts ReplayOptions) error {
+	if opts.Print {
+		if opts.Search != "" {
+			matches := cast.Search(opts.Search)
+			if len(matches) == 0 {
+				return fmt.Errorf("%q does not appear in %s", opts.Search, title)
+			}
+			for _, m := range matches {
+				fmt.Fprintf(w, "%8s  %-8s %s\n", formatOffset(m.Time), cast.StateAt(m.Time), m.Line)
+			}
+			return nil
+		}
+		at := opts.At.Seconds()
+		frame, _ := cast.FrameAt(at)
+		fmt.Fprintf(w, "%s at %s (%s)\n", title, formatOffset(at), cast.Header.Start().Add(opts.At).Format("2006-01-02 15:04:05"))
+		if state := cast.StateAt(at); state != "" {
+			fmt.Fprintf(w, "state: %s\n", state)
+		}
+		fmt.Fprintln(w, frame.Screen())
+		return nil
+	}
+	m := NewReplay(cast, title)
+	m.seek(opts.At.Seconds())
+	if opts.Search != "" {
+		m.runSearch(opts.Search)
+	}
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+// ReplayModel is a terminal player for a pane recording.
+type ReplayModel struct {
+	cast     *recording.Cast
+	title    string
+	pos      float64
+	playing  bool
+	width    int
+	height   int
+	input    textinput.Model
+	query    string
+	matches  []recording.Match
+	matchIdx int
+	message  string
+}
+
+type replayTickMsg struct{}
+
+// NewReplay returns a paused player at the start of the recording.
+func NewReplay(cast *recording.Cast, title string) ReplayModel {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Placeholder = "search output"
+	return ReplayModel{cast: cast, title: title, input: input, width: 80, height: 24}
+}
+
+func (m ReplayModel) Init() tea.Cmd {
+	return nil
+}
+
+func tickReplay() tea.Cmd {
+	return tea.Tick(replayTick, func(time.Time) tea.Msg { return replayTickMsg{} })
+}
+
+func (m *ReplayModel) seek(t float64) {
+	end := m.cast.Duration()
+	if t < 0 {
+		t = 0
+	}
+	if t > end {
+		t = end
+	}
+	m.pos = t
+}
+
+func (m *ReplayModel) runSearch(query string) {
+	m.query = query
+	m.matches = m.cast.Search(query)
+	m.matchIdx = -1
+	if len(m.matches) == 0 {
+		m.message = fmt.Sprintf("%q not found", query)
+		return
+	}
+	m.jumpMatch(1)
+}
+
+// jumpMatch moves to the next (dir > 0) or previous match.
+func (m *ReplayModel) jumpMatch(dir int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.matchIdx = (m.matchIdx + dir + len(m.matches)) % len(m.matches)
+	match := m.matches[m.matchIdx]
+	m.seek(match.Time)
+	m.playing = false
+	m.message = fmt.Sprintf("match %d/%d: %s", m.matchIdx+1, len(m.matches), match.Line)
+}
+
+// jumpMarker moves to the next (dir > 0) or previous state marker.
+func (m *ReplayModel) jumpMarker(dir int) {
+	markers := m.cast.Markers()
+	if dir > 0 {
+		for _, mk := range markers {
+			if mk.Time > m.pos {
+				m.seek(mk.Time)
+				return
+			}
+		}
+		return
+	}
+	for i := len(markers) - 1; i >= 0; i-- {
+		if markers[i].Time < m.pos {
+			m.seek(markers[i].Time)
+			return
+		}
+	}
+}
+
+func (m ReplayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case replayTickMsg:
+		if !m.playing {
+			return m, nil
+		}
+		m.seek(m.pos + replayTick.Seconds())
+		if m.pos >= m.cast.Duration() {
+			m.playing = false
+			return m, nil
+		}
+		return m, tickReplay()
+	case tea.KeyMsg:
+		if m.input.Focused() {
+			switch msg.Type {
+			case tea.KeyEnter:
+				m.input.Blur()
+				m.runSearch(m.input.Value())
+				return m, nil
+			case tea.KeyEsc:
+				m.input.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+		m.message = ""
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case " ":
+			m.playing = !m.playing
+			if m.playing {
+				if m.pos >= m.cast.Duration() {
+					m.pos = 0
+				}
+				return m, tickReplay()
+			}
+		case "left", "h":
+			m.seek(m.pos - 5)
+		case "right", "l":
+			m.seek(m.pos + 5)
+		case "shift+left", "H":
+			m.seek(m.pos - 60)
+		case "shift+right", "L":
+			m.seek(m.pos + 60)
+		case "home", "g":
+			m.seek(0)
+		case "end", "G":
+			m.seek(m.cast.Duration())
+		case "[":
+			m.jumpMarker(-1)
+		case "]":
+			m.jumpMarker(1)
+		case "/":
+			m.input.SetValue(m.query)
+			m.input.Focus()
+			return m, textinput.Blink
+		case "n":
+			m.jumpMatch(1)
+		case "N":
+			m.jumpMatch(-1)
+		}
+	}
+	return m, nil
+}
+
+func (m ReplayModel) View() string {
+	state := m.cast.StateAt(m.pos)
+	if state == "" {
+		state = "-"
+	}
+	playing := "paused"
+	if m.playing {
+		playing = "playing"
+	}
+	header := fmt.Sprintf("%s  %s / %s  %s  %s",
+		TitleStyle.Render(m.title),
+		formatOffset(m.pos), formatOffset(m.cast.Duration()),
+		LabelStyle.Render("state: "+state),
+		LabelStyle.Render(playing))
+
+	screenHeight := m.height - 4
+	if screenHeight < 1 {
+		screenHeight = 1
+	}
+	frame, _ := m.cast.FrameAt(m.pos)
+	lines := strings.Split(frame.Screen(), "\n")
+	if len(lines) > screenHeight {
+		lines = lines[len(lines)-screenHeight:]
+	}
+	for len(lines) < screenHeight {
+		lines = append(lines, "")
+	}
+	for i, l := range lines {
+		if lipgloss.Width(l) > m.width {
+			lines[i] = string([]rune(l)[:m.width])
+		}
+	}
+
+	footer := HelpDescStyle.Render("space play/pause  ←/→ 5s  H/L 1m  [/] state change  / search  n/N match  q quit")
+	if m.input.Focused() {
+		footer = m.input.View()
+	} else if m.message != "" {
+		footer = LabelStyle.Render(m.message)
+	}
+	return strings.Join([]string{header, strings.Join(lines, "\n"), m.timeline(), footer}, "\n")
+}
+
+// timeline draws playback progress with a tick at each state change.
+func (m ReplayModel) timeline() string {
+	width := m.width
+	if width < 10 {
+		width = 10
+	}
+	end := m.cast.Duration()
+	bar := []rune(strings.Repeat("─", width))
+	col := func(t float64) int {
+		if end <= 0 {
+			return 0
+		}
+		c := int(t / end * float64(width-1))
+		if c < 0 {
+			c = 0
+		}
+		if c >= width {
+			c = width - 1
+		}
+		return c
+	}
+	for _, mk := range m.cast.Markers() {
+		bar[col(mk.Time)] = '┼'
+	}
+	bar[col(m.pos)] = '●'
+	return string(bar)
+}
+
+func formatOffset(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(100 * time.Millisecond)
+	h := int(d.Hours())
+	mins := int(d.Minutes()) % 60
+	secs := d.Seconds() - float64(h*3600+mins*60)
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%04.1f", h, mins, secs)
+	}
+	return fmt.Sprintf("%d:%04.1f", mins, secs)
+}
@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+)
+
+func testCast(t *testing.T) *recording.Cast {
+	t.Helper()
+	var buf bytes.Buffer
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	w, err := recording.NewWriter(&buf, recording.Header{Width: 80, Height: 24}, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = w.State(start, "working")
+	_ = w.Output(start, "building")
+	_ = w.Output(start.Add(10*time.Second), "building\nFAIL pkg/db")
+	_ = w.State(start.Add(12*time.Second), "waiting")
+	_ = w.Output(start.Add(20*time.Second), "")
+	cast, err := recording.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cast
+}
+
+func TestReplayPrint(t *testing.T) {
+	cast := testCast(t)
+	var out bytes.Buffer
+	if err := Replay(&out, cast, "claude-api", ReplayOptions{At: 15 * time.Second, Print: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "state: waiting") || !strings.Contains(got, "FAIL pkg/db") {
+		t.Fatalf("unexpected frame output:\n%s", got)
+	}
+
+	out.Reset()
+	if err := Replay(&out, cast, "claude-api", ReplayOptions{Search: "fail", Print: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "0:10.0  working  FAIL pkg/db" {
+		t.Fatalf("unexpected matches: %q", got)
+	}
+	if err := Replay(&out, cast, "claude-api", ReplayOptions{Search: "panic", Print: true}); err == nil {
+		t.Fatal("expected an error when nothing matches")
+	}
+}
+
+func TestReplayModelSeeksMarkersAndMatches(t *testing.T) {
+	m := NewReplay(testCast(t), "claude-api")
+	key := func(s string) {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+		m = next.(ReplayModel)
+	}
+
+	key("]")
+	if m.pos != 12 {
+		t.Fatalf("expected the waiting marker at 12s, got %v", m.pos)
+	}
+	key("l")
+	if m.pos != 17 {
+		t.Fatalf("expected 17s, got %v", m.pos)
+	}
+	if !strings.Contains(m.View(), "state: waiting") {
+		t.Fatalf("expected the state in the header:\n%s", m.View())
+	}
+
+	m.runSearch("FAIL")
+	if m.pos != 10 || !strings.Contains(m.View(), "match 1/1") {
+		t.Fatalf("expected to jump to the match at 10s, got %v", m.pos)
+	}
+	key("G")
+	if m.pos != 20 || strings.Contains(m.View(), "FAIL pkg/db") {
+		t.Fatalf("expected the cleared screen at the end, got %v:\n%s", m.pos, m.View())
+	}
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/bigend/colony"
+)
+
+// heatmap is a colony's worktree pairs laid out as a symmetric grid.
+type heatmap struct {
+	Colony colony.Colony
+	Labels []string
+	Rows   [][]heatCell
+	Pairs  []pairView // at some risk, riskiest first
+}
+
+// pairView is a worktree pair at risk, listed under the grid.
+type pairView struct {
+	Label     string
+	Percent   int
+	Base      string
+	Shared    string
+	Conflicts string
+}
+
+// heatCell is one worktree pair of the grid; the diagonal is Self.
+type heatCell struct {
+	Self    bool
+	Known   bool
+	Percent int
+	Shared  int
+	Merge   int // predicted conflicting files
+	Class   string
+}
+
+func buildHeatmap(c colony.Colony) heatmap {
+	h := heatmap{Colony: c}
+	index := make(map[string]int, len(c.Worktrees))
+	for i, wt := range c.Worktrees {
+		index[wt.Path] = i
+		h.Labels = append(h.Labels, worktreeLabel(wt))
+	}
+	h.Rows = make([][]heatCell, len(c.Worktrees))
+	for i := range h.Rows {
+		h.Rows[i] = make([]heatCell, len(c.Worktrees))
+		h.Rows[i][i] = heatCell{Self: true, Class: "bg-gray-900"}
+	}
+	for _, p := range c.Pairs {
+		i, okA := index[p.A.Path]
+		j, okB := index[p.B.Path]
+		if !okA || !okB {
+			continue
+		}
+		percent := int(math.Round(p.Risk * 100))
+		cell := heatCell{Known: true, Percent: percent, Shared: len(p.Shared), Merge: len(p.Conflicts), Class: riskClass(p.Risk)}
+		h.Rows[i][j], h.Rows[j][i] = cell, cell
+		if p.Risk > 0 {
+			h.Pairs = append(h.Pairs, pairView{
+				Label:     worktreeLabel(p.A) + " ↔ " + worktreeLabel(p.B),
+				Percent:   percent,
+				Base:      p.Base,
+				Shared:    strings.Join(p.Shared, ", "),
+				Conflicts: strings.Join(p.Conflicts, ", "),
+			})
+		}
+	}
+	sort.SliceStable(h.Pairs, func(i, j int) bool { return h.Pairs[i].Percent > h.Pairs[j].Percent })
+	return h
+}
+
+func worktreeLabel(wt colony.Worktree) string {
+	if wt.Branch != "" {
+		return wt.Branch
+	}
+	return filepath.Base(wt.Path)
+}
+
+// riskClass colors a cell from clear through overlap to conflict.
+func riskClass(risk float64) string {
+	switch {
+	case risk >= 0.75:
+		return "bg-red-700"
+	case risk >= 0.5:
+		return "bg-orange-700"
+	case risk >= 0.2:
+		return "bg-yellow-700"
+	case risk > 0:
+		return "bg-yellow-900"
+	default:
+		return "bg-green-900"
+	}
+}
+
+// handleColonies shows each colony's worktree conflict heatmap.
+func (s *Server) handleColonies(w http.ResponseWriter, r *http.Request) {
+	state := s.agg.GetState()
+	var maps []heatmap
+	for _, c := range state.Colonies {
+		if len(c.Worktrees) > 1 {
+			maps = append(maps, buildHeatmap(c))
+		}
+	}
+	s.render(w, "colonies.html", map[string]any{
+		"Heatmaps":  maps,
+		"UpdatedAt": state.UpdatedAt,
+	})
+}
+
+// handleColoniesAPI returns the colonies with their worktree pair risks.
+func (s *Server) handleColoniesAPI(w http.ResponseWriter, r *http.Request) {
+	state := s.agg.GetState()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state.Colonies)
+}
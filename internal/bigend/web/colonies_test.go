@@ -0,0 +1,63 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+	"github.com/mistakeknot/autarch/internal/bigend/colony"
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+)
+
+func TestColoniesHeatmap(t *testing.T) {
+	main := colony.Worktree{Path: "/r/api", Branch: "main"}
+	a := colony.Worktree{Path: "/r/api/.tandemonium/worktrees/a", Branch: "feature-a"}
+	b := colony.Worktree{Path: "/r/api/.tandemonium/worktrees/b"}
+	c := colony.Colony{
+		Name:      "api",
+		Root:      "/r/api",
+		Worktrees: []colony.Worktree{main, a, b},
+		Pairs: []colony.Pair{
+			{A: main, B: a},
+			{A: main, B: b, Shared: []string{"go.mod"}, ChangedA: 1, ChangedB: 4, Risk: 0.4},
+			{A: a, B: b, Base: "0123456789abcdef", Shared: []string{"api.go", "go.mod"}, Conflicts: []string{"api.go"}, Risk: 0.75},
+		},
+	}
+
+	h := buildHeatmap(c)
+	if strings.Join(h.Labels, ",") != "main,feature-a,b" {
+		t.Fatalf("labels = %v", h.Labels)
+	}
+	if !h.Rows[0][0].Self || h.Rows[1][2] != h.Rows[2][1] || h.Rows[1][2].Percent != 75 || h.Rows[1][2].Merge != 1 {
+		t.Fatalf("unexpected grid %+v", h.Rows)
+	}
+	if h.Rows[0][1].Class != "bg-green-900" || h.Rows[1][2].Class != "bg-red-700" {
+		t.Errorf("classes = %s, %s", h.Rows[0][1].Class, h.Rows[1][2].Class)
+	}
+	if len(h.Pairs) != 2 || h.Pairs[0].Label != "feature-a ↔ b" || h.Pairs[0].Conflicts != "api.go" {
+		t.Errorf("pairs = %+v", h.Pairs)
+	}
+
+	srv := NewServer(config.ServerConfig{}, &fakeAgg{state: aggregator.State{Colonies: []colony.Colony{c, {Name: "solo", Worktrees: []colony.Worktree{main}}}}})
+	w := httptest.NewRecorder()
+	srv.handleColonies(w, httptest.NewRequest(http.MethodGet, "/colonies", nil))
+	body := w.Body.String()
+	for _, want := range []string{"feature-a ↔ b", "Conflicts: api.go", "75%", "0123456789ab"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in colonies page", want)
+		}
+	}
+	if strings.Contains(body, "solo") {
+		t.Error("colony with one worktree shown")
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleColoniesAPI(w, httptest.NewRequest(http.MethodGet, "/api/colonies", nil))
+	var got []colony.Colony
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || len(got) != 2 || len(got[0].Pairs) != 3 {
+		t.Fatalf("api = %+v (%v)", got, err)
+	}
+}
@@ -0,0 +1,125 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/mcp"
+)
+
+// mcpTailLines is how much of each log the MCP page shows; the log
+// endpoint serves up to mcpMaxLogLines.
+const (
+	mcpTailLines   = 20
+	mcpMaxLogLines = 2000
+)
+
+// mcpView is a supervised component as the MCP page shows it.
+type mcpView struct {
+	mcp.ComponentStatus
+	Uptime  string
+	Latency string
+	CPU     string
+	Memory  string
+	RetryIn string
+	Tail    []string
+}
+
+func newMCPView(c mcp.ComponentStatus, tail []string, now time.Time) mcpView {
+	v := mcpView{ComponentStatus: c, Tail: tail}
+	if c.Status == mcp.StatusRunning && !c.StartedAt.IsZero() {
+		v.Uptime = now.Sub(c.StartedAt).Truncate(time.Second).String()
+		v.CPU = fmt.Sprintf("%.1f%%", c.CPUPercent)
+		v.Memory = formatBytes(c.RSSBytes)
+	}
+	if !c.LastProbeAt.IsZero() {
+		v.Latency = c.ProbeLatency.Round(time.Millisecond).String()
+	}
+	if c.Status == mcp.StatusRestarting && c.NextRestart.After(now) {
+		v.RetryIn = c.NextRestart.Sub(now).Round(time.Second).String()
+	}
+	return v
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.0f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// handleMCP lists every supervised MCP component with its health,
+// resources and log tail. The tails can hold secrets, so like the log
+// endpoint the page is only for the local user.
+func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if !ownerOnly(w, r) {
+		return
+	}
+	now := time.Now()
+	var views []mcpView
+	for _, c := range s.agg.MCPComponents() {
+		views = append(views, newMCPView(c, s.agg.MCPTail(c.ProjectPath, c.Component, mcpTailLines), now))
+	}
+	s.render(w, "mcp.html", map[string]any{
+		"Components": views,
+	})
+}
+
+// handleMCPAPI returns the supervised components as JSON. Statuses carry
+// log tails and errors, so it is owner-only too.
+func (s *Server) handleMCPAPI(w http.ResponseWriter, r *http.Request) {
+	if !ownerOnly(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.agg.MCPComponents())
+}
+
+// handleMCPLog serves a component's log tail as plain text:
+// /api/mcp/log?project=<path>&component=<name>&lines=<n>. Logs can hold
+// secrets, so only the local user may read them.
+func (s *Server) handleMCPLog(w http.ResponseWriter, r *http.Request) {
+	if !ownerOnly(w, r) {
+		return
+	}
+	project := r.URL.Query().Get("project")
+	component := r.URL.Query().Get("component")
+	if project == "" || component == "" {
+		http.Error(w, "project and component are required", http.StatusBadRequest)
+		return
+	}
+	if !mcp.ValidComponent(component) {
+		http.Error(w, "invalid component", http.StatusBadRequest)
+		return
+	}
+	known := false
+	for _, c := range s.agg.MCPComponents() {
+		known = known || (c.ProjectPath == project && c.Component == component)
+	}
+	if !known {
+		http.NotFound(w, r)
+		return
+	}
+	lines := 200
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid lines", http.StatusBadRequest)
+			return
+		}
+		lines = min(n, mcpMaxLogLines)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, line := range s.agg.MCPTail(project, component, lines) {
+		fmt.Fprintln(w, line)
+	}
+}
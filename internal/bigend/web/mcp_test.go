@@ -0,0 +1,82 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/mcp"
+)
+
+func TestMCPPage(t *testing.T) {
+	now := time.Now()
+	agg := &fakeAgg{
+		mcp: []mcp.ComponentStatus{
+			{ProjectPath: "/r/demo", Component: "server", Status: mcp.StatusRunning, Pid: 42, StartedAt: now.Add(-time.Minute),
+				Health: mcp.HealthHealthy, Probe: mcp.ProbeStdio, LastProbeAt: now, ProbeLatency: 3 * time.Millisecond,
+				CPUPercent: 12.5, RSSBytes: 64 << 20, Procs: 2, Restarts: 1, LogPath: "/logs/r_demo/server.log"},
+			{ProjectPath: "/r/demo", Component: "client", Status: mcp.StatusRestarting, NextRestart: now.Add(8 * time.Second),
+				LastError: "exit status 1"},
+		},
+		mcpTail: []string{"listening on stdio", "[bigend] restarting in 8s"},
+	}
+	srv := NewServer(config.ServerConfig{}, agg)
+	local := func(method, target string) *http.Request {
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = "127.0.0.1:40000"
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	srv.handleMCP(w, local(http.MethodGet, "/mcp"))
+	body := w.Body.String()
+	for _, want := range []string{"healthy", "12.5%", "64.0 MiB (2 procs)", "restarting", "retry in", "exit status 1",
+		"listening on stdio", "/logs/r_demo/server.log", "/api/projects//r/demo/mcp/client/restart"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in mcp page", want)
+		}
+	}
+	for name, handler := range map[string]http.HandlerFunc{"page": srv.handleMCP, "api": srv.handleMCPAPI} {
+		w = httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/mcp", nil))
+		if w.Code != http.StatusForbidden || strings.Contains(w.Body.String(), "listening on stdio") {
+			t.Errorf("remote %s: code %d, body %q", name, w.Code, w.Body.String())
+		}
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleProjectMCPAction(w, local(http.MethodPost, "/api/projects//r/demo/mcp/server/restart"))
+	if w.Code != http.StatusOK || agg.mcpRestarted != "/r/demo server" {
+		t.Errorf("restart: code %d, restarted %q", w.Code, agg.mcpRestarted)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleMCPLog(w, local(http.MethodGet, "/api/mcp/log?project=/r/demo&component=server&lines=10"))
+	if w.Body.String() != "listening on stdio\n[bigend] restarting in 8s\n" {
+		t.Errorf("log = %q", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	srv.handleMCPLog(w, local(http.MethodGet, "/api/mcp/log?project=/r/demo"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("log without component: code %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	srv.handleMCPLog(w, httptest.NewRequest(http.MethodGet, "/api/mcp/log?project=/r/demo&component=server", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("remote log: code %d", w.Code)
+	}
+	for target, code := range map[string]int{
+		"/api/mcp/log?project=/r/demo&component=../../etc/passwd": http.StatusBadRequest,
+		"/api/mcp/log?project=/r/demo&component=other":            http.StatusNotFound,
+		"/api/mcp/log?project=/r/other&component=server":          http.StatusNotFound,
+	} {
+		w = httptest.NewRecorder()
+		srv.handleMCPLog(w, local(http.MethodGet, target))
+		if w.Code != code {
+			t.Errorf("%s: code %d, want %d", target, w.Code, code)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+	"github.com/mistakeknot/autarch/internal/bigend/notify"
+)
+
+// resultClass colors a sink's outcome for a notification.
+func resultClass(result string) string {
+	switch result {
+	case notify.ResultSent:
+		return "bg-green-900 text-green-300"
+	case notify.ResultFailed:
+		return "bg-red-900 text-red-300"
+	case notify.ResultPending:
+		return "bg-blue-900 text-blue-300"
+	default: // held back by quiet hours or the rate limit
+		return "bg-gray-700 text-gray-300"
+	}
+}
+
+// severityClass colors a notification's severity.
+func severityClass(severity string) string {
+	switch severity {
+	case "critical":
+		return "text-red-400"
+	case "warning":
+		return "text-yellow-400"
+	default:
+		return "text-gray-400"
+	}
+}
+
+// handleNotifications shows the notification center: the sinks and what
+// each did with recent notifications.
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	s.render(w, "notifications.html", map[string]any{
+		"Sinks":         s.agg.NotificationSinks(),
+		"Notifications": s.agg.Notifications(),
+	})
+}
+
+// handleNotificationsAPI returns the sinks and recent notifications.
+func (s *Server) handleNotificationsAPI(w http.ResponseWriter, r *http.Request) {
+	sinks, history := s.agg.NotificationSinks(), s.agg.Notifications()
+	if sinks == nil {
+		sinks = []notify.SinkInfo{}
+	}
+	if history == nil {
+		history = []notify.Entry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"sinks":         sinks,
+		"notifications": history,
+	})
+}
+
+// handleNotificationTest sends a test notification to every sink. Sinks
+// reach outside Bigend, so only the local user may trigger one.
+func (s *Server) handleNotificationTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ownerOnly(w, r) {
+		return
+	}
+	if err := s.agg.TestNotification(); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, aggregator.ErrNotificationsDisabled) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/notify"
+)
+
+func TestNotificationsPage(t *testing.T) {
+	agg := &fakeAgg{
+		sinks: []notify.SinkInfo{
+			{Name: "desk", Type: notify.TypeDesktop, QuietHours: "22:00-07:00", Quiet: true},
+			{Name: "hook", Type: notify.TypeWebhook, Routes: 2, RateLimit: 5, RateWindow: time.Minute},
+		},
+		notifications: []notify.Entry{{
+			Notification: notify.Notification{Kind: notify.KindState, Title: "api-claude is error", Body: "claude agent in /srv/api", Severity: "critical", State: "error", At: time.Now()},
+			Outcomes: []notify.Outcome{
+				{Sink: "desk", Result: notify.ResultQuiet},
+				{Sink: "hook", Result: notify.ResultFailed, Error: "webhook: 500 Internal Server Error"},
+			},
+		}},
+	}
+	srv := NewServer(config.ServerConfig{}, agg)
+
+	w := httptest.NewRecorder()
+	srv.handleNotifications(w, httptest.NewRequest(http.MethodGet, "/notifications", nil))
+	body := w.Body.String()
+	for _, want := range []string{"22:00-07:00", "(quiet now)", "5 per 1m0s", "api-claude is error", "desk: quiet", "hook: failed", "webhook: 500", "Send test"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in notifications page", want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleNotificationsAPI(w, httptest.NewRequest(http.MethodGet, "/api/notifications", nil))
+	var got struct {
+		Sinks         []notify.SinkInfo `json:"sinks"`
+		Notifications []notify.Entry    `json:"notifications"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || len(got.Sinks) != 2 || got.Notifications[0].Outcomes[1].Sink != "hook" {
+		t.Errorf("api: %+v (%v)", got, err)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleNotificationTest(w, httptest.NewRequest(http.MethodPost, "/api/notifications/test", nil))
+	if w.Code != http.StatusForbidden || agg.tested {
+		t.Errorf("remote test: code %d, sent %v", w.Code, agg.tested)
+	}
+	local := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/notifications/test", nil)
+		req.RemoteAddr = "127.0.0.1:40000"
+		return req
+	}
+	w = httptest.NewRecorder()
+	srv.handleNotificationTest(w, local())
+	if w.Code != http.StatusAccepted || !agg.tested {
+		t.Errorf("test: code %d, sent %v", w.Code, agg.tested)
+	}
+	w = httptest.NewRecorder()
+	NewServer(config.ServerConfig{}, &fakeAgg{}).handleNotificationTest(w, local())
+	if w.Code != http.StatusConflict {
+		t.Errorf("test without sinks: code %d", w.Code)
+	}
+}
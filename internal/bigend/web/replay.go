@@ -0,0 +1,121 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+)
+
+// handleSessionRoutes handles /sessions/* routes: the replay page at
+// /sessions/{name}/replay[?file=...] and the live terminal at
+// /sessions/{name}/terminal[?token=...].
+func (s *Server) handleSessionRoutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/sessions/")
+	if name, ok := strings.CutSuffix(path, "/replay"); ok && name != "" {
+		s.handleReplay(w, r, name)
+		return
+	}
+	if name, ok := strings.CutSuffix(path, "/terminal"); ok && name != "" {
+		s.handleTerminal(w, r, name)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request, session string) {
+	if !ownerOnly(w, r) {
+		return
+	}
+	dir := s.agg.RecordingDir()
+	var recordings []recording.Info
+	if dir != "" {
+		var err error
+		if recordings, err = recording.List(dir, session); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	selected := r.URL.Query().Get("file")
+	if selected == "" && len(recordings) > 0 {
+		selected = recordings[0].Name
+	}
+	s.render(w, "replay.html", map[string]any{
+		"Session":    session,
+		"Enabled":    dir != "",
+		"Recordings": recordings,
+		"Selected":   selected,
+	})
+}
+
+// handleRecordingsAPI serves recordings:
+//
+//	GET /api/recordings/{session}              recordings of a session, newest first
+//	GET /api/recordings/{session}/{file}       the asciicast file
+//	GET /api/recordings/{session}/{file}?q=... frames where the text appears
+//
+// Recordings are for the local user only.
+func (s *Server) handleRecordingsAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ownerOnly(w, r) {
+		return
+	}
+	dir := s.agg.RecordingDir()
+	if dir == "" {
+		http.Error(w, "recording is disabled", http.StatusNotFound)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/api/recordings/")
+	session, file, _ := strings.Cut(path, "/")
+	if session == "" {
+		http.NotFound(w, r)
+		return
+	}
+	recordings, err := recording.List(dir, session)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if file == "" {
+		if recordings == nil {
+			recordings = []recording.Info{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(recordings)
+		return
+	}
+
+	// Only files listed for the session are served, which also keeps the
+	// path inside the recordings directory.
+	var info *recording.Info
+	for i := range recordings {
+		if recordings[i].Name == file {
+			info = &recordings[i]
+			break
+		}
+	}
+	if info == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if q := r.URL.Query().Get("q"); q != "" {
+		cast, err := recording.Load(info.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		matches := cast.Search(q)
+		if matches == nil {
+			matches = []recording.Match{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(matches)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	http.ServeFile(w, r, info.Path)
+}
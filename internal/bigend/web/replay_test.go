@@ -0,0 +1,95 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/recording"
+)
+
+func TestReplayPageAndRecordingsAPI(t *testing.T) {
+	dir := t.TempDir()
+	rec := recording.NewRecorder(dir)
+	at := time.Date(2026, 3, 4, 10, 0, 0, 0, time.Local)
+	if err := rec.Capture("claude-api", "FAIL pkg/db", at); err != nil {
+		t.Fatal(err)
+	}
+	rec.Close()
+	const file = "claude-api-20260304-100000.cast"
+
+	srv := NewServer(config.ServerConfig{}, &fakeAgg{recordingDir: dir})
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "127.0.0.1:40000"
+		if strings.HasPrefix(path, "/api/") {
+			srv.handleRecordingsAPI(w, req)
+		} else {
+			srv.handleSessionRoutes(w, req)
+		}
+		return w
+	}
+
+	w := get("/sessions/claude-api/replay")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), file) {
+		t.Fatalf("expected the replay page to offer %s, got %d", file, w.Code)
+	}
+
+	var list []recording.Info
+	w = get("/api/recordings/claude-api")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].Name != file {
+		t.Fatalf("unexpected list %d: %s", w.Code, w.Body.String())
+	}
+
+	w = get("/api/recordings/claude-api/" + file)
+	if cast, err := recording.Decode(w.Body); err != nil || len(cast.Frames()) != 1 {
+		t.Fatalf("expected the cast file, got %d (%v)", w.Code, err)
+	}
+
+	var matches []recording.Match
+	w = get("/api/recordings/claude-api/" + file + "?q=fail")
+	if err := json.Unmarshal(w.Body.Bytes(), &matches); err != nil || len(matches) != 1 || matches[0].Line != "FAIL pkg/db" {
+		t.Fatalf("unexpected matches: %s", w.Body.String())
+	}
+
+	if w = get("/api/recordings/other/" + file); w.Code != http.StatusNotFound {
+		t.Fatalf("expected another session's file to be refused, got %d", w.Code)
+	}
+	if w = get("/api/recordings/claude-api/..%2Fsecret.cast"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected an unlisted file to be refused, got %d", w.Code)
+	}
+
+	for _, path := range []string{"/sessions/claude-api/replay", "/api/recordings/claude-api/" + file} {
+		remote := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.0.2.1:5000"
+		if strings.HasPrefix(path, "/api/") {
+			srv.handleRecordingsAPI(remote, req)
+		} else {
+			srv.handleSessionRoutes(remote, req)
+		}
+		if remote.Code != http.StatusForbidden {
+			t.Fatalf("expected %s to be refused to a remote viewer, got %d", path, remote.Code)
+		}
+	}
+	shared := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/recordings/claude-api?token=abc", nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	if srv.handleRecordingsAPI(shared, req); shared.Code != http.StatusForbidden {
+		t.Fatalf("expected a share token not to reach recordings, got %d", shared.Code)
+	}
+
+	off := NewServer(config.ServerConfig{}, &fakeAgg{})
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/sessions/claude-api/replay", nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	off.handleSessionRoutes(w, req)
+	if !strings.Contains(w.Body.String(), "Recording is disabled") {
+		t.Fatalf("expected the disabled notice, got %d", w.Code)
+	}
+}
@@ -11,15 +11,21 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
 	"github.com/mistakeknot/autarch/internal/bigend/coldwine"
 	"github.com/mistakeknot/autarch/internal/bigend/config"
 	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+	"github.com/mistakeknot/autarch/internal/bigend/federation"
+	"github.com/mistakeknot/autarch/internal/bigend/mcp"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
+	"github.com/mistakeknot/autarch/internal/bigend/notify"
+	"github.com/mistakeknot/autarch/internal/bigend/rules"
+	"github.com/mistakeknot/autarch/internal/bigend/share"
 	"github.com/mistakeknot/autarch/internal/bigend/tmux"
 	"github.com/mistakeknot/autarch/pkg/intermute"
-	"nhooyr.io/websocket"
 )
 
 //go:embed templates/*.html
@@ -32,6 +38,10 @@ type Server struct {
 	statusClient statusClient
 	templates    map[string]*template.Template
 	srv          *http.Server
+	shares       *share.Store
+	shareTTL     time.Duration
+	control      *share.Control
+	viewerSeq    atomic.Int64
 }
 
 type aggregatorAPI interface {
@@ -46,11 +56,22 @@ type aggregatorAPI interface {
 	GetActiveReservations() ([]intermute.Reservation, error)
 	NewSession(name, projectPath, agentType string) error
 	RestartSession(name, projectPath, agentType string) error
+	DisposeSession(name string) error
 	RenameSession(oldName, newName string) error
 	ForkSession(name, projectPath, agentType string) error
 	AttachSession(name string) error
 	StartMCP(ctx context.Context, projectPath, component string) error
 	StopMCP(projectPath, component string) error
+	RestartMCP(ctx context.Context, projectPath, component string) error
+	MCPComponents() []mcp.ComponentStatus
+	MCPTail(projectPath, component string, n int) []string
+	Notifications() []notify.Entry
+	NotificationSinks() []notify.SinkInfo
+	TestNotification() error
+	RecordingDir() string
+	RuleFirings() []rules.Firing
+	Metrics() []metrics.Sample
+	MetricSeries(q metrics.Query) ([]metrics.Point, error)
 }
 
 type statusClient interface {
@@ -70,11 +91,30 @@ func NewServer(cfg config.ServerConfig, agg aggregatorAPI) *Server {
 		agg:          agg,
 		statusClient: tmux.NewClient(),
 		templates:    make(map[string]*template.Template),
+		shareTTL:     cfg.Share.TTL,
+		control:      share.NewControl(),
+	}
+	if cfg.Share.Path != "" {
+		store, err := share.Open(cfg.Share.Path)
+		if err != nil {
+			slog.Warn("terminal sharing disabled", "error", err)
+		} else {
+			s.shares = store
+		}
+	}
+	if s.shareTTL <= 0 {
+		s.shareTTL = 24 * time.Hour
 	}
 
 	// Template functions
 	funcs := template.FuncMap{
 		"basename": filepath.Base,
+		"localName": func(id string) string {
+			_, name := federation.Split(id)
+			return name
+		},
+		"resultClass":   resultClass,
+		"severityClass": severityClass,
 	}
 
 	// Load templates - each page gets its own template with layout
@@ -83,7 +123,7 @@ func NewServer(cfg config.ServerConfig, agg aggregatorAPI) *Server {
 	layoutStr := string(layoutBytes)
 
 	// Pages to load
-	pages := []string{"dashboard.html", "projects.html", "agents.html", "sessions.html", "tasks.html", "agent_detail.html"}
+	pages := []string{"dashboard.html", "projects.html", "agents.html", "sessions.html", "tasks.html", "agent_detail.html", "replay.html", "trends.html", "terminal.html", "colonies.html", "mcp.html", "notifications.html"}
 
 	for _, page := range pages {
 		pageBytes, err := fs.ReadFile(tmplFS, page)
@@ -110,11 +150,26 @@ func (s *Server) ListenAndServe(addr string) error {
 	mux.HandleFunc("/agents", s.handleAgents)
 	mux.HandleFunc("/agents/", s.handleAgentDetail)
 	mux.HandleFunc("/sessions", s.handleSessions)
+	mux.HandleFunc("/sessions/", s.handleSessionRoutes)
+	mux.HandleFunc("/trends", s.handleTrends)
+	mux.HandleFunc("/colonies", s.handleColonies)
+	mux.HandleFunc("/mcp", s.handleMCP)
+	mux.HandleFunc("/notifications", s.handleNotifications)
+	mux.HandleFunc("/metrics", s.handlePrometheus)
 	mux.HandleFunc("/api/sessions/new", s.handleSessionNew)
 	mux.HandleFunc("/api/sessions/", s.handleSessionAction)
 	mux.HandleFunc("/api/projects/", s.handleProjectMCPAction)
 	mux.HandleFunc("/api/refresh", s.handleRefresh)
 	mux.HandleFunc("/api/agents", s.handleAgentsAPI)
+	mux.HandleFunc("/api/colonies", s.handleColoniesAPI)
+	mux.HandleFunc("/api/mcp", s.handleMCPAPI)
+	mux.HandleFunc("/api/mcp/log", s.handleMCPLog)
+	mux.HandleFunc("/api/notifications", s.handleNotificationsAPI)
+	mux.HandleFunc("/api/notifications/test", s.handleNotificationTest)
+	mux.HandleFunc("/api/recordings/", s.handleRecordingsAPI)
+	mux.HandleFunc("/api/rules/firings", s.handleRuleFirings)
+	mux.HandleFunc("/api/shares", s.handleShares)
+	mux.HandleFunc("/api/shares/", s.handleShares)
 
 	// WebSocket for terminal streaming
 	mux.HandleFunc("/ws/terminal/", s.handleTerminalWS)
@@ -132,7 +187,11 @@ func (s *Server) ListenAndServe(addr string) error {
 
 // Shutdown gracefully stops the server
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.srv.Shutdown(ctx)
+	err := s.srv.Shutdown(ctx)
+	if s.shares != nil {
+		s.shares.Close()
+	}
+	return err
 }
 
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
@@ -142,8 +201,11 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	state := s.agg.GetState()
+	window, _ := trendWindow(defaultTrendRange)
+	trends, _ := s.trendCharts("", window, time.Now())
 	s.render(w, "dashboard.html", map[string]any{
-		"State": state,
+		"State":  state,
+		"Trends": trends,
 	})
 }
 
@@ -268,6 +330,9 @@ func (s *Server) handleSessionNew(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if !ownerOnly(w, r) {
+		return
+	}
 	var payload sessionActionPayload
 	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -302,6 +367,9 @@ func (s *Server) handleSessionAction(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if !ownerOnly(w, r) {
+		return
+	}
 	path := strings.TrimPrefix(r.URL.Path, "/api/sessions/")
 	parts := strings.Split(path, "/")
 	if len(parts) < 2 {
@@ -328,6 +396,20 @@ func (s *Server) handleSessionAction(w http.ResponseWriter, r *http.Request) {
 		}
 		w.WriteHeader(http.StatusOK)
 		return
+	case "share":
+		s.createShare(w, r, name)
+		return
+	case "dispose":
+		if _, ok := findSession(s.agg.GetState(), name); !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if err := s.agg.DisposeSession(name); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
 	case "rename":
 		var payload renamePayload
 		if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
@@ -400,111 +482,20 @@ func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleAgentsAPI(w http.ResponseWriter, r *http.Request) {
-	state := s.agg.GetState()
+// handleRuleFirings returns recent automation rule firings, newest first.
+func (s *Server) handleRuleFirings(w http.ResponseWriter, r *http.Request) {
+	firings := s.agg.RuleFirings()
+	if firings == nil {
+		firings = []rules.Firing{}
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(state.Agents)
+	json.NewEncoder(w).Encode(firings)
 }
 
-// handleTerminalWS streams terminal output for a session via WebSocket.
-//
-// Path format: /ws/terminal/{session_name}
-//
-// The server sends JSON messages with two types:
-//   - {"type": "output", "content": "..."} - terminal output
-//   - {"type": "error", "message": "..."} - error message
-func (s *Server) handleTerminalWS(w http.ResponseWriter, r *http.Request) {
-	// Extract session name from path
-	sessionName := strings.TrimPrefix(r.URL.Path, "/ws/terminal/")
-	if sessionName == "" {
-		http.Error(w, "session name required", http.StatusBadRequest)
-		return
-	}
-
-	// Verify session exists
+func (s *Server) handleAgentsAPI(w http.ResponseWriter, r *http.Request) {
 	state := s.agg.GetState()
-	var found bool
-	for _, session := range state.Sessions {
-		if session.Name == sessionName {
-			found = true
-			break
-		}
-	}
-	if !found {
-		http.NotFound(w, r)
-		return
-	}
-
-	// Accept WebSocket connection
-	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
-		OriginPatterns: []string{"*"}, // Allow all origins for local development
-	})
-	if err != nil {
-		slog.Error("websocket accept failed", "error", err)
-		return
-	}
-	defer conn.Close(websocket.StatusNormalClosure, "closing")
-
-	ctx := r.Context()
-
-	// Get tmux client for capturing pane
-	tmuxClient, ok := s.statusClient.(*tmux.Client)
-	if !ok {
-		errMsg := struct {
-			Type    string `json:"type"`
-			Message string `json:"message"`
-		}{
-			Type:    "error",
-			Message: "tmux client not available",
-		}
-		data, _ := json.Marshal(errMsg)
-		conn.Write(ctx, websocket.MessageText, data)
-		return
-	}
-
-	// Stream terminal output at ~10 FPS
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	var lastOutput string
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			// Capture pane output
-			output, err := tmuxClient.CapturePane(sessionName, 50)
-			if err != nil {
-				errMsg := struct {
-					Type    string `json:"type"`
-					Message string `json:"message"`
-				}{
-					Type:    "error",
-					Message: "session ended or capture failed",
-				}
-				data, _ := json.Marshal(errMsg)
-				conn.Write(ctx, websocket.MessageText, data)
-				return
-			}
-
-			// Only send if output changed
-			if output != lastOutput {
-				msg := struct {
-					Type    string `json:"type"`
-					Content string `json:"content"`
-				}{
-					Type:    "output",
-					Content: output,
-				}
-				data, _ := json.Marshal(msg)
-
-				if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
-					return // Client disconnected
-				}
-				lastOutput = output
-			}
-		}
-	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state.Agents)
 }
 
 func (s *Server) handleProjectMCPAction(w http.ResponseWriter, r *http.Request) {
@@ -512,6 +503,9 @@ func (s *Server) handleProjectMCPAction(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if !ownerOnly(w, r) {
+		return
+	}
 	path := strings.TrimPrefix(r.URL.Path, "/api/projects")
 	if !strings.Contains(path, "/mcp/") {
 		http.NotFound(w, r)
@@ -542,6 +536,12 @@ func (s *Server) handleProjectMCPAction(w http.ResponseWriter, r *http.Request)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
+	case "restart":
+		if err := s.agg.RestartMCP(r.Context(), projectPath, component); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
 	default:
 		http.NotFound(w, r)
 	}
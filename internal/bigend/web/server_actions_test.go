@@ -10,6 +10,10 @@ import (
 	"github.com/mistakeknot/autarch/internal/bigend/coldwine"
 	"github.com/mistakeknot/autarch/internal/bigend/config"
 	"github.com/mistakeknot/autarch/internal/bigend/discovery"
+	"github.com/mistakeknot/autarch/internal/bigend/mcp"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
+	"github.com/mistakeknot/autarch/internal/bigend/notify"
+	"github.com/mistakeknot/autarch/internal/bigend/rules"
 	"github.com/mistakeknot/autarch/pkg/intermute"
 )
 
@@ -19,6 +23,16 @@ type fakeAgg struct {
 	restartName    string
 	restartProject string
 	restartType    string
+	recordingDir   string
+	disposed       string
+	samples        []metrics.Sample
+	series         map[string][]metrics.Point // by metric name; nil when metrics are disabled
+	mcp            []mcp.ComponentStatus
+	mcpTail        []string
+	mcpRestarted   string
+	notifications  []notify.Entry
+	sinks          []notify.SinkInfo
+	tested         bool
 }
 
 func (f *fakeAgg) GetState() aggregator.State                 { return f.state }
@@ -37,11 +51,37 @@ func (f *fakeAgg) GetAgentReservations(agentID string) ([]intermute.Reservation,
 }
 func (f *fakeAgg) GetActiveReservations() ([]intermute.Reservation, error)      { return nil, nil }
 func (f *fakeAgg) NewSession(name, projectPath, agentType string) error         { return nil }
+func (f *fakeAgg) DisposeSession(name string) error                             { f.disposed = name; return nil }
 func (f *fakeAgg) RenameSession(oldName, newName string) error                  { return nil }
 func (f *fakeAgg) ForkSession(name, projectPath, agentType string) error        { return nil }
 func (f *fakeAgg) AttachSession(name string) error                              { return nil }
 func (f *fakeAgg) StartMCP(ctx context.Context, projectPath, component string) error { return nil }
 func (f *fakeAgg) StopMCP(projectPath, component string) error                  { return nil }
+func (f *fakeAgg) RestartMCP(ctx context.Context, projectPath, component string) error {
+	f.mcpRestarted = projectPath + " " + component
+	return nil
+}
+func (f *fakeAgg) MCPComponents() []mcp.ComponentStatus                      { return f.mcp }
+func (f *fakeAgg) MCPTail(projectPath, component string, n int) []string     { return f.mcpTail }
+func (f *fakeAgg) Notifications() []notify.Entry                            { return f.notifications }
+func (f *fakeAgg) NotificationSinks() []notify.SinkInfo                     { return f.sinks }
+func (f *fakeAgg) TestNotification() error {
+	if f.sinks == nil {
+		return aggregator.ErrNotificationsDisabled
+	}
+	f.tested = true
+	return nil
+}
+
+func (f *fakeAgg) RecordingDir() string        { return f.recordingDir }
+func (f *fakeAgg) RuleFirings() []rules.Firing { return nil }
+func (f *fakeAgg) Metrics() []metrics.Sample   { return f.samples }
+func (f *fakeAgg) MetricSeries(q metrics.Query) ([]metrics.Point, error) {
+	if f.series == nil {
+		return nil, aggregator.ErrMetricsDisabled
+	}
+	return f.series[q.Name], nil
+}
 
 func (f *fakeAgg) RestartSession(name, projectPath, agentType string) error {
 	f.restartCalled = true
@@ -66,6 +106,13 @@ func TestRestartSessionEndpoint(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/api/sessions/demo/restart", nil)
 	w := httptest.NewRecorder()
 
+	srv.handleSessionAction(w, req)
+	if w.Code != http.StatusForbidden || agg.restartCalled {
+		t.Fatalf("expected a remote restart to be refused, got %d", w.Code)
+	}
+
+	req.RemoteAddr = "127.0.0.1:40000"
+	w = httptest.NewRecorder()
 	srv.handleSessionAction(w, req)
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", w.Code)
@@ -0,0 +1,438 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/federation"
+	"github.com/mistakeknot/autarch/internal/bigend/share"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// terminalClient is the part of the tmux client a terminal stream uses.
+type terminalClient interface {
+	CapturePane(sessionName string, lines int) (string, error)
+	SendKeys(sessionName string, keys string) error
+	SendLiteral(sessionName string, text string) error
+}
+
+// ownerActor names the local user in the audit log.
+const ownerActor = "owner"
+
+// shareCheckEvery is how many output ticks pass between checks that a
+// viewer's token is still active.
+const shareCheckEvery = 20
+
+var (
+	errShareRequired = errors.New("share token required")
+	errReadOnly      = errors.New("this share is read-only")
+	errNotInControl  = errors.New("take control before typing")
+	errSharing       = errors.New("terminal sharing is disabled")
+	errShareEnded    = errors.New("share revoked or expired")
+
+	// tmuxKey matches tmux key names such as Enter, BSpace, C-c or M-Left.
+	tmuxKey = regexp.MustCompile(`^(?:[CMS]-)*[A-Za-z0-9]{1,12}$`)
+)
+
+// terminalMessage is sent to terminal viewers.
+type terminalMessage struct {
+	Type    string      `json:"type"` // output, error or control
+	Content string      `json:"content,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Actor   string      `json:"actor,omitempty"`  // control: the viewer
+	Scope   share.Scope `json:"scope,omitempty"`  // control: the viewer's scope
+	Holder  string      `json:"holder,omitempty"` // control: who may type
+	You     bool        `json:"you,omitempty"`    // control: the viewer holds control
+}
+
+// terminalInput is sent by terminal viewers.
+type terminalInput struct {
+	Type string `json:"type"`           // input or take
+	Data string `json:"data,omitempty"` // literal text
+	Key  string `json:"key,omitempty"`  // a tmux key name
+}
+
+// isLocalRequest reports whether the request comes from this machine.
+func isLocalRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isOwner reports whether the request is from the local user rather than
+// a share link.
+func isOwner(r *http.Request) bool {
+	return r.URL.Query().Get("token") == "" && isLocalRequest(r)
+}
+
+// ownerOnly answers 403 unless the request is from the local user. Share
+// links only reach the terminal they were issued for.
+func ownerOnly(w http.ResponseWriter, r *http.Request) bool {
+	if isOwner(r) {
+		return true
+	}
+	http.Error(w, "only available from the host running Bigend", http.StatusForbidden)
+	return false
+}
+
+// viewerFor authorizes a terminal viewer of session. The local user may
+// type; everyone else needs a share token and gets its scope.
+func (s *Server) viewerFor(r *http.Request, session string) (share.Viewer, int, error) {
+	v := share.Viewer{ID: "v" + strconv.FormatInt(s.viewerSeq.Add(1), 10)}
+	secret := r.URL.Query().Get("token")
+	if secret == "" {
+		if !isLocalRequest(r) {
+			return v, http.StatusUnauthorized, errShareRequired
+		}
+		v.Actor, v.Scope = ownerActor, share.ScopeInteract
+		return v, 0, nil
+	}
+	if s.shares == nil {
+		return v, http.StatusServiceUnavailable, errSharing
+	}
+	t, err := s.shares.Authorize(session, secret)
+	if err != nil {
+		return v, http.StatusForbidden, err
+	}
+	v.Actor, v.TokenID, v.Scope = t.Label, t.ID, t.Scope
+	return v, 0, nil
+}
+
+// handleTerminalWS streams a session's pane to a viewer via WebSocket and
+// forwards the keystrokes of the viewer in control.
+//
+// Path format: /ws/terminal/{session_name}[?token=...]
+//
+// The server sends JSON messages with three types:
+//   - {"type": "output", "content": "..."} - terminal output
+//   - {"type": "control", "actor": "...", "scope": "...", "holder": "...", "you": true} - who may type
+//   - {"type": "error", "message": "..."} - error message
+//
+// Viewers send {"type": "take"} to take control, then
+// {"type": "input", "data": "..."} for text or {"type": "input", "key": "Enter"}.
+func (s *Server) handleTerminalWS(w http.ResponseWriter, r *http.Request) {
+	sessionName := strings.TrimPrefix(r.URL.Path, "/ws/terminal/")
+	if sessionName == "" {
+		http.Error(w, "session name required", http.StatusBadRequest)
+		return
+	}
+	if host, _ := federation.Split(sessionName); host != "" {
+		http.Error(w, "terminals stream from the host that runs them", http.StatusBadRequest)
+		return
+	}
+	if _, ok := findSession(s.agg.GetState(), sessionName); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	viewer, status, err := s.viewerFor(r, sessionName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// The local user's browser must be on this origin, so other pages it
+	// visits cannot drive the session; share links carry their own token.
+	opts := &websocket.AcceptOptions{}
+	if viewer.TokenID != "" {
+		opts.OriginPatterns = []string{"*"}
+	}
+	conn, err := websocket.Accept(w, r, opts)
+	if err != nil {
+		slog.Error("websocket accept failed", "error", err)
+		return
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "closing")
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	term, ok := s.statusClient.(terminalClient)
+	if !ok {
+		wsjson.Write(ctx, conn, terminalMessage{Type: "error", Message: "tmux client not available"})
+		return
+	}
+
+	s.audit(sessionName, viewer, share.EventJoin, string(viewer.Scope))
+	defer func() {
+		s.control.Release(sessionName, viewer.ID)
+		s.audit(sessionName, viewer, share.EventLeave, "")
+	}()
+	go s.readTerminalInput(ctx, cancel, conn, term, sessionName, viewer)
+
+	// Stream terminal output at ~10 FPS
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	var lastOutput string
+	lastHolder, sentControl := "", false
+	for tick := 1; ; tick++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if viewer.TokenID != "" && tick%shareCheckEvery == 0 && !s.shares.Valid(viewer.TokenID) {
+			wsjson.Write(ctx, conn, terminalMessage{Type: "error", Message: errShareEnded.Error()})
+			return
+		}
+
+		holder, _ := s.control.Holder(sessionName)
+		if !sentControl || holder.ID != lastHolder {
+			msg := terminalMessage{Type: "control", Actor: viewer.Actor, Scope: viewer.Scope, Holder: holder.Actor, You: holder.ID == viewer.ID}
+			if err := wsjson.Write(ctx, conn, msg); err != nil {
+				return
+			}
+			lastHolder, sentControl = holder.ID, true
+		}
+
+		output, err := term.CapturePane(sessionName, 50)
+		if err != nil {
+			wsjson.Write(ctx, conn, terminalMessage{Type: "error", Message: "session ended or capture failed"})
+			return
+		}
+		// Only send if output changed
+		if output != lastOutput {
+			if err := wsjson.Write(ctx, conn, terminalMessage{Type: "output", Content: output}); err != nil {
+				return // Client disconnected
+			}
+			lastOutput = output
+		}
+	}
+}
+
+// readTerminalInput handles a viewer's messages until it disconnects.
+func (s *Server) readTerminalInput(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, term terminalClient, session string, viewer share.Viewer) {
+	defer cancel()
+	for {
+		var in terminalInput
+		if err := wsjson.Read(ctx, conn, &in); err != nil {
+			return
+		}
+		var err error
+		switch in.Type {
+		case "take":
+			err = s.takeControl(session, viewer)
+		case "input":
+			err = s.sendInput(term, session, viewer, in)
+		default:
+			err = fmt.Errorf("unknown message type %q", in.Type)
+		}
+		if err != nil {
+			if werr := wsjson.Write(ctx, conn, terminalMessage{Type: "error", Message: err.Error()}); werr != nil || errors.Is(err, errShareEnded) {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) takeControl(session string, viewer share.Viewer) error {
+	if !s.shareValid(viewer) {
+		s.audit(session, viewer, share.EventDenied, "take control")
+		return errShareEnded
+	}
+	if !viewer.CanType() {
+		s.audit(session, viewer, share.EventDenied, "take control")
+		return errReadOnly
+	}
+	prev, replaced := s.control.Take(session, viewer)
+	data := ""
+	if replaced {
+		data = "from " + prev.Actor
+	}
+	s.audit(session, viewer, share.EventControl, data)
+	return nil
+}
+
+func (s *Server) sendInput(term terminalClient, session string, viewer share.Viewer, in terminalInput) error {
+	keys := in.Data
+	if in.Key != "" {
+		keys = "<" + in.Key + ">"
+	}
+	if keys == "" {
+		return nil
+	}
+	// A revoked share must not get another keystroke in, however soon
+	// after the revocation the frame arrives.
+	switch {
+	case !s.shareValid(viewer):
+		s.audit(session, viewer, share.EventDenied, keys)
+		return errShareEnded
+	case !viewer.CanType():
+		s.audit(session, viewer, share.EventDenied, keys)
+		return errReadOnly
+	case !s.control.Holds(session, viewer.ID):
+		s.audit(session, viewer, share.EventDenied, keys)
+		return errNotInControl
+	}
+	var err error
+	if in.Key != "" {
+		if !tmuxKey.MatchString(in.Key) {
+			return fmt.Errorf("unsupported key %q", in.Key)
+		}
+		err = term.SendKeys(session, in.Key)
+	} else {
+		err = term.SendLiteral(session, in.Data)
+	}
+	if err != nil {
+		return err
+	}
+	s.audit(session, viewer, share.EventKeys, keys)
+	return nil
+}
+
+// shareValid reports whether the viewer's share, if it has one, is still
+// live. The local user needs none.
+func (s *Server) shareValid(viewer share.Viewer) bool {
+	return viewer.TokenID == "" || s.shares.Valid(viewer.TokenID)
+}
+
+func (s *Server) audit(session string, viewer share.Viewer, kind, data string) {
+	if s.shares == nil {
+		return
+	}
+	e := share.Event{Session: session, TokenID: viewer.TokenID, Actor: viewer.Actor, Kind: kind, Data: data}
+	if err := s.shares.Record(e); err != nil {
+		slog.Warn("share audit failed", "session", session, "error", err)
+	}
+}
+
+// createShare issues a share token for a session. Only the local user may
+// share; the response holds the secret and the link to hand out.
+func (s *Server) createShare(w http.ResponseWriter, r *http.Request, name string) {
+	if !s.requireOwner(w, r) {
+		return
+	}
+	if _, ok := findSession(s.agg.GetState(), name); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if host, _ := federation.Split(name); host != "" {
+		http.Error(w, "share the session from the host that runs it", http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	scope, err := share.ParseScope(r.FormValue("scope"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ttl := s.shareTTL
+	if v := r.FormValue("ttl"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid ttl", http.StatusBadRequest)
+			return
+		}
+		ttl = min(d, s.shareTTL)
+	}
+	token, secret, err := s.shares.Create(name, scope, r.FormValue("label"), ttl)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"token":  token,
+		"secret": secret,
+		"url":    "/sessions/" + url.PathEscape(name) + "/terminal?token=" + secret,
+	})
+}
+
+// handleShares serves the local user's share management API:
+//
+//	GET  /api/shares?session=NAME         - tokens, newest first
+//	GET  /api/shares/audit?session=NAME   - audit log, newest first
+//	POST /api/shares/{id}/revoke          - revoke a token
+func (s *Server) handleShares(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOwner(w, r) {
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/shares"), "/")
+	session := r.URL.Query().Get("session")
+	var data any
+	var err error
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		data, err = s.shares.Tokens(session)
+	case path == "audit" && r.Method == http.MethodGet:
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		data, err = s.shares.Audit(session, limit)
+	case strings.HasSuffix(path, "/revoke") && r.Method == http.MethodPost:
+		if err := s.shares.Revoke(strings.TrimSuffix(path, "/revoke")); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+func (s *Server) requireOwner(w http.ResponseWriter, r *http.Request) bool {
+	switch {
+	case s.shares == nil:
+		http.Error(w, errSharing.Error(), http.StatusServiceUnavailable)
+	case !isOwner(r):
+		http.Error(w, "sharing is managed from the host running Bigend", http.StatusForbidden)
+	default:
+		return true
+	}
+	return false
+}
+
+// handleTerminal shows a live session. The local user also gets the
+// share controls and audit log.
+func (s *Server) handleTerminal(w http.ResponseWriter, r *http.Request, session string) {
+	if _, ok := findSession(s.agg.GetState(), session); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	owner := isOwner(r)
+	data := map[string]any{
+		"Session": session,
+		"Token":   r.URL.Query().Get("token"),
+		"Owner":   owner,
+		"Sharing": s.shares != nil,
+		"TTL":     s.shareTTL.String(),
+	}
+	if owner && s.shares != nil {
+		tokens, err := s.shares.Tokens(session)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		events, err := s.shares.Audit(session, 50)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data["Tokens"], data["Audit"], data["Now"] = tokens, events, time.Now()
+	}
+	s.render(w, "terminal.html", data)
+}
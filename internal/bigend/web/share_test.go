@@ -0,0 +1,253 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/share"
+	"github.com/mistakeknot/autarch/internal/bigend/tmux"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+type fakeTerminal struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (f *fakeTerminal) DetectStatus(name string) tmux.Status { return tmux.StatusUnknown }
+func (f *fakeTerminal) CapturePane(name string, lines int) (string, error) {
+	return "$ ", nil
+}
+func (f *fakeTerminal) SendKeys(name, keys string) error { return f.send("<" + keys + ">") }
+func (f *fakeTerminal) SendLiteral(name, text string) error {
+	return f.send(text)
+}
+func (f *fakeTerminal) send(s string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, s)
+	return nil
+}
+func (f *fakeTerminal) keys() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return strings.Join(f.sent, "")
+}
+
+func newShareServer(t *testing.T) (*Server, *fakeTerminal) {
+	t.Helper()
+	cfg := config.ServerConfig{Share: config.ShareConfig{Path: filepath.Join(t.TempDir(), "share.db"), TTL: time.Hour}}
+	srv := NewServer(cfg, &fakeAgg{state: aggregator.State{Sessions: []aggregator.TmuxSession{{Name: "claude-api"}}}})
+	t.Cleanup(func() { srv.shares.Close() })
+	term := &fakeTerminal{}
+	srv.statusClient = term
+	return srv, term
+}
+
+// shareLink creates a share as the local user and returns its secret.
+func shareLink(t *testing.T, srv *Server, scope, label string) string {
+	t.Helper()
+	form := url.Values{"scope": {scope}, "label": {label}}
+	req := httptest.NewRequest(http.MethodPost, "/api/sessions/claude-api/share", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "127.0.0.1:40000"
+	w := httptest.NewRecorder()
+	srv.handleSessionAction(w, req)
+	var resp struct {
+		Secret string `json:"secret"`
+		URL    string `json:"url"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Secret == "" || !strings.HasSuffix(resp.URL, "/terminal?token="+resp.Secret) {
+		t.Fatalf("share failed %d: %s", w.Code, w.Body.String())
+	}
+	return resp.Secret
+}
+
+type viewerConn struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func dialTerminal(t *testing.T, base, secret string) *viewerConn {
+	t.Helper()
+	u := "ws" + strings.TrimPrefix(base, "http") + "/ws/terminal/claude-api"
+	if secret != "" {
+		u += "?token=" + secret
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close(websocket.StatusNormalClosure, "") })
+	return &viewerConn{t: t, conn: conn}
+}
+
+func (v *viewerConn) send(msg terminalInput) {
+	v.t.Helper()
+	if err := wsjson.Write(context.Background(), v.conn, msg); err != nil {
+		v.t.Fatal(err)
+	}
+}
+
+// await reads messages until one matches.
+func (v *viewerConn) await(match func(terminalMessage) bool) terminalMessage {
+	v.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for {
+		var msg terminalMessage
+		if err := wsjson.Read(ctx, v.conn, &msg); err != nil {
+			v.t.Fatalf("no matching message: %v", err)
+		}
+		if match(msg) {
+			return msg
+		}
+	}
+}
+
+func isError(text string) func(terminalMessage) bool {
+	return func(m terminalMessage) bool { return m.Type == "error" && strings.Contains(m.Message, text) }
+}
+
+func TestSharedTerminalScopesAndHandoff(t *testing.T) {
+	srv, term := newShareServer(t)
+	hs := httptest.NewServer(http.HandlerFunc(srv.handleTerminalWS))
+	defer hs.Close()
+
+	observer := dialTerminal(t, hs.URL, shareLink(t, srv, "observe", "carol"))
+	msg := observer.await(func(m terminalMessage) bool { return m.Type == "control" })
+	if msg.Actor != "carol" || msg.Scope != share.ScopeObserve || msg.You {
+		t.Fatalf("unexpected control message %+v", msg)
+	}
+	observer.await(func(m terminalMessage) bool { return m.Type == "output" && m.Content == "$ " })
+	observer.send(terminalInput{Type: "take"})
+	observer.await(isError("read-only"))
+	observer.send(terminalInput{Type: "input", Data: "rm -rf /"})
+	observer.await(isError("read-only"))
+
+	bob := dialTerminal(t, hs.URL, shareLink(t, srv, "interact", "bob"))
+	bob.send(terminalInput{Type: "input", Data: "ls"})
+	bob.await(isError("take control"))
+	bob.send(terminalInput{Type: "take"})
+	bob.await(func(m terminalMessage) bool { return m.Type == "control" && m.You })
+	observer.await(func(m terminalMessage) bool { return m.Type == "control" && m.Holder == "bob" })
+	bob.send(terminalInput{Type: "input", Data: "ls"})
+	bob.send(terminalInput{Type: "input", Key: "Enter"})
+	bob.send(terminalInput{Type: "input", Key: "Enter; rm"})
+	bob.await(isError("unsupported key"))
+	if got := term.keys(); got != "ls<Enter>" {
+		t.Fatalf("unexpected keys sent %q", got)
+	}
+
+	owner := dialTerminal(t, hs.URL, "")
+	owner.send(terminalInput{Type: "take"})
+	owner.await(func(m terminalMessage) bool { return m.Type == "control" && m.You })
+	bob.await(func(m terminalMessage) bool { return m.Type == "control" && !m.You && m.Holder == ownerActor })
+	bob.send(terminalInput{Type: "input", Data: "pwd"})
+	bob.await(isError("take control"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/shares/audit?session=claude-api", nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	w := httptest.NewRecorder()
+	srv.handleShares(w, req)
+	var events []share.Event
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatal(err)
+	}
+	var log []string
+	for i := len(events) - 1; i >= 0; i-- {
+		if e := events[i]; e.Kind != share.EventJoin {
+			log = append(log, e.Actor+" "+e.Kind+" "+e.Data)
+		}
+	}
+	want := []string{
+		"carol denied take control", "carol denied rm -rf /",
+		"bob denied ls", "bob control ", "bob keys ls", "bob keys <Enter>",
+		"owner control from bob", "bob denied pwd",
+	}
+	if strings.Join(log, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected audit log:\n%s", strings.Join(log, "\n"))
+	}
+}
+
+func TestTerminalRefusesRemoteViewersWithoutValidShare(t *testing.T) {
+	srv, _ := newShareServer(t)
+	secret := shareLink(t, srv, "interact", "bob")
+
+	serve := func(target, remote string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		srv.handleTerminalWS(w, req)
+		return w.Code
+	}
+	if code := serve("/ws/terminal/claude-api", "192.0.2.1:5000"); code != http.StatusUnauthorized {
+		t.Fatalf("expected a remote viewer without a token to be refused, got %d", code)
+	}
+	if code := serve("/ws/terminal/claude-api?token=wrong", "192.0.2.1:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected a bad token to be refused, got %d", code)
+	}
+	if code := serve("/ws/terminal/devbox:claude-api", "127.0.0.1:5000"); code != http.StatusBadRequest {
+		t.Fatalf("expected a federated session to be refused, got %d", code)
+	}
+
+	tokens, _ := srv.shares.Tokens("claude-api")
+	req := httptest.NewRequest(http.MethodPost, "/api/shares/"+tokens[0].ID+"/revoke", nil)
+	req.RemoteAddr = "192.0.2.1:5000"
+	w := httptest.NewRecorder()
+	srv.handleShares(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected only the local user to manage shares, got %d", w.Code)
+	}
+	req.RemoteAddr = "127.0.0.1:40000"
+	w = httptest.NewRecorder()
+	srv.handleShares(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("revoke failed %d: %s", w.Code, w.Body.String())
+	}
+	if code := serve("/ws/terminal/claude-api?token="+secret, "192.0.2.1:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected a revoked token to be refused, got %d", code)
+	}
+
+	// A share link does not make its holder the owner.
+	req = httptest.NewRequest(http.MethodGet, "/sessions/claude-api/terminal?token="+secret, nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	w = httptest.NewRecorder()
+	srv.handleSessionRoutes(w, req)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Audit log") {
+		t.Fatalf("expected the viewer page without share controls, got %d", w.Code)
+	}
+}
+
+func TestRevokedShareCannotType(t *testing.T) {
+	srv, term := newShareServer(t)
+	hs := httptest.NewServer(http.HandlerFunc(srv.handleTerminalWS))
+	defer hs.Close()
+
+	bob := dialTerminal(t, hs.URL, shareLink(t, srv, "interact", "bob"))
+	bob.send(terminalInput{Type: "take"})
+	bob.await(func(m terminalMessage) bool { return m.Type == "control" && m.You })
+
+	tokens, _ := srv.shares.Tokens("claude-api")
+	if err := srv.shares.Revoke(tokens[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	bob.send(terminalInput{Type: "input", Data: "rm -rf /"})
+	bob.await(isError("revoked"))
+	if got := term.keys(); got != "" {
+		t.Fatalf("expected no keys after the revocation, got %q", got)
+	}
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/aggregator"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
+)
+
+// trendRanges are the windows offered on the trends page.
+var trendRanges = []struct {
+	Name   string
+	Window time.Duration
+}{
+	{"1h", time.Hour},
+	{"6h", 6 * time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// defaultTrendRange is the window of the trends page and the dashboard.
+const defaultTrendRange = "6h"
+
+func trendWindow(name string) (time.Duration, bool) {
+	for _, tr := range trendRanges {
+		if tr.Name == name {
+			return tr.Window, true
+		}
+	}
+	return 0, false
+}
+
+// Chart sizes, in SVG viewBox units.
+const (
+	chartWidth  = 100
+	chartHeight = 30
+)
+
+// trendChart is a trend ready to draw as an SVG polyline.
+type trendChart struct {
+	Title  string
+	Rate   bool
+	Value  float64 // latest value, or the total over the window for a rate
+	Peak   float64
+	Points string
+	Empty  bool
+}
+
+// trendCharts queries every trend for a project ("" for the fleet). It
+// returns aggregator.ErrMetricsDisabled when there is no history.
+func (s *Server) trendCharts(project string, window time.Duration, now time.Time) ([]trendChart, error) {
+	var charts []trendChart
+	for _, t := range metrics.Trends {
+		q := t.Query(project, window, now, metrics.DefaultPoints)
+		points, err := s.agg.MetricSeries(q)
+		if err != nil {
+			return nil, err
+		}
+		value, peak := metrics.Summary(t, points)
+		charts = append(charts, trendChart{
+			Title:  t.Title,
+			Rate:   t.Rate,
+			Value:  value,
+			Peak:   peak,
+			Points: polyline(points, q.Since, window, peak),
+			Empty:  len(points) == 0,
+		})
+	}
+	return charts, nil
+}
+
+// polyline lays points out along the window, scaled from zero to peak.
+func polyline(points []metrics.Point, since time.Time, window time.Duration, peak float64) string {
+	var b strings.Builder
+	for i, p := range points {
+		x := float64(p.At.Sub(since)) / float64(window) * chartWidth
+		y := float64(chartHeight - 1)
+		if peak > 0 {
+			y -= p.Value / peak * (chartHeight - 2)
+		}
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%.1f,%.1f", x, y)
+	}
+	return b.String()
+}
+
+// handleTrends shows metric history for the fleet or one project:
+// /trends[?project=<path>&range=1h|6h|24h|7d].
+func (s *Server) handleTrends(w http.ResponseWriter, r *http.Request) {
+	project := r.URL.Query().Get("project")
+	rng := r.URL.Query().Get("range")
+	window, ok := trendWindow(rng)
+	if !ok {
+		rng = defaultTrendRange
+		window, _ = trendWindow(rng)
+	}
+
+	charts, err := s.trendCharts(project, window, time.Now())
+	if err != nil && !errors.Is(err, aggregator.ErrMetricsDisabled) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.render(w, "trends.html", map[string]any{
+		"Enabled":  err == nil,
+		"Charts":   charts,
+		"Project":  project,
+		"Range":    rng,
+		"Ranges":   trendRanges,
+		"Projects": s.agg.GetState().Projects,
+	})
+}
+
+// handlePrometheus serves the latest metrics sample in the Prometheus text
+// format for local scraping.
+func (s *Server) handlePrometheus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.WritePrometheus(w, s.agg.Metrics())
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/bigend/config"
+	"github.com/mistakeknot/autarch/internal/bigend/metrics"
+)
+
+func TestTrendsPageAndPrometheusEndpoint(t *testing.T) {
+	now := time.Now()
+	agg := &fakeAgg{
+		samples: []metrics.Sample{
+			{Name: metrics.MetricCommits, Project: "/p/api", Value: 12},
+			{Name: metrics.MetricAgents, Project: "/p/api", Label: "working", Value: 2},
+		},
+		series: map[string][]metrics.Point{
+			metrics.MetricTasksDone: {
+				{At: now.Add(-2 * time.Hour), Value: 3},
+				{At: now.Add(-time.Hour), Value: 1},
+			},
+		},
+	}
+	srv := NewServer(config.ServerConfig{}, agg)
+
+	w := httptest.NewRecorder()
+	srv.handlePrometheus(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE bigend_commits gauge",
+		`bigend_commits{project="/p/api"} 12`,
+		`bigend_agents{project="/p/api",state="working"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleTrends(w, httptest.NewRequest(http.MethodGet, "/trends?range=24h", nil))
+	body = w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "Tasks done") || !strings.Contains(body, "<polyline") {
+		t.Fatalf("expected trend charts, got %d:\n%s", w.Code, body)
+	}
+	if !strings.Contains(body, "total over 24h") {
+		t.Fatalf("expected the selected range to be used")
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleDashboard(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(w.Body.String(), "Fleet Trends") {
+		t.Fatalf("expected fleet sparklines on the dashboard")
+	}
+
+	off := NewServer(config.ServerConfig{}, &fakeAgg{})
+	w = httptest.NewRecorder()
+	off.handleTrends(w, httptest.NewRequest(http.MethodGet, "/trends", nil))
+	if !strings.Contains(w.Body.String(), "Metrics history is disabled") {
+		t.Fatalf("expected the disabled notice, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	off.handleDashboard(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(w.Body.String(), "Fleet Trends") {
+		t.Fatalf("expected no trends on the dashboard without history")
+	}
+}
@@ -13,6 +13,7 @@ import (
 type Criterion struct {
 	ID          string   `yaml:"id" json:"id"`                                 // AC-001
 	StoryID     string   `yaml:"story_id" json:"story_id"`                     // STORY-001
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"` // Free-form criterion text
 	Given       string   `yaml:"given" json:"given"`                           // User is logged in
 	When        string   `yaml:"when" json:"when"`                             // User clicks share button
 	Then        string   `yaml:"then" json:"then"`                             // Tweet compose dialog opens
@@ -0,0 +1,211 @@
+package acceptance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scaffold styles.
+const (
+	StyleGo    = "go"
+	StyleShell = "shell"
+)
+
+// ChecksDir is where a task's acceptance checks live, relative to its
+// worktree.
+func ChecksDir(taskID string) string {
+	return filepath.Join("acceptance", taskID)
+}
+
+// GoTestFile is the scaffolded Go test file inside ChecksDir.
+const GoTestFile = "acceptance_test.go"
+
+// ShellSkipCode is the exit status a shell check uses to say it is not
+// implemented yet, as with automake's SKIP.
+const ShellSkipCode = 77
+
+// LoadTaskCriteria reads the acceptance criteria of a task spec. Entries may
+// be plain strings or maps with an id, a description or given/when/then
+// clauses, and an optional test_command. Entries without an ID are numbered
+// <TASK>-AC-001, <TASK>-AC-002, ...
+func LoadTaskCriteria(specPath string) ([]Criterion, error) {
+	raw, err := os.ReadFile(specPath)
+	if err != nil {
+		return nil, err
+	}
+	var spec struct {
+		ID       string      `yaml:"id"`
+		StoryID  string      `yaml:"story_id"`
+		Criteria []yaml.Node `yaml:"acceptance_criteria"`
+	}
+	if err := yaml.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", specPath, err)
+	}
+	var out []Criterion
+	seen := map[string]bool{}
+	for i, node := range spec.Criteria {
+		var c Criterion
+		switch node.Kind {
+		case yaml.ScalarNode:
+			c.Description = node.Value
+		case yaml.MappingNode:
+			if err := node.Decode(&c); err != nil {
+				return nil, fmt.Errorf("parse %s: acceptance criterion %d: %w", specPath, i+1, err)
+			}
+		default:
+			continue
+		}
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("%s-AC-%03d", spec.ID, i+1)
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("parse %s: duplicate acceptance criterion %s", specPath, c.ID)
+		}
+		seen[c.ID] = true
+		if c.StoryID == "" {
+			c.StoryID = spec.StoryID
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// Summary is the one-line text of a criterion.
+func Summary(c Criterion) string {
+	if c.Description != "" {
+		return c.Description
+	}
+	var parts []string
+	for _, p := range [][2]string{{"Given", c.Given}, {"when", c.When}, {"then", c.Then}} {
+		if strings.TrimSpace(p[1]) != "" {
+			parts = append(parts, p[0]+" "+p[1])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// scenario is the Gherkin form of c, leaving out empty clauses so
+// description-only criteria read as a single Then.
+func scenario(c Criterion) string {
+	if c.Given == "" && c.When == "" && c.Then == "" {
+		return fmt.Sprintf("Scenario: %s\n  Then %s\n", c.ID, c.Description)
+	}
+	out := FormatGherkin(&c)
+	for _, edge := range c.EdgeCases {
+		out += "  # Edge case: " + edge + "\n"
+	}
+	return out
+}
+
+var nonIdent = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// GoTestName is the test function that verifies c.
+func GoTestName(c Criterion) string {
+	return "TestAcceptance_" + strings.Trim(nonIdent.ReplaceAllString(c.ID, "_"), "_")
+}
+
+// ShellScriptName is the script that verifies c.
+func ShellScriptName(c Criterion) string {
+	return strings.Trim(nonIdent.ReplaceAllString(c.ID, "-"), "-") + ".sh"
+}
+
+// ScaffoldResult lists the checks written and those left alone because
+// they already existed or the criterion runs its own test_command.
+type ScaffoldResult struct {
+	Dir     string   `json:"dir"`
+	Written []string `json:"written,omitempty"`
+	Kept    []string `json:"kept,omitempty"`
+}
+
+// Scaffold writes check skeletons for criteria into the task's checks
+// directory under worktree. Every skeleton reports itself as skipped, so a
+// criterion stays unverified until its check is filled in. Existing checks
+// are kept; a Go test file only gains the tests it is missing.
+func Scaffold(worktree, taskID, style string, criteria []Criterion) (ScaffoldResult, error) {
+	dir := filepath.Join(worktree, ChecksDir(taskID))
+	res := ScaffoldResult{Dir: dir}
+	var todo []Criterion
+	for _, c := range criteria {
+		if c.TestCommand != "" {
+			res.Kept = append(res.Kept, c.ID)
+			continue
+		}
+		todo = append(todo, c)
+	}
+	if len(todo) == 0 {
+		return res, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return res, err
+	}
+	switch style {
+	case StyleGo:
+		return scaffoldGo(res, taskID, todo)
+	case StyleShell:
+		return scaffoldShell(res, todo)
+	}
+	return res, fmt.Errorf("unknown scaffold style %q (use %s or %s)", style, StyleGo, StyleShell)
+}
+
+func scaffoldGo(res ScaffoldResult, taskID string, criteria []Criterion) (ScaffoldResult, error) {
+	path := filepath.Join(res.Dir, GoTestFile)
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return res, err
+	}
+	var b strings.Builder
+	if len(existing) == 0 {
+		fmt.Fprintf(&b, "// Acceptance checks for %s, scaffolded by coldwine acceptance scaffold.\n", taskID)
+		b.WriteString("// Replace each t.Skip with a real check; a skipped test leaves its\n")
+		b.WriteString("// criterion unverified.\n")
+		b.WriteString("package acceptance\n\nimport \"testing\"\n")
+	} else {
+		b.Write(existing)
+	}
+	for _, c := range criteria {
+		name := GoTestName(c)
+		if strings.Contains(string(existing), "func "+name+"(") {
+			res.Kept = append(res.Kept, c.ID)
+			continue
+		}
+		b.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimRight(scenario(c), "\n"), "\n") {
+			b.WriteString("//\t" + line + "\n")
+		}
+		fmt.Fprintf(&b, "func %s(t *testing.T) {\n\tt.Skip(%q)\n}\n", name, "not implemented: "+c.ID)
+		res.Written = append(res.Written, c.ID)
+	}
+	if len(res.Written) == 0 {
+		return res, nil
+	}
+	return res, os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+func scaffoldShell(res ScaffoldResult, criteria []Criterion) (ScaffoldResult, error) {
+	for _, c := range criteria {
+		path := filepath.Join(res.Dir, ShellScriptName(c))
+		if _, err := os.Stat(path); err == nil {
+			res.Kept = append(res.Kept, c.ID)
+			continue
+		}
+		var b strings.Builder
+		b.WriteString("#!/bin/sh\n")
+		fmt.Fprintf(&b, "# Acceptance check for %s. Runs from the worktree root; exit 0 when the\n", c.ID)
+		fmt.Fprintf(&b, "# criterion holds, %d while the check is not implemented.\n#\n", ShellSkipCode)
+		for _, line := range strings.Split(strings.TrimRight(scenario(c), "\n"), "\n") {
+			b.WriteString("# " + line + "\n")
+		}
+		fmt.Fprintf(&b, "echo 'not implemented: %s' >&2\nexit %d\n", strings.ReplaceAll(c.ID, "'", ""), ShellSkipCode)
+		if err := os.WriteFile(path, []byte(b.String()), 0o755); err != nil {
+			return res, err
+		}
+		res.Written = append(res.Written, c.ID)
+	}
+	return res, nil
+}
@@ -0,0 +1,294 @@
+package acceptance
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/git"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+// maxOutput bounds the check output kept as evidence; the tail is kept.
+const maxOutput = 4000
+
+// Verifier runs the acceptance checks of a task in its worktree. Each
+// criterion is checked by, in order: its test_command, its shell script, or
+// its Go test in the task's checks directory.
+type Verifier struct {
+	Worktree string
+	TaskID   string
+	Timeout  time.Duration // per check run; zero means no limit
+	Now      func() time.Time
+}
+
+// Verify runs every check and returns one result per criterion.
+func (v *Verifier) Verify(ctx context.Context, criteria []Criterion) ([]storage.AcceptanceResult, error) {
+	dir := filepath.Join(v.Worktree, ChecksDir(v.TaskID))
+	goTests, err := goTestNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	revision, _ := git.HeadCommit(&git.DirRunner{Dir: v.Worktree})
+
+	results := make([]storage.AcceptanceResult, len(criteria))
+	var goCriteria []int
+	for i, c := range criteria {
+		r := storage.AcceptanceResult{TaskID: v.TaskID, CriterionID: c.ID, Revision: revision, CheckKind: "none", Status: storage.AcceptanceMissing}
+		script := filepath.Join(dir, ShellScriptName(c))
+		switch {
+		case c.TestCommand != "":
+			r.CheckKind, r.CheckRef = "command", c.TestCommand
+			v.runShell(ctx, &r, "sh", "-c", c.TestCommand)
+		case fileExists(script):
+			r.CheckKind, r.CheckRef = "shell", filepath.Join(ChecksDir(v.TaskID), ShellScriptName(c))
+			v.runShell(ctx, &r, "sh", r.CheckRef)
+		case goTests[GoTestName(c)]:
+			r.CheckKind, r.CheckRef = "go", GoTestName(c)
+			goCriteria = append(goCriteria, i)
+		default:
+			r.Output = "no check found; run coldwine acceptance scaffold"
+		}
+		results[i] = r
+	}
+	if len(goCriteria) > 0 {
+		v.runGoTests(ctx, dir, results, goCriteria)
+	}
+	at := v.now().UTC()
+	for i := range results {
+		results[i].VerifiedAt = at
+	}
+	return results, nil
+}
+
+func (v *Verifier) now() time.Time {
+	if v.Now != nil {
+		return v.Now()
+	}
+	return time.Now()
+}
+
+func (v *Verifier) command(ctx context.Context, dir, name string, args ...string) ([]byte, time.Duration, int, error) {
+	if v.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	elapsed := time.Since(start)
+	if ctx.Err() == context.DeadlineExceeded {
+		out = append(out, []byte(fmt.Sprintf("\ntimed out after %s\n", v.Timeout))...)
+		return out, elapsed, -1, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return out, elapsed, exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return out, elapsed, -1, err
+	}
+	return out, elapsed, 0, nil
+}
+
+// runShell runs a command or script check from the worktree root.
+func (v *Verifier) runShell(ctx context.Context, r *storage.AcceptanceResult, name string, args ...string) {
+	out, elapsed, code, err := v.command(ctx, v.Worktree, name, args...)
+	r.DurationMS = elapsed.Milliseconds()
+	r.Output = tail(string(out))
+	switch {
+	case err != nil:
+		r.Status, r.Output = storage.AcceptanceFailed, err.Error()
+	case code == 0:
+		r.Status = storage.AcceptancePassed
+	case code == ShellSkipCode:
+		r.Status = storage.AcceptanceSkipped
+	default:
+		r.Status = storage.AcceptanceFailed
+	}
+}
+
+type testEvent struct {
+	Action string
+	Test   string
+	Output string
+}
+
+// runGoTests runs the Go checks in one go test invocation and reads each
+// test's outcome from its JSON events. A test with no outcome, for example
+// because the package failed to build, fails with the run's output.
+func (v *Verifier) runGoTests(ctx context.Context, dir string, results []storage.AcceptanceResult, idx []int) {
+	names := make([]string, len(idx))
+	for i, n := range idx {
+		names[i] = results[n].CheckRef
+	}
+	out, elapsed, _, err := v.command(ctx, dir, "go", "test", "-json", "-count=1", "-run", "^("+strings.Join(names, "|")+")$", ".")
+	status := map[string]string{}
+	output := map[string]*strings.Builder{}
+	var plain strings.Builder
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var ev testEvent
+		if json.Unmarshal(sc.Bytes(), &ev) != nil {
+			plain.WriteString(sc.Text() + "\n")
+			continue
+		}
+		if ev.Test == "" {
+			plain.WriteString(ev.Output)
+			continue
+		}
+		if output[ev.Test] == nil {
+			output[ev.Test] = &strings.Builder{}
+		}
+		output[ev.Test].WriteString(ev.Output)
+		switch ev.Action {
+		case "pass":
+			status[ev.Test] = storage.AcceptancePassed
+		case "fail":
+			status[ev.Test] = storage.AcceptanceFailed
+		case "skip":
+			status[ev.Test] = storage.AcceptanceSkipped
+		}
+	}
+	for _, n := range idx {
+		r := &results[n]
+		r.DurationMS = elapsed.Milliseconds()
+		if s, ok := status[r.CheckRef]; ok {
+			r.Status = s
+			r.Output = tail(output[r.CheckRef].String())
+			continue
+		}
+		r.Status = storage.AcceptanceFailed
+		r.Output = tail(plain.String())
+		if err != nil {
+			r.Output = err.Error()
+		}
+	}
+}
+
+// goTestNames returns the test functions declared in dir's test files.
+func goTestNames(dir string) (map[string]bool, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, f := range files {
+		raw, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(raw), "\n") {
+			rest, ok := strings.CutPrefix(line, "func Test")
+			if !ok {
+				continue
+			}
+			if name, _, ok := strings.Cut(rest, "("); ok {
+				names["Test"+name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+func fileExists(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && !st.IsDir()
+}
+
+func tail(s string) string {
+	if len(s) <= maxOutput {
+		return s
+	}
+	return "...\n" + s[len(s)-maxOutput:]
+}
+
+// Unverified returns the IDs of criteria whose latest result did not pass.
+// When revision is set, a pass recorded at any other revision is stale and
+// counts as unverified too.
+func Unverified(criteria []Criterion, results []storage.AcceptanceResult, revision string) []string {
+	passed := map[string]bool{}
+	for _, r := range results {
+		if r.Status == storage.AcceptancePassed && (revision == "" || r.Revision == revision) {
+			passed[r.CriterionID] = true
+		}
+	}
+	var out []string
+	for _, c := range criteria {
+		if !passed[c.ID] {
+			out = append(out, c.ID)
+		}
+	}
+	return out
+}
+
+// UnverifiedError blocks approval of a task with unverified criteria.
+type UnverifiedError struct {
+	TaskID   string
+	Criteria []string
+}
+
+func (e *UnverifiedError) Error() string {
+	return fmt.Sprintf("%s has unverified acceptance criteria: %s (run coldwine acceptance verify %s)",
+		e.TaskID, strings.Join(e.Criteria, ", "), e.TaskID)
+}
+
+// Revision is the commit a task would be approved at: the tip of branch,
+// or the HEAD of the task's worktree when no branch is given. It is empty
+// when there is neither.
+func Revision(root, taskID, branch string) (string, error) {
+	if branch != "" {
+		return git.ResolveCommit(&git.DirRunner{Dir: root}, branch)
+	}
+	worktree, err := project.SafePath(project.WorktreesDir(root), taskID)
+	if err != nil {
+		return "", err
+	}
+	if st, err := os.Stat(worktree); err != nil || !st.IsDir() {
+		return "", nil
+	}
+	return git.HeadCommit(&git.DirRunner{Dir: worktree})
+}
+
+// CheckApproval returns an *UnverifiedError unless every acceptance
+// criterion in the task's spec passed its last verification, and that
+// verification ran at the revision being approved (see Revision). Tasks
+// without a spec or without criteria are not gated.
+func CheckApproval(db *sql.DB, root, taskID, branch string) error {
+	specPath, err := project.TaskSpecPath(root, taskID)
+	if err != nil {
+		return err
+	}
+	criteria, err := LoadTaskCriteria(specPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil || len(criteria) == 0 {
+		return err
+	}
+	results, err := storage.ListAcceptanceResults(db, taskID)
+	if err != nil {
+		return err
+	}
+	revision, err := Revision(root, taskID, branch)
+	if err != nil {
+		return err
+	}
+	if ids := Unverified(criteria, results, revision); len(ids) > 0 {
+		return &UnverifiedError{TaskID: taskID, Criteria: ids}
+	}
+	return nil
+}
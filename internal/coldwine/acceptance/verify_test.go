@@ -0,0 +1,173 @@
+package acceptance
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+func statuses(results []storage.AcceptanceResult) map[string]string {
+	out := map[string]string{}
+	for _, r := range results {
+		out[r.CriterionID] = r.CheckKind + ":" + r.Status
+	}
+	return out
+}
+
+func TestVerifyRunsCommandsScriptsAndGoTests(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not on PATH")
+	}
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, "go.mod"), []byte("module example.com/wt\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	criteria := []Criterion{
+		{ID: "T-AC-1", Description: "command passes", TestCommand: "test -f go.mod"},
+		{ID: "T-AC-2", Description: "script fails"},
+		{ID: "T-AC-3", Description: "script not written"},
+		{ID: "T-AC-4", Description: "go test passes"},
+		{ID: "T-AC-5", Description: "go test fails"},
+		{ID: "T-AC-6", Description: "go test skipped"},
+		{ID: "T-AC-7", Description: "no check"},
+	}
+	if _, err := Scaffold(worktree, "T", StyleShell, criteria[1:3]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Scaffold(worktree, "T", StyleGo, criteria[3:6]); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(worktree, ChecksDir("T"))
+	if err := os.WriteFile(filepath.Join(dir, "T-AC-2.sh"), []byte("echo missing header\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, GoTestFile)
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filled := strings.Replace(string(src), `t.Skip("not implemented: T-AC-4")`, `t.Log("ok")`, 1)
+	filled = strings.Replace(filled, `t.Skip("not implemented: T-AC-5")`, `t.Fatal("header row missing")`, 1)
+	if err := os.WriteFile(path, []byte(filled), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	v := &Verifier{Worktree: worktree, TaskID: "T"}
+	results, err := v.Verify(context.Background(), criteria)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"T-AC-1": "command:pass",
+		"T-AC-2": "shell:fail",
+		"T-AC-3": "shell:skip",
+		"T-AC-4": "go:pass",
+		"T-AC-5": "go:fail",
+		"T-AC-6": "go:skip",
+		"T-AC-7": "none:missing",
+	}
+	if got := statuses(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if !strings.Contains(results[1].Output, "missing header") || !strings.Contains(results[4].Output, "header row missing") {
+		t.Fatalf("expected check output as evidence, got %q / %q", results[1].Output, results[4].Output)
+	}
+	if got := Unverified(criteria, results, ""); !reflect.DeepEqual(got, []string{"T-AC-2", "T-AC-3", "T-AC-5", "T-AC-6", "T-AC-7"}) {
+		t.Fatalf("unexpected unverified %v", got)
+	}
+}
+
+func TestVerifyGoBuildFailureFailsEveryGoCheck(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not on PATH")
+	}
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, "go.mod"), []byte("module example.com/wt\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	criteria := []Criterion{{ID: "T-AC-1"}, {ID: "T-AC-2"}}
+	if _, err := Scaffold(worktree, "T", StyleGo, criteria); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(worktree, ChecksDir("T"), GoTestFile)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("\nfunc broken() { undefinedCall() }\n")
+	_ = f.Close()
+
+	results, err := (&Verifier{Worktree: worktree, TaskID: "T"}).Verify(context.Background(), criteria)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Status != storage.AcceptanceFailed || !strings.Contains(r.Output, "undefinedCall") {
+			t.Fatalf("expected build failure to fail %s, got %+v", r.CriterionID, r)
+		}
+	}
+}
+
+func TestCheckApproval(t *testing.T) {
+	root := t.TempDir()
+	if err := project.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckApproval(db, root, "TAND-001", ""); err != nil {
+		t.Fatalf("task without a spec should not be gated: %v", err)
+	}
+	spec := "id: TAND-001\nacceptance_criteria:\n  - First\n  - Second\n"
+	if err := os.WriteFile(filepath.Join(project.SpecsDir(root), "TAND-001.yaml"), []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.ReplaceAcceptanceResults(db, "TAND-001", []storage.AcceptanceResult{
+		{CriterionID: "TAND-001-AC-001", Status: storage.AcceptancePassed, CheckKind: "shell"},
+		{CriterionID: "TAND-001-AC-002", Status: storage.AcceptanceSkipped, CheckKind: "shell"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var unverified *UnverifiedError
+	if err := CheckApproval(db, root, "TAND-001", ""); !errors.As(err, &unverified) || !reflect.DeepEqual(unverified.Criteria, []string{"TAND-001-AC-002"}) {
+		t.Fatalf("expected TAND-001-AC-002 to block approval, got %v", err)
+	}
+	if err := storage.ReplaceAcceptanceResults(db, "TAND-001", []storage.AcceptanceResult{
+		{CriterionID: "TAND-001-AC-001", Status: storage.AcceptancePassed, CheckKind: "shell"},
+		{CriterionID: "TAND-001-AC-002", Status: storage.AcceptancePassed, CheckKind: "go"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckApproval(db, root, "TAND-001", ""); err != nil {
+		t.Fatalf("expected approval once verified, got %v", err)
+	}
+}
+
+func TestUnverifiedTreatsPassesAtAnotherRevisionAsStale(t *testing.T) {
+	criteria := []Criterion{{ID: "T-AC-1"}, {ID: "T-AC-2"}, {ID: "T-AC-3"}}
+	results := []storage.AcceptanceResult{
+		{CriterionID: "T-AC-1", Status: storage.AcceptancePassed, Revision: "abc"},
+		{CriterionID: "T-AC-2", Status: storage.AcceptancePassed, Revision: "old"},
+		{CriterionID: "T-AC-3", Status: storage.AcceptancePassed},
+	}
+	if got := Unverified(criteria, results, "abc"); !reflect.DeepEqual(got, []string{"T-AC-2", "T-AC-3"}) {
+		t.Fatalf("unexpected unverified %v", got)
+	}
+	if got := Unverified(criteria, results, ""); len(got) != 0 {
+		t.Fatalf("without a revision every pass counts, got %v", got)
+	}
+}
@@ -0,0 +1,237 @@
+// Package accounting records what agent sessions cost and rolls it up by
+// task, story, epic and agent. Token counts come from the agents' own JSON
+// output; cost is what the agent reports, or else tokens priced from a
+// per-model table.
+package accounting
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/mistakeknot/autarch/internal/coldwine/tasks"
+)
+
+// DefaultIdleGap is the longest pause between log output that still counts
+// as active time.
+const DefaultIdleGap = 2 * time.Minute
+
+// Price is USD per million tokens.
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
+// Cost returns the cost of u. A cost the agent reported wins; otherwise the
+// tokens are priced by the longest model prefix in prices.
+func Cost(u agent.Usage, prices map[string]Price) float64 {
+	if u.CostReported {
+		return u.CostUSD
+	}
+	p, ok := lookupPrice(u.Model, prices)
+	if !ok {
+		return 0
+	}
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheReadTokens)*p.CacheRead +
+		float64(u.CacheWriteTokens)*p.CacheWrite) / 1e6
+}
+
+func lookupPrice(model string, prices map[string]Price) (Price, bool) {
+	best := ""
+	found := false
+	for name := range prices {
+		if strings.HasPrefix(model, name) && (!found || len(name) > len(best)) {
+			best, found = name, true
+		}
+	}
+	return prices[best], found
+}
+
+// Meter turns session log batches into usage samples. It is not safe for
+// concurrent use.
+type Meter struct {
+	DB     *sql.DB
+	Prices map[string]Price
+	// Models is the model each target runs, per target, for pricing
+	// batches before the agent has named its model.
+	Models  map[string]string
+	IdleGap time.Duration
+	Now     func() time.Time
+
+	counters map[string]*agent.UsageCounter // per task
+}
+
+// Sample records the usage in lines, newly read from the log of a task
+// running on target. An empty batch still advances the sample clock so the
+// idle time before it is not counted as active. A batch that names no model
+// (Codex only reports it when the session starts) is priced as the model
+// already recorded for the task, or else the target's configured model.
+func (m *Meter) Sample(taskID, target string, lines []string) error {
+	if m.counters == nil {
+		m.counters = map[string]*agent.UsageCounter{}
+	}
+	counter := m.counters[taskID]
+	if counter == nil {
+		counter = &agent.UsageCounter{}
+		m.counters[taskID] = counter
+	}
+	u := counter.Parse(target, lines)
+	if u.Model == "" {
+		prev, err := storage.GetTaskUsage(m.DB, taskID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		u.Model = prev.Model
+	}
+	if u.Model == "" {
+		u.Model = m.Models[target]
+	}
+	now := time.Now()
+	if m.Now != nil {
+		now = m.Now()
+	}
+	gap := m.IdleGap
+	if gap <= 0 {
+		gap = DefaultIdleGap
+	}
+	return storage.AddTaskUsage(m.DB, taskID, storage.UsageSample{
+		Agent:            target,
+		Model:            u.Model,
+		InputTokens:      u.InputTokens,
+		OutputTokens:     u.OutputTokens,
+		CacheReadTokens:  u.CacheReadTokens,
+		CacheWriteTokens: u.CacheWriteTokens,
+		CostUSD:          Cost(u, m.Prices),
+		Active:           len(lines) > 0,
+		At:               now.UTC(),
+	}, gap)
+}
+
+// Totals is usage summed over one or more tasks.
+type Totals struct {
+	Tasks            int     `json:"tasks"`
+	InputTokens      int64   `json:"input_tokens"`
+	OutputTokens     int64   `json:"output_tokens"`
+	CacheReadTokens  int64   `json:"cache_read_tokens"`
+	CacheWriteTokens int64   `json:"cache_write_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	WallSeconds      float64 `json:"wall_seconds"`
+	ActiveSeconds    float64 `json:"active_seconds"`
+}
+
+// Tokens is every token counted, cached or not.
+func (t Totals) Tokens() int64 {
+	return t.InputTokens + t.OutputTokens + t.CacheReadTokens + t.CacheWriteTokens
+}
+
+func (t *Totals) add(u storage.TaskUsage, now time.Time) {
+	t.Tasks++
+	t.InputTokens += u.InputTokens
+	t.OutputTokens += u.OutputTokens
+	t.CacheReadTokens += u.CacheReadTokens
+	t.CacheWriteTokens += u.CacheWriteTokens
+	t.CostUSD += u.CostUSD
+	t.WallSeconds += u.WallSeconds(now)
+	t.ActiveSeconds += u.ActiveSeconds
+}
+
+// Report is usage rolled up at each level. Tasks without a story or epic
+// are left out of those maps but still count toward Total.
+type Report struct {
+	Total   Totals            `json:"total"`
+	ByTask  map[string]Totals `json:"by_task"`
+	ByStory map[string]Totals `json:"by_story"`
+	ByEpic  map[string]Totals `json:"by_epic"`
+	ByAgent map[string]Totals `json:"by_agent"`
+}
+
+// Rollup groups usage by task, story, epic and agent. list supplies the
+// story and epic of each task.
+func Rollup(usage []storage.TaskUsage, list []tasks.TaskProposal, now time.Time) Report {
+	byID := make(map[string]tasks.TaskProposal, len(list))
+	for _, t := range list {
+		byID[t.ID] = t
+	}
+	r := Report{
+		ByTask:  map[string]Totals{},
+		ByStory: map[string]Totals{},
+		ByEpic:  map[string]Totals{},
+		ByAgent: map[string]Totals{},
+	}
+	addTo := func(m map[string]Totals, key string, u storage.TaskUsage) {
+		if key == "" {
+			return
+		}
+		t := m[key]
+		t.add(u, now)
+		m[key] = t
+	}
+	for _, u := range usage {
+		r.Total.add(u, now)
+		addTo(r.ByTask, u.TaskID, u)
+		addTo(r.ByAgent, u.Agent, u)
+		if t, ok := byID[u.TaskID]; ok {
+			addTo(r.ByStory, t.StoryID, u)
+			addTo(r.ByEpic, t.EpicID, u)
+		}
+	}
+	return r
+}
+
+// Load reads every task's usage and rolls it up.
+func Load(db *sql.DB, list []tasks.TaskProposal, now time.Time) (Report, error) {
+	usage, err := storage.ListTaskUsage(db)
+	if err != nil {
+		return Report{}, err
+	}
+	return Rollup(usage, list, now), nil
+}
+
+// Budget caps an epic's spend. Zero fields are unlimited.
+type Budget struct {
+	USD    float64 `json:"usd,omitempty"`
+	Tokens int64   `json:"tokens,omitempty"`
+}
+
+// BudgetStatus is an epic's spend against its budget.
+type BudgetStatus struct {
+	EpicID      string  `json:"epic_id"`
+	Budget      Budget  `json:"budget"`
+	SpentUSD    float64 `json:"spent_usd"`
+	SpentTokens int64   `json:"spent_tokens"`
+	Exceeded    bool    `json:"exceeded"`
+}
+
+func (b BudgetStatus) String() string {
+	var parts []string
+	if b.Budget.USD > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f of $%.2f", b.SpentUSD, b.Budget.USD))
+	}
+	if b.Budget.Tokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d of %d tokens", b.SpentTokens, b.Budget.Tokens))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CheckBudgets compares each budgeted epic's spend with its budget, in
+// epic order.
+func CheckBudgets(r Report, budgets map[string]Budget) []BudgetStatus {
+	var out []BudgetStatus
+	for epic, b := range budgets {
+		spent := r.ByEpic[epic]
+		st := BudgetStatus{EpicID: epic, Budget: b, SpentUSD: spent.CostUSD, SpentTokens: spent.Tokens()}
+		st.Exceeded = (b.USD > 0 && st.SpentUSD >= b.USD) || (b.Tokens > 0 && st.SpentTokens >= b.Tokens)
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EpicID < out[j].EpicID })
+	return out
+}
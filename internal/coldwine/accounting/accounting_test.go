@@ -0,0 +1,135 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/mistakeknot/autarch/internal/coldwine/tasks"
+)
+
+func TestCostPrefersReportedThenLongestPrefix(t *testing.T) {
+	prices := map[string]Price{
+		"claude":          {Input: 10, Output: 10},
+		"claude-sonnet-4": {Input: 3, Output: 15, CacheRead: 0.3},
+	}
+	u := agent.Usage{Model: "claude-sonnet-4-5", InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 1_000_000}
+	if got := Cost(u, prices); got < 4.7999 || got > 4.8001 {
+		t.Fatalf("expected $4.80, got %v", got)
+	}
+	u.CostUSD, u.CostReported = 1.25, true
+	if got := Cost(u, prices); got != 1.25 {
+		t.Fatalf("expected reported cost, got %v", got)
+	}
+	if got := Cost(agent.Usage{Model: "gpt-5", InputTokens: 100}, prices); got != 0 {
+		t.Fatalf("expected unpriced model to cost 0, got %v", got)
+	}
+}
+
+func TestMeterSamplesIntoStorage(t *testing.T) {
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := &Meter{DB: db, Prices: map[string]Price{"gpt-5": {Input: 1, Output: 10}}, Now: func() time.Time { return now }}
+	if err := m.Sample("T1", "codex", nil); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	lines := []string{`{"type":"turn.completed","model":"gpt-5-codex","usage":{"input_tokens":2000000,"cached_input_tokens":1000000,"output_tokens":100000}}`}
+	if err := m.Sample("T1", "codex", lines); err != nil {
+		t.Fatal(err)
+	}
+	u, err := storage.GetTaskUsage(db, "T1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Agent != "codex" || u.InputTokens != 1_000_000 || u.CacheReadTokens != 1_000_000 || u.ActiveSeconds != 60 {
+		t.Fatalf("unexpected usage %+v", u)
+	}
+	if u.CostUSD < 1.9999 || u.CostUSD > 2.0001 {
+		t.Fatalf("expected $2.00 from the price table, got %v", u.CostUSD)
+	}
+}
+
+func TestMeterPricesBatchesWithoutAModel(t *testing.T) {
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	m := &Meter{
+		DB:     db,
+		Prices: map[string]Price{"gpt-5": {Input: 1}, "o4": {Input: 2}},
+		Models: map[string]string{"codex": "o4-mini"},
+	}
+	tokens := []string{`{"id":"0","msg":{"type":"token_count","input_tokens":1000000,"cached_input_tokens":0,"output_tokens":0}}`}
+
+	// Nothing has named a model yet: the target's configured model is used.
+	if err := m.Sample("T1", "codex", tokens); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := storage.GetTaskUsage(db, "T1"); u.CostUSD != 2 {
+		t.Fatalf("expected the configured model's price, got %+v", u)
+	}
+
+	// Once the session names its model, later batches are priced by it.
+	configured := `{"id":"0","msg":{"type":"session_configured","model":"gpt-5-codex"}}`
+	if err := m.Sample("T2", "codex", []string{configured}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Sample("T2", "codex", tokens); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := storage.GetTaskUsage(db, "T2"); u.Model != "gpt-5-codex" || u.CostUSD != 1 {
+		t.Fatalf("expected the session's model to price later batches, got %+v", u)
+	}
+}
+
+func TestRollupAndBudgets(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	usage := []storage.TaskUsage{
+		{TaskID: "T1", Agent: "claude", InputTokens: 100, OutputTokens: 10, CostUSD: 1, ActiveSeconds: 30, StartedAt: start, EndedAt: start.Add(time.Minute)},
+		{TaskID: "T2", Agent: "codex", InputTokens: 200, CostUSD: 2, ActiveSeconds: 60, StartedAt: start},
+		{TaskID: "T3", Agent: "claude", OutputTokens: 5, CostUSD: 0.5, StartedAt: start},
+	}
+	list := []tasks.TaskProposal{
+		{ID: "T1", EpicID: "EPIC-001", StoryID: "EPIC-001-S01"},
+		{ID: "T2", EpicID: "EPIC-001", StoryID: "EPIC-001-S02"},
+		{ID: "T3", EpicID: "EPIC-002"},
+	}
+	r := Rollup(usage, list, start.Add(2*time.Minute))
+	if r.Total.Tasks != 3 || r.Total.CostUSD != 3.5 || r.Total.Tokens() != 315 {
+		t.Fatalf("unexpected total %+v", r.Total)
+	}
+	if e := r.ByEpic["EPIC-001"]; e.Tasks != 2 || e.CostUSD != 3 || e.WallSeconds != 180 || e.ActiveSeconds != 90 {
+		t.Fatalf("unexpected epic rollup %+v", e)
+	}
+	if len(r.ByStory) != 2 || r.ByAgent["claude"].Tasks != 2 || r.ByTask["T3"].CostUSD != 0.5 {
+		t.Fatalf("unexpected rollup %+v", r)
+	}
+
+	statuses := CheckBudgets(r, map[string]Budget{
+		"EPIC-001": {USD: 5, Tokens: 300},
+		"EPIC-002": {USD: 0.5},
+		"EPIC-003": {USD: 1},
+	})
+	if len(statuses) != 3 || statuses[0].EpicID != "EPIC-001" {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+	if !statuses[0].Exceeded || !statuses[1].Exceeded || statuses[2].Exceeded {
+		t.Fatalf("expected tokens to exceed EPIC-001 and cost EPIC-002, got %+v", statuses)
+	}
+	if got := statuses[0].String(); got != "$3.00 of $5.00, 310 of 300 tokens" {
+		t.Fatalf("unexpected budget summary %q", got)
+	}
+}
@@ -1,14 +1,272 @@
 package agent
 
-import "strings"
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
 
+// Agent states reported by detectors.
+const (
+	StateWorking = "working"
+	StateWaiting = "waiting" // paused for input; never changes task status
+	StateDone    = "done"
+	StateBlocked = "blocked"
+)
+
+// MinConfidence is the confidence a detection needs before callers act on a
+// done or blocked state.
+const MinConfidence = 0.5
+
+// maxEvidence bounds the length of each evidence line.
+const maxEvidence = 200
+
+// Detection is a state reported by a detector, with the log lines that
+// support it.
+type Detection struct {
+	State      string   `json:"state"`
+	Confidence float64  `json:"confidence"`
+	Detector   string   `json:"detector"`
+	Evidence   []string `json:"evidence,omitempty"`
+	Line       int      `json:"line"` // index of the last evidence line
+}
+
+// Actionable reports whether the detection is a done or blocked state with
+// enough confidence to change task status.
+func (d Detection) Actionable() bool {
+	return (d.State == StateDone || d.State == StateBlocked) && d.Confidence >= MinConfidence
+}
+
+// Detector reads a batch of session log lines and reports the latest state
+// it can see in them.
+type Detector interface {
+	Name() string
+	Detect(lines []string) (Detection, bool)
+}
+
+// DetectorSet holds the detectors to run for each agent type. Detectors
+// registered under "" run for every agent.
+type DetectorSet struct {
+	byAgent map[string][]Detector
+}
+
+// NewDetectorSet returns an empty set.
+func NewDetectorSet() *DetectorSet {
+	return &DetectorSet{byAgent: make(map[string][]Detector)}
+}
+
+// DefaultDetectors returns explicit markers and keyword fallback for every
+// agent, plus transcript parsers for Claude Code and Codex.
+func DefaultDetectors() *DetectorSet {
+	set := NewDetectorSet()
+	set.Register("", MarkerDetector{}, KeywordDetector{})
+	set.Register("claude", ClaudeDetector{})
+	set.Register("codex", CodexDetector{})
+	return set
+}
+
+// Register adds detectors for an agent type.
+func (s *DetectorSet) Register(agentType string, detectors ...Detector) {
+	s.byAgent[agentType] = append(s.byAgent[agentType], detectors...)
+}
+
+// For returns the detectors for an agent. Target names such as
+// "claude-opus" match the "claude" parsers by prefix.
+func (s *DetectorSet) For(agentType string) []Detector {
+	names := make([]string, 0, len(s.byAgent))
+	for name := range s.byAgent {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []Detector
+	for _, name := range names {
+		if strings.HasPrefix(agentType, name) {
+			out = append(out, s.byAgent[name]...)
+		}
+	}
+	return out
+}
+
+// Detect runs the agent's detectors over lines. The most confident
+// detection wins; ties go to the one seen later in the log. With no
+// detection the state is working at zero confidence.
+func (s *DetectorSet) Detect(agentType string, lines []string) Detection {
+	best := Detection{State: StateWorking, Line: -1}
+	found := false
+	for _, d := range s.For(agentType) {
+		det, ok := d.Detect(lines)
+		if !ok {
+			continue
+		}
+		if !found || det.Confidence > best.Confidence || (det.Confidence == best.Confidence && det.Line > best.Line) {
+			best, found = det, true
+		}
+	}
+	return best
+}
+
+var defaultDetectors = DefaultDetectors()
+
+// Detect runs the default detectors for agentType over lines.
+func Detect(agentType string, lines []string) Detection {
+	return defaultDetectors.Detect(agentType, lines)
+}
+
+// DetectState classifies a single line with the default detectors. It
+// reports what they saw, even a keyword too weak to be actionable on its
+// own; callers changing task status should use Detect and Actionable.
 func DetectState(line string) string {
-    l := strings.ToLower(line)
-    if strings.Contains(l, "done") || strings.Contains(l, "complete") {
-        return "done"
-    }
-    if strings.Contains(l, "blocked") || strings.Contains(l, "waiting") {
-        return "blocked"
-    }
-    return "working"
+	return Detect("", []string{line}).State
+}
+
+func evidence(line string) string {
+	line = strings.TrimSpace(line)
+	if len(line) > maxEvidence {
+		line = line[:maxEvidence] + "…"
+	}
+	return line
+}
+
+// MarkerDetector reads explicit markers an agent or wrapper script prints:
+//
+//	COLDWINE:STATE done
+//	COLDWINE:STATE=blocked waiting on API credentials
+type MarkerDetector struct{}
+
+var markerPattern = regexp.MustCompile(`COLDWINE:STATE[=: ]\s*(working|done|blocked)\b`)
+
+func (MarkerDetector) Name() string { return "marker" }
+
+func (m MarkerDetector) Detect(lines []string) (Detection, bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		match := markerPattern.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		return Detection{State: match[1], Confidence: 1, Detector: m.Name(), Evidence: []string{evidence(lines[i])}, Line: i}, true
+	}
+	return Detection{}, false
+}
+
+// KeywordDetector is the low-confidence fallback for plain text logs. It only
+// accepts a status word that opens a line, such as "Done." or "Blocked:", and
+// ignores negated phrasing like "not done yet". Its detections sit below
+// MinConfidence: a keyword alone is shown, but never changes task status.
+type KeywordDetector struct{}
+
+const keywordConfidence = 0.4
+
+var (
+	keywordDone    = regexp.MustCompile(`(?i)^\W*(done|complete|completed|finished)\s*([.!:]|$)`)
+	keywordBlocked = regexp.MustCompile(`(?i)^\W*(blocked|stuck)\s*([.!:]|$)`)
+	keywordNegated = regexp.MustCompile(`(?i)\b(not|never|isn't|wasn't|aren't|almost|nearly)\b`)
+)
+
+func (KeywordDetector) Name() string { return "keyword" }
+
+func (k KeywordDetector) Detect(lines []string) (Detection, bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		var state string
+		switch {
+		case keywordBlocked.MatchString(line):
+			state = StateBlocked
+		case keywordDone.MatchString(line) && !keywordNegated.MatchString(line):
+			state = StateDone
+		default:
+			continue
+		}
+		return Detection{State: state, Confidence: keywordConfidence, Detector: k.Name(), Evidence: []string{evidence(line)}, Line: i}, true
+	}
+	return Detection{}, false
+}
+
+// ClaudeDetector parses Claude Code JSONL: the stream-json output of
+// `claude -p` and session transcripts.
+type ClaudeDetector struct{}
+
+type claudeEvent struct {
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+	IsError bool   `json:"is_error"`
+	Message struct {
+		StopReason string `json:"stop_reason"`
+	} `json:"message"`
+}
+
+func (ClaudeDetector) Name() string { return "claude-jsonl" }
+
+func (c ClaudeDetector) Detect(lines []string) (Detection, bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		var ev claudeEvent
+		if !decodeJSONLine(lines[i], &ev) {
+			continue
+		}
+		det := Detection{Detector: c.Name(), Evidence: []string{evidence(lines[i])}, Line: i}
+		switch {
+		case ev.Type == "result" && !ev.IsError && ev.Subtype == "success":
+			det.State, det.Confidence = StateDone, 0.95
+		case ev.Type == "result":
+			det.State, det.Confidence = StateBlocked, 0.9
+		case ev.Type == "assistant" && ev.Message.StopReason == "end_turn":
+			// A finished turn outside -p mode means the agent stopped, perhaps
+			// to ask a question; only a result event means the task is done.
+			det.State, det.Confidence = StateWaiting, 0.7
+		case ev.Type == "assistant" || ev.Type == "user" || ev.Type == "system":
+			det.State, det.Confidence = StateWorking, 0.6
+		default:
+			continue
+		}
+		return det, true
+	}
+	return Detection{}, false
+}
+
+// CodexDetector parses `codex exec --json` events, both the current
+// thread/turn events and the older msg-wrapped protocol.
+type CodexDetector struct{}
+
+type codexEvent struct {
+	Type string `json:"type"`
+	Msg  *struct {
+		Type string `json:"type"`
+	} `json:"msg"`
+}
+
+func (CodexDetector) Name() string { return "codex-json" }
+
+func (c CodexDetector) Detect(lines []string) (Detection, bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		var ev codexEvent
+		if !decodeJSONLine(lines[i], &ev) {
+			continue
+		}
+		kind := ev.Type
+		if ev.Msg != nil {
+			kind = ev.Msg.Type
+		}
+		det := Detection{Detector: c.Name(), Evidence: []string{evidence(lines[i])}, Line: i}
+		switch kind {
+		case "turn.completed", "task_complete":
+			det.State, det.Confidence = StateDone, 0.9
+		case "turn.failed", "error", "stream_error":
+			det.State, det.Confidence = StateBlocked, 0.85
+		case "thread.started", "turn.started", "item.started", "item.updated", "item.completed",
+			"task_started", "agent_message", "agent_reasoning", "exec_command_begin", "exec_command_end":
+			det.State, det.Confidence = StateWorking, 0.6
+		default:
+			continue
+		}
+		return det, true
+	}
+	return Detection{}, false
+}
+
+func decodeJSONLine(line string, v any) bool {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return false
+	}
+	return json.Unmarshal([]byte(line), v) == nil
 }
@@ -1,6 +1,11 @@
 package agent
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestDetectCompletion(t *testing.T) {
 	state := DetectState("Done. All tests pass.")
@@ -15,3 +20,105 @@ func TestDetectBlocked(t *testing.T) {
 		t.Fatalf("expected blocked, got %s", state)
 	}
 }
+
+func TestDetectStateIgnoresIncidentalKeywords(t *testing.T) {
+	for _, line := range []string{
+		"not done yet",
+		"Almost complete: 3 of 4 steps",
+		"the task is done when tests pass",
+		"waiting for go test",
+		"Is the migration done?",
+	} {
+		if state := DetectState(line); state != "working" {
+			t.Errorf("%q: expected working, got %s", line, state)
+		}
+	}
+}
+
+func TestDetectorFixtures(t *testing.T) {
+	cases := []struct {
+		fixture    string
+		agent      string
+		state      string
+		detector   string
+		confidence float64
+		evidence   string
+	}{
+		{"claude_success.jsonl", "claude", "done", "claude-jsonl", 0.95, `"subtype":"success"`},
+		{"claude_error.jsonl", "claude", "blocked", "claude-jsonl", 0.9, `"error_max_turns"`},
+		{"claude_working.jsonl", "claude", "working", "claude-jsonl", 0.6, `"stop_reason":"tool_use"`},
+		{"codex_complete.jsonl", "codex", "done", "codex-json", 0.9, `"turn.completed"`},
+		{"codex_failed.jsonl", "codex", "blocked", "codex-json", 0.85, "stream disconnected"},
+		{"codex_legacy.jsonl", "codex", "done", "codex-json", 0.9, `"task_complete"`},
+		{"marker.log", "aider", "done", "marker", 1, "COLDWINE:STATE done"},
+		{"marker_blocked.log", "claude", "blocked", "marker", 1, "API credentials"},
+		{"claude_question.jsonl", "claude", "waiting", "claude-jsonl", 0.7, `"stop_reason":"end_turn"`},
+		{"plain_done.log", "", "done", "keyword", 0.4, "Done. All tests pass."},
+		{"plain_negated.log", "", "working", "", 0, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "detect", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+			det := Detect(tc.agent, lines)
+			if det.State != tc.state || det.Detector != tc.detector || det.Confidence != tc.confidence {
+				t.Fatalf("got %s/%s/%.2f, want %s/%s/%.2f", det.State, det.Detector, det.Confidence, tc.state, tc.detector, tc.confidence)
+			}
+			if tc.evidence == "" {
+				if len(det.Evidence) != 0 {
+					t.Fatalf("expected no evidence, got %v", det.Evidence)
+				}
+				return
+			}
+			if len(det.Evidence) == 0 || !strings.Contains(det.Evidence[0], tc.evidence) {
+				t.Fatalf("expected evidence containing %q, got %v", tc.evidence, det.Evidence)
+			}
+		})
+	}
+}
+
+func TestWeakDetectionsAreNotActionable(t *testing.T) {
+	for _, tc := range []struct{ agent, line string }{
+		{"claude", `{"type":"assistant","message":{"stop_reason":"end_turn"}}`},
+		{"", "Done. All tests pass."},
+		{"", "Blocked: waiting on user"},
+	} {
+		if det := Detect(tc.agent, []string{tc.line}); det.Actionable() {
+			t.Errorf("%q: %s at %.2f should not change task status", tc.line, det.State, det.Confidence)
+		}
+	}
+}
+
+func TestDetectorSetUsesAgentParsersByPrefix(t *testing.T) {
+	line := `{"type":"result","subtype":"success","is_error":false}`
+	if det := Detect("claude-opus", []string{line}); det.Detector != "claude-jsonl" {
+		t.Fatalf("expected claude parser for claude-opus, got %q", det.Detector)
+	}
+	if det := Detect("codex", []string{line}); det.State != "working" {
+		t.Fatalf("claude event should not be parsed for codex, got %+v", det)
+	}
+}
+
+type fixedDetector struct{ det Detection }
+
+func (f fixedDetector) Name() string { return f.det.Detector }
+
+func (f fixedDetector) Detect([]string) (Detection, bool) { return f.det, true }
+
+func TestDetectorSetPrefersConfidenceThenRecency(t *testing.T) {
+	set := NewDetectorSet()
+	set.Register("",
+		fixedDetector{Detection{State: "done", Confidence: 0.5, Detector: "early", Line: 1}},
+		fixedDetector{Detection{State: "blocked", Confidence: 0.5, Detector: "late", Line: 4}},
+	)
+	if det := set.Detect("", nil); det.Detector != "late" {
+		t.Fatalf("expected later detection on tie, got %s", det.Detector)
+	}
+	set.Register("custom", fixedDetector{Detection{State: "done", Confidence: 0.8, Detector: "custom", Line: 0}})
+	if det := set.Detect("custom", nil); det.Detector != "custom" {
+		t.Fatalf("expected most confident detection, got %s", det.Detector)
+	}
+}
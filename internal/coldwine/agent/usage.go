@@ -0,0 +1,152 @@
+package agent
+
+import "strings"
+
+// Usage is token use and cost reported in a batch of session log lines.
+type Usage struct {
+	Model            string  `json:"model,omitempty"`
+	InputTokens      int64   `json:"input_tokens"`
+	OutputTokens     int64   `json:"output_tokens"`
+	CacheReadTokens  int64   `json:"cache_read_tokens"`
+	CacheWriteTokens int64   `json:"cache_write_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	// CostReported is set when CostUSD came from the agent itself rather
+	// than being left for a price table.
+	CostReported bool `json:"cost_reported"`
+}
+
+// Zero reports whether the batch carried no usage at all.
+func (u Usage) Zero() bool {
+	return u.InputTokens == 0 && u.OutputTokens == 0 && u.CacheReadTokens == 0 && u.CacheWriteTokens == 0 && u.CostUSD == 0
+}
+
+// ParseUsage reads token usage and the model from Claude Code stream-json
+// or Codex JSON output. Other agents report nothing.
+func ParseUsage(agentType string, lines []string) Usage {
+	var c UsageCounter
+	return c.Parse(agentType, lines)
+}
+
+// UsageCounter parses successive batches of one session's log. Claude Code
+// can split the repeats of a message across two reads of the log, so the
+// message IDs already counted are kept between batches.
+type UsageCounter struct {
+	seen map[string]bool
+}
+
+// Parse is ParseUsage for the next batch of the session's log.
+func (c *UsageCounter) Parse(agentType string, lines []string) Usage {
+	switch {
+	case strings.HasPrefix(agentType, "claude"):
+		if c.seen == nil {
+			c.seen = map[string]bool{}
+		}
+		return parseClaudeUsage(lines, c.seen)
+	case strings.HasPrefix(agentType, "codex"):
+		return parseCodexUsage(lines)
+	}
+	return Usage{}
+}
+
+type claudeTokens struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+type claudeUsageEvent struct {
+	Type         string  `json:"type"`
+	Model        string  `json:"model"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	Message      struct {
+		ID    string        `json:"id"`
+		Model string        `json:"model"`
+		Usage *claudeTokens `json:"usage"`
+	} `json:"message"`
+}
+
+// parseClaudeUsage sums per-message usage from assistant events. Claude
+// Code repeats an assistant message once per content block with the same
+// usage, so each message ID is counted once; seen holds the IDs already
+// counted. Cost only appears on result events.
+func parseClaudeUsage(lines []string, seen map[string]bool) Usage {
+	var u Usage
+	for _, line := range lines {
+		var ev claudeUsageEvent
+		if !decodeJSONLine(line, &ev) {
+			continue
+		}
+		switch ev.Type {
+		case "system":
+			if ev.Model != "" {
+				u.Model = ev.Model
+			}
+		case "assistant":
+			if ev.Message.Model != "" {
+				u.Model = ev.Message.Model
+			}
+			if ev.Message.Usage == nil || (ev.Message.ID != "" && seen[ev.Message.ID]) {
+				continue
+			}
+			seen[ev.Message.ID] = true
+			u.InputTokens += ev.Message.Usage.InputTokens
+			u.OutputTokens += ev.Message.Usage.OutputTokens
+			u.CacheReadTokens += ev.Message.Usage.CacheReadInputTokens
+			u.CacheWriteTokens += ev.Message.Usage.CacheCreationInputTokens
+		case "result":
+			if ev.TotalCostUSD > 0 {
+				u.CostUSD += ev.TotalCostUSD
+				u.CostReported = true
+			}
+		}
+	}
+	return u
+}
+
+type codexTokens struct {
+	InputTokens       int64 `json:"input_tokens"`
+	CachedInputTokens int64 `json:"cached_input_tokens"`
+	OutputTokens      int64 `json:"output_tokens"`
+}
+
+type codexUsageEvent struct {
+	Type  string       `json:"type"`
+	Model string       `json:"model"`
+	Usage *codexTokens `json:"usage"`
+	Msg   *struct {
+		Type  string `json:"type"`
+		Model string `json:"model"`
+		codexTokens
+	} `json:"msg"`
+}
+
+// parseCodexUsage reads turn.completed usage, and the token_count and
+// session_configured messages of the older protocol. Codex counts cached
+// input inside input_tokens, so it is split out here.
+func parseCodexUsage(lines []string) Usage {
+	var u Usage
+	add := func(t codexTokens) {
+		u.InputTokens += t.InputTokens - t.CachedInputTokens
+		u.CacheReadTokens += t.CachedInputTokens
+		u.OutputTokens += t.OutputTokens
+	}
+	for _, line := range lines {
+		var ev codexUsageEvent
+		if !decodeJSONLine(line, &ev) {
+			continue
+		}
+		if ev.Model != "" {
+			u.Model = ev.Model
+		}
+		switch {
+		case ev.Type == "turn.completed" && ev.Usage != nil:
+			add(*ev.Usage)
+		case ev.Msg != nil && ev.Msg.Type == "token_count":
+			add(ev.Msg.codexTokens)
+		case ev.Msg != nil && ev.Msg.Type == "session_configured" && ev.Msg.Model != "":
+			u.Model = ev.Msg.Model
+		}
+	}
+	return u
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fixtureLines(t *testing.T, parts ...string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(append([]string{"testdata"}, parts...)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
+
+func TestParseUsageFixtures(t *testing.T) {
+	cases := []struct {
+		agent string
+		path  []string
+		want  Usage
+	}{
+		{"claude", []string{"usage", "claude.jsonl"}, Usage{Model: "claude-sonnet-4-5", InputTokens: 20, OutputTokens: 120, CacheReadTokens: 21000, CacheWriteTokens: 3500, CostUSD: 0.0612, CostReported: true}},
+		{"codex", []string{"detect", "codex_complete.jsonl"}, Usage{InputTokens: 315, OutputTokens: 122, CacheReadTokens: 24448}},
+		{"codex", []string{"usage", "codex_legacy.jsonl"}, Usage{Model: "gpt-5-codex", InputTokens: 1100, OutputTokens: 100, CacheReadTokens: 1000}},
+		{"aider", []string{"usage", "claude.jsonl"}, Usage{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.agent+"/"+tc.path[1], func(t *testing.T) {
+			if got := ParseUsage(tc.agent, fixtureLines(t, tc.path...)); got != tc.want {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseUsageIgnoresPlainText(t *testing.T) {
+	if u := ParseUsage("claude", []string{"input_tokens: 5", "COLDWINE:STATE done"}); !u.Zero() {
+		t.Fatalf("expected no usage, got %+v", u)
+	}
+}
+
+func TestUsageCounterCountsMessagesOnceAcrossBatches(t *testing.T) {
+	lines := fixtureLines(t, "usage", "claude.jsonl")
+	// Split between the two repeats of msg_01.
+	var c UsageCounter
+	first := c.Parse("claude", lines[:2])
+	rest := c.Parse("claude", lines[2:])
+	if got := first.InputTokens + rest.InputTokens; got != 20 {
+		t.Fatalf("expected 20 input tokens across batches, got %d", got)
+	}
+	if got := first.OutputTokens + rest.OutputTokens; got != 120 {
+		t.Fatalf("expected 120 output tokens across batches, got %d", got)
+	}
+}
@@ -0,0 +1,408 @@
+// Package archive moves a project's Coldwine state between machines. An
+// archive is a zstd-compressed tar holding a JSON manifest, every state
+// table as JSON lines, and the files under .tandemonium: specs, plans,
+// session and merge logs, config and attachment blobs. Worktrees stay out;
+// their branches travel with git.
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format names the archive layout; Version is bumped on incompatible
+// changes to it.
+const (
+	Format  = "coldwine-archive"
+	Version = 1
+)
+
+const manifestName = "manifest.json"
+
+// Manifest describes an archive's contents. Every other entry is listed
+// with its checksum, and an archive whose entries do not match is rejected.
+type Manifest struct {
+	Format     string       `json:"format"`
+	Version    int          `json:"version"`
+	CreatedAt  time.Time    `json:"created_at"`
+	SourceRoot string       `json:"source_root"`
+	Tables     []TableEntry `json:"tables"`
+	Files      []FileEntry  `json:"files"`
+}
+
+// TableEntry is one state table, stored at Path as one JSON object per row.
+type TableEntry struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Rows   int    `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// FileEntry is a file from the state directory. Path is relative to
+// .tandemonium and uses forward slashes.
+type FileEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Rows is the number of rows across all tables.
+func (m Manifest) Rows() int {
+	n := 0
+	for _, t := range m.Tables {
+		n += t.Rows
+	}
+	return n
+}
+
+func stateDir(root string) string {
+	return filepath.Join(root, ".tandemonium")
+}
+
+// skipped reports whether a path under the state directory stays out of
+// archives: the database itself, worktrees and live sockets.
+func skipped(rel string) bool {
+	top, _, _ := strings.Cut(rel, "/")
+	switch top {
+	case "worktrees", "mailbus":
+		return true
+	}
+	return strings.HasPrefix(rel, "state.db")
+}
+
+// tableOrder lists parent tables before the tables that reference them, so
+// rows import with foreign keys enforced. Tables not listed follow by name.
+var tableOrder = []string{
+	"tasks", "sessions", "review_queue",
+	"epics", "stories", "work_tasks", "agent_sessions", "worktrees",
+}
+
+func tableRank(name string) int {
+	for i, t := range tableOrder {
+		if t == name {
+			return i
+		}
+	}
+	return len(tableOrder)
+}
+
+func sortTables(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := tableRank(names[i]), tableRank(names[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+}
+
+func listTables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sortTables(names)
+	return names, nil
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// dumpTable writes every row of a table as a JSON object per line.
+func dumpTable(db *sql.DB, name string) ([]byte, int, error) {
+	rows, err := db.Query(`SELECT * FROM ` + quoteIdent(name) + ` ORDER BY rowid`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, 0, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	n := 0
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, 0, err
+		}
+		row := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			if b, ok := vals[i].([]byte); ok {
+				vals[i] = string(b)
+			}
+			row[c] = vals[i]
+		}
+		if err := enc.Encode(row); err != nil {
+			return nil, 0, err
+		}
+		n++
+	}
+	return buf.Bytes(), n, rows.Err()
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hashFile(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func listFiles(root string) ([]FileEntry, error) {
+	dir := stateDir(root)
+	var out []FileEntry
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if skipped(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		sum, size, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		out = append(out, FileEntry{Path: rel, Size: size, SHA256: sum})
+		return nil
+	})
+	return out, err
+}
+
+// Export writes the project's state to w as an archive.
+func Export(w io.Writer, db *sql.DB, root string, now time.Time) (Manifest, error) {
+	m := Manifest{Format: Format, Version: Version, CreatedAt: now.UTC()}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return m, err
+	}
+	m.SourceRoot = root
+	names, err := listTables(db)
+	if err != nil {
+		return m, err
+	}
+	dumps := make([][]byte, len(names))
+	for i, name := range names {
+		data, n, err := dumpTable(db, name)
+		if err != nil {
+			return m, fmt.Errorf("dump %s: %w", name, err)
+		}
+		dumps[i] = data
+		m.Tables = append(m.Tables, TableEntry{Name: name, Path: "tables/" + name + ".jsonl", Rows: n, SHA256: checksum(data)})
+	}
+	if m.Files, err = listFiles(root); err != nil {
+		return m, err
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return m, err
+	}
+	tw := tar.NewWriter(zw)
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	if err := writeEntry(tw, manifestName, manifest, now); err != nil {
+		return m, err
+	}
+	for i, t := range m.Tables {
+		if err := writeEntry(tw, t.Path, dumps[i], now); err != nil {
+			return m, err
+		}
+	}
+	for _, f := range m.Files {
+		if err := copyFile(tw, root, f, now); err != nil {
+			return m, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return m, err
+	}
+	return m, zw.Close()
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte, now time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: now}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func copyFile(tw *tar.Writer, root string, f FileEntry, now time.Time) error {
+	src, err := os.Open(filepath.Join(stateDir(root), filepath.FromSlash(f.Path)))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := tw.WriteHeader(&tar.Header{Name: "files/" + f.Path, Mode: 0o644, Size: f.Size, ModTime: now}); err != nil {
+		return err
+	}
+	// The file changing since it was hashed fails the copy rather than
+	// producing an archive that no longer matches its manifest.
+	if _, err := io.CopyN(tw, src, f.Size); err != nil {
+		return fmt.Errorf("archive %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// Archive is a read and verified archive.
+type Archive struct {
+	Manifest Manifest
+	tables   map[string][]byte
+	files    map[string][]byte
+}
+
+// ErrCorrupt is wrapped by every integrity failure Read reports.
+var ErrCorrupt = errors.New("archive integrity check failed")
+
+func corrupt(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrCorrupt, fmt.Sprintf(format, args...))
+}
+
+// Read loads an archive and checks it against its manifest: the format and
+// version must be supported, every listed entry present with a matching
+// checksum, and nothing unlisted included.
+func Read(r io.Reader) (*Archive, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	entries := map[string][]byte{}
+	var manifest []byte
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, corrupt("%v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, corrupt("unexpected entry %s", hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, corrupt("%s: %v", hdr.Name, err)
+		}
+		if hdr.Name == manifestName {
+			manifest = data
+			continue
+		}
+		if _, dup := entries[hdr.Name]; dup {
+			return nil, corrupt("duplicate entry %s", hdr.Name)
+		}
+		entries[hdr.Name] = data
+	}
+	if manifest == nil {
+		return nil, corrupt("no %s", manifestName)
+	}
+	a := &Archive{tables: map[string][]byte{}, files: map[string][]byte{}}
+	if err := json.Unmarshal(manifest, &a.Manifest); err != nil {
+		return nil, corrupt("%s: %v", manifestName, err)
+	}
+	m := a.Manifest
+	if m.Format != Format {
+		return nil, fmt.Errorf("not a %s (format %q)", Format, m.Format)
+	}
+	if m.Version < 1 || m.Version > Version {
+		return nil, fmt.Errorf("unsupported %s version %d (this build reads up to %d)", Format, m.Version, Version)
+	}
+	take := func(name, sum string) ([]byte, error) {
+		data, ok := entries[name]
+		if !ok {
+			return nil, corrupt("missing %s", name)
+		}
+		delete(entries, name)
+		if checksum(data) != sum {
+			return nil, corrupt("checksum mismatch for %s", name)
+		}
+		return data, nil
+	}
+	for _, t := range m.Tables {
+		if t.Path != "tables/"+t.Name+".jsonl" {
+			return nil, corrupt("table %s stored at %s", t.Name, t.Path)
+		}
+		data, err := take(t.Path, t.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		a.tables[t.Name] = data
+	}
+	for _, f := range m.Files {
+		if f.Path == "" || path.IsAbs(f.Path) || path.Clean(f.Path) != f.Path || strings.HasPrefix(f.Path, "../") || skipped(f.Path) {
+			return nil, corrupt("invalid file path %q", f.Path)
+		}
+		data, err := take("files/"+f.Path, f.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		a.files[f.Path] = data
+	}
+	for name := range entries {
+		return nil, corrupt("unlisted entry %s", name)
+	}
+	return a, nil
+}
@@ -0,0 +1,356 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+func openProject(t *testing.T, root string) *sql.DB {
+	t.Helper()
+	if err := project.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.Open(project.StateDBPath(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// seedProject gives root an approved task with a session, log, spec,
+// failed merge, acceptance result, agent and a message with an attachment.
+func seedProject(t *testing.T, root, taskID, messageID string) *sql.DB {
+	t.Helper()
+	db := openProject(t, root)
+	sessionID := agent.SessionID(taskID)
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(storage.InsertTask(db, storage.Task{ID: taskID, Title: "Export " + taskID, Status: "review"}))
+	must(storage.InsertSession(db, storage.Session{ID: sessionID, TaskID: taskID, State: "done"}))
+	must(storage.QueueMerge(db, taskID, "feature/"+taskID))
+	mergeLog := filepath.Join(project.MergeLogsDir(root), taskID+".log")
+	writeFile(t, mergeLog, "ok "+taskID+"\n")
+	must(storage.FinishMerge(db, taskID, "failed", mergeLog, "tests failed"))
+	must(storage.ReplaceAcceptanceResults(db, taskID, []storage.AcceptanceResult{
+		{CriterionID: taskID + "-AC-001", Status: storage.AcceptancePassed, CheckKind: "command"},
+	}))
+	_, err := storage.UpsertAgent(db, storage.AgentProfile{Name: "claude", Program: "claude"})
+	must(err)
+	must(storage.SendMessage(db, storage.Message{
+		ID: messageID, ThreadID: taskID, Sender: "claude", Subject: "done", Body: "see log",
+		CreatedAt: "2026-01-02T03:04:05Z", Importance: "normal",
+	}, []string{"human"}))
+	src := filepath.Join(t.TempDir(), "notes.txt")
+	writeFile(t, src, "notes for "+taskID)
+	must(storage.AddAttachmentsWithStore(db, project.AttachmentsDir(root), messageID, []storage.Attachment{
+		{Path: src, CreatedAt: "2026-01-02T03:04:05Z"},
+	}))
+	writeFile(t, filepath.Join(project.SpecsDir(root), taskID+".yaml"),
+		"id: "+taskID+"\ntitle: Export\nacceptance_criteria:\n  - Exports include the header row\n")
+	writeFile(t, filepath.Join(project.SessionsDir(root), sessionID+".log"), "log of "+taskID+"\n")
+	return db
+}
+
+func exportProject(t *testing.T, db *sql.DB, root string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := Export(&buf, db, root, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readArchive(t *testing.T, data []byte) *Archive {
+	t.Helper()
+	a, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func count(t *testing.T, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestExportImportRoundTripsIntoEmptyProject(t *testing.T) {
+	src := t.TempDir()
+	srcDB := seedProject(t, src, "TAND-001", "msg-1")
+	data := exportProject(t, srcDB, src)
+
+	a := readArchive(t, data)
+	if a.Manifest.Version != Version || a.Manifest.SourceRoot != src {
+		t.Fatalf("unexpected manifest: %+v", a.Manifest)
+	}
+	for _, f := range a.Manifest.Files {
+		if strings.HasPrefix(f.Path, "state.db") {
+			t.Fatalf("database file archived: %s", f.Path)
+		}
+	}
+
+	dst := t.TempDir()
+	dstDB := openProject(t, dst)
+	res, err := a.Import(dstDB, dst, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Remapped) != 0 || len(res.FilesKept) != 0 {
+		t.Fatalf("expected a plain import, got %+v", res)
+	}
+	if res.Rows() != a.Manifest.Rows() {
+		t.Fatalf("imported %d rows, archive has %d", res.Rows(), a.Manifest.Rows())
+	}
+	task, err := storage.GetTask(dstDB, "TAND-001")
+	if err != nil || task.Status != "approved" {
+		t.Fatalf("task not imported: %+v, %v", task, err)
+	}
+	atts, err := storage.ListAttachments(dstDB, "msg-1")
+	if err != nil || len(atts) != 1 {
+		t.Fatalf("attachment not imported: %+v, %v", atts, err)
+	}
+	blob, err := storage.ReadAttachmentData(project.AttachmentsDir(dst), atts[0].BlobHash, 1024)
+	if err != nil || string(blob) != "notes for TAND-001" {
+		t.Fatalf("attachment blob not imported: %q, %v", blob, err)
+	}
+	entries, err := storage.ListMergeQueue(dstDB)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("merge queue not imported: %+v, %v", entries, err)
+	}
+	if want := filepath.Join(project.MergeLogsDir(dst), "TAND-001.log"); entries[0].LogPath != want {
+		t.Fatalf("log path %q not rebased to %q", entries[0].LogPath, want)
+	}
+
+	// A second export of the copy carries the same rows.
+	again := readArchive(t, exportProject(t, dstDB, dst))
+	for i, tbl := range again.Manifest.Tables {
+		if tbl.Rows != a.Manifest.Tables[i].Rows {
+			t.Fatalf("table %s: %d rows after round trip, want %d", tbl.Name, tbl.Rows, a.Manifest.Tables[i].Rows)
+		}
+	}
+}
+
+func TestImportRemapsClashingIDs(t *testing.T) {
+	src := t.TempDir()
+	srcDB := seedProject(t, src, "TAND-001", "msg-1")
+	a := readArchive(t, exportProject(t, srcDB, src))
+
+	dst := t.TempDir()
+	dstDB := seedProject(t, dst, "TAND-001", "msg-1")
+	if err := storage.InsertTask(dstDB, storage.Task{ID: "TAND-002", Title: "Other", Status: "todo"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dry, err := a.Import(dstDB, dst, Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dry.FilesWritten) == 0 || count(t, dstDB, `SELECT COUNT(*) FROM tasks`) != 2 {
+		t.Fatalf("dry run changed the project or reported nothing: %+v", dry)
+	}
+	if _, err := os.Stat(filepath.Join(project.SpecsDir(dst), "TAND-003.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("dry run wrote files: %v", err)
+	}
+
+	res, err := a.Import(dstDB, dst, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Remapped[KindTask]["TAND-001"]; got != "TAND-003" {
+		t.Fatalf("TAND-001 remapped to %q, want TAND-003 (remapped %+v)", got, res.Remapped)
+	}
+	if got := res.Remapped[KindSession]["tand-TAND-001"]; got != "tand-TAND-003" {
+		t.Fatalf("session follows its task: got %q", got)
+	}
+	if got := res.Remapped[KindMessage]["msg-1"]; got != "msg-2" {
+		t.Fatalf("msg-1 remapped to %q, want msg-2", got)
+	}
+
+	sess, err := storage.GetSession(dstDB, "tand-TAND-003")
+	if err != nil || sess.TaskID != "TAND-003" {
+		t.Fatalf("session not remapped: %+v, %v", sess, err)
+	}
+	if n := count(t, dstDB, `SELECT COUNT(*) FROM merge_queue WHERE task_id = 'TAND-003'`); n != 1 {
+		t.Fatalf("merge entry not remapped")
+	}
+	results, err := storage.ListAcceptanceResults(dstDB, "TAND-003")
+	if err != nil || len(results) != 1 || results[0].CriterionID != "TAND-003-AC-001" {
+		t.Fatalf("acceptance results not remapped: %+v, %v", results, err)
+	}
+	atts, err := storage.ListAttachments(dstDB, "msg-2")
+	if err != nil || len(atts) != 1 {
+		t.Fatalf("attachment not remapped: %+v, %v", atts, err)
+	}
+	if n := count(t, dstDB, `SELECT COUNT(*) FROM mailboxes WHERE message_id = 'msg-2'`); n != 1 {
+		t.Fatalf("mailbox not remapped")
+	}
+	for _, tr := range res.Tables {
+		if tr.Name == "agents" && (tr.Imported != 0 || tr.Skipped != 1) {
+			t.Fatalf("existing agent should be kept: %+v", tr)
+		}
+	}
+
+	spec, err := os.ReadFile(filepath.Join(project.SpecsDir(dst), "TAND-003.yaml"))
+	if err != nil || !strings.Contains(string(spec), "id: TAND-003") {
+		t.Fatalf("spec not renamed: %q, %v", spec, err)
+	}
+	orig, err := os.ReadFile(filepath.Join(project.SpecsDir(dst), "TAND-001.yaml"))
+	if err != nil || !strings.Contains(string(orig), "id: TAND-001") {
+		t.Fatalf("existing spec changed: %q, %v", orig, err)
+	}
+	logData, err := os.ReadFile(filepath.Join(project.SessionsDir(dst), "tand-TAND-003.log"))
+	if err != nil || string(logData) != "log of TAND-001\n" {
+		t.Fatalf("session log not renamed: %q, %v", logData, err)
+	}
+	entries, err := storage.ListMergeQueue(dstDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.TaskID == "TAND-003" && e.LogPath != filepath.Join(project.MergeLogsDir(dst), "TAND-003.log") {
+			t.Fatalf("merge log path not renamed: %q", e.LogPath)
+		}
+	}
+}
+
+func TestImportFailureLeavesProjectUntouched(t *testing.T) {
+	src := t.TempDir()
+	srcDB := seedProject(t, src, "TAND-001", "msg-1")
+	a := readArchive(t, exportProject(t, srcDB, src))
+	// The second file cannot be written under the first, which sorts last.
+	a.files["specs/zz"] = []byte("file")
+	a.files["specs/zz/nested.yaml"] = []byte("nested")
+
+	dst := t.TempDir()
+	dstDB := openProject(t, dst)
+	if _, err := a.Import(dstDB, dst, Options{}); err == nil {
+		t.Fatal("expected the import to fail")
+	}
+	if n := count(t, dstDB, `SELECT COUNT(*) FROM tasks`); n != 0 {
+		t.Fatalf("rows committed despite the failure: %d tasks", n)
+	}
+	for _, path := range []string{
+		filepath.Join(project.SpecsDir(dst), "TAND-001.yaml"),
+		filepath.Join(project.SpecsDir(dst), "zz"),
+		filepath.Join(project.SessionsDir(dst), "tand-TAND-001.log"),
+		filepath.Join(project.MergeLogsDir(dst), "TAND-001.log"),
+	} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("%s left behind: %v", path, err)
+		}
+	}
+}
+
+func rewriteArchive(t *testing.T, data []byte, edit func(name string, body []byte) []byte) []byte {
+	t.Helper()
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var out bytes.Buffer
+	zw, err := zstd.NewWriter(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, tw := tar.NewReader(zr), tar.NewWriter(zw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = edit(hdr.Name, body)
+		hdr.Size = int64(len(body))
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestReadRejectsTamperedArchive(t *testing.T) {
+	root := t.TempDir()
+	db := seedProject(t, root, "TAND-001", "msg-1")
+	data := exportProject(t, db, root)
+
+	tampered := rewriteArchive(t, data, func(name string, body []byte) []byte {
+		if name == "tables/tasks.jsonl" {
+			return bytes.Replace(body, []byte("approved"), []byte("rejected"), 1)
+		}
+		return body
+	})
+	if _, err := Read(bytes.NewReader(tampered)); !errors.Is(err, ErrCorrupt) || !strings.Contains(err.Error(), "tables/tasks.jsonl") {
+		t.Fatalf("expected checksum failure, got %v", err)
+	}
+
+	future := rewriteArchive(t, data, func(name string, body []byte) []byte {
+		if name == manifestName {
+			return bytes.Replace(body, []byte(`"version": 1`), []byte(`"version": 99`), 1)
+		}
+		return body
+	})
+	if _, err := Read(bytes.NewReader(future)); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Fatalf("expected version rejection, got %v", err)
+	}
+}
+
+func TestFreshID(t *testing.T) {
+	used := map[string]bool{"TAND-009": true, "TAND-010": true, "notes": true, "notes-2": true}
+	if got := freshID("TAND-009", used); got != "TAND-011" {
+		t.Fatalf("got %q", got)
+	}
+	if got := freshID("notes", used); got != "notes-3" {
+		t.Fatalf("got %q", got)
+	}
+}
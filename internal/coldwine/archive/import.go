@@ -0,0 +1,726 @@
+package archive
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// ID kinds that are remapped on import. Each kind is one ID space shared
+// by the columns listed in idRefs.
+const (
+	KindTask         = "task"
+	KindSession      = "session"
+	KindMessage      = "message"
+	KindEpic         = "epic"
+	KindStory        = "story"
+	KindWorkTask     = "work_task"
+	KindAgentSession = "agent_session"
+	KindWorktree     = "worktree"
+)
+
+type idRef struct {
+	table, column, kind string
+}
+
+var idRefs = []idRef{
+	{"tasks", "id", KindTask},
+	{"review_queue", "task_id", KindTask},
+	{"sessions", "task_id", KindTask},
+	{"scheduler_tasks", "task_id", KindTask},
+	{"merge_queue", "task_id", KindTask},
+	{"agent_health", "task_id", KindTask},
+	{"agent_incidents", "task_id", KindTask},
+	{"task_usage", "task_id", KindTask},
+	{"acceptance_results", "task_id", KindTask},
+	{"sessions", "id", KindSession},
+	{"scheduler_tasks", "session_id", KindSession},
+	{"agent_health", "session_id", KindSession},
+	{"agent_incidents", "session_id", KindSession},
+	{"messages", "id", KindMessage},
+	{"mailboxes", "message_id", KindMessage},
+	{"attachments", "message_id", KindMessage},
+	{"epics", "id", KindEpic},
+	{"stories", "epic_id", KindEpic},
+	{"stories", "id", KindStory},
+	{"work_tasks", "story_id", KindStory},
+	{"work_tasks", "id", KindWorkTask},
+	{"agent_sessions", "task_id", KindWorkTask},
+	{"worktrees", "task_id", KindWorkTask},
+	{"agent_sessions", "id", KindAgentSession},
+	{"work_tasks", "session_ref", KindAgentSession},
+	{"worktrees", "id", KindWorktree},
+	{"work_tasks", "worktree_ref", KindWorktree},
+}
+
+func refKind(table, column string) string {
+	for _, r := range idRefs {
+		if r.table == table && r.column == column {
+			return r.kind
+		}
+	}
+	return ""
+}
+
+// fileKinds maps state directories whose files are named after an ID to
+// that ID's kind and file suffix.
+var fileKinds = map[string][2]string{
+	"specs":     {KindTask, ".yaml"},
+	"merge":     {KindTask, ".log"},
+	"sessions":  {KindSession, ".log"},
+	"worktrees": {KindTask, ""},
+}
+
+// Options control an import.
+type Options struct {
+	// DryRun checks the import against the project and reports what it
+	// would do, changing nothing.
+	DryRun bool
+}
+
+// TableResult counts the rows of one table imported, and those skipped
+// because an equal key already existed (agents, contact policies).
+type TableResult struct {
+	Name     string `json:"name"`
+	Imported int    `json:"imported"`
+	Skipped  int    `json:"skipped,omitempty"`
+}
+
+// Result describes an import.
+type Result struct {
+	DryRun bool          `json:"dry_run,omitempty"`
+	Tables []TableResult `json:"tables"`
+	// UnknownTables are archive tables this build has no schema for.
+	UnknownTables []string `json:"unknown_tables,omitempty"`
+	FilesWritten  []string `json:"files_written,omitempty"`
+	// FilesKept already existed with other content and were left alone.
+	FilesKept      []string `json:"files_kept,omitempty"`
+	FilesUnchanged int      `json:"files_unchanged,omitempty"`
+	// Remapped lists, by kind, the IDs that clashed with the project and
+	// what they were renamed to.
+	Remapped map[string]map[string]string `json:"remapped,omitempty"`
+}
+
+// Rows is the number of rows imported across all tables.
+func (r Result) Rows() int {
+	n := 0
+	for _, t := range r.Tables {
+		n += t.Imported
+	}
+	return n
+}
+
+type column struct {
+	name    string
+	autoInc bool
+}
+
+type importer struct {
+	a        *Archive
+	db       *sql.DB
+	root     string
+	srcRoot  string
+	rows     map[string][]map[string]interface{}
+	columns  map[string][]column
+	remapped map[string]map[string]string
+}
+
+// Import adds the archive's state to the project at root. Row IDs that
+// SQLite assigns are reassigned; task, session, message and planning IDs
+// that already exist in the project are renamed (TAND-001 becomes the next
+// free TAND-NNN), along with every reference to them, the files named
+// after them and paths under the archive's source root. Rows go in as one
+// transaction; existing files are never overwritten. Files are written
+// before the rows are committed and removed again if either step fails, so
+// a failed import leaves the project as it was.
+func (a *Archive) Import(db *sql.DB, root string, opts Options) (Result, error) {
+	res := Result{DryRun: opts.DryRun}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return res, err
+	}
+	im := &importer{a: a, db: db, root: root, srcRoot: a.Manifest.SourceRoot, rows: map[string][]map[string]interface{}{}}
+	for name, data := range a.tables {
+		rows, err := decodeRows(data)
+		if err != nil {
+			return res, fmt.Errorf("%w: table %s: %v", ErrCorrupt, name, err)
+		}
+		im.rows[name] = rows
+	}
+	if err := im.loadColumns(); err != nil {
+		return res, err
+	}
+	if err := im.buildRemap(); err != nil {
+		return res, err
+	}
+	res.Remapped = im.remapped
+
+	// Every query against the project happens before the transaction, as
+	// the state database allows a single connection.
+	names := make([]string, 0, len(im.rows))
+	for name := range im.rows {
+		names = append(names, name)
+	}
+	sortTables(names)
+	files, err := im.planFiles(&res)
+	if err != nil {
+		return res, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+	for _, name := range names {
+		cols, ok := im.columns[name]
+		if !ok {
+			res.UnknownTables = append(res.UnknownTables, name)
+			continue
+		}
+		tr, err := im.insertRows(tx, name, cols)
+		if err != nil {
+			return res, fmt.Errorf("import %s: %w", name, err)
+		}
+		res.Tables = append(res.Tables, tr)
+	}
+	if opts.DryRun {
+		return res, nil
+	}
+	undo, err := writeFiles(files)
+	if err != nil {
+		return res, err
+	}
+	if err := tx.Commit(); err != nil {
+		undo()
+		return res, err
+	}
+	return res, nil
+}
+
+func decodeRows(data []byte) ([]map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out []map[string]interface{}
+	for dec.More() {
+		var row map[string]interface{}
+		if err := dec.Decode(&row); err != nil {
+			return nil, err
+		}
+		for k, v := range row {
+			if n, ok := v.(json.Number); ok {
+				if i, err := n.Int64(); err == nil {
+					row[k] = i
+				} else if f, err := n.Float64(); err == nil {
+					row[k] = f
+				}
+			}
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+// loadColumns reads the project's schema, adding the planning tables first
+// if the archive has them and the project does not.
+func (im *importer) loadColumns() error {
+	if err := im.readColumns(); err != nil {
+		return err
+	}
+	for name := range im.rows {
+		if _, ok := im.columns[name]; !ok {
+			if err := storage.MigrateV2(im.db); err != nil {
+				return err
+			}
+			return im.readColumns()
+		}
+	}
+	return nil
+}
+
+func (im *importer) readColumns() error {
+	names, err := listTables(im.db)
+	if err != nil {
+		return err
+	}
+	im.columns = map[string][]column{}
+	for _, name := range names {
+		rows, err := im.db.Query(`SELECT name, type, pk FROM pragma_table_info(?)`, name)
+		if err != nil {
+			return err
+		}
+		var cols []column
+		pks := 0
+		for rows.Next() {
+			var col, typ string
+			var pk int
+			if err := rows.Scan(&col, &typ, &pk); err != nil {
+				rows.Close()
+				return err
+			}
+			if pk > 0 {
+				pks++
+			}
+			cols = append(cols, column{name: col, autoInc: pk == 1 && strings.EqualFold(typ, "INTEGER")})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if pks > 1 {
+			for i := range cols {
+				cols[i].autoInc = false
+			}
+		}
+		im.columns[name] = cols
+	}
+	return nil
+}
+
+// buildRemap finds the archive IDs already taken in the project and picks
+// new ones. Sessions of renamed tasks follow their task's name.
+func (im *importer) buildRemap() error {
+	taken := map[string]map[string]bool{}
+	incoming := map[string]map[string]bool{}
+	add := func(m map[string]map[string]bool, kind, id string) {
+		if id == "" {
+			return
+		}
+		if m[kind] == nil {
+			m[kind] = map[string]bool{}
+		}
+		m[kind][id] = true
+	}
+	for _, r := range idRefs {
+		for _, row := range im.rows[r.table] {
+			if s, ok := row[r.column].(string); ok {
+				add(incoming, r.kind, s)
+			}
+		}
+		if _, ok := im.columns[r.table]; !ok {
+			continue
+		}
+		ids, err := distinct(im.db, r.table, r.column)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			add(taken, r.kind, id)
+		}
+	}
+	for rel := range im.a.files {
+		if kind, id, ok := fileID(rel); ok {
+			add(incoming, kind, id)
+		}
+	}
+	for dir, fk := range fileKinds {
+		entries, err := os.ReadDir(filepath.Join(stateDir(im.root), dir))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		for _, e := range entries {
+			if id, ok := strings.CutSuffix(e.Name(), fk[1]); ok {
+				add(taken, fk[0], id)
+			}
+		}
+	}
+
+	im.remapped = map[string]map[string]string{}
+	kinds := []string{KindTask, KindSession, KindMessage, KindEpic, KindStory, KindWorkTask, KindAgentSession, KindWorktree}
+	for _, kind := range kinds {
+		var derive func(string) string
+		if kind == KindSession {
+			tasks := im.remapped[KindTask]
+			derive = func(id string) string {
+				if taskID, ok := strings.CutPrefix(id, agent.SessionID("")); ok && tasks[taskID] != "" {
+					return agent.SessionID(tasks[taskID])
+				}
+				return ""
+			}
+		}
+		if m := remapIDs(incoming[kind], taken[kind], derive); len(m) > 0 {
+			im.remapped[kind] = m
+		}
+	}
+	return nil
+}
+
+func distinct(db *sql.DB, table, col string) ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT ` + quoteIdent(col) + ` FROM ` + quoteIdent(table) + ` WHERE ` + quoteIdent(col) + ` IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// fileID reports the kind and ID a state file is named after.
+func fileID(rel string) (string, string, bool) {
+	dir, name, ok := strings.Cut(rel, "/")
+	if !ok {
+		return "", "", false
+	}
+	fk, ok := fileKinds[dir]
+	if !ok {
+		return "", "", false
+	}
+	if fk[1] == "" {
+		name, _, _ = strings.Cut(name, "/")
+		return fk[0], name, true
+	}
+	id, ok := strings.CutSuffix(name, fk[1])
+	if !ok || strings.Contains(id, "/") {
+		return "", "", false
+	}
+	return fk[0], id, true
+}
+
+var trailingNumber = regexp.MustCompile(`^(.*?)([0-9]+)$`)
+
+// remapIDs renames the incoming IDs found in taken, and those derive gives
+// a new name. New IDs avoid both sets and each other.
+func remapIDs(incoming, taken map[string]bool, derive func(string) string) map[string]string {
+	used := map[string]bool{}
+	for id := range taken {
+		used[id] = true
+	}
+	ids := make([]string, 0, len(incoming))
+	for id := range incoming {
+		used[id] = true
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := map[string]string{}
+	for _, id := range ids {
+		want := id
+		if derive != nil {
+			if d := derive(id); d != "" {
+				want = d
+			}
+		}
+		if want == id && !taken[id] {
+			continue
+		}
+		if want == id || used[want] {
+			want = freshID(want, used)
+		}
+		used[want] = true
+		out[id] = want
+	}
+	return out
+}
+
+// freshID returns the first unused ID after id: its trailing number
+// counted up with the same width, or else a -2, -3, ... suffix.
+func freshID(id string, used map[string]bool) string {
+	if m := trailingNumber.FindStringSubmatch(id); m != nil {
+		if n, err := strconv.Atoi(m[2]); err == nil {
+			for i := n + 1; ; i++ {
+				c := fmt.Sprintf("%s%0*d", m[1], len(m[2]), i)
+				if !used[c] {
+					return c
+				}
+			}
+		}
+	}
+	for i := 2; ; i++ {
+		c := fmt.Sprintf("%s-%d", id, i)
+		if !used[c] {
+			return c
+		}
+	}
+}
+
+func (im *importer) mapID(kind, id string) string {
+	if n, ok := im.remapped[kind][id]; ok {
+		return n
+	}
+	return id
+}
+
+func (im *importer) insertRows(tx *sql.Tx, table string, cols []column) (TableResult, error) {
+	tr := TableResult{Name: table}
+	var names []string
+	for _, c := range cols {
+		if !c.autoInc {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) == 0 {
+		return tr, nil
+	}
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = quoteIdent(n)
+	}
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO ` + quoteIdent(table) + ` (` + strings.Join(quoted, ", ") +
+		`) VALUES (` + strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ") + `)`)
+	if err != nil {
+		return tr, err
+	}
+	defer stmt.Close()
+	for _, row := range im.rows[table] {
+		args := make([]interface{}, len(names))
+		for i, n := range names {
+			args[i] = im.value(table, n, row)
+		}
+		out, err := stmt.Exec(args...)
+		if err != nil {
+			return tr, err
+		}
+		if n, _ := out.RowsAffected(); n > 0 {
+			tr.Imported++
+		} else {
+			tr.Skipped++
+		}
+	}
+	return tr, nil
+}
+
+// value is a row's column as it goes into the project: remapped if it
+// holds an ID, rebased if it is a path under the source root.
+func (im *importer) value(table, col string, row map[string]interface{}) interface{} {
+	v := row[col]
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	if kind := refKind(table, col); kind != "" {
+		return im.mapID(kind, s)
+	}
+	if table == "acceptance_results" && col == "criterion_id" {
+		if taskID, ok := row["task_id"].(string); ok {
+			return im.criterionID(taskID, s)
+		}
+	}
+	return im.rebase(s)
+}
+
+// criterionID renames criterion IDs numbered after a renamed task.
+func (im *importer) criterionID(taskID, id string) string {
+	n, ok := im.remapped[KindTask][taskID]
+	if !ok {
+		return id
+	}
+	if rest, ok := strings.CutPrefix(id, taskID+"-"); ok {
+		return n + "-" + rest
+	}
+	return id
+}
+
+// rebase moves a path under the archive's source root to the project
+// root, following renamed state files.
+func (im *importer) rebase(s string) string {
+	if im.srcRoot == "" || !strings.HasPrefix(s, im.srcRoot) {
+		return s
+	}
+	rest := s[len(im.srcRoot):]
+	if rest != "" && rest[0] != '/' {
+		return s
+	}
+	if rel, ok := strings.CutPrefix(rest, "/.tandemonium/"); ok {
+		return filepath.Join(stateDir(im.root), filepath.FromSlash(im.relPath(rel)))
+	}
+	return im.root + rest
+}
+
+// relPath is where an archived state file goes in the project.
+func (im *importer) relPath(rel string) string {
+	kind, id, ok := fileID(rel)
+	if !ok {
+		return rel
+	}
+	n, ok := im.remapped[kind][id]
+	if !ok {
+		return rel
+	}
+	dir, name, _ := strings.Cut(rel, "/")
+	return dir + "/" + n + strings.TrimPrefix(name, id)
+}
+
+// stagedFile is an archived state file due to be written into the project.
+type stagedFile struct {
+	dst  string
+	data []byte
+}
+
+// planFiles decides where each archived file goes and records the outcome
+// in res, writing nothing.
+func (im *importer) planFiles(res *Result) ([]stagedFile, error) {
+	rels := make([]string, 0, len(im.a.files))
+	for rel := range im.a.files {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	base := stateDir(im.root)
+	var out []stagedFile
+	for _, rel := range rels {
+		data := im.a.files[rel]
+		if strings.HasPrefix(rel, "specs/") && strings.HasSuffix(rel, ".yaml") {
+			var err error
+			if data, err = im.rewriteSpec(data); err != nil {
+				return nil, fmt.Errorf("rewrite %s: %w", rel, err)
+			}
+		}
+		target := im.relPath(rel)
+		dst, err := project.SafePath(base, filepath.FromSlash(target))
+		if err != nil {
+			return nil, err
+		}
+		existing, err := os.ReadFile(dst)
+		switch {
+		case err == nil && bytes.Equal(existing, data):
+			res.FilesUnchanged++
+			continue
+		case err == nil:
+			res.FilesKept = append(res.FilesKept, target)
+			continue
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		}
+		res.FilesWritten = append(res.FilesWritten, target)
+		out = append(out, stagedFile{dst: dst, data: data})
+	}
+	return out, nil
+}
+
+// writeFiles creates the staged files and their directories. It returns a
+// function that removes everything it created, and calls it itself when a
+// write fails. A file that appeared since planning is not overwritten.
+func writeFiles(files []stagedFile) (func(), error) {
+	var created []string
+	undo := func() {
+		for i := len(created) - 1; i >= 0; i-- {
+			_ = os.Remove(created[i])
+		}
+	}
+	for _, f := range files {
+		dir := filepath.Dir(f.dst)
+		missing := missingDirs(dir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			undo()
+			return nil, err
+		}
+		created = append(created, missing...)
+		out, err := os.OpenFile(f.dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			undo()
+			return nil, err
+		}
+		created = append(created, f.dst)
+		_, err = out.Write(f.data)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			undo()
+			return nil, err
+		}
+	}
+	return undo, nil
+}
+
+// missingDirs lists dir and those of its parents that do not exist yet,
+// outermost first.
+func missingDirs(dir string) []string {
+	var out []string
+	for {
+		if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		out = append([]string{dir}, out...)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return out
+}
+
+// rewriteSpec renames a task spec's ID, dependencies and numbered
+// acceptance criteria after renamed tasks. Specs that need no change keep
+// their bytes.
+func (im *importer) rewriteSpec(data []byte) ([]byte, error) {
+	tasks := im.remapped[KindTask]
+	if len(tasks) == 0 {
+		return data, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil
+	}
+	spec := doc.Content[0]
+	changed := false
+	rename := func(n *yaml.Node, to string) {
+		if n.Kind == yaml.ScalarNode && to != n.Value {
+			n.Value = to
+			changed = true
+		}
+	}
+	taskID := ""
+	if n := mappingValue(spec, "id"); n != nil {
+		taskID = n.Value
+		rename(n, im.mapID(KindTask, n.Value))
+	}
+	if deps := mappingValue(spec, "dependencies"); deps != nil && deps.Kind == yaml.SequenceNode {
+		for _, d := range deps.Content {
+			rename(d, im.mapID(KindTask, d.Value))
+		}
+	}
+	if criteria := mappingValue(spec, "acceptance_criteria"); criteria != nil && criteria.Kind == yaml.SequenceNode && taskID != "" {
+		for _, c := range criteria.Content {
+			if id := mappingValue(c, "id"); id != nil {
+				rename(id, im.criterionID(taskID, id.Value))
+			}
+		}
+	}
+	if !changed {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
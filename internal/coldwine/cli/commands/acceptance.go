@@ -0,0 +1,270 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/acceptance"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/spf13/cobra"
+)
+
+func AcceptanceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "acceptance",
+		Short: "Scaffold and run executable checks for acceptance criteria",
+		Long: `Each acceptance criterion in a task spec is checked by, in order: its
+test_command, a shell script, or a Go test in acceptance/<TASK>/ inside the
+task's worktree.
+
+  acceptance_criteria:
+    - id: TAND-001-AC-001
+      given: a saved draft
+      when: the user reloads the page
+      then: the draft is restored
+    - description: Exports include the header row
+      test_command: go test ./export -run TestHeader
+
+A task cannot be approved until every criterion passed its last
+"coldwine acceptance verify".`,
+	}
+	cmd.AddCommand(acceptanceScaffoldCmd(), acceptanceVerifyCmd(), acceptanceStatusCmd())
+	return cmd
+}
+
+func taskIDArg(name string) cobra.PositionalArgs {
+	return wrapArgs(name, func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return project.ValidateTaskID(args[0])
+	})
+}
+
+// taskCriteria loads the task's acceptance criteria and locates its worktree.
+func taskCriteria(root, taskID string) ([]acceptance.Criterion, string, error) {
+	specPath, err := project.TaskSpecPath(root, taskID)
+	if err != nil {
+		return nil, "", err
+	}
+	criteria, err := acceptance.LoadTaskCriteria(specPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if !worktreeExists(root, taskID) {
+		return nil, "", fmt.Errorf("%s has no worktree", taskID)
+	}
+	worktree, err := project.SafePath(project.WorktreesDir(root), taskID)
+	return criteria, worktree, err
+}
+
+func acceptanceScaffoldCmd() *cobra.Command {
+	var (
+		style   string
+		jsonOut bool
+	)
+	cmd := &cobra.Command{
+		Use:   "scaffold <task-id>",
+		Short: "Write check skeletons for a task's acceptance criteria into its worktree",
+		Long: `Write a skipped Go test (--style go) or a shell script (--style shell)
+for every criterion without a test_command. Skeletons report themselves as
+skipped, so their criteria stay unverified until the checks are written.
+Existing checks are never overwritten.`,
+		Args: taskIDArg("acceptance scaffold"),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("acceptance scaffold", err)
+				}
+			}()
+			root, err := project.FindRoot(".")
+			if err != nil {
+				return err
+			}
+			criteria, worktree, err := taskCriteria(root, args[0])
+			if err != nil {
+				return err
+			}
+			if len(criteria) == 0 {
+				return fmt.Errorf("%s has no acceptance criteria", args[0])
+			}
+			res, err := acceptance.Scaffold(worktree, args[0], style, criteria)
+			if err != nil {
+				return err
+			}
+			if jsonOut {
+				return writeJSON(cmd, res)
+			}
+			out := cmd.OutOrStdout()
+			for _, id := range res.Written {
+				fmt.Fprintf(out, "scaffolded %s\n", id)
+			}
+			for _, id := range res.Kept {
+				fmt.Fprintf(out, "kept       %s\n", id)
+			}
+			fmt.Fprintf(out, "Checks in %s\n", res.Dir)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&style, "style", acceptance.StyleGo, "Check style: go or shell")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+func acceptanceVerifyCmd() *cobra.Command {
+	var (
+		timeout time.Duration
+		jsonOut bool
+	)
+	cmd := &cobra.Command{
+		Use:   "verify <task-id>",
+		Short: "Run a task's acceptance checks and record the results for review",
+		Long: `Run every acceptance check of the task in its worktree and record the
+pass/fail results, with their output, as review evidence. Exits non-zero
+while any criterion is unverified.`,
+		Args:         taskIDArg("acceptance verify"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("acceptance verify", err)
+				}
+			}()
+			taskID := args[0]
+			root, err := project.FindRoot(".")
+			if err != nil {
+				return err
+			}
+			criteria, worktree, err := taskCriteria(root, taskID)
+			if err != nil {
+				return err
+			}
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			v := &acceptance.Verifier{Worktree: worktree, TaskID: taskID, Timeout: timeout}
+			results, err := v.Verify(ctx, criteria)
+			if err != nil {
+				return err
+			}
+			if err := storage.ReplaceAcceptanceResults(db, taskID, results); err != nil {
+				return err
+			}
+			unverified := acceptance.Unverified(criteria, results, "")
+			if jsonOut {
+				if err := writeJSON(cmd, map[string]interface{}{
+					"task_id":    taskID,
+					"results":    results,
+					"unverified": unverified,
+				}); err != nil {
+					return err
+				}
+			} else {
+				writeAcceptanceResults(cmd, criteria, results)
+			}
+			if len(unverified) > 0 {
+				return &acceptance.UnverifiedError{TaskID: taskID, Criteria: unverified}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "Time limit for each check run")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+func acceptanceStatusCmd() *cobra.Command {
+	var jsonOut bool
+	cmd := &cobra.Command{
+		Use:   "status <task-id>",
+		Short: "Show the last verification of a task's acceptance criteria",
+		Args:  taskIDArg("acceptance status"),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("acceptance status", err)
+				}
+			}()
+			taskID := args[0]
+			root, err := project.FindRoot(".")
+			if err != nil {
+				return err
+			}
+			specPath, err := project.TaskSpecPath(root, taskID)
+			if err != nil {
+				return err
+			}
+			criteria, err := acceptance.LoadTaskCriteria(specPath)
+			if err != nil {
+				return err
+			}
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+			results, err := storage.ListAcceptanceResults(db, taskID)
+			if err != nil {
+				return err
+			}
+			revision, err := acceptance.Revision(root, taskID, "")
+			if err != nil {
+				return err
+			}
+			if jsonOut {
+				return writeJSON(cmd, map[string]interface{}{
+					"task_id":    taskID,
+					"revision":   revision,
+					"results":    results,
+					"unverified": acceptance.Unverified(criteria, results, revision),
+				})
+			}
+			writeAcceptanceResults(cmd, criteria, results)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+// checkAcceptance blocks approval while any of the task's acceptance
+// criteria is unverified at the tip of branch.
+func checkAcceptance(root, taskID, branch string) error {
+	db, closeDB, err := openStateDB()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	return acceptance.CheckApproval(db, root, taskID, branch)
+}
+
+func writeAcceptanceResults(cmd *cobra.Command, criteria []acceptance.Criterion, results []storage.AcceptanceResult) {
+	byID := make(map[string]storage.AcceptanceResult, len(results))
+	for _, r := range results {
+		byID[r.CriterionID] = r
+	}
+	out := cmd.OutOrStdout()
+	for _, c := range criteria {
+		r, ok := byID[c.ID]
+		if !ok {
+			fmt.Fprintf(out, "%-7s %s  %s\n", "-", c.ID, acceptance.Summary(c))
+			continue
+		}
+		fmt.Fprintf(out, "%-7s %s  %s\n", r.Status, c.ID, acceptance.Summary(c))
+		if r.Status != storage.AcceptancePassed && strings.TrimSpace(r.Output) != "" {
+			for _, line := range strings.Split(strings.TrimRight(r.Output, "\n"), "\n") {
+				fmt.Fprintf(out, "        | %s\n", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/acceptance"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/spf13/cobra"
+)
+
+func runCommand(t *testing.T, cmd *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+	out := bytes.NewBuffer(nil)
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestAcceptanceScaffoldVerifyGatesApproval(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	gitIn(t, dir, "init", "-b", "main")
+	gitIn(t, dir, "config", "user.email", "test@example.com")
+	gitIn(t, dir, "config", "user.name", "Test User")
+	writeFiles(t, dir, map[string]string{".gitignore": ".tandemonium/\n"})
+	gitIn(t, dir, "add", ".")
+	gitIn(t, dir, "commit", "-m", "init")
+	if err := project.Init(dir); err != nil {
+		t.Fatal(err)
+	}
+	spec := "id: TAND-001\ntitle: Export\nacceptance_criteria:\n  - id: TAND-001-AC-001\n    description: CSV has a header\n  - description: Lint is clean\n    test_command: \"true\"\n"
+	if err := os.WriteFile(filepath.Join(project.SpecsDir(dir), "TAND-001.yaml"), []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Join(project.WorktreesDir(dir), "TAND-001")
+	gitIn(t, dir, "worktree", "add", "-b", "feature/TAND-001", worktree)
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCommand(t, AcceptanceCmd(), "scaffold", "TAND-001", "--style", "shell")
+	if err != nil {
+		t.Fatalf("scaffold failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "scaffolded TAND-001-AC-001") || !strings.Contains(out, "kept       TAND-001-AC-002") {
+		t.Fatalf("unexpected scaffold output:\n%s", out)
+	}
+
+	out, err = runCommand(t, AcceptanceCmd(), "verify", "TAND-001")
+	var unverified *acceptance.UnverifiedError
+	if !errors.As(err, &unverified) || len(unverified.Criteria) != 1 {
+		t.Fatalf("expected the scaffolded check to stay unverified, got %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "skip    TAND-001-AC-001  CSV has a header") || !strings.Contains(out, "pass    TAND-001-AC-002  Lint is clean") {
+		t.Fatalf("unexpected verify output:\n%s", out)
+	}
+	if _, err := runCommand(t, ApproveCmd(), "TAND-001", "feature/TAND-001"); err == nil || !strings.Contains(err.Error(), "unverified acceptance criteria: TAND-001-AC-001") {
+		t.Fatalf("expected approval to be blocked, got %v", err)
+	}
+
+	script := filepath.Join(worktree, acceptance.ChecksDir("TAND-001"), "TAND-001-AC-001.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runCommand(t, AcceptanceCmd(), "verify", "TAND-001", "--json"); err != nil || !strings.Contains(out, `"status": "pass"`) {
+		t.Fatalf("expected verify to pass, got %v\n%s", err, out)
+	}
+
+	// A commit after verification makes the passes stale.
+	writeFiles(t, worktree, map[string]string{"export.go": "package export\n"})
+	gitIn(t, worktree, "add", "export.go")
+	gitIn(t, worktree, "commit", "-m", "export")
+	if _, err := runCommand(t, ApproveCmd(), "TAND-001", "feature/TAND-001"); !errors.As(err, &unverified) || len(unverified.Criteria) != 2 {
+		t.Fatalf("expected a stale verification to block approval, got %v", err)
+	}
+	if out, err := runCommand(t, AcceptanceCmd(), "verify", "TAND-001"); err != nil {
+		t.Fatalf("expected verify to pass again, got %v\n%s", err, out)
+	}
+	out, err = runCommand(t, ApproveCmd(), "TAND-001", "feature/TAND-001")
+	if err != nil || !strings.Contains(out, "queued feature/TAND-001 for merge") {
+		t.Fatalf("expected approval after verification, got %v\n%s", err, out)
+	}
+}
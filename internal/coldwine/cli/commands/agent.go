@@ -3,7 +3,6 @@ package commands
 import (
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/mistakeknot/autarch/internal/coldwine/project"
 	"github.com/mistakeknot/autarch/internal/coldwine/storage"
@@ -159,38 +158,6 @@ func agentWhoisCmd() *cobra.Command {
 	return cmd
 }
 
-func agentHealthCmd() *cobra.Command {
-	var jsonOut bool
-	cmd := &cobra.Command{
-		Use:   "health",
-		Short: "Check agent registry health",
-		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			defer func() {
-				if err != nil {
-					err = wrapCommandError("agent health", err)
-				}
-			}()
-			_, closeDB, err := openStateDB()
-			if err != nil {
-				return err
-			}
-			defer closeDB()
-			ts := time.Now().UTC().Format(time.RFC3339Nano)
-			if jsonOut {
-				payload := map[string]interface{}{
-					"status":    "ok",
-					"timestamp": ts,
-				}
-				return writeJSON(cmd, payload)
-			}
-			fmt.Fprintf(cmd.OutOrStdout(), "ok %s\n", ts)
-			return nil
-		},
-	}
-	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
-	return cmd
-}
-
 func ensureProjectAndDB() (string, bool, error) {
 	cwd, _ := os.Getwd()
 	root, err := project.FindRoot(cwd)
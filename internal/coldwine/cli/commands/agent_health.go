@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/config"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/scheduler"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/mistakeknot/autarch/internal/coldwine/supervisor"
+	"github.com/mistakeknot/autarch/internal/coldwine/tasks"
+	"github.com/mistakeknot/autarch/internal/coldwine/tmux"
+	"github.com/spf13/cobra"
+)
+
+// newHealthProbe and listTmuxSessions are replaced in tests.
+var (
+	newHealthProbe = func(root string, cfg config.Config, db *sql.DB) supervisor.Probe {
+		return supervisor.NewTmuxProbe(restartSession(root, cfg, db))
+	}
+	listTmuxSessions = func() ([]string, error) { return tmux.ListSessions("tand-") }
+)
+
+func agentHealthCmd() *cobra.Command {
+	var (
+		check     bool
+		watch     bool
+		interval  time.Duration
+		incidents int
+		reset     string
+		jsonOut   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "health",
+		Short: "Show agent session health and incident history",
+		Long: `Show the supervisor's view of each agent session and its recent incidents.
+
+A session is healthy while its log grows or its agent sends heartbeats. A
+live session with neither for coding_agent.stall_timeout seconds is stalled;
+CPU use tells a busy loop from an agent idle at a prompt. Dead and stalled
+sessions are handled by coding_agent.restart_policy:
+
+  never       record the incident and escalate by mail
+  on-failure  restart at once, up to max_restarts
+  backoff     restart after restart_backoff seconds, doubling each attempt
+
+Escalations go to coding_agent.escalate_to (default $TAND_MAIL_RECIPIENT or
+$USER). A session the policy gave up on stays failed until --reset.
+
+Examples:
+  coldwine agent health                 # last recorded state
+  coldwine agent health --check         # run one supervisor pass first
+  coldwine agent health --watch         # supervise until interrupted`,
+		Args: wrapArgs("agent health", cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("agent health", err)
+				}
+			}()
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+			// Heartbeats live in agent_sessions.
+			if err := storage.MigrateV2(db); err != nil {
+				return err
+			}
+			if reset != "" {
+				if err := storage.ResetAgentHealth(db, reset); err != nil {
+					return err
+				}
+			}
+
+			if check || watch {
+				root, err := project.FindRoot(".")
+				if err != nil {
+					return err
+				}
+				cfg, err := config.LoadFromProject(root)
+				if err != nil {
+					return err
+				}
+				opts, err := supervisorOptions(cfg)
+				if err != nil {
+					return err
+				}
+				sup := supervisor.New(db, newHealthProbe(root, cfg, db), opts)
+				list := func() ([]supervisor.Session, error) { return supervisedSessions(db, root) }
+				if watch {
+					if !cmd.Flags().Changed("interval") && cfg.Coding.HealthCheckInterval > 0 {
+						interval = time.Duration(cfg.Coding.HealthCheckInterval) * time.Second
+					}
+					ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+					defer stop()
+					out := cmd.OutOrStdout()
+					err := sup.Run(ctx, interval, list, func(inc storage.AgentIncident) {
+						printIncident(out, inc)
+					})
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				sessions, err := list()
+				if err != nil {
+					return err
+				}
+				if _, _, err := sup.CheckAll(sessions); err != nil {
+					return err
+				}
+			}
+
+			states, err := storage.ListAgentHealth(db)
+			if err != nil {
+				return err
+			}
+			history, err := storage.ListAgentIncidents(db, "", incidents)
+			if err != nil {
+				return err
+			}
+			status := "ok"
+			for _, h := range states {
+				if h.Status != supervisor.StatusHealthy {
+					status = "degraded"
+				}
+			}
+			ts := time.Now().UTC().Format(time.RFC3339Nano)
+			if jsonOut {
+				if states == nil {
+					states = []storage.AgentHealth{}
+				}
+				if history == nil {
+					history = []storage.AgentIncident{}
+				}
+				payload := map[string]interface{}{
+					"status":    status,
+					"timestamp": ts,
+					"sessions":  states,
+					"incidents": history,
+				}
+				return writeJSON(cmd, payload)
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "%s %s\n", status, ts)
+			now := time.Now()
+			for _, h := range states {
+				line := fmt.Sprintf("%-20s %-10s restarts=%d last output %s ago", h.SessionID, h.Status, h.Restarts, now.Sub(h.LastOutputAt).Round(time.Second))
+				if h.Detail != "" {
+					line += ": " + h.Detail
+				}
+				fmt.Fprintln(out, line)
+			}
+			if len(history) > 0 {
+				fmt.Fprintln(out, "Incidents:")
+				for _, inc := range history {
+					printIncident(out, inc)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&check, "check", false, "Run one supervisor pass before reporting")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Supervise sessions until interrupted")
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "Interval between supervisor passes with --watch")
+	cmd.Flags().IntVar(&incidents, "incidents", 20, "Number of recent incidents to show")
+	cmd.Flags().StringVar(&reset, "reset", "", "Clear a session's health state and restart count")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+func printIncident(out io.Writer, inc storage.AgentIncident) {
+	line := fmt.Sprintf("  %s %-20s %s", inc.CreatedAt.Local().Format("2006-01-02 15:04:05"), inc.SessionID, inc.Kind)
+	if inc.Detail != "" {
+		line += ": " + inc.Detail
+	}
+	if inc.Action != "" {
+		line += " [" + inc.Action + "]"
+	}
+	fmt.Fprintln(out, line)
+}
+
+func supervisorOptions(cfg config.Config) (supervisor.Options, error) {
+	mode := cfg.Coding.RestartPolicy
+	if mode == "" {
+		mode = supervisor.PolicyNever
+		if cfg.Coding.RestartOnFailure {
+			mode = supervisor.PolicyOnFailure
+		}
+	}
+	policy, err := supervisor.ParsePolicy(mode, cfg.Coding.MaxRestarts, time.Duration(cfg.Coding.RestartBackoff)*time.Second)
+	if err != nil {
+		return supervisor.Options{}, err
+	}
+	escalate := cfg.Coding.EscalateTo
+	if len(escalate) == 0 {
+		recipient := strings.TrimSpace(os.Getenv("TAND_MAIL_RECIPIENT"))
+		if recipient == "" {
+			recipient = strings.TrimSpace(os.Getenv("USER"))
+		}
+		if recipient != "" {
+			escalate = []string{recipient}
+		}
+	}
+	return supervisor.Options{
+		StallAfter: time.Duration(cfg.Coding.StallTimeout) * time.Second,
+		Policy:     policy,
+		EscalateTo: escalate,
+	}, nil
+}
+
+// supervisedSessions is every live tand- session plus sessions the supervisor
+// already tracks, so a session that died is still checked. Sessions whose
+// task has moved past in_progress are left alone.
+func supervisedSessions(db *sql.DB, root string) ([]supervisor.Session, error) {
+	ids := map[string]bool{}
+	live, err := listTmuxSessions()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range live {
+		ids[id] = true
+	}
+	tracked, err := storage.ListAgentHealth(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range tracked {
+		ids[h.SessionID] = true
+	}
+	var out []supervisor.Session
+	for id := range ids {
+		taskID := strings.TrimPrefix(id, "tand-")
+		if task, err := storage.GetTask(db, taskID); err == nil && task.Status != "in_progress" {
+			continue
+		}
+		out = append(out, supervisor.Session{
+			ID:      id,
+			TaskID:  taskID,
+			LogPath: filepath.Join(project.SessionsDir(root), id+".log"),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// restartSession kills what is left of a session and relaunches the task's
+// agent in its existing worktree, on the target the scheduler last used.
+func restartSession(root string, cfg config.Config, db *sql.DB) func(s supervisor.Session) error {
+	return func(s supervisor.Session) error {
+		rt := scheduler.NewTmuxRuntime(root)
+		if rt.Alive(s.ID) {
+			if err := tmux.StopSession(rt.Runner, s.ID); err != nil {
+				return err
+			}
+		}
+		target := cfg.Scheduler.DefaultAgent
+		if cps, err := storage.ListSchedulerTasks(db); err == nil && cps[s.TaskID].Target != "" {
+			target = cps[s.TaskID].Target
+		}
+		task := tasks.TaskProposal{ID: s.TaskID}
+		if t, err := storage.GetTask(db, s.TaskID); err == nil {
+			task.Title = t.Title
+		}
+		return rt.Launch(scheduler.Launch{Task: task, TaskID: s.TaskID, Target: target, Session: s.ID})
+	}
+}
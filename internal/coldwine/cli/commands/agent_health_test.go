@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/config"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/supervisor"
+)
+
+type deadProbe struct{ restarted []string }
+
+func (p *deadProbe) Alive(string) bool { return false }
+
+func (p *deadProbe) CPUTicks(string) (int64, bool) { return 0, false }
+
+func (p *deadProbe) Restart(s supervisor.Session) error {
+	p.restarted = append(p.restarted, s.ID)
+	return nil
+}
+
+func TestAgentHealthCheckRecordsIncidents(t *testing.T) {
+	root := t.TempDir()
+	if err := project.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	cfgText := "[coding_agent]\nrestart_policy = \"on-failure\"\nescalate_to = [\"ops\"]\n"
+	if err := os.WriteFile(filepath.Join(root, ".tandemonium", "config.toml"), []byte(cfgText), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	probe := &deadProbe{}
+	prevProbe, prevList := newHealthProbe, listTmuxSessions
+	newHealthProbe = func(string, config.Config, *sql.DB) supervisor.Probe { return probe }
+	listTmuxSessions = func() ([]string, error) { return []string{"tand-TAND-001"}, nil }
+	defer func() { newHealthProbe, listTmuxSessions = prevProbe, prevList }()
+
+	cmd := AgentCmd()
+	out := bytes.NewBuffer(nil)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"health", "--check", "--json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Status   string `json:"status"`
+		Sessions []struct {
+			SessionID string `json:"session_id"`
+			Status    string `json:"status"`
+			Restarts  int    `json:"restarts"`
+		} `json:"sessions"`
+		Incidents []struct {
+			Kind string `json:"kind"`
+		} `json:"incidents"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode: %v\n%s", err, out.String())
+	}
+	if len(probe.restarted) != 1 || probe.restarted[0] != "tand-TAND-001" {
+		t.Fatalf("expected restart, got %v", probe.restarted)
+	}
+	if len(payload.Sessions) != 1 || payload.Sessions[0].Restarts != 1 {
+		t.Fatalf("unexpected sessions %+v", payload.Sessions)
+	}
+	if len(payload.Incidents) != 2 || payload.Incidents[0].Kind != supervisor.IncidentRestarted {
+		t.Fatalf("unexpected incidents %+v", payload.Incidents)
+	}
+}
@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 
+	"github.com/mistakeknot/autarch/internal/coldwine/config"
 	"github.com/mistakeknot/autarch/internal/coldwine/git"
 	"github.com/mistakeknot/autarch/internal/coldwine/project"
 	"github.com/mistakeknot/autarch/internal/coldwine/storage"
@@ -10,9 +11,19 @@ import (
 )
 
 func ApproveCmd() *cobra.Command {
+	var direct bool
 	cmd := &cobra.Command{
 		Use:   "approve",
-		Short: "Approve a task by merging its branch and marking done",
+		Short: "Approve a task by queueing its branch for merge",
+		Long: `Approve a task and hand its branch to the merge queue. "coldwine merge run"
+rebases, tests and lands queued branches one at a time. "coldwine run" starts
+the task's dependents only once its branch has landed.
+
+With merge.queue = false in config, or --direct, the branch is merged
+immediately and the task marked done.
+
+Tasks with acceptance criteria are approved only after every criterion
+passed "coldwine acceptance verify" at the branch's current commit.`,
 		Args: wrapArgs("approve", func(cmd *cobra.Command, args []string) error {
 			if err := cobra.ExactArgs(2)(cmd, args); err != nil {
 				return err
@@ -28,13 +39,33 @@ func ApproveCmd() *cobra.Command {
 			taskID := args[0]
 			branch := args[1]
 
-			if err := git.MergeBranch(&git.ExecRunner{}, branch); err != nil {
+			root, err := project.FindRoot(".")
+			if err != nil {
 				return err
 			}
-			root, err := project.FindRoot(".")
+			cfg, err := config.LoadFromProject(root)
 			if err != nil {
 				return err
 			}
+			if err := checkAcceptance(root, taskID, branch); err != nil {
+				return err
+			}
+			if cfg.Merge.Queue && !direct {
+				db, closeDB, err := openStateDB()
+				if err != nil {
+					return err
+				}
+				defer closeDB()
+				if err := storage.QueueMerge(db, taskID, branch); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Approved %s (queued %s for merge)\n", taskID, branch)
+				return nil
+			}
+
+			if err := git.MergeBranch(&git.ExecRunner{}, branch); err != nil {
+				return err
+			}
 			db, err := storage.Open(project.StateDBPath(root))
 			if err != nil {
 				return err
@@ -47,5 +78,6 @@ func ApproveCmd() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&direct, "direct", false, "Merge immediately instead of queueing")
 	return cmd
 }
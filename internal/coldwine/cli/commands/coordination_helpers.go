@@ -3,6 +3,7 @@ package commands
 import (
 	"database/sql"
 
+	"github.com/mistakeknot/autarch/internal/coldwine/mailbus"
 	"github.com/mistakeknot/autarch/internal/coldwine/project"
 	"github.com/mistakeknot/autarch/internal/coldwine/storage"
 )
@@ -20,5 +21,9 @@ func openStateDB() (*sql.DB, func(), error) {
 		db.Close()
 		return nil, nil, err
 	}
-	return db, func() { _ = db.Close() }, nil
+	mailbus.Attach(db, project.MailBusDir(root))
+	return db, func() {
+		mailbus.Detach(db)
+		_ = db.Close()
+	}, nil
 }
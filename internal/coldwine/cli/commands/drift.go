@@ -0,0 +1,267 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/config"
+	"github.com/mistakeknot/autarch/internal/coldwine/drift"
+	"github.com/mistakeknot/autarch/internal/coldwine/git"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	csignals "github.com/mistakeknot/autarch/internal/coldwine/signals"
+	"github.com/mistakeknot/autarch/internal/coldwine/specs"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/mistakeknot/autarch/pkg/signals"
+	"github.com/spf13/cobra"
+)
+
+type taskDrift struct {
+	TaskID   string          `json:"task_id"`
+	Base     string          `json:"base"`
+	Findings []drift.Finding `json:"findings"`
+}
+
+func DriftCmd() *cobra.Command {
+	var (
+		base    string
+		emit    bool
+		jsonOut bool
+	)
+	cmd := &cobra.Command{
+		Use:   "drift [task-id...]",
+		Short: "Report files tasks changed outside their declared scope",
+		Long: `Compare each task worktree against its merge base with the target branch
+and classify changed files the task did not declare:
+
+  new_file      added file not matched by files_to_modify
+  outside_task  existing file not matched by files_to_modify
+  outside_epic  file no task in the same epic declares
+  reserved      file reserved by another agent
+
+files_to_modify entries may be globs ("internal/store/**", "*.sql") or
+directories. Renames count as in scope when either path is declared.
+With --emit, each finding is published as an execution_drift signal
+carrying the file's diff.`,
+		Args: wrapArgs("drift", func(cmd *cobra.Command, args []string) error {
+			for _, id := range args {
+				if err := project.ValidateTaskID(id); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("drift", err)
+				}
+			}()
+			root, err := project.FindRoot(".")
+			if err != nil {
+				return err
+			}
+			cfg, err := config.LoadFromProject(root)
+			if err != nil {
+				return err
+			}
+			if base == "" {
+				base = cfg.Review.TargetBranch
+			}
+			if base == "" {
+				base = "main"
+			}
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+			active, err := storage.ListActiveReservations(db, 10000)
+			if err != nil {
+				return err
+			}
+			reservations := make([]drift.Reservation, 0, len(active))
+			for _, r := range active {
+				reservations = append(reservations, drift.Reservation{Path: r.Path, Owner: r.Owner})
+			}
+
+			summaries, _ := specs.LoadSummaries(project.SpecsDir(root))
+			ids := args
+			if len(ids) == 0 {
+				for _, s := range summaries {
+					if worktreeExists(root, s.ID) {
+						ids = append(ids, s.ID)
+					}
+				}
+			}
+
+			emitter := csignals.NewEmitter()
+			var results []taskDrift
+			var sigs []signals.Signal
+			for _, id := range ids {
+				summary, ok := specs.FindByID(summaries, id)
+				if !ok {
+					return fmt.Errorf("task spec not found: %s", id)
+				}
+				if !worktreeExists(root, id) {
+					fmt.Fprintf(cmd.ErrOrStderr(), "WARN: %s has no worktree\n", id)
+					continue
+				}
+				worktree, err := project.SafePath(project.WorktreesDir(root), id)
+				if err != nil {
+					return err
+				}
+				scope := drift.Scope{
+					Allowed:      summary.FilesToModify,
+					Epic:         epicScope(summaries, summary.EpicID),
+					Owners:       taskOwners(db, id),
+					Reservations: reservations,
+				}
+				res, err := analyzeWorktree(&git.DirRunner{Dir: worktree}, base, scope)
+				if err != nil {
+					return fmt.Errorf("%s: %w", id, err)
+				}
+				res.TaskID = id
+				results = append(results, res)
+				specID := summary.EpicID
+				if specID == "" {
+					specID = id
+				}
+				for _, f := range res.Findings {
+					if sig := emitter.FileDrift(specID, id, f); sig != nil {
+						sigs = append(sigs, *sig)
+					}
+				}
+			}
+
+			published := 0
+			if emit && len(sigs) > 0 {
+				client := signals.NewClient(signals.DefaultServerURL())
+				ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+				defer cancel()
+				for _, sig := range sigs {
+					if err := client.Publish(ctx, sig); err != nil {
+						fmt.Fprintf(cmd.ErrOrStderr(), "WARN: publish signal: %v\n", err)
+						break
+					}
+					published++
+				}
+			}
+
+			if jsonOut {
+				if results == nil {
+					results = []taskDrift{}
+				}
+				if sigs == nil {
+					sigs = []signals.Signal{}
+				}
+				return writeJSON(cmd, map[string]interface{}{
+					"tasks":     results,
+					"signals":   sigs,
+					"published": published,
+				})
+			}
+			out := cmd.OutOrStdout()
+			total := 0
+			for _, res := range results {
+				for _, f := range res.Findings {
+					line := fmt.Sprintf("%s  %s  %s", res.TaskID, f.Path, strings.Join(f.Kinds, ","))
+					if f.OldPath != "" {
+						line += " (renamed from " + f.OldPath + ")"
+					}
+					if len(f.Holders) > 0 {
+						line += " held by " + strings.Join(f.Holders, ", ")
+					}
+					fmt.Fprintln(out, line)
+					total++
+				}
+			}
+			if total == 0 {
+				fmt.Fprintln(out, "No drift.")
+			}
+			if emit {
+				fmt.Fprintf(out, "Published %d execution_drift signal(s)\n", published)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&base, "base", "", "Branch to compare against (default review.target_branch or main)")
+	cmd.Flags().BoolVar(&emit, "emit", false, "Publish execution_drift signals to the signals server")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+// analyzeWorktree diffs the worktree, including uncommitted and untracked
+// files, against its merge base with base and attaches each finding's patch.
+func analyzeWorktree(r git.Runner, base string, scope drift.Scope) (taskDrift, error) {
+	res := taskDrift{Base: base}
+	mergeBase, err := git.MergeBase(r, base, "HEAD")
+	if err != nil {
+		return res, fmt.Errorf("merge-base %s: %w", base, err)
+	}
+	entries, err := git.DiffNameStatus(r, mergeBase)
+	if err != nil {
+		return res, err
+	}
+	changes := make([]drift.Change, 0, len(entries))
+	for _, e := range entries {
+		changes = append(changes, drift.Change{Status: e.Status, Path: e.Path, OldPath: e.OldPath})
+	}
+	untracked, err := git.UntrackedFiles(r)
+	if err != nil {
+		return res, err
+	}
+	for _, path := range untracked {
+		changes = append(changes, drift.Change{Status: "A", Path: path})
+	}
+	res.Findings = drift.Analyze(scope, changes)
+	for i, f := range res.Findings {
+		paths := []string{f.Path}
+		if f.OldPath != "" {
+			paths = append(paths, f.OldPath)
+		}
+		if diff, err := git.DiffPaths(r, mergeBase, paths...); err == nil {
+			res.Findings[i].Diff = diff
+		}
+	}
+	if res.Findings == nil {
+		res.Findings = []drift.Finding{}
+	}
+	return res, nil
+}
+
+// epicScope is every files_to_modify pattern declared by tasks in the epic.
+func epicScope(list []specs.SpecSummary, epicID string) []string {
+	if epicID == "" {
+		return nil
+	}
+	var out []string
+	for _, s := range list {
+		if s.EpicID == epicID {
+			out = append(out, s.FilesToModify...)
+		}
+	}
+	return out
+}
+
+// taskOwners lists the names a task's agent may hold reservations under.
+func taskOwners(db *sql.DB, taskID string) []string {
+	owners := []string{agent.SessionID(taskID)}
+	if s, err := storage.GetAgentSessionByTask(db, taskID); err == nil && s.AgentName != "" {
+		owners = append(owners, s.AgentName)
+	}
+	return owners
+}
+
+func worktreeExists(root, taskID string) bool {
+	path, err := project.SafePath(project.WorktreesDir(root), taskID)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+func gitIn(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDriftCmdClassifiesWorktreeChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	gitIn(t, root, "init", "-b", "main")
+	gitIn(t, root, "config", "user.email", "test@example.com")
+	gitIn(t, root, "config", "user.name", "Test User")
+	writeFiles(t, root, map[string]string{
+		".gitignore":               ".tandemonium/\n",
+		"internal/store/db.go":     "package store\n\nfunc Open() {}\n",
+		"internal/api/handler.go":  "package api\n",
+		"internal/store/legacy.go": "package store\n\n// legacy helpers kept for the v1 schema\nfunc Legacy() {}\n",
+	})
+	gitIn(t, root, "add", ".")
+	gitIn(t, root, "commit", "-m", "init")
+	if err := project.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, project.SpecsDir(root), map[string]string{
+		"TAND-001.yaml": "id: TAND-001\ntitle: Store\nstatus: in_progress\nepic_id: EPIC-001\nfiles_to_modify:\n  - internal/store/**\n",
+		"TAND-002.yaml": "id: TAND-002\ntitle: API\nstatus: todo\nepic_id: EPIC-001\nfiles_to_modify:\n  - internal/api/\n",
+	})
+	worktree := filepath.Join(project.WorktreesDir(root), "TAND-001")
+	gitIn(t, root, "worktree", "add", "-b", "feature/TAND-001", worktree)
+	if err := os.MkdirAll(filepath.Join(worktree, "internal/store/v1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, worktree, "mv", "internal/store/legacy.go", "internal/store/v1/legacy.go")
+	writeFiles(t, worktree, map[string]string{
+		"internal/store/db.go":    "package store\n\nfunc Open() error { return nil }\n",
+		"internal/api/handler.go": "package api\n\nfunc Handle() {}\n",
+		"scripts/migrate.sh":      "#!/bin/sh\n",
+	})
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	db, closeDB, err := openStateDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.ReservePaths(db, "tand-TAND-002", []string{"internal/api/"}, true, "api work", 0); err != nil {
+		t.Fatal(err)
+	}
+	closeDB()
+
+	cmd := DriftCmd()
+	out := bytes.NewBuffer(nil)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Tasks []struct {
+			TaskID   string `json:"task_id"`
+			Findings []struct {
+				Path    string   `json:"path"`
+				Kinds   []string `json:"kinds"`
+				Holders []string `json:"holders"`
+				Diff    string   `json:"diff"`
+			} `json:"findings"`
+		} `json:"tasks"`
+		Signals []struct {
+			Type     string `json:"type"`
+			Severity string `json:"severity"`
+		} `json:"signals"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode: %v\n%s", err, out.String())
+	}
+	if len(payload.Tasks) != 1 || payload.Tasks[0].TaskID != "TAND-001" {
+		t.Fatalf("unexpected tasks %+v", payload.Tasks)
+	}
+	findings := payload.Tasks[0].Findings
+	if len(findings) != 2 {
+		t.Fatalf("expected api and script findings, got %+v", findings)
+	}
+	api, script := findings[0], findings[1]
+	if api.Path != "internal/api/handler.go" || len(api.Holders) != 1 || api.Holders[0] != "tand-TAND-002" {
+		t.Fatalf("unexpected api finding %+v", api)
+	}
+	if api.Diff == "" {
+		t.Fatal("expected diff on finding")
+	}
+	if script.Path != "scripts/migrate.sh" || len(script.Kinds) != 2 || script.Kinds[1] != "outside_epic" {
+		t.Fatalf("unexpected script finding %+v", script)
+	}
+	if len(payload.Signals) != 2 || payload.Signals[0].Type != "execution_drift" || payload.Signals[0].Severity != "critical" {
+		t.Fatalf("unexpected signals %+v", payload.Signals)
+	}
+}
@@ -37,6 +37,7 @@ func ExecuteCmd() *cobra.Command {
 			}
 			m := tui.NewModelWithDB(db)
 			m.ConfirmApprove = cfg.TUI.ConfirmApprove
+			m.QueueMerges = cfg.Merge.Queue
 			p := tea.NewProgram(m)
 			_, err = p.Run()
 			return err
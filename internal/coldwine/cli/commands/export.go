@@ -2,17 +2,63 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"time"
 
+	"github.com/mistakeknot/autarch/internal/coldwine/archive"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
 	"github.com/spf13/cobra"
 )
 
 func ExportCmd() *cobra.Command {
-	return &cobra.Command{
+	var output string
+	cmd := &cobra.Command{
 		Use:   "export",
-		Short: "Export state to JSON",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Fprintln(cmd.OutOrStdout(), "Export not implemented")
+		Short: "Export project state to a portable archive",
+		Long: `Write the whole Coldwine state to a zstd-compressed tar: every state
+table, specs and plans, session and merge logs, config and attachments,
+with a manifest of checksums. Worktrees are left out; push their branches
+with git. Restore with "coldwine import".`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("export", err)
+				}
+			}()
+			root, err := project.FindRoot(".")
+			if err != nil {
+				return err
+			}
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			now := time.Now()
+			if output == "" {
+				output = fmt.Sprintf("coldwine-%s.tar.zst", now.UTC().Format("20060102-150405"))
+			}
+			if output == "-" {
+				_, err = archive.Export(cmd.OutOrStdout(), db, root, now)
+				return err
+			}
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			m, err := archive.Export(f, db, root, now)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				_ = os.Remove(output)
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Exported %d rows from %d tables and %d files to %s\n", m.Rows(), len(m.Tables), len(m.Files), output)
 			return nil
 		},
 	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Archive path, or - for stdout (default coldwine-<time>.tar.zst)")
+	return cmd
 }
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+func TestExportImportMovesProject(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	for _, dir := range []string{src, dst} {
+		if err := project.Init(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := storage.Open(project.StateDBPath(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.InsertTask(db, storage.Task{ID: "TAND-001", Title: "Export", Status: "todo"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+	if err := os.WriteFile(filepath.Join(project.SpecsDir(src), "TAND-001.yaml"), []byte("id: TAND-001\ntitle: Export\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+
+	archivePath := filepath.Join(t.TempDir(), "state.tar.zst")
+	if err := os.Chdir(src); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runCommand(t, ExportCmd(), "--output", archivePath)
+	if err != nil || !strings.Contains(out, "1 files to "+archivePath) {
+		t.Fatalf("export failed: %v\n%s", err, out)
+	}
+
+	if err := os.Chdir(dst); err != nil {
+		t.Fatal(err)
+	}
+	out, err = runCommand(t, ImportCmd(), archivePath, "--dry-run")
+	if err != nil || !strings.Contains(out, "Would import 1 rows and 1 files from "+src) {
+		t.Fatalf("dry run failed: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(project.SpecsDir(dst), "TAND-001.yaml")); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote the spec: %v", err)
+	}
+	out, err = runCommand(t, ImportCmd(), archivePath)
+	if err != nil || !strings.Contains(out, "Imported 1 rows and 1 files") {
+		t.Fatalf("import failed: %v\n%s", err, out)
+	}
+	out, err = runCommand(t, ImportCmd(), archivePath)
+	if err != nil || !strings.Contains(out, "renamed task TAND-001 -> TAND-002") {
+		t.Fatalf("second import should rename the clashing task: %v\n%s", err, out)
+	}
+}
@@ -2,18 +2,98 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"sort"
 
+	"github.com/mistakeknot/autarch/internal/coldwine/archive"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
 	"github.com/spf13/cobra"
 )
 
 func ImportCmd() *cobra.Command {
-	return &cobra.Command{
+	var (
+		dryRun  bool
+		jsonOut bool
+	)
+	cmd := &cobra.Command{
 		Use:   "import <file>",
-		Short: "Import state from JSON",
-		Args:  wrapArgs("import", cobra.ExactArgs(1)),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Fprintln(cmd.OutOrStdout(), "Import not implemented")
+		Short: "Import state from an archive made by coldwine export",
+		Long: `Check an archive's integrity and add its state to this project. IDs
+that clash with the project's are renamed, with every reference, log and
+spec following; existing files are never overwritten. Use --dry-run to see
+what would change.`,
+		Args: wrapArgs("import", cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("import", err)
+				}
+			}()
+			root, err := project.FindRoot(".")
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			a, err := archive.Read(f)
+			if err != nil {
+				return err
+			}
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+			res, err := a.Import(db, root, archive.Options{DryRun: dryRun})
+			if err != nil {
+				return err
+			}
+			if jsonOut {
+				return writeJSON(cmd, res)
+			}
+			writeImportResult(cmd, a.Manifest, res)
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Check the archive and report the import without changing anything")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+func writeImportResult(cmd *cobra.Command, m archive.Manifest, res archive.Result) {
+	out := cmd.OutOrStdout()
+	verb := "Imported"
+	if res.DryRun {
+		verb = "Would import"
+	}
+	fmt.Fprintf(out, "%s %d rows and %d files from %s (exported %s)\n", verb, res.Rows(), len(res.FilesWritten), m.SourceRoot, m.CreatedAt.Format("2006-01-02 15:04"))
+	for _, t := range res.Tables {
+		if t.Skipped > 0 {
+			fmt.Fprintf(out, "  %s: %d skipped, already present\n", t.Name, t.Skipped)
+		}
+	}
+	kinds := make([]string, 0, len(res.Remapped))
+	for kind := range res.Remapped {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		ids := make([]string, 0, len(res.Remapped[kind]))
+		for id := range res.Remapped[kind] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Fprintf(out, "  renamed %s %s -> %s\n", kind, id, res.Remapped[kind][id])
+		}
+	}
+	for _, p := range res.FilesKept {
+		fmt.Fprintf(out, "  kept existing %s\n", p)
+	}
+	for _, t := range res.UnknownTables {
+		fmt.Fprintf(out, "  ignored unknown table %s\n", t)
+	}
 }
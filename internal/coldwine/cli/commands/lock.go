@@ -14,7 +14,7 @@ func LockCmd() *cobra.Command {
 		Use:   "lock",
 		Short: "Manage file reservations",
 	}
-	cmd.AddCommand(lockReserveCmd(), lockReleaseCmd(), lockRenewCmd(), lockForceReleaseCmd())
+	cmd.AddCommand(lockReserveCmd(), lockReleaseCmd(), lockRenewCmd(), lockForceReleaseCmd(), lockInstallHooksCmd(), lockCheckCmd())
 	return cmd
 }
 
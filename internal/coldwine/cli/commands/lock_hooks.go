@@ -0,0 +1,314 @@
+package commands
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/config"
+	"github.com/mistakeknot/autarch/internal/coldwine/git"
+	"github.com/mistakeknot/autarch/internal/coldwine/lockhooks"
+	"github.com/mistakeknot/autarch/internal/coldwine/mailbus"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/spf13/cobra"
+)
+
+// lockOverrideThread is the message thread overrides are logged on.
+const lockOverrideThread = "lock-overrides"
+
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+func lockInstallHooksCmd() *cobra.Command {
+	var binary string
+	var jsonOut bool
+	cmd := &cobra.Command{
+		Use:   "install-hooks",
+		Short: "Install reservation-checking git hooks in each worktree",
+		Long: `Install pre-commit and pre-push hooks in the project and every task
+worktree. The hooks run ` + "`coldwine lock check`" + `, which compares staged or pushed
+paths against exclusive reservations held by other owners.
+
+lock.hook_policy decides what happens on a conflict:
+
+  block  reject the commit or push (default)
+  warn   print the conflict and let it through
+
+Set COLDWINE_LOCK_OVERRIDE="reason" to commit over a block. Overrides and
+warned conflicts are mailed to the holders on the lock-overrides thread.
+Existing hooks are kept as <hook>.local and run first.`,
+		Args: wrapArgs("lock install-hooks", cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("lock install-hooks", err)
+				}
+			}()
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			root, err := project.FindRoot(cwd)
+			if err != nil {
+				return err
+			}
+			if binary == "" {
+				binary = "coldwine"
+				if exe, err := os.Executable(); err == nil {
+					binary = exe
+				}
+			}
+			trees := []string{root}
+			if entries, err := os.ReadDir(project.WorktreesDir(root)); err == nil {
+				for _, e := range entries {
+					if e.IsDir() {
+						trees = append(trees, filepath.Join(project.WorktreesDir(root), e.Name()))
+					}
+				}
+			}
+			// Linked worktrees share the main repository's hooks directory.
+			seen := map[string]bool{}
+			installed := []string{}
+			for _, tree := range trees {
+				dir, err := git.HooksDir(&git.DirRunner{Dir: tree})
+				if err != nil {
+					return fmt.Errorf("%s: %w", tree, err)
+				}
+				if seen[dir] {
+					continue
+				}
+				seen[dir] = true
+				paths, err := lockhooks.Install(dir, binary)
+				if err != nil {
+					return err
+				}
+				installed = append(installed, paths...)
+			}
+			if jsonOut {
+				return writeJSON(cmd, map[string]interface{}{"installed": installed})
+			}
+			for _, p := range installed {
+				fmt.Fprintf(cmd.OutOrStdout(), "installed %s\n", p)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&binary, "binary", "", "Coldwine executable the hooks call (default: this binary)")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+func lockCheckCmd() *cobra.Command {
+	var (
+		hook    string
+		owners  []string
+		policy  string
+		base    string
+		jsonOut bool
+	)
+	cmd := &cobra.Command{
+		Use:   "check [path...]",
+		Short: "Check paths against other owners' reservations",
+		Long: `Check paths against exclusive reservations held by other owners. With
+--hook pre-commit the staged paths are checked; with --hook pre-push the
+refs git passes on stdin are. Otherwise the paths given are checked.
+
+The owner is the task whose worktree this is, plus --owner and
+$COLDWINE_AGENT; outside a task worktree it defaults to $USER.`,
+		Args: wrapArgs("lock check", cobra.ArbitraryArgs),
+		// Runs inside git hooks, where a usage dump would bury the conflict.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("lock check", err)
+				}
+			}()
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			root, err := project.FindRoot(cwd)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.LoadFromProject(root)
+			if err != nil {
+				return err
+			}
+			if policy == "" {
+				policy = cfg.Lock.HookPolicy
+			}
+			mode, err := lockhooks.ParsePolicy(policy)
+			if err != nil {
+				return err
+			}
+			if base == "" {
+				base = cfg.Review.TargetBranch
+			}
+			if base == "" {
+				base = "main"
+			}
+			db, err := storage.Open(project.StateDBPath(root))
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			if err := storage.Migrate(db); err != nil {
+				return err
+			}
+			mailbus.Attach(db, project.MailBusDir(root))
+			defer mailbus.Detach(db)
+
+			r := &git.DirRunner{Dir: cwd}
+			var paths []string
+			switch hook {
+			case "":
+				paths = args
+			case "pre-commit":
+				paths, err = git.StagedFiles(r)
+			case "pre-push":
+				paths, err = pushedFiles(r, cmd.InOrStdin(), base)
+			default:
+				return fmt.Errorf("unknown hook %q", hook)
+			}
+			if err != nil {
+				return err
+			}
+
+			self := append([]string{}, owners...)
+			if agent := strings.TrimSpace(os.Getenv("COLDWINE_AGENT")); agent != "" {
+				self = append(self, agent)
+			}
+			if top, err := git.TopLevel(r); err == nil && filepath.Dir(top) == project.WorktreesDir(root) {
+				self = append(self, taskOwners(db, filepath.Base(top))...)
+			}
+			if len(self) == 0 {
+				if user := strings.TrimSpace(os.Getenv("USER")); user != "" {
+					self = append(self, user)
+				}
+			}
+
+			active, err := storage.ListActiveReservations(db, 10000)
+			if err != nil {
+				return err
+			}
+			violations := lockhooks.Check(active, self, paths)
+			override := strings.TrimSpace(os.Getenv("COLDWINE_LOCK_OVERRIDE"))
+			blocked := len(violations) > 0 && mode == lockhooks.PolicyBlock && override == ""
+			if len(violations) > 0 && !blocked {
+				if err := logLockOverride(db, self, hook, mode, override, violations); err != nil {
+					return err
+				}
+			}
+			if jsonOut {
+				if violations == nil {
+					violations = []lockhooks.Violation{}
+				}
+				payload := map[string]interface{}{
+					"policy":     mode,
+					"owners":     self,
+					"violations": violations,
+					"blocked":    blocked,
+					"override":   override,
+				}
+				if err := writeJSON(cmd, payload); err != nil {
+					return err
+				}
+			} else {
+				out := cmd.ErrOrStderr()
+				for _, v := range violations {
+					fmt.Fprintf(out, "reserved %s holder=%s reservation=%s expires=%s\n", v.Path, v.Holder, v.Reservation, v.ExpiresAt)
+				}
+				if len(violations) > 0 && !blocked {
+					fmt.Fprintf(out, "proceeding over %d reserved path(s); holders notified\n", len(violations))
+				}
+			}
+			if blocked {
+				return fmt.Errorf("%d path(s) reserved by %s; set COLDWINE_LOCK_OVERRIDE=\"reason\" to override", len(violations), strings.Join(lockhooks.Holders(violations), ", "))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&hook, "hook", "", "Git hook being run (pre-commit or pre-push)")
+	cmd.Flags().StringSliceVar(&owners, "owner", nil, "Owner whose own reservations are allowed")
+	cmd.Flags().StringVar(&policy, "policy", "", "Conflict policy: block or warn (default lock.hook_policy)")
+	cmd.Flags().StringVar(&base, "base", "", "Branch new pushes are compared against (default review.target_branch or main)")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+// pushedFiles reads pre-push ref lines ("<local ref> <local sha> <remote ref>
+// <remote sha>") and lists the paths each pushed range changes. A new remote
+// branch is compared against its merge base with base.
+func pushedFiles(r git.Runner, in io.Reader, base string) ([]string, error) {
+	seen := map[string]bool{}
+	var paths []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[1] == zeroSHA {
+			continue
+		}
+		local, from := fields[1], fields[3]
+		if from == zeroSHA {
+			mb, err := git.MergeBase(r, local, base)
+			if err != nil {
+				continue
+			}
+			from = mb
+		}
+		changed, err := git.ChangedFiles(r, from, local)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range changed {
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// logLockOverride records a commit or push that went ahead over other
+// owners' reservations by mailing the holders.
+func logLockOverride(db *sql.DB, owners []string, hook, mode, reason string, violations []lockhooks.Violation) error {
+	sender := "unknown"
+	if len(owners) > 0 {
+		sender = owners[0]
+	}
+	action := "commit"
+	if hook == "pre-push" {
+		action = "push"
+	}
+	var body strings.Builder
+	if reason != "" {
+		fmt.Fprintf(&body, "%s overrode reservations for a %s: %s\n\n", sender, action, reason)
+	} else {
+		fmt.Fprintf(&body, "%s made a %s over reservations (policy %s)\n\n", sender, action, mode)
+	}
+	for _, v := range violations {
+		fmt.Fprintf(&body, "- %s (reserved %s by %s)\n", v.Path, v.Reservation, v.Holder)
+	}
+	msg := storage.Message{
+		ID:         fmt.Sprintf("msg-%d", time.Now().UTC().UnixNano()),
+		ThreadID:   lockOverrideThread,
+		Sender:     sender,
+		Subject:    fmt.Sprintf("Reservation override: %d path(s)", len(violations)),
+		Body:       body.String(),
+		Importance: "high",
+	}
+	return storage.SendMessage(db, msg, lockhooks.Holders(violations))
+}
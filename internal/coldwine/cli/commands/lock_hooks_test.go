@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/lockhooks"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+func TestLockHooksBlockOtherOwnersReservations(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	gitIn(t, root, "init", "-b", "main")
+	gitIn(t, root, "config", "user.email", "test@example.com")
+	gitIn(t, root, "config", "user.name", "Test User")
+	writeFiles(t, root, map[string]string{
+		".gitignore":           ".tandemonium/\n",
+		"internal/store/db.go": "package store\n",
+		"README.md":            "hello\n",
+	})
+	gitIn(t, root, "add", ".")
+	gitIn(t, root, "commit", "-m", "init")
+	if err := project.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Join(project.WorktreesDir(root), "TAND-001")
+	gitIn(t, root, "worktree", "add", "-b", "feature/TAND-001", worktree)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	install := LockCmd()
+	var out bytes.Buffer
+	install.SetOut(&out)
+	install.SetArgs([]string{"install-hooks", "--binary", "/usr/local/bin/coldwine"})
+	if err := install.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	// The worktree shares the main repository's hooks, so each hook is written once.
+	if got := strings.Count(out.String(), "installed "); got != 2 {
+		t.Fatalf("expected 2 hooks installed, got:\n%s", out.String())
+	}
+	if !lockhooks.Installed(filepath.Join(root, ".git", "hooks", "pre-commit")) {
+		t.Fatal("pre-commit hook not installed")
+	}
+
+	db, closeDB, err := openStateDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB()
+	if _, err := storage.ReservePaths(db, "tand-TAND-002", []string{"internal/store/**"}, true, "schema work", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.ReservePaths(db, "tand-TAND-001", []string{"README.md"}, true, "docs", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, worktree, map[string]string{
+		"internal/store/db.go": "package store\n\nfunc Open() {}\n",
+		"README.md":            "hello again\n",
+	})
+	gitIn(t, worktree, "add", ".")
+	if err := os.Chdir(worktree); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COLDWINE_AGENT", "")
+	t.Setenv("COLDWINE_LOCK_OVERRIDE", "")
+
+	check := LockCmd()
+	var stderr bytes.Buffer
+	check.SetOut(&bytes.Buffer{})
+	check.SetErr(&stderr)
+	check.SetArgs([]string{"check", "--hook", "pre-commit"})
+	err = check.Execute()
+	if err == nil || !strings.Contains(err.Error(), "reserved by tand-TAND-002") {
+		t.Fatalf("expected block, got %v", err)
+	}
+	if strings.Contains(stderr.String(), "README.md") {
+		t.Fatalf("own reservation reported as a violation:\n%s", stderr.String())
+	}
+
+	t.Setenv("COLDWINE_LOCK_OVERRIDE", "hotfix for broken build")
+	override := LockCmd()
+	override.SetOut(&bytes.Buffer{})
+	override.SetErr(&bytes.Buffer{})
+	override.SetArgs([]string{"check", "--hook", "pre-commit"})
+	if err := override.Execute(); err != nil {
+		t.Fatalf("override should pass: %v", err)
+	}
+	msgs, err := storage.ListThreadMessages(db, lockOverrideThread, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Body, "hotfix for broken build") || !strings.Contains(msgs[0].Body, "internal/store/db.go") {
+		t.Fatalf("expected override logged, got %+v", msgs)
+	}
+	inbox, err := storage.FetchInbox(db, "tand-TAND-002", 10)
+	if err != nil || len(inbox) != 1 {
+		t.Fatalf("expected holder notified, got %+v %v", inbox, err)
+	}
+}
+
+func TestLockCheckWarnPolicyLetsPushThrough(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	gitIn(t, root, "init", "-b", "main")
+	gitIn(t, root, "config", "user.email", "test@example.com")
+	gitIn(t, root, "config", "user.name", "Test User")
+	writeFiles(t, root, map[string]string{".gitignore": ".tandemonium/\n", "api.go": "package api\n"})
+	gitIn(t, root, "add", ".")
+	gitIn(t, root, "commit", "-m", "init")
+	if err := project.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, filepath.Join(root, ".tandemonium"), map[string]string{"config.toml": "[lock]\nhook_policy = \"warn\"\n"})
+	gitIn(t, root, "checkout", "-b", "feature")
+	writeFiles(t, root, map[string]string{"api.go": "package api\n\nfunc Handle() {}\n"})
+	gitIn(t, root, "commit", "-am", "handle")
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	db, closeDB, err := openStateDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB()
+	if _, err := storage.ReservePaths(db, "bob", []string{"api.go"}, true, "", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	head, err := exec.Command("git", "-C", root, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COLDWINE_AGENT", "")
+	t.Setenv("COLDWINE_LOCK_OVERRIDE", "")
+	t.Setenv("USER", "alice")
+
+	cmd := LockCmd()
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetIn(strings.NewReader("refs/heads/feature " + strings.TrimSpace(string(head)) + " refs/heads/feature " + zeroSHA + "\n"))
+	cmd.SetArgs([]string{"check", "--hook", "pre-push"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("warn policy should not block: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "reserved api.go holder=bob") {
+		t.Fatalf("expected warning, got:\n%s", stderr.String())
+	}
+	inbox, err := storage.FetchInbox(db, "bob", 10)
+	if err != nil || len(inbox) != 1 || inbox[0].Message.Sender != "alice" {
+		t.Fatalf("expected bob notified by alice, got %+v %v", inbox, err)
+	}
+}
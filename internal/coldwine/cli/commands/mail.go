@@ -32,6 +32,8 @@ func MailCmd() *cobra.Command {
 		mailSummarizeCmd(),
 		mailPolicyCmd(),
 		mailContactCmd(),
+		mailWatchCmd(),
+		mailReceiptsCmd(),
 	)
 	return cmd
 }
@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/mailbus"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/mistakeknot/autarch/internal/coldwine/tmux"
+	"github.com/spf13/cobra"
+)
+
+func mailWatchCmd() *cobra.Command {
+	var (
+		recipient    string
+		inject       bool
+		remind       time.Duration
+		maxReminders int
+		interval     time.Duration
+		jsonOut      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Stream coordination messages as they are sent",
+		Long: `Stream mail events live: new messages, delivery receipts and acks.
+
+With --recipient only that recipient's messages (and receipts for messages
+it sent) are shown, and each message shown is recorded as delivered.
+
+With --inject, urgent and ack-required messages are typed into the
+recipient's tmux session (a session named after the recipient, or the
+session of a task the agent owns). Notices are only typed while the pane is
+running an agent (claude, codex or gemini); a pane at a shell prompt is
+skipped until the agent is back. Ack-required messages are repeated every
+--remind until acknowledged, at most --max-reminders times.
+
+Examples:
+  coldwine mail watch --recipient tand-TAND-001
+  coldwine mail watch --inject --json`,
+		Args: wrapArgs("mail watch", cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapMailError("watch", err)
+				}
+			}()
+			root, err := project.FindRoot(".")
+			if err != nil {
+				return err
+			}
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+			if inject {
+				// Agent sessions are used to find a recipient's pane.
+				if err := storage.MigrateV2(db); err != nil {
+					return err
+				}
+			}
+			listener, err := mailbus.Listen(project.MailBusDir(root))
+			if err != nil {
+				return err
+			}
+			defer listener.Close()
+
+			base := cmd.Context()
+			if base == nil {
+				base = context.Background()
+			}
+			ctx, stop := signal.NotifyContext(base, os.Interrupt)
+			defer stop()
+
+			w := &mailWatchWriter{out: cmd.OutOrStdout(), json: jsonOut}
+			var injector *mailbus.Injector
+			if inject {
+				injector = &mailbus.Injector{DB: db, Runner: &tmux.ExecRunner{}, Remind: remind, MaxReminders: maxReminders}
+			}
+			sweep := func() error {
+				if injector == nil {
+					return nil
+				}
+				done, err := injector.Sweep()
+				for _, inj := range done {
+					w.injection(inj)
+				}
+				return err
+			}
+			if err := sweep(); err != nil {
+				return err
+			}
+			var tick <-chan time.Time
+			if inject && interval > 0 {
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				tick = ticker.C
+			}
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-tick:
+					if err := sweep(); err != nil {
+						fmt.Fprintln(cmd.ErrOrStderr(), err)
+					}
+				case ev, ok := <-listener.Events:
+					if !ok {
+						return nil
+					}
+					if !watchShows(db, recipient, ev) {
+						continue
+					}
+					w.event(ev)
+					if ev.Kind == storage.MailEventMessage {
+						if recipient != "" {
+							if _, err := storage.MarkMessageDelivered(db, ev.Message.ID, recipient, ""); err != nil {
+								return err
+							}
+						}
+						if err := sweep(); err != nil {
+							fmt.Fprintln(cmd.ErrOrStderr(), err)
+						}
+					}
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVar(&recipient, "recipient", "", "Only show this recipient's mail and record deliveries to it")
+	cmd.Flags().BoolVar(&inject, "inject", false, "Type urgent and ack-required messages into recipients' tmux sessions")
+	cmd.Flags().DurationVar(&remind, "remind", 5*time.Minute, "Interval between reminders for unacknowledged messages")
+	cmd.Flags().IntVar(&maxReminders, "max-reminders", 3, "Reminders per unacknowledged message")
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "How often to check for due reminders with --inject")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print one JSON event per line")
+	return cmd
+}
+
+// watchShows reports whether a watcher filtered to recipient should see ev:
+// messages addressed to it, and receipts for messages it sent.
+func watchShows(db *sql.DB, recipient string, ev storage.MailEvent) bool {
+	if recipient == "" {
+		return true
+	}
+	if ev.Kind == storage.MailEventMessage {
+		for _, r := range ev.Recipients {
+			if r == recipient {
+				return true
+			}
+		}
+		return false
+	}
+	msg, err := storage.GetMessageByID(db, ev.Message.ID)
+	return err == nil && msg.Sender == recipient
+}
+
+// watchEvent is the JSON form of a mail event, keyed like `mail inbox --json`.
+type watchEvent struct {
+	Kind        string   `json:"kind"`
+	ID          string   `json:"id"`
+	ThreadID    string   `json:"thread_id,omitempty"`
+	Sender      string   `json:"sender,omitempty"`
+	Subject     string   `json:"subject,omitempty"`
+	Body        string   `json:"body,omitempty"`
+	CreatedAt   string   `json:"created_ts,omitempty"`
+	Importance  string   `json:"importance,omitempty"`
+	AckRequired bool     `json:"ack_required,omitempty"`
+	Recipients  []string `json:"recipients"`
+	At          string   `json:"at,omitempty"`
+}
+
+type mailWatchWriter struct {
+	out  io.Writer
+	json bool
+}
+
+func (w *mailWatchWriter) event(ev storage.MailEvent) {
+	if w.json {
+		_ = json.NewEncoder(w.out).Encode(watchEvent{
+			Kind:        ev.Kind,
+			ID:          ev.Message.ID,
+			ThreadID:    ev.Message.ThreadID,
+			Sender:      ev.Message.Sender,
+			Subject:     ev.Message.Subject,
+			Body:        ev.Message.Body,
+			CreatedAt:   ev.Message.CreatedAt,
+			Importance:  ev.Message.Importance,
+			AckRequired: ev.Message.AckRequired,
+			Recipients:  ev.Recipients,
+			At:          ev.At,
+		})
+		return
+	}
+	switch ev.Kind {
+	case storage.MailEventMessage:
+		m := ev.Message
+		fmt.Fprintf(w.out, "%s %-6s %s %s -> %s: %s\n", m.CreatedAt, m.Importance, m.ID, m.Sender, strings.Join(ev.Recipients, ","), m.Subject)
+	default:
+		fmt.Fprintf(w.out, "%s %-6s %s %s\n", ev.At, ev.Kind, ev.Message.ID, strings.Join(ev.Recipients, ","))
+	}
+}
+
+func (w *mailWatchWriter) injection(inj mailbus.Injection) {
+	if w.json {
+		_ = json.NewEncoder(w.out).Encode(struct {
+			Kind string `json:"kind"`
+			mailbus.Injection
+		}{Kind: "injected", Injection: inj})
+		return
+	}
+	fmt.Fprintf(w.out, "%s injected %s into %s (reminder %d)\n", time.Now().UTC().Format(time.RFC3339Nano), inj.MessageID, inj.Session, inj.Reminder)
+}
+
+func mailReceiptsCmd() *cobra.Command {
+	var messageID string
+	var jsonOut bool
+	cmd := &cobra.Command{
+		Use:   "receipts",
+		Short: "Show per-recipient delivery, read and ack receipts for a message",
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapMailError("receipts", err)
+				}
+			}()
+			if strings.TrimSpace(messageID) == "" {
+				return fmt.Errorf("message id required")
+			}
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+			receipts, err := storage.ListDeliveryReceipts(db, messageID)
+			if err != nil {
+				return err
+			}
+			if len(receipts) == 0 {
+				return fmt.Errorf("message not found")
+			}
+			if jsonOut {
+				payload := map[string]interface{}{
+					"id":       messageID,
+					"receipts": receipts,
+				}
+				return writeJSON(cmd, payload)
+			}
+			for _, r := range receipts {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\tdelivered=%s\tread=%s\tack=%s\tinjected=%d\n", r.Recipient, dash(r.DeliveredAt), dash(r.ReadAt), dash(r.AckAt), r.InjectCount)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&messageID, "id", "", "Message id")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
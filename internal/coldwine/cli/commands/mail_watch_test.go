@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMailWatchStreamsMessagesAndReceipts(t *testing.T) {
+	// Keep the socket path short enough for AF_UNIX.
+	dir, err := os.MkdirTemp("", "cw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := project.Init(dir); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watch := MailCmd()
+	out := &syncBuffer{}
+	watch.SetOut(out)
+	watch.SetArgs([]string{"watch", "--recipient", "bob", "--json"})
+	done := make(chan error, 1)
+	go func() { done <- watch.ExecuteContext(ctx) }()
+	waitFor(t, "subscriber socket", func() bool {
+		socks, _ := filepath.Glob(filepath.Join(project.MailBusDir(dir), "*.sock"))
+		return len(socks) == 1
+	})
+
+	for _, args := range [][]string{
+		{"send", "--to", "carol", "--from", "alice", "--subject", "Not for bob", "--body", "x"},
+		{"send", "--id", "msg-1", "--to", "bob", "--from", "alice", "--subject", "Rebase now", "--body", "x", "--importance", "urgent", "--ack"},
+		{"send", "--id", "msg-2", "--to", "dave", "--from", "bob", "--subject", "Review", "--body", "x"},
+		{"ack", "--id", "msg-2", "--recipient", "dave"},
+	} {
+		cmd := MailCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	waitFor(t, "message and ack events", func() bool {
+		got := out.String()
+		return strings.Contains(got, `"kind":"ack"`) && strings.Contains(got, `"id":"msg-1"`)
+	})
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+
+	byKind := map[string]watchEvent{}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	for _, line := range lines {
+		var ev watchEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("bad line %q: %v", line, err)
+		}
+		byKind[ev.Kind] = ev
+	}
+	// Events arrive on separate connections, so their order is not fixed.
+	if len(lines) != 2 {
+		t.Fatalf("expected msg-1 and the ack of bob's message, got %s", out.String())
+	}
+	if ev := byKind[storage.MailEventMessage]; ev.ID != "msg-1" || ev.Importance != "urgent" || !ev.AckRequired {
+		t.Fatalf("unexpected message event %+v", ev)
+	}
+	if ev := byKind[storage.MailEventAck]; ev.ID != "msg-2" || ev.Recipients[0] != "dave" {
+		t.Fatalf("expected ack of bob's message, got %+v", ev)
+	}
+
+	receipts := MailCmd()
+	receiptsOut := &bytes.Buffer{}
+	receipts.SetOut(receiptsOut)
+	receipts.SetArgs([]string{"receipts", "--id", "msg-1", "--json"})
+	if err := receipts.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Receipts []storage.DeliveryReceipt `json:"receipts"`
+	}
+	if err := json.Unmarshal(receiptsOut.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Receipts) != 1 || payload.Receipts[0].DeliveredAt == "" || payload.Receipts[0].AckAt != "" {
+		t.Fatalf("unexpected receipts %+v", payload.Receipts)
+	}
+}
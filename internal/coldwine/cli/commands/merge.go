@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/config"
+	"github.com/mistakeknot/autarch/internal/coldwine/mergequeue"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/spf13/cobra"
+)
+
+func MergeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge",
+		Short: "Process the merge queue of approved tasks",
+		Long: `Approved tasks wait in the merge queue. Each branch is rebased onto the
+latest base branch in its worktree, run through merge.test_command, and
+fast-forwarded into the base only if the tests pass.
+
+Failing tests send the task back to review with the log attached. Rebase
+conflicts are reported per file to the agents holding reservations on them.
+
+Config (config.toml):
+  [merge]
+  queue = true
+  test_command = "go test ./..."
+  test_timeout = 600`,
+	}
+	cmd.AddCommand(mergeRunCmd(), mergeListCmd())
+	return cmd
+}
+
+func mergeRunCmd() *cobra.Command {
+	var (
+		once    bool
+		jsonOut bool
+	)
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Rebase, test and land queued branches one at a time",
+		Args:  wrapArgs("merge run", cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("merge run", err)
+				}
+			}()
+			root, err := project.FindRoot(".")
+			if err != nil {
+				return err
+			}
+			cfg, err := config.LoadFromProject(root)
+			if err != nil {
+				return err
+			}
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			q := &mergequeue.Queue{
+				DB:          db,
+				Root:        root,
+				Base:        cfg.Review.TargetBranch,
+				TestCommand: strings.TrimSpace(cfg.Merge.TestCommand),
+				TestTimeout: time.Duration(cfg.Merge.TestTimeout) * time.Second,
+			}
+			if _, err := q.RequeueInterrupted(); err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			var outcomes []mergequeue.Outcome
+			if once {
+				var outcome *mergequeue.Outcome
+				outcome, err = q.ProcessNext(ctx)
+				if outcome != nil {
+					outcomes = append(outcomes, *outcome)
+				}
+			} else {
+				outcomes, err = q.ProcessAll(ctx)
+			}
+			if err != nil {
+				return err
+			}
+			if jsonOut {
+				if outcomes == nil {
+					outcomes = []mergequeue.Outcome{}
+				}
+				return writeJSON(cmd, outcomes)
+			}
+			out := cmd.OutOrStdout()
+			if len(outcomes) == 0 {
+				fmt.Fprintln(out, "Merge queue is empty.")
+			}
+			for _, o := range outcomes {
+				fmt.Fprintf(out, "%-9s %s (%s): %s\n", o.State, o.TaskID, o.Branch, o.Detail)
+				for _, c := range o.Conflicts {
+					owners := "unreserved"
+					if len(c.Owners) > 0 {
+						owners = strings.Join(c.Owners, ", ")
+					}
+					fmt.Fprintf(out, "  conflict %s (%s)\n", c.Path, owners)
+				}
+				if o.LogPath != "" && o.State != storage.MergeMerged {
+					fmt.Fprintf(out, "  log: %s\n", o.LogPath)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&once, "once", false, "Process a single queued branch")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+func mergeListCmd() *cobra.Command {
+	var jsonOut bool
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List merge queue entries",
+		Args:  wrapArgs("merge list", cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("merge list", err)
+				}
+			}()
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+			entries, err := storage.ListMergeQueue(db)
+			if err != nil {
+				return err
+			}
+			if jsonOut {
+				if entries == nil {
+					entries = []storage.MergeEntry{}
+				}
+				return writeJSON(cmd, entries)
+			}
+			out := cmd.OutOrStdout()
+			if len(entries) == 0 {
+				fmt.Fprintln(out, "Merge queue is empty.")
+			}
+			for _, e := range entries {
+				line := fmt.Sprintf("%-9s %s (%s)", e.State, e.TaskID, e.Branch)
+				if e.Detail != "" {
+					line += ": " + e.Detail
+				}
+				fmt.Fprintln(out, line)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+)
+
+func TestApproveQueuesAndMergeListShowsEntry(t *testing.T) {
+	root := t.TempDir()
+	if err := project.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := ApproveCmd()
+	out := bytes.NewBuffer(nil)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"TAND-001", "feature/TAND-001"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "queued feature/TAND-001 for merge") {
+		t.Fatalf("unexpected approve output: %s", out.String())
+	}
+
+	cmd = MergeCmd()
+	out.Reset()
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"list"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "queued    TAND-001 (feature/TAND-001)") {
+		t.Fatalf("unexpected list output: %s", out.String())
+	}
+}
@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/config"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/scheduler"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/spf13/cobra"
+)
+
+// newRunRuntime is replaced in tests.
+var newRunRuntime = func(root string) scheduler.Runtime {
+	return scheduler.NewTmuxRuntime(root)
+}
+
+func RunCmd() *cobra.Command {
+	var (
+		agentName string
+		limits    []string
+		interval  time.Duration
+		once      bool
+		dryRun    bool
+		retry     bool
+		jsonOut   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run ready tasks in dependency order",
+		Long: `Launch ready tasks into worktrees and tmux sessions, watch for completion,
+and start dependents once their dependencies are approved and merged. A run
+with tasks waiting on a dependency in review keeps polling until it lands.
+
+Tasks declare dependencies and an optional agent target in their spec:
+  dependencies: [TAND-001]
+  agent: codex
+
+Completion is read from session logs: Claude Code stream-json results, Codex
+--json turn events, or an explicit "COLDWINE:STATE done|blocked" line.
+
+Progress is checkpointed in the state DB. Re-running after a crash adopts
+sessions that are still alive instead of launching them again. Failed tasks
+stay failed until --retry clears them.
+
+Token use, cost and time are recorded per task from the same logs. An epic
+with a budget in [budget.epics.<EPIC>] starts no new tasks once its spend
+reaches the budget; running tasks are left to finish.
+
+Examples:
+  coldwine run                          # run until nothing is left to start
+  coldwine run --limit claude=2         # at most two claude sessions at once
+  coldwine run --once                   # one scheduling pass, then exit
+  coldwine run --dry-run                # show what would launch`,
+		Args: wrapArgs("run", cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() {
+				if err != nil {
+					err = wrapCommandError("run", err)
+				}
+			}()
+			root, err := project.FindRoot(".")
+			if err != nil {
+				return err
+			}
+			cfg, err := config.LoadFromProject(root)
+			if err != nil {
+				return err
+			}
+			opts, err := runOptions(cfg, agentName, limits)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("interval") && cfg.Scheduler.PollInterval > 0 {
+				interval = time.Duration(cfg.Scheduler.PollInterval) * time.Second
+			}
+			if err := storage.RebuildFromSpecs(root); err != nil {
+				return err
+			}
+			db, closeDB, err := openStateDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			list, warnings := scheduler.LoadTasks(project.SpecsDir(root))
+			for _, w := range warnings {
+				fmt.Fprintln(cmd.ErrOrStderr(), "WARN:", w)
+			}
+			sched, err := scheduler.New(db, list, newRunRuntime(root), opts)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+
+			if retry {
+				cleared, err := sched.Retry()
+				if err != nil {
+					return err
+				}
+				if !jsonOut && len(cleared) > 0 {
+					fmt.Fprintf(out, "Cleared failed: %s\n", strings.Join(cleared, ", "))
+				}
+			}
+
+			if dryRun {
+				launches, err := sched.Plan()
+				if err != nil {
+					return err
+				}
+				if jsonOut {
+					return writeJSON(cmd, map[string]interface{}{"launches": launches})
+				}
+				if len(launches) == 0 {
+					fmt.Fprintln(out, "Nothing ready to launch.")
+				}
+				for _, l := range launches {
+					fmt.Fprintf(out, "would launch %s on %s (%s)\n", l.TaskID, l.Target, l.Session)
+				}
+				return nil
+			}
+
+			if !jsonOut {
+				sched.OnEvent = func(ev scheduler.Event) {
+					subject := ev.TaskID
+					if subject == "" {
+						subject = ev.Epic
+					}
+					line := fmt.Sprintf("%-10s %s", ev.Kind, subject)
+					if ev.Target != "" {
+						line += " on " + ev.Target
+					}
+					if ev.Detail != "" {
+						line += ": " + ev.Detail
+					}
+					fmt.Fprintln(out, line)
+				}
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			var res scheduler.Result
+			if once {
+				res, err = sched.Step(ctx)
+			} else {
+				res, err = sched.Run(ctx, interval)
+			}
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+			if jsonOut {
+				if err := writeJSON(cmd, res); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(out, "%d running, %d waiting, %d failed\n", res.Active, len(res.Waiting), len(res.Failed))
+				if len(res.Paused) > 0 {
+					fmt.Fprintf(out, "Paused over budget: %s\n", strings.Join(res.Paused, ", "))
+				}
+				if len(res.Landing) > 0 {
+					fmt.Fprintf(out, "Awaiting review or merge: %s\n", strings.Join(res.Landing, ", "))
+				}
+			}
+			if len(res.Failed) > 0 {
+				return fmt.Errorf("%d task(s) failed: %s", len(res.Failed), strings.Join(res.Failed, ", "))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&agentName, "agent", "", "Default agent target for tasks without one (default from config)")
+	cmd.Flags().StringArrayVar(&limits, "limit", nil, "Concurrency limit per target, as target=N (repeatable)")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "Polling interval between passes")
+	cmd.Flags().BoolVar(&once, "once", false, "Run a single scheduling pass and exit")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would launch without starting anything")
+	cmd.Flags().BoolVar(&retry, "retry", false, "Clear failed tasks so they can be launched again")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON output")
+	return cmd
+}
+
+func runOptions(cfg config.Config, agentName string, limits []string) (scheduler.Options, error) {
+	opts := scheduler.Options{
+		DefaultTarget: cfg.Scheduler.DefaultAgent,
+		Limits:        make(map[string]int),
+		DefaultLimit:  cfg.General.MaxAgents,
+		Prices:        accountingPrices(cfg),
+		Models:        cfg.Accounting.Models,
+		IdleGap:       time.Duration(cfg.Accounting.IdleGap) * time.Second,
+		Budgets:       epicBudgets(cfg),
+	}
+	if agentName != "" {
+		opts.DefaultTarget = agentName
+	}
+	for target, n := range cfg.Scheduler.Limits {
+		opts.Limits[target] = n
+	}
+	for _, raw := range limits {
+		target, value, ok := strings.Cut(raw, "=")
+		n, err := strconv.Atoi(value)
+		if !ok || target == "" || err != nil || n < 1 {
+			return opts, fmt.Errorf("invalid --limit %q (use target=N)", raw)
+		}
+		opts.Limits[target] = n
+	}
+	return opts, nil
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/scheduler"
+)
+
+type fakeRunRuntime struct{ alive map[string]bool }
+
+func (f *fakeRunRuntime) Launch(l scheduler.Launch) error {
+	f.alive[l.Session] = true
+	return nil
+}
+
+func (f *fakeRunRuntime) Alive(id string) bool { return f.alive[id] }
+
+func (f *fakeRunRuntime) LogPath(id string) string {
+	return filepath.Join(os.TempDir(), "missing-"+id+".log")
+}
+
+func TestRunCmdLaunchesReadyTasks(t *testing.T) {
+	root := t.TempDir()
+	if err := project.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	specs := map[string]string{
+		"TAND-001.yaml": "id: TAND-001\ntitle: Schema\nstatus: todo\n",
+		"TAND-002.yaml": "id: TAND-002\ntitle: API\nstatus: todo\ndependencies: [TAND-001]\n",
+		"TAND-003.yaml": "id: TAND-003\ntitle: CLI\nstatus: todo\nagent: codex\n",
+	}
+	for name, body := range specs {
+		if err := os.WriteFile(filepath.Join(project.SpecsDir(root), name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	rt := &fakeRunRuntime{alive: map[string]bool{}}
+	prev := newRunRuntime
+	newRunRuntime = func(string) scheduler.Runtime { return rt }
+	defer func() { newRunRuntime = prev }()
+
+	cmd := RunCmd()
+	out := bytes.NewBuffer(nil)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--dry-run"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "would launch TAND-001 on claude") || !strings.Contains(out.String(), "would launch TAND-003 on codex") {
+		t.Fatalf("unexpected dry run output: %s", out.String())
+	}
+	if len(rt.alive) != 0 {
+		t.Fatal("dry run launched sessions")
+	}
+
+	cmd = RunCmd()
+	out.Reset()
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--once", "--limit", "claude=1"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !rt.alive["tand-TAND-001"] || !rt.alive["tand-TAND-003"] || rt.alive["tand-TAND-002"] {
+		t.Fatalf("unexpected sessions %v", rt.alive)
+	}
+	if !strings.Contains(out.String(), "2 running, 1 waiting, 0 failed") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+
+	cmd = RunCmd()
+	cmd.SetOut(bytes.NewBuffer(nil))
+	cmd.SetArgs([]string{"--limit", "claude"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected invalid --limit error")
+	}
+}
@@ -1,10 +1,15 @@
 package commands
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/mistakeknot/autarch/internal/coldwine/accounting"
+	"github.com/mistakeknot/autarch/internal/coldwine/config"
 	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/scheduler"
 	"github.com/mistakeknot/autarch/internal/coldwine/specs"
 	"github.com/mistakeknot/autarch/internal/coldwine/storage"
 	"github.com/mistakeknot/autarch/internal/coldwine/tmux"
@@ -19,6 +24,8 @@ type statusSummary struct {
 	Sessions     []string
 	SpecCount    int
 	SpecWarnings int
+	Usage        *accounting.Report
+	Budgets      []accounting.BudgetStatus
 }
 
 func statusSummaryFromCwd() statusSummary {
@@ -36,6 +43,7 @@ func statusSummaryFromCwd() statusSummary {
 					sum.TaskCounts = counts
 				}
 			}
+			sum.Usage, sum.Budgets = loadUsage(db, root)
 			db.Close()
 		}
 	}
@@ -50,6 +58,21 @@ func statusSummaryFromCwd() statusSummary {
 	return sum
 }
 
+// loadUsage rolls up recorded usage and checks it against epic budgets.
+// Databases from before usage was recorded report none.
+func loadUsage(db *sql.DB, root string) (*accounting.Report, []accounting.BudgetStatus) {
+	list, _ := scheduler.LoadTasks(project.SpecsDir(root))
+	report, err := accounting.Load(db, list, time.Now())
+	if err != nil {
+		return nil, nil
+	}
+	var budgets []accounting.BudgetStatus
+	if cfg, err := config.LoadFromProject(root); err == nil {
+		budgets = accounting.CheckBudgets(report, epicBudgets(cfg))
+	}
+	return &report, budgets
+}
+
 func summariesToCounts(summaries []specs.SpecSummary) map[string]int {
 	counts := make(map[string]int)
 	for _, s := range summaries {
@@ -76,6 +99,16 @@ func formatStatusLines(sum statusSummary) []string {
 	if sum.SpecCount > 0 {
 		lines = append(lines, fmt.Sprintf("specs: %d (warnings: %d)", sum.SpecCount, sum.SpecWarnings))
 	}
+	if sum.Usage != nil && sum.Usage.Total.Tasks > 0 {
+		lines = append(lines, "usage: "+formatTotals(sum.Usage.Total))
+	}
+	for _, b := range sum.Budgets {
+		line := fmt.Sprintf("budget %s: %s", b.EpicID, b)
+		if b.Exceeded {
+			line += " (exceeded, paused)"
+		}
+		lines = append(lines, line)
+	}
 	lines = append(lines, fmt.Sprintf("tmux sessions: %d", len(sum.Sessions)))
 	return lines
 }
@@ -104,6 +137,8 @@ func StatusCmd() *cobra.Command {
 					"sessions":      sum.Sessions,
 					"spec_count":    sum.SpecCount,
 					"spec_warnings": sum.SpecWarnings,
+					"usage":         sum.Usage,
+					"budgets":       sum.Budgets,
 				}
 				return writeJSON(cmd, payload)
 			}
@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/mistakeknot/autarch/internal/coldwine/accounting"
 	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
 )
 
 func TestStatusJSONOutput(t *testing.T) {
@@ -38,3 +42,67 @@ func TestStatusJSONOutput(t *testing.T) {
 		t.Fatalf("expected initialized true")
 	}
 }
+
+func TestStatusJSONIncludesUsageAndBudgets(t *testing.T) {
+	dir := t.TempDir()
+	if err := project.Init(dir); err != nil {
+		t.Fatal(err)
+	}
+	spec := "id: TAND-001\ntitle: Schema\nepic_id: EPIC-001\nstory_id: EPIC-001-S01\n"
+	if err := os.WriteFile(filepath.Join(project.SpecsDir(dir), "TAND-001.yaml"), []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := "[budget.epics.EPIC-001]\nusd = 1.0\n"
+	if err := os.WriteFile(filepath.Join(dir, ".tandemonium", "config.toml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.Open(project.StateDBPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-time.Minute).UTC()
+	if err := storage.AddTaskUsage(db, "TAND-001", storage.UsageSample{Agent: "claude", InputTokens: 1000, OutputTokens: 200, CostUSD: 1.5, At: start}, 0); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	cmd := StatusCmd()
+	out := bytes.NewBuffer(nil)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	var payload struct {
+		Usage   accounting.Report         `json:"usage"`
+		Budgets []accounting.BudgetStatus `json:"budgets"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if payload.Usage.Total.InputTokens != 1000 || payload.Usage.Total.CostUSD != 1.5 {
+		t.Fatalf("unexpected total %+v", payload.Usage.Total)
+	}
+	for _, key := range []map[string]accounting.Totals{payload.Usage.ByTask, payload.Usage.ByEpic, payload.Usage.ByStory, payload.Usage.ByAgent} {
+		if len(key) != 1 {
+			t.Fatalf("expected one entry per rollup, got %+v", payload.Usage)
+		}
+	}
+	if payload.Usage.ByEpic["EPIC-001"].WallSeconds < 59 {
+		t.Fatalf("expected wall time since the first sample, got %+v", payload.Usage.ByEpic["EPIC-001"])
+	}
+	if len(payload.Budgets) != 1 || !payload.Budgets[0].Exceeded {
+		t.Fatalf("expected EPIC-001 over budget, got %+v", payload.Budgets)
+	}
+}
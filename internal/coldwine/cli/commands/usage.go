@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/accounting"
+	"github.com/mistakeknot/autarch/internal/coldwine/config"
+)
+
+func accountingPrices(cfg config.Config) map[string]accounting.Price {
+	prices := make(map[string]accounting.Price, len(cfg.Accounting.Prices))
+	for model, p := range cfg.Accounting.Prices {
+		prices[model] = accounting.Price{Input: p.Input, Output: p.Output, CacheRead: p.CacheRead, CacheWrite: p.CacheWrite}
+	}
+	return prices
+}
+
+func epicBudgets(cfg config.Config) map[string]accounting.Budget {
+	budgets := make(map[string]accounting.Budget, len(cfg.Budget.Epics))
+	for epic, b := range cfg.Budget.Epics {
+		budgets[epic] = accounting.Budget{USD: b.USD, Tokens: b.Tokens}
+	}
+	return budgets
+}
+
+// formatTotals is the one-line form of usage used by status.
+func formatTotals(t accounting.Totals) string {
+	return fmt.Sprintf("$%.2f, %d tokens (%d in, %d out, %d cached), %s active / %s wall over %d task(s)",
+		t.CostUSD, t.Tokens(), t.InputTokens, t.OutputTokens, t.CacheReadTokens+t.CacheWriteTokens,
+		secondsDuration(t.ActiveSeconds), secondsDuration(t.WallSeconds), t.Tasks)
+}
+
+func secondsDuration(s float64) time.Duration {
+	return (time.Duration(s) * time.Second).Round(time.Second)
+}
@@ -62,6 +62,7 @@ func newRootCommand() *cobra.Command {
 			}
 			m := tui.NewModel()
 			m.ConfirmApprove = cfg.TUI.ConfirmApprove
+			m.QueueMerges = cfg.Merge.Queue
 			m.RefreshTasks()
 			p := tea.NewProgram(m)
 			_, err = p.Run()
@@ -124,6 +125,10 @@ Use --from-prd to import epics from an existing Praude PRD instead:
 		commands.ImportCmd(),
 		commands.ScanCmd(),
 		commands.ApplyCmd(),
+		commands.RunCmd(),
+		commands.MergeCmd(),
+		commands.DriftCmd(),
+		commands.AcceptanceCmd(),
 	)
 	root.Flags().BoolVarP(&quickMode, "quick", "q", false, "Create task in quick mode")
 	return root
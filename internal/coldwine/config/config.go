@@ -21,8 +21,13 @@ type ReviewConfig struct {
 }
 
 type CodingAgentConfig struct {
-	HealthCheckInterval int  `toml:"health_check_interval"`
-	RestartOnFailure    bool `toml:"restart_on_failure"`
+	HealthCheckInterval int      `toml:"health_check_interval"`
+	RestartOnFailure    bool     `toml:"restart_on_failure"`
+	RestartPolicy       string   `toml:"restart_policy"` // never, on-failure, backoff
+	MaxRestarts         int      `toml:"max_restarts"`
+	RestartBackoff      int      `toml:"restart_backoff"` // seconds before the first backoff restart
+	StallTimeout        int      `toml:"stall_timeout"`   // seconds without output or heartbeat
+	EscalateTo          []string `toml:"escalate_to"`
 }
 
 type LLMSummaryConfig struct {
@@ -30,12 +35,57 @@ type LLMSummaryConfig struct {
 	TimeoutSeconds int    `toml:"timeout_seconds"`
 }
 
+type SchedulerConfig struct {
+	DefaultAgent string         `toml:"default_agent"`
+	Limits       map[string]int `toml:"limits"`
+	PollInterval int            `toml:"poll_interval"`
+}
+
+type MergeConfig struct {
+	Queue       bool   `toml:"queue"`
+	TestCommand string `toml:"test_command"`
+	TestTimeout int    `toml:"test_timeout"`
+}
+
+type LockConfig struct {
+	HookPolicy string `toml:"hook_policy"`
+}
+
+// PriceConfig is USD per million tokens for models matching the table key
+// as a prefix.
+type PriceConfig struct {
+	Input      float64 `toml:"input"`
+	Output     float64 `toml:"output"`
+	CacheRead  float64 `toml:"cache_read"`
+	CacheWrite float64 `toml:"cache_write"`
+}
+
+type AccountingConfig struct {
+	Prices  map[string]PriceConfig `toml:"prices"`
+	Models  map[string]string      `toml:"models"`   // per agent target, for pricing before the agent names its model
+	IdleGap int                    `toml:"idle_gap"` // seconds of silence still counted as active
+}
+
+type EpicBudgetConfig struct {
+	USD    float64 `toml:"usd"`
+	Tokens int64   `toml:"tokens"`
+}
+
+type BudgetConfig struct {
+	Epics map[string]EpicBudgetConfig `toml:"epics"`
+}
+
 type Config struct {
 	General    GeneralConfig     `toml:"general"`
 	TUI        TUIConfig         `toml:"tui"`
 	Review     ReviewConfig      `toml:"review"`
 	Coding     CodingAgentConfig `toml:"coding_agent"`
 	LLMSummary LLMSummaryConfig  `toml:"llm_summary"`
+	Scheduler  SchedulerConfig   `toml:"scheduler"`
+	Merge      MergeConfig       `toml:"merge"`
+	Lock       LockConfig        `toml:"lock"`
+	Accounting AccountingConfig  `toml:"accounting"`
+	Budget     BudgetConfig      `toml:"budget"`
 }
 
 func defaultConfig() Config {
@@ -43,8 +93,12 @@ func defaultConfig() Config {
 		General:    GeneralConfig{MaxAgents: 4},
 		TUI:        TUIConfig{ConfirmApprove: true},
 		Review:     ReviewConfig{TargetBranch: ""},
-		Coding:     CodingAgentConfig{HealthCheckInterval: 0, RestartOnFailure: false},
+		Coding:     CodingAgentConfig{HealthCheckInterval: 0, RestartOnFailure: false, MaxRestarts: 3, RestartBackoff: 30, StallTimeout: 900},
 		LLMSummary: LLMSummaryConfig{Command: "", TimeoutSeconds: 0},
+		Scheduler:  SchedulerConfig{DefaultAgent: "claude", PollInterval: 5},
+		Merge:      MergeConfig{Queue: true, TestCommand: "", TestTimeout: 600},
+		Lock:       LockConfig{HookPolicy: "block"},
+		Accounting: AccountingConfig{IdleGap: 120},
 	}
 }
 
@@ -102,3 +102,36 @@ timeout_seconds = 15
 		t.Fatalf("expected timeout 15, got %d", cfg.LLMSummary.TimeoutSeconds)
 	}
 }
+
+func TestLoadProjectConfigAccountingAndBudgets(t *testing.T) {
+	dir := t.TempDir()
+	cfgDir := filepath.Join(dir, ".tandemonium")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.toml"), []byte(`
+[accounting.prices."claude-sonnet"]
+input = 3
+output = 15
+cache_read = 0.3
+
+[budget.epics.EPIC-001]
+usd = 25
+tokens = 2000000
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFromProject(dir)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if p := cfg.Accounting.Prices["claude-sonnet"]; p.Input != 3 || p.Output != 15 || p.CacheRead != 0.3 {
+		t.Fatalf("unexpected price: %+v", p)
+	}
+	if cfg.Accounting.IdleGap != 120 {
+		t.Fatalf("expected idle gap default 120, got %d", cfg.Accounting.IdleGap)
+	}
+	if b := cfg.Budget.Epics["EPIC-001"]; b.USD != 25 || b.Tokens != 2000000 {
+		t.Fatalf("unexpected budget: %+v", b)
+	}
+}
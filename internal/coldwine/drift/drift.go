@@ -1,15 +1,171 @@
 package drift
 
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// DetectDrift returns the changed paths that no allowed pattern covers.
 func DetectDrift(allowed, changed []string) []string {
-	allowedSet := make(map[string]struct{})
-	for _, a := range allowed {
-		allowedSet[a] = struct{}{}
-	}
 	var drift []string
 	for _, c := range changed {
-		if _, ok := allowedSet[c]; !ok {
+		if !MatchAny(allowed, c) {
 			drift = append(drift, c)
 		}
 	}
 	return drift
 }
+
+// Drift kinds. A finding can carry more than one.
+const (
+	KindNewFile     = "new_file"     // added file the task does not declare
+	KindOutsideTask = "outside_task" // existing file the task does not declare
+	KindOutsideEpic = "outside_epic" // file no task in the epic declares
+	KindReserved    = "reserved"     // file reserved by another agent
+)
+
+// Change is one changed file. Renames carry the source path in OldPath.
+type Change struct {
+	Status  string // git status letter: A, M, D, R, C, T
+	Path    string
+	OldPath string
+}
+
+// Reservation is an active path reservation; Path may be a glob.
+type Reservation struct {
+	Path  string
+	Owner string
+}
+
+// Scope is what a task is allowed to touch.
+type Scope struct {
+	Allowed      []string // the task's files_to_modify patterns
+	Epic         []string // patterns declared anywhere in the epic; empty skips the epic check
+	Owners       []string // names the task's agent reserves under
+	Reservations []Reservation
+}
+
+// Finding is a changed file that falls outside the task's scope.
+type Finding struct {
+	Path    string   `json:"path"`
+	OldPath string   `json:"old_path,omitempty"`
+	Status  string   `json:"status"`
+	Kinds   []string `json:"kinds"`
+	Holders []string `json:"holders,omitempty"`
+	Diff    string   `json:"diff,omitempty"`
+}
+
+// Has reports whether the finding includes kind.
+func (f Finding) Has(kind string) bool {
+	for _, k := range f.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Analyze classifies each change against scope. A rename counts as in scope
+// when either side of it is; reservations held by the task's own agent are
+// ignored.
+func Analyze(scope Scope, changes []Change) []Finding {
+	self := make(map[string]bool, len(scope.Owners))
+	for _, o := range scope.Owners {
+		self[o] = true
+	}
+	var out []Finding
+	for _, c := range changes {
+		paths := []string{c.Path}
+		if c.OldPath != "" {
+			paths = append(paths, c.OldPath)
+		}
+		f := Finding{Path: c.Path, OldPath: c.OldPath, Status: c.Status}
+		if !matchEither(scope.Allowed, paths) {
+			if c.Status == "A" || c.Status == "C" {
+				f.Kinds = append(f.Kinds, KindNewFile)
+			} else {
+				f.Kinds = append(f.Kinds, KindOutsideTask)
+			}
+			if len(scope.Epic) > 0 && !matchEither(scope.Epic, paths) {
+				f.Kinds = append(f.Kinds, KindOutsideEpic)
+			}
+		}
+		holders := map[string]bool{}
+		for _, r := range scope.Reservations {
+			if !self[r.Owner] && matchEither([]string{r.Path}, paths) {
+				holders[r.Owner] = true
+			}
+		}
+		if len(holders) > 0 {
+			f.Kinds = append(f.Kinds, KindReserved)
+			for h := range holders {
+				f.Holders = append(f.Holders, h)
+			}
+			sort.Strings(f.Holders)
+		}
+		if len(f.Kinds) > 0 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func matchEither(patterns, paths []string) bool {
+	for _, p := range paths {
+		if MatchAny(patterns, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAny reports whether any pattern matches name.
+func MatchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a slash-separated path matches pattern. Patterns use
+// path.Match syntax per segment, "**" matches any number of segments, and a
+// pattern naming a directory (with or without a trailing slash) matches
+// everything beneath it.
+func Match(pattern, name string) bool {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "./")
+	name = strings.TrimPrefix(name, "./")
+	if pattern == "" {
+		return false
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == name || strings.HasPrefix(name, pattern+"/") {
+		return true
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	// A pattern that ends on a directory covers its contents.
+	return true
+}
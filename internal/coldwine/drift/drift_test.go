@@ -1,6 +1,9 @@
 package drift
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestDetectDrift(t *testing.T) {
 	spec := []string{"a.txt"}
@@ -10,3 +13,71 @@ func TestDetectDrift(t *testing.T) {
 		t.Fatal("expected drift for b.txt")
 	}
 }
+
+func TestDetectDriftHonoursGlobsAndDirectories(t *testing.T) {
+	allowed := []string{"internal/store/**", "cmd/", "*.md"}
+	changed := []string{"internal/store/sql/schema.go", "cmd/app/main.go", "README.md", "docs/guide.md", "internal/api/api.go"}
+	got := DetectDrift(allowed, changed)
+	want := []string{"docs/guide.md", "internal/api/api.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"a.txt", "a.txt", true},
+		{"./a.txt", "a.txt", true},
+		{"internal", "internal/x/y.go", true},
+		{"internal/", "internal/x/y.go", true},
+		{"internal", "internalx/y.go", false},
+		{"internal/*.go", "internal/a.go", true},
+		{"internal/*.go", "internal/x/a.go", false},
+		{"internal/**/*.go", "internal/a.go", true},
+		{"internal/**/*.go", "internal/x/y/a.go", true},
+		{"**/testdata/**", "pkg/a/testdata/f.json", true},
+		{"*.sql", "schema.sql", true},
+		{"*.sql", "db/schema.sql", false},
+		{"", "a.txt", false},
+	}
+	for _, c := range cases {
+		if got := Match(c.pattern, c.name); got != c.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
+
+func TestAnalyzeClassifiesDrift(t *testing.T) {
+	scope := Scope{
+		Allowed: []string{"internal/store/**"},
+		Epic:    []string{"internal/store/**", "internal/api/**"},
+		Owners:  []string{"tand-TAND-001"},
+		Reservations: []Reservation{
+			{Path: "internal/api/**", Owner: "agent-api"},
+			{Path: "internal/store/cache.go", Owner: "tand-TAND-001"},
+		},
+	}
+	changes := []Change{
+		{Status: "M", Path: "internal/store/cache.go"},
+		{Status: "R", Path: "internal/store/v2/db.go", OldPath: "internal/store/db.go"},
+		{Status: "R", Path: "pkg/db.go", OldPath: "internal/store/legacy.go"},
+		{Status: "A", Path: "internal/store/new.go"},
+		{Status: "M", Path: "internal/api/handler.go"},
+		{Status: "A", Path: "scripts/migrate.sh"},
+	}
+	got := Analyze(scope, changes)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", got)
+	}
+	if got[0].Path != "internal/api/handler.go" ||
+		!reflect.DeepEqual(got[0].Kinds, []string{KindOutsideTask, KindReserved}) ||
+		!reflect.DeepEqual(got[0].Holders, []string{"agent-api"}) {
+		t.Fatalf("unexpected reserved finding %+v", got[0])
+	}
+	if got[1].Path != "scripts/migrate.sh" || !reflect.DeepEqual(got[1].Kinds, []string{KindNewFile, KindOutsideEpic}) {
+		t.Fatalf("unexpected new file finding %+v", got[1])
+	}
+}
@@ -40,6 +40,7 @@ type Epic struct {
 	Risks              []string `yaml:"risks,omitempty"`
 	Estimates          string   `yaml:"estimates,omitempty"`
 	Stories            []Story  `yaml:"stories,omitempty"`
+	SourcePRD          string   `yaml:"source_prd,omitempty"` // Gurgeh spec the epic was imported from
 }
 
 // ExistingMode controls how to handle pre-existing epic files.
@@ -14,3 +14,28 @@ func ParseNameOnly(output string) []string {
 	}
 	return files
 }
+
+// NameStatus is one entry of `git diff --name-status`. Renames and copies
+// carry the source path in OldPath.
+type NameStatus struct {
+	Status  string // A, M, D, R, C, T
+	Path    string
+	OldPath string
+}
+
+func ParseNameStatus(output string) []NameStatus {
+	var out []NameStatus
+	for _, l := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(l), "\t")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		// Rename and copy statuses carry a similarity score, e.g. R087.
+		entry := NameStatus{Status: fields[0][:1], Path: fields[len(fields)-1]}
+		if len(fields) == 3 {
+			entry.OldPath = fields[1]
+		}
+		out = append(out, entry)
+	}
+	return out
+}
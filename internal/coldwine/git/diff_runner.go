@@ -1,6 +1,9 @@
 package git
 
-import "os/exec"
+import (
+	"os/exec"
+	"strings"
+)
 
 type Runner interface {
 	Run(name string, args ...string) (string, error)
@@ -28,3 +31,87 @@ func DiffNameOnlyRange(r Runner, base, branch string) ([]string, error) {
 	}
 	return ParseNameOnly(out), nil
 }
+
+// DiffNameStatus lists changes between rev and the working tree, following
+// renames.
+func DiffNameStatus(r Runner, rev string) ([]NameStatus, error) {
+	out, err := r.Run("git", "diff", "--name-status", "-M", rev)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNameStatus(out), nil
+}
+
+// DiffPaths returns the patch for paths between rev and the working tree.
+func DiffPaths(r Runner, rev string, paths ...string) (string, error) {
+	args := append([]string{"diff", "-M", rev, "--"}, paths...)
+	return r.Run("git", args...)
+}
+
+func MergeBase(r Runner, a, b string) (string, error) {
+	out, err := r.Run("git", "merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// UntrackedFiles lists untracked files that are not ignored.
+func UntrackedFiles(r Runner) ([]string, error) {
+	out, err := r.Run("git", "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	return ParseNameOnly(out), nil
+}
+
+// StagedFiles lists paths staged for commit. Renames contribute both the
+// source and destination path.
+func StagedFiles(r Runner) ([]string, error) {
+	out, err := r.Run("git", "diff", "--cached", "--name-status", "-M")
+	if err != nil {
+		return nil, err
+	}
+	return nameStatusPaths(ParseNameStatus(out)), nil
+}
+
+// ChangedFiles lists paths changed between two revisions, including both
+// sides of renames.
+func ChangedFiles(r Runner, from, to string) ([]string, error) {
+	out, err := r.Run("git", "diff", "--name-status", "-M", from, to)
+	if err != nil {
+		return nil, err
+	}
+	return nameStatusPaths(ParseNameStatus(out)), nil
+}
+
+func nameStatusPaths(entries []NameStatus) []string {
+	var paths []string
+	for _, e := range entries {
+		if e.OldPath != "" {
+			paths = append(paths, e.OldPath)
+		}
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
+
+// HooksDir returns the hooks directory git uses for the working tree,
+// honouring core.hooksPath. Linked worktrees share their main repository's
+// hooks.
+func HooksDir(r Runner) (string, error) {
+	out, err := r.Run("git", "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// TopLevel returns the root of the working tree.
+func TopLevel(r Runner) (string, error) {
+	out, err := r.Run("git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -9,3 +9,17 @@ func TestParseNameOnly(t *testing.T) {
 		t.Fatal("expected 2")
 	}
 }
+
+func TestParseNameStatusFollowsRenames(t *testing.T) {
+	out := "M\tstore.go\nR087\told/api.go\tnew/api.go\nA\tnew/util.go\n"
+	got := ParseNameStatus(out)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", got)
+	}
+	if got[1].Status != "R" || got[1].OldPath != "old/api.go" || got[1].Path != "new/api.go" {
+		t.Fatalf("unexpected rename %+v", got[1])
+	}
+	if got[2].Status != "A" || got[2].OldPath != "" {
+		t.Fatalf("unexpected add %+v", got[2])
+	}
+}
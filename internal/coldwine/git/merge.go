@@ -1,6 +1,94 @@
 package git
 
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
 func MergeBranch(r Runner, branch string) error {
 	_, err := r.Run("git", "merge", branch)
 	return err
 }
+
+// DirRunner runs commands in a fixed working directory, such as a task
+// worktree.
+type DirRunner struct{ Dir string }
+
+func (d *DirRunner) Run(name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = d.Dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// ConflictError reports the files left unmerged by a failed rebase.
+type ConflictError struct {
+	Files  []string
+	Output string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflicts in %s", strings.Join(e.Files, ", "))
+}
+
+// CurrentBranch returns the checked-out branch name.
+func CurrentBranch(r Runner) (string, error) {
+	out, err := r.Run("git", "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse: %w: %s", err, strings.TrimSpace(out))
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// HeadCommit returns the full hash of HEAD.
+func HeadCommit(r Runner) (string, error) {
+	out, err := r.Run("git", "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse: %w: %s", err, strings.TrimSpace(out))
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ResolveCommit returns the full hash of the commit rev names.
+func ResolveCommit(r Runner, rev string) (string, error) {
+	out, err := r.Run("git", "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse %s: %w: %s", rev, err, strings.TrimSpace(out))
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ConflictedFiles lists paths with unresolved merge conflicts.
+func ConflictedFiles(r Runner) ([]string, error) {
+	out, err := r.Run("git", "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	return ParseNameOnly(out), nil
+}
+
+// Rebase rebases the checked-out branch onto base. On conflicts the rebase
+// is aborted and a *ConflictError lists the conflicting files.
+func Rebase(r Runner, base string) error {
+	out, err := r.Run("git", "rebase", base)
+	if err == nil {
+		return nil
+	}
+	files, _ := ConflictedFiles(r)
+	_, _ = r.Run("git", "rebase", "--abort")
+	if len(files) > 0 {
+		return &ConflictError{Files: files, Output: out}
+	}
+	return fmt.Errorf("git rebase %s: %w: %s", base, err, strings.TrimSpace(out))
+}
+
+// FastForward merges branch into the checked-out branch, refusing anything
+// but a fast-forward.
+func FastForward(r Runner, branch string) error {
+	out, err := r.Run("git", "merge", "--ff-only", branch)
+	if err != nil {
+		return fmt.Errorf("git merge --ff-only %s: %w: %s", branch, err, strings.TrimSpace(out))
+	}
+	return nil
+}
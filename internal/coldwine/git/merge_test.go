@@ -1,6 +1,10 @@
 package git
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 type fakeMergeRunner struct{ args [][]string }
 
@@ -16,3 +20,50 @@ func TestMergeBranch(t *testing.T) {
 		t.Fatal("expected git merge")
 	}
 }
+
+type scriptedRunner struct {
+	calls   [][]string
+	outputs map[string]string
+	fail    map[string]bool
+}
+
+func (s *scriptedRunner) Run(name string, args ...string) (string, error) {
+	s.calls = append(s.calls, append([]string{name}, args...))
+	key := strings.Join(args, " ")
+	if s.fail[key] {
+		return s.outputs[key], errors.New("exit status 1")
+	}
+	return s.outputs[key], nil
+}
+
+func TestRebaseReportsConflictsAndAborts(t *testing.T) {
+	r := &scriptedRunner{
+		outputs: map[string]string{
+			"rebase main":                      "CONFLICT (content): Merge conflict in store.go",
+			"diff --name-only --diff-filter=U": "store.go\nschema.sql\n",
+		},
+		fail: map[string]bool{"rebase main": true},
+	}
+	err := Rebase(r, "main")
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	if strings.Join(conflict.Files, ",") != "store.go,schema.sql" {
+		t.Fatalf("unexpected files %v", conflict.Files)
+	}
+	last := r.calls[len(r.calls)-1]
+	if strings.Join(last[1:], " ") != "rebase --abort" {
+		t.Fatalf("expected rebase --abort, got %v", last)
+	}
+}
+
+func TestFastForwardUsesFFOnly(t *testing.T) {
+	r := &scriptedRunner{}
+	if err := FastForward(r, "feature/TAND-001"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(r.calls[0], " ") != "git merge --ff-only feature/TAND-001" {
+		t.Fatalf("unexpected call %v", r.calls[0])
+	}
+}
@@ -0,0 +1,155 @@
+// Package lockhooks turns Coldwine path reservations into git hooks. The
+// installed pre-commit and pre-push hooks call back into `coldwine lock
+// check`, which compares the paths being committed or pushed against
+// exclusive reservations held by other owners.
+package lockhooks
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/drift"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+// Hook policies.
+const (
+	PolicyBlock = "block"
+	PolicyWarn  = "warn"
+)
+
+// Hooks are the git hooks Install writes.
+var Hooks = []string{"pre-commit", "pre-push"}
+
+// marker identifies hooks written by Install.
+const marker = "# coldwine-lock-hook"
+
+// chainSuffix is appended to a pre-existing hook that Install moves aside.
+const chainSuffix = ".local"
+
+// ParsePolicy validates a policy name; empty means block.
+func ParsePolicy(name string) (string, error) {
+	switch strings.TrimSpace(name) {
+	case "", PolicyBlock:
+		return PolicyBlock, nil
+	case PolicyWarn:
+		return PolicyWarn, nil
+	}
+	return "", fmt.Errorf("unknown hook policy %q (use block or warn)", name)
+}
+
+// Violation is a path another owner holds an exclusive reservation on.
+type Violation struct {
+	Path        string `json:"path"`
+	Reservation string `json:"reservation"`
+	Holder      string `json:"holder"`
+	Reason      string `json:"reason,omitempty"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+// Check returns the violations in paths. Reservation paths may be globs or
+// directories; reservations held by any of owners, and shared reservations,
+// are ignored.
+func Check(reservations []storage.Reservation, owners, paths []string) []Violation {
+	self := make(map[string]bool, len(owners))
+	for _, o := range owners {
+		self[o] = true
+	}
+	var out []Violation
+	seen := map[string]bool{}
+	for _, path := range paths {
+		for _, r := range reservations {
+			if !r.Exclusive || self[r.Owner] || !drift.Match(r.Path, path) {
+				continue
+			}
+			key := path + "\x00" + r.Owner
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, Violation{Path: path, Reservation: r.Path, Holder: r.Owner, Reason: r.Reason, ExpiresAt: r.ExpiresAt})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// Holders returns the distinct owners in violations.
+func Holders(violations []Violation) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range violations {
+		if !seen[v.Holder] {
+			seen[v.Holder] = true
+			out = append(out, v.Holder)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Script returns the hook body. binary is the coldwine executable to call.
+// pre-push receives the pushed refs on stdin, which is replayed to any
+// chained hook before coldwine reads it.
+func Script(hook, binary string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(marker + "\n")
+	b.WriteString("# Installed by `coldwine lock install-hooks`. Set COLDWINE_LOCK_OVERRIDE=\"reason\"\n")
+	b.WriteString("# to commit over another agent's reservation; the override is logged.\n")
+	b.WriteString("hookdir=$(dirname \"$0\")\n")
+	if hook == "pre-push" {
+		b.WriteString("input=$(cat)\n")
+		fmt.Fprintf(&b, "if [ -x \"$hookdir/%s%s\" ]; then\n", hook, chainSuffix)
+		fmt.Fprintf(&b, "  printf '%%s\\n' \"$input\" | \"$hookdir/%s%s\" \"$@\" || exit $?\n", hook, chainSuffix)
+		b.WriteString("fi\n")
+		fmt.Fprintf(&b, "printf '%%s\\n' \"$input\" | exec %s lock check --hook %s\n", shellQuote(binary), hook)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "if [ -x \"$hookdir/%s%s\" ]; then\n", hook, chainSuffix)
+	fmt.Fprintf(&b, "  \"$hookdir/%s%s\" \"$@\" || exit $?\n", hook, chainSuffix)
+	b.WriteString("fi\n")
+	fmt.Fprintf(&b, "exec %s lock check --hook %s\n", shellQuote(binary), hook)
+	return b.String()
+}
+
+// Installed reports whether path is a hook written by Install.
+func Installed(path string) bool {
+	raw, err := os.ReadFile(path)
+	return err == nil && bytes.Contains(raw, []byte(marker))
+}
+
+// Install writes the hooks into hooksDir. A pre-existing hook that Install
+// did not write is kept as <hook>.local and run first. It returns the paths
+// written.
+func Install(hooksDir, binary string) ([]string, error) {
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, hook := range Hooks {
+		path := filepath.Join(hooksDir, hook)
+		if _, err := os.Stat(path); err == nil && !Installed(path) {
+			local := path + chainSuffix
+			if _, err := os.Stat(local); err == nil {
+				return written, fmt.Errorf("%s exists and %s is already taken", path, local)
+			}
+			if err := os.Rename(path, local); err != nil {
+				return written, err
+			}
+		}
+		if err := os.WriteFile(path, []byte(Script(hook, binary)), 0o755); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "'\"'\"'") + "'"
+}
@@ -0,0 +1,83 @@
+package lockhooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+func TestCheckIgnoresOwnAndSharedReservations(t *testing.T) {
+	reservations := []storage.Reservation{
+		{Path: "internal/store/**", Owner: "tand-TAND-002", Exclusive: true},
+		{Path: "internal/api/", Owner: "tand-TAND-001", Exclusive: true},
+		{Path: "docs/README.md", Owner: "alice", Exclusive: false},
+	}
+	paths := []string{"internal/store/db.go", "internal/api/handler.go", "docs/README.md", "main.go"}
+	got := Check(reservations, []string{"tand-TAND-001"}, paths)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 violation, got %+v", got)
+	}
+	if got[0].Path != "internal/store/db.go" || got[0].Holder != "tand-TAND-002" || got[0].Reservation != "internal/store/**" {
+		t.Fatalf("unexpected violation %+v", got[0])
+	}
+	if holders := Holders(got); len(holders) != 1 || holders[0] != "tand-TAND-002" {
+		t.Fatalf("unexpected holders %v", holders)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for in, want := range map[string]string{"": PolicyBlock, "block": PolicyBlock, "warn": PolicyWarn} {
+		got, err := ParsePolicy(in)
+		if err != nil || got != want {
+			t.Fatalf("ParsePolicy(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParsePolicy("ignore"); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
+
+func TestInstallChainsExistingHook(t *testing.T) {
+	dir := t.TempDir()
+	existing := "#!/bin/sh\necho lint\n"
+	if err := os.WriteFile(filepath.Join(dir, "pre-commit"), []byte(existing), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := Install(dir, "/opt/cold wine/coldwine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 hooks, got %v", paths)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "pre-commit.local"))
+	if err != nil || string(raw) != existing {
+		t.Fatalf("existing hook not preserved: %q %v", raw, err)
+	}
+	hook, _ := os.ReadFile(filepath.Join(dir, "pre-commit"))
+	if !strings.Contains(string(hook), `"$hookdir/pre-commit.local"`) {
+		t.Fatalf("hook does not chain:\n%s", hook)
+	}
+	if !strings.Contains(string(hook), `exec '/opt/cold wine/coldwine' lock check --hook pre-commit`) {
+		t.Fatalf("hook does not call coldwine:\n%s", hook)
+	}
+	push, _ := os.ReadFile(filepath.Join(dir, "pre-push"))
+	if !strings.Contains(string(push), "input=$(cat)") {
+		t.Fatalf("pre-push hook does not capture stdin:\n%s", push)
+	}
+
+	// Reinstalling replaces our hooks without touching the chained one.
+	if _, err := Install(dir, "coldwine"); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ = os.ReadFile(filepath.Join(dir, "pre-commit.local"))
+	if string(raw) != existing {
+		t.Fatalf("chained hook changed on reinstall: %q", raw)
+	}
+	if !Installed(filepath.Join(dir, "pre-commit")) {
+		t.Fatal("expected reinstalled hook to carry the marker")
+	}
+}
@@ -0,0 +1,178 @@
+// Package mailbus pushes coordination mail events to live subscribers.
+//
+// Each subscriber listens on its own Unix socket in the project's mailbus
+// directory. Publishers write one JSON-encoded storage.MailEvent per line to
+// every socket they find, so there is no broker process to keep alive. The
+// state database stays the source of truth: a subscriber that misses an
+// event can always catch up from the mail log.
+package mailbus
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+const socketSuffix = ".sock"
+
+// dialTimeout bounds how long a publisher waits on one subscriber.
+const dialTimeout = 500 * time.Millisecond
+
+var listenerSeq atomic.Int64
+
+// Publish sends ev to every subscriber socket in dir and returns how many
+// accepted it. Sockets whose listener is gone are removed.
+func Publish(dir string, ev storage.MailEvent) (int, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return 0, err
+	}
+	payload = append(payload, '\n')
+	sockets, err := filepath.Glob(filepath.Join(dir, "*"+socketSuffix))
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	var errs []error
+	for _, path := range sockets {
+		conn, err := net.DialTimeout("unix", path, dialTimeout)
+		if err != nil {
+			if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, os.ErrNotExist) {
+				_ = os.Remove(path)
+				continue
+			}
+			errs = append(errs, err)
+			continue
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+		_, err = conn.Write(payload)
+		_ = conn.Close()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// Listener receives events published to its socket.
+type Listener struct {
+	Events <-chan storage.MailEvent
+
+	ln     net.Listener
+	path   string
+	events chan storage.MailEvent
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Listen creates a subscriber socket in dir.
+func Listen(dir string) (*Listener, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d-%d%s", os.Getpid(), listenerSeq.Add(1), socketSuffix))
+	_ = os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan storage.MailEvent, 64)
+	l := &Listener{Events: events, ln: ln, path: path, events: events, done: make(chan struct{})}
+	go l.accept()
+	return l, nil
+}
+
+// Path is the listener's socket path.
+func (l *Listener) Path() string {
+	return l.path
+}
+
+// Close stops the listener and removes its socket. Events is closed once
+// pending connections drain.
+func (l *Listener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+		err = l.ln.Close()
+		_ = os.Remove(l.path)
+	})
+	return err
+}
+
+func (l *Listener) accept() {
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		close(l.events)
+	}()
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			scanner := bufio.NewScanner(conn)
+			scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+			for scanner.Scan() {
+				var ev storage.MailEvent
+				if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+					continue
+				}
+				select {
+				case l.events <- ev:
+				case <-l.done:
+					return
+				}
+			}
+		}()
+	}
+}
+
+var (
+	attachMu    sync.Mutex
+	attached    = map[*sql.DB]string{}
+	installOnce sync.Once
+)
+
+// Attach publishes mail events committed through db to subscribers in dir.
+func Attach(db *sql.DB, dir string) {
+	attachMu.Lock()
+	attached[db] = dir
+	attachMu.Unlock()
+	installOnce.Do(func() { storage.SetMailNotifier(notify) })
+}
+
+// Detach stops publishing events for db.
+func Detach(db *sql.DB) {
+	attachMu.Lock()
+	delete(attached, db)
+	attachMu.Unlock()
+}
+
+// notify is best effort: subscribers recover anything missed from the
+// mail log, so a failed publish must not fail the send.
+func notify(db *sql.DB, ev storage.MailEvent) {
+	attachMu.Lock()
+	dir, ok := attached[db]
+	attachMu.Unlock()
+	if ok {
+		_, _ = Publish(dir, ev)
+	}
+}
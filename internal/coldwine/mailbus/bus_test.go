@@ -0,0 +1,99 @@
+package mailbus
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+func nextEvent(t *testing.T, l *Listener) storage.MailEvent {
+	t.Helper()
+	select {
+	case ev := <-l.Events:
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return storage.MailEvent{}
+}
+
+func TestPublishReachesEveryListener(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bus")
+	a, err := Listen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := Listen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// A socket left behind by a dead subscriber is cleaned up.
+	stale := filepath.Join(dir, "999999-1.sock")
+	ln, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	sent, err := Publish(dir, storage.MailEvent{Kind: storage.MailEventMessage, Message: storage.Message{ID: "msg-1"}, Recipients: []string{"bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 2 {
+		t.Fatalf("sent = %d, want 2", sent)
+	}
+	for _, l := range []*Listener{a, b} {
+		if ev := nextEvent(t, l); ev.Message.ID != "msg-1" || ev.Recipients[0] != "bob" {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("stale socket not removed: %v", err)
+	}
+
+	a.Close()
+	if _, err := os.Stat(a.Path()); !os.IsNotExist(err) {
+		t.Fatalf("closed listener left its socket: %v", err)
+	}
+}
+
+func TestAttachPublishesStoredMail(t *testing.T) {
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "bus")
+	l, err := Listen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	Attach(db, dir)
+	defer Detach(db)
+
+	if err := storage.SendMessage(db, storage.Message{ID: "msg-7", Sender: "alice", Subject: "Hi", Body: "b", Importance: "urgent"}, []string{"bob"}); err != nil {
+		t.Fatal(err)
+	}
+	ev := nextEvent(t, l)
+	if ev.Kind != storage.MailEventMessage || ev.Message.Subject != "Hi" || ev.Message.Importance != "urgent" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if err := storage.AckMessage(db, "msg-7", "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, l); ev.Kind != storage.MailEventAck || ev.Recipients[0] != "bob" {
+		t.Fatalf("unexpected ack event %+v", ev)
+	}
+}
@@ -0,0 +1,174 @@
+package mailbus
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/mistakeknot/autarch/internal/coldwine/tmux"
+)
+
+// Injection is one notice typed into a recipient's tmux pane.
+type Injection struct {
+	MessageID string `json:"message_id"`
+	Recipient string `json:"recipient"`
+	Session   string `json:"session"`
+	Reminder  int    `json:"reminder"`
+}
+
+// DefaultAgentCommands are the pane commands notices may be typed into.
+var DefaultAgentCommands = []string{"claude", "codex", "gemini"}
+
+// Injector types urgent and unacknowledged messages into the recipient's
+// tmux session. Urgent messages are injected once; messages that require an
+// ack are repeated every Remind until acknowledged or MaxReminders is
+// reached. Notices are only typed into a pane whose foreground command is an
+// agent, never into a shell.
+type Injector struct {
+	DB           *sql.DB
+	Runner       tmux.Runner
+	Remind       time.Duration
+	MaxReminders int
+	// Resolve maps a recipient to a live tmux session; nil uses the
+	// recipient name itself, then the sessions of tasks the agent owns.
+	Resolve func(recipient string) (string, bool)
+	// PaneCommand reports a session's foreground command; nil asks tmux.
+	PaneCommand func(session string) (string, error)
+	// Agents lists the accepted pane commands; nil uses
+	// DefaultAgentCommands.
+	Agents []string
+	Now    func() time.Time
+}
+
+// Sweep injects every pending delivery that is due and records a delivery
+// receipt for it.
+func (in *Injector) Sweep() ([]Injection, error) {
+	pending, err := storage.ListPendingDeliveries(in.DB)
+	if err != nil {
+		return nil, err
+	}
+	now := in.now()
+	var out []Injection
+	for _, p := range pending {
+		if !in.due(p, now) {
+			continue
+		}
+		session, ok := in.resolve(p.Recipient)
+		if !ok || !in.atAgent(session) {
+			continue
+		}
+		if err := tmux.SendText(in.Runner, session, Notice(p.MessageDelivery, p.InjectCount)); err != nil {
+			return out, fmt.Errorf("inject %s into %s: %w", p.Message.ID, session, err)
+		}
+		ts := now.UTC().Format(time.RFC3339Nano)
+		if err := storage.RecordMessageInjection(in.DB, p.Message.ID, p.Recipient, ts); err != nil {
+			return out, err
+		}
+		if _, err := storage.MarkMessageDelivered(in.DB, p.Message.ID, p.Recipient, ts); err != nil {
+			return out, err
+		}
+		out = append(out, Injection{MessageID: p.Message.ID, Recipient: p.Recipient, Session: session, Reminder: p.InjectCount})
+	}
+	return out, nil
+}
+
+func (in *Injector) due(p storage.PendingDelivery, now time.Time) bool {
+	if p.InjectCount == 0 {
+		return true
+	}
+	if !p.Message.AckRequired || p.InjectCount > in.MaxReminders || in.Remind <= 0 {
+		return false
+	}
+	last, err := time.Parse(time.RFC3339Nano, p.InjectedAt)
+	if err != nil {
+		return true
+	}
+	return now.Sub(last) >= in.Remind
+}
+
+func (in *Injector) resolve(recipient string) (string, bool) {
+	if in.Resolve != nil {
+		return in.Resolve(recipient)
+	}
+	if tmux.HasExactSession(in.Runner, recipient) {
+		return recipient, true
+	}
+	sessions, err := storage.ListAgentSessionsByAgent(in.DB, recipient)
+	if err != nil {
+		return "", false
+	}
+	for _, s := range sessions {
+		id := agent.SessionID(s.TaskID)
+		if tmux.HasExactSession(in.Runner, id) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// atAgent reports whether the session's active pane is running an agent.
+// A pane left at a shell prompt would run the notice as a command.
+func (in *Injector) atAgent(session string) bool {
+	paneCommand := in.PaneCommand
+	if paneCommand == nil {
+		paneCommand = tmux.PaneCommand
+	}
+	cmd, err := paneCommand(session)
+	if err != nil {
+		return false
+	}
+	agents := in.Agents
+	if agents == nil {
+		agents = DefaultAgentCommands
+	}
+	return slices.Contains(agents, filepath.Base(cmd))
+}
+
+func (in *Injector) now() time.Time {
+	if in.Now != nil {
+		return in.Now()
+	}
+	return time.Now()
+}
+
+// Notice is the single line typed into the recipient's pane. reminder is
+// the number of earlier injections. Sender and subject are cleaned of shell
+// metacharacters, and the text is plain prose so that even a pane that falls
+// back to a shell has nothing to expand or run.
+func Notice(d storage.MessageDelivery, reminder int) string {
+	var b strings.Builder
+	b.WriteString("Coldwine mail: ")
+	if reminder > 0 {
+		fmt.Fprintf(&b, "reminder %d, ", reminder)
+	}
+	if d.Message.Importance == "urgent" {
+		b.WriteString("URGENT ")
+	}
+	fmt.Fprintf(&b, "message %s from %s: %s.", oneLine(d.Message.ID), oneLine(d.Message.Sender), oneLine(d.Message.Subject))
+	fmt.Fprintf(&b, " Read it with coldwine mail inbox --recipient %s", oneLine(d.Recipient))
+	if d.Message.AckRequired {
+		fmt.Fprintf(&b, " and acknowledge with coldwine mail ack --id %s --recipient %s", oneLine(d.Message.ID), oneLine(d.Recipient))
+	}
+	b.WriteString(".")
+	return oneLine(b.String())
+}
+
+// shellMeta are the characters a shell would interpret in a typed line.
+const shellMeta = "`$\\;&|<>(){}[]*?!#~'\""
+
+// oneLine flattens text so it cannot submit early, send control keys or be
+// interpreted by a shell.
+func oneLine(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(shellMeta, r) {
+			return ' '
+		}
+		return r
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
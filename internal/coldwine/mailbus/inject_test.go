@@ -0,0 +1,162 @@
+package mailbus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+type fakeTmux struct {
+	sessions map[string]bool
+	// panes maps a session to its foreground command; missing means claude.
+	panes map[string]string
+	sent  []string
+}
+
+func (f *fakeTmux) Run(name string, args ...string) error {
+	if len(args) > 0 && args[0] == "has-session" {
+		if strings.HasPrefix(args[2], "=") && f.sessions[strings.TrimPrefix(args[2], "=")] {
+			return nil
+		}
+		return errNoSession
+	}
+	if len(args) == 6 && args[0] == "send-keys" && args[3] == "-l" && args[4] == "--" {
+		f.sent = append(f.sent, args[2]+" "+args[5])
+	}
+	return nil
+}
+
+func (f *fakeTmux) paneCommand(session string) (string, error) {
+	if cmd, ok := f.panes[session]; ok {
+		return cmd, nil
+	}
+	return "claude", nil
+}
+
+var errNoSession = errors.New("no session")
+
+func TestInjectorRemindsUntilAcked(t *testing.T) {
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SendMessage(db, storage.Message{ID: "msg-1", Sender: "alice", Subject: "Stop editing\nschema.sql", Body: "b", Importance: "urgent"}, []string{"tand-TAND-001"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SendMessage(db, storage.Message{ID: "msg-2", Sender: "alice", Subject: "Confirm plan", Body: "b", AckRequired: true}, []string{"tand-TAND-001", "offline"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SendMessage(db, storage.Message{ID: "msg-3", Sender: "alice", Subject: "FYI", Body: "b"}, []string{"tand-TAND-001"}); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &fakeTmux{sessions: map[string]bool{"tand-TAND-001": true}}
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	in := &Injector{DB: db, Runner: runner, PaneCommand: runner.paneCommand, Remind: 5 * time.Minute, MaxReminders: 1, Now: func() time.Time { return now }}
+
+	done, err := in.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 {
+		t.Fatalf("expected urgent and ack-required messages injected, got %+v", done)
+	}
+	if !strings.HasPrefix(runner.sent[0], "=tand-TAND-001: ") || !strings.Contains(runner.sent[0], "URGENT message msg-1 from alice: Stop editing schema.sql.") {
+		t.Fatalf("unexpected notice %q", runner.sent[0])
+	}
+	if !strings.Contains(runner.sent[1], "coldwine mail ack --id msg-2 --recipient tand-TAND-001") {
+		t.Fatalf("ack instructions missing: %q", runner.sent[1])
+	}
+
+	// Nothing is due again until the reminder interval passes.
+	if done, _ := in.Sweep(); len(done) != 0 {
+		t.Fatalf("unexpected early reminder %+v", done)
+	}
+	now = now.Add(6 * time.Minute)
+	done, err = in.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].MessageID != "msg-2" || done[0].Reminder != 1 {
+		t.Fatalf("expected one reminder for msg-2, got %+v", done)
+	}
+	// MaxReminders is reached.
+	now = now.Add(6 * time.Minute)
+	if done, _ := in.Sweep(); len(done) != 0 {
+		t.Fatalf("reminders should stop at the limit, got %+v", done)
+	}
+
+	receipts, err := storage.ListDeliveryReceipts(db, "msg-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipts[0].Recipient != "offline" || receipts[0].DeliveredAt != "" {
+		t.Fatalf("recipient without a session should not be delivered: %+v", receipts[0])
+	}
+	if receipts[1].DeliveredAt == "" || receipts[1].InjectCount != 2 {
+		t.Fatalf("unexpected receipt %+v", receipts[1])
+	}
+}
+
+func TestInjectorSkipsShellPanesAndNeutralisesNotices(t *testing.T) {
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	msg := storage.Message{ID: "msg-1", Sender: "$(whoami)", Subject: "run `rm -rf ~`; echo hi > /tmp/x", Body: "b", Importance: "urgent"}
+	if err := storage.SendMessage(db, msg, []string{"tand-TAND-001", "tand-TAND-002"}); err != nil {
+		t.Fatal(err)
+	}
+	runner := &fakeTmux{
+		sessions: map[string]bool{"tand-TAND-001": true, "tand-TAND-002": true},
+		panes:    map[string]string{"tand-TAND-002": "bash"},
+	}
+	in := &Injector{DB: db, Runner: runner, PaneCommand: runner.paneCommand}
+	done, err := in.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].Session != "tand-TAND-001" {
+		t.Fatalf("expected only the agent pane injected, got %+v", done)
+	}
+	for _, c := range "`$;&|<>()~" {
+		if strings.ContainsRune(runner.sent[0], c) {
+			t.Fatalf("notice keeps shell metacharacter %q: %q", c, runner.sent[0])
+		}
+	}
+	if !strings.Contains(runner.sent[0], "from whoami: run rm -rf echo hi /tmp/x.") {
+		t.Fatalf("unexpected notice %q", runner.sent[0])
+	}
+}
+
+func TestInjectorResolvesExactSessionNames(t *testing.T) {
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.MigrateV2(db); err != nil {
+		t.Fatal(err)
+	}
+	in := &Injector{DB: db, Runner: &fakeTmux{sessions: map[string]bool{"tand-TAND-0010": true}}}
+	if _, ok := in.resolve("tand-TAND-0010"); !ok {
+		t.Fatal("expected exact session to resolve")
+	}
+	if s, ok := in.resolve("tand-TAND-001"); ok {
+		t.Fatalf("prefix should not resolve, got %q", s)
+	}
+}
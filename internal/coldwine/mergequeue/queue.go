@@ -0,0 +1,328 @@
+// Package mergequeue lands approved task branches one at a time. Each entry
+// is rebased in its worktree onto the latest base branch, run through the
+// configured test command, and fast-forwarded into the base only if the tests
+// pass. Conflicts and failures send the task back to review and notify the
+// agents involved through Coldwine mail.
+package mergequeue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/git"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/specs"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+// Sender is the mail sender used for merge queue notifications.
+const Sender = "merge-queue"
+
+// TestFunc runs command in dir and returns its combined output.
+type TestFunc func(ctx context.Context, dir, command string) ([]byte, error)
+
+// Queue processes the merge queue in the state DB.
+type Queue struct {
+	DB          *sql.DB
+	Root        string
+	Base        string // branch to land on; defaults to "main"
+	TestCommand string // empty skips the test gate
+	TestTimeout time.Duration
+
+	// Git returns a runner for dir; defaults to git.DirRunner.
+	Git func(dir string) git.Runner
+	// Test runs the test command; defaults to sh -c in the worktree.
+	Test TestFunc
+}
+
+// Conflict is one conflicting file and the agents holding reservations on it.
+type Conflict struct {
+	Path   string   `json:"path"`
+	Owners []string `json:"owners,omitempty"`
+}
+
+// Outcome is the result of processing one entry.
+type Outcome struct {
+	TaskID    string     `json:"task_id"`
+	Branch    string     `json:"branch"`
+	State     string     `json:"state"`
+	Detail    string     `json:"detail,omitempty"`
+	LogPath   string     `json:"log_path,omitempty"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	Notified  []string   `json:"notified,omitempty"`
+}
+
+func (q *Queue) base() string {
+	if q.Base == "" {
+		return "main"
+	}
+	return q.Base
+}
+
+func (q *Queue) runner(dir string) git.Runner {
+	if q.Git != nil {
+		return q.Git(dir)
+	}
+	return &git.DirRunner{Dir: dir}
+}
+
+// ProcessAll drains the queue, stopping early only on errors that leave the
+// repository in an unknown state.
+func (q *Queue) ProcessAll(ctx context.Context) ([]Outcome, error) {
+	var out []Outcome
+	for {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		outcome, err := q.ProcessNext(ctx)
+		if err != nil || outcome == nil {
+			return out, err
+		}
+		out = append(out, *outcome)
+	}
+}
+
+// ProcessNext handles the oldest queued entry. It returns nil when the queue
+// is empty.
+func (q *Queue) ProcessNext(ctx context.Context) (*Outcome, error) {
+	entry, err := storage.NextMerge(q.DB)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	main := q.runner(q.Root)
+	current, err := git.CurrentBranch(main)
+	if err != nil {
+		return nil, err
+	}
+	if current != q.base() {
+		return nil, fmt.Errorf("repository is on %s, expected %s", current, q.base())
+	}
+	if err := storage.StartMerge(q.DB, entry.TaskID); err != nil {
+		return nil, err
+	}
+	outcome := &Outcome{TaskID: entry.TaskID, Branch: entry.Branch}
+
+	worktree, err := project.SafePath(project.WorktreesDir(q.Root), entry.TaskID)
+	if err != nil {
+		return q.reject(outcome, storage.MergeFailed, err.Error())
+	}
+	if _, err := os.Stat(worktree); err != nil {
+		return q.reject(outcome, storage.MergeFailed, "worktree not found: "+worktree)
+	}
+	// The rebase runs in the worktree but the fast-forward names the queued
+	// branch, so they must be the same branch.
+	branch, err := git.CurrentBranch(q.runner(worktree))
+	if err != nil {
+		return q.reject(outcome, storage.MergeFailed, "reading worktree branch: "+err.Error())
+	}
+	if branch != entry.Branch {
+		return q.reject(outcome, storage.MergeFailed, fmt.Sprintf("worktree is on %s, not the queued branch %s", branch, entry.Branch))
+	}
+
+	if err := git.Rebase(q.runner(worktree), q.base()); err != nil {
+		var conflict *git.ConflictError
+		if !errors.As(err, &conflict) {
+			return q.reject(outcome, storage.MergeFailed, err.Error())
+		}
+		return q.conflict(outcome, conflict.Files)
+	}
+
+	if q.TestCommand != "" {
+		logPath, passed, err := q.runTests(ctx, entry.TaskID, worktree)
+		if err != nil {
+			return q.requeue(outcome, err)
+		}
+		outcome.LogPath = logPath
+		if !passed {
+			return q.reject(outcome, storage.MergeFailed, "tests failed: "+q.TestCommand)
+		}
+	}
+
+	if err := git.FastForward(main, entry.Branch); err != nil {
+		// Nothing else should move the base between rebase and merge; put the
+		// entry back and let the caller look.
+		return q.requeue(outcome, err)
+	}
+	if err := storage.ApproveTask(q.DB, entry.TaskID); err != nil {
+		// The branch has landed; the retry finds nothing to rebase or
+		// fast-forward and approves the task.
+		return q.requeue(outcome, err)
+	}
+	outcome.State = storage.MergeMerged
+	outcome.Detail = "merged into " + q.base()
+	return outcome, storage.FinishMerge(q.DB, entry.TaskID, outcome.State, outcome.LogPath, outcome.Detail)
+}
+
+// requeue puts an entry back in the queue after an error that says nothing
+// about the branch, so it is retried instead of left in testing.
+func (q *Queue) requeue(outcome *Outcome, err error) (*Outcome, error) {
+	_ = storage.FinishMerge(q.DB, outcome.TaskID, storage.MergeQueued, outcome.LogPath, err.Error())
+	return nil, err
+}
+
+// RequeueInterrupted puts back entries a run stopped in the middle of, say
+// by crashing, and returns how many there were. Call it before processing;
+// only one run may process the queue at a time.
+func (q *Queue) RequeueInterrupted() (int, error) {
+	return storage.RequeueTestingMerges(q.DB)
+}
+
+func (q *Queue) runTests(ctx context.Context, taskID, worktree string) (string, bool, error) {
+	if q.TestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.TestTimeout)
+		defer cancel()
+	}
+	test := q.Test
+	if test == nil {
+		test = shellTest
+	}
+	output, testErr := test(ctx, worktree, q.TestCommand)
+	if ctx.Err() == context.DeadlineExceeded {
+		output = append(output, []byte(fmt.Sprintf("\ntimed out after %s\n", q.TestTimeout))...)
+	}
+
+	dir := project.MergeLogsDir(q.Root)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", false, err
+	}
+	logPath, err := project.SafePath(dir, taskID+".log")
+	if err != nil {
+		return "", false, err
+	}
+	header := fmt.Sprintf("$ %s\n# %s in %s\n", q.TestCommand, time.Now().UTC().Format(time.RFC3339), worktree)
+	if err := os.WriteFile(logPath, append([]byte(header), output...), 0o644); err != nil {
+		return "", false, err
+	}
+	return logPath, testErr == nil, nil
+}
+
+func shellTest(ctx context.Context, dir, command string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+// conflict notifies the reservation holders of each conflicting file, plus
+// the task's own agent, then sends the task back to review.
+func (q *Queue) conflict(outcome *Outcome, files []string) (*Outcome, error) {
+	owner := q.taskOwner(outcome.TaskID)
+	byOwner := map[string][]string{owner: nil}
+	for _, path := range files {
+		c := Conflict{Path: path}
+		reservations, err := storage.ListActiveReservationsForPath(q.DB, path)
+		if err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{}
+		for _, r := range reservations {
+			if !seen[r.Owner] {
+				seen[r.Owner] = true
+				c.Owners = append(c.Owners, r.Owner)
+				byOwner[r.Owner] = append(byOwner[r.Owner], path)
+			}
+		}
+		outcome.Conflicts = append(outcome.Conflicts, c)
+	}
+
+	var lines []string
+	for _, c := range outcome.Conflicts {
+		holders := "unreserved"
+		if len(c.Owners) > 0 {
+			holders = "reserved by " + strings.Join(c.Owners, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("- %s (%s)", c.Path, holders))
+	}
+	body := fmt.Sprintf("Rebasing %s onto %s hit conflicts:\n%s\n\nThe task is back in review.",
+		outcome.Branch, q.base(), strings.Join(lines, "\n"))
+
+	recipients := make([]string, 0, len(byOwner))
+	for r := range byOwner {
+		recipients = append(recipients, r)
+	}
+	sort.Strings(recipients)
+	subject := fmt.Sprintf("Merge conflict: %s onto %s", outcome.TaskID, q.base())
+	if err := q.notify(outcome.TaskID, subject, body, recipients, ""); err != nil {
+		return nil, err
+	}
+	outcome.Notified = recipients
+	paths := make([]string, len(outcome.Conflicts))
+	for i, c := range outcome.Conflicts {
+		paths[i] = c.Path
+	}
+	return q.sendBack(outcome, storage.MergeConflict, "conflicts in "+strings.Join(paths, ", "))
+}
+
+// reject notifies the task's agent, attaching the test log if there is one,
+// and sends the task back to review.
+func (q *Queue) reject(outcome *Outcome, state, detail string) (*Outcome, error) {
+	owner := q.taskOwner(outcome.TaskID)
+	body := fmt.Sprintf("%s was not merged into %s: %s\n\nThe task is back in review.", outcome.Branch, q.base(), detail)
+	subject := fmt.Sprintf("Merge gate failed: %s", outcome.TaskID)
+	if err := q.notify(outcome.TaskID, subject, body, []string{owner}, outcome.LogPath); err != nil {
+		return nil, err
+	}
+	outcome.Notified = []string{owner}
+	return q.sendBack(outcome, state, detail)
+}
+
+func (q *Queue) sendBack(outcome *Outcome, state, detail string) (*Outcome, error) {
+	outcome.State, outcome.Detail = state, detail
+	if err := storage.ReturnToReview(q.DB, outcome.TaskID); err != nil {
+		return nil, err
+	}
+	if specPath, err := project.TaskSpecPath(q.Root, outcome.TaskID); err == nil {
+		if _, statErr := os.Stat(specPath); statErr == nil {
+			feedback := "Merge queue: " + detail
+			if outcome.LogPath != "" {
+				feedback += " (log: " + outcome.LogPath + ")"
+			}
+			_ = specs.AppendReviewFeedback(specPath, feedback)
+		}
+	}
+	return outcome, storage.FinishMerge(q.DB, outcome.TaskID, state, outcome.LogPath, detail)
+}
+
+// taskOwner is the agent that worked on the task, falling back to the
+// task's tmux session name.
+func (q *Queue) taskOwner(taskID string) string {
+	if s, err := storage.GetAgentSessionByTask(q.DB, taskID); err == nil && s.AgentName != "" {
+		return s.AgentName
+	}
+	return agent.SessionID(taskID)
+}
+
+func (q *Queue) notify(taskID, subject, body string, recipients []string, attachment string) error {
+	msg := storage.Message{
+		ID:          fmt.Sprintf("msg-%d", time.Now().UTC().UnixNano()),
+		ThreadID:    "merge-" + taskID,
+		Sender:      Sender,
+		Subject:     subject,
+		Body:        body,
+		Importance:  "high",
+		AckRequired: true,
+	}
+	if err := storage.SendMessage(q.DB, msg, recipients); err != nil {
+		return err
+	}
+	if attachment == "" {
+		return nil
+	}
+	return storage.AddAttachmentsWithStore(q.DB, project.AttachmentsDir(q.Root), msg.ID, []storage.Attachment{{
+		MessageID: msg.ID,
+		Path:      attachment,
+		Note:      filepath.Base(attachment),
+	}})
+}
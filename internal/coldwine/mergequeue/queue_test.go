@@ -0,0 +1,242 @@
+package mergequeue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/git"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+// fakeGit scripts git per directory: worktrees are on feature/<task>
+// unless listed in branches, rebases in listed worktrees conflict, and every
+// command is recorded.
+type fakeGit struct {
+	calls     []string
+	conflicts map[string][]string // worktree dir -> conflicting files
+	branches  map[string]string   // dir -> checked-out branch
+}
+
+func (f *fakeGit) runner(dir string) git.Runner { return &fakeDirRunner{git: f, dir: dir} }
+
+type fakeDirRunner struct {
+	git *fakeGit
+	dir string
+}
+
+func (r *fakeDirRunner) Run(name string, args ...string) (string, error) {
+	cmd := strings.Join(args, " ")
+	r.git.calls = append(r.git.calls, filepath.Base(r.dir)+": "+cmd)
+	files := r.git.conflicts[r.dir]
+	switch {
+	case cmd == "rev-parse --abbrev-ref HEAD":
+		if b, ok := r.git.branches[r.dir]; ok {
+			return b + "\n", nil
+		}
+		if filepath.Base(filepath.Dir(r.dir)) == "worktrees" {
+			return "feature/" + filepath.Base(r.dir) + "\n", nil
+		}
+		return "main\n", nil
+	case strings.HasPrefix(cmd, "rebase ") && cmd != "rebase --abort" && len(files) > 0:
+		return "CONFLICT", errors.New("exit status 1")
+	case cmd == "diff --name-only --diff-filter=U":
+		return strings.Join(files, "\n"), nil
+	}
+	return "", nil
+}
+
+func setup(t *testing.T, ids ...string) (*Queue, *fakeGit) {
+	t.Helper()
+	root := t.TempDir()
+	if err := project.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if err := os.MkdirAll(filepath.Join(project.WorktreesDir(root), id), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		_ = storage.InsertTask(db, storage.Task{ID: id, Title: id, Status: "review"})
+		if err := storage.QueueMerge(db, id, "feature/"+id); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond) // keep enqueue order stable
+	}
+	g := &fakeGit{conflicts: map[string][]string{}, branches: map[string]string{}}
+	return &Queue{DB: db, Root: root, Base: "main", Git: g.runner}, g
+}
+
+func inbox(t *testing.T, q *Queue, recipient string) []storage.MessageDelivery {
+	t.Helper()
+	msgs, err := storage.FetchInbox(q.DB, recipient, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msgs
+}
+
+func TestProcessAllLandsPassingBranchesInOrder(t *testing.T) {
+	q, g := setup(t, "TAND-001", "TAND-002")
+	var tested []string
+	q.TestCommand = "go test ./..."
+	q.Test = func(ctx context.Context, dir, command string) ([]byte, error) {
+		tested = append(tested, filepath.Base(dir))
+		return []byte("ok\n"), nil
+	}
+	outcomes, err := q.ProcessAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcomes) != 2 || outcomes[0].TaskID != "TAND-001" || outcomes[1].State != storage.MergeMerged {
+		t.Fatalf("unexpected outcomes %+v", outcomes)
+	}
+	if strings.Join(tested, ",") != "TAND-001,TAND-002" {
+		t.Fatalf("unexpected test order %v", tested)
+	}
+	joined := strings.Join(g.calls, "\n")
+	for _, want := range []string{"TAND-001: rebase main", "TAND-002: rebase main", "merge --ff-only feature/TAND-002"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("missing %q in calls:\n%s", want, joined)
+		}
+	}
+	task, _ := storage.GetTask(q.DB, "TAND-001")
+	if task.Status != "done" {
+		t.Fatalf("expected done, got %s", task.Status)
+	}
+}
+
+func TestProcessNextSendsFailingTestsBackToReview(t *testing.T) {
+	q, g := setup(t, "TAND-001")
+	q.TestCommand = "make test"
+	q.Test = func(ctx context.Context, dir, command string) ([]byte, error) {
+		return []byte("FAIL: TestStore\n"), errors.New("exit status 2")
+	}
+	outcome, err := q.ProcessNext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.State != storage.MergeFailed {
+		t.Fatalf("expected failed, got %+v", outcome)
+	}
+	for _, call := range g.calls {
+		if strings.Contains(call, "--ff-only") {
+			t.Fatal("failing branch was merged")
+		}
+	}
+	data, err := os.ReadFile(outcome.LogPath)
+	if err != nil || !strings.Contains(string(data), "FAIL: TestStore") {
+		t.Fatalf("expected test log, got %q %v", data, err)
+	}
+	if ids, _ := storage.ListReviewQueue(q.DB); len(ids) != 1 {
+		t.Fatalf("expected task back in review, got %v", ids)
+	}
+	msgs := inbox(t, q, "tand-TAND-001")
+	if len(msgs) != 1 || msgs[0].Message.Sender != Sender {
+		t.Fatalf("expected failure notice, got %+v", msgs)
+	}
+	atts, _ := storage.ListAttachments(q.DB, msgs[0].Message.ID)
+	if len(atts) != 1 || atts[0].Path != outcome.LogPath {
+		t.Fatalf("expected log attached, got %+v", atts)
+	}
+}
+
+func TestProcessNextNotifiesReservationOwnersOfConflicts(t *testing.T) {
+	q, g := setup(t, "TAND-001")
+	g.conflicts[filepath.Join(project.WorktreesDir(q.Root), "TAND-001")] = []string{"store.go", "README.md"}
+	if _, err := storage.ReservePaths(q.DB, "agent-store", []string{"store.go"}, true, "schema work", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	outcome, err := q.ProcessNext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.State != storage.MergeConflict || len(outcome.Conflicts) != 2 {
+		t.Fatalf("unexpected outcome %+v", outcome)
+	}
+	if got := outcome.Conflicts[0]; got.Path != "store.go" || len(got.Owners) != 1 || got.Owners[0] != "agent-store" {
+		t.Fatalf("unexpected conflict %+v", got)
+	}
+	if len(outcome.Conflicts[1].Owners) != 0 {
+		t.Fatalf("expected README.md unreserved, got %+v", outcome.Conflicts[1])
+	}
+	for _, recipient := range []string{"agent-store", "tand-TAND-001"} {
+		msgs := inbox(t, q, recipient)
+		if len(msgs) != 1 || !strings.Contains(msgs[0].Message.Body, "store.go (reserved by agent-store)") {
+			t.Fatalf("expected conflict notice for %s, got %+v", recipient, msgs)
+		}
+	}
+	entries, _ := storage.ListMergeQueue(q.DB)
+	if entries[0].State != storage.MergeConflict {
+		t.Fatalf("expected conflict state, got %+v", entries[0])
+	}
+}
+
+func TestProcessNextRejectsWorktreeOnAnotherBranch(t *testing.T) {
+	q, g := setup(t, "TAND-001")
+	g.branches[filepath.Join(project.WorktreesDir(q.Root), "TAND-001")] = "feature/other"
+	outcome, err := q.ProcessNext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.State != storage.MergeFailed || !strings.Contains(outcome.Detail, "feature/other") {
+		t.Fatalf("expected branch mismatch failure, got %+v", outcome)
+	}
+	for _, call := range g.calls {
+		if strings.Contains(call, "rebase") || strings.Contains(call, "--ff-only") {
+			t.Fatalf("mismatched branch was rebased or merged: %s", call)
+		}
+	}
+}
+
+func TestProcessNextEmptyQueue(t *testing.T) {
+	q, _ := setup(t)
+	outcome, err := q.ProcessNext(context.Background())
+	if err != nil || outcome != nil {
+		t.Fatalf("expected nil outcome, got %+v %v", outcome, err)
+	}
+}
+
+func TestProcessNextRequeuesAfterAnError(t *testing.T) {
+	q, _ := setup(t, "TAND-001")
+	q.TestCommand = "make test"
+	q.Test = func(ctx context.Context, dir, command string) ([]byte, error) { return nil, nil }
+	// The test log cannot be written where a file is in the way.
+	if err := os.WriteFile(project.MergeLogsDir(q.Root), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.ProcessNext(context.Background()); err == nil {
+		t.Fatal("expected the log error")
+	}
+	entries, _ := storage.ListMergeQueue(q.DB)
+	if len(entries) != 1 || entries[0].State != storage.MergeQueued {
+		t.Fatalf("expected the entry back in the queue, got %+v", entries)
+	}
+
+	// A run that died mid-attempt leaves its entry in testing
+	if err := storage.StartMerge(q.DB, "TAND-001"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := q.RequeueInterrupted(); err != nil || n != 1 {
+		t.Fatalf("expected one entry requeued, got %d %v", n, err)
+	}
+	if err := os.Remove(project.MergeLogsDir(q.Root)); err != nil {
+		t.Fatal(err)
+	}
+	outcome, err := q.ProcessNext(context.Background())
+	if err != nil || outcome == nil || outcome.State != storage.MergeMerged {
+		t.Fatalf("expected the retry to merge, got %+v %v", outcome, err)
+	}
+}
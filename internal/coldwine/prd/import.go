@@ -92,6 +92,7 @@ func importFromNewPRD(prd *praudeSpecs.PRD) (*ImportResult, error) {
 			AcceptanceCriteria: criteria,
 			Estimates:          mapComplexityToEstimate(feature.Complexity),
 			Stories:            stories,
+			SourcePRD:          prd.ID,
 		}
 
 		result.Epics = append(result.Epics, epic)
@@ -113,11 +114,12 @@ func importFromLegacySpec(spec praudeSpecs.Spec) (*ImportResult, error) {
 
 	// Create one epic from the spec
 	epic := epics.Epic{
-		ID:       "EPIC-001",
-		Title:    spec.Title,
-		Summary:  spec.Summary,
-		Status:   mapStatus(spec.Status),
-		Priority: mapPriority(spec.Priority),
+		ID:        "EPIC-001",
+		Title:     spec.Title,
+		Summary:   spec.Summary,
+		Status:    mapStatus(spec.Status),
+		Priority:  mapPriority(spec.Priority),
+		SourcePRD: spec.ID,
 	}
 
 	// Map acceptance criteria
@@ -67,6 +67,9 @@ priority: 1
 	if epic.Priority != "p1" {
 		t.Errorf("Expected priority 'p1', got %s", epic.Priority)
 	}
+	if epic.SourcePRD != "PRD-001" {
+		t.Errorf("Expected source PRD 'PRD-001', got %s", epic.SourcePRD)
+	}
 }
 
 func TestImportFromPRD_NotFound(t *testing.T) {
@@ -46,6 +46,15 @@ func WorktreesDir(root string) string {
 	return filepath.Join(root, ".tandemonium", "worktrees")
 }
 
+func MergeLogsDir(root string) string {
+	return filepath.Join(root, ".tandemonium", "merge")
+}
+
+// MailBusDir holds the sockets of live mail subscribers.
+func MailBusDir(root string) string {
+	return filepath.Join(root, ".tandemonium", "mailbus")
+}
+
 var taskIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
 
 func ValidateTaskID(id string) error {
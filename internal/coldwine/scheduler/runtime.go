@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/tmux"
+)
+
+// TmuxRuntime launches tasks into git worktrees and tmux sessions, the same
+// layout the TUI uses when a task is started by hand.
+type TmuxRuntime struct {
+	Root      string
+	Runner    tmux.Runner
+	Worktrees agent.WorktreeCreator
+	// Command returns the shell line that starts the agent inside a new
+	// session. Nil, or an empty line, leaves the session at a shell prompt.
+	Command func(l Launch) (string, error)
+}
+
+// NewTmuxRuntime returns a runtime backed by git and tmux that starts each
+// task's agent target in its session.
+func NewTmuxRuntime(root string) *TmuxRuntime {
+	return &TmuxRuntime{
+		Root:      root,
+		Runner:    &tmux.ExecRunner{},
+		Worktrees: &agent.GitWorktreeAdapter{},
+		Command:   AgentCommand(root),
+	}
+}
+
+func (r *TmuxRuntime) Launch(l Launch) error {
+	for _, dir := range []string{project.WorktreesDir(r.Root), project.SessionsDir(r.Root)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	worktree, err := project.SafePath(project.WorktreesDir(r.Root), l.TaskID)
+	if err != nil {
+		return err
+	}
+	logPath, err := project.SafePath(project.SessionsDir(r.Root), l.Session+".log")
+	if err != nil {
+		return err
+	}
+	starter := &agent.TmuxSessionAdapter{Runner: r.Runner}
+	if _, statErr := os.Stat(worktree); statErr == nil {
+		// An earlier launch created the worktree before it was interrupted.
+		err = starter.Start(l.Session, worktree, logPath)
+	} else {
+		err = agent.StartTask(r.Worktrees, starter, l.TaskID, r.Root, worktree, logPath)
+	}
+	if err != nil {
+		return err
+	}
+	if r.Command == nil {
+		return nil
+	}
+	line, err := r.Command(l)
+	if err != nil || line == "" {
+		return err
+	}
+	return tmux.SendLine(r.Runner, l.Session, line)
+}
+
+func (r *TmuxRuntime) Alive(sessionID string) bool {
+	return tmux.HasSession(r.Runner, sessionID)
+}
+
+func (r *TmuxRuntime) LogPath(sessionID string) string {
+	return filepath.Join(project.SessionsDir(r.Root), sessionID+".log")
+}
+
+// AgentCommand resolves each launch's target from the agent registry and
+// builds a command line that hands the agent its task.
+func AgentCommand(root string) func(l Launch) (string, error) {
+	return func(l Launch) (string, error) {
+		target, err := agent.ResolveTarget(root, l.Target)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(target.Command) == "" {
+			return "", fmt.Errorf("agent target %q has no command", l.Target)
+		}
+		specPath, err := project.TaskSpecPath(root, l.TaskID)
+		if err != nil {
+			return "", err
+		}
+		var words []string
+		keys := make([]string, 0, len(target.Env))
+		for k := range target.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			words = append(words, k+"="+tmux.ShellQuote(target.Env[k]))
+		}
+		words = append(words, tmux.ShellQuote(target.Command))
+		for _, arg := range target.Args {
+			words = append(words, tmux.ShellQuote(arg))
+		}
+		prompt := fmt.Sprintf("Implement task %s: %s. The task spec is at %s.", l.TaskID, l.Task.Title, specPath)
+		words = append(words, tmux.ShellQuote(prompt))
+		return strings.Join(words, " "), nil
+	}
+}
@@ -0,0 +1,726 @@
+// Package scheduler runs Coldwine tasks in dependency order. Each pass
+// reconciles checkpointed launches against live tmux sessions, applies
+// completion detected in session logs, and launches newly ready tasks up to a
+// per-target concurrency limit. A task finished by its agent unlocks its
+// dependents only once it has been approved and merged. Every launch is
+// checkpointed in the state DB before any side effect, so a restarted
+// scheduler adopts running sessions instead of launching duplicates.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/accounting"
+	"github.com/mistakeknot/autarch/internal/coldwine/agent"
+	"github.com/mistakeknot/autarch/internal/coldwine/project"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/mistakeknot/autarch/internal/coldwine/tasks"
+	"github.com/mistakeknot/autarch/internal/coldwine/tmux"
+	"gopkg.in/yaml.v3"
+)
+
+// Event kinds reported by Step.
+const (
+	EventLaunched   = "launched"
+	EventAdopted    = "adopted"
+	EventRelaunched = "relaunched"
+	EventFinished   = "finished"
+	EventFailed     = "failed"
+	EventUnlocked   = "unlocked"
+	EventPaused     = "paused"
+)
+
+// Runtime starts and observes agent sessions.
+type Runtime interface {
+	// Launch creates the task worktree if needed and starts its session.
+	Launch(l Launch) error
+	Alive(sessionID string) bool
+	LogPath(sessionID string) string
+}
+
+// Launch describes one task start.
+type Launch struct {
+	Task    tasks.TaskProposal `json:"-"`
+	TaskID  string             `json:"task_id"`
+	Target  string             `json:"target"`
+	Session string             `json:"session"`
+}
+
+// Options configures target selection, concurrency and spend.
+type Options struct {
+	DefaultTarget string
+	Limits        map[string]int // per target; missing targets use DefaultLimit
+	DefaultLimit  int
+	Prices        map[string]accounting.Price  // prices models whose agent reports no cost
+	Models        map[string]string            // per target; see accounting.Meter
+	IdleGap       time.Duration                // see accounting.Meter
+	Budgets       map[string]accounting.Budget // per epic; no launches in an epic over budget
+}
+
+// Event is one scheduler transition.
+type Event struct {
+	Kind    string `json:"kind"`
+	TaskID  string `json:"task_id"`
+	Target  string `json:"target,omitempty"`
+	Session string `json:"session,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+	Epic    string `json:"epic,omitempty"`
+}
+
+// Result summarizes one Step.
+type Result struct {
+	Events  []Event  `json:"events"`
+	Active  int      `json:"active"`
+	Waiting []string `json:"waiting,omitempty"` // open tasks that cannot start yet
+	Failed  []string `json:"failed,omitempty"`
+	Paused  []string `json:"paused,omitempty"`  // epics over budget
+	Landing []string `json:"landing,omitempty"` // finished, awaiting review or merge, with tasks waiting on them
+	Done    bool     `json:"done"`              // nothing running, landing or left to launch
+}
+
+// Scheduler walks the task DAG. It is not safe for concurrent use.
+type Scheduler struct {
+	db         *sql.DB
+	runtime    Runtime
+	opts       Options
+	tasks      []tasks.TaskProposal
+	byID       map[string]tasks.TaskProposal
+	dependents map[string][]string
+	meter      *accounting.Meter
+	paused     map[string]bool // epics already reported over budget
+	complete   map[string]bool // tasks seen complete; nil before the first Step
+
+	// OnEvent, if set, is called for each event as it happens.
+	OnEvent func(Event)
+}
+
+// New validates the task list and returns a scheduler over it. Tasks are
+// launched in priority order, then by ID.
+func New(db *sql.DB, list []tasks.TaskProposal, rt Runtime, opts Options) (*Scheduler, error) {
+	byID := make(map[string]tasks.TaskProposal, len(list))
+	for _, t := range list {
+		if err := project.ValidateTaskID(t.ID); err != nil {
+			return nil, err
+		}
+		if _, dup := byID[t.ID]; dup {
+			return nil, fmt.Errorf("duplicate task id: %s", t.ID)
+		}
+		byID[t.ID] = t
+	}
+	if cycle := findCycle(list, byID); len(cycle) > 0 {
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	sorted := append([]tasks.TaskProposal(nil), list...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := priorityKey(sorted[i]), priorityKey(sorted[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return &Scheduler{
+		db:         db,
+		runtime:    rt,
+		opts:       opts,
+		tasks:      sorted,
+		byID:       byID,
+		dependents: tasks.BuildDependencyGraph(list),
+		meter:      &accounting.Meter{DB: db, Prices: opts.Prices, Models: opts.Models, IdleGap: opts.IdleGap},
+		paused:     map[string]bool{},
+	}, nil
+}
+
+func priorityKey(t tasks.TaskProposal) string {
+	if t.Priority == "" {
+		return "p9"
+	}
+	return string(t.Priority)
+}
+
+func findCycle(list []tasks.TaskProposal, byID map[string]tasks.TaskProposal) []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	mark := make(map[string]int, len(list))
+	var stack []string
+	var cycle []string
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		switch mark[id] {
+		case visiting:
+			for i, s := range stack {
+				if s == id {
+					cycle = append(append([]string(nil), stack[i:]...), id)
+				}
+			}
+			return true
+		case visited:
+			return false
+		}
+		mark[id] = visiting
+		stack = append(stack, id)
+		for _, dep := range byID[id].Dependencies {
+			if _, ok := byID[dep]; ok && visit(dep) {
+				return true
+			}
+		}
+		stack = stack[:len(stack)-1]
+		mark[id] = visited
+		return false
+	}
+	for _, t := range list {
+		if visit(t.ID) {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// Target returns the agent target a task runs on.
+func (s *Scheduler) Target(t tasks.TaskProposal) string {
+	if t.Agent != "" {
+		return t.Agent
+	}
+	return s.opts.DefaultTarget
+}
+
+func (s *Scheduler) limit(target string) int {
+	if n := s.opts.Limits[target]; n > 0 {
+		return n
+	}
+	if s.opts.DefaultLimit > 0 {
+		return s.opts.DefaultLimit
+	}
+	return 1
+}
+
+// IsComplete reports whether a task status satisfies its dependents: the
+// task's work has merged. A direct approval, or the merge queue landing a
+// queued one, marks the task done; "approved" only means queued for merge.
+// A task the agent marked done is still in review, which loadStatuses
+// reports as "review".
+func IsComplete(status string) bool {
+	switch status {
+	case "done", "merged", "closed":
+		return true
+	}
+	return false
+}
+
+// isFinished reports whether the agent's part of a task is over, whether or
+// not its work has merged yet.
+func isFinished(status string) bool {
+	switch status {
+	case "review", "approved":
+		return true
+	}
+	return IsComplete(status)
+}
+
+// isOpen reports whether a task status allows the scheduler to start it.
+func isOpen(status string) bool {
+	switch status {
+	case "", "todo", "ready", "open", "assigned":
+		return true
+	}
+	return false
+}
+
+// Retry clears failed checkpoints so the tasks can be launched again. With
+// no IDs every failed task is cleared. It returns the cleared IDs.
+func (s *Scheduler) Retry(ids ...string) ([]string, error) {
+	checkpoints, err := storage.ListSchedulerTasks(s.db)
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	var cleared []string
+	for _, t := range s.tasks {
+		cp, ok := checkpoints[t.ID]
+		if !ok || cp.State != storage.SchedulerFailed || (len(ids) > 0 && !want[t.ID]) {
+			continue
+		}
+		if err := storage.DeleteSchedulerTask(s.db, t.ID); err != nil {
+			return cleared, err
+		}
+		// A task the agent reported blocked goes back to todo.
+		if status, err := storage.GetTask(s.db, t.ID); err == nil && !isOpen(status.Status) && !isFinished(status.Status) {
+			if err := storage.UpdateTaskStatus(s.db, t.ID, "todo"); err != nil {
+				return cleared, err
+			}
+		}
+		cleared = append(cleared, t.ID)
+	}
+	return cleared, nil
+}
+
+// Plan returns the launches the next Step would make, ignoring any sessions
+// that Step would first reconcile. It has no side effects.
+func (s *Scheduler) Plan() ([]Launch, error) {
+	statuses, err := s.loadStatuses(false)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints, err := storage.ListSchedulerTasks(s.db)
+	if err != nil {
+		return nil, err
+	}
+	over, err := s.overBudget()
+	if err != nil {
+		return nil, err
+	}
+	launches, _ := s.selectLaunches(statuses, checkpoints, over)
+	return launches, nil
+}
+
+// Step runs one scheduling pass.
+func (s *Scheduler) Step(ctx context.Context) (Result, error) {
+	var res Result
+	statuses, err := s.loadStatuses(true)
+	if err != nil {
+		return res, err
+	}
+	checkpoints, err := storage.ListSchedulerTasks(s.db)
+	if err != nil {
+		return res, err
+	}
+
+	for _, t := range s.tasks {
+		cp, ok := checkpoints[t.ID]
+		if !ok || !cp.Active() {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		next, ev, err := s.reconcile(t, cp, statuses)
+		if err != nil {
+			return res, err
+		}
+		checkpoints[t.ID] = next
+		if ev != nil {
+			s.emit(&res, *ev)
+		}
+	}
+	s.noteComplete(&res, statuses, checkpoints)
+
+	over, err := s.overBudget()
+	if err != nil {
+		return res, err
+	}
+	for _, st := range over {
+		res.Paused = append(res.Paused, st.EpicID)
+		if !s.paused[st.EpicID] {
+			s.paused[st.EpicID] = true
+			s.emit(&res, Event{Kind: EventPaused, Epic: st.EpicID, Detail: "over budget: " + st.String()})
+		}
+	}
+	launches, waiting := s.selectLaunches(statuses, checkpoints, over)
+	for _, l := range launches {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		cp, ev, err := s.launch(l, checkpoints[l.TaskID].Attempts)
+		if err != nil {
+			return res, err
+		}
+		checkpoints[l.TaskID] = cp
+		s.emit(&res, ev)
+	}
+
+	for _, t := range s.tasks {
+		switch cp := checkpoints[t.ID]; {
+		case cp.Active():
+			res.Active++
+		case cp.State == storage.SchedulerFailed:
+			res.Failed = append(res.Failed, t.ID)
+		}
+	}
+	res.Waiting = waiting
+	res.Landing = s.landing(statuses, waiting)
+	res.Done = res.Active == 0 && len(res.Landing) == 0
+	return res, nil
+}
+
+// landing returns the finished but unmerged tasks that waiting tasks depend
+// on; the run is not over while they can still land.
+func (s *Scheduler) landing(statuses map[string]string, waiting []string) []string {
+	blocking := map[string]bool{}
+	for _, id := range waiting {
+		for _, dep := range s.byID[id].Dependencies {
+			if st, ok := statuses[dep]; ok && isFinished(st) && !IsComplete(st) {
+				blocking[dep] = true
+			}
+		}
+	}
+	var out []string
+	for _, t := range s.tasks {
+		if blocking[t.ID] {
+			out = append(out, t.ID)
+		}
+	}
+	return out
+}
+
+// Run steps until no task is running and none can start, or ctx is done.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) (Result, error) {
+	var all Result
+	for {
+		res, err := s.Step(ctx)
+		all.Events = append(all.Events, res.Events...)
+		all.Active, all.Waiting, all.Failed, all.Paused, all.Landing, all.Done = res.Active, res.Waiting, res.Failed, res.Paused, res.Landing, res.Done
+		if err != nil || res.Done {
+			return all, err
+		}
+		select {
+		case <-ctx.Done():
+			return all, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (s *Scheduler) emit(res *Result, ev Event) {
+	res.Events = append(res.Events, ev)
+	if s.OnEvent != nil {
+		s.OnEvent(ev)
+	}
+}
+
+// loadStatuses reads task statuses from the tasks table, reporting done
+// tasks still in the review queue as review. With insert set, tasks missing
+// from the table are added as todo.
+func (s *Scheduler) loadStatuses(insert bool) (map[string]string, error) {
+	queue, err := storage.ListReviewQueue(s.db)
+	if err != nil {
+		return nil, err
+	}
+	inReview := make(map[string]bool, len(queue))
+	for _, id := range queue {
+		inReview[id] = true
+	}
+	statuses := make(map[string]string, len(s.tasks))
+	for _, t := range s.tasks {
+		row, err := storage.GetTask(s.db, t.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			statuses[t.ID] = "todo"
+			if !insert {
+				continue
+			}
+			title := t.Title
+			if title == "" {
+				title = t.ID
+			}
+			if err := storage.InsertTask(s.db, storage.Task{ID: t.ID, Title: title, Status: "todo"}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		statuses[t.ID] = row.Status
+		if row.Status == "done" && inReview[t.ID] {
+			statuses[t.ID] = "review"
+		}
+	}
+	return statuses, nil
+}
+
+// overBudget returns the budgeted epics whose spend has reached their
+// budget, keyed by epic ID.
+func (s *Scheduler) overBudget() (map[string]accounting.BudgetStatus, error) {
+	if len(s.opts.Budgets) == 0 {
+		return nil, nil
+	}
+	report, err := accounting.Load(s.db, s.tasks, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	over := map[string]accounting.BudgetStatus{}
+	for _, st := range accounting.CheckBudgets(report, s.opts.Budgets) {
+		if st.Exceeded {
+			over[st.EpicID] = st
+		}
+	}
+	return over, nil
+}
+
+// selectLaunches returns ready tasks that fit under their target's limit,
+// and the IDs of open tasks still waiting on dependencies, capacity or
+// budget. Running tasks in an epic over budget are left to finish.
+func (s *Scheduler) selectLaunches(statuses map[string]string, checkpoints map[string]storage.SchedulerTask, over map[string]accounting.BudgetStatus) ([]Launch, []string) {
+	active := make(map[string]int)
+	for _, cp := range checkpoints {
+		if cp.Active() {
+			active[cp.Target]++
+		}
+	}
+	var launches []Launch
+	var waiting []string
+	for _, t := range s.tasks {
+		if !isOpen(statuses[t.ID]) {
+			continue
+		}
+		// Failed tasks stay put until their checkpoint is cleared.
+		if cp, ok := checkpoints[t.ID]; ok && (cp.Active() || cp.State == storage.SchedulerFailed) {
+			continue
+		}
+		target := s.Target(t)
+		_, paused := over[t.EpicID]
+		if paused || !s.depsComplete(t, statuses) || active[target] >= s.limit(target) {
+			waiting = append(waiting, t.ID)
+			continue
+		}
+		active[target]++
+		launches = append(launches, Launch{Task: t, TaskID: t.ID, Target: target, Session: agent.SessionID(t.ID)})
+	}
+	return launches, waiting
+}
+
+// depsComplete reports whether every dependency of t is complete. Unknown
+// dependencies never complete; cross-epic placeholders are ignored.
+func (s *Scheduler) depsComplete(t tasks.TaskProposal, statuses map[string]string) bool {
+	for _, dep := range t.Dependencies {
+		if strings.Contains(dep, ":*") {
+			continue
+		}
+		if _, ok := s.byID[dep]; !ok || !IsComplete(statuses[dep]) {
+			return false
+		}
+	}
+	return true
+}
+
+// noteComplete reports dependents unlocked by tasks that completed since the
+// last Step. The first Step only records what is already complete.
+func (s *Scheduler) noteComplete(res *Result, statuses map[string]string, checkpoints map[string]storage.SchedulerTask) {
+	first := s.complete == nil
+	if first {
+		s.complete = make(map[string]bool)
+	}
+	for _, t := range s.tasks {
+		if !IsComplete(statuses[t.ID]) || s.complete[t.ID] {
+			continue
+		}
+		s.complete[t.ID] = true
+		if !first {
+			s.unlock(res, t.ID, statuses, checkpoints)
+		}
+	}
+}
+
+func (s *Scheduler) unlock(res *Result, id string, statuses map[string]string, checkpoints map[string]storage.SchedulerTask) {
+	for _, depID := range s.dependents[id] {
+		t, ok := s.byID[depID]
+		if !ok || !isOpen(statuses[depID]) || !s.depsComplete(t, statuses) {
+			continue
+		}
+		if cp, ok := checkpoints[depID]; ok && cp.Active() {
+			continue
+		}
+		s.emit(res, Event{Kind: EventUnlocked, TaskID: depID, Detail: "after " + id})
+	}
+}
+
+// launch checkpoints the task as launching, then starts it. A session that
+// already exists under the task's ID is adopted rather than started again.
+func (s *Scheduler) launch(l Launch, attempts int) (storage.SchedulerTask, Event, error) {
+	cp := storage.SchedulerTask{TaskID: l.TaskID, Target: l.Target, SessionID: l.Session, State: storage.SchedulerLaunching, Attempts: attempts + 1}
+	if err := storage.UpsertSchedulerTask(s.db, cp); err != nil {
+		return cp, Event{}, err
+	}
+	ev := Event{Kind: EventLaunched, TaskID: l.TaskID, Target: l.Target, Session: l.Session}
+	if s.runtime.Alive(l.Session) {
+		ev.Kind = EventAdopted
+	} else if err := s.runtime.Launch(l); err != nil {
+		return s.fail(cp, Event{Kind: EventFailed, TaskID: l.TaskID, Target: l.Target, Session: l.Session, Detail: err.Error()})
+	}
+	return s.markRunning(cp, ev)
+}
+
+func (s *Scheduler) markRunning(cp storage.SchedulerTask, ev Event) (storage.SchedulerTask, Event, error) {
+	if err := storage.UpdateTaskStatus(s.db, cp.TaskID, "in_progress"); err != nil {
+		return cp, ev, err
+	}
+	if _, err := storage.GetSession(s.db, cp.SessionID); errors.Is(err, sql.ErrNoRows) {
+		if err := storage.InsertSession(s.db, storage.Session{ID: cp.SessionID, TaskID: cp.TaskID, State: "working"}); err != nil {
+			return cp, ev, err
+		}
+	} else if err != nil {
+		return cp, ev, err
+	} else if err := storage.UpdateSessionState(s.db, cp.SessionID, "working"); err != nil {
+		return cp, ev, err
+	}
+	cp.State, cp.Detail = storage.SchedulerRunning, ""
+	return cp, ev, storage.UpdateSchedulerTaskState(s.db, cp.TaskID, cp.State, cp.Detail)
+}
+
+func (s *Scheduler) fail(cp storage.SchedulerTask, ev Event) (storage.SchedulerTask, Event, error) {
+	cp.State, cp.Detail = storage.SchedulerFailed, ev.Detail
+	if err := storage.EndTaskUsage(s.db, cp.TaskID, time.Time{}); err != nil {
+		return cp, ev, err
+	}
+	return cp, ev, storage.UpdateSchedulerTaskState(s.db, cp.TaskID, cp.State, cp.Detail)
+}
+
+// reconcile brings an active checkpoint up to date with the task status, the
+// session log and tmux.
+func (s *Scheduler) reconcile(t tasks.TaskProposal, cp storage.SchedulerTask, statuses map[string]string) (storage.SchedulerTask, *Event, error) {
+	ev := Event{TaskID: t.ID, Target: cp.Target, Session: cp.SessionID}
+	if cp.State == storage.SchedulerRunning {
+		det, err := s.pollLog(t.ID, cp)
+		if err != nil {
+			return cp, nil, err
+		}
+		if det != nil {
+			statuses[t.ID] = det.State
+			if det.State == agent.StateDone {
+				statuses[t.ID] = "review"
+			}
+			ev.Detail = describeDetection(*det)
+		}
+	}
+	status := statuses[t.ID]
+	switch {
+	case isFinished(status):
+		cp.State = storage.SchedulerFinished
+		if ev.Detail == "" {
+			ev.Detail = "status " + status
+		}
+		ev.Kind, cp.Detail = EventFinished, ev.Detail
+		if err := storage.EndTaskUsage(s.db, cp.TaskID, time.Time{}); err != nil {
+			return cp, nil, err
+		}
+		return cp, &ev, storage.UpdateSchedulerTaskState(s.db, cp.TaskID, cp.State, cp.Detail)
+	case status == "blocked":
+		if ev.Detail == "" {
+			ev.Detail = "status blocked"
+		}
+		ev.Kind = EventFailed
+		next, ev, err := s.fail(cp, ev)
+		return next, &ev, err
+	}
+
+	alive := s.runtime.Alive(cp.SessionID)
+	if cp.State == storage.SchedulerLaunching {
+		// The previous run stopped mid-launch: adopt the session if it came
+		// up, otherwise finish the launch under the same session ID.
+		ev.Kind = EventAdopted
+		if !alive {
+			ev.Kind = EventRelaunched
+			l := Launch{Task: t, TaskID: t.ID, Target: cp.Target, Session: cp.SessionID}
+			if err := s.runtime.Launch(l); err != nil {
+				ev.Kind, ev.Detail = EventFailed, err.Error()
+				next, ev, err := s.fail(cp, ev)
+				return next, &ev, err
+			}
+		}
+		next, ev, err := s.markRunning(cp, ev)
+		return next, &ev, err
+	}
+	if !alive {
+		ev.Kind, ev.Detail = EventFailed, "session exited before the task completed"
+		next, ev, err := s.fail(cp, ev)
+		return next, &ev, err
+	}
+	return cp, nil, nil
+}
+
+// pollLog records usage from new session log lines, then runs the target's
+// detectors over them and applies an actionable done or blocked detection,
+// returning it.
+func (s *Scheduler) pollLog(taskID string, cp storage.SchedulerTask) (*agent.Detection, error) {
+	sess, err := storage.GetSession(s.db, cp.SessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines, offset, err := tmux.ReadFromOffset(s.runtime.LogPath(cp.SessionID), sess.Offset)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := storage.UpdateSessionOffset(s.db, cp.SessionID, offset); err != nil {
+		return nil, err
+	}
+	if err := s.meter.Sample(taskID, cp.Target, lines); err != nil {
+		return nil, err
+	}
+	det := agent.Detect(cp.Target, lines)
+	if !det.Actionable() {
+		return nil, nil
+	}
+	if err := storage.ApplyDetectionAtomic(s.db, taskID, cp.SessionID, det.State); err != nil {
+		return nil, err
+	}
+	if det.State == agent.StateDone {
+		_ = storage.AddToReviewQueue(s.db, taskID)
+	}
+	return &det, nil
+}
+
+func describeDetection(det agent.Detection) string {
+	out := fmt.Sprintf("%s by %s (%.2f)", det.State, det.Detector, det.Confidence)
+	if len(det.Evidence) > 0 {
+		out += ": " + det.Evidence[len(det.Evidence)-1]
+	}
+	return out
+}
+
+// taskSpec is a task file in the specs directory. Epic files share the
+// directory and are recognised by their stories list or EPIC- prefix.
+type taskSpec struct {
+	tasks.TaskProposal `yaml:",inline"`
+	Stories            []yaml.Node `yaml:"stories"`
+}
+
+// LoadTasks reads task specs from dir, skipping epics, and resolves
+// cross-epic dependency placeholders.
+func LoadTasks(dir string) ([]tasks.TaskProposal, []string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	var list []tasks.TaskProposal
+	var warnings []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			warnings = append(warnings, "read failed: "+path)
+			continue
+		}
+		var spec taskSpec
+		if err := yaml.Unmarshal(raw, &spec); err != nil {
+			warnings = append(warnings, "parse failed: "+path)
+			continue
+		}
+		if spec.ID == "" || len(spec.Stories) > 0 || strings.HasPrefix(spec.ID, "EPIC-") {
+			continue
+		}
+		list = append(list, spec.TaskProposal)
+	}
+	tasks.ResolveCrossEpicDependencies(list)
+	return list, warnings
+}
@@ -0,0 +1,377 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/accounting"
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/mistakeknot/autarch/internal/coldwine/tasks"
+)
+
+type fakeRuntime struct {
+	dir      string
+	alive    map[string]bool
+	launched []string
+	failOn   map[string]bool
+}
+
+func newFakeRuntime(t *testing.T) *fakeRuntime {
+	return &fakeRuntime{dir: t.TempDir(), alive: map[string]bool{}, failOn: map[string]bool{}}
+}
+
+func (f *fakeRuntime) Launch(l Launch) error {
+	if f.failOn[l.TaskID] {
+		return errors.New("worktree exists")
+	}
+	f.launched = append(f.launched, l.TaskID)
+	f.alive[l.Session] = true
+	return nil
+}
+
+func (f *fakeRuntime) Alive(id string) bool { return f.alive[id] }
+
+func (f *fakeRuntime) LogPath(id string) string { return filepath.Join(f.dir, id+".log") }
+
+func (f *fakeRuntime) log(t *testing.T, id, line string) {
+	t.Helper()
+	fh, err := os.OpenFile(f.LogPath(id), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	if _, err := fh.WriteString(line + "\n"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestScheduler(t *testing.T, rt Runtime, list []tasks.TaskProposal, opts Options) *Scheduler {
+	t.Helper()
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(db, list, rt, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func step(t *testing.T, s *Scheduler) Result {
+	t.Helper()
+	res, err := s.Step(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func kinds(res Result) []string {
+	var out []string
+	for _, ev := range res.Events {
+		out = append(out, ev.Kind+":"+ev.TaskID)
+	}
+	return out
+}
+
+var diamond = []tasks.TaskProposal{
+	{ID: "TAND-001", Title: "Schema"},
+	{ID: "TAND-002", Title: "API", Dependencies: []string{"TAND-001"}},
+	{ID: "TAND-003", Title: "CLI", Dependencies: []string{"TAND-001"}, Agent: "codex"},
+	{ID: "TAND-004", Title: "Docs", Dependencies: []string{"TAND-002", "TAND-003"}},
+}
+
+func TestStepWalksDAGAndUnlocksDependents(t *testing.T) {
+	rt := newFakeRuntime(t)
+	s := newTestScheduler(t, rt, diamond, Options{DefaultTarget: "claude", DefaultLimit: 2})
+
+	res := step(t, s)
+	if got := kinds(res); !reflect.DeepEqual(got, []string{"launched:TAND-001"}) {
+		t.Fatalf("first pass: %v", got)
+	}
+	if res.Active != 1 || len(res.Waiting) != 3 || res.Done {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	rt.log(t, "tand-TAND-001", "schema is not done yet")
+	if res = step(t, s); len(res.Events) != 0 {
+		t.Fatalf("incidental keyword finished the task: %v", kinds(res))
+	}
+	rt.log(t, "tand-TAND-001", "COLDWINE:STATE done")
+	res = step(t, s)
+	if got := kinds(res); !reflect.DeepEqual(got, []string{"finished:TAND-001"}) {
+		t.Fatalf("second pass: dependents must wait for review, got %v", got)
+	}
+	if !strings.HasPrefix(res.Events[0].Detail, "done by marker") {
+		t.Fatalf("expected detection detail, got %q", res.Events[0].Detail)
+	}
+	if res.Done || !reflect.DeepEqual(res.Landing, []string{"TAND-001"}) {
+		t.Fatalf("expected the run to wait for TAND-001 to land, got %+v", res)
+	}
+	queue, _ := storage.ListReviewQueue(s.db)
+	if !reflect.DeepEqual(queue, []string{"TAND-001"}) {
+		t.Fatalf("expected TAND-001 queued for review, got %v", queue)
+	}
+	if res = step(t, s); len(res.Events) != 0 {
+		t.Fatalf("unmerged dependency unlocked dependents: %v", kinds(res))
+	}
+
+	// Approval merges the branch and completes the task.
+	if err := storage.ApproveTask(s.db, "TAND-001"); err != nil {
+		t.Fatal(err)
+	}
+	res = step(t, s)
+	want := []string{"unlocked:TAND-002", "unlocked:TAND-003", "launched:TAND-002", "launched:TAND-003"}
+	if got := kinds(res); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after approval: got %v, want %v", got, want)
+	}
+	if res.Events[3].Target != "codex" {
+		t.Fatalf("expected TAND-003 on codex, got %q", res.Events[4].Target)
+	}
+	task, err := storage.GetTask(s.db, "TAND-002")
+	if err != nil || task.Status != "in_progress" {
+		t.Fatalf("expected TAND-002 in_progress, got %+v %v", task, err)
+	}
+
+	for _, id := range []string{"TAND-002", "TAND-003"} {
+		rt.log(t, "tand-"+id, "COLDWINE:STATE done")
+	}
+	step(t, s)
+	for _, id := range []string{"TAND-002", "TAND-003"} {
+		if err := storage.ApproveTask(s.db, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res = step(t, s)
+	if got := kinds(res); got[len(got)-1] != "launched:TAND-004" {
+		t.Fatalf("third pass: %v", got)
+	}
+	rt.log(t, "tand-TAND-004", "COLDWINE:STATE done")
+	res = step(t, s)
+	if !res.Done || res.Active != 0 || len(res.Waiting) != 0 {
+		t.Fatalf("expected run complete, got %+v", res)
+	}
+	if len(rt.launched) != 4 {
+		t.Fatalf("expected 4 launches, got %v", rt.launched)
+	}
+}
+
+func TestQueuedApprovalWaitsForMerge(t *testing.T) {
+	rt := newFakeRuntime(t)
+	list := []tasks.TaskProposal{
+		{ID: "TAND-001"},
+		{ID: "TAND-002", Dependencies: []string{"TAND-001"}},
+	}
+	s := newTestScheduler(t, rt, list, Options{DefaultTarget: "claude"})
+	step(t, s)
+	rt.log(t, "tand-TAND-001", "COLDWINE:STATE done")
+	step(t, s)
+
+	// coldwine approve with merge.queue: approved, but not merged yet.
+	if err := storage.QueueMerge(s.db, "TAND-001", "feature/TAND-001"); err != nil {
+		t.Fatal(err)
+	}
+	res := step(t, s)
+	if len(res.Events) != 0 || res.Done || !reflect.DeepEqual(res.Landing, []string{"TAND-001"}) {
+		t.Fatalf("queued approval unlocked dependents: %v %+v", kinds(res), res)
+	}
+
+	// The merge queue lands it.
+	if err := storage.ApproveTask(s.db, "TAND-001"); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.FinishMerge(s.db, "TAND-001", storage.MergeMerged, "", "merged into main"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"unlocked:TAND-002", "launched:TAND-002"}
+	if got := kinds(step(t, s)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after merge: got %v, want %v", got, want)
+	}
+}
+
+func TestStepRespectsPerTargetLimits(t *testing.T) {
+	rt := newFakeRuntime(t)
+	list := []tasks.TaskProposal{
+		{ID: "TAND-001"}, {ID: "TAND-002"}, {ID: "TAND-003"},
+		{ID: "TAND-004", Agent: "codex"},
+	}
+	s := newTestScheduler(t, rt, list, Options{DefaultTarget: "claude", Limits: map[string]int{"claude": 2}, DefaultLimit: 4})
+	res := step(t, s)
+	if !reflect.DeepEqual(rt.launched, []string{"TAND-001", "TAND-002", "TAND-004"}) {
+		t.Fatalf("unexpected launches %v", rt.launched)
+	}
+	if !reflect.DeepEqual(res.Waiting, []string{"TAND-003"}) {
+		t.Fatalf("expected TAND-003 waiting on capacity, got %v", res.Waiting)
+	}
+}
+
+func TestStepRecordsUsageAndPausesEpicOverBudget(t *testing.T) {
+	rt := newFakeRuntime(t)
+	list := []tasks.TaskProposal{
+		{ID: "TAND-001", EpicID: "EPIC-001"},
+		{ID: "TAND-002", EpicID: "EPIC-001"},
+		{ID: "TAND-003", EpicID: "EPIC-002"},
+	}
+	s := newTestScheduler(t, rt, list, Options{
+		DefaultTarget: "claude",
+		DefaultLimit:  1,
+		Budgets:       map[string]accounting.Budget{"EPIC-001": {USD: 0.5}},
+	})
+	step(t, s)
+	rt.log(t, "tand-TAND-001", `{"type":"assistant","message":{"id":"m1","model":"claude-sonnet-4","usage":{"input_tokens":100,"output_tokens":40}}}`)
+	rt.log(t, "tand-TAND-001", `{"type":"result","total_cost_usd":0.75}`)
+	rt.log(t, "tand-TAND-001", "COLDWINE:STATE done")
+
+	res := step(t, s)
+	want := []string{"finished:TAND-001", "paused:", "launched:TAND-003"}
+	if got := kinds(res); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if res.Events[1].Epic != "EPIC-001" || !reflect.DeepEqual(res.Paused, []string{"EPIC-001"}) {
+		t.Fatalf("expected EPIC-001 paused, got %+v", res)
+	}
+	if !reflect.DeepEqual(res.Waiting, []string{"TAND-002"}) {
+		t.Fatalf("expected TAND-002 held back, got %v", res.Waiting)
+	}
+	usage, err := storage.GetTaskUsage(s.db, "TAND-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.InputTokens != 100 || usage.OutputTokens != 40 || usage.CostUSD != 0.75 || usage.Model != "claude-sonnet-4" || usage.EndedAt.IsZero() {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+
+	// The pause is reported once.
+	rt.log(t, "tand-TAND-003", "COLDWINE:STATE done")
+	res = step(t, s)
+	if got := kinds(res); !reflect.DeepEqual(got, []string{"finished:TAND-003"}) {
+		t.Fatalf("third pass: %v", got)
+	}
+	if !res.Done || len(res.Paused) != 1 {
+		t.Fatalf("expected paused run to settle, got %+v", res)
+	}
+}
+
+func TestStepResumesWithoutDuplicateLaunches(t *testing.T) {
+	rt := newFakeRuntime(t)
+	list := []tasks.TaskProposal{{ID: "TAND-001"}, {ID: "TAND-002"}, {ID: "TAND-003"}}
+	s := newTestScheduler(t, rt, list, Options{DefaultTarget: "claude", DefaultLimit: 3})
+
+	// TAND-001 is running from an earlier scheduler.
+	step(t, s)
+	rt.launched = nil
+	// TAND-002 crashed after its checkpoint but before tmux came up;
+	// TAND-003 crashed after the session started.
+	for _, cp := range []storage.SchedulerTask{
+		{TaskID: "TAND-002", Target: "claude", SessionID: "tand-TAND-002", State: storage.SchedulerLaunching, Attempts: 1},
+		{TaskID: "TAND-003", Target: "claude", SessionID: "tand-TAND-003", State: storage.SchedulerLaunching, Attempts: 1},
+	} {
+		if err := storage.UpsertSchedulerTask(s.db, cp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	delete(rt.alive, "tand-TAND-002")
+	rt.alive["tand-TAND-003"] = true
+
+	resumed, err := New(s.db, list, rt, s.opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := step(t, resumed)
+	want := []string{"relaunched:TAND-002", "adopted:TAND-003"}
+	if got := kinds(res); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(rt.launched, []string{"TAND-002"}) {
+		t.Fatalf("expected only TAND-002 relaunched, got %v", rt.launched)
+	}
+	if res.Active != 3 {
+		t.Fatalf("expected 3 active, got %d", res.Active)
+	}
+}
+
+func TestStepFailsDeadSessionsAndRetry(t *testing.T) {
+	rt := newFakeRuntime(t)
+	list := []tasks.TaskProposal{
+		{ID: "TAND-001"},
+		{ID: "TAND-002", Dependencies: []string{"TAND-001"}},
+	}
+	s := newTestScheduler(t, rt, list, Options{DefaultTarget: "claude"})
+	step(t, s)
+	delete(rt.alive, "tand-TAND-001")
+
+	res := step(t, s)
+	if got := kinds(res); !reflect.DeepEqual(got, []string{"failed:TAND-001"}) {
+		t.Fatalf("expected failure, got %v", got)
+	}
+	if !res.Done || !reflect.DeepEqual(res.Failed, []string{"TAND-001"}) || !reflect.DeepEqual(res.Waiting, []string{"TAND-002"}) {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res = step(t, s); len(res.Events) != 0 {
+		t.Fatalf("failed task relaunched: %v", kinds(res))
+	}
+
+	cleared, err := s.Retry()
+	if err != nil || !reflect.DeepEqual(cleared, []string{"TAND-001"}) {
+		t.Fatalf("retry: %v %v", cleared, err)
+	}
+	if got := kinds(step(t, s)); !reflect.DeepEqual(got, []string{"launched:TAND-001"}) {
+		t.Fatalf("expected relaunch after retry, got %v", got)
+	}
+}
+
+func TestStepRecordsLaunchErrors(t *testing.T) {
+	rt := newFakeRuntime(t)
+	rt.failOn["TAND-001"] = true
+	s := newTestScheduler(t, rt, []tasks.TaskProposal{{ID: "TAND-001"}}, Options{DefaultTarget: "claude"})
+	res := step(t, s)
+	if len(res.Events) != 1 || res.Events[0].Kind != EventFailed || res.Events[0].Detail != "worktree exists" {
+		t.Fatalf("unexpected events %+v", res.Events)
+	}
+	cps, err := storage.ListSchedulerTasks(s.db)
+	if err != nil || cps["TAND-001"].State != storage.SchedulerFailed {
+		t.Fatalf("expected failed checkpoint, got %+v %v", cps, err)
+	}
+}
+
+func TestNewRejectsCycles(t *testing.T) {
+	list := []tasks.TaskProposal{
+		{ID: "TAND-001", Dependencies: []string{"TAND-002"}},
+		{ID: "TAND-002", Dependencies: []string{"TAND-001"}},
+	}
+	_, err := New(nil, list, nil, Options{})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestLoadTasksSkipsEpics(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"TAND-001.yaml": "id: TAND-001\ntitle: Schema\nstatus: todo\n",
+		"TAND-002.yaml": "id: TAND-002\ntitle: API\nagent: codex\ndependencies: [TAND-001]\n",
+		"EPIC-001.yaml": "id: EPIC-001\ntitle: Core\nstories:\n  - id: EPIC-001-S01\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, warnings := LoadTasks(dir)
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+	if len(list) != 2 || list[1].Agent != "codex" || !reflect.DeepEqual(list[1].Dependencies, []string{"TAND-001"}) {
+		t.Fatalf("unexpected tasks %+v", list)
+	}
+}
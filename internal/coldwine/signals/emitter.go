@@ -1,14 +1,17 @@
 // Package signals provides Coldwine-specific signal emission.
 // Coldwine emits execution_drift signals when tasks significantly exceed
-// estimates or when agents repeatedly fail on the same story.
+// estimates, when agents repeatedly fail on the same story, or when a task
+// changes files outside its declared scope.
 package signals
 
 import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/mistakeknot/autarch/internal/coldwine/drift"
 	"github.com/mistakeknot/autarch/pkg/signals"
 )
 
@@ -56,6 +59,51 @@ func (e *Emitter) AgentFailureDrift(specID, storyID, storyTitle string, failureC
 	return &s
 }
 
+// maxDriftDiff bounds the diff carried in a file drift signal.
+const maxDriftDiff = 4000
+
+// FileDrift reports a file a task changed outside its scope. Touching
+// another agent's reservation is critical; leaving the epic is a warning.
+func (e *Emitter) FileDrift(specID, taskID string, f drift.Finding) *signals.Signal {
+	if len(f.Kinds) == 0 {
+		return nil
+	}
+	severity := signals.SeverityInfo
+	switch {
+	case f.Has(drift.KindReserved):
+		severity = signals.SeverityCritical
+	case f.Has(drift.KindOutsideEpic):
+		severity = signals.SeverityWarning
+	}
+	title := fmt.Sprintf("Task %s changed %s outside its scope", taskID, f.Path)
+	if f.Has(drift.KindReserved) {
+		title = fmt.Sprintf("Task %s changed %s reserved by %s", taskID, f.Path, strings.Join(f.Holders, ", "))
+	}
+	detail := "kinds: " + strings.Join(f.Kinds, ", ")
+	if f.OldPath != "" {
+		detail += fmt.Sprintf("\nrenamed from %s", f.OldPath)
+	}
+	if f.Diff != "" {
+		diff := f.Diff
+		if len(diff) > maxDriftDiff {
+			diff = diff[:maxDriftDiff] + "\n…"
+		}
+		detail += "\n\n" + diff
+	}
+	s := signals.Signal{
+		ID:            generateID(),
+		Type:          signals.SignalExecutionDrift,
+		Source:        "coldwine",
+		SpecID:        specID,
+		AffectedField: taskID + ":" + f.Path,
+		Severity:      severity,
+		Title:         title,
+		Detail:        detail,
+		CreatedAt:     time.Now(),
+	}
+	return &s
+}
+
 func generateID() string {
 	b := make([]byte, 8)
 	_, _ = rand.Read(b)
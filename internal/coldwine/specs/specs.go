@@ -13,6 +13,7 @@ type SpecSummary struct {
 	Title         string
 	Status        string
 	Path          string
+	EpicID        string
 	FilesToModify []string
 }
 
@@ -20,6 +21,7 @@ type specDoc struct {
 	ID            string   `yaml:"id"`
 	Title         string   `yaml:"title"`
 	Status        string   `yaml:"status"`
+	EpicID        string   `yaml:"epic_id"`
 	FilesToModify []string `yaml:"files_to_modify"`
 }
 
@@ -68,6 +70,7 @@ func LoadSummaries(dir string) ([]SpecSummary, []string) {
 			Title:         doc.Title,
 			Status:        doc.Status,
 			Path:          path,
+			EpicID:        doc.EpicID,
 			FilesToModify: doc.FilesToModify,
 		})
 	}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Acceptance check outcomes. Only AcceptancePassed verifies a criterion.
+const (
+	AcceptancePassed  = "pass"
+	AcceptanceFailed  = "fail"
+	AcceptanceSkipped = "skip"    // the check exists but is not implemented
+	AcceptanceMissing = "missing" // no check was found for the criterion
+)
+
+// AcceptanceResult is the latest verification of one acceptance criterion.
+type AcceptanceResult struct {
+	TaskID      string    `json:"task_id"`
+	CriterionID string    `json:"criterion_id"`
+	Status      string    `json:"status"`
+	CheckKind   string    `json:"check_kind"` // command, shell, go or none
+	CheckRef    string    `json:"check_ref,omitempty"`
+	Output      string    `json:"output,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	Revision    string    `json:"revision,omitempty"` // worktree HEAD when verified
+	VerifiedAt  time.Time `json:"verified_at"`
+}
+
+// ReplaceAcceptanceResults stores a verification run, dropping results
+// from earlier runs for criteria that no longer exist.
+func ReplaceAcceptanceResults(db *sql.DB, taskID string, results []AcceptanceResult) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM acceptance_results WHERE task_id = ?`, taskID); err != nil {
+		return err
+	}
+	now := nowTimestamp()
+	for _, r := range results {
+		ts := now
+		if !r.VerifiedAt.IsZero() {
+			ts = r.VerifiedAt.UTC().Format(time.RFC3339Nano)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO acceptance_results (task_id, criterion_id, status, check_kind, check_ref, output, duration_ms, revision, verified_ts)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			taskID, r.CriterionID, r.Status, r.CheckKind, r.CheckRef, r.Output, r.DurationMS, r.Revision, ts); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListAcceptanceResults returns a task's latest results by criterion ID.
+func ListAcceptanceResults(db *sql.DB, taskID string) ([]AcceptanceResult, error) {
+	rows, err := db.Query(`
+		SELECT task_id, criterion_id, status, check_kind, check_ref, output, duration_ms, revision, verified_ts
+		FROM acceptance_results WHERE task_id = ? ORDER BY criterion_id ASC`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AcceptanceResult
+	for rows.Next() {
+		var r AcceptanceResult
+		var ref, output, revision sql.NullString
+		var verified string
+		if err := rows.Scan(&r.TaskID, &r.CriterionID, &r.Status, &r.CheckKind, &ref, &output, &r.DurationMS, &revision, &verified); err != nil {
+			return nil, err
+		}
+		r.CheckRef, r.Output, r.Revision = ref.String, output.String, revision.String
+		r.VerifiedAt, _ = time.Parse(time.RFC3339Nano, verified)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
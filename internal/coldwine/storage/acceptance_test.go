@@ -0,0 +1,46 @@
+package storage
+
+import "testing"
+
+func TestReplaceAcceptanceResults(t *testing.T) {
+	db, err := OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	first := []AcceptanceResult{
+		{CriterionID: "AC-2", Status: AcceptanceFailed, CheckKind: "shell", CheckRef: "acceptance/T1/AC-2.sh", Output: "boom", DurationMS: 12, Revision: "abc123"},
+		{CriterionID: "AC-1", Status: AcceptancePassed, CheckKind: "go"},
+	}
+	if err := ReplaceAcceptanceResults(db, "T1", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceAcceptanceResults(db, "T2", []AcceptanceResult{{CriterionID: "AC-1", Status: AcceptanceMissing, CheckKind: "none"}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ListAcceptanceResults(db, "T1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].CriterionID != "AC-1" || got[1].Output != "boom" || got[1].Revision != "abc123" || got[1].VerifiedAt.IsZero() {
+		t.Fatalf("unexpected results %+v", got)
+	}
+
+	// A new run replaces the old one, dropping criteria no longer present.
+	if err := ReplaceAcceptanceResults(db, "T1", []AcceptanceResult{{CriterionID: "AC-1", Status: AcceptancePassed, CheckKind: "go"}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ListAcceptanceResults(db, "T1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].CriterionID != "AC-1" {
+		t.Fatalf("expected only AC-1 after re-run, got %+v", got)
+	}
+	if other, _ := ListAcceptanceResults(db, "T2"); len(other) != 1 {
+		t.Fatalf("other tasks must be untouched, got %+v", other)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/drift"
 )
 
 type Message struct {
@@ -26,6 +28,9 @@ type MessageDelivery struct {
 	Recipient string
 	ReadAt    string
 	AckAt     string
+	// DeliveredAt is when a live subscriber or pane injection first
+	// delivered the message to the recipient.
+	DeliveredAt string
 }
 
 type Reservation struct {
@@ -101,6 +106,7 @@ func SendMessage(db *sql.DB, msg Message, recipients []string) error {
 	if err := tx.Commit(); err != nil {
 		return err
 	}
+	notifyMail(db, MailEvent{Kind: MailEventMessage, Message: msg, Recipients: recipients})
 	return nil
 }
 
@@ -138,7 +144,7 @@ func FetchInboxPage(db *sql.DB, recipient string, limit int, sinceTs string, urg
 	}
 	query := `
 SELECT m.id, m.thread_id, m.sender, m.subject, m.body, m.created_ts, m.importance, m.ack_required, m.metadata,
-       mb.recipient, mb.read_ts, mb.ack_ts
+       mb.recipient, mb.read_ts, mb.ack_ts, mb.delivered_ts
 FROM mailboxes mb
 JOIN messages m ON m.id = mb.message_id
 WHERE mb.recipient = ?`
@@ -176,16 +182,18 @@ LIMIT ?`
 		var recipient string
 		var readAt sql.NullString
 		var ackAt sql.NullString
+		var deliveredAt sql.NullString
 		var ackRequired int
-		if err := rows.Scan(&msg.ID, &msg.ThreadID, &msg.Sender, &msg.Subject, &msg.Body, &msg.CreatedAt, &msg.Importance, &ackRequired, &msg.Metadata, &recipient, &readAt, &ackAt); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.ThreadID, &msg.Sender, &msg.Subject, &msg.Body, &msg.CreatedAt, &msg.Importance, &ackRequired, &msg.Metadata, &recipient, &readAt, &ackAt, &deliveredAt); err != nil {
 			return nil, "", err
 		}
 		msg.AckRequired = ackRequired != 0
 		deliveries = append(deliveries, MessageDelivery{
-			Message:   msg,
-			Recipient: recipient,
-			ReadAt:    readAt.String,
-			AckAt:     ackAt.String,
+			Message:     msg,
+			Recipient:   recipient,
+			ReadAt:      readAt.String,
+			AckAt:       ackAt.String,
+			DeliveredAt: deliveredAt.String,
 		})
 	}
 	if err := rows.Err(); err != nil {
@@ -215,6 +223,7 @@ func AckMessage(db *sql.DB, messageID, recipient, ackTs string) error {
 	if rows == 0 {
 		return fmt.Errorf("message not found")
 	}
+	notifyMail(db, MailEvent{Kind: MailEventAck, Message: Message{ID: messageID}, Recipients: []string{recipient}, At: ackTs})
 	return nil
 }
 
@@ -335,6 +344,37 @@ LIMIT ?`, nowTs, limit)
 	return out, rows.Err()
 }
 
+// ListActiveReservationsForPath returns unexpired, unreleased reservations
+// covering path. Reservation paths may be globs or directories, matched as
+// drift.Match does for the lock hooks.
+func ListActiveReservationsForPath(db *sql.DB, path string) ([]Reservation, error) {
+	rows, err := db.Query(`SELECT id, path, owner, exclusive, reason, created_ts, expires_ts, released_ts
+FROM reservations
+WHERE released_ts IS NULL AND expires_ts > ?
+ORDER BY created_ts ASC`, nowTimestamp())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Reservation{}
+	for rows.Next() {
+		var res Reservation
+		var exclusive int
+		var released sql.NullString
+		if err := rows.Scan(&res.ID, &res.Path, &res.Owner, &exclusive, &res.Reason, &res.CreatedAt, &res.ExpiresAt, &released); err != nil {
+			return nil, err
+		}
+		if !drift.Match(res.Path, path) {
+			continue
+		}
+		res.Exclusive = exclusive != 0
+		res.ReleasedAt = released.String
+		out = append(out, res)
+	}
+	return out, rows.Err()
+}
+
 func ReleasePaths(db *sql.DB, owner string, paths []string) (int, error) {
 	if len(paths) == 0 {
 		return 0, nil
@@ -130,7 +130,77 @@ CREATE TABLE IF NOT EXISTS attachments (
   byte_size INTEGER,
   mime_type TEXT
 );
+CREATE TABLE IF NOT EXISTS scheduler_tasks (
+  task_id TEXT PRIMARY KEY,
+  target TEXT NOT NULL,
+  session_id TEXT NOT NULL,
+  state TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  detail TEXT,
+  updated_ts TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS merge_queue (
+  task_id TEXT PRIMARY KEY,
+  branch TEXT NOT NULL,
+  state TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  log_path TEXT,
+  detail TEXT,
+  enqueued_ts TEXT NOT NULL,
+  updated_ts TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS agent_health (
+  session_id TEXT PRIMARY KEY,
+  task_id TEXT NOT NULL,
+  status TEXT NOT NULL,
+  log_offset INTEGER NOT NULL DEFAULT 0,
+  cpu_ticks INTEGER NOT NULL DEFAULT 0,
+  last_output_ts TEXT,
+  last_cpu_ts TEXT,
+  restarts INTEGER NOT NULL DEFAULT 0,
+  restarted_ts TEXT,
+  next_restart_ts TEXT,
+  detail TEXT,
+  updated_ts TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS agent_incidents (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  session_id TEXT NOT NULL,
+  task_id TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  detail TEXT,
+  action TEXT,
+  created_ts TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS task_usage (
+  task_id TEXT PRIMARY KEY,
+  agent TEXT NOT NULL,
+  model TEXT,
+  input_tokens INTEGER NOT NULL DEFAULT 0,
+  output_tokens INTEGER NOT NULL DEFAULT 0,
+  cache_read_tokens INTEGER NOT NULL DEFAULT 0,
+  cache_write_tokens INTEGER NOT NULL DEFAULT 0,
+  cost_usd REAL NOT NULL DEFAULT 0,
+  active_seconds REAL NOT NULL DEFAULT 0,
+  started_ts TEXT NOT NULL,
+  last_sample_ts TEXT NOT NULL,
+  ended_ts TEXT,
+  updated_ts TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS acceptance_results (
+  task_id TEXT NOT NULL,
+  criterion_id TEXT NOT NULL,
+  status TEXT NOT NULL,
+  check_kind TEXT NOT NULL,
+  check_ref TEXT,
+  output TEXT,
+  duration_ms INTEGER NOT NULL DEFAULT 0,
+  revision TEXT,
+  verified_ts TEXT NOT NULL,
+  PRIMARY KEY (task_id, criterion_id)
+);
 CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+CREATE INDEX IF NOT EXISTS idx_agent_incidents_session ON agent_incidents(session_id);
 CREATE INDEX IF NOT EXISTS idx_sessions_task_id ON sessions(task_id);
 CREATE INDEX IF NOT EXISTS idx_messages_thread_id ON messages(thread_id);
 CREATE INDEX IF NOT EXISTS idx_mailboxes_recipient ON mailboxes(recipient);
@@ -145,15 +215,40 @@ CREATE INDEX IF NOT EXISTS idx_attachments_message_id ON attachments(message_id)
 	if err != nil {
 		return err
 	}
-	return addAttachmentColumns(db)
+	if err := addAttachmentColumns(db); err != nil {
+		return err
+	}
+	if err := addMailboxColumns(db); err != nil {
+		return err
+	}
+	return addAgentHealthColumns(db)
 }
 
 func addAttachmentColumns(db *sql.DB) error {
-	columns := []string{
+	return addColumns(db, []string{
 		"ALTER TABLE attachments ADD COLUMN blob_hash TEXT",
 		"ALTER TABLE attachments ADD COLUMN byte_size INTEGER",
 		"ALTER TABLE attachments ADD COLUMN mime_type TEXT",
-	}
+	})
+}
+
+// addMailboxColumns adds delivery receipt tracking to mailboxes.
+func addMailboxColumns(db *sql.DB) error {
+	return addColumns(db, []string{
+		"ALTER TABLE mailboxes ADD COLUMN delivered_ts TEXT",
+		"ALTER TABLE mailboxes ADD COLUMN injected_ts TEXT",
+		"ALTER TABLE mailboxes ADD COLUMN inject_count INTEGER NOT NULL DEFAULT 0",
+	})
+}
+
+// addAgentHealthColumns records when a session was last restarted.
+func addAgentHealthColumns(db *sql.DB) error {
+	return addColumns(db, []string{
+		"ALTER TABLE agent_health ADD COLUMN restarted_ts TEXT",
+	})
+}
+
+func addColumns(db *sql.DB, columns []string) error {
 	for _, stmt := range columns {
 		if _, err := db.Exec(stmt); err != nil {
 			if strings.Contains(err.Error(), "duplicate column name") {
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AgentHealth is the supervisor's view of one agent session, carried between
+// checks so progress and restart attempts survive supervisor restarts.
+type AgentHealth struct {
+	SessionID     string    `json:"session_id"`
+	TaskID        string    `json:"task_id"`
+	Status        string    `json:"status"`
+	LogOffset     int64     `json:"log_offset"`
+	CPUTicks      int64     `json:"cpu_ticks"`
+	LastOutputAt  time.Time `json:"last_output_at"`
+	LastCPUAt     time.Time `json:"last_cpu_at"`
+	Restarts      int       `json:"restarts"`
+	RestartedAt   time.Time `json:"restarted_at,omitempty"`
+	NextRestartAt time.Time `json:"next_restart_at,omitempty"`
+	Detail        string    `json:"detail,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// AgentIncident is one entry in a session's health history.
+type AgentIncident struct {
+	ID        int64     `json:"id"`
+	SessionID string    `json:"session_id"`
+	TaskID    string    `json:"task_id"`
+	Kind      string    `json:"kind"`
+	Detail    string    `json:"detail,omitempty"`
+	Action    string    `json:"action,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func UpsertAgentHealth(db *sql.DB, h AgentHealth) error {
+	_, err := db.Exec(`
+		INSERT INTO agent_health (session_id, task_id, status, log_offset, cpu_ticks, last_output_ts, last_cpu_ts, restarts, restarted_ts, next_restart_ts, detail, updated_ts)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(session_id) DO UPDATE SET
+		  task_id = excluded.task_id,
+		  status = excluded.status,
+		  log_offset = excluded.log_offset,
+		  cpu_ticks = excluded.cpu_ticks,
+		  last_output_ts = excluded.last_output_ts,
+		  last_cpu_ts = excluded.last_cpu_ts,
+		  restarts = excluded.restarts,
+		  restarted_ts = excluded.restarted_ts,
+		  next_restart_ts = excluded.next_restart_ts,
+		  detail = excluded.detail,
+		  updated_ts = excluded.updated_ts`,
+		h.SessionID, h.TaskID, h.Status, h.LogOffset, h.CPUTicks,
+		formatOptionalTime(h.LastOutputAt), formatOptionalTime(h.LastCPUAt),
+		h.Restarts, formatOptionalTime(h.RestartedAt), formatOptionalTime(h.NextRestartAt), h.Detail, nowTimestamp())
+	return err
+}
+
+const agentHealthColumns = `session_id, task_id, status, log_offset, cpu_ticks, last_output_ts, last_cpu_ts, restarts, restarted_ts, next_restart_ts, detail, updated_ts`
+
+// GetAgentHealth returns the stored health for a session, or sql.ErrNoRows.
+func GetAgentHealth(db *sql.DB, sessionID string) (AgentHealth, error) {
+	row := db.QueryRow(`SELECT `+agentHealthColumns+` FROM agent_health WHERE session_id = ?`, sessionID)
+	return scanAgentHealth(row)
+}
+
+func ListAgentHealth(db *sql.DB) ([]AgentHealth, error) {
+	rows, err := db.Query(`SELECT ` + agentHealthColumns + ` FROM agent_health ORDER BY session_id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AgentHealth
+	for rows.Next() {
+		h, err := scanAgentHealth(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+// ResetAgentHealth forgets a session's health state so the supervisor
+// starts counting restarts again. Incident history is kept.
+func ResetAgentHealth(db *sql.DB, sessionID string) error {
+	_, err := db.Exec(`DELETE FROM agent_health WHERE session_id = ?`, sessionID)
+	return err
+}
+
+func scanAgentHealth(row rowScanner) (AgentHealth, error) {
+	var h AgentHealth
+	var lastOutput, lastCPU, restarted, nextRestart, detail sql.NullString
+	var updated string
+	if err := row.Scan(&h.SessionID, &h.TaskID, &h.Status, &h.LogOffset, &h.CPUTicks,
+		&lastOutput, &lastCPU, &h.Restarts, &restarted, &nextRestart, &detail, &updated); err != nil {
+		return AgentHealth{}, err
+	}
+	h.LastOutputAt = parseOptionalTime(lastOutput)
+	h.LastCPUAt = parseOptionalTime(lastCPU)
+	h.RestartedAt = parseOptionalTime(restarted)
+	h.NextRestartAt = parseOptionalTime(nextRestart)
+	h.Detail = detail.String
+	h.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updated)
+	return h, nil
+}
+
+func AddAgentIncident(db *sql.DB, inc AgentIncident) (AgentIncident, error) {
+	if inc.CreatedAt.IsZero() {
+		inc.CreatedAt = time.Now().UTC()
+	}
+	res, err := db.Exec(`INSERT INTO agent_incidents (session_id, task_id, kind, detail, action, created_ts) VALUES (?, ?, ?, ?, ?, ?)`,
+		inc.SessionID, inc.TaskID, inc.Kind, inc.Detail, inc.Action, inc.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return inc, err
+	}
+	inc.ID, _ = res.LastInsertId()
+	return inc, nil
+}
+
+// ListAgentIncidents returns incidents newest first. An empty sessionID
+// lists every session.
+func ListAgentIncidents(db *sql.DB, sessionID string, limit int) ([]AgentIncident, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := `SELECT id, session_id, task_id, kind, detail, action, created_ts FROM agent_incidents`
+	args := []any{}
+	if sessionID != "" {
+		query += ` WHERE session_id = ?`
+		args = append(args, sessionID)
+	}
+	query += ` ORDER BY created_ts DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AgentIncident
+	for rows.Next() {
+		var inc AgentIncident
+		var detail, action sql.NullString
+		var created string
+		if err := rows.Scan(&inc.ID, &inc.SessionID, &inc.TaskID, &inc.Kind, &detail, &action, &created); err != nil {
+			return nil, err
+		}
+		inc.Detail, inc.Action = detail.String, action.String
+		inc.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+		out = append(out, inc)
+	}
+	return out, rows.Err()
+}
+
+func formatOptionalTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseOptionalTime(v sql.NullString) time.Time {
+	if !v.Valid || v.String == "" {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339Nano, v.String)
+	return t
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// Mail event kinds passed to the mail notifier.
+const (
+	MailEventMessage   = "message"
+	MailEventAck       = "ack"
+	MailEventDelivered = "delivered"
+)
+
+// MailEvent describes a committed change to the mail log.
+type MailEvent struct {
+	Kind       string   `json:"kind"`
+	Message    Message  `json:"message"`
+	Recipients []string `json:"recipients"`
+	At         string   `json:"at,omitempty"`
+}
+
+var (
+	mailNotifierMu sync.RWMutex
+	mailNotifier   func(db *sql.DB, ev MailEvent)
+)
+
+// SetMailNotifier installs fn to be called after messages are sent,
+// delivered or acknowledged, so subscribers can be told without polling.
+// Passing nil removes it.
+func SetMailNotifier(fn func(db *sql.DB, ev MailEvent)) {
+	mailNotifierMu.Lock()
+	mailNotifier = fn
+	mailNotifierMu.Unlock()
+}
+
+func notifyMail(db *sql.DB, ev MailEvent) {
+	mailNotifierMu.RLock()
+	fn := mailNotifier
+	mailNotifierMu.RUnlock()
+	if fn != nil {
+		fn(db, ev)
+	}
+}
+
+// PendingDelivery is an unacknowledged delivery that needs the recipient's
+// attention: an urgent message or one that requires an ack.
+type PendingDelivery struct {
+	MessageDelivery
+	InjectedAt  string
+	InjectCount int
+}
+
+// MarkMessageDelivered records the first delivery receipt for a recipient.
+// Later receipts leave the original timestamp in place.
+func MarkMessageDelivered(db *sql.DB, messageID, recipient, ts string) (bool, error) {
+	if ts == "" {
+		ts = nowTimestamp()
+	}
+	res, err := db.Exec(`UPDATE mailboxes SET delivered_ts = ? WHERE message_id = ? AND recipient = ? AND delivered_ts IS NULL`, ts, messageID, recipient)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows > 0 {
+		notifyMail(db, MailEvent{Kind: MailEventDelivered, Message: Message{ID: messageID}, Recipients: []string{recipient}, At: ts})
+	}
+	return rows > 0, nil
+}
+
+// RecordMessageInjection notes that a message was typed into the
+// recipient's terminal.
+func RecordMessageInjection(db *sql.DB, messageID, recipient, ts string) error {
+	if ts == "" {
+		ts = nowTimestamp()
+	}
+	_, err := db.Exec(`UPDATE mailboxes SET injected_ts = ?, inject_count = inject_count + 1 WHERE message_id = ? AND recipient = ?`, ts, messageID, recipient)
+	return err
+}
+
+// ListPendingDeliveries returns unacknowledged urgent or ack-required
+// deliveries, oldest first.
+func ListPendingDeliveries(db *sql.DB) ([]PendingDelivery, error) {
+	rows, err := db.Query(`
+SELECT m.id, m.thread_id, m.sender, m.subject, m.body, m.created_ts, m.importance, m.ack_required, m.metadata,
+       mb.recipient, mb.read_ts, mb.delivered_ts, mb.injected_ts, mb.inject_count
+FROM mailboxes mb
+JOIN messages m ON m.id = mb.message_id
+WHERE mb.ack_ts IS NULL AND (m.importance = 'urgent' OR m.ack_required = 1)
+ORDER BY m.created_ts ASC, m.id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PendingDelivery
+	for rows.Next() {
+		var p PendingDelivery
+		var ackRequired int
+		var metadata, readAt, deliveredAt, injectedAt sql.NullString
+		if err := rows.Scan(&p.Message.ID, &p.Message.ThreadID, &p.Message.Sender, &p.Message.Subject, &p.Message.Body,
+			&p.Message.CreatedAt, &p.Message.Importance, &ackRequired, &metadata,
+			&p.Recipient, &readAt, &deliveredAt, &injectedAt, &p.InjectCount); err != nil {
+			return nil, err
+		}
+		p.Message.AckRequired = ackRequired != 0
+		p.Message.Metadata = metadata.String
+		p.ReadAt = readAt.String
+		p.DeliveredAt = deliveredAt.String
+		p.InjectedAt = injectedAt.String
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// DeliveryReceipt is one recipient's delivery state for a message.
+type DeliveryReceipt struct {
+	Recipient   string `json:"recipient"`
+	DeliveredAt string `json:"delivered_ts,omitempty"`
+	ReadAt      string `json:"read_ts,omitempty"`
+	AckAt       string `json:"ack_ts,omitempty"`
+	InjectedAt  string `json:"injected_ts,omitempty"`
+	InjectCount int    `json:"inject_count"`
+}
+
+// ListDeliveryReceipts returns the per-recipient receipts for a message.
+func ListDeliveryReceipts(db *sql.DB, messageID string) ([]DeliveryReceipt, error) {
+	rows, err := db.Query(`SELECT recipient, delivered_ts, read_ts, ack_ts, injected_ts, inject_count
+FROM mailboxes WHERE message_id = ? ORDER BY recipient ASC`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []DeliveryReceipt{}
+	for rows.Next() {
+		var r DeliveryReceipt
+		var delivered, read, ack, injected sql.NullString
+		if err := rows.Scan(&r.Recipient, &delivered, &read, &ack, &injected, &r.InjectCount); err != nil {
+			return nil, err
+		}
+		r.DeliveredAt, r.ReadAt, r.AckAt, r.InjectedAt = delivered.String, read.String, ack.String, injected.String
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestDeliveryReceiptsAndPending(t *testing.T) {
+	db, err := OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []MailEvent
+	SetMailNotifier(func(_ *sql.DB, ev MailEvent) { events = append(events, ev) })
+	defer SetMailNotifier(nil)
+
+	if err := SendMessage(db, Message{ID: "msg-1", Sender: "alice", Subject: "Rebase", Body: "now", Importance: "urgent"}, []string{"bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SendMessage(db, Message{ID: "msg-2", Sender: "alice", Subject: "FYI", Body: "later"}, []string{"bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SendMessage(db, Message{ID: "msg-3", Sender: "alice", Subject: "Confirm", Body: "ack", AckRequired: true}, []string{"bob", "carol"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := ListPendingDeliveries(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 3 {
+		t.Fatalf("expected urgent and ack-required deliveries only, got %+v", pending)
+	}
+
+	first, err := MarkMessageDelivered(db, "msg-3", "bob", "2026-01-15T00:00:00Z")
+	if err != nil || !first {
+		t.Fatalf("first receipt: %v %v", first, err)
+	}
+	again, err := MarkMessageDelivered(db, "msg-3", "bob", "2026-01-15T00:05:00Z")
+	if err != nil || again {
+		t.Fatalf("second receipt should be a no-op: %v %v", again, err)
+	}
+	if err := RecordMessageInjection(db, "msg-3", "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := AckMessage(db, "msg-3", "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	receipts, err := ListDeliveryReceipts(db, "msg-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 2 || receipts[0].Recipient != "bob" {
+		t.Fatalf("unexpected receipts %+v", receipts)
+	}
+	if receipts[0].DeliveredAt != "2026-01-15T00:00:00Z" || receipts[0].AckAt == "" || receipts[0].InjectCount != 1 {
+		t.Fatalf("unexpected bob receipt %+v", receipts[0])
+	}
+	if receipts[1].DeliveredAt != "" {
+		t.Fatalf("carol should not have a receipt yet: %+v", receipts[1])
+	}
+
+	pending, err = ListPendingDeliveries(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("acked delivery should no longer be pending, got %+v", pending)
+	}
+
+	kinds := []string{}
+	for _, ev := range events {
+		kinds = append(kinds, ev.Kind)
+	}
+	want := []string{MailEventMessage, MailEventMessage, MailEventMessage, MailEventDelivered, MailEventAck}
+	if len(kinds) != len(want) {
+		t.Fatalf("events = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("events = %v, want %v", kinds, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Merge queue states. Queued entries are processed oldest first; the other
+// states record the outcome of the last attempt.
+const (
+	MergeQueued   = "queued"
+	MergeTesting  = "testing"
+	MergeMerged   = "merged"
+	MergeFailed   = "failed"
+	MergeConflict = "conflict"
+)
+
+// MergeEntry is one approved task waiting for, or done with, the merge queue.
+type MergeEntry struct {
+	TaskID     string    `json:"task_id"`
+	Branch     string    `json:"branch"`
+	State      string    `json:"state"`
+	Attempts   int       `json:"attempts"`
+	LogPath    string    `json:"log_path,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// QueueMerge approves a task without merging it: the task leaves the review
+// queue with status approved and its branch joins the back of the merge
+// queue. Re-queuing a task resets its entry.
+func QueueMerge(db *sql.DB, taskID, branch string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	now := nowTimestamp()
+	if _, err := tx.Exec(`
+		INSERT INTO merge_queue (task_id, branch, state, attempts, log_path, detail, enqueued_ts, updated_ts)
+		VALUES (?, ?, ?, 0, '', '', ?, ?)
+		ON CONFLICT(task_id) DO UPDATE SET
+		  branch = excluded.branch,
+		  state = excluded.state,
+		  log_path = '',
+		  detail = '',
+		  enqueued_ts = excluded.enqueued_ts,
+		  updated_ts = excluded.updated_ts`,
+		taskID, branch, MergeQueued, now, now); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE tasks SET status = ? WHERE id = ?`, "approved", taskID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM review_queue WHERE task_id = ?`, taskID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// NextMerge returns the oldest queued entry, or sql.ErrNoRows.
+func NextMerge(db *sql.DB) (MergeEntry, error) {
+	row := db.QueryRow(`SELECT task_id, branch, state, attempts, log_path, detail, enqueued_ts, updated_ts
+		FROM merge_queue WHERE state = ? ORDER BY enqueued_ts ASC, task_id ASC LIMIT 1`, MergeQueued)
+	return scanMergeEntry(row)
+}
+
+// ListMergeQueue returns every entry, oldest first.
+func ListMergeQueue(db *sql.DB) ([]MergeEntry, error) {
+	rows, err := db.Query(`SELECT task_id, branch, state, attempts, log_path, detail, enqueued_ts, updated_ts
+		FROM merge_queue ORDER BY enqueued_ts ASC, task_id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []MergeEntry
+	for rows.Next() {
+		e, err := scanMergeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMergeEntry(row rowScanner) (MergeEntry, error) {
+	var e MergeEntry
+	var logPath, detail sql.NullString
+	var enqueued, updated string
+	if err := row.Scan(&e.TaskID, &e.Branch, &e.State, &e.Attempts, &logPath, &detail, &enqueued, &updated); err != nil {
+		return MergeEntry{}, err
+	}
+	e.LogPath, e.Detail = logPath.String, detail.String
+	e.EnqueuedAt, _ = time.Parse(time.RFC3339Nano, enqueued)
+	e.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updated)
+	return e, nil
+}
+
+// StartMerge marks an entry as being tested and counts the attempt.
+func StartMerge(db *sql.DB, taskID string) error {
+	_, err := db.Exec(`UPDATE merge_queue SET state = ?, attempts = attempts + 1, updated_ts = ? WHERE task_id = ?`,
+		MergeTesting, nowTimestamp(), taskID)
+	return err
+}
+
+// RequeueTestingMerges puts entries left in testing back in the queue and
+// returns how many there were.
+func RequeueTestingMerges(db *sql.DB) (int, error) {
+	res, err := db.Exec(`UPDATE merge_queue SET state = ?, updated_ts = ? WHERE state = ?`,
+		MergeQueued, nowTimestamp(), MergeTesting)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// FinishMerge records the outcome of an attempt.
+func FinishMerge(db *sql.DB, taskID, state, logPath, detail string) error {
+	_, err := db.Exec(`UPDATE merge_queue SET state = ?, log_path = ?, detail = ?, updated_ts = ? WHERE task_id = ?`,
+		state, logPath, detail, nowTimestamp(), taskID)
+	return err
+}
+
+// ReturnToReview puts a task back in the review queue with status review.
+func ReturnToReview(db *sql.DB, taskID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE tasks SET status = ? WHERE id = ?`, "review", taskID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO review_queue (task_id) VALUES (?)`, taskID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMergeQueueLifecycle(t *testing.T) {
+	db, _ := OpenTemp()
+	defer db.Close()
+	_ = Migrate(db)
+	_ = InsertTask(db, Task{ID: "TAND-001", Title: "Test", Status: "review"})
+	_ = AddToReviewQueue(db, "TAND-001")
+
+	if err := QueueMerge(db, "TAND-001", "feature/TAND-001"); err != nil {
+		t.Fatal(err)
+	}
+	tsk, _ := GetTask(db, "TAND-001")
+	if tsk.Status != "approved" {
+		t.Fatalf("expected approved, got %s", tsk.Status)
+	}
+	if ids, _ := ListReviewQueue(db); len(ids) != 0 {
+		t.Fatalf("expected review queue empty, got %v", ids)
+	}
+	entry, err := NextMerge(db)
+	if err != nil || entry.Branch != "feature/TAND-001" || entry.State != MergeQueued {
+		t.Fatalf("unexpected entry %+v %v", entry, err)
+	}
+
+	_ = StartMerge(db, "TAND-001")
+	_ = FinishMerge(db, "TAND-001", MergeFailed, "/tmp/TAND-001.log", "tests failed")
+	_ = ReturnToReview(db, "TAND-001")
+	if _, err := NextMerge(db); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected empty queue, got %v", err)
+	}
+	if ids, _ := ListReviewQueue(db); len(ids) != 1 {
+		t.Fatalf("expected task back in review, got %v", ids)
+	}
+	entries, _ := ListMergeQueue(db)
+	if len(entries) != 1 || entries[0].Attempts != 1 || entries[0].LogPath != "/tmp/TAND-001.log" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	_ = QueueMerge(db, "TAND-001", "feature/TAND-001")
+	entry, _ = NextMerge(db)
+	if entry.State != MergeQueued || entry.Detail != "" || entry.Attempts != 1 {
+		t.Fatalf("expected requeued entry, got %+v", entry)
+	}
+}
+
+func TestListActiveReservationsForPathMatchesGlobs(t *testing.T) {
+	db, _ := OpenTemp()
+	defer db.Close()
+	_ = Migrate(db)
+	for owner, path := range map[string]string{"alice": "internal/**", "bob": "cmd/*.go", "carol": "docs/"} {
+		if _, err := ReservePaths(db, owner, []string{path}, true, "work", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, want := range map[string]string{
+		"internal/store/x.go": "alice",
+		"cmd/main.go":         "bob",
+		"docs/guide/intro.md": "carol",
+		"README.md":           "",
+	} {
+		got, err := ListActiveReservationsForPath(db, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case want == "" && len(got) != 0:
+			t.Errorf("%s: expected no reservations, got %+v", path, got)
+		case want != "" && (len(got) != 1 || got[0].Owner != want):
+			t.Errorf("%s: expected %s's reservation, got %+v", path, want, got)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Scheduler checkpoint states. A task is launching from the moment the
+// scheduler commits to starting it until its session is confirmed alive.
+const (
+	SchedulerLaunching = "launching"
+	SchedulerRunning   = "running"
+	SchedulerFinished  = "finished"
+	SchedulerFailed    = "failed"
+)
+
+// SchedulerTask is the scheduler's checkpoint for one task.
+type SchedulerTask struct {
+	TaskID    string
+	Target    string
+	SessionID string
+	State     string
+	Attempts  int
+	Detail    string
+	UpdatedAt time.Time
+}
+
+// Active reports whether the checkpoint holds a concurrency slot.
+func (t SchedulerTask) Active() bool {
+	return t.State == SchedulerLaunching || t.State == SchedulerRunning
+}
+
+// UpsertSchedulerTask writes a checkpoint, replacing any previous one for
+// the same task.
+func UpsertSchedulerTask(db *sql.DB, t SchedulerTask) error {
+	_, err := db.Exec(`
+		INSERT INTO scheduler_tasks (task_id, target, session_id, state, attempts, detail, updated_ts)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(task_id) DO UPDATE SET
+		  target = excluded.target,
+		  session_id = excluded.session_id,
+		  state = excluded.state,
+		  attempts = excluded.attempts,
+		  detail = excluded.detail,
+		  updated_ts = excluded.updated_ts`,
+		t.TaskID, t.Target, t.SessionID, t.State, t.Attempts, t.Detail, nowTimestamp())
+	return err
+}
+
+// UpdateSchedulerTaskState changes the state and detail of a checkpoint.
+func UpdateSchedulerTaskState(db *sql.DB, taskID, state, detail string) error {
+	_, err := db.Exec(`UPDATE scheduler_tasks SET state = ?, detail = ?, updated_ts = ? WHERE task_id = ?`,
+		state, detail, nowTimestamp(), taskID)
+	return err
+}
+
+// DeleteSchedulerTask removes a task's checkpoint.
+func DeleteSchedulerTask(db *sql.DB, taskID string) error {
+	_, err := db.Exec(`DELETE FROM scheduler_tasks WHERE task_id = ?`, taskID)
+	return err
+}
+
+// ListSchedulerTasks returns all checkpoints keyed by task ID.
+func ListSchedulerTasks(db *sql.DB) (map[string]SchedulerTask, error) {
+	rows, err := db.Query(`SELECT task_id, target, session_id, state, attempts, detail, updated_ts FROM scheduler_tasks`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]SchedulerTask)
+	for rows.Next() {
+		var t SchedulerTask
+		var detail sql.NullString
+		var updated string
+		if err := rows.Scan(&t.TaskID, &t.Target, &t.SessionID, &t.State, &t.Attempts, &detail, &updated); err != nil {
+			return nil, err
+		}
+		t.Detail = detail.String
+		t.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updated)
+		out[t.TaskID] = t
+	}
+	return out, rows.Err()
+}
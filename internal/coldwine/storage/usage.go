@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// TaskUsage is the accumulated token use, cost and time of a task's agent
+// sessions.
+type TaskUsage struct {
+	TaskID           string    `json:"task_id"`
+	Agent            string    `json:"agent"`
+	Model            string    `json:"model,omitempty"`
+	InputTokens      int64     `json:"input_tokens"`
+	OutputTokens     int64     `json:"output_tokens"`
+	CacheReadTokens  int64     `json:"cache_read_tokens"`
+	CacheWriteTokens int64     `json:"cache_write_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	ActiveSeconds    float64   `json:"active_seconds"`
+	StartedAt        time.Time `json:"started_at"`
+	LastSampleAt     time.Time `json:"last_sample_at"`
+	EndedAt          time.Time `json:"ended_at,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// WallSeconds is the time from the first sample to the end of the task, or
+// to now while it is still running.
+func (u TaskUsage) WallSeconds(now time.Time) float64 {
+	end := now
+	if !u.EndedAt.IsZero() {
+		end = u.EndedAt
+	}
+	if u.StartedAt.IsZero() || end.Before(u.StartedAt) {
+		return 0
+	}
+	return end.Sub(u.StartedAt).Seconds()
+}
+
+// UsageSample is what one read of a session log adds to a task.
+type UsageSample struct {
+	Agent            string
+	Model            string
+	InputTokens      int64
+	OutputTokens     int64
+	CacheReadTokens  int64
+	CacheWriteTokens int64
+	CostUSD          float64
+	// Active is set when the log grew since the previous sample; the time
+	// between the two samples, up to maxGap, counts as active time.
+	Active bool
+	At     time.Time
+}
+
+// AddTaskUsage folds a sample into the task's usage, starting the record on
+// the first sample. A sample after EndTaskUsage reopens the task.
+func AddTaskUsage(db *sql.DB, taskID string, s UsageSample, maxGap time.Duration) error {
+	if s.At.IsZero() {
+		s.At = time.Now().UTC()
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	u, err := scanTaskUsage(tx.QueryRow(`SELECT `+taskUsageColumns+` FROM task_usage WHERE task_id = ?`, taskID))
+	if errors.Is(err, sql.ErrNoRows) {
+		u = TaskUsage{TaskID: taskID, StartedAt: s.At, LastSampleAt: s.At}
+	} else if err != nil {
+		return err
+	}
+	if s.Agent != "" {
+		u.Agent = s.Agent
+	}
+	if s.Model != "" {
+		u.Model = s.Model
+	}
+	u.InputTokens += s.InputTokens
+	u.OutputTokens += s.OutputTokens
+	u.CacheReadTokens += s.CacheReadTokens
+	u.CacheWriteTokens += s.CacheWriteTokens
+	u.CostUSD += s.CostUSD
+	if s.Active && s.At.After(u.LastSampleAt) {
+		gap := s.At.Sub(u.LastSampleAt)
+		if maxGap > 0 && gap > maxGap {
+			gap = maxGap
+		}
+		u.ActiveSeconds += gap.Seconds()
+	}
+	if s.At.After(u.LastSampleAt) {
+		u.LastSampleAt = s.At
+	}
+	_, err = tx.Exec(`
+		INSERT INTO task_usage (task_id, agent, model, input_tokens, output_tokens, cache_read_tokens, cache_write_tokens, cost_usd, active_seconds, started_ts, last_sample_ts, ended_ts, updated_ts)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL, ?)
+		ON CONFLICT(task_id) DO UPDATE SET
+		  agent = excluded.agent,
+		  model = excluded.model,
+		  input_tokens = excluded.input_tokens,
+		  output_tokens = excluded.output_tokens,
+		  cache_read_tokens = excluded.cache_read_tokens,
+		  cache_write_tokens = excluded.cache_write_tokens,
+		  cost_usd = excluded.cost_usd,
+		  active_seconds = excluded.active_seconds,
+		  last_sample_ts = excluded.last_sample_ts,
+		  ended_ts = NULL,
+		  updated_ts = excluded.updated_ts`,
+		taskID, u.Agent, u.Model, u.InputTokens, u.OutputTokens, u.CacheReadTokens, u.CacheWriteTokens,
+		u.CostUSD, u.ActiveSeconds, u.StartedAt.UTC().Format(time.RFC3339Nano), u.LastSampleAt.UTC().Format(time.RFC3339Nano), nowTimestamp())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EndTaskUsage stops the task's wall clock. Tasks without usage are ignored.
+func EndTaskUsage(db *sql.DB, taskID string, at time.Time) error {
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	_, err := db.Exec(`UPDATE task_usage SET ended_ts = ?, updated_ts = ? WHERE task_id = ? AND ended_ts IS NULL`,
+		at.UTC().Format(time.RFC3339Nano), nowTimestamp(), taskID)
+	return err
+}
+
+const taskUsageColumns = `task_id, agent, model, input_tokens, output_tokens, cache_read_tokens, cache_write_tokens, cost_usd, active_seconds, started_ts, last_sample_ts, ended_ts, updated_ts`
+
+// GetTaskUsage returns a task's usage, or sql.ErrNoRows.
+func GetTaskUsage(db *sql.DB, taskID string) (TaskUsage, error) {
+	return scanTaskUsage(db.QueryRow(`SELECT `+taskUsageColumns+` FROM task_usage WHERE task_id = ?`, taskID))
+}
+
+func ListTaskUsage(db *sql.DB) ([]TaskUsage, error) {
+	rows, err := db.Query(`SELECT ` + taskUsageColumns + ` FROM task_usage ORDER BY task_id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TaskUsage
+	for rows.Next() {
+		u, err := scanTaskUsage(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func scanTaskUsage(row rowScanner) (TaskUsage, error) {
+	var u TaskUsage
+	var model, ended sql.NullString
+	var started, lastSample, updated string
+	if err := row.Scan(&u.TaskID, &u.Agent, &model, &u.InputTokens, &u.OutputTokens, &u.CacheReadTokens, &u.CacheWriteTokens,
+		&u.CostUSD, &u.ActiveSeconds, &started, &lastSample, &ended, &updated); err != nil {
+		return TaskUsage{}, err
+	}
+	u.Model = model.String
+	u.StartedAt, _ = time.Parse(time.RFC3339Nano, started)
+	u.LastSampleAt, _ = time.Parse(time.RFC3339Nano, lastSample)
+	u.EndedAt = parseOptionalTime(ended)
+	u.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updated)
+	return u, nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTaskUsageAccumulatesAndTracksTime(t *testing.T) {
+	db, err := OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetTaskUsage(db, "T1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected no usage yet, got %v", err)
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	samples := []UsageSample{
+		{Agent: "claude", At: start},
+		{Agent: "claude", Model: "claude-sonnet-4-5", InputTokens: 100, OutputTokens: 20, CostUSD: 0.01, Active: true, At: start.Add(30 * time.Second)},
+		// Ten idle minutes only count up to the gap limit.
+		{Agent: "claude", InputTokens: 50, CacheReadTokens: 400, CostUSD: 0.02, Active: true, At: start.Add(10*time.Minute + 30*time.Second)},
+		{Agent: "claude", At: start.Add(11 * time.Minute)},
+	}
+	for _, s := range samples {
+		if err := AddTaskUsage(db, "T1", s, 2*time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := EndTaskUsage(db, "T1", start.Add(12*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := EndTaskUsage(db, "T2", start); err != nil {
+		t.Fatalf("ending a task without usage: %v", err)
+	}
+
+	u, err := GetTaskUsage(db, "T1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Model != "claude-sonnet-4-5" || u.InputTokens != 150 || u.OutputTokens != 20 || u.CacheReadTokens != 400 {
+		t.Fatalf("unexpected tokens %+v", u)
+	}
+	if u.CostUSD < 0.0299 || u.CostUSD > 0.0301 {
+		t.Fatalf("expected cost 0.03, got %v", u.CostUSD)
+	}
+	if u.ActiveSeconds != 150 {
+		t.Fatalf("expected 150 active seconds, got %v", u.ActiveSeconds)
+	}
+	if got := u.WallSeconds(start.Add(time.Hour)); got != 720 {
+		t.Fatalf("expected wall time to stop at the end, got %v", got)
+	}
+
+	// More output reopens the task.
+	if err := AddTaskUsage(db, "T1", UsageSample{Agent: "claude", Active: true, At: start.Add(13 * time.Minute)}, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	list, err := ListTaskUsage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || !list[0].EndedAt.IsZero() {
+		t.Fatalf("expected T1 running again, got %+v", list)
+	}
+}
@@ -0,0 +1,130 @@
+package supervisor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/tmux"
+)
+
+// TmuxProbe checks sessions through tmux and measures CPU time from /proc.
+type TmuxProbe struct {
+	Runner tmux.Runner
+	// ProcDir is the proc filesystem root; empty means /proc.
+	ProcDir string
+	// PanePID returns the pid of the session's pane process.
+	PanePID func(sessionID string) (int, error)
+	// RestartFunc relaunches a session; nil makes restarts fail.
+	RestartFunc func(s Session) error
+}
+
+func NewTmuxProbe(restart func(s Session) error) *TmuxProbe {
+	return &TmuxProbe{Runner: &tmux.ExecRunner{}, PanePID: tmuxPanePID, RestartFunc: restart}
+}
+
+func (p *TmuxProbe) Alive(sessionID string) bool {
+	return tmux.HasSession(p.Runner, sessionID)
+}
+
+func (p *TmuxProbe) Restart(s Session) error {
+	if p.RestartFunc == nil {
+		return errRestartUnsupported
+	}
+	return p.RestartFunc(s)
+}
+
+// CPUTicks sums user and system clock ticks over the pane process and all of
+// its descendants, so an agent started from the pane's shell is counted.
+func (p *TmuxProbe) CPUTicks(sessionID string) (int64, bool) {
+	if p.PanePID == nil {
+		return 0, false
+	}
+	pid, err := p.PanePID(sessionID)
+	if err != nil {
+		return 0, false
+	}
+	procs := readProcs(p.procDir())
+	if _, ok := procs[pid]; !ok {
+		return 0, false
+	}
+	children := make(map[int][]int)
+	for child, st := range procs {
+		children[st.ppid] = append(children[st.ppid], child)
+	}
+	var total int64
+	stack := []int{pid}
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		total += procs[cur].ticks
+		stack = append(stack, children[cur]...)
+	}
+	return total, true
+}
+
+func (p *TmuxProbe) procDir() string {
+	if p.ProcDir != "" {
+		return p.ProcDir
+	}
+	return "/proc"
+}
+
+type procStat struct {
+	ppid  int
+	ticks int64
+}
+
+func readProcs(dir string) map[int]procStat {
+	out := make(map[int]procStat)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return out
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		if st, ok := parseProcStat(string(raw)); ok {
+			out[pid] = st
+		}
+	}
+	return out
+}
+
+// parseProcStat reads ppid, utime and stime from a /proc/<pid>/stat line.
+// The command name is parenthesised and may contain spaces, so fields are
+// counted from the last closing parenthesis.
+func parseProcStat(line string) (procStat, bool) {
+	end := strings.LastIndexByte(line, ')')
+	if end < 0 {
+		return procStat{}, false
+	}
+	fields := strings.Fields(line[end+1:])
+	// fields[0] is state; ppid is field 4, utime 14 and stime 15 in proc(5).
+	if len(fields) < 13 {
+		return procStat{}, false
+	}
+	ppid, err1 := strconv.Atoi(fields[1])
+	utime, err2 := strconv.ParseInt(fields[11], 10, 64)
+	stime, err3 := strconv.ParseInt(fields[12], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return procStat{}, false
+	}
+	return procStat{ppid: ppid, ticks: utime + stime}, true
+}
+
+func tmuxPanePID(sessionID string) (int, error) {
+	out, err := exec.Command("tmux", "display-message", "-p", "-t", sessionID, "#{pane_pid}").Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
@@ -0,0 +1,30 @@
+package supervisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTmuxProbeSumsCPUOverProcessTree(t *testing.T) {
+	dir := t.TempDir()
+	stats := map[string]string{
+		"100": "100 (bash) S 1 100 100 0 -1 0 0 0 0 0 3 2 0 0 20 0 1 0",
+		"101": "101 (claude code) R 100 100 100 0 -1 0 0 0 0 0 40 10 0 0 20 0 1 0",
+		"102": "102 (node) S 101 100 100 0 -1 0 0 0 0 0 5 5 0 0 20 0 1 0",
+		"200": "200 (other) S 1 200 200 0 -1 0 0 0 0 0 99 99 0 0 20 0 1 0",
+	}
+	for pid, line := range stats {
+		if err := os.MkdirAll(filepath.Join(dir, pid), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, pid, "stat"), []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := &TmuxProbe{ProcDir: dir, PanePID: func(string) (int, error) { return 100, nil }}
+	ticks, ok := p.CPUTicks("tand-TAND-001")
+	if !ok || ticks != 65 {
+		t.Fatalf("expected 65 ticks, got %d %v", ticks, ok)
+	}
+}
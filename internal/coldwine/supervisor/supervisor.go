@@ -0,0 +1,387 @@
+// Package supervisor watches agent sessions for signs of life beyond "the
+// tmux session exists". A session is healthy while its log grows, its agent
+// reports heartbeats through storage.TouchAgentSession, or both. A live
+// session that does neither for the stall timeout is stalled; CPU activity
+// tells a busy loop from an agent idling at a prompt. Dead and stalled
+// sessions are restarted according to the restart policy and escalated
+// through Coldwine mail when the policy gives up.
+package supervisor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+	"github.com/mistakeknot/autarch/internal/coldwine/tmux"
+)
+
+// Session health states.
+const (
+	StatusHealthy    = "healthy"
+	StatusStalled    = "stalled"
+	StatusDead       = "dead"
+	StatusRestarting = "restarting" // waiting out a backoff delay
+	StatusFailed     = "failed"     // the policy gave up; needs a reset
+)
+
+// Incident kinds.
+const (
+	IncidentExited        = "exited"
+	IncidentStalled       = "stalled"
+	IncidentRecovered     = "recovered"
+	IncidentRestarted     = "restarted"
+	IncidentRestartFailed = "restart_failed"
+	IncidentGaveUp        = "gave_up"
+)
+
+// Restart policy modes.
+const (
+	PolicyNever     = "never"
+	PolicyOnFailure = "on-failure"
+	PolicyBackoff   = "backoff"
+)
+
+// Sender is the mail sender for escalations.
+const Sender = "supervisor"
+
+// defaultStableAfter is how long a restarted session must stay healthy
+// before its restart count is forgiven when the policy has no MaxBackoff.
+const defaultStableAfter = 10 * time.Minute
+
+// Policy decides whether and when a dead or stalled session is restarted.
+type Policy struct {
+	Mode        string
+	MaxAttempts int           // 0 means unlimited
+	Backoff     time.Duration // first backoff delay, doubled per attempt
+	MaxBackoff  time.Duration // also the uptime after which restarts reset
+}
+
+// ParsePolicy validates a policy mode name.
+func ParsePolicy(mode string, maxAttempts int, backoff time.Duration) (Policy, error) {
+	p := Policy{Mode: strings.TrimSpace(mode), MaxAttempts: maxAttempts, Backoff: backoff}
+	switch p.Mode {
+	case PolicyNever, PolicyOnFailure, PolicyBackoff:
+	default:
+		return p, fmt.Errorf("unknown restart policy %q (use never, on-failure or backoff)", mode)
+	}
+	if p.Mode == PolicyBackoff && p.Backoff <= 0 {
+		p.Backoff = 30 * time.Second
+	}
+	return p, nil
+}
+
+// delay is the wait before restart attempt n (0-based).
+func (p Policy) delay(n int) time.Duration {
+	if p.Mode != PolicyBackoff {
+		return 0
+	}
+	d := p.Backoff
+	for i := 0; i < n; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return d
+}
+
+// stableAfter is the uptime after a restart that resets the restart count,
+// so a session that recovered is not given up on for an unrelated failure
+// hours later.
+func (p Policy) stableAfter() time.Duration {
+	if p.MaxBackoff > 0 {
+		return p.MaxBackoff
+	}
+	return defaultStableAfter
+}
+
+// Probe inspects and restarts sessions.
+type Probe interface {
+	Alive(sessionID string) bool
+	// CPUTicks returns cumulative CPU time for the session's processes,
+	// or false when it cannot be measured.
+	CPUTicks(sessionID string) (int64, bool)
+	Restart(s Session) error
+}
+
+// Session is one supervised agent session.
+type Session struct {
+	ID      string
+	TaskID  string
+	LogPath string
+}
+
+// Options configures a Supervisor.
+type Options struct {
+	StallAfter time.Duration // zero disables stall detection
+	Policy     Policy
+	EscalateTo []string
+	Now        func() time.Time
+}
+
+// Supervisor checks sessions and applies the restart policy.
+type Supervisor struct {
+	db    *sql.DB
+	probe Probe
+	opts  Options
+}
+
+func New(db *sql.DB, probe Probe, opts Options) *Supervisor {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.Policy.Mode == "" {
+		opts.Policy.Mode = PolicyNever
+	}
+	return &Supervisor{db: db, probe: probe, opts: opts}
+}
+
+// Check runs one health pass over a session and returns its updated state
+// with any incidents recorded during the pass.
+func (s *Supervisor) Check(sess Session) (storage.AgentHealth, []storage.AgentIncident, error) {
+	now := s.opts.Now().UTC()
+	h, err := storage.GetAgentHealth(s.db, sess.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		h = storage.AgentHealth{SessionID: sess.ID, TaskID: sess.TaskID, Status: StatusHealthy, LastOutputAt: now, LastCPUAt: now}
+	} else if err != nil {
+		return h, nil, err
+	}
+	if h.Status == StatusFailed {
+		return h, nil, nil
+	}
+	var incidents []storage.AgentIncident
+	record := func(kind, detail, action string) error {
+		inc, err := storage.AddAgentIncident(s.db, storage.AgentIncident{
+			SessionID: sess.ID, TaskID: sess.TaskID, Kind: kind, Detail: detail, Action: action, CreatedAt: now,
+		})
+		if err == nil {
+			incidents = append(incidents, inc)
+		}
+		return err
+	}
+
+	progressed := s.readProgress(sess, &h, now)
+	alive := s.probe.Alive(sess.ID)
+	if ticks, ok := s.probe.CPUTicks(sess.ID); ok && ticks != h.CPUTicks {
+		h.CPUTicks = ticks
+		h.LastCPUAt = now
+	}
+
+	var problem, detail string
+	switch {
+	case !alive:
+		problem, detail = IncidentExited, "tmux session is gone"
+	case s.opts.StallAfter > 0 && now.Sub(h.LastOutputAt) >= s.opts.StallAfter:
+		problem = IncidentStalled
+		idle := now.Sub(h.LastOutputAt).Round(time.Second)
+		if now.Sub(h.LastCPUAt) < s.opts.StallAfter {
+			detail = fmt.Sprintf("no output or heartbeat for %s while using CPU; possibly looping", idle)
+		} else {
+			detail = fmt.Sprintf("no output, heartbeat or CPU for %s; possibly waiting at a prompt", idle)
+		}
+	}
+
+	if problem == "" {
+		if h.Status != StatusHealthy && progressed {
+			if err := record(IncidentRecovered, "output resumed", ""); err != nil {
+				return h, incidents, err
+			}
+		}
+		h.Status, h.Detail, h.NextRestartAt = StatusHealthy, "", time.Time{}
+		if h.Restarts > 0 && now.Sub(h.RestartedAt) >= s.opts.Policy.stableAfter() {
+			h.Restarts = 0
+		}
+		return h, incidents, storage.UpsertAgentHealth(s.db, h)
+	}
+
+	status := StatusDead
+	if problem == IncidentStalled {
+		status = StatusStalled
+	}
+	switch {
+	case h.Status == StatusRestarting && now.Before(h.NextRestartAt):
+		// Waiting out the backoff.
+	case h.Status == StatusRestarting:
+		if err := s.restart(sess, &h, now, record); err != nil {
+			return h, incidents, err
+		}
+	case h.Status == status:
+		// Already reported; the policy has nothing more to do.
+	default:
+		h.Status, h.Detail = status, detail
+		if err := s.apply(sess, &h, problem, detail, now, record); err != nil {
+			return h, incidents, err
+		}
+	}
+	return h, incidents, storage.UpsertAgentHealth(s.db, h)
+}
+
+func (s *Supervisor) apply(sess Session, h *storage.AgentHealth, problem, detail string, now time.Time, record func(kind, detail, action string) error) error {
+	p := s.opts.Policy
+	switch {
+	case p.Mode == PolicyNever:
+		if err := record(problem, detail, "escalated"); err != nil {
+			return err
+		}
+		return s.escalate(sess, problem, detail)
+	case p.MaxAttempts > 0 && h.Restarts >= p.MaxAttempts:
+		if err := record(problem, detail, ""); err != nil {
+			return err
+		}
+		return s.giveUp(sess, h, fmt.Sprintf("%d restart(s) used", h.Restarts), record)
+	}
+	wait := p.delay(h.Restarts)
+	if wait <= 0 {
+		if err := record(problem, detail, "restarting"); err != nil {
+			return err
+		}
+		return s.restart(sess, h, now, record)
+	}
+	h.Status = StatusRestarting
+	h.NextRestartAt = now.Add(wait)
+	return record(problem, detail, fmt.Sprintf("restart in %s", wait))
+}
+
+func (s *Supervisor) restart(sess Session, h *storage.AgentHealth, now time.Time, record func(kind, detail, action string) error) error {
+	h.Restarts++
+	h.RestartedAt = now
+	h.NextRestartAt = time.Time{}
+	if err := s.probe.Restart(sess); err != nil {
+		h.Detail = "restart failed: " + err.Error()
+		if max := s.opts.Policy.MaxAttempts; max > 0 && h.Restarts >= max {
+			if recErr := record(IncidentRestartFailed, err.Error(), ""); recErr != nil {
+				return recErr
+			}
+			return s.giveUp(sess, h, err.Error(), record)
+		}
+		// Try again on a later pass, after the policy's delay.
+		wait := s.opts.Policy.delay(h.Restarts)
+		h.Status = StatusRestarting
+		h.NextRestartAt = now.Add(wait)
+		if recErr := record(IncidentRestartFailed, err.Error(), "escalated"); recErr != nil {
+			return recErr
+		}
+		return s.escalate(sess, IncidentRestartFailed, err.Error())
+	}
+	h.Status, h.Detail = StatusHealthy, ""
+	h.LastOutputAt, h.LastCPUAt = now, now
+	return record(IncidentRestarted, fmt.Sprintf("attempt %d", h.Restarts), "")
+}
+
+func (s *Supervisor) giveUp(sess Session, h *storage.AgentHealth, detail string, record func(kind, detail, action string) error) error {
+	h.Status, h.Detail = StatusFailed, "gave up: "+detail
+	h.NextRestartAt = time.Time{}
+	if err := record(IncidentGaveUp, detail, "escalated"); err != nil {
+		return err
+	}
+	return s.escalate(sess, IncidentGaveUp, detail)
+}
+
+// readProgress advances the log offset and folds heartbeats into
+// LastOutputAt. It reports whether anything new was seen.
+func (s *Supervisor) readProgress(sess Session, h *storage.AgentHealth, now time.Time) bool {
+	progressed := false
+	if sess.LogPath != "" {
+		lines, offset, err := tmux.ReadFromOffset(sess.LogPath, h.LogOffset)
+		if err == nil {
+			if offset > h.LogOffset && len(lines) > 0 {
+				progressed = true
+				h.LastOutputAt = now
+				// Output is a heartbeat; record it for other readers.
+				_ = storage.TouchAgentSession(s.db, sess.ID)
+			}
+			h.LogOffset = offset
+		}
+	}
+	if as, err := storage.GetAgentSession(s.db, sess.ID); err == nil && as.LastActiveAt.After(h.LastOutputAt) {
+		progressed = true
+		h.LastOutputAt = as.LastActiveAt.UTC()
+	}
+	return progressed
+}
+
+func (s *Supervisor) escalate(sess Session, kind, detail string) error {
+	if len(s.opts.EscalateTo) == 0 {
+		return nil
+	}
+	body := fmt.Sprintf("Session %s (task %s): %s\n%s", sess.ID, sess.TaskID, kind, detail)
+	if tail := logTail(sess.LogPath, 20); tail != "" {
+		body += "\n\nLast output:\n" + tail
+	}
+	return storage.SendMessage(s.db, storage.Message{
+		ID:          fmt.Sprintf("msg-%d", time.Now().UTC().UnixNano()),
+		ThreadID:    "health-" + sess.ID,
+		Sender:      Sender,
+		Subject:     fmt.Sprintf("Agent %s %s", sess.ID, strings.ReplaceAll(kind, "_", " ")),
+		Body:        body,
+		Importance:  "urgent",
+		AckRequired: true,
+	}, s.opts.EscalateTo)
+}
+
+func logTail(path string, n int) string {
+	if path == "" {
+		return ""
+	}
+	lines, _, err := tmux.ReadFromOffset(path, 0)
+	if err != nil {
+		return ""
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckAll checks every session, continuing past per-session errors.
+func (s *Supervisor) CheckAll(sessions []Session) ([]storage.AgentHealth, []storage.AgentIncident, error) {
+	var states []storage.AgentHealth
+	var incidents []storage.AgentIncident
+	var errs []error
+	for _, sess := range sessions {
+		h, inc, err := s.Check(sess)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sess.ID, err))
+			continue
+		}
+		states = append(states, h)
+		incidents = append(incidents, inc...)
+	}
+	return states, incidents, errors.Join(errs...)
+}
+
+// Run checks the sessions returned by list every interval until ctx ends.
+// onIncident, if set, is called for each new incident.
+func (s *Supervisor) Run(ctx context.Context, interval time.Duration, list func() ([]Session, error), onIncident func(storage.AgentIncident)) error {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		sessions, err := list()
+		if err != nil {
+			return err
+		}
+		_, incidents, err := s.CheckAll(sessions)
+		if onIncident != nil {
+			for _, inc := range incidents {
+				onIncident(inc)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+var errRestartUnsupported = errors.New("no restart command configured")
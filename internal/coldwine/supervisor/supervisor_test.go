@@ -0,0 +1,253 @@
+package supervisor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/coldwine/storage"
+)
+
+type fakeProbe struct {
+	alive      bool
+	ticks      int64
+	restarts   int
+	restartErr error
+}
+
+func (f *fakeProbe) Alive(string) bool { return f.alive }
+
+func (f *fakeProbe) CPUTicks(string) (int64, bool) { return f.ticks, true }
+
+func (f *fakeProbe) Restart(Session) error {
+	f.restarts++
+	if f.restartErr != nil {
+		return f.restartErr
+	}
+	f.alive = true
+	return nil
+}
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func setup(t *testing.T, policy Policy) (*Supervisor, *fakeProbe, *clock, Session) {
+	t.Helper()
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.MigrateV2(db); err != nil {
+		t.Fatal(err)
+	}
+	probe := &fakeProbe{alive: true}
+	c := &clock{t: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	sup := New(db, probe, Options{StallAfter: 10 * time.Minute, Policy: policy, EscalateTo: []string{"ops"}, Now: c.now})
+	sess := Session{ID: "tand-TAND-001", TaskID: "TAND-001", LogPath: filepath.Join(t.TempDir(), "tand-TAND-001.log")}
+	return sup, probe, c, sess
+}
+
+func appendLog(t *testing.T, path, line string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func check(t *testing.T, sup *Supervisor, sess Session) (storage.AgentHealth, []string) {
+	t.Helper()
+	h, incidents, err := sup.Check(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, inc := range incidents {
+		kinds = append(kinds, inc.Kind)
+	}
+	return h, kinds
+}
+
+func escalations(t *testing.T, sup *Supervisor) []storage.MessageDelivery {
+	t.Helper()
+	msgs, err := storage.FetchInbox(sup.db, "ops", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msgs
+}
+
+func TestCheckDetectsStallAtPromptAndEscalates(t *testing.T) {
+	sup, _, c, sess := setup(t, Policy{Mode: PolicyNever})
+	appendLog(t, sess.LogPath, "Reading files...")
+	if h, kinds := check(t, sup, sess); h.Status != StatusHealthy || len(kinds) != 0 {
+		t.Fatalf("expected healthy, got %s %v", h.Status, kinds)
+	}
+
+	c.advance(5 * time.Minute)
+	appendLog(t, sess.LogPath, "Do you want to proceed? (y/n)")
+	check(t, sup, sess)
+	c.advance(11 * time.Minute)
+	h, kinds := check(t, sup, sess)
+	if h.Status != StatusStalled || !reflect.DeepEqual(kinds, []string{IncidentStalled}) {
+		t.Fatalf("expected stall, got %s %v", h.Status, kinds)
+	}
+	if !strings.Contains(h.Detail, "waiting at a prompt") {
+		t.Fatalf("expected idle stall detail, got %q", h.Detail)
+	}
+	msgs := escalations(t, sup)
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Message.Body, "Do you want to proceed?") {
+		t.Fatalf("expected escalation with log tail, got %+v", msgs)
+	}
+
+	// Still stalled: no duplicate incident or mail.
+	c.advance(time.Minute)
+	if _, kinds := check(t, sup, sess); len(kinds) != 0 {
+		t.Fatalf("expected no new incidents, got %v", kinds)
+	}
+	appendLog(t, sess.LogPath, "y")
+	if h, kinds := check(t, sup, sess); h.Status != StatusHealthy || !reflect.DeepEqual(kinds, []string{IncidentRecovered}) {
+		t.Fatalf("expected recovery, got %s %v", h.Status, kinds)
+	}
+}
+
+func TestCheckTreatsHeartbeatsAsProgressAndFlagsBusyLoops(t *testing.T) {
+	sup, probe, c, sess := setup(t, Policy{Mode: PolicyNever})
+	check(t, sup, sess)
+	if _, err := sup.db.Exec(`INSERT INTO epics (id, title, created_at, updated_at) VALUES ('E', 'E', '', '');
+		INSERT INTO stories (id, epic_id, title, created_at, updated_at) VALUES ('S', 'E', 'S', '', '');
+		INSERT INTO work_tasks (id, story_id, title, created_at, updated_at) VALUES ('TAND-001', 'S', 'T', '', '')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.InsertAgentSession(sup.db, storage.AgentSession{ID: sess.ID, TaskID: "TAND-001", AgentName: "a", AgentProgram: "claude", State: "working"}); err != nil {
+		t.Fatal(err)
+	}
+	// The heartbeat is stamped with the wall clock; move the fake clock to it.
+	c.t = time.Now().UTC().Add(9 * time.Minute)
+	if h, _ := check(t, sup, sess); h.Status != StatusHealthy {
+		t.Fatalf("heartbeat should keep the session healthy, got %s", h.Status)
+	}
+
+	c.advance(11 * time.Minute)
+	probe.ticks = 5000
+	h, _ := check(t, sup, sess)
+	if h.Status != StatusStalled || !strings.Contains(h.Detail, "possibly looping") {
+		t.Fatalf("expected busy stall, got %s %q", h.Status, h.Detail)
+	}
+}
+
+func TestCheckRestartsDeadSessionsWithBackoffUntilGivingUp(t *testing.T) {
+	sup, probe, c, sess := setup(t, Policy{Mode: PolicyBackoff, MaxAttempts: 2, Backoff: time.Minute})
+	check(t, sup, sess)
+
+	probe.alive = false
+	h, kinds := check(t, sup, sess)
+	if h.Status != StatusRestarting || !reflect.DeepEqual(kinds, []string{IncidentExited}) || probe.restarts != 0 {
+		t.Fatalf("expected scheduled restart, got %s %v restarts=%d", h.Status, kinds, probe.restarts)
+	}
+	c.advance(30 * time.Second)
+	if _, kinds := check(t, sup, sess); len(kinds) != 0 || probe.restarts != 0 {
+		t.Fatalf("restarted before backoff: %v", kinds)
+	}
+	c.advance(31 * time.Second)
+	h, kinds = check(t, sup, sess)
+	if h.Status != StatusHealthy || !reflect.DeepEqual(kinds, []string{IncidentRestarted}) || probe.restarts != 1 {
+		t.Fatalf("expected restart, got %s %v", h.Status, kinds)
+	}
+
+	// Second death waits twice as long.
+	probe.alive = false
+	h, _ = check(t, sup, sess)
+	if got := h.NextRestartAt.Sub(c.t); got != 2*time.Minute {
+		t.Fatalf("expected 2m backoff, got %s", got)
+	}
+	c.advance(2 * time.Minute)
+	check(t, sup, sess)
+
+	probe.alive = false
+	h, kinds = check(t, sup, sess)
+	if h.Status != StatusFailed || !reflect.DeepEqual(kinds, []string{IncidentExited, IncidentGaveUp}) {
+		t.Fatalf("expected give up, got %s %v", h.Status, kinds)
+	}
+	if msgs := escalations(t, sup); len(msgs) != 1 || !strings.Contains(msgs[0].Message.Subject, "gave up") {
+		t.Fatalf("expected give-up escalation, got %+v", msgs)
+	}
+	if _, kinds := check(t, sup, sess); len(kinds) != 0 {
+		t.Fatalf("failed session should stay quiet, got %v", kinds)
+	}
+	history, _ := storage.ListAgentIncidents(sup.db, sess.ID, 0)
+	if len(history) != 6 {
+		t.Fatalf("expected 6 incidents in history, got %d", len(history))
+	}
+}
+
+func TestCheckOnFailureEscalatesRestartErrors(t *testing.T) {
+	sup, probe, _, sess := setup(t, Policy{Mode: PolicyOnFailure, MaxAttempts: 3})
+	probe.alive = false
+	probe.restartErr = errors.New("worktree missing")
+	h, kinds := check(t, sup, sess)
+	if h.Status != StatusRestarting || !reflect.DeepEqual(kinds, []string{IncidentExited, IncidentRestartFailed}) {
+		t.Fatalf("unexpected %s %v", h.Status, kinds)
+	}
+	if msgs := escalations(t, sup); len(msgs) != 1 {
+		t.Fatalf("expected restart failure escalation, got %d", len(msgs))
+	}
+	probe.restartErr = nil
+	if h, kinds := check(t, sup, sess); h.Status != StatusHealthy || !reflect.DeepEqual(kinds, []string{IncidentRestarted}) {
+		t.Fatalf("expected retry to succeed, got %s %v", h.Status, kinds)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	if _, err := ParsePolicy("sometimes", 0, 0); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+	p, err := ParsePolicy("backoff", 3, 0)
+	if err != nil || p.Backoff != 30*time.Second {
+		t.Fatalf("unexpected %+v %v", p, err)
+	}
+	p.MaxBackoff = 90 * time.Second
+	if got := []time.Duration{p.delay(0), p.delay(1), p.delay(2)}; !reflect.DeepEqual(got, []time.Duration{30 * time.Second, 60 * time.Second, 90 * time.Second}) {
+		t.Fatalf("unexpected delays %v", got)
+	}
+}
+
+func TestCheckResetsRestartsAfterStableUptime(t *testing.T) {
+	sup, probe, c, sess := setup(t, Policy{Mode: PolicyOnFailure, MaxAttempts: 1, MaxBackoff: 30 * time.Minute})
+	probe.alive = false
+	if h, _ := check(t, sup, sess); h.Restarts != 1 {
+		t.Fatalf("expected one restart, got %d", h.Restarts)
+	}
+	c.advance(20 * time.Minute)
+	appendLog(t, sess.LogPath, "working")
+	if h, _ := check(t, sup, sess); h.Restarts != 1 {
+		t.Fatalf("restarts reset before the stable window: %d", h.Restarts)
+	}
+	c.advance(10 * time.Minute)
+	appendLog(t, sess.LogPath, "still working")
+	if h, _ := check(t, sup, sess); h.Restarts != 0 {
+		t.Fatalf("expected restarts to reset after stable uptime, got %d", h.Restarts)
+	}
+
+	// A later crash starts the count afresh instead of giving up.
+	probe.alive = false
+	h, kinds := check(t, sup, sess)
+	if h.Status != StatusHealthy || h.Restarts != 1 || !reflect.DeepEqual(kinds, []string{IncidentExited, IncidentRestarted}) {
+		t.Fatalf("expected a fresh restart, got %s restarts=%d %v", h.Status, h.Restarts, kinds)
+	}
+}
@@ -18,6 +18,7 @@ type TaskProposal struct {
 	Type        TaskType `yaml:"type"`
 	Priority    epics.Priority `yaml:"priority"`
 	Dependencies []string `yaml:"dependencies,omitempty"`
+	Agent       string   `yaml:"agent,omitempty"` // Agent target; empty uses the default
 	Ready       bool     `yaml:"-"` // Computed: no blockers
 	Edited      bool     `yaml:"-"` // User has modified
 }
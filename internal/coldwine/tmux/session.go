@@ -2,6 +2,7 @@ package tmux
 
 import (
 	"fmt"
+	"os/exec"
 	"regexp"
 	"strings"
 )
@@ -31,6 +32,47 @@ func StopSession(r Runner, id string) error {
 	return r.Run("tmux", "kill-session", "-t", id)
 }
 
+// HasSession reports whether a tmux session with the given ID exists.
+func HasSession(r Runner, id string) bool {
+	return r.Run("tmux", "has-session", "-t", id) == nil
+}
+
+// SendLine types a command line into the session and presses Enter.
+func SendLine(r Runner, id, line string) error {
+	return r.Run("tmux", "send-keys", "-t", id, line, "Enter")
+}
+
+// SendText types text literally into the session's active pane and presses
+// Enter. The session name is matched exactly, and -l keeps tmux from reading
+// words such as "Enter" or "C-c" in the text as key names.
+func SendText(r Runner, id, text string) error {
+	target := "=" + id + ":"
+	if err := r.Run("tmux", "send-keys", "-t", target, "-l", "--", text); err != nil {
+		return err
+	}
+	return r.Run("tmux", "send-keys", "-t", target, "Enter")
+}
+
+// HasExactSession is HasSession without tmux's prefix matching, so "tand-1"
+// does not match a session named "tand-10".
+func HasExactSession(r Runner, id string) bool {
+	return r.Run("tmux", "has-session", "-t", "="+id) == nil
+}
+
+// PaneCommand returns the command running in the session's active pane.
+func PaneCommand(id string) (string, error) {
+	out, err := exec.Command("tmux", "display-message", "-p", "-t", "="+id+":", "#{pane_current_command}").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ShellQuote quotes value for use as a single POSIX shell word.
+func ShellQuote(value string) string {
+	return shellQuote(value)
+}
+
 func shellQuote(value string) string {
 	return "'" + strings.ReplaceAll(value, "'", "'\"'\"'") + "'"
 }
@@ -49,3 +49,19 @@ func TestStartSessionRejectsLogPathWithShellMeta(t *testing.T) {
 		t.Fatalf("expected no commands, got %d", len(r.cmds))
 	}
 }
+
+func TestSendLineBuildsCommand(t *testing.T) {
+	r := &fakeRunner{}
+	if err := SendLine(r, "tand-TAND-004", "claude 'do it'"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"tmux", "send-keys", "-t", "tand-TAND-004", "claude 'do it'", "Enter"}
+	if len(r.cmds) != 1 || len(r.cmds[0]) != len(want) {
+		t.Fatalf("unexpected commands: %v", r.cmds)
+	}
+	for i := range want {
+		if r.cmds[0][i] != want[i] {
+			t.Fatalf("arg %d: got %q, want %q", i, r.cmds[0][i], want[i])
+		}
+	}
+}
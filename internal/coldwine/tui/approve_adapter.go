@@ -3,6 +3,7 @@ package tui
 import (
 	"database/sql"
 
+	"github.com/mistakeknot/autarch/internal/coldwine/acceptance"
 	"github.com/mistakeknot/autarch/internal/coldwine/git"
 	"github.com/mistakeknot/autarch/internal/coldwine/project"
 	"github.com/mistakeknot/autarch/internal/coldwine/storage"
@@ -11,26 +12,40 @@ import (
 type ApproveAdapter struct {
 	DB     *sql.DB
 	Runner git.Runner
+	// Queue sends approved branches to the merge queue instead of merging
+	// them directly.
+	Queue bool
 }
 
+// Approve refuses tasks with unverified acceptance criteria before touching
+// the branch. Outside a project there are no specs, so nothing is gated.
 func (a *ApproveAdapter) Approve(taskID, branch string) error {
-	runner := a.Runner
-	if runner == nil {
-		runner = &git.ExecRunner{}
-	}
-	if err := git.MergeBranch(runner, branch); err != nil {
-		return err
-	}
+	root, rootErr := project.FindRoot(".")
 	db := a.DB
 	if db == nil {
-		root, err := project.FindRoot(".")
-		if err != nil {
-			return err
+		if rootErr != nil {
+			return rootErr
 		}
+		var err error
 		db, err = storage.OpenShared(project.StateDBPath(root))
 		if err != nil {
 			return err
 		}
 	}
+	if rootErr == nil {
+		if err := acceptance.CheckApproval(db, root, taskID, branch); err != nil {
+			return err
+		}
+	}
+	if a.Queue {
+		return storage.QueueMerge(db, taskID, branch)
+	}
+	runner := a.Runner
+	if runner == nil {
+		runner = &git.ExecRunner{}
+	}
+	if err := git.MergeBranch(runner, branch); err != nil {
+		return err
+	}
 	return storage.ApproveTask(db, taskID)
 }
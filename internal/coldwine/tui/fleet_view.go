@@ -1,3 +1,41 @@
 package tui
 
-// Placeholder for fleet view rendering helpers.
+import (
+	"fmt"
+	"time"
+)
+
+func hasUsage(task TaskItem) bool {
+	return task.Tokens > 0 || task.CostUSD > 0
+}
+
+// fleetUsageSummary is the cost and token total for the summary line, or
+// nothing before any usage has been recorded.
+func fleetUsageSummary(list []TaskItem) string {
+	var tokens int64
+	var cost float64
+	for _, t := range list {
+		tokens += t.Tokens
+		cost += t.CostUSD
+	}
+	if tokens == 0 && cost == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" | Cost: $%.2f | Tokens: %s", cost, compactCount(tokens))
+}
+
+func formatTaskUsage(task TaskItem) string {
+	active := (time.Duration(task.ActiveSeconds) * time.Second).Round(time.Second)
+	return fmt.Sprintf("$%.2f, %s tokens, %s active", task.CostUSD, compactCount(task.Tokens), active)
+}
+
+// compactCount renders large counts as 12.3k or 4.5M.
+func compactCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -36,6 +36,7 @@ type Model struct {
 	Status               string
 	StatusLevel          StatusLevel
 	ConfirmApprove       bool
+	QueueMerges          bool
 	ViewMode             ViewMode
 	Review               ReviewState
 	TaskList             []TaskItem
@@ -682,8 +683,14 @@ func (m Model) View() string {
 		}
 		out += "\nTESTS: " + m.Review.Detail.TestsSummary + "\n\n"
 		out += "ACCEPTANCE CRITERIA\n"
-		for _, ac := range m.Review.Detail.AcceptanceCriteria {
-			out += "- " + ac + "\n"
+		if len(m.Review.Detail.Acceptance) > 0 {
+			for _, ac := range m.Review.Detail.Acceptance {
+				out += fmt.Sprintf("- [%s] %s %s\n", ac.Status, ac.ID, ac.Text)
+			}
+		} else {
+			for _, ac := range m.Review.Detail.AcceptanceCriteria {
+				out += "- " + ac + "\n"
+			}
 		}
 		out += "\n[d]iff  [a]pprove  [f]eedback  [X]reject  [e]dit story  [b]ack\n"
 		return render(out)
@@ -701,10 +708,11 @@ func (m Model) View() string {
 	if m.RightTab == RightTabCoord {
 		coordSummary = fmt.Sprintf(" | coord: urgent=%s recipient=%s", coordOnOff(m.CoordUrgentOnly), m.coordRecipientFilterLabel())
 	}
-	summary := fmt.Sprintf("Tasks: %d | Running: %d | Review: %d | filter: %s | search: %s%s\n\n",
+	summary := fmt.Sprintf("Tasks: %d | Running: %d | Review: %d%s | filter: %s | search: %s%s\n\n",
 		len(m.TaskList),
 		countStatus(m.TaskList, "in_progress"),
 		countStatus(m.TaskList, "review"),
+		fleetUsageSummary(m.TaskList),
 		filterLabel,
 		searchLabel,
 		coordSummary,
@@ -798,6 +806,9 @@ func (m Model) View() string {
 			sessionLine += " " + detail.SessionState
 		}
 		right = append(right, sessionLine)
+		if task, ok := m.selectedTask(); ok && task.ID == detail.ID && hasUsage(task) {
+			right = append(right, "Usage: "+formatTaskUsage(task))
+		}
 		md := "## Summary\n"
 		if detail.Summary != "" {
 			md += detail.Summary + "\n\n"
@@ -1509,6 +1520,9 @@ func (m *Model) approveTaskByID(taskID string) error {
 		approver = &ApproveAdapter{}
 		m.Review.Approver = approver
 	}
+	if adapter, ok := approver.(*ApproveAdapter); ok {
+		adapter.Queue = m.QueueMerges
+	}
 	lookup := m.Review.BranchLookup
 	if lookup == nil {
 		lookup = func(taskID string) (string, error) {
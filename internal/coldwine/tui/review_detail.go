@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/mistakeknot/autarch/internal/coldwine/acceptance"
 	"github.com/mistakeknot/autarch/internal/coldwine/config"
 	"github.com/mistakeknot/autarch/internal/coldwine/git"
 	"github.com/mistakeknot/autarch/internal/coldwine/project"
@@ -19,6 +20,13 @@ type ReviewFile struct {
 	Deleted int
 }
 
+// AcceptanceEvidence is a criterion with the outcome of its last check.
+type AcceptanceEvidence struct {
+	ID     string
+	Text   string
+	Status string // a storage.Acceptance* status, or "unverified"
+}
+
 type ReviewDetail struct {
 	TaskID             string
 	Title              string
@@ -27,6 +35,7 @@ type ReviewDetail struct {
 	StoryDrift         string
 	Alignment          string
 	AcceptanceCriteria []string
+	Acceptance         []AcceptanceEvidence
 	Files              []ReviewFile
 	TestsSummary       string
 }
@@ -93,6 +102,10 @@ func LoadReviewDetailWithDB(db *sql.DB, taskID string) (ReviewDetail, error) {
 	if testsSummary == "" {
 		testsSummary = "Tests: unknown"
 	}
+	var evidence []AcceptanceEvidence
+	if db != nil {
+		evidence = loadAcceptanceEvidence(db, specPath, taskID)
+	}
 	storyDrift := "unknown"
 	if detail.UserStoryHash != "" && detail.UserStory != "" {
 		if detail.UserStoryHash == specs.StoryHash(detail.UserStory) {
@@ -117,9 +130,31 @@ func LoadReviewDetailWithDB(db *sql.DB, taskID string) (ReviewDetail, error) {
 		StoryDrift:         storyDrift,
 		Alignment:          alignment,
 		AcceptanceCriteria: detail.AcceptanceCriteria,
+		Acceptance:         evidence,
 		Files:              files,
 		TestsSummary:       testsSummary,
 	}, nil
 }
 
+func loadAcceptanceEvidence(db *sql.DB, specPath, taskID string) []AcceptanceEvidence {
+	criteria, err := acceptance.LoadTaskCriteria(specPath)
+	if err != nil || len(criteria) == 0 {
+		return nil
+	}
+	results, _ := storage.ListAcceptanceResults(db, taskID)
+	status := make(map[string]string, len(results))
+	for _, r := range results {
+		status[r.CriterionID] = r.Status
+	}
+	out := make([]AcceptanceEvidence, 0, len(criteria))
+	for _, c := range criteria {
+		s := status[c.ID]
+		if s == "" {
+			s = "unverified"
+		}
+		out = append(out, AcceptanceEvidence{ID: c.ID, Text: acceptance.Summary(c), Status: s})
+	}
+	return out
+}
+
 var ErrNoReviewTask = errors.New("no review task selected")
@@ -59,3 +59,19 @@ func TestReviewDetailShowsAlignmentLabels(t *testing.T) {
 		t.Fatalf("expected out-of-scope alignment label")
 	}
 }
+
+func TestReviewDetailShowsAcceptanceEvidence(t *testing.T) {
+	m := NewModel()
+	m.ViewMode = ViewReview
+	m.Review.Detail = ReviewDetail{
+		AcceptanceCriteria: []string{"First"},
+		Acceptance: []AcceptanceEvidence{
+			{ID: "T1-AC-001", Text: "First", Status: "pass"},
+			{ID: "T1-AC-002", Text: "Second", Status: "unverified"},
+		},
+	}
+	out := m.View()
+	if !strings.Contains(out, "[pass] T1-AC-001 First") || !strings.Contains(out, "[unverified] T1-AC-002 Second") {
+		t.Fatalf("expected acceptance evidence, got %q", out)
+	}
+}
@@ -12,6 +12,10 @@ type TaskItem struct {
 	Title        string
 	Status       string
 	SessionState string
+	// Usage recorded by the scheduler; zero when the task never ran.
+	Tokens        int64
+	CostUSD       float64
+	ActiveSeconds float64
 }
 
 func LoadTasks(db *sql.DB) ([]TaskItem, error) {
@@ -25,8 +29,12 @@ SELECT
     WHERE s.task_id = t.id
     ORDER BY rowid DESC
     LIMIT 1
-  ), '') AS session_state
+  ), '') AS session_state,
+  COALESCE(u.input_tokens + u.output_tokens + u.cache_read_tokens + u.cache_write_tokens, 0),
+  COALESCE(u.cost_usd, 0),
+  COALESCE(u.active_seconds, 0)
 FROM tasks t
+LEFT JOIN task_usage u ON u.task_id = t.id
 ORDER BY t.id ASC`)
 	if err != nil {
 		return nil, err
@@ -35,7 +43,7 @@ ORDER BY t.id ASC`)
 	var out []TaskItem
 	for rows.Next() {
 		var item TaskItem
-		if err := rows.Scan(&item.ID, &item.Title, &item.Status, &item.SessionState); err != nil {
+		if err := rows.Scan(&item.ID, &item.Title, &item.Status, &item.SessionState, &item.Tokens, &item.CostUSD, &item.ActiveSeconds); err != nil {
 			return nil, err
 		}
 		out = append(out, item)
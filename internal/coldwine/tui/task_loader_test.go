@@ -51,3 +51,29 @@ func TestLoadTasksIncludesSessionState(t *testing.T) {
 		t.Fatalf("expected session state working")
 	}
 }
+
+func TestLoadTasksIncludesUsage(t *testing.T) {
+	db, err := storage.OpenTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.InsertTask(db, storage.Task{ID: "T1", Title: "One", Status: "in_progress"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.AddTaskUsage(db, "T1", storage.UsageSample{Agent: "codex", InputTokens: 1500, OutputTokens: 500, CostUSD: 0.25}, 0); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadTasks(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Tokens != 2000 || list[0].CostUSD != 0.25 {
+		t.Fatalf("expected usage on T1, got %+v", list)
+	}
+	if got := fleetUsageSummary(list); got != " | Cost: $0.25 | Tokens: 2.0k" {
+		t.Fatalf("unexpected fleet summary %q", got)
+	}
+}
@@ -0,0 +1,388 @@
+// Package alignment tracks how closely each PRD follows its vision spec over
+// time. Scores are replayed from spec history on load (no daemon) and kept as
+// a per-PRD time series under .gurgeh/alignment/.
+package alignment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/arbiter"
+	"github.com/mistakeknot/autarch/internal/gurgeh/arbiter/consistency"
+	"github.com/mistakeknot/autarch/internal/gurgeh/project"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDriftThreshold is the score below which a PRD is considered to have
+// drifted from its vision.
+const DefaultDriftThreshold = 0.6
+
+// TriggerCurrent marks a sample taken from the working copy of the specs
+// rather than from a saved revision.
+const TriggerCurrent = "current"
+
+// Sample is one alignment score for a PRD/vision version pair.
+type Sample struct {
+	Timestamp     time.Time `yaml:"timestamp" json:"timestamp"`
+	PRDVersion    int       `yaml:"prd_version" json:"prd_version"`
+	VisionID      string    `yaml:"vision_id" json:"vision_id"`
+	VisionVersion int       `yaml:"vision_version" json:"vision_version"`
+	Score         float64   `yaml:"score" json:"score"`
+	Trigger       string    `yaml:"trigger" json:"trigger"`                               // "prd:v3", "vision:v2", "current"
+	Unreferenced  []string  `yaml:"unreferenced,omitempty" json:"unreferenced,omitempty"` // vision goal IDs
+	Contradicted  []string  `yaml:"contradicted,omitempty" json:"contradicted,omitempty"` // vision assumption IDs
+}
+
+// Series is the stored alignment history of one PRD, oldest first.
+type Series struct {
+	PRDID   string   `yaml:"prd_id" json:"prd_id"`
+	Samples []Sample `yaml:"samples" json:"samples"`
+
+	// Added counts the samples the last Refresh or Preview merged in; they
+	// sort to the end of Samples. Zero is treated as one, the latest.
+	Added int `yaml:"-" json:"-"`
+}
+
+// Latest returns the most recent sample, or nil if there are none.
+func (s *Series) Latest() *Sample {
+	if len(s.Samples) == 0 {
+		return nil
+	}
+	return &s.Samples[len(s.Samples)-1]
+}
+
+// Crossed reports whether an added sample fell below threshold while the
+// one before it was at or above it.
+func (s *Series) Crossed(threshold float64) bool {
+	return s.Crossing(threshold) >= 0
+}
+
+// Crossing returns the index of the most recent added sample that fell
+// below threshold from at or above it, or -1. Every consecutive pair ending
+// in an added sample is checked, since one refresh can add several.
+func (s *Series) Crossing(threshold float64) int {
+	added := s.Added
+	if added < 1 {
+		added = 1
+	}
+	for i := len(s.Samples) - 1; i >= 1 && i >= len(s.Samples)-added; i-- {
+		if s.Samples[i].Score < threshold && s.Samples[i-1].Score >= threshold {
+			return i
+		}
+	}
+	return -1
+}
+
+// Compute scores prd against vision. Vision goal and assumption IDs listed
+// in prd.DismissedConflicts are treated as aligned.
+func Compute(vision, prd *specs.Spec) Sample {
+	dismissed := make(map[string]bool, len(prd.DismissedConflicts))
+	for _, id := range prd.DismissedConflicts {
+		dismissed[id] = true
+	}
+	info := &consistency.VisionInfo{}
+	skipGoals, skipBets := make(map[int]bool), make(map[int]bool)
+	for i, g := range vision.Goals {
+		info.Goals = append(info.Goals, g.Description)
+		skipGoals[i] = g.ID != "" && dismissed[g.ID]
+	}
+	for i, a := range vision.Assumptions {
+		info.Assumptions = append(info.Assumptions, a.Description)
+		skipBets[i] = a.ID != "" && dismissed[a.ID]
+	}
+
+	sections := make(map[int]*consistency.SectionInfo)
+	for phase, section := range arbiter.MigrateFromSpec(prd, "").Sections {
+		sections[int(phase)] = &consistency.SectionInfo{
+			Content:  section.Content,
+			Accepted: section.Status == arbiter.DraftAccepted,
+		}
+	}
+	result := consistency.ScoreVisionAlignment(info, sections, skipGoals, skipBets)
+
+	sample := Sample{
+		PRDVersion:    prd.Version,
+		VisionID:      vision.ID,
+		VisionVersion: vision.Version,
+		Score:         result.Score,
+	}
+	for _, i := range result.Unreferenced {
+		sample.Unreferenced = append(sample.Unreferenced, itemID(vision.Goals[i].ID, "goal", i))
+	}
+	for _, i := range result.Contradicted {
+		sample.Contradicted = append(sample.Contradicted, itemID(vision.Assumptions[i].ID, "assumption", i))
+	}
+	return sample
+}
+
+func itemID(id, kind string, index int) string {
+	if id != "" {
+		return id
+	}
+	return fmt.Sprintf("%s[%d]", kind, index)
+}
+
+// Replay recomputes the score at every revision of either spec found in
+// specs.LoadHistory, in timestamp order. A spec without history contributes
+// its current state throughout. The working copy is scored last and kept
+// only if it differs from the final revision's score.
+func Replay(root string, vision, prd *specs.Spec) ([]Sample, error) {
+	return replay(root, vision, prd, nil)
+}
+
+// replay is Replay without the revision samples whose key is in stored.
+// Snapshots are loaded only for the samples it scores, so a refresh of an
+// unchanged history reads revision metadata alone. A sample whose
+// snapshot cannot be read is skipped.
+func replay(root string, vision, prd *specs.Spec, stored map[string]bool) ([]Sample, error) {
+	type event struct {
+		rev  specs.SpecRevision
+		side int
+	}
+	// Each side is the PRD or vision version a sample is scored at, and
+	// its snapshot once loaded. Sides with history start from their first
+	// revision, not the working copy.
+	sides := []struct {
+		id      string
+		version int
+		known   bool
+		spec    *specs.Spec
+	}{{prd.ID, prd.Version, true, prd}, {vision.ID, vision.Version, true, vision}}
+	var events []event
+	for i := range sides {
+		revs, err := specs.LoadHistory(root, sides[i].id)
+		if err != nil {
+			return nil, fmt.Errorf("load history for %s: %w", sides[i].id, err)
+		}
+		if len(revs) > 0 {
+			sides[i].known, sides[i].spec = false, nil
+		}
+		for _, rev := range revs {
+			events = append(events, event{rev: rev, side: i})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].rev.Timestamp.Before(events[j].rev.Timestamp)
+	})
+
+	var out []Sample
+	for _, e := range events {
+		sides[e.side].version, sides[e.side].known, sides[e.side].spec = e.rev.Version, true, nil
+		if !sides[0].known || !sides[1].known {
+			continue
+		}
+		trigger := fmt.Sprintf("prd:v%d", e.rev.Version)
+		if e.side == 1 {
+			trigger = fmt.Sprintf("vision:v%d", e.rev.Version)
+		}
+		if stored[sampleKey(Sample{Trigger: trigger, PRDVersion: sides[0].version, VisionID: vision.ID, VisionVersion: sides[1].version})] {
+			continue
+		}
+		loaded := true
+		for i := range sides {
+			if sides[i].spec == nil {
+				snap, err := specs.LoadRevisionSpec(root, sides[i].id, sides[i].version)
+				if err != nil {
+					loaded = false
+					break
+				}
+				sides[i].spec = &snap
+			}
+		}
+		if !loaded {
+			continue
+		}
+		sample := Compute(sides[1].spec, sides[0].spec)
+		sample.Timestamp = e.rev.Timestamp
+		sample.Trigger = trigger
+		out = append(out, sample)
+	}
+
+	current := Compute(vision, prd)
+	current.Trigger = TriggerCurrent
+	current.Timestamp = time.Now()
+	if len(out) == 0 || out[len(out)-1].Score != current.Score {
+		out = append(out, current)
+	}
+	return out, nil
+}
+
+// sampleKey identifies a revision sample by its trigger and version pair.
+func sampleKey(s Sample) string {
+	return fmt.Sprintf("%s|%d|%s|%d", s.Trigger, s.PRDVersion, s.VisionID, s.VisionVersion)
+}
+
+// Path returns the series file for a PRD.
+func Path(root, prdID string) string {
+	return filepath.Join(project.RootDir(root), "alignment", prdID+".yaml")
+}
+
+// Load reads the stored series for a PRD. A missing file is an empty series.
+func Load(root, prdID string) (*Series, error) {
+	series := &Series{PRDID: prdID}
+	data, err := os.ReadFile(Path(root, prdID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return series, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, series); err != nil {
+		return nil, fmt.Errorf("parse alignment series %s: %w", prdID, err)
+	}
+	return series, nil
+}
+
+// Save writes the series for a PRD.
+func Save(root string, series *Series) error {
+	path := Path(root, series.PRDID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create alignment dir: %w", err)
+	}
+	data, err := yaml.Marshal(series)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Refresh replays the PRD's alignment and appends samples not yet stored,
+// saving the series when any were added.
+func Refresh(root string, vision, prd *specs.Spec) (*Series, error) {
+	series, err := Preview(root, vision, prd)
+	if err != nil || series.Added == 0 {
+		return series, err
+	}
+	return series, Save(root, series)
+}
+
+// Preview is Refresh without saving. Revision samples are keyed by trigger
+// and version pair and only revisions not yet stored are scored; a
+// working-copy sample is added only when its score changed since the last
+// sample.
+func Preview(root string, vision, prd *specs.Spec) (*Series, error) {
+	series, err := Load(root, prd.ID)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]bool, len(series.Samples))
+	for _, s := range series.Samples {
+		if s.Trigger != TriggerCurrent {
+			stored[sampleKey(s)] = true
+		}
+	}
+	replayed, err := replay(root, vision, prd, stored)
+	if err != nil {
+		return nil, err
+	}
+	var added []Sample
+	for _, s := range replayed {
+		last := series.Latest()
+		if n := len(added); n > 0 {
+			last = &added[n-1]
+		}
+		if s.Trigger == TriggerCurrent {
+			if last != nil && last.Score == s.Score {
+				continue
+			}
+		} else if stored[sampleKey(s)] {
+			continue
+		}
+		added = append(added, s)
+	}
+	if len(added) == 0 {
+		return series, nil
+	}
+	// Added samples go after the stored ones, so a revision found late
+	// (say, history synced from elsewhere) is still checked for a crossing.
+	sort.SliceStable(added, func(i, j int) bool {
+		return added[i].Timestamp.Before(added[j].Timestamp)
+	})
+	series.Samples = append(series.Samples, added...)
+	series.Added = len(added)
+	return series, nil
+}
+
+// RefreshAll refreshes every active PRD that has a VisionRef pointing at an
+// existing vision spec, returning the series sorted by PRD ID. Specs that
+// cannot be loaded or refreshed are reported as warnings.
+func RefreshAll(root string) ([]*Series, []string) {
+	return eachPRD(root, nil, Refresh)
+}
+
+// PreviewAll is RefreshAll without saving, for read-only views.
+func PreviewAll(root string) ([]*Series, []string) {
+	return eachPRD(root, nil, Preview)
+}
+
+// RefreshFor is RefreshAll limited to the PRDs a spec affects: the spec
+// itself when it is a PRD, or the PRDs referencing it when it is a vision.
+func RefreshFor(root string, spec *specs.Spec) ([]*Series, []string) {
+	return eachPRD(root, func(prd *specs.Spec) bool {
+		return prd.ID == spec.ID || (spec.IsVision() && prd.VisionRef == spec.ID)
+	}, Refresh)
+}
+
+func eachPRD(root string, match func(prd *specs.Spec) bool, fn func(root string, vision, prd *specs.Spec) (*Series, error)) ([]*Series, []string) {
+	summaries, warnings := specs.LoadSummaries(project.SpecsDir(root))
+	byID := make(map[string]*specs.Spec, len(summaries))
+	for _, s := range summaries {
+		spec, err := specs.LoadSpec(s.Path)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", s.Path, err))
+			continue
+		}
+		byID[spec.ID] = &spec
+	}
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var out []*Series
+	for _, id := range ids {
+		prd := byID[id]
+		if prd.IsVision() || prd.VisionRef == "" || (match != nil && !match(prd)) {
+			continue
+		}
+		vision, ok := byID[prd.VisionRef]
+		if !ok || !vision.IsVision() {
+			warnings = append(warnings, fmt.Sprintf("%s: vision_ref %s not found", id, prd.VisionRef))
+			continue
+		}
+		series, err := fn(root, vision, prd)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		out = append(out, series)
+	}
+	return out, warnings
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the last width scores as a block-character plot.
+func Sparkline(samples []Sample, width int) string {
+	if width > 0 && len(samples) > width {
+		samples = samples[len(samples)-width:]
+	}
+	var b strings.Builder
+	for _, s := range samples {
+		idx := int(s.Score * float64(len(sparkBlocks)-1))
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(sparkBlocks) {
+			idx = len(sparkBlocks) - 1
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
@@ -0,0 +1,176 @@
+package alignment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"gopkg.in/yaml.v3"
+)
+
+func testVision() *specs.Spec {
+	return &specs.Spec{
+		ID:          "VIS-001",
+		Type:        specs.SpecTypeVision,
+		Goals:       []specs.Goal{{ID: "GOAL-001", Description: "Faster research cycles"}},
+		Assumptions: []specs.Assumption{{ID: "ASSM-001", Description: "Teams adopt terminal tooling"}},
+	}
+}
+
+func alignedPRD() *specs.Spec {
+	return &specs.Spec{
+		ID:           "PRD-001",
+		Title:        "Research",
+		Summary:      "Research cycles are slow",
+		Requirements: []string{"REQ-001: Terminal tooling for research"},
+		VisionRef:    "VIS-001",
+	}
+}
+
+func TestComputeAndDismissedConflicts(t *testing.T) {
+	vision := testVision()
+	prd := alignedPRD()
+	if got := Compute(vision, prd); got.Score != 1.0 {
+		t.Fatalf("expected aligned PRD to score 1.0, got %+v", got)
+	}
+
+	prd.Title = "Billing"
+	prd.Summary = "Billing is confusing"
+	prd.Requirements = []string{"REQ-001: We will not ship terminal tooling"}
+	got := Compute(vision, prd)
+	if got.Score >= DefaultDriftThreshold {
+		t.Fatalf("expected drifted score, got %v", got.Score)
+	}
+	if len(got.Contradicted) != 1 || got.Contradicted[0] != "ASSM-001" {
+		t.Fatalf("expected ASSM-001 contradicted, got %v", got.Contradicted)
+	}
+
+	prd.DismissedConflicts = []string{"ASSM-001"}
+	if dismissed := Compute(vision, prd); dismissed.Score <= got.Score || len(dismissed.Contradicted) != 0 {
+		t.Fatalf("expected dismissed conflict to raise score, got %+v", dismissed)
+	}
+}
+
+func TestRefreshReplaysHistory(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".gurgeh", "specs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	vision := testVision()
+	prd := alignedPRD()
+	if _, err := specs.SaveRevision(root, vision, "user", "manual", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := specs.SaveRevision(root, prd, "user", "manual", nil); err != nil {
+		t.Fatal(err)
+	}
+	prd.Requirements = []string{"REQ-001: We will not ship terminal tooling"}
+	if _, err := specs.SaveRevision(root, prd, "user", "manual", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	series, err := Refresh(root, vision, prd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series.Samples) != 2 {
+		t.Fatalf("expected one sample per PRD revision, got %+v", series.Samples)
+	}
+	if series.Samples[0].Trigger != "prd:v1" || series.Samples[1].Trigger != "prd:v2" {
+		t.Fatalf("unexpected triggers: %+v", series.Samples)
+	}
+	if series.Added != 2 || !series.Crossed(0.8) {
+		t.Fatalf("expected drop below 0.8, got %.2f → %.2f", series.Samples[0].Score, series.Samples[1].Score)
+	}
+
+	again, err := Refresh(root, vision, prd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Samples) != 2 || again.Added != 0 {
+		t.Fatalf("refresh should not duplicate samples, got %d", len(again.Samples))
+	}
+	stored, err := Load(root, prd.ID)
+	if err != nil || len(stored.Samples) != 2 {
+		t.Fatalf("expected stored series, got %+v (%v)", stored, err)
+	}
+
+	// Scored revisions are not loaded again
+	for _, v := range []string{"_v1.yaml", "_v2.yaml"} {
+		if err := os.Remove(filepath.Join(root, ".gurgeh", "specs", "history", prd.ID+v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if again, err := Refresh(root, vision, prd); err != nil || again.Added != 0 {
+		t.Fatalf("expected an incremental refresh to add nothing, got %+v (%v)", again, err)
+	}
+}
+
+func TestCrossedChecksEveryAddedSample(t *testing.T) {
+	series := &Series{Samples: []Sample{{Score: 0.9}, {Score: 0.9}, {Score: 0.3}, {Score: 0.4}}}
+	if series.Crossed(0.6) {
+		t.Fatal("latest pair alone should not count as a crossing")
+	}
+	series.Added = 3
+	if got := series.Crossing(0.6); got != 2 {
+		t.Fatalf("expected crossing at sample 2 within the batch, got %d", got)
+	}
+	series.Added = 1
+	if series.Crossed(0.6) {
+		t.Fatal("crossing before the added samples should not count")
+	}
+}
+
+func TestPreviewDoesNotWrite(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".gurgeh", "specs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	series, err := Preview(root, testVision(), alignedPRD())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series.Added != 1 || len(series.Samples) != 1 {
+		t.Fatalf("expected one previewed sample, got %+v", series)
+	}
+	if _, err := os.Stat(Path(root, series.PRDID)); !os.IsNotExist(err) {
+		t.Fatalf("preview wrote the series: %v", err)
+	}
+}
+
+func TestRefreshAllSkipsPRDsWithoutVision(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, ".gurgeh", "specs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	orphan := &specs.Spec{ID: "PRD-002", Title: "Orphan", Summary: "s"}
+	for _, spec := range []*specs.Spec{testVision(), alignedPRD(), orphan} {
+		data, err := yaml.Marshal(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, spec.ID+".yaml"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, warnings := RefreshAll(root)
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	if len(all) != 1 || all[0].PRDID != "PRD-001" || all[0].Latest().Trigger != TriggerCurrent {
+		t.Fatalf("unexpected series: %+v", all)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	got := Sparkline([]Sample{{Score: 0}, {Score: 0.5}, {Score: 1}}, 0)
+	if got != "▁▄█" {
+		t.Fatalf("sparkline = %q", got)
+	}
+	if got := Sparkline([]Sample{{Score: 0}, {Score: 1}}, 1); got != "█" {
+		t.Fatalf("width-limited sparkline = %q", got)
+	}
+}
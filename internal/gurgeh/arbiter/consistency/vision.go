@@ -53,6 +53,59 @@ func CheckVisionAlignment(vision *VisionInfo, sections map[int]*SectionInfo) []C
 	return conflicts
 }
 
+// VisionAlignment is a graded counterpart to CheckVisionAlignment.
+// Indices refer to VisionInfo.Goals and VisionInfo.Assumptions.
+type VisionAlignment struct {
+	Score        float64 // 0.0–1.0
+	Unreferenced []int   // principles not referenced by problem or features
+	Contradicted []int   // strategic bets the features may contradict
+}
+
+// ScoreVisionAlignment scores a PRD against its vision: 60% principle
+// coverage across the problem and features sections, 40% strategic bets
+// left uncontradicted by the features. Indices in skipGoals and skipBets
+// are treated as aligned (dismissed conflicts); unaccepted sections count
+// as empty.
+func ScoreVisionAlignment(vision *VisionInfo, sections map[int]*SectionInfo, skipGoals, skipBets map[int]bool) VisionAlignment {
+	result := VisionAlignment{Score: 1.0}
+	if vision == nil {
+		return result
+	}
+	accepted := func(phase int) string {
+		if s, ok := sections[phase]; ok && s.Accepted {
+			return s.Content
+		}
+		return ""
+	}
+	problem, features := accepted(1), accepted(3)
+
+	coverage := 1.0
+	if len(vision.Goals) > 0 {
+		covered := 0
+		for i, goal := range vision.Goals {
+			if skipGoals[i] || anyKeywordOverlap(problem+"\n"+features, []string{goal}) {
+				covered++
+				continue
+			}
+			result.Unreferenced = append(result.Unreferenced, i)
+		}
+		coverage = float64(covered) / float64(len(vision.Goals))
+	}
+
+	bets := 1.0
+	if len(vision.Assumptions) > 0 {
+		for i, bet := range vision.Assumptions {
+			if !skipBets[i] && features != "" && detectContradiction(features, bet) {
+				result.Contradicted = append(result.Contradicted, i)
+			}
+		}
+		bets = 1 - float64(len(result.Contradicted))/float64(len(vision.Assumptions))
+	}
+
+	result.Score = coverage*0.6 + bets*0.4
+	return result
+}
+
 // anyKeywordOverlap returns true if the content shares meaningful words
 // with at least one of the reference strings.
 func anyKeywordOverlap(content string, references []string) bool {
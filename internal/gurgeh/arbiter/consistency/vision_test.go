@@ -87,3 +87,36 @@ func TestCheckVisionAlignment_UnacceptedSectionsSkipped(t *testing.T) {
 		t.Errorf("expected no conflicts for unaccepted sections, got %d", len(conflicts))
 	}
 }
+
+func TestScoreVisionAlignment(t *testing.T) {
+	vision := &VisionInfo{
+		Goals:       []string{"Faster research cycles", "Optimize perishable logistics"},
+		Assumptions: []string{"Teams adopt terminal tooling"},
+	}
+	sections := map[int]*SectionInfo{
+		1: {Content: "Research cycles are slow", Accepted: true},
+		3: {Content: "We will not build terminal tooling", Accepted: true},
+	}
+
+	got := ScoreVisionAlignment(vision, sections, nil, nil)
+	if got.Score != 0.3 {
+		t.Errorf("expected score 0.3, got %v", got.Score)
+	}
+	if len(got.Unreferenced) != 1 || got.Unreferenced[0] != 1 {
+		t.Errorf("expected goal 1 unreferenced, got %v", got.Unreferenced)
+	}
+	if len(got.Contradicted) != 1 || got.Contradicted[0] != 0 {
+		t.Errorf("expected bet 0 contradicted, got %v", got.Contradicted)
+	}
+
+	dismissed := ScoreVisionAlignment(vision, sections, map[int]bool{1: true}, map[int]bool{0: true})
+	if dismissed.Score != 1.0 {
+		t.Errorf("expected dismissed conflicts to restore score 1.0, got %v", dismissed.Score)
+	}
+}
+
+func TestScoreVisionAlignment_NilVision(t *testing.T) {
+	if got := ScoreVisionAlignment(nil, nil, nil, nil); got.Score != 1.0 {
+		t.Errorf("expected 1.0 without a vision, got %v", got.Score)
+	}
+}
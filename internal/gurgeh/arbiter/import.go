@@ -0,0 +1,392 @@
+package arbiter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+)
+
+// ImportReport describes how an external PRD document was mapped onto a spec.
+type ImportReport struct {
+	Source   string   `json:"source"`
+	Mapped   []string `json:"mapped"`   // spec fields that were populated
+	Unmapped []string `json:"unmapped"` // content that could not be placed
+}
+
+func (r *ImportReport) mapped(field string) {
+	for _, f := range r.Mapped {
+		if f == field {
+			return
+		}
+	}
+	r.Mapped = append(r.Mapped, field)
+}
+
+func (r *ImportReport) unmapped(format string, args ...any) {
+	r.Unmapped = append(r.Unmapped, fmt.Sprintf(format, args...))
+}
+
+// markdownSection is a level-2 (or deeper top-level) block of a Markdown PRD.
+type markdownSection struct {
+	heading string
+	body    string
+}
+
+// ImportMarkdown converts a Markdown PRD into a specs.Spec. The first level-1
+// heading becomes the title; level-2 headings are matched by keyword onto
+// Summary, UserStory, Goals, NonGoals, Assumptions, Requirements, CUJs and
+// Acceptance. Sections that match nothing are listed in the report.
+// ID and CreatedAt are left for the caller to assign.
+func ImportMarkdown(source, content string) (*specs.Spec, *ImportReport) {
+	spec := &specs.Spec{Type: specs.SpecTypePRD, Status: "draft"}
+	report := &ImportReport{Source: source}
+
+	preamble, sections := splitMarkdownSections(content, spec)
+	if spec.Title != "" {
+		report.mapped("title")
+	}
+
+	for _, sec := range sections {
+		key := classifyHeading(sec.heading)
+		body := strings.TrimSpace(sec.body)
+		if body == "" {
+			report.unmapped("section %q is empty", sec.heading)
+			continue
+		}
+		switch key {
+		case "summary":
+			if spec.Summary != "" {
+				spec.Summary += "\n\n"
+			}
+			spec.Summary += body
+			report.mapped("summary")
+		case "user_story":
+			spec.UserStory = specs.UserStory{Text: body}
+			report.mapped("user_story")
+		case "goals":
+			goals := parseGoals("## Goals\n" + body)
+			if len(goals) == 0 {
+				report.unmapped("section %q has no list items to map to goals", sec.heading)
+				continue
+			}
+			spec.Goals = append(spec.Goals, goals...)
+			report.mapped("goals")
+		case "non_goals":
+			items := parseBulletItems(body)
+			if len(items) == 0 {
+				report.unmapped("section %q has no list items to map to non_goals", sec.heading)
+				continue
+			}
+			for _, item := range items {
+				spec.NonGoals = append(spec.NonGoals, specs.NonGoal{Description: item})
+			}
+			report.mapped("non_goals")
+		case "assumptions":
+			items := parseBulletItems(body)
+			if len(items) == 0 {
+				report.unmapped("section %q has no list items to map to assumptions", sec.heading)
+				continue
+			}
+			for _, item := range items {
+				spec.Assumptions = append(spec.Assumptions, specs.Assumption{Description: item})
+			}
+			report.mapped("assumptions")
+		case "requirements":
+			items := parseBulletItems(body)
+			if len(items) == 0 {
+				report.unmapped("section %q has no list items to map to requirements", sec.heading)
+				continue
+			}
+			spec.Requirements = append(spec.Requirements, items...)
+			report.mapped("requirements")
+		case "cujs":
+			cujs := parseCUJs(body)
+			if len(cujs) == 0 {
+				report.unmapped("section %q has no ### journeys to map to critical_user_journeys", sec.heading)
+				continue
+			}
+			spec.CriticalUserJourneys = append(spec.CriticalUserJourneys, cujs...)
+			report.mapped("critical_user_journeys")
+		case "acceptance":
+			criteria := parseAcceptanceCriteria(body)
+			if len(criteria) == 0 {
+				for _, item := range parseBulletItems(body) {
+					criteria = append(criteria, specs.AcceptanceCriterion{Description: item})
+				}
+			}
+			if len(criteria) == 0 {
+				report.unmapped("section %q has no list items to map to acceptance_criteria", sec.heading)
+				continue
+			}
+			spec.Acceptance = append(spec.Acceptance, criteria...)
+			report.mapped("acceptance_criteria")
+		default:
+			report.unmapped("section %q does not match a spec field", sec.heading)
+		}
+	}
+
+	if preamble != "" {
+		if spec.Summary == "" {
+			spec.Summary = preamble
+			report.mapped("summary")
+		} else {
+			report.unmapped("text before the first section was not imported")
+		}
+	}
+
+	numberAcceptance(spec.Acceptance)
+	numberCUJs(spec.CriticalUserJourneys)
+	return spec, report
+}
+
+// splitMarkdownSections sets spec.Title from the first level-1 heading and
+// splits the rest of the document at level-2 headings. Deeper headings stay
+// inside their parent section so parseCUJs can see "### Journey" blocks.
+func splitMarkdownSections(content string, spec *specs.Spec) (string, []markdownSection) {
+	var (
+		preamble []string
+		sections []markdownSection
+		current  *markdownSection
+		body     []string
+		inFence  bool
+	)
+	flush := func() {
+		if current != nil {
+			current.body = strings.Join(body, "\n")
+			sections = append(sections, *current)
+		}
+		body = nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if !inFence {
+			if strings.HasPrefix(trimmed, "# ") {
+				if spec.Title == "" {
+					spec.Title = strings.TrimSpace(strings.TrimPrefix(trimmed, "# "))
+					continue
+				}
+			}
+			if strings.HasPrefix(trimmed, "## ") {
+				flush()
+				current = &markdownSection{heading: strings.TrimSpace(strings.TrimPrefix(trimmed, "## "))}
+				continue
+			}
+		}
+		if current == nil {
+			preamble = append(preamble, line)
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return strings.TrimSpace(strings.Join(preamble, "\n")), sections
+}
+
+// classifyHeading maps a section heading onto a spec field key.
+// Order matters: "Non-Goals" must be checked before "Goals".
+func classifyHeading(heading string) string {
+	h := strings.ToLower(heading)
+	switch {
+	case strings.Contains(h, "non-goal"), strings.Contains(h, "non goal"),
+		strings.Contains(h, "out of scope"), strings.Contains(h, "not doing"):
+		return "non_goals"
+	case strings.Contains(h, "goal"), strings.Contains(h, "objective"):
+		return "goals"
+	case strings.Contains(h, "assumption"):
+		return "assumptions"
+	case strings.Contains(h, "acceptance"):
+		return "acceptance"
+	case strings.Contains(h, "journey"), strings.Contains(h, "cuj"):
+		return "cujs"
+	case strings.Contains(h, "requirement"):
+		return "requirements"
+	case strings.Contains(h, "user story"), strings.Contains(h, "users"), strings.Contains(h, "persona"):
+		return "user_story"
+	case strings.Contains(h, "summary"), strings.Contains(h, "overview"),
+		strings.Contains(h, "problem"), strings.Contains(h, "background"):
+		return "summary"
+	}
+	return ""
+}
+
+// ImportGherkin converts a Gherkin .feature file into a specs.Spec. The
+// Feature name becomes the title, its free-text description the summary,
+// and each Scenario (or Scenario Outline) a structured Requirement.
+// Background steps are prepended to every scenario's Given clause.
+func ImportGherkin(source, content string) (*specs.Spec, *ImportReport) {
+	spec := &specs.Spec{Type: specs.SpecTypePRD, Status: "draft"}
+	report := &ImportReport{Source: source}
+
+	var (
+		description []string
+		background  gherkinSteps
+		current     *gherkinScenario
+		scenarios   []gherkinScenario
+		inBody      = "" // "feature", "background", "scenario", "examples"
+		inDocString bool
+	)
+	flush := func() {
+		if current != nil {
+			scenarios = append(scenarios, *current)
+			current = nil
+		}
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, `"""`) || strings.HasPrefix(trimmed, "```") {
+			if !inDocString {
+				report.unmapped("line %d: doc string not imported", i+1)
+			}
+			inDocString = !inDocString
+			continue
+		}
+		if inDocString || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		keyword, rest := splitGherkinKeyword(trimmed)
+		switch keyword {
+		case "Feature":
+			spec.Title = rest
+			report.mapped("title")
+			inBody = "feature"
+		case "Background":
+			flush()
+			inBody = "background"
+		case "Rule":
+			flush()
+			report.unmapped("line %d: rule %q grouping not imported", i+1, rest)
+			inBody = "feature"
+		case "Scenario", "Example", "Scenario Outline", "Scenario Template":
+			flush()
+			current = &gherkinScenario{name: rest}
+			inBody = "scenario"
+		case "Examples", "Scenarios":
+			if current != nil {
+				report.unmapped("line %d: examples table for scenario %q not imported", i+1, current.name)
+			}
+			inBody = "examples"
+		case "Given", "When", "Then", "And", "But", "*":
+			switch inBody {
+			case "background":
+				background.add(keyword, rest)
+			case "scenario":
+				current.steps.add(keyword, rest)
+			default:
+				report.unmapped("line %d: step outside a scenario: %s", i+1, trimmed)
+			}
+		default:
+			switch {
+			case strings.HasPrefix(trimmed, "@"):
+				report.unmapped("line %d: tags %s not imported", i+1, trimmed)
+			case strings.HasPrefix(trimmed, "|"):
+				if inBody != "examples" {
+					report.unmapped("line %d: data table not imported", i+1)
+				}
+			case inBody == "feature":
+				description = append(description, trimmed)
+			default:
+				report.unmapped("line %d: %s", i+1, trimmed)
+			}
+		}
+	}
+	flush()
+
+	if len(description) > 0 {
+		spec.Summary = strings.Join(description, "\n")
+		report.mapped("summary")
+	}
+	for i, sc := range scenarios {
+		given := append(append([]string{}, background.given...), sc.steps.given...)
+		req := specs.Requirement{
+			ID:         fmt.Sprintf("REQ-%03d", i+1),
+			FeatureRef: spec.Title,
+			Type:       "functional",
+			Given:      strings.Join(given, " and "),
+			When:       strings.Join(sc.steps.when, " and "),
+			Then:       strings.Join(sc.steps.then, " and "),
+			Status:     "draft",
+		}
+		if req.When == "" || req.Then == "" {
+			report.unmapped("scenario %q is missing When or Then steps", sc.name)
+		}
+		spec.StructuredRequirements = append(spec.StructuredRequirements, req)
+		spec.Requirements = append(spec.Requirements, fmt.Sprintf("%s: %s", req.ID, sc.name))
+	}
+	if len(scenarios) > 0 {
+		report.mapped("structured_requirements")
+		report.mapped("requirements")
+	}
+	return spec, report
+}
+
+type gherkinSteps struct {
+	given, when, then []string
+	last              string
+}
+
+// add appends a step, resolving And/But/* to the previous primary keyword.
+func (s *gherkinSteps) add(keyword, text string) {
+	switch keyword {
+	case "Given", "When", "Then":
+		s.last = keyword
+	}
+	switch s.last {
+	case "When":
+		s.when = append(s.when, text)
+	case "Then":
+		s.then = append(s.then, text)
+	default:
+		s.given = append(s.given, text)
+	}
+}
+
+type gherkinScenario struct {
+	name  string
+	steps gherkinSteps
+}
+
+var gherkinBlockKeywords = []string{
+	"Scenario Outline", "Scenario Template", "Feature", "Background", "Rule",
+	"Scenario", "Example", "Examples", "Scenarios",
+}
+
+var gherkinStepKeywords = []string{"Given", "When", "Then", "And", "But", "*"}
+
+// splitGherkinKeyword returns the leading Gherkin keyword of a line and the
+// remaining text, or ("", line) when the line is free text.
+func splitGherkinKeyword(line string) (string, string) {
+	for _, kw := range gherkinBlockKeywords {
+		if strings.HasPrefix(line, kw+":") {
+			return kw, strings.TrimSpace(line[len(kw)+1:])
+		}
+	}
+	for _, kw := range gherkinStepKeywords {
+		if strings.HasPrefix(line, kw+" ") {
+			return kw, strings.TrimSpace(line[len(kw)+1:])
+		}
+	}
+	return "", line
+}
+
+func numberAcceptance(criteria []specs.AcceptanceCriterion) {
+	for i := range criteria {
+		if criteria[i].ID == "" {
+			criteria[i].ID = fmt.Sprintf("AC-%03d", i+1)
+		}
+	}
+}
+
+func numberCUJs(cujs []specs.CriticalUserJourney) {
+	for i := range cujs {
+		if cujs[i].ID == "" {
+			cujs[i].ID = fmt.Sprintf("CUJ-%03d", i+1)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package arbiter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/arbiter"
+)
+
+const markdownPRD = `# Concept Search
+
+Researchers waste time on keyword search.
+
+## Goals
+
+- Reduce search time by 50%
+- Surface related papers
+
+## Non-Goals
+
+- Full-text indexing of PDFs
+
+## Assumptions
+
+- Users have stable internet
+
+## Critical User Journeys
+
+### First Search (Priority: high)
+
+1. Open app
+2. Type query
+- Relevant results in top 5
+
+## Acceptance Criteria
+
+- [AC-001] Search returns results
+- [ ] Results load under 2s
+
+## Competitive Notes
+
+Nobody does this well.
+`
+
+func TestImportMarkdown_MapsSections(t *testing.T) {
+	spec, report := arbiter.ImportMarkdown("prd.md", markdownPRD)
+
+	if spec.Title != "Concept Search" {
+		t.Errorf("Title: got %q", spec.Title)
+	}
+	if spec.Summary != "Researchers waste time on keyword search." {
+		t.Errorf("Summary: got %q", spec.Summary)
+	}
+	if len(spec.Goals) != 2 || spec.Goals[0].Description != "Reduce search time by 50%" {
+		t.Errorf("Goals: got %+v", spec.Goals)
+	}
+	if len(spec.NonGoals) != 1 {
+		t.Errorf("NonGoals: got %+v", spec.NonGoals)
+	}
+	if len(spec.Assumptions) != 1 {
+		t.Errorf("Assumptions: got %+v", spec.Assumptions)
+	}
+	if len(spec.CriticalUserJourneys) != 1 {
+		t.Fatalf("CUJs: got %+v", spec.CriticalUserJourneys)
+	}
+	cuj := spec.CriticalUserJourneys[0]
+	if cuj.Title != "First Search" || cuj.Priority != "high" || len(cuj.Steps) != 2 || cuj.ID != "CUJ-001" {
+		t.Errorf("CUJ: got %+v", cuj)
+	}
+	if len(spec.Acceptance) != 2 || spec.Acceptance[0].ID != "AC-001" || spec.Acceptance[1].ID != "AC-002" {
+		t.Errorf("Acceptance: got %+v", spec.Acceptance)
+	}
+
+	if len(report.Unmapped) != 1 || !strings.Contains(report.Unmapped[0], "Competitive Notes") {
+		t.Errorf("Unmapped: got %v", report.Unmapped)
+	}
+}
+
+func TestImportMarkdown_ReportsSectionsWithoutItems(t *testing.T) {
+	_, report := arbiter.ImportMarkdown("prd.md", "# T\n\n## Goals\n\nBe faster.\n")
+	if len(report.Unmapped) != 1 || !strings.Contains(report.Unmapped[0], "no list items") {
+		t.Errorf("Unmapped: got %v", report.Unmapped)
+	}
+}
+
+const featureFile = `@search
+Feature: Concept search
+  Researchers find papers by concept.
+
+  Background:
+    Given a signed-in researcher
+
+  Scenario: Search by concept
+    Given the index contains "transformers"
+    When they search for "attention"
+    Then they see "transformers"
+    And results are ranked
+
+  Scenario Outline: Empty query
+    When they search for "<q>"
+    Then they see a hint
+
+    Examples:
+      | q |
+      |   |
+`
+
+func TestImportGherkin_ScenariosBecomeRequirements(t *testing.T) {
+	spec, report := arbiter.ImportGherkin("search.feature", featureFile)
+
+	if spec.Title != "Concept search" {
+		t.Errorf("Title: got %q", spec.Title)
+	}
+	if spec.Summary != "Researchers find papers by concept." {
+		t.Errorf("Summary: got %q", spec.Summary)
+	}
+	if len(spec.StructuredRequirements) != 2 {
+		t.Fatalf("requirements: got %+v", spec.StructuredRequirements)
+	}
+	req := spec.StructuredRequirements[0]
+	if req.ID != "REQ-001" {
+		t.Errorf("ID: got %q", req.ID)
+	}
+	if req.Given != `a signed-in researcher and the index contains "transformers"` {
+		t.Errorf("Given: got %q", req.Given)
+	}
+	if req.When != `they search for "attention"` {
+		t.Errorf("When: got %q", req.When)
+	}
+	if req.Then != `they see "transformers" and results are ranked` {
+		t.Errorf("Then: got %q", req.Then)
+	}
+	if len(spec.Requirements) != 2 || spec.Requirements[1] != "REQ-002: Empty query" {
+		t.Errorf("Requirements: got %v", spec.Requirements)
+	}
+
+	var sawTags, sawExamples bool
+	for _, u := range report.Unmapped {
+		sawTags = sawTags || strings.Contains(u, "@search")
+		sawExamples = sawExamples || strings.Contains(u, "examples table")
+	}
+	if !sawTags || !sawExamples {
+		t.Errorf("Unmapped: got %v", report.Unmapped)
+	}
+}
@@ -0,0 +1,372 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"gopkg.in/yaml.v3"
+)
+
+// AnswerFile scripts a headless sprint: the initial input, per-phase answers
+// and rules applied to every generated draft.
+//
+//	input: "Offline sync for the mobile app"
+//	phases:
+//	  problem:
+//	    content: "Field teams lose edits when connectivity drops."
+//	  users:
+//	    option: 2
+//	rules:
+//	  - contains: "TODO"
+//	    action: stop
+type AnswerFile struct {
+	Input  string                 `yaml:"input"`
+	Type   string                 `yaml:"type,omitempty"` // "" or "prd" for PRDs, "vision" for vision specs
+	Phases map[string]PhaseAnswer `yaml:"phases,omitempty"`
+	Rules  []AnswerRule           `yaml:"rules,omitempty"`
+}
+
+// PhaseAnswer is the scripted user response for one phase. Content, Option
+// and Append revise the draft in that order of precedence; Append may be
+// combined with either. Accept defaults to true; false stops the run at
+// this phase so a human can pick it up.
+type PhaseAnswer struct {
+	Content string `yaml:"content,omitempty"`
+	Option  int    `yaml:"option,omitempty"` // 1-based index into the draft's alternatives
+	Append  string `yaml:"append,omitempty"`
+	Reason  string `yaml:"reason,omitempty"`
+	Accept  *bool  `yaml:"accept,omitempty"`
+}
+
+// AnswerRule acts on drafts after the phase answer is applied. The first
+// matching rule wins. Phase is a phase key (empty matches every phase) and
+// Contains a case-insensitive substring of the draft (empty matches any).
+type AnswerRule struct {
+	Phase    string `yaml:"phase,omitempty"`
+	Contains string `yaml:"contains,omitempty"`
+	Action   string `yaml:"action"`            // accept, revise, stop
+	Content  string `yaml:"content,omitempty"` // revise: replacement draft
+	Append   string `yaml:"append,omitempty"`  // revise: text appended to the draft
+}
+
+// Rule actions.
+const (
+	RuleAccept = "accept"
+	RuleRevise = "revise"
+	RuleStop   = "stop"
+)
+
+var phaseKeys = map[Phase]string{
+	PhaseVision:             "vision",
+	PhaseProblem:            "problem",
+	PhaseUsers:              "users",
+	PhaseFeaturesGoals:      "features_goals",
+	PhaseRequirements:       "requirements",
+	PhaseScopeAssumptions:   "scope_assumptions",
+	PhaseCUJs:               "cujs",
+	PhaseAcceptanceCriteria: "acceptance_criteria",
+}
+
+// Key returns the snake_case name used for the phase in answer files.
+func (p Phase) Key() string {
+	return phaseKeys[p]
+}
+
+// ParsePhase accepts a phase key ("features_goals") or display name
+// ("Features + Goals"), case-insensitively.
+func ParsePhase(s string) (Phase, error) {
+	norm := strings.ToLower(strings.TrimSpace(s))
+	for _, p := range AllPhases() {
+		if norm == p.Key() || norm == strings.ToLower(p.String()) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown phase %q", s)
+}
+
+// LoadAnswerFile reads and validates an answer file.
+func LoadAnswerFile(path string) (*AnswerFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var af AnswerFile
+	if err := yaml.Unmarshal(data, &af); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := af.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &af, nil
+}
+
+// Validate checks phase names, rule actions and the spec type.
+func (af *AnswerFile) Validate() error {
+	if strings.TrimSpace(af.Input) == "" {
+		return fmt.Errorf("input is required")
+	}
+	switch af.Type {
+	case "", specs.SpecTypePRD, specs.SpecTypeVision:
+	default:
+		return fmt.Errorf("invalid type %q (use prd or vision)", af.Type)
+	}
+	for name := range af.Phases {
+		if _, err := ParsePhase(name); err != nil {
+			return err
+		}
+	}
+	for i, r := range af.Rules {
+		if r.Phase != "" {
+			if _, err := ParsePhase(r.Phase); err != nil {
+				return fmt.Errorf("rules[%d]: %w", i, err)
+			}
+		}
+		switch r.Action {
+		case RuleAccept, RuleStop:
+		case RuleRevise:
+			if r.Content == "" && r.Append == "" {
+				return fmt.Errorf("rules[%d]: revise needs content or append", i)
+			}
+		default:
+			return fmt.Errorf("rules[%d]: invalid action %q (use accept, revise, or stop)", i, r.Action)
+		}
+	}
+	return nil
+}
+
+func (af *AnswerFile) answerFor(p Phase) (PhaseAnswer, bool) {
+	for name, ans := range af.Phases {
+		if parsed, err := ParsePhase(name); err == nil && parsed == p {
+			return ans, true
+		}
+	}
+	return PhaseAnswer{}, false
+}
+
+func (af *AnswerFile) ruleFor(p Phase, content string) (int, *AnswerRule) {
+	lower := strings.ToLower(content)
+	for i := range af.Rules {
+		r := &af.Rules[i]
+		if r.Phase != "" {
+			if parsed, _ := ParsePhase(r.Phase); parsed != p {
+				continue
+			}
+		}
+		if r.Contains != "" && !strings.Contains(lower, strings.ToLower(r.Contains)) {
+			continue
+		}
+		return i, r
+	}
+	return -1, nil
+}
+
+// TranscriptStep records what happened in one phase of a headless run.
+type TranscriptStep struct {
+	Phase      string   `yaml:"phase" json:"phase"`
+	Draft      string   `yaml:"draft" json:"draft"` // as generated
+	Action     string   `yaml:"action" json:"action"`
+	Rule       string   `yaml:"rule,omitempty" json:"rule,omitempty"` // "rules[N]" when a rule fired
+	Final      string   `yaml:"final" json:"final"`
+	Conflicts  []string `yaml:"conflicts,omitempty" json:"conflicts,omitempty"`
+	Confidence float64  `yaml:"confidence" json:"confidence"`
+}
+
+// Transcript is the record of a headless sprint run.
+type Transcript struct {
+	SprintID  string           `yaml:"sprint_id" json:"sprint_id"`
+	Input     string           `yaml:"input" json:"input"`
+	Steps     []TranscriptStep `yaml:"steps" json:"steps"`
+	Completed bool             `yaml:"completed" json:"completed"`
+	StoppedAt string           `yaml:"stopped_at,omitempty" json:"stopped_at,omitempty"`
+	Reason    string           `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// Markdown renders the transcript without the sprint ID or timestamps so it
+// can be compared against golden files.
+func (t *Transcript) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Sprint transcript\n\nInput: %s\n", t.Input)
+	for _, s := range t.Steps {
+		fmt.Fprintf(&b, "\n## %s — %s", s.Phase, s.Action)
+		if s.Rule != "" {
+			fmt.Fprintf(&b, " (%s)", s.Rule)
+		}
+		fmt.Fprintf(&b, "\n\nConfidence: %.2f\n", s.Confidence)
+		for _, c := range s.Conflicts {
+			fmt.Fprintf(&b, "Conflict: %s\n", c)
+		}
+		if s.Final != s.Draft {
+			fmt.Fprintf(&b, "\n### Draft\n\n%s\n", strings.TrimSpace(s.Draft))
+		}
+		fmt.Fprintf(&b, "\n### Final\n\n%s\n", strings.TrimSpace(s.Final))
+	}
+	if t.Completed {
+		b.WriteString("\nCompleted.\n")
+	} else {
+		fmt.Fprintf(&b, "\nStopped at %s: %s\n", t.StoppedAt, t.Reason)
+	}
+	return b.String()
+}
+
+// RunResult is the outcome of a headless sprint.
+type RunResult struct {
+	State      *SprintState
+	Spec       *specs.Spec // nil unless the run completed
+	Transcript *Transcript
+}
+
+// RunHeadless drives a sprint through every phase using answers instead of
+// a user, persisting state with SaveSprintState after each phase. A run that
+// stops early (rule, accept: false, or blocker conflict) returns the partial
+// transcript and a nil Spec; only I/O and generation failures are errors.
+func (o *Orchestrator) RunHeadless(ctx context.Context, af *AnswerFile) (*RunResult, error) {
+	if err := af.Validate(); err != nil {
+		return nil, err
+	}
+	var (
+		state *SprintState
+		err   error
+	)
+	if af.Type == specs.SpecTypeVision {
+		state, err = o.StartVision(ctx, af.Input)
+	} else {
+		state, err = o.Start(ctx, af.Input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tr := &Transcript{SprintID: state.ID, Input: af.Input}
+	result := &RunResult{State: state, Transcript: tr}
+	stop := func(phase Phase, reason string) (*RunResult, error) {
+		tr.StoppedAt = phase.Key()
+		tr.Reason = reason
+		return result, SaveSprintState(state)
+	}
+
+	phases := AllPhases()
+	for i, phase := range phases {
+		section := state.Sections[phase]
+		step := TranscriptStep{Phase: phase.Key(), Draft: section.Content, Action: RuleAccept}
+
+		accept := true
+		if ans, ok := af.answerFor(phase); ok {
+			content := section.Content
+			switch {
+			case ans.Content != "":
+				content = ans.Content
+			case ans.Option > 0:
+				if ans.Option > len(section.Options) {
+					return nil, fmt.Errorf("%s: option %d out of range (%d available)", phase.Key(), ans.Option, len(section.Options))
+				}
+				content = section.Options[ans.Option-1]
+			}
+			if ans.Append != "" {
+				content = strings.TrimRight(content, "\n") + "\n" + ans.Append
+			}
+			if content != section.Content {
+				o.ReviseDraft(state, content, answerReason(ans.Reason, "answer file"))
+				step.Action = RuleRevise
+			}
+			if ans.Accept != nil {
+				accept = *ans.Accept
+			}
+		}
+
+		if idx, rule := af.ruleFor(phase, section.Content); rule != nil {
+			step.Rule = fmt.Sprintf("rules[%d]", idx)
+			switch rule.Action {
+			case RuleStop:
+				accept = false
+			case RuleAccept:
+				accept = true
+			case RuleRevise:
+				content := section.Content
+				if rule.Content != "" {
+					content = rule.Content
+				}
+				if rule.Append != "" {
+					content = strings.TrimRight(content, "\n") + "\n" + rule.Append
+				}
+				o.ReviseDraft(state, content, answerReason("", step.Rule))
+				step.Action = RuleRevise
+			}
+		}
+
+		step.Final = section.Content
+		if !accept {
+			step.Action = RuleStop
+			tr.Steps = append(tr.Steps, step)
+			reason := "accept: false"
+			if step.Rule != "" {
+				reason = step.Rule + " requested stop"
+			}
+			return stop(phase, reason)
+		}
+		o.AcceptDraft(state)
+
+		if i+1 < len(phases) {
+			if _, err := o.Advance(ctx, state); err != nil {
+				step.Conflicts = conflictMessages(state.Conflicts)
+				step.Confidence = state.Confidence.Total()
+				tr.Steps = append(tr.Steps, step)
+				if IsBlockerError(err) {
+					return stop(phase, err.Error())
+				}
+				return nil, err
+			}
+		} else {
+			state.Conflicts = o.checkConsistency(state)
+			o.updateConfidence(state)
+		}
+		step.Conflicts = conflictMessages(state.Conflicts)
+		step.Confidence = state.Confidence.Total()
+		tr.Steps = append(tr.Steps, step)
+		if err := SaveSprintState(state); err != nil {
+			return nil, err
+		}
+	}
+
+	spec, err := o.ExportSpec(state)
+	if err != nil {
+		return nil, err
+	}
+	result.Spec = spec
+	tr.Completed = true
+	return result, nil
+}
+
+func answerReason(reason, fallback string) string {
+	if reason != "" {
+		return reason
+	}
+	return fallback
+}
+
+func conflictMessages(conflicts []Conflict) []string {
+	var out []string
+	for _, c := range conflicts {
+		out = append(out, c.Message)
+	}
+	return out
+}
+
+// SaveTranscript writes the transcript as Markdown next to the sprint state
+// and returns its path.
+func SaveTranscript(projectPath string, tr *Transcript) (string, error) {
+	if tr.SprintID == "" || filepath.Base(tr.SprintID) != tr.SprintID {
+		return "", fmt.Errorf("invalid sprint ID: %q", tr.SprintID)
+	}
+	dir := filepath.Join(projectPath, sprintsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create sprints dir: %w", err)
+	}
+	path := filepath.Join(dir, tr.SprintID+".transcript.md")
+	if err := os.WriteFile(path, []byte(tr.Markdown()), 0644); err != nil {
+		return "", fmt.Errorf("write transcript: %w", err)
+	}
+	return path, nil
+}
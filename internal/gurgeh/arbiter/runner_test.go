@@ -0,0 +1,117 @@
+package arbiter
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files")
+
+func goldenAnswers() *AnswerFile {
+	return &AnswerFile{
+		Input: "Offline sync for mobile field teams",
+		Phases: map[string]PhaseAnswer{
+			"problem": {Content: "## Problem\n\nField teams lose edits when connectivity drops."},
+			"users":   {Append: "Field technicians on rugged Android devices."},
+		},
+		Rules: []AnswerRule{
+			{Phase: "features_goals", Action: RuleRevise, Content: "## Features\n\n1. Offline edit queue\n\n## Goals\n\n- Zero lost edits"},
+		},
+	}
+}
+
+func TestRunHeadlessGolden(t *testing.T) {
+	root := t.TempDir()
+	o := NewOrchestrator(root)
+	res, err := o.RunHeadless(context.Background(), goldenAnswers())
+	if err != nil {
+		t.Fatalf("RunHeadless: %v", err)
+	}
+	if !res.Transcript.Completed || res.Spec == nil {
+		t.Fatalf("expected completed run, stopped at %s: %s", res.Transcript.StoppedAt, res.Transcript.Reason)
+	}
+	if res.Spec.Summary != "Field teams lose edits when connectivity drops." {
+		t.Errorf("unexpected summary %q", res.Spec.Summary)
+	}
+	if len(res.Spec.Goals) != 1 {
+		t.Errorf("expected rule-revised goals, got %+v", res.Spec.Goals)
+	}
+	if ids, _ := ListSprints(root); len(ids) != 1 {
+		t.Errorf("expected sprint state to be saved, got %v", ids)
+	}
+
+	golden := filepath.Join("testdata", "sprint_run.golden")
+	got := res.Transcript.Markdown()
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden (run with -update to create): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("transcript differs from %s; run with -update to accept:\n%s", golden, got)
+	}
+}
+
+func TestRunHeadlessStopRule(t *testing.T) {
+	root := t.TempDir()
+	af := &AnswerFile{
+		Input: "Offline sync",
+		Rules: []AnswerRule{{Contains: "[Describe", Action: RuleStop}},
+	}
+	res, err := NewOrchestrator(root).RunHeadless(context.Background(), af)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Spec != nil || res.Transcript.StoppedAt != "problem" {
+		t.Fatalf("expected stop at problem, got %+v", res.Transcript)
+	}
+	if _, err := LoadSprintState(root, res.State.ID); err != nil {
+		t.Errorf("stopped sprint should be resumable: %v", err)
+	}
+	if !strings.Contains(res.Transcript.Markdown(), "Stopped at problem: rules[0] requested stop") {
+		t.Errorf("unexpected transcript:\n%s", res.Transcript.Markdown())
+	}
+}
+
+func TestRunHeadlessStopsOnBlocker(t *testing.T) {
+	af := &AnswerFile{
+		Input: "Planner",
+		Phases: map[string]PhaseAnswer{
+			"problem":        {Content: "Solo founders juggle too many tools."},
+			"features_goals": {Content: "## Features\n\n1. Enterprise SSO for 100+ seats"},
+		},
+	}
+	res, err := NewOrchestrator(t.TempDir()).RunHeadless(context.Background(), af)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Transcript.StoppedAt != "features_goals" || len(res.Transcript.Steps[3].Conflicts) == 0 {
+		t.Fatalf("expected blocker at features_goals, got %+v", res.Transcript)
+	}
+}
+
+func TestAnswerFileValidate(t *testing.T) {
+	cases := map[string]*AnswerFile{
+		"missing input": {},
+		"unknown phase": {Input: "x", Phases: map[string]PhaseAnswer{"pricing": {}}},
+		"bad action":    {Input: "x", Rules: []AnswerRule{{Action: "skip"}}},
+		"empty revise":  {Input: "x", Rules: []AnswerRule{{Action: RuleRevise}}},
+		"bad type":      {Input: "x", Type: "epic"},
+	}
+	for name, af := range cases {
+		if err := af.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if p, err := ParsePhase("Features + Goals"); err != nil || p != PhaseFeaturesGoals {
+		t.Errorf("ParsePhase display name = %v, %v", p, err)
+	}
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/alignment"
+	"github.com/mistakeknot/autarch/internal/gurgeh/project"
+	gsignals "github.com/mistakeknot/autarch/internal/gurgeh/signals"
+	"github.com/spf13/cobra"
+)
+
+// AlignmentCmd reports vision-to-PRD alignment scores over time.
+func AlignmentCmd() *cobra.Command {
+	var (
+		threshold float64
+		jsonOut   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "alignment [prd-id]",
+		Short: "Show vision alignment score history for PRDs",
+		Long: `Score each PRD that has a vision_ref against its vision spec.
+
+Scores are replayed from spec history, so every revision of either the PRD
+or the vision adds a sample. Samples are stored in .gurgeh/alignment/.
+A PRD whose score falls below --threshold emits a vision_drift signal.
+
+Vision goal and assumption IDs listed in a PRD's dismissed_conflicts count
+as aligned.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			if err := project.EnsureInitialized(cwd); err != nil {
+				return err
+			}
+
+			all, emitted, warnings := gsignals.RefreshVisionDrift(cwd, threshold)
+			for _, w := range warnings {
+				fmt.Fprintln(cmd.ErrOrStderr(), "WARN:", w)
+			}
+			if len(args) == 1 {
+				var match []*alignment.Series
+				for _, s := range all {
+					if s.PRDID == args[0] {
+						match = append(match, s)
+					}
+				}
+				if len(match) == 0 {
+					return fmt.Errorf("no alignment for %s (missing spec or vision_ref)", args[0])
+				}
+				all = match
+			}
+			if jsonOut {
+				return writeJSON(cmd, all)
+			}
+
+			out := cmd.OutOrStdout()
+			if len(all) == 0 {
+				fmt.Fprintln(out, "No PRDs with a vision_ref.")
+				return nil
+			}
+			for _, s := range all {
+				latest := s.Latest()
+				marker := ""
+				if latest.Score < threshold {
+					marker = "  DRIFT"
+				}
+				fmt.Fprintf(out, "%s → %s  %.2f  %s%s\n", s.PRDID, latest.VisionID, latest.Score, alignment.Sparkline(s.Samples, 30), marker)
+				if len(args) == 1 {
+					for _, sample := range s.Samples {
+						fmt.Fprintf(out, "  %s  %-10s %.2f\n", sample.Timestamp.Format("2006-01-02 15:04"), sample.Trigger, sample.Score)
+					}
+				}
+			}
+			for _, sig := range emitted {
+				fmt.Fprintf(out, "Signal: %s — %s\n", sig.Title, sig.Detail)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Float64Var(&threshold, "threshold", alignment.DefaultDriftThreshold, "Score below which a PRD has drifted")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+	return cmd
+}
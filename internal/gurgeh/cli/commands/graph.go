@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/dependency"
+	"github.com/mistakeknot/autarch/internal/gurgeh/project"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"github.com/mistakeknot/autarch/pkg/discovery"
+	"github.com/spf13/cobra"
+)
+
+// GraphCmd creates the graph command for cross-spec dependencies.
+func GraphCmd() *cobra.Command {
+	var (
+		format   string
+		order    bool
+		impact   string
+		revision int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Show the dependency graph across specs",
+		Long: `Show how specs depend on each other via depends_on, blocks and supersedes.
+
+Links are declared on specs as spec IDs, optionally pinned to a requirement:
+  depends_on: ["PRD-001#REQ-002"]
+
+Archived specs are included. Dependency cycles are always reported.
+
+Examples:
+  gurgeh graph                          # links, cycles and dangling refs
+  gurgeh graph --format mermaid         # Mermaid flowchart (also: dot, json)
+  gurgeh graph --order                  # handoff order for Coldwine
+  gurgeh graph --impact PRD-001         # downstream specs and epics
+  gurgeh graph --impact PRD-001 --revision 3`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			g, warnings := dependency.LoadSpecGraph(project.SpecsDir(root), project.ArchivedSpecsDir(root))
+			for _, w := range warnings {
+				fmt.Fprintln(cmd.ErrOrStderr(), "WARN:", w)
+			}
+			out := cmd.OutOrStdout()
+
+			if impact != "" {
+				return runGraphImpact(cmd, root, g, impact, revision, format == "json")
+			}
+			if order {
+				ids, err := g.HandoffOrder()
+				if err != nil {
+					return err
+				}
+				if format == "json" {
+					return writeJSON(cmd, ids)
+				}
+				for i, id := range ids {
+					fmt.Fprintf(out, "%d. %s  %s\n", i+1, id, g.Nodes[id].Title)
+				}
+				return nil
+			}
+
+			switch format {
+			case "dot":
+				fmt.Fprint(out, g.DOT())
+			case "mermaid":
+				fmt.Fprint(out, g.Mermaid())
+			case "json":
+				return writeJSON(cmd, struct {
+					*dependency.SpecGraph
+					Cycles [][]string `json:"cycles"`
+				}{g, g.Cycles()})
+			case "text":
+				printGraph(cmd, g)
+			default:
+				return fmt.Errorf("invalid format %q (use text, dot, mermaid, or json)", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, dot, mermaid, json")
+	cmd.Flags().BoolVar(&order, "order", false, "Print specs in dependency order for Coldwine handoff")
+	cmd.Flags().StringVar(&impact, "impact", "", "Report specs and Coldwine epics affected by revising this spec")
+	cmd.Flags().IntVar(&revision, "revision", 0, "With --impact, only count requirements changed in this revision")
+
+	return cmd
+}
+
+func writeJSON(cmd *cobra.Command, v any) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printGraph(cmd *cobra.Command, g *dependency.SpecGraph) {
+	out := cmd.OutOrStdout()
+	if len(g.Links) == 0 {
+		fmt.Fprintln(out, "No cross-spec links.")
+	}
+	for _, l := range g.Links {
+		target := l.To
+		if l.Requirement != "" {
+			target += "#" + l.Requirement
+		}
+		fmt.Fprintf(out, "%s %s %s\n", l.From, l.Kind, target)
+	}
+	for _, l := range g.Dangling {
+		fmt.Fprintf(out, "WARN: %s %s %s: spec not found\n", l.From, l.Kind, l.To)
+	}
+	for _, c := range g.Cycles() {
+		fmt.Fprintf(out, "CYCLE: %s\n", strings.Join(c, " → "))
+	}
+}
+
+type graphImpact struct {
+	SpecID  string                    `json:"spec_id"`
+	Changed []string                  `json:"changed_requirements,omitempty"`
+	Specs   []dependency.ImpactedSpec `json:"specs"`
+	Epics   []impactedEpic            `json:"epics"`
+}
+
+type impactedEpic struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	SourcePRD string `json:"source_prd"`
+}
+
+func runGraphImpact(cmd *cobra.Command, root string, g *dependency.SpecGraph, specID string, revision int, jsonOut bool) error {
+	if _, ok := g.Nodes[specID]; !ok {
+		return fmt.Errorf("spec not found: %s", specID)
+	}
+	report := graphImpact{SpecID: specID, Specs: []dependency.ImpactedSpec{}, Epics: []impactedEpic{}}
+
+	var changed map[string]bool
+	if revision > 0 {
+		after, err := specs.LoadRevisionSpec(root, specID, revision)
+		if err != nil {
+			return fmt.Errorf("load revision %d: %w", revision, err)
+		}
+		var before specs.Spec
+		if revision > 1 {
+			if before, err = specs.LoadRevisionSpec(root, specID, revision-1); err != nil {
+				return fmt.Errorf("load revision %d: %w", revision-1, err)
+			}
+		}
+		changed = dependency.ChangedRequirements(&before, &after)
+		for id := range changed {
+			report.Changed = append(report.Changed, id)
+		}
+		sort.Strings(report.Changed)
+	}
+	report.Specs = append(report.Specs, g.Impact(specID, changed)...)
+
+	affected := map[string]bool{specID: true}
+	for _, s := range report.Specs {
+		affected[s.ID] = true
+	}
+	epics, _ := discovery.ColdwineEpics(root)
+	for _, e := range epics {
+		if affected[e.SourcePRD] {
+			report.Epics = append(report.Epics, impactedEpic{ID: e.ID, Title: e.Title, Status: e.Status, SourcePRD: e.SourcePRD})
+		}
+	}
+
+	if jsonOut {
+		return writeJSON(cmd, report)
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Impact of revising %s\n", specID)
+	if revision > 0 {
+		fmt.Fprintf(out, "  Changed requirements in v%d: %s\n", revision, joinOrNone(report.Changed))
+	}
+	fmt.Fprintf(out, "\nDownstream specs (%d):\n", len(report.Specs))
+	for _, s := range report.Specs {
+		fmt.Fprintf(out, "  %s%s  %s (via %s)\n", strings.Repeat("  ", s.Depth-1), s.ID, s.Title, s.Via)
+	}
+	fmt.Fprintf(out, "\nColdwine epics (%d):\n", len(report.Epics))
+	for _, e := range report.Epics {
+		fmt.Fprintf(out, "  %s  %s [%s] from %s\n", e.ID, e.Title, e.Status, e.SourcePRD)
+	}
+	return nil
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
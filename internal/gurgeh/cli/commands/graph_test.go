@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupGraphProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	specsDir := filepath.Join(root, ".gurgeh", "specs")
+	epicsDir := filepath.Join(root, ".coldwine", "specs")
+	for _, dir := range []string{specsDir, epicsDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(specsDir, "PRD-001.yaml"):  "id: PRD-001\ntitle: Auth\nsummary: s\n",
+		filepath.Join(specsDir, "PRD-002.yaml"):  "id: PRD-002\ntitle: Billing\nsummary: s\ndepends_on: [\"PRD-001#REQ-002\"]\n",
+		filepath.Join(epicsDir, "EPIC-001.yaml"): "id: EPIC-001\ntitle: Billing epic\nstatus: todo\nsource_prd: PRD-002\n",
+		filepath.Join(epicsDir, "EPIC-002.yaml"): "id: EPIC-002\ntitle: Other\nstatus: todo\nsource_prd: PRD-009\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(cwd) })
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestGraphCmdOrderAndMermaid(t *testing.T) {
+	setupGraphProject(t)
+
+	cmd := GraphCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--order"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("graph --order: %v", err)
+	}
+	if !strings.Contains(out.String(), "1. PRD-001") || !strings.Contains(out.String(), "2. PRD-002") {
+		t.Fatalf("unexpected order output %q", out.String())
+	}
+
+	cmd = GraphCmd()
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--format", "mermaid"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("graph --format mermaid: %v", err)
+	}
+	if !strings.Contains(out.String(), "PRD_002 -->|depends_on REQ-002| PRD_001") {
+		t.Fatalf("unexpected mermaid %q", out.String())
+	}
+}
+
+func TestGraphCmdImpactListsEpics(t *testing.T) {
+	setupGraphProject(t)
+
+	cmd := GraphCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--impact", "PRD-001"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("graph --impact: %v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "PRD-002  Billing (via PRD-001#REQ-002)") {
+		t.Fatalf("missing downstream spec: %q", got)
+	}
+	if !strings.Contains(got, "EPIC-001") || strings.Contains(got, "EPIC-002") {
+		t.Fatalf("unexpected epics: %q", got)
+	}
+}
+
+func TestGraphCmdRejectsUnknownFormat(t *testing.T) {
+	setupGraphProject(t)
+
+	cmd := GraphCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--format", "svg"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected invalid format error")
+	}
+}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/arbiter"
+	"github.com/mistakeknot/autarch/internal/gurgeh/project"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportCmd creates the import command for existing Markdown and Gherkin PRDs.
+func ImportCmd() *cobra.Command {
+	var (
+		format  string
+		dryRun  bool
+		jsonOut bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import <file>...",
+		Short: "Import existing Markdown or Gherkin PRDs as specs",
+		Long: `Import PRDs written outside Gurgeh into .gurgeh/specs.
+
+Markdown files are split on level-2 headings and mapped onto Summary,
+Goals, Non-Goals, Assumptions, Requirements, Critical User Journeys and
+Acceptance Criteria. Gherkin .feature files map each Scenario to a
+structured Given/When/Then requirement.
+
+Each import prints a report listing the content that could not be mapped.
+
+Examples:
+  gurgeh import docs/search-prd.md
+  gurgeh import features/*.feature
+  gurgeh import --dry-run docs/prd.md`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := importFormat(format); err != nil {
+				return err
+			}
+			root, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			specsDir := project.SpecsDir(root)
+			if !dryRun {
+				if err := os.MkdirAll(specsDir, 0o755); err != nil {
+					return fmt.Errorf("failed to create specs directory: %w", err)
+				}
+			}
+
+			// Allocate IDs up front: a dry run writes nothing, so NextID
+			// would hand every file the same one.
+			ids := specs.NewIDs(specsDir)
+
+			// Convert every file before writing any, so a bad input
+			// leaves no partial import behind.
+			var results []importResult
+			var data [][]byte
+			for _, path := range args {
+				raw, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				spec, report := importSpec(path, string(raw), format)
+
+				id := ids.Next()
+				spec.ID = id
+				spec.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+				spec.Metadata.ValidationWarnings = report.Unmapped
+
+				out, err := yaml.Marshal(spec)
+				if err != nil {
+					return fmt.Errorf("failed to serialize spec: %w", err)
+				}
+				results = append(results, importResult{ID: id, Report: report})
+				data = append(data, out)
+			}
+
+			for i := range results {
+				if dryRun {
+					if !jsonOut {
+						fmt.Fprintf(cmd.OutOrStdout(), "# %s (dry run from %s)\n%s\n", results[i].ID, results[i].Report.Source, data[i])
+					}
+					continue
+				}
+				path := filepath.Join(specsDir, results[i].ID+".yaml")
+				if err := os.WriteFile(path, data[i], 0o644); err != nil {
+					for _, res := range results[:i] {
+						os.Remove(res.Path)
+					}
+					return fmt.Errorf("failed to write spec: %w", err)
+				}
+				results[i].Path = path
+			}
+
+			if jsonOut {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(results)
+			}
+			for _, res := range results {
+				printImportReport(cmd, res)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "auto", "Source format: auto, markdown, gherkin")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the converted spec without writing it")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output import reports as JSON")
+
+	return cmd
+}
+
+type importResult struct {
+	ID     string                `json:"id"`
+	Path   string                `json:"path,omitempty"`
+	Report *arbiter.ImportReport `json:"report"`
+}
+
+func importFormat(format string) error {
+	switch format {
+	case "auto", "markdown", "gherkin":
+		return nil
+	default:
+		return fmt.Errorf("invalid format %q (use auto, markdown, or gherkin)", format)
+	}
+}
+
+// importSpec dispatches to the Markdown or Gherkin importer. In auto mode the
+// .feature extension selects Gherkin and everything else is read as Markdown.
+func importSpec(path, content, format string) (*specs.Spec, *arbiter.ImportReport) {
+	if format == "gherkin" || (format == "auto" && strings.EqualFold(filepath.Ext(path), ".feature")) {
+		return arbiter.ImportGherkin(path, content)
+	}
+	return arbiter.ImportMarkdown(path, content)
+}
+
+func printImportReport(cmd *cobra.Command, res importResult) {
+	out := cmd.OutOrStdout()
+	if res.Path != "" {
+		fmt.Fprintf(out, "Imported %s → %s (%s)\n", res.Report.Source, res.ID, res.Path)
+	} else {
+		fmt.Fprintf(out, "Would import %s → %s\n", res.Report.Source, res.ID)
+	}
+	if len(res.Report.Mapped) > 0 {
+		fmt.Fprintf(out, "  Mapped: %s\n", strings.Join(res.Report.Mapped, ", "))
+	}
+	if len(res.Report.Unmapped) == 0 {
+		fmt.Fprintln(out, "  Unmapped: none")
+		return
+	}
+	fmt.Fprintf(out, "  Unmapped (%d):\n", len(res.Report.Unmapped))
+	for _, u := range res.Report.Unmapped {
+		fmt.Fprintf(out, "    - %s\n", u)
+	}
+}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+)
+
+func TestImportCmdWritesSpecAndReport(t *testing.T) {
+	root := t.TempDir()
+	specsDir := filepath.Join(root, ".gurgeh", "specs")
+	if err := os.MkdirAll(specsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(root, "login.feature")
+	feature := "Feature: Login\n  Scenario: Valid password\n    Given a user\n    When they log in\n    Then they see the dashboard\n"
+	if err := os.WriteFile(src, []byte(feature), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := ImportCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{src})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !strings.Contains(out.String(), "Unmapped: none") {
+		t.Fatalf("expected clean report, got %q", out.String())
+	}
+	spec, err := specs.LoadSpec(filepath.Join(specsDir, "PRD-001.yaml"))
+	if err != nil {
+		t.Fatalf("load imported spec: %v", err)
+	}
+	if spec.Title != "Login" || len(spec.StructuredRequirements) != 1 {
+		t.Fatalf("unexpected spec: %+v", spec)
+	}
+}
+
+func TestImportCmdDryRunAllocatesDistinctIDs(t *testing.T) {
+	root := t.TempDir()
+	var args []string
+	for _, name := range []string{"a.md", "b.md"} {
+		src := filepath.Join(root, name)
+		if err := os.WriteFile(src, []byte("# "+name+"\n\n## Summary\nText.\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, src)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := ImportCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs(append([]string{"--dry-run"}, args...))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	for _, want := range []string{"→ PRD-001", "→ PRD-002"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in %q", want, out.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, ".gurgeh")); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote files: %v", err)
+	}
+}
+
+func TestImportCmdRejectsUnknownFormat(t *testing.T) {
+	cmd := ImportCmd()
+	cmd.SetArgs([]string{"--format", "docx", "prd.docx"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected invalid format error")
+	}
+}
+
+func TestImportCmdWritesNothingWhenAnInputFails(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "a.md")
+	if err := os.WriteFile(src, []byte("# A\n\n## Summary\nText.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := ImportCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{src, filepath.Join(root, "missing.md")})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected the missing file to fail the import")
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, ".gurgeh", "specs"))
+	if len(entries) != 0 {
+		t.Fatalf("expected no specs written, got %d", len(entries))
+	}
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/arbiter"
+	"github.com/mistakeknot/autarch/internal/gurgeh/project"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// SprintCmd groups arbiter sprint commands.
+func SprintCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sprint",
+		Short: "Run arbiter spec sprints",
+	}
+	cmd.AddCommand(sprintRunCmd())
+	return cmd
+}
+
+type sprintRunResult struct {
+	Answers    string `json:"answers"`
+	SprintID   string `json:"sprint_id"`
+	SpecID     string `json:"spec_id,omitempty"`
+	SpecPath   string `json:"spec_path,omitempty"`
+	Transcript string `json:"transcript"`
+	StoppedAt  string `json:"stopped_at,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+func sprintRunCmd() *cobra.Command {
+	var (
+		answers []string
+		dryRun  bool
+		jsonOut bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run --answers <file>...",
+		Short: "Run a sprint headlessly from answer files",
+		Long: `Drive the arbiter sprint without the TUI.
+
+Each answer file produces one sprint. Phase answers replace, pick an
+alternative for, or append to the generated draft; rules then accept,
+revise, or stop on drafts that match. Sprint state is saved to
+.gurgeh/sprints after every phase and a Markdown transcript is written
+alongside it. Completed sprints are exported to .gurgeh/specs.
+
+Answer file:
+  input: "Offline sync for the mobile app"
+  type: prd                 # or vision
+  phases:
+    problem:
+      content: "Field teams lose edits when connectivity drops."
+    users:
+      append: "Field technicians on rugged Android devices."
+    acceptance_criteria:
+      accept: false         # stop here for human review
+  rules:
+    - contains: "[Describe"
+      action: stop
+
+Phases: vision, problem, users, features_goals, requirements,
+scope_assumptions, cujs, acceptance_criteria.
+
+Exits non-zero if any sprint stopped before the last phase.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(answers) == 0 {
+				return fmt.Errorf("--answers is required")
+			}
+			root, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			if err := project.EnsureInitialized(root); err != nil {
+				return err
+			}
+			// Validate every file before running any sprint.
+			files := make([]*arbiter.AnswerFile, len(answers))
+			for i, path := range answers {
+				if files[i], err = arbiter.LoadAnswerFile(path); err != nil {
+					return err
+				}
+			}
+
+			// Allocate IDs up front: a dry run writes nothing, so NextID
+			// would hand every sprint the same one.
+			ids := specs.NewIDs(project.SpecsDir(root))
+			var results []sprintRunResult
+			stopped := 0
+			for i, af := range files {
+				run, err := arbiter.NewOrchestrator(root).RunHeadless(cmd.Context(), af)
+				if err != nil {
+					return fmt.Errorf("%s: %w", answers[i], err)
+				}
+				res := sprintRunResult{Answers: answers[i], SprintID: run.State.ID}
+				if res.Transcript, err = arbiter.SaveTranscript(root, run.Transcript); err != nil {
+					return err
+				}
+				if run.Spec == nil {
+					res.StoppedAt, res.Reason = run.Transcript.StoppedAt, run.Transcript.Reason
+					stopped++
+				} else if res.SpecID, res.SpecPath, err = writeSprintSpec(root, run.Spec, ids.Next(), dryRun); err != nil {
+					return err
+				}
+				results = append(results, res)
+			}
+
+			if jsonOut {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(results); err != nil {
+					return err
+				}
+			} else {
+				printSprintResults(cmd, results, dryRun)
+			}
+			if stopped > 0 {
+				return fmt.Errorf("%d of %d sprint(s) stopped early", stopped, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&answers, "answers", nil, "Answer file (repeat for a batch)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Run sprints without exporting specs")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output results as JSON")
+	return cmd
+}
+
+// writeSprintSpec gives the exported spec its ID and writes it.
+func writeSprintSpec(root string, spec *specs.Spec, id string, dryRun bool) (string, string, error) {
+	specsDir := project.SpecsDir(root)
+	if dryRun {
+		return id, "", nil
+	}
+	spec.ID = id
+	spec.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to serialize spec: %w", err)
+	}
+	if err := os.MkdirAll(specsDir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create specs directory: %w", err)
+	}
+	path := filepath.Join(specsDir, id+".yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", "", fmt.Errorf("failed to write spec: %w", err)
+	}
+	return id, path, nil
+}
+
+func printSprintResults(cmd *cobra.Command, results []sprintRunResult, dryRun bool) {
+	out := cmd.OutOrStdout()
+	for _, res := range results {
+		switch {
+		case res.StoppedAt != "":
+			fmt.Fprintf(out, "%s: stopped at %s (%s)\n", res.Answers, res.StoppedAt, res.Reason)
+		case dryRun:
+			fmt.Fprintf(out, "%s: completed (would export %s)\n", res.Answers, res.SpecID)
+		default:
+			fmt.Fprintf(out, "%s: exported %s → %s\n", res.Answers, res.SpecID, res.SpecPath)
+		}
+		fmt.Fprintf(out, "  Sprint: %s\n  Transcript: %s\n", res.SprintID, res.Transcript)
+	}
+}
@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+)
+
+func TestSprintRunExportsSpecAndTranscript(t *testing.T) {
+	root := t.TempDir()
+	specsDir := filepath.Join(root, ".gurgeh", "specs")
+	if err := os.MkdirAll(specsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	answers := filepath.Join(root, "answers.yaml")
+	content := "input: Offline sync\nphases:\n  problem:\n    content: Field teams lose edits offline.\n"
+	if err := os.WriteFile(answers, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := SprintCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"run", "--answers", answers})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("sprint run: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "exported PRD-001") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	spec, err := specs.LoadSpec(filepath.Join(specsDir, "PRD-001.yaml"))
+	if err != nil {
+		t.Fatalf("load exported spec: %v", err)
+	}
+	if spec.Summary != "Field teams lose edits offline." {
+		t.Fatalf("unexpected summary %q", spec.Summary)
+	}
+	transcripts, _ := filepath.Glob(filepath.Join(root, ".gurgeh", "sprints", "*.transcript.md"))
+	if len(transcripts) != 1 {
+		t.Fatalf("expected one transcript, got %v", transcripts)
+	}
+}
+
+func TestSprintRunReportsStoppedSprint(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".gurgeh", "specs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	answers := filepath.Join(root, "answers.yaml")
+	content := "input: Offline sync\nphases:\n  users:\n    accept: false\n"
+	if err := os.WriteFile(answers, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := SprintCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"run", "--answers", answers})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "stopped early") {
+		t.Fatalf("expected stopped-early error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "stopped at users") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+func TestSprintRunDryRunAllocatesDistinctIDs(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".gurgeh", "specs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"run", "--dry-run"}
+	for _, name := range []string{"a.yaml", "b.yaml"} {
+		path := filepath.Join(root, name)
+		content := "input: Offline sync\nphases:\n  problem:\n    content: Field teams lose edits offline.\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, "--answers", path)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := SprintCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("sprint run: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"would export PRD-001", "would export PRD-002"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in %q", want, out.String())
+		}
+	}
+	if written, _ := filepath.Glob(filepath.Join(root, ".gurgeh", "specs", "*.yaml")); len(written) != 0 {
+		t.Fatalf("dry run wrote specs: %v", written)
+	}
+}
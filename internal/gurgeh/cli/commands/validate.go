@@ -16,11 +16,32 @@ import (
 func ValidateCmd() *cobra.Command {
 	var mode string
 	var jsonOut bool
+	var opts lintOptions
 	cmd := &cobra.Command{
 		Use:   "validate <id>",
 		Short: "Validate a PRD spec",
-		Args:  cobra.ExactArgs(1),
+		Long: `Validate a PRD spec.
+
+By default only the schema checks run. Use --lint to run the unified rule
+engine (schema, stakeholder validators, consistency checkers and reviewers)
+with the per-project rule settings from [lint.rules] in config.toml.
+Lint exits non-zero when any error-severity finding remains, so it can gate CI.
+
+Examples:
+  gurgeh validate PRD-001
+  gurgeh validate PRD-001 --lint --fix
+  gurgeh validate --lint --all --format sarif > gurgeh.sarif
+  gurgeh validate --list-rules`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.listRules || opts.all {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.listRules {
+				return listLintRules(cmd)
+			}
 			root, err := os.Getwd()
 			if err != nil {
 				return err
@@ -29,6 +50,9 @@ func ValidateCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if opts.enabled() {
+				return runLint(cmd, root, cfg, args, opts)
+			}
 			selected := mode
 			if selected == "" {
 				selected = cfg.ValidationMode
@@ -83,6 +107,11 @@ func ValidateCmd() *cobra.Command {
 	}
 	cmd.Flags().StringVar(&mode, "mode", "", "Validation mode (hard|soft)")
 	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print validation results as JSON")
+	cmd.Flags().BoolVar(&opts.lint, "lint", false, "Run the configurable lint rule engine")
+	cmd.Flags().StringVar(&opts.format, "format", "", "Lint output format (text|json|sarif); implies --lint")
+	cmd.Flags().BoolVar(&opts.fix, "fix", false, "Apply lint autofixes and save the spec; implies --lint")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Lint every spec in the project; implies --lint")
+	cmd.Flags().BoolVar(&opts.listRules, "list-rules", false, "List lint rule IDs and default severities")
 	return cmd
 }
 
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/config"
+	"github.com/mistakeknot/autarch/internal/gurgeh/lint"
+	"github.com/mistakeknot/autarch/internal/gurgeh/project"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+type lintOptions struct {
+	lint      bool
+	format    string
+	fix       bool
+	all       bool
+	listRules bool
+}
+
+func (o lintOptions) enabled() bool {
+	return o.lint || o.format != "" || o.fix || o.all
+}
+
+func listLintRules(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	for _, rule := range lint.DefaultRegistry().Rules() {
+		fix := ""
+		if _, ok := rule.(lint.Fixer); ok {
+			fix = " [autofix]"
+		}
+		fmt.Fprintf(out, "%-34s %-8s %s%s\n", rule.ID(), rule.DefaultSeverity(), rule.Description(), fix)
+	}
+	return nil
+}
+
+func runLint(cmd *cobra.Command, root string, cfg config.Config, args []string, opts lintOptions) error {
+	format := opts.format
+	if format == "" {
+		format = "text"
+	}
+	if format != "text" && format != "json" && format != "sarif" {
+		return fmt.Errorf("invalid lint format %q (use text, json, or sarif)", format)
+	}
+	reg := lint.DefaultRegistry()
+	engine, err := lint.NewEngine(reg, cfg.Lint, root)
+	if err != nil {
+		return err
+	}
+
+	specsDir := project.SpecsDir(root)
+	var paths []string
+	if opts.all {
+		for _, s := range sortedSpecSummaries(specsDir) {
+			paths = append(paths, s.Path)
+		}
+	} else {
+		path, err := resolveSpecPath(specsDir, args[0])
+		if err != nil {
+			return err
+		}
+		paths = []string{path}
+	}
+
+	var reports []*lint.Report
+	for _, path := range paths {
+		spec, err := specs.LoadSpec(path)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", path, err)
+		}
+		var fixed []string
+		if opts.fix {
+			if fixed = engine.Fix(&spec); len(fixed) > 0 {
+				data, err := yaml.Marshal(&spec)
+				if err != nil {
+					return fmt.Errorf("failed to serialize spec: %w", err)
+				}
+				if err := os.WriteFile(path, data, 0o644); err != nil {
+					return fmt.Errorf("failed to write spec: %w", err)
+				}
+			}
+		}
+		report := engine.Run(&spec)
+		report.Path = path
+		report.Fixed = fixed
+		reports = append(reports, report)
+	}
+
+	switch format {
+	case "json":
+		err = lint.WriteJSON(cmd.OutOrStdout(), reports)
+	case "sarif":
+		err = lint.WriteSARIF(cmd.OutOrStdout(), reg, reports, root)
+	default:
+		printLintReports(cmd, reports)
+	}
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for _, r := range reports {
+		failures += r.Count(lint.SeverityError)
+	}
+	if failures > 0 {
+		return fmt.Errorf("lint failed: %d error(s)", failures)
+	}
+	return nil
+}
+
+func sortedSpecSummaries(dir string) []specs.Summary {
+	summaries, _ := specs.LoadSummaries(dir)
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID < summaries[j].ID })
+	return summaries
+}
+
+func printLintReports(cmd *cobra.Command, reports []*lint.Report) {
+	out := cmd.OutOrStdout()
+	for _, r := range reports {
+		name := r.SpecID
+		if name == "" {
+			name = filepath.Base(r.Path)
+		}
+		if len(r.Fixed) > 0 {
+			fmt.Fprintf(out, "%s: fixed %s\n", name, strings.Join(r.Fixed, ", "))
+		}
+		if len(r.Findings) == 0 {
+			fmt.Fprintf(out, "%s: OK\n", name)
+			continue
+		}
+		for _, f := range r.Findings {
+			loc := ""
+			if f.Location != "" {
+				loc = " (" + f.Location + ")"
+			}
+			fmt.Fprintf(out, "%s: %s [%s] %s%s\n", name, strings.ToUpper(string(f.Severity)), f.RuleID, f.Message, loc)
+		}
+		fmt.Fprintf(out, "%s: %d error(s), %d warning(s), %d info\n", name,
+			r.Count(lint.SeverityError), r.Count(lint.SeverityWarning), r.Count(lint.SeverityInfo))
+	}
+}
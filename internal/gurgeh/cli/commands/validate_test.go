@@ -103,3 +103,56 @@ func TestValidateCmdPrintsErrors(t *testing.T) {
 		t.Fatalf("expected validation error")
 	}
 }
+
+func TestValidateCmdLintFailsOnErrorsAndFixes(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".gurgeh", "specs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := "validation_mode = \"soft\"\n\n[lint.rules.\"review/completeness\"]\nenabled = false\n"
+	if err := os.WriteFile(filepath.Join(root, ".gurgeh", "config.toml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	spec := "id: \"PRD-001\"\ntitle: \"T\"\nsummary: \"S\"\nstatus: \"drafting\"\ncritical_user_journeys:\n  - id: \"CUJ-001\"\n    priority: \"medium\"\n"
+	specPath := filepath.Join(root, ".gurgeh", "specs", "PRD-001.yaml")
+	if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := ValidateCmd()
+	buf := bytes.NewBuffer(nil)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs([]string{"PRD-001", "--lint"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatalf("expected lint failure, got %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "[cuj/priority]") || strings.Contains(buf.String(), "[review/completeness]") {
+		t.Fatalf("unexpected lint output: %q", buf.String())
+	}
+
+	cmd = ValidateCmd()
+	buf.Reset()
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs([]string{"PRD-001", "--fix"})
+	_ = cmd.Execute()
+	if !strings.Contains(buf.String(), "fixed") {
+		t.Fatalf("expected fix summary, got %q", buf.String())
+	}
+	raw, err := os.ReadFile(specPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "priority: med\n") || !strings.Contains(string(raw), "status: draft") {
+		t.Fatalf("expected fixes written, got:\n%s", raw)
+	}
+}
@@ -45,6 +45,7 @@ func NewRoot() *cobra.Command {
 		commands.InterviewCmd(),
 		commands.RunCmd(),
 		commands.ResearchCmd(),
+		commands.ImportCmd(),
 		commands.ImportResearchCmd(),
 		commands.SuggestCmd(),
 		commands.SuggestionsCmd(),
@@ -58,6 +59,9 @@ func NewRoot() *cobra.Command {
 		commands.HistoryCmd(),
 		commands.DiffCmd(),
 		commands.PrioritizeCmd(),
+		commands.GraphCmd(),
+		commands.AlignmentCmd(),
+		commands.SprintCmd(),
 		commands.SignalsCmd(),
 		commands.VisionReviewCmd(),
 		commands.ServeCmd(),
@@ -9,8 +9,22 @@ import (
 )
 
 type Config struct {
-	ValidationMode string                   `toml:"validation_mode"`
+	ValidationMode string                  `toml:"validation_mode"`
 	Agents         map[string]AgentProfile `toml:"agents"`
+	Lint           LintConfig              `toml:"lint"`
+}
+
+// LintConfig tunes the spec lint rules per project. Rules are keyed by their
+// stable rule ID, e.g. [lint.rules."cuj/linked-requirements"].
+type LintConfig struct {
+	Rules map[string]LintRuleConfig `toml:"rules"`
+}
+
+// LintRuleConfig enables, disables, re-grades or tunes a single lint rule.
+type LintRuleConfig struct {
+	Enabled  *bool          `toml:"enabled"`  // nil keeps the rule's default (on)
+	Severity string         `toml:"severity"` // error, warning, info; empty keeps the default
+	Options  map[string]any `toml:"options"`  // rule-specific tuning knobs
 }
 
 type AgentProfile struct {
@@ -42,6 +56,14 @@ args = []
 [agents.droid]
 command = "droid"
 args = []
+
+# Spec lint rules (list them with: gurgeh validate --list-rules)
+# [lint.rules."cuj/linked-requirements"]
+# severity = "error"
+# [lint.rules."review/scope-creep"]
+# options = { max_requirements = 10 }
+# [lint.rules."consistency/assumption"]
+# enabled = false
 `
 
 func LoadFromRoot(root string) (Config, error) {
@@ -66,3 +66,37 @@ func TestLoadConfigMergesGlobalAgents(t *testing.T) {
 		t.Fatalf("expected codex from global config")
 	}
 }
+
+func TestLoadConfigReadsLintRules(t *testing.T) {
+	root := t.TempDir()
+	cfgDir := filepath.Join(root, ".gurgeh")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	raw := `validation_mode = "soft"
+
+[lint.rules."cuj/linked-requirements"]
+severity = "error"
+
+[lint.rules."review/scope-creep"]
+enabled = false
+options = { max_requirements = 10 }
+`
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.toml"), []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFromRoot(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Lint.Rules["cuj/linked-requirements"].Severity; got != "error" {
+		t.Fatalf("expected error severity, got %q", got)
+	}
+	scope := cfg.Lint.Rules["review/scope-creep"]
+	if scope.Enabled == nil || *scope.Enabled {
+		t.Fatalf("expected scope-creep disabled")
+	}
+	if scope.Options["max_requirements"] != int64(10) {
+		t.Fatalf("expected max_requirements option, got %#v", scope.Options)
+	}
+}
@@ -0,0 +1,403 @@
+package dependency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+)
+
+// LinkKind is the type of an explicit cross-spec link.
+type LinkKind string
+
+const (
+	LinkDependsOn  LinkKind = "depends_on" // From builds on To
+	LinkBlocks     LinkKind = "blocks"     // From must land before To
+	LinkSupersedes LinkKind = "supersedes" // From replaces To
+)
+
+// SpecNode is a spec in the cross-spec graph.
+type SpecNode struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	Path     string `json:"path,omitempty"`
+	Archived bool   `json:"archived"`
+}
+
+// SpecLink is a directed link exactly as declared on the From spec.
+// Requirement is set when the link targets one requirement ("PRD-001#REQ-002").
+type SpecLink struct {
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Kind        LinkKind `json:"kind"`
+	Requirement string   `json:"requirement,omitempty"`
+}
+
+// before returns the (upstream, downstream) pair for ordering links.
+// Supersedes links carry no ordering and return ok=false.
+func (l SpecLink) before() (upstream, downstream string, ok bool) {
+	switch l.Kind {
+	case LinkDependsOn:
+		return l.To, l.From, true
+	case LinkBlocks:
+		return l.From, l.To, true
+	}
+	return "", "", false
+}
+
+// SpecGraph is the dependency graph across all specs in a project.
+type SpecGraph struct {
+	Nodes map[string]*SpecNode `json:"nodes"`
+	Links []SpecLink           `json:"links"`
+	// Dangling lists links whose target spec does not exist.
+	Dangling []SpecLink `json:"dangling,omitempty"`
+}
+
+// LoadSpecGraph builds the graph from the active and archived specs dirs.
+// Unreadable specs are skipped and reported as warnings.
+func LoadSpecGraph(activeDir, archivedDir string) (*SpecGraph, []string) {
+	summaries, warnings := specs.LoadSummariesWithArchived(activeDir, archivedDir, true)
+	var list []specs.Spec
+	archived := make(map[string]bool)
+	paths := make(map[string]string)
+	for _, s := range summaries {
+		spec, err := specs.LoadSpec(s.Path)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", s.Path, err))
+			continue
+		}
+		list = append(list, spec)
+		paths[spec.ID] = s.Path
+		if strings.HasPrefix(s.Path, archivedDir) {
+			archived[spec.ID] = true
+		}
+	}
+	g := BuildSpecGraph(list, archived)
+	for id, p := range paths {
+		if n, ok := g.Nodes[id]; ok {
+			n.Path = p
+		}
+	}
+	return g, warnings
+}
+
+// BuildSpecGraph builds the graph from loaded specs. archived marks spec IDs
+// that live in the archive; they stay in the graph for impact analysis but
+// are left out of the handoff order.
+func BuildSpecGraph(list []specs.Spec, archived map[string]bool) *SpecGraph {
+	g := &SpecGraph{Nodes: make(map[string]*SpecNode)}
+	for _, s := range list {
+		g.Nodes[s.ID] = &SpecNode{ID: s.ID, Title: s.Title, Status: s.Status, Archived: archived[s.ID]}
+	}
+	for _, s := range list {
+		for _, group := range []struct {
+			kind LinkKind
+			refs []string
+		}{
+			{LinkDependsOn, s.DependsOn},
+			{LinkBlocks, s.Blocks},
+			{LinkSupersedes, s.Supersedes},
+		} {
+			for _, ref := range group.refs {
+				to, req := ParseSpecRef(ref)
+				if to == "" {
+					continue
+				}
+				link := SpecLink{From: s.ID, To: to, Kind: group.kind, Requirement: req}
+				if _, ok := g.Nodes[to]; !ok {
+					g.Dangling = append(g.Dangling, link)
+					continue
+				}
+				g.Links = append(g.Links, link)
+			}
+		}
+	}
+	return g
+}
+
+// ParseSpecRef splits "PRD-001#REQ-002" into spec and requirement IDs.
+func ParseSpecRef(ref string) (specID, requirement string) {
+	ref = strings.TrimSpace(ref)
+	if i := strings.Index(ref, "#"); i >= 0 {
+		return strings.TrimSpace(ref[:i]), strings.TrimSpace(ref[i+1:])
+	}
+	return ref, ""
+}
+
+func (g *SpecGraph) sortedIDs() []string {
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// downstream maps each spec to the specs that must come after it.
+func (g *SpecGraph) downstream() map[string][]string {
+	adj := make(map[string][]string)
+	for _, l := range g.Links {
+		if up, down, ok := l.before(); ok {
+			adj[up] = append(adj[up], down)
+		}
+	}
+	for id := range adj {
+		sort.Strings(adj[id])
+	}
+	return adj
+}
+
+// Cycles returns every dependency cycle (strongly connected component with
+// more than one spec, or a self-link), each sorted by spec ID.
+func (g *SpecGraph) Cycles() [][]string {
+	adj := g.downstream()
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+	next := 0
+
+	var connect func(v string)
+	connect = func(v string) {
+		index[v] = next
+		low[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range adj[v] {
+			if _, seen := index[w]; !seen {
+				connect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var scc []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		if len(scc) > 1 || selfLinked(adj, v) {
+			sort.Strings(scc)
+			cycles = append(cycles, scc)
+		}
+	}
+	for _, id := range g.sortedIDs() {
+		if _, seen := index[id]; !seen {
+			connect(id)
+		}
+	}
+	return cycles
+}
+
+func selfLinked(adj map[string][]string, id string) bool {
+	for _, w := range adj[id] {
+		if w == id {
+			return true
+		}
+	}
+	return false
+}
+
+// HandoffOrder returns the active specs in dependency order for the Coldwine
+// handoff: every spec appears after the specs it depends on. Ties are broken
+// by spec ID so the order is stable. It fails when the graph has a cycle.
+func (g *SpecGraph) HandoffOrder() ([]string, error) {
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(cycles[0], " → "))
+	}
+	adj := g.downstream()
+	inDegree := make(map[string]int)
+	for _, id := range g.sortedIDs() {
+		if !g.Nodes[id].Archived {
+			inDegree[id] = 0
+		}
+	}
+	for id := range inDegree {
+		for _, w := range adj[id] {
+			if _, active := inDegree[w]; active {
+				inDegree[w]++
+			}
+		}
+	}
+	var ready, order []string
+	for id, d := range inDegree {
+		if d == 0 {
+			ready = append(ready, id)
+		}
+	}
+	sort.Strings(ready)
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, w := range adj[id] {
+			if _, active := inDegree[w]; !active {
+				continue
+			}
+			inDegree[w]--
+			if inDegree[w] == 0 {
+				ready = append(ready, w)
+				sort.Strings(ready)
+			}
+		}
+	}
+	return order, nil
+}
+
+// ImpactedSpec is a downstream spec affected by a revision.
+type ImpactedSpec struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Depth int    `json:"depth"` // 1 = direct dependent
+	Via   string `json:"via"`   // upstream spec (and requirement) that links it in
+}
+
+// Impact walks downstream from specID and returns every affected spec,
+// nearest first. When changedReqs is non-nil, direct dependents pinned to
+// a single requirement ("PRD-001#REQ-002") are only affected if that
+// requirement is in changedReqs; transitive dependents always are.
+func (g *SpecGraph) Impact(specID string, changedReqs map[string]bool) []ImpactedSpec {
+	var out []ImpactedSpec
+	visited := map[string]bool{specID: true}
+	frontier := []string{specID}
+	for depth := 1; len(frontier) > 0; depth++ {
+		var next []string
+		for _, up := range frontier {
+			for _, l := range g.Links {
+				u, down, ok := l.before()
+				if !ok || u != up || visited[down] {
+					continue
+				}
+				pinned := l.Kind == LinkDependsOn && l.Requirement != ""
+				if depth == 1 && changedReqs != nil && pinned && !changedReqs[l.Requirement] {
+					continue
+				}
+				visited[down] = true
+				via := up
+				if pinned {
+					via += "#" + l.Requirement
+				}
+				out = append(out, ImpactedSpec{ID: down, Title: g.Nodes[down].Title, Depth: depth, Via: via})
+				next = append(next, down)
+			}
+		}
+		sort.Strings(next)
+		frontier = next
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Depth != out[j].Depth {
+			return out[i].Depth < out[j].Depth
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// ChangedRequirements returns the REQ-NNN IDs whose text differs between two
+// versions of a spec, including requirements added or removed.
+func ChangedRequirements(before, after *specs.Spec) map[string]bool {
+	index := func(s *specs.Spec) map[string]string {
+		out := make(map[string]string)
+		for _, req := range s.Requirements {
+			fields := strings.Fields(req)
+			if len(fields) == 0 {
+				continue
+			}
+			if id := strings.TrimSuffix(fields[0], ":"); strings.HasPrefix(id, "REQ-") {
+				out[id] = req
+			}
+		}
+		for _, req := range s.StructuredRequirements {
+			out[req.ID] = req.Given + "|" + req.When + "|" + req.Then
+		}
+		return out
+	}
+	a, b := index(before), index(after)
+	changed := make(map[string]bool)
+	for id, text := range a {
+		if b[id] != text {
+			changed[id] = true
+		}
+	}
+	for id := range b {
+		if _, ok := a[id]; !ok {
+			changed[id] = true
+		}
+	}
+	return changed
+}
+
+// DOT renders the graph in Graphviz DOT format.
+func (g *SpecGraph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph specs {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, id := range g.sortedIDs() {
+		n := g.Nodes[id]
+		style := ""
+		if n.Archived {
+			style = ", style=dashed"
+		}
+		sb.WriteString(fmt.Sprintf("  %q [label=%q%s];\n", id, id+"\n"+n.Title, style))
+	}
+	for _, l := range g.Links {
+		label := string(l.Kind)
+		if l.Requirement != "" {
+			label += " " + l.Requirement
+		}
+		style := ""
+		if l.Kind == LinkSupersedes {
+			style = ", style=dashed"
+		}
+		sb.WriteString(fmt.Sprintf("  %q -> %q [label=%q%s];\n", l.From, l.To, label, style))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+func (g *SpecGraph) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for _, id := range g.sortedIDs() {
+		n := g.Nodes[id]
+		title := strings.ReplaceAll(n.Title, `"`, "'")
+		if n.Archived {
+			title += " (archived)"
+		}
+		sb.WriteString(fmt.Sprintf("  %s[\"%s: %s\"]\n", mermaidID(id), id, title))
+	}
+	for _, l := range g.Links {
+		label := string(l.Kind)
+		if l.Requirement != "" {
+			label += " " + l.Requirement
+		}
+		arrow := "-->"
+		if l.Kind == LinkSupersedes {
+			arrow = "-.->"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s|%s| %s\n", mermaidID(l.From), arrow, label, mermaidID(l.To)))
+	}
+	return sb.String()
+}
+
+// mermaidID makes a spec ID safe for use as a Mermaid node identifier.
+func mermaidID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, id)
+}
@@ -0,0 +1,129 @@
+package dependency
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+)
+
+func testGraph() *SpecGraph {
+	return BuildSpecGraph([]specs.Spec{
+		{ID: "PRD-001", Title: "Auth"},
+		{ID: "PRD-002", Title: "Billing", DependsOn: []string{"PRD-001#REQ-002"}},
+		{ID: "PRD-003", Title: "Invoices", DependsOn: []string{"PRD-002"}},
+		{ID: "PRD-004", Title: "Audit", Blocks: []string{"PRD-003"}, DependsOn: []string{"PRD-009"}},
+		{ID: "PRD-005", Title: "Auth v2", Supersedes: []string{"PRD-001"}},
+	}, nil)
+}
+
+func TestParseSpecRef(t *testing.T) {
+	id, req := ParseSpecRef(" PRD-001#REQ-002 ")
+	if id != "PRD-001" || req != "REQ-002" {
+		t.Fatalf("got %q %q", id, req)
+	}
+	id, req = ParseSpecRef("PRD-003")
+	if id != "PRD-003" || req != "" {
+		t.Fatalf("got %q %q", id, req)
+	}
+}
+
+func TestBuildSpecGraphReportsDangling(t *testing.T) {
+	g := testGraph()
+	if len(g.Dangling) != 1 || g.Dangling[0].To != "PRD-009" {
+		t.Fatalf("expected PRD-009 dangling, got %+v", g.Dangling)
+	}
+	if len(g.Links) != 4 {
+		t.Fatalf("expected 4 links, got %+v", g.Links)
+	}
+}
+
+func TestHandoffOrder(t *testing.T) {
+	order, err := testGraph().HandoffOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PRD-001", "PRD-002", "PRD-004", "PRD-003", "PRD-005"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+}
+
+func TestHandoffOrderSkipsArchived(t *testing.T) {
+	g := BuildSpecGraph([]specs.Spec{
+		{ID: "PRD-001", Title: "Old"},
+		{ID: "PRD-002", Title: "New", DependsOn: []string{"PRD-001"}},
+	}, map[string]bool{"PRD-001": true})
+	order, err := g.HandoffOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(order, []string{"PRD-002"}) {
+		t.Fatalf("order = %v", order)
+	}
+}
+
+func TestCyclesDetected(t *testing.T) {
+	g := BuildSpecGraph([]specs.Spec{
+		{ID: "PRD-001", DependsOn: []string{"PRD-002"}},
+		{ID: "PRD-002", DependsOn: []string{"PRD-003"}},
+		{ID: "PRD-003", DependsOn: []string{"PRD-002"}},
+		{ID: "PRD-004", DependsOn: []string{"PRD-004"}},
+	}, nil)
+	cycles := g.Cycles()
+	if len(cycles) != 2 {
+		t.Fatalf("expected 2 cycles, got %v", cycles)
+	}
+	if _, err := g.HandoffOrder(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestSupersedesIsNotACycle(t *testing.T) {
+	g := BuildSpecGraph([]specs.Spec{
+		{ID: "PRD-001", DependsOn: []string{"PRD-002"}},
+		{ID: "PRD-002", Supersedes: []string{"PRD-001"}},
+	}, nil)
+	if cycles := g.Cycles(); len(cycles) != 0 {
+		t.Fatalf("unexpected cycles %v", cycles)
+	}
+}
+
+func TestImpactFollowsPinnedRequirements(t *testing.T) {
+	g := testGraph()
+
+	all := g.Impact("PRD-001", nil)
+	if len(all) != 2 || all[0].ID != "PRD-002" || all[0].Via != "PRD-001#REQ-002" || all[1].ID != "PRD-003" || all[1].Depth != 2 {
+		t.Fatalf("unexpected impact %+v", all)
+	}
+
+	if got := g.Impact("PRD-001", map[string]bool{"REQ-001": true}); len(got) != 0 {
+		t.Fatalf("unpinned requirement change should not propagate: %+v", got)
+	}
+	if got := g.Impact("PRD-001", map[string]bool{"REQ-002": true}); len(got) != 2 {
+		t.Fatalf("pinned requirement change should propagate: %+v", got)
+	}
+}
+
+func TestChangedRequirements(t *testing.T) {
+	before := &specs.Spec{Requirements: []string{"REQ-001: login", "REQ-002: logout"}}
+	after := &specs.Spec{Requirements: []string{"REQ-001: login", "REQ-002: logout everywhere", "REQ-003: sso"}}
+	got := ChangedRequirements(before, after)
+	want := map[string]bool{"REQ-002": true, "REQ-003": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("changed = %v, want %v", got, want)
+	}
+}
+
+func TestRenderDOTAndMermaid(t *testing.T) {
+	g := testGraph()
+	dot := g.DOT()
+	if !strings.Contains(dot, `"PRD-002" -> "PRD-001" [label="depends_on REQ-002"]`) {
+		t.Fatalf("missing pinned edge in DOT:\n%s", dot)
+	}
+	mermaid := g.Mermaid()
+	if !strings.HasPrefix(mermaid, "flowchart LR") || !strings.Contains(mermaid, "PRD_005 -.->|supersedes| PRD_001") {
+		t.Fatalf("unexpected mermaid:\n%s", mermaid)
+	}
+}
@@ -0,0 +1,185 @@
+package lint
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/arbiter"
+	"github.com/mistakeknot/autarch/internal/gurgeh/consistency"
+	"github.com/mistakeknot/autarch/internal/gurgeh/review"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"github.com/mistakeknot/autarch/internal/gurgeh/validation"
+)
+
+// reviewerRule exposes a review.PRDReviewer as rule "review/<name>".
+type reviewerRule struct {
+	reviewer review.PRDReviewer
+	// tune returns a reviewer configured from rule options; nil means fixed.
+	tune func(ctx *Context) review.PRDReviewer
+}
+
+// ReviewerRule wraps a PRD reviewer as a lint rule.
+func ReviewerRule(r review.PRDReviewer) Rule {
+	return &reviewerRule{reviewer: r}
+}
+
+func (r *reviewerRule) ID() string                { return "review/" + r.reviewer.Name() }
+func (r *reviewerRule) Description() string       { return "PRD reviewer: " + r.reviewer.Name() }
+func (r *reviewerRule) DefaultSeverity() Severity { return SeverityWarning }
+
+func (r *reviewerRule) Check(spec *specs.Spec, ctx *Context) []Finding {
+	reviewer := r.reviewer
+	if r.tune != nil {
+		reviewer = r.tune(ctx)
+	}
+	result, err := reviewer.Review(context.Background(), spec)
+	if err != nil {
+		return []Finding{{Severity: SeverityError, Message: "reviewer failed: " + err.Error()}}
+	}
+	out := make([]Finding, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		out = append(out, Finding{
+			Severity: reviewSeverity(issue.Severity),
+			Message:  issue.Description,
+			Location: issue.Location,
+		})
+	}
+	return out
+}
+
+func reviewSeverity(s review.IssueSeverity) Severity {
+	switch s {
+	case review.SeverityError:
+		return SeverityError
+	case review.SeverityWarning:
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+// scopeCreepRule exposes review.ScopeCreepDetector with its thresholds
+// tunable through the max_requirements and max_cujs options.
+func scopeCreepRule() Rule {
+	def := review.NewScopeCreepDetector()
+	return &reviewerRule{
+		reviewer: def,
+		tune: func(ctx *Context) review.PRDReviewer {
+			return &review.ScopeCreepDetector{
+				MaxRequirements: ctx.Int("max_requirements", def.MaxRequirements),
+				MaxCUJs:         ctx.Int("max_cujs", def.MaxCUJs),
+			}
+		},
+	}
+}
+
+// validatorRule exposes a validation.Validator as rule "validation/<perspective>".
+type validatorRule struct {
+	validator validation.Validator
+}
+
+// ValidatorRule wraps a stakeholder perspective validator as a lint rule.
+func ValidatorRule(v validation.Validator) Rule {
+	return &validatorRule{validator: v}
+}
+
+func (r *validatorRule) ID() string { return "validation/" + string(r.validator.Perspective()) }
+func (r *validatorRule) Description() string {
+	return "Stakeholder validation: " + string(r.validator.Perspective()) + " perspective"
+}
+func (r *validatorRule) DefaultSeverity() Severity { return SeverityWarning }
+
+func (r *validatorRule) Check(spec *specs.Spec, _ *Context) []Finding {
+	result := r.validator.Validate(spec)
+	out := make([]Finding, 0, len(result.Concerns))
+	for _, c := range result.Concerns {
+		msg := c.Title
+		if c.Description != "" {
+			msg += ": " + c.Description
+		}
+		out = append(out, Finding{
+			Severity: concernSeverity(c.Severity),
+			Message:  msg,
+			Location: c.Section,
+		})
+	}
+	return out
+}
+
+func concernSeverity(s validation.Severity) Severity {
+	switch s {
+	case validation.SeverityCritical:
+		return SeverityError
+	case validation.SeverityHigh, validation.SeverityMedium:
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+// checkerRule exposes a consistency.Checker as rule "consistency/<name>".
+// Checkers work on sprint state, so the spec is migrated first.
+type checkerRule struct {
+	checker consistency.Checker
+}
+
+// CheckerRule wraps a cross-section consistency checker as a lint rule.
+func CheckerRule(c consistency.Checker) Rule {
+	return &checkerRule{checker: c}
+}
+
+func (r *checkerRule) ID() string                { return "consistency/" + r.checker.Name() }
+func (r *checkerRule) Description() string       { return "Cross-section consistency: " + r.checker.Name() }
+func (r *checkerRule) DefaultSeverity() Severity { return SeverityWarning }
+
+func (r *checkerRule) Check(spec *specs.Spec, ctx *Context) []Finding {
+	if spec.IsVision() {
+		return nil
+	}
+	state := arbiter.MigrateFromSpec(spec, ctx.Root)
+	conflicts := r.checker.Check(state)
+	out := make([]Finding, 0, len(conflicts))
+	for _, c := range conflicts {
+		sev := SeverityWarning
+		if c.Severity == arbiter.SeverityBlocker {
+			sev = SeverityError
+		}
+		sections := make([]string, len(c.Sections))
+		for i, p := range c.Sections {
+			sections[i] = p.String()
+		}
+		out = append(out, Finding{
+			Severity: sev,
+			Message:  c.Message,
+			Location: strings.Join(sections, ", "),
+		})
+	}
+	return out
+}
+
+// DefaultRegistry returns every built-in rule: the schema checks from
+// specs.Validate, the stakeholder validators, the consistency checkers and
+// the PRD reviewers.
+func DefaultRegistry() *Registry {
+	reg := NewRegistry()
+	var rules []Rule
+	rules = append(rules, SchemaRules()...)
+	rules = append(rules,
+		ValidatorRule(&validation.ProductValidator{}),
+		ValidatorRule(&validation.DesignValidator{}),
+		ValidatorRule(&validation.EngineeringValidator{}),
+		CheckerRule(&consistency.UserFeatureChecker{}),
+		CheckerRule(&consistency.GoalFeatureChecker{}),
+		CheckerRule(&consistency.ScopeCreepChecker{}),
+		CheckerRule(&consistency.AssumptionChecker{}),
+		ReviewerRule(review.NewCompletenessReviewer()),
+		ReviewerRule(review.NewCUJConsistencyReviewer()),
+		ReviewerRule(review.NewAcceptanceCriteriaReviewer()),
+		scopeCreepRule(),
+	)
+	for _, rule := range rules {
+		// Built-in IDs are unique by construction.
+		_ = reg.Register(rule)
+	}
+	return reg
+}
@@ -0,0 +1,239 @@
+// Package lint provides a unified rule engine for spec quality checks.
+// Every check — schema validation, stakeholder validators, consistency
+// checkers and PRD reviewers — is registered as a Rule with a stable ID so
+// projects can enable, disable, re-grade or tune it in .gurgeh/config.toml.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/config"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+)
+
+// Severity grades a finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // Fails validation (CI gate)
+	SeverityWarning Severity = "warning" // Should fix
+	SeverityInfo    Severity = "info"    // Nice to fix
+)
+
+// ParseSeverity validates a configured severity string.
+func ParseSeverity(s string) (Severity, error) {
+	switch Severity(strings.ToLower(strings.TrimSpace(s))) {
+	case SeverityError:
+		return SeverityError, nil
+	case SeverityWarning:
+		return SeverityWarning, nil
+	case SeverityInfo:
+		return SeverityInfo, nil
+	}
+	return "", fmt.Errorf("invalid lint severity %q (use error, warning, or info)", s)
+}
+
+// Finding is a single rule violation.
+type Finding struct {
+	RuleID   string   `json:"rule_id"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Location string   `json:"location,omitempty"` // spec field path, e.g. "critical_user_journeys[0]"
+	Fixable  bool     `json:"fixable,omitempty"`
+}
+
+// Context carries per-run state into rule checks.
+type Context struct {
+	// Root is the project root, used to resolve evidence paths.
+	Root string
+	// Options holds the rule's tuning knobs from config.
+	Options map[string]any
+}
+
+// Int returns an integer option, or def when unset or not numeric.
+func (c *Context) Int(key string, def int) int {
+	switch v := c.Options[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return def
+}
+
+// Rule is a single lint check. Findings may carry their own severity; the
+// engine only overrides it when the project configures one for the rule.
+type Rule interface {
+	// ID returns the stable rule identifier, e.g. "cuj/priority".
+	ID() string
+	// Description explains what the rule checks.
+	Description() string
+	// DefaultSeverity is used for findings that don't set one.
+	DefaultSeverity() Severity
+	// Check returns the rule's findings for spec.
+	Check(spec *specs.Spec, ctx *Context) []Finding
+}
+
+// Fixer is implemented by rules that can repair their own findings.
+type Fixer interface {
+	// Fix mutates spec in place and reports whether anything changed.
+	Fix(spec *specs.Spec) bool
+}
+
+// Registry holds rules by ID.
+type Registry struct {
+	rules map[string]Rule
+	order []string
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{rules: make(map[string]Rule)}
+}
+
+// Register adds a rule. Registering a duplicate ID is an error so rule IDs
+// stay stable and unambiguous in config and SARIF output.
+func (r *Registry) Register(rule Rule) error {
+	id := rule.ID()
+	if id == "" {
+		return fmt.Errorf("lint rule has empty ID")
+	}
+	if _, ok := r.rules[id]; ok {
+		return fmt.Errorf("duplicate lint rule ID %q", id)
+	}
+	r.rules[id] = rule
+	r.order = append(r.order, id)
+	return nil
+}
+
+// Get returns the rule with the given ID.
+func (r *Registry) Get(id string) (Rule, bool) {
+	rule, ok := r.rules[id]
+	return rule, ok
+}
+
+// Rules returns all rules in registration order.
+func (r *Registry) Rules() []Rule {
+	out := make([]Rule, 0, len(r.order))
+	for _, id := range r.order {
+		out = append(out, r.rules[id])
+	}
+	return out
+}
+
+// Engine runs the rules of a registry under a project's lint config.
+type Engine struct {
+	registry *Registry
+	config   map[string]config.LintRuleConfig
+	root     string
+}
+
+// NewEngine creates an engine. It rejects config entries that name unknown
+// rules or invalid severities so typos don't silently disable a CI gate.
+func NewEngine(reg *Registry, cfg config.LintConfig, root string) (*Engine, error) {
+	for id, rc := range cfg.Rules {
+		if _, ok := reg.Get(id); !ok {
+			return nil, fmt.Errorf("unknown lint rule %q in config", id)
+		}
+		if rc.Severity != "" {
+			if _, err := ParseSeverity(rc.Severity); err != nil {
+				return nil, fmt.Errorf("lint rule %q: %w", id, err)
+			}
+		}
+	}
+	return &Engine{registry: reg, config: cfg.Rules, root: root}, nil
+}
+
+// Enabled reports whether a rule runs under the current config.
+func (e *Engine) Enabled(id string) bool {
+	rc, ok := e.config[id]
+	return !ok || rc.Enabled == nil || *rc.Enabled
+}
+
+// Report is the outcome of linting one spec.
+type Report struct {
+	SpecID   string    `json:"spec_id"`
+	Path     string    `json:"path,omitempty"`
+	Findings []Finding `json:"findings"`
+	Fixed    []string  `json:"fixed,omitempty"` // rule IDs whose autofix changed the spec
+}
+
+// Count returns the number of findings at the given severity.
+func (r *Report) Count(sev Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == sev {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed reports whether any finding is an error.
+func (r *Report) Failed() bool {
+	return r.Count(SeverityError) > 0
+}
+
+// Run lints spec with every enabled rule.
+func (e *Engine) Run(spec *specs.Spec) *Report {
+	report := &Report{SpecID: spec.ID, Findings: []Finding{}}
+	for _, rule := range e.registry.Rules() {
+		id := rule.ID()
+		if !e.Enabled(id) {
+			continue
+		}
+		rc := e.config[id]
+		ctx := &Context{Root: e.root, Options: rc.Options}
+		_, fixable := rule.(Fixer)
+		for _, f := range rule.Check(spec, ctx) {
+			f.RuleID = id
+			if f.Severity == "" {
+				f.Severity = rule.DefaultSeverity()
+			}
+			if rc.Severity != "" {
+				f.Severity, _ = ParseSeverity(rc.Severity)
+			}
+			f.Fixable = fixable
+			report.Findings = append(report.Findings, f)
+		}
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank(report.Findings[i].Severity) < severityRank(report.Findings[j].Severity)
+	})
+	return report
+}
+
+// Fix applies the autofix of every enabled rule that currently has findings
+// and returns the IDs of the rules that changed the spec.
+func (e *Engine) Fix(spec *specs.Spec) []string {
+	var fixed []string
+	for _, rule := range e.registry.Rules() {
+		fixer, ok := rule.(Fixer)
+		if !ok || !e.Enabled(rule.ID()) {
+			continue
+		}
+		ctx := &Context{Root: e.root, Options: e.config[rule.ID()].Options}
+		if len(rule.Check(spec, ctx)) == 0 {
+			continue
+		}
+		if fixer.Fix(spec) {
+			fixed = append(fixed, rule.ID())
+		}
+	}
+	return fixed
+}
+
+func severityRank(s Severity) int {
+	switch s {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
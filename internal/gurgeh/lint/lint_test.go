@@ -0,0 +1,191 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/config"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+)
+
+func findings(report *Report, ruleID string) []Finding {
+	var out []Finding
+	for _, f := range report.Findings {
+		if f.RuleID == ruleID {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestDefaultRegistryHasUniqueStableIDs(t *testing.T) {
+	reg := DefaultRegistry()
+	for _, id := range []string{
+		"spec/required-fields", "cuj/priority", "validation/product",
+		"consistency/scope-creep", "review/scope-creep", "review/completeness",
+	} {
+		if _, ok := reg.Get(id); !ok {
+			t.Errorf("missing rule %q", id)
+		}
+	}
+	if err := reg.Register(SchemaRules()[0]); err == nil {
+		t.Fatal("expected duplicate rule ID error")
+	}
+}
+
+func TestEngineAppliesConfig(t *testing.T) {
+	off := false
+	cfg := config.LintConfig{Rules: map[string]config.LintRuleConfig{
+		"spec/required-fields":   {Severity: "info"},
+		"validation/engineering": {Enabled: &off},
+	}}
+	engine, err := NewEngine(DefaultRegistry(), cfg, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := engine.Run(&specs.Spec{ID: "PRD-001"})
+
+	got := findings(report, "spec/required-fields")
+	if len(got) != 2 {
+		t.Fatalf("expected title and summary findings, got %+v", got)
+	}
+	for _, f := range got {
+		if f.Severity != SeverityInfo {
+			t.Errorf("expected configured info severity, got %s", f.Severity)
+		}
+	}
+	if len(findings(report, "validation/engineering")) != 0 {
+		t.Error("expected disabled rule to be skipped")
+	}
+}
+
+func TestNewEngineRejectsUnknownRules(t *testing.T) {
+	cfg := config.LintConfig{Rules: map[string]config.LintRuleConfig{"spec/typo": {}}}
+	if _, err := NewEngine(DefaultRegistry(), cfg, "."); err == nil {
+		t.Fatal("expected unknown rule error")
+	}
+	cfg = config.LintConfig{Rules: map[string]config.LintRuleConfig{"cuj/id": {Severity: "fatal"}}}
+	if _, err := NewEngine(DefaultRegistry(), cfg, "."); err == nil {
+		t.Fatal("expected invalid severity error")
+	}
+}
+
+func TestScopeCreepOptionsTuneThreshold(t *testing.T) {
+	spec := &specs.Spec{ID: "PRD-001", Requirements: []string{"a", "b", "c"}}
+	cfg := config.LintConfig{Rules: map[string]config.LintRuleConfig{
+		"review/scope-creep": {Options: map[string]any{"max_requirements": int64(2)}},
+	}}
+	engine, err := NewEngine(DefaultRegistry(), cfg, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sawTooMany bool
+	for _, f := range findings(engine.Run(spec), "review/scope-creep") {
+		sawTooMany = sawTooMany || strings.Contains(f.Message, "many requirements")
+	}
+	if !sawTooMany {
+		t.Fatal("expected scope creep finding with tuned threshold")
+	}
+}
+
+func TestEngineFixRepairsCUJs(t *testing.T) {
+	spec := &specs.Spec{
+		ID:     "PRD-001",
+		Status: "drafting",
+		CriticalUserJourneys: []specs.CriticalUserJourney{
+			{ID: "CUJ-001", Priority: "medium"},
+			{ID: "", Priority: "urgent"},
+			{ID: "CUJ-001", Priority: "high"},
+		},
+	}
+	engine, err := NewEngine(DefaultRegistry(), config.LintConfig{}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed := engine.Fix(spec)
+	if len(fixed) != 3 {
+		t.Fatalf("expected status, cuj/id and cuj/priority fixes, got %v", fixed)
+	}
+	if spec.Status != "draft" {
+		t.Errorf("status: got %q", spec.Status)
+	}
+	ids := map[string]bool{}
+	for _, cuj := range spec.CriticalUserJourneys {
+		ids[cuj.ID] = true
+	}
+	if len(ids) != 3 || ids[""] {
+		t.Errorf("expected unique CUJ ids, got %+v", spec.CriticalUserJourneys)
+	}
+	if spec.CriticalUserJourneys[0].Priority != "med" || spec.CriticalUserJourneys[1].Priority != "med" {
+		t.Errorf("priorities: got %+v", spec.CriticalUserJourneys)
+	}
+	report := engine.Run(spec)
+	for _, id := range []string{"spec/status", "cuj/id", "cuj/priority"} {
+		if len(findings(report, id)) != 0 {
+			t.Errorf("expected %s clean after fix", id)
+		}
+	}
+}
+
+func TestSchemaRulesMatchValidate(t *testing.T) {
+	engine, err := NewEngine(DefaultRegistry(), config.LintConfig{}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := &specs.Spec{ID: "PRD-001", Title: "T", Summary: "S", Status: "Draft", Type: "PRD"}
+	report := engine.Run(spec)
+	for _, id := range []string{"spec/status", "spec/type"} {
+		if len(findings(report, id)) != 0 {
+			t.Errorf("expected %s to accept mixed case, as specs.Validate does", id)
+		}
+	}
+	if fixed := engine.Fix(spec); len(fixed) != 0 || spec.Status != "Draft" {
+		t.Errorf("expected no fixes for valid status, got %v (%q)", fixed, spec.Status)
+	}
+}
+
+func TestVisionSpecsSkipPRDRules(t *testing.T) {
+	engine, err := NewEngine(DefaultRegistry(), config.LintConfig{}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := engine.Run(&specs.Spec{ID: "VIS-001", Type: specs.SpecTypeVision, Title: "V", Summary: "S"})
+	if len(findings(report, "research/market")) != 0 {
+		t.Fatal("expected vision spec to skip market research rule")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	reg := DefaultRegistry()
+	reports := []*Report{{
+		SpecID: "PRD-001",
+		Path:   "/proj/.gurgeh/specs/PRD-001.yaml",
+		Findings: []Finding{
+			{RuleID: "cuj/priority", Severity: SeverityError, Message: "invalid cuj priority: x", Location: "critical_user_journeys[0].priority"},
+			{RuleID: "review/completeness", Severity: SeverityInfo, Message: "m"},
+		},
+	}}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, reg, reports, "/proj"); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(reg.Rules()) {
+		t.Errorf("expected all rules in driver, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 2 || run.Results[0].Level != "error" || run.Results[1].Level != "note" {
+		t.Fatalf("unexpected results: %+v", run.Results)
+	}
+	if uri := run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != ".gurgeh/specs/PRD-001.yaml" {
+		t.Errorf("uri: got %q", uri)
+	}
+}
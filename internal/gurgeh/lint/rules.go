@@ -0,0 +1,297 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+)
+
+// specRule is a built-in rule defined by plain functions.
+type specRule struct {
+	id          string
+	description string
+	severity    Severity
+	prdOnly     bool // vision specs skip PRD-specific rules
+	check       func(spec *specs.Spec, ctx *Context) []Finding
+}
+
+func (r *specRule) ID() string                { return r.id }
+func (r *specRule) Description() string       { return r.description }
+func (r *specRule) DefaultSeverity() Severity { return r.severity }
+
+func (r *specRule) Check(spec *specs.Spec, ctx *Context) []Finding {
+	if r.prdOnly && spec.IsVision() {
+		return nil
+	}
+	return r.check(spec, ctx)
+}
+
+// fixableRule adds an autofix to a specRule.
+type fixableRule struct {
+	specRule
+	fix func(spec *specs.Spec) bool
+}
+
+func (r *fixableRule) Fix(spec *specs.Spec) bool { return r.fix(spec) }
+
+// SchemaRules returns the rules behind specs.Validate, one per check. They
+// accept what Validate accepts, so statuses and types match in any case.
+func SchemaRules() []Rule {
+	return []Rule{
+		&specRule{
+			id:          "spec/required-fields",
+			description: "Spec has an id, title and summary",
+			severity:    SeverityError,
+			check: func(spec *specs.Spec, _ *Context) []Finding {
+				var out []Finding
+				for _, f := range []struct{ name, value string }{
+					{"id", spec.ID}, {"title", spec.Title}, {"summary", spec.Summary},
+				} {
+					if strings.TrimSpace(f.value) == "" {
+						out = append(out, Finding{Message: "missing required field: " + f.name, Location: f.name})
+					}
+				}
+				return out
+			},
+		},
+		&fixableRule{
+			specRule: specRule{
+				id:          "spec/status",
+				description: "Status is one of interview, draft, research, suggestions, validated, archived",
+				severity:    SeverityWarning,
+				check: func(spec *specs.Spec, _ *Context) []Finding {
+					if spec.Status == "" || specs.ValidStatus(spec.Status) {
+						return nil
+					}
+					return []Finding{{Message: "invalid status: " + spec.Status, Location: "status"}}
+				},
+			},
+			fix: func(spec *specs.Spec) bool {
+				if spec.Status == "" || specs.ValidStatus(spec.Status) {
+					return false
+				}
+				spec.Status = "draft"
+				return true
+			},
+		},
+		&specRule{
+			id:          "spec/type",
+			description: "Type is prd or vision",
+			severity:    SeverityError,
+			check: func(spec *specs.Spec, _ *Context) []Finding {
+				if spec.Type == "" || specs.ValidSpecType(spec.Type) {
+					return nil
+				}
+				return []Finding{{Message: "invalid spec type: " + spec.Type, Location: "type"}}
+			},
+		},
+		&fixableRule{
+			specRule: specRule{
+				id:          "cuj/id",
+				description: "Every CUJ has a unique id",
+				severity:    SeverityError,
+				prdOnly:     true,
+				check: func(spec *specs.Spec, _ *Context) []Finding {
+					var out []Finding
+					seen := make(map[string]struct{})
+					for i, cuj := range spec.CriticalUserJourneys {
+						loc := fmt.Sprintf("critical_user_journeys[%d]", i)
+						if cuj.ID == "" {
+							out = append(out, Finding{Message: "cuj id is required", Location: loc})
+							continue
+						}
+						if _, ok := seen[cuj.ID]; ok {
+							out = append(out, Finding{Message: "duplicate cuj id: " + cuj.ID, Location: loc})
+						}
+						seen[cuj.ID] = struct{}{}
+					}
+					return out
+				},
+			},
+			fix: func(spec *specs.Spec) bool {
+				changed := false
+				taken := make(map[string]struct{})
+				for _, cuj := range spec.CriticalUserJourneys {
+					taken[cuj.ID] = struct{}{}
+				}
+				seen := make(map[string]struct{})
+				for i := range spec.CriticalUserJourneys {
+					cuj := &spec.CriticalUserJourneys[i]
+					if _, dup := seen[cuj.ID]; cuj.ID == "" || dup {
+						cuj.ID = nextFreeID("CUJ", taken)
+						taken[cuj.ID] = struct{}{}
+						changed = true
+					}
+					seen[cuj.ID] = struct{}{}
+				}
+				return changed
+			},
+		},
+		&fixableRule{
+			specRule: specRule{
+				id:          "cuj/priority",
+				description: "CUJ priority is critical, high, med or low",
+				severity:    SeverityError,
+				prdOnly:     true,
+				check: func(spec *specs.Spec, _ *Context) []Finding {
+					var out []Finding
+					for i, cuj := range spec.CriticalUserJourneys {
+						if !specs.ValidCUJPriority(cuj.Priority) {
+							out = append(out, Finding{
+								Message:  "invalid cuj priority: " + cuj.Priority,
+								Location: fmt.Sprintf("critical_user_journeys[%d].priority", i),
+							})
+						}
+					}
+					return out
+				},
+			},
+			fix: func(spec *specs.Spec) bool {
+				changed := false
+				for i := range spec.CriticalUserJourneys {
+					cuj := &spec.CriticalUserJourneys[i]
+					if specs.ValidCUJPriority(cuj.Priority) {
+						continue
+					}
+					fixed := normalize(cuj.Priority)
+					if fixed == "medium" || !specs.ValidCUJPriority(fixed) {
+						fixed = "med"
+					}
+					cuj.Priority = fixed
+					changed = true
+				}
+				return changed
+			},
+		},
+		&specRule{
+			id:          "cuj/linked-requirements",
+			description: "Every CUJ links to existing REQ-NNN requirements",
+			severity:    SeverityWarning,
+			prdOnly:     true,
+			check: func(spec *specs.Spec, _ *Context) []Finding {
+				var out []Finding
+				reqIDs := specs.RequirementIDs(spec.Requirements)
+				for i, cuj := range spec.CriticalUserJourneys {
+					loc := fmt.Sprintf("critical_user_journeys[%d].linked_requirements", i)
+					if len(cuj.LinkedRequirements) == 0 {
+						out = append(out, Finding{Message: "cuj missing linked requirements: " + cuj.ID, Location: loc})
+						continue
+					}
+					for _, link := range cuj.LinkedRequirements {
+						if _, ok := reqIDs[link]; !ok {
+							out = append(out, Finding{Message: "cuj linked requirement not found: " + link, Location: loc})
+						}
+					}
+				}
+				return out
+			},
+		},
+		&specRule{
+			id:          "research/market",
+			description: "Market research is present with unique ids",
+			severity:    SeverityWarning,
+			prdOnly:     true,
+			check: func(spec *specs.Spec, _ *Context) []Finding {
+				if len(spec.MarketResearch) == 0 {
+					return []Finding{{Message: "market research missing", Location: "market_research"}}
+				}
+				ids := make([]string, len(spec.MarketResearch))
+				for i, item := range spec.MarketResearch {
+					ids[i] = item.ID
+				}
+				return idFindings("market research", "market_research", ids)
+			},
+		},
+		&specRule{
+			id:          "research/competitive",
+			description: "Competitive landscape is present with unique ids",
+			severity:    SeverityWarning,
+			prdOnly:     true,
+			check: func(spec *specs.Spec, _ *Context) []Finding {
+				if len(spec.CompetitiveLandscape) == 0 {
+					return []Finding{{Message: "competitive landscape missing", Location: "competitive_landscape"}}
+				}
+				ids := make([]string, len(spec.CompetitiveLandscape))
+				for i, item := range spec.CompetitiveLandscape {
+					ids[i] = item.ID
+				}
+				return idFindings("competitive landscape", "competitive_landscape", ids)
+			},
+		},
+		&specRule{
+			id:          "research/evidence",
+			description: "Research claims cite existing files under .praude/research",
+			severity:    SeverityWarning,
+			prdOnly:     true,
+			check: func(spec *specs.Spec, ctx *Context) []Finding {
+				var out []Finding
+				for i, item := range spec.MarketResearch {
+					out = append(out, evidenceFindings(ctx, fmt.Sprintf("market_research[%d]", i), item.EvidenceRefs)...)
+				}
+				for i, item := range spec.CompetitiveLandscape {
+					out = append(out, evidenceFindings(ctx, fmt.Sprintf("competitive_landscape[%d]", i), item.EvidenceRefs)...)
+				}
+				return out
+			},
+		},
+	}
+}
+
+// idFindings reports missing and duplicate ids in a research section.
+// These are errors regardless of the rule's default severity.
+func idFindings(label, section string, ids []string) []Finding {
+	var out []Finding
+	seen := make(map[string]struct{})
+	for i, id := range ids {
+		loc := fmt.Sprintf("%s[%d]", section, i)
+		if id == "" {
+			out = append(out, Finding{Severity: SeverityError, Message: label + " id is required", Location: loc})
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			out = append(out, Finding{Severity: SeverityError, Message: "duplicate " + label + " id: " + id, Location: loc})
+		}
+		seen[id] = struct{}{}
+	}
+	return out
+}
+
+func evidenceFindings(ctx *Context, loc string, refs []specs.EvidenceRef) []Finding {
+	if len(refs) == 0 {
+		return []Finding{{Message: "missing evidence refs", Location: loc}}
+	}
+	var out []Finding
+	for _, ref := range refs {
+		switch {
+		case ref.Path == "":
+			out = append(out, Finding{Message: "evidence ref missing path", Location: loc})
+		case !specs.IsResearchPath(ref.Path):
+			out = append(out, Finding{Message: "evidence ref outside research dir: " + ref.Path, Location: loc})
+		default:
+			root := ctx.Root
+			if root == "" {
+				root = "."
+			}
+			if _, err := os.Stat(filepath.Join(root, filepath.Clean(ref.Path))); err != nil {
+				out = append(out, Finding{Message: "evidence ref missing file: " + ref.Path, Location: loc})
+			}
+		}
+	}
+	return out
+}
+
+func nextFreeID(prefix string, taken map[string]struct{}) string {
+	for n := 1; ; n++ {
+		id := fmt.Sprintf("%s-%03d", prefix, n)
+		if _, ok := taken[id]; !ok {
+			return id
+		}
+	}
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
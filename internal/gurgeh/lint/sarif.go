@@ -0,0 +1,125 @@
+package lint
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifText          `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysical `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogical `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysical struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogical struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// WriteSARIF writes reports as a SARIF 2.1.0 log so CI systems can annotate
+// the spec files. Report paths are emitted relative to root.
+func WriteSARIF(w io.Writer, reg *Registry, reports []*Report, root string) error {
+	driver := sarifDriver{Name: "gurgeh"}
+	for _, rule := range reg.Rules() {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID(),
+			ShortDescription:     sarifText{Text: rule.Description()},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.DefaultSeverity())},
+		})
+	}
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, report := range reports {
+		uri := report.Path
+		if rel, err := filepath.Rel(root, report.Path); err == nil && report.Path != "" {
+			uri = filepath.ToSlash(rel)
+		}
+		for _, f := range report.Findings {
+			loc := sarifLocation{}
+			if uri != "" {
+				loc.PhysicalLocation = &sarifPhysical{ArtifactLocation: sarifArtifact{URI: uri}}
+			}
+			name := report.SpecID
+			if f.Location != "" {
+				name += "." + f.Location
+			}
+			loc.LogicalLocations = []sarifLogical{{FullyQualifiedName: name}}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    f.RuleID,
+				Level:     sarifLevel(f.Severity),
+				Message:   sarifText{Text: f.Message},
+				Locations: []sarifLocation{loc},
+			})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}})
+}
+
+// WriteJSON writes reports as an indented JSON array.
+func WriteJSON(w io.Writer, reports []*Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mistakeknot/autarch/internal/gurgeh/alignment"
 	"github.com/mistakeknot/autarch/internal/gurgeh/project"
 	gsignals "github.com/mistakeknot/autarch/internal/gurgeh/signals"
 	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
@@ -130,6 +131,9 @@ func (s *Server) handleSpec(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) refreshSignals(ctx context.Context, spec specs.Spec) {
+	// vision_drift is persisted as the alignment series is refreshed
+	drift, _ := gsignals.CheckSpecDrift(s.root, &spec, alignment.DefaultDriftThreshold)
+
 	store, err := gsignals.NewStore(s.root)
 	if err != nil {
 		return
@@ -138,10 +142,11 @@ func (s *Server) refreshSignals(ctx context.Context, spec specs.Spec) {
 
 	emitter := gsignals.NewEmitter()
 	sigs := emitter.CheckSpec(&spec)
+	_ = store.EmitAll(sigs)
+	sigs = append(sigs, drift...)
 	if len(sigs) == 0 {
 		return
 	}
-	_ = store.EmitAll(sigs)
 
 	client := psignals.NewClient(psignals.DefaultServerURL())
 	publishCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
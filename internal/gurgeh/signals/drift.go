@@ -0,0 +1,53 @@
+package signals
+
+import (
+	"fmt"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/alignment"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"github.com/mistakeknot/autarch/pkg/signals"
+)
+
+// RefreshVisionDrift refreshes the alignment series of every PRD with a
+// vision_ref and persists vision_drift signals for those that just crossed
+// threshold. Load and store problems are returned as warnings.
+func RefreshVisionDrift(root string, threshold float64) ([]*alignment.Series, []signals.Signal, []string) {
+	all, warnings := alignment.RefreshAll(root)
+	return emitVisionDrift(root, all, threshold, warnings)
+}
+
+// CheckSpecDrift is RefreshVisionDrift for the PRDs a loaded spec affects:
+// the spec itself, or the PRDs referencing it when it is a vision. It is
+// run on spec load like the other Gurgeh signals; revisions already scored
+// are not replayed.
+func CheckSpecDrift(root string, spec *specs.Spec, threshold float64) ([]signals.Signal, []string) {
+	if !spec.IsVision() && spec.VisionRef == "" {
+		return nil, nil
+	}
+	series, warnings := alignment.RefreshFor(root, spec)
+	_, emitted, warnings := emitVisionDrift(root, series, threshold, warnings)
+	return emitted, warnings
+}
+
+func emitVisionDrift(root string, all []*alignment.Series, threshold float64, warnings []string) ([]*alignment.Series, []signals.Signal, []string) {
+	emitter := NewEmitter()
+	var emitted []signals.Signal
+	for _, series := range all {
+		// A series the refresh left alone has nothing new to report.
+		if series.Added > 0 {
+			emitted = append(emitted, emitter.CheckVisionDrift(series, threshold)...)
+		}
+	}
+	if len(emitted) == 0 {
+		return all, nil, warnings
+	}
+	store, err := NewStore(root)
+	if err != nil {
+		return all, emitted, append(warnings, fmt.Sprintf("opening signal store: %v", err))
+	}
+	defer store.Close()
+	if err := store.EmitAll(emitted); err != nil {
+		warnings = append(warnings, fmt.Sprintf("emitting vision_drift: %v", err))
+	}
+	return all, emitted, warnings
+}
@@ -0,0 +1,76 @@
+package signals
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/alignment"
+	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCheckSpecDriftOnLoad(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, ".gurgeh", "specs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	vision := &specs.Spec{
+		ID:          "VIS-001",
+		Type:        specs.SpecTypeVision,
+		Goals:       []specs.Goal{{ID: "GOAL-001", Description: "Faster research cycles"}},
+		Assumptions: []specs.Assumption{{ID: "ASSM-001", Description: "Teams adopt terminal tooling"}},
+	}
+	prd := &specs.Spec{
+		ID:           "PRD-001",
+		Title:        "Research",
+		Summary:      "Research cycles are slow",
+		Requirements: []string{"REQ-001: Terminal tooling for research"},
+		VisionRef:    "VIS-001",
+	}
+	write := func(spec *specs.Spec) {
+		t.Helper()
+		data, err := yaml.Marshal(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, spec.ID+".yaml"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, spec := range []*specs.Spec{vision, prd} {
+		if _, err := specs.SaveRevision(root, spec, "user", "manual", nil); err != nil {
+			t.Fatal(err)
+		}
+		write(spec)
+	}
+	if sigs, warnings := CheckSpecDrift(root, prd, alignment.DefaultDriftThreshold); len(sigs) != 0 || len(warnings) != 0 {
+		t.Fatalf("expected an aligned PRD not to drift, got %+v %v", sigs, warnings)
+	}
+
+	// A revision that contradicts the vision drifts once, whichever spec
+	// is loaded next
+	prd.Title = "Billing"
+	prd.Summary = "Billing is confusing"
+	prd.Requirements = []string{"REQ-001: We will not ship terminal tooling"}
+	if _, err := specs.SaveRevision(root, prd, "user", "manual", nil); err != nil {
+		t.Fatal(err)
+	}
+	write(prd)
+	sigs, warnings := CheckSpecDrift(root, vision, alignment.DefaultDriftThreshold)
+	if len(sigs) != 1 || sigs[0].SpecID != "PRD-001" || len(warnings) != 0 {
+		t.Fatalf("expected one vision_drift for PRD-001, got %+v %v", sigs, warnings)
+	}
+	if sigs, _ := CheckSpecDrift(root, prd, alignment.DefaultDriftThreshold); len(sigs) != 0 {
+		t.Fatalf("expected the drift to be emitted once, got %+v", sigs)
+	}
+	store, err := NewStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if active, err := store.Active("PRD-001"); err != nil || len(active) != 1 {
+		t.Fatalf("expected the signal persisted, got %+v (%v)", active, err)
+	}
+}
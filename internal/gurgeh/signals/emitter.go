@@ -1,14 +1,16 @@
 // Package signals provides Gurgeh-specific signal emission.
-// Gurgeh emits assumption_decayed, hypothesis_stale, and spec_health_low signals
-// checked on spec load — no background process.
+// Gurgeh emits assumption_decayed, hypothesis_stale, spec_health_low, and
+// vision_drift signals checked on spec load — no background process.
 package signals
 
 import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/mistakeknot/autarch/internal/gurgeh/alignment"
 	"github.com/mistakeknot/autarch/internal/gurgeh/specs"
 	"github.com/mistakeknot/autarch/pkg/signals"
 )
@@ -114,6 +116,46 @@ func (e *Emitter) checkSpecHealth(spec *specs.Spec) []signals.Signal {
 	return nil
 }
 
+// CheckVisionDrift emits vision_drift when a PRD's alignment score has just
+// fallen below threshold, in any of the samples the last refresh added.
+// Scores under half the threshold are critical.
+func (e *Emitter) CheckVisionDrift(series *alignment.Series, threshold float64) []signals.Signal {
+	if series == nil {
+		return nil
+	}
+	i := series.Crossing(threshold)
+	if i < 0 {
+		return nil
+	}
+	latest := series.Latest()
+	prev, crossed := series.Samples[i-1], series.Samples[i]
+	severity := signals.SeverityWarning
+	if latest.Score < threshold/2 {
+		severity = signals.SeverityCritical
+	}
+	detail := fmt.Sprintf("Alignment with %s dropped from %.2f to %.2f (%s)", crossed.VisionID, prev.Score, crossed.Score, crossed.Trigger)
+	if latest != &series.Samples[i] {
+		detail += fmt.Sprintf(", now %.2f", latest.Score)
+	}
+	if len(latest.Unreferenced) > 0 {
+		detail += fmt.Sprintf("; unreferenced principles: %s", strings.Join(latest.Unreferenced, ", "))
+	}
+	if len(latest.Contradicted) > 0 {
+		detail += fmt.Sprintf("; contradicted bets: %s", strings.Join(latest.Contradicted, ", "))
+	}
+	return []signals.Signal{{
+		ID:            generateID(),
+		Type:          signals.SignalVisionDrift,
+		Source:        "gurgeh",
+		SpecID:        series.PRDID,
+		AffectedField: "vision_alignment",
+		Severity:      severity,
+		Title:         fmt.Sprintf("PRD drifted from vision %s", latest.VisionID),
+		Detail:        detail,
+		CreatedAt:     time.Now(),
+	}}
+}
+
 func generateID() string {
 	b := make([]byte, 8)
 	_, _ = rand.Read(b)
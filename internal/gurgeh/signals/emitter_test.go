@@ -0,0 +1,45 @@
+package signals
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/autarch/internal/gurgeh/alignment"
+	"github.com/mistakeknot/autarch/pkg/signals"
+)
+
+func TestCheckVisionDrift(t *testing.T) {
+	e := NewEmitter()
+	series := &alignment.Series{
+		PRDID: "PRD-001",
+		Samples: []alignment.Sample{
+			{VisionID: "VIS-001", Score: 0.9, Trigger: "prd:v1"},
+			{VisionID: "VIS-001", Score: 0.2, Trigger: "vision:v2", Contradicted: []string{"ASSM-001"}},
+		},
+	}
+
+	sigs := e.CheckVisionDrift(series, 0.6)
+	if len(sigs) != 1 {
+		t.Fatalf("expected 1 signal, got %d", len(sigs))
+	}
+	sig := sigs[0]
+	if sig.Type != signals.SignalVisionDrift || sig.SpecID != "PRD-001" || sig.AffectedField != "vision_alignment" {
+		t.Errorf("unexpected signal: %+v", sig)
+	}
+	if sig.Severity != signals.SeverityCritical {
+		t.Errorf("expected critical for score under half the threshold, got %s", sig.Severity)
+	}
+
+	series.Samples = append(series.Samples, alignment.Sample{VisionID: "VIS-001", Score: 0.1})
+	if sigs := e.CheckVisionDrift(series, 0.6); len(sigs) != 0 {
+		t.Errorf("expected no signal while already below threshold, got %d", len(sigs))
+	}
+
+	// One refresh adding both the drop and a later sample still below.
+	series.Added = 2
+	series.Samples = series.Samples[:2]
+	series.Samples = append(series.Samples, alignment.Sample{VisionID: "VIS-001", Score: 0.5})
+	if sigs := e.CheckVisionDrift(series, 0.6); len(sigs) != 1 || !strings.Contains(sigs[0].Detail, "now 0.50") {
+		t.Errorf("expected a signal for a crossing inside the batch, got %+v", sigs)
+	}
+}
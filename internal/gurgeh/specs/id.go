@@ -10,10 +10,23 @@ import (
 
 var idPattern = regexp.MustCompile(`^PRD-(\d+)\.ya?ml$`)
 
+// NextID returns the spec ID after the highest one in dir.
 func NextID(dir string) (string, error) {
+	return NewIDs(dir).Next(), nil
+}
+
+// IDs hands out consecutive spec IDs, starting after the highest one in
+// a directory, for batches that must not wait for each spec to be written.
+type IDs struct {
+	next int
+}
+
+// NewIDs starts allocating after the highest spec ID in dir. A missing
+// directory starts at PRD-001.
+func NewIDs(dir string) *IDs {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return "PRD-001", nil
+		return &IDs{next: 1}
 	}
 	var nums []int
 	for _, e := range entries {
@@ -29,5 +42,12 @@ func NextID(dir string) (string, error) {
 	if len(nums) > 0 {
 		next = nums[len(nums)-1] + 1
 	}
-	return fmt.Sprintf("PRD-%03d", next), nil
+	return &IDs{next: next}
+}
+
+// Next returns the next ID.
+func (a *IDs) Next() string {
+	id := fmt.Sprintf("PRD-%03d", a.next)
+	a.next++
+	return id
 }
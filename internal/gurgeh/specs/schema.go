@@ -130,6 +130,9 @@ type Spec struct {
 	CreatedAt            string                     `yaml:"created_at"`
 	Status               string                     `yaml:"status"`
 	VisionRef            string                     `yaml:"vision_ref,omitempty"`          // ID of vision spec this PRD aligns to
+	DependsOn            []string                   `yaml:"depends_on,omitempty"`          // specs this one builds on ("PRD-001" or "PRD-001#REQ-002")
+	Blocks               []string                   `yaml:"blocks,omitempty"`              // specs that cannot start until this one lands
+	Supersedes           []string                   `yaml:"supersedes,omitempty"`          // specs this one replaces
 	LastReviewedAt       string                     `yaml:"last_reviewed_at,omitempty"`    // RFC3339
 	ReviewCadenceDays    int                        `yaml:"review_cadence_days,omitempty"` // default 30
 	StrategicContext     StrategicContext           `yaml:"strategic_context"`
@@ -40,10 +40,10 @@ func Validate(raw []byte, opts ValidationOptions) (ValidationResult, error) {
 	if doc.ID == "" || doc.Title == "" || doc.Summary == "" {
 		res.Errors = append(res.Errors, "missing required fields")
 	}
-	if doc.Status != "" && !validStatus(doc.Status) {
+	if doc.Status != "" && !ValidStatus(doc.Status) {
 		res.Warnings = append(res.Warnings, "invalid status: "+doc.Status)
 	}
-	if doc.Type != "" && !validSpecType(doc.Type) {
+	if doc.Type != "" && !ValidSpecType(doc.Type) {
 		res.Errors = append(res.Errors, "invalid spec type: "+doc.Type)
 	}
 
@@ -52,7 +52,7 @@ func Validate(raw []byte, opts ValidationOptions) (ValidationResult, error) {
 		return res, nil
 	}
 
-	reqIDs := requirementIDs(doc.Requirements)
+	reqIDs := RequirementIDs(doc.Requirements)
 	validateCUJs(&res, doc.CriticalUserJourneys, reqIDs, opts.Mode)
 	validateMarketResearch(&res, doc.MarketResearch, opts)
 	validateCompetitiveLandscape(&res, doc.CompetitiveLandscape, opts)
@@ -70,7 +70,7 @@ func validateCUJs(res *ValidationResult, cujs []CriticalUserJourney, reqIDs map[
 			}
 			seen[cuj.ID] = struct{}{}
 		}
-		if !validCUJPriority(cuj.Priority) {
+		if !ValidCUJPriority(cuj.Priority) {
 			res.Errors = append(res.Errors, "invalid cuj priority: "+cuj.Priority)
 		}
 		if len(cuj.LinkedRequirements) == 0 {
@@ -133,7 +133,7 @@ func validateEvidenceRefs(res *ValidationResult, refs []EvidenceRef, opts Valida
 			addModeIssue(res, opts.Mode, section+" evidence ref missing path")
 			continue
 		}
-		if !isResearchPath(ref.Path) {
+		if !IsResearchPath(ref.Path) {
 			addModeIssue(res, opts.Mode, section+" evidence ref outside research dir: "+ref.Path)
 			continue
 		}
@@ -152,7 +152,8 @@ func addModeIssue(res *ValidationResult, mode ValidationMode, msg string) {
 	res.Warnings = append(res.Warnings, msg)
 }
 
-func validCUJPriority(priority string) bool {
+// ValidCUJPriority reports whether priority is an accepted CUJ priority.
+func ValidCUJPriority(priority string) bool {
 	switch strings.ToLower(priority) {
 	case "critical", "high", "med", "low":
 		return true
@@ -161,7 +162,8 @@ func validCUJPriority(priority string) bool {
 	}
 }
 
-func validSpecType(t string) bool {
+// ValidSpecType reports whether t is a known spec type.
+func ValidSpecType(t string) bool {
 	switch strings.ToLower(t) {
 	case SpecTypePRD, SpecTypeVision:
 		return true
@@ -170,7 +172,8 @@ func validSpecType(t string) bool {
 	}
 }
 
-func validStatus(status string) bool {
+// ValidStatus reports whether status is a known spec status.
+func ValidStatus(status string) bool {
 	switch strings.ToLower(strings.TrimSpace(status)) {
 	case "interview", "draft", "research", "suggestions", "validated", "archived":
 		return true
@@ -179,7 +182,8 @@ func validStatus(status string) bool {
 	}
 }
 
-func requirementIDs(requirements []string) map[string]struct{} {
+// RequirementIDs collects the "REQ-NNN" prefixes of free-text requirements.
+func RequirementIDs(requirements []string) map[string]struct{} {
 	ids := make(map[string]struct{})
 	for _, req := range requirements {
 		fields := strings.Fields(req)
@@ -194,7 +198,8 @@ func requirementIDs(requirements []string) map[string]struct{} {
 	return ids
 }
 
-func isResearchPath(path string) bool {
+// IsResearchPath reports whether path is a relative path inside .praude/research.
+func IsResearchPath(path string) bool {
 	if filepath.IsAbs(path) {
 		return false
 	}
@@ -12,6 +12,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/mistakeknot/autarch/internal/gurgeh/agents"
+	"github.com/mistakeknot/autarch/internal/gurgeh/alignment"
 	"github.com/mistakeknot/autarch/internal/gurgeh/arbiter"
 	"github.com/mistakeknot/autarch/internal/gurgeh/archive"
 	"github.com/mistakeknot/autarch/internal/gurgeh/config"
@@ -52,6 +53,7 @@ type Model struct {
 	helpOverlay       pkgtui.HelpOverlay
 	sprint            *SprintView
 	suggestions       suggestionsState
+	alignment         map[string]*alignment.Series
 }
 
 func NewModel() Model {
@@ -407,6 +409,9 @@ func (m Model) renderDetail() []string {
 		if trimmed != "" {
 			lines = append(lines, strings.Split(trimmed, "\n")...)
 		}
+		if line := formatAlignment(m.alignment[spec.ID]); line != "" {
+			lines = append(lines, line)
+		}
 	}
 	if strings.TrimSpace(m.status) != "" {
 		lines = append(lines, "Last action: "+m.status)
@@ -424,6 +429,13 @@ func (m *Model) reloadSummaries() {
 		selectedID = sel.ID
 	}
 	m.summaries = list
+	// Read-only and incremental: spec loads and gurgeh alignment store
+	// samples and emit vision_drift
+	series, _ := alignment.PreviewAll(m.root)
+	m.alignment = make(map[string]*alignment.Series, len(series))
+	for _, s := range series {
+		m.alignment[s.PRDID] = s
+	}
 	if m.searchOverlay != nil {
 		m.searchOverlay.SetItems(list)
 	}
@@ -906,6 +918,18 @@ func formatResearchDetail(spec specs.Spec) string {
 	return "Market: " + market + " | Competitive: " + comp
 }
 
+func formatAlignment(series *alignment.Series) string {
+	if series == nil || series.Latest() == nil {
+		return ""
+	}
+	latest := series.Latest()
+	line := fmt.Sprintf("Vision alignment (%s): %.2f %s", latest.VisionID, latest.Score, alignment.Sparkline(series.Samples, 24))
+	if latest.Score < alignment.DefaultDriftThreshold {
+		line += " DRIFT"
+	}
+	return line
+}
+
 func formatWarnings(spec specs.Spec) []string {
 	if len(spec.Metadata.ValidationWarnings) == 0 {
 		return nil
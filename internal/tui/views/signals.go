@@ -512,6 +512,7 @@ var signalTypeFilters = []string{
 	string(signals.SignalSpecHealthLow),
 	string(signals.SignalExecutionDrift),
 	string(signals.SignalVisionDrift),
+	string(signals.SignalAgentState),
 }
 
 var eventTypeFilters = []string{
@@ -0,0 +1,110 @@
+// Package agenthook is the versioned protocol agents use to report what
+// they are doing. Agent hooks (Claude Code, Codex CLI, Gemini CLI) run the
+// autarch-hook emitter, which translates the agent's hook payload into an
+// Event and writes it as one JSON line to Bigend's local socket.
+//
+// Version 1 events:
+//
+//	{"v":1,"kind":"tool_call","agent":"claude","session":"claude-api",
+//	 "agent_session":"3f2a...","project_dir":"/home/me/api","tool":"Bash",
+//	 "at":"2026-06-01T12:00:00Z"}
+//
+// Consumers must ignore unknown fields and reject versions newer than
+// they understand.
+package agenthook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Version is the protocol version this package speaks.
+const Version = 1
+
+// Kind is what happened in the agent.
+type Kind string
+
+const (
+	KindSessionStart Kind = "session_start"     // the agent started
+	KindPrompt       Kind = "prompt"            // the user submitted a prompt
+	KindToolCall     Kind = "tool_call"         // the agent is running a tool
+	KindPermission   Kind = "permission_prompt" // the agent waits for approval
+	KindIdle         Kind = "idle"              // the turn ended; waiting for input
+	KindError        Kind = "error"             // a tool or the agent failed
+	KindStop         Kind = "stop"              // the agent exited
+)
+
+// Kinds lists every kind of this version.
+var Kinds = []Kind{KindSessionStart, KindPrompt, KindToolCall, KindPermission, KindIdle, KindError, KindStop}
+
+// Event is one hook event.
+type Event struct {
+	V            int       `json:"v"`
+	Kind         Kind      `json:"kind"`
+	Agent        string    `json:"agent"`                   // claude, codex, gemini
+	Session      string    `json:"session,omitempty"`       // tmux session the agent runs in
+	AgentSession string    `json:"agent_session,omitempty"` // the agent's own session id
+	ProjectDir   string    `json:"project_dir,omitempty"`
+	Tool         string    `json:"tool,omitempty"`    // tool_call, permission_prompt
+	Message      string    `json:"message,omitempty"` // error text or notification
+	At           time.Time `json:"at"`
+}
+
+// ErrVersion is returned for events of a newer protocol version.
+var ErrVersion = errors.New("unsupported hook protocol version")
+
+// Validate checks an event can be consumed.
+func (e Event) Validate() error {
+	if e.V < 1 || e.V > Version {
+		return fmt.Errorf("%w %d", ErrVersion, e.V)
+	}
+	known := false
+	for _, k := range Kinds {
+		known = known || e.Kind == k
+	}
+	if !known {
+		return fmt.Errorf("unknown hook event kind %q", e.Kind)
+	}
+	if e.Agent == "" {
+		return errors.New("hook event has no agent")
+	}
+	if e.At.IsZero() {
+		return errors.New("hook event has no time")
+	}
+	return nil
+}
+
+// DefaultSocket is where Bigend listens for hook events. AUTARCH_HOOK_SOCKET
+// overrides it.
+func DefaultSocket() string {
+	if s := os.Getenv("AUTARCH_HOOK_SOCKET"); s != "" {
+		return s
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".autarch", "bigend.sock")
+}
+
+// Send writes an event to the socket.
+func Send(ctx context.Context, socket string, e Event) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetWriteDeadline(deadline)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(append(data, '\n'))
+	return err
+}
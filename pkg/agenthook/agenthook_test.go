@@ -0,0 +1,136 @@
+package agenthook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTranslate(t *testing.T) {
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		agent   string
+		payload string
+		want    Kind
+	}{
+		{AgentClaude, `{"hook_event_name":"SessionStart","session_id":"s1","cwd":"/src/api"}`, KindSessionStart},
+		{AgentClaude, `{"hook_event_name":"UserPromptSubmit"}`, KindPrompt},
+		{AgentClaude, `{"hook_event_name":"PreToolUse","tool_name":"Bash"}`, KindToolCall},
+		{AgentClaude, `{"hook_event_name":"PermissionRequest","tool_name":"Bash"}`, KindPermission},
+		{AgentClaude, `{"hook_event_name":"Notification","message":"Claude needs your permission to use Bash"}`, KindPermission},
+		{AgentClaude, `{"hook_event_name":"Notification","notification_type":"idle_prompt"}`, KindIdle},
+		{AgentClaude, `{"hook_event_name":"PostToolUseFailure"}`, KindError},
+		{AgentClaude, `{"hook_event_name":"Stop"}`, KindIdle},
+		{AgentClaude, `{"hook_event_name":"SessionEnd"}`, KindStop},
+		{AgentGemini, `{"hook_event_name":"BeforeTool","tool_name":"run_shell_command"}`, KindToolCall},
+		{AgentGemini, `{"hook_event_name":"Notification","notification_type":"ToolPermission"}`, KindPermission},
+		{AgentGemini, `{"hook_event_name":"AfterAgent"}`, KindIdle},
+		{AgentCodex, `{"type":"agent-turn-complete","thread-id":"t1","cwd":"/src/api"}`, KindIdle},
+		{AgentCodex, `approval-requested`, KindPermission},
+	}
+	for _, tt := range tests {
+		e, err := Translate(tt.agent, []byte(tt.payload), at)
+		if err != nil {
+			t.Errorf("Translate(%s, %s): %v", tt.agent, tt.payload, err)
+			continue
+		}
+		if e.Kind != tt.want {
+			t.Errorf("Translate(%s, %s) kind = %s, want %s", tt.agent, tt.payload, e.Kind, tt.want)
+		}
+		if err := e.Validate(); err != nil {
+			t.Errorf("Translate(%s, %s) invalid: %v", tt.agent, tt.payload, err)
+		}
+	}
+
+	e, _ := Translate(AgentClaude, []byte(`{"hook_event_name":"PreToolUse","session_id":"s1","cwd":"/src/api","tool_name":"Edit"}`), at)
+	if e.AgentSession != "s1" || e.ProjectDir != "/src/api" || e.Tool != "Edit" || e.Agent != AgentClaude {
+		t.Errorf("fields not carried over: %+v", e)
+	}
+
+	if _, err := Translate(AgentClaude, []byte(`{"hook_event_name":"PreCompact"}`), at); !errors.Is(err, ErrIgnored) {
+		t.Errorf("PreCompact: got %v, want ErrIgnored", err)
+	}
+	if _, err := Translate("aider", []byte(`{}`), at); err == nil {
+		t.Error("unknown agent accepted")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ok := Event{V: Version, Kind: KindIdle, Agent: AgentClaude, At: time.Now()}
+	if err := ok.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	future := ok
+	future.V = Version + 1
+	if err := future.Validate(); !errors.Is(err, ErrVersion) {
+		t.Errorf("future version: got %v, want ErrVersion", err)
+	}
+	unknown := ok
+	unknown.Kind = "thinking"
+	if err := unknown.Validate(); err == nil {
+		t.Error("unknown kind accepted")
+	}
+	legacy := ok
+	legacy.V = 0
+	if err := legacy.Validate(); err == nil {
+		t.Error("unversioned event accepted")
+	}
+}
+
+func TestSend(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "hook.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	got := make(chan Event, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var e Event
+		if scanner := bufio.NewScanner(conn); scanner.Scan() {
+			json.Unmarshal(scanner.Bytes(), &e)
+		}
+		got <- e
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sent := Event{V: Version, Kind: KindToolCall, Agent: AgentCodex, Session: "codex-api", At: time.Now().UTC().Truncate(time.Second)}
+	if err := Send(ctx, socket, sent); err != nil {
+		t.Fatal(err)
+	}
+	if e := <-got; e != sent {
+		t.Errorf("received %+v, want %+v", e, sent)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	hooks := ClaudeHooks("/bin/autarch-hook")
+	for _, name := range claudeEvents {
+		if _, ok := hooks[name]; !ok {
+			t.Errorf("claude hooks missing %s", name)
+		}
+	}
+	entry := hooks["PreToolUse"].([]any)[0].(map[string]any)
+	if entry["matcher"] != ".*" {
+		t.Errorf("PreToolUse matcher = %v", entry["matcher"])
+	}
+	cmd := entry["hooks"].([]any)[0].(map[string]any)["command"]
+	if cmd != "/bin/autarch-hook claude" {
+		t.Errorf("command = %v", cmd)
+	}
+	if argv := CodexNotify("/bin/autarch-hook"); len(argv) != 2 || argv[1] != AgentCodex {
+		t.Errorf("CodexNotify = %v", argv)
+	}
+}
@@ -0,0 +1,48 @@
+package agenthook
+
+// claudeEvents and geminiEvents are the hooks the emitter is registered for.
+var (
+	claudeEvents = []string{
+		"SessionStart", "UserPromptSubmit", "PreToolUse", "PostToolUse", "PostToolUseFailure",
+		"PermissionRequest", "Notification", "Stop", "SessionEnd",
+	}
+	geminiEvents = []string{
+		"SessionStart", "BeforeAgent", "BeforeTool", "AfterTool", "Notification", "AfterAgent", "SessionEnd",
+	}
+	// toolEvents take a tool matcher.
+	toolEvents = map[string]bool{
+		"PreToolUse": true, "PostToolUse": true, "PostToolUseFailure": true, "PermissionRequest": true,
+		"BeforeTool": true, "AfterTool": true,
+	}
+)
+
+// ClaudeHooks is the "hooks" section of Claude Code's settings.json that
+// runs the emitter at bin on every state change.
+func ClaudeHooks(bin string) map[string]any {
+	return hookSection(bin+" "+AgentClaude, claudeEvents, ".*")
+}
+
+// GeminiHooks is the "hooks" section of Gemini CLI's settings.json.
+func GeminiHooks(bin string) map[string]any {
+	return hookSection(bin+" "+AgentGemini, geminiEvents, "*")
+}
+
+// CodexNotify is the notify program for Codex CLI's config.toml. Codex
+// appends its event JSON as the last argument.
+func CodexNotify(bin string) []string {
+	return []string{bin, AgentCodex}
+}
+
+func hookSection(command string, events []string, matchAll string) map[string]any {
+	out := make(map[string]any, len(events))
+	for _, name := range events {
+		entry := map[string]any{
+			"hooks": []any{map[string]any{"type": "command", "command": command, "timeout": 5}},
+		}
+		if toolEvents[name] {
+			entry["matcher"] = matchAll
+		}
+		out[name] = []any{entry}
+	}
+	return out
+}
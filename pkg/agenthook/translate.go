@@ -0,0 +1,133 @@
+package agenthook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Agents the emitter translates hooks for.
+const (
+	AgentClaude = "claude"
+	AgentCodex  = "codex"
+	AgentGemini = "gemini"
+)
+
+// ErrIgnored is returned for hook invocations that say nothing about the
+// agent's state, such as a notification Bigend has no kind for.
+var ErrIgnored = errors.New("hook event ignored")
+
+// hookPayload is the JSON Claude Code and Gemini CLI pass to hooks on stdin.
+type hookPayload struct {
+	HookEventName    string `json:"hook_event_name"`
+	SessionID        string `json:"session_id"`
+	Cwd              string `json:"cwd"`
+	ToolName         string `json:"tool_name"`
+	Message          string `json:"message"`
+	NotificationType string `json:"notification_type"`
+}
+
+// codexPayload is the JSON Codex CLI passes to its notify program.
+type codexPayload struct {
+	Type     string `json:"type"`
+	ThreadID string `json:"thread-id"`
+	Cwd      string `json:"cwd"`
+}
+
+// Translate builds an event from one hook invocation of agent. For Claude
+// and Gemini, payload is the hook's stdin; for Codex it is the notify
+// argument, either its JSON or a bare event name.
+func Translate(agent string, payload []byte, at time.Time) (Event, error) {
+	e := Event{V: Version, Agent: agent, At: at.UTC()}
+	var err error
+	switch agent {
+	case AgentClaude, AgentGemini:
+		var p hookPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return e, fmt.Errorf("%s hook payload: %w", agent, err)
+		}
+		e.AgentSession, e.ProjectDir, e.Tool, e.Message = p.SessionID, p.Cwd, p.ToolName, p.Message
+		if agent == AgentClaude {
+			e.Kind, err = claudeKind(p)
+		} else {
+			e.Kind, err = geminiKind(p)
+		}
+	case AgentCodex:
+		var p codexPayload
+		if trimmed := bytes.TrimSpace(payload); len(trimmed) > 0 && trimmed[0] == '{' {
+			if err := json.Unmarshal(trimmed, &p); err != nil {
+				return e, fmt.Errorf("codex notify payload: %w", err)
+			}
+		} else {
+			p.Type = string(trimmed)
+		}
+		e.AgentSession, e.ProjectDir = p.ThreadID, p.Cwd
+		e.Kind, err = codexKind(p.Type)
+	default:
+		return e, fmt.Errorf("unknown agent %q (want claude, codex or gemini)", agent)
+	}
+	return e, err
+}
+
+func claudeKind(p hookPayload) (Kind, error) {
+	switch p.HookEventName {
+	case "SessionStart":
+		return KindSessionStart, nil
+	case "UserPromptSubmit":
+		return KindPrompt, nil
+	case "PreToolUse", "PostToolUse":
+		return KindToolCall, nil
+	case "PermissionRequest":
+		return KindPermission, nil
+	case "PostToolUseFailure":
+		return KindError, nil
+	case "Stop", "SubagentStop":
+		return KindIdle, nil
+	case "SessionEnd":
+		return KindStop, nil
+	case "Notification":
+		msg := strings.ToLower(p.Message)
+		switch {
+		case p.NotificationType == "permission_prompt" || strings.Contains(msg, "permission"):
+			return KindPermission, nil
+		case p.NotificationType == "idle_prompt" || strings.Contains(msg, "waiting for your input"):
+			return KindIdle, nil
+		}
+	}
+	return "", fmt.Errorf("claude %s: %w", p.HookEventName, ErrIgnored)
+}
+
+func geminiKind(p hookPayload) (Kind, error) {
+	switch p.HookEventName {
+	case "SessionStart":
+		return KindSessionStart, nil
+	case "BeforeAgent":
+		return KindPrompt, nil
+	case "BeforeTool", "AfterTool":
+		return KindToolCall, nil
+	case "AfterAgent":
+		return KindIdle, nil
+	case "SessionEnd":
+		return KindStop, nil
+	case "Notification":
+		if p.NotificationType == "ToolPermission" {
+			return KindPermission, nil
+		}
+	}
+	return "", fmt.Errorf("gemini %s: %w", p.HookEventName, ErrIgnored)
+}
+
+func codexKind(event string) (Kind, error) {
+	switch event {
+	case "agent-turn-complete":
+		return KindIdle, nil
+	case "approval-requested":
+		return KindPermission, nil
+	case "error":
+		return KindError, nil
+	}
+	return "", fmt.Errorf("codex %s: %w", event, ErrIgnored)
+}
@@ -25,6 +25,7 @@ type ColdwineEpic struct {
 		Priority           string   `yaml:"priority"`
 		AcceptanceCriteria []string `yaml:"acceptance_criteria"`
 	} `yaml:"stories"`
+	SourcePRD string `yaml:"source_prd"`
 }
 
 // ColdwineEpics loads all epics from a project's .coldwine/specs directory.
@@ -10,6 +10,9 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
 )
 
 const defaultSignalsURL = "http://127.0.0.1:8092"
@@ -71,3 +74,31 @@ func (c *Client) Publish(ctx context.Context, sig Signal) error {
 	}
 	return nil
 }
+
+// Stream subscribes to signals of the given types, all when empty, and
+// calls fn with each one until ctx is done or the connection drops.
+func (c *Client) Stream(ctx context.Context, types []SignalType, fn func(Signal)) error {
+	if c == nil {
+		return fmt.Errorf("signals client is nil")
+	}
+	u := "ws" + strings.TrimPrefix(c.baseURL, "http") + "/ws"
+	if len(types) > 0 {
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = string(t)
+		}
+		u += "?types=" + strings.Join(names, ",")
+	}
+	conn, _, err := websocket.Dial(ctx, u, nil)
+	if err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "closing")
+	for {
+		var sig Signal
+		if err := wsjson.Read(ctx, conn, &sig); err != nil {
+			return err
+		}
+		fn(sig)
+	}
+}
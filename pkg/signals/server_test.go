@@ -2,6 +2,7 @@ package signals
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -94,3 +95,45 @@ func TestServerPublishRejectsMissingFields(t *testing.T) {
 		t.Fatalf("expected status 400, got %d", resp.StatusCode)
 	}
 }
+
+func TestClientStream(t *testing.T) {
+	broker := NewBroker()
+	srv := NewServer(broker)
+	srv.routes()
+	ts := httptest.NewServer(srv.mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got := make(chan Signal, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewClient(ts.URL).Stream(ctx, []SignalType{SignalAgentState}, func(sig Signal) {
+			select {
+			case got <- sig:
+			default:
+			}
+		})
+	}()
+
+	// Publish until the subscription is in place
+	deadline := time.After(2 * time.Second)
+	for {
+		broker.Publish(Signal{Type: SignalSpecHealthLow, Source: "gurgeh", Title: "filtered out"})
+		broker.Publish(Signal{Type: SignalAgentState, Source: "bigend", Title: "agent blocked", Severity: SeverityCritical})
+		select {
+		case sig := <-got:
+			if sig.Title != "agent blocked" || sig.Severity != SeverityCritical {
+				t.Fatalf("unexpected signal %+v", sig)
+			}
+			cancel()
+			if err := <-done; err == nil {
+				t.Error("expected the stream to end with the context")
+			}
+			return
+		case <-deadline:
+			t.Fatal("no signal streamed")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}